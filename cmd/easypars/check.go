@@ -0,0 +1,47 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+
+	"easypars/pkg/parser"
+)
+
+// runCheckSource runs the check-source command and returns its exit code
+// The command fetches the live source once and prints the compatibility
+// report (-format text or json). The exit code is the verdict: 0
+// compatible, 1 degraded, 2 incompatible or failed, so deploy pipelines
+// can stop on a source the parser no longer understands.
+func runCheckSource(p *parser.Parser, thresholds parser.CompatThresholds, args []string) int {
+	flags := flag.NewFlagSet("check-source", flag.ContinueOnError)
+	format := flags.String("format", "text", "report format: text or json")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if *format != "text" && *format != "json" {
+		fmt.Fprintf(os.Stderr, "Unsupported format %q, use text or json\n", *format)
+		return 2
+	}
+
+	report, err := p.CheckSource(context.Background(), thresholds)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Source check failed: %v\n", err)
+		return 2
+	}
+
+	if *format == "json" {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(report); err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to write the report: %v\n", err)
+			return 2
+		}
+	} else {
+		fmt.Print(report.Text())
+	}
+
+	return report.ExitCode()
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"easypars/pkg/clock"
+	"easypars/pkg/parser"
+)
+
+// checkPage is a results page of May 2024 with two fights; without the
+// month header it is degraded
+const checkPage = `<html><body><div class="month">Май 2024</div><table>
+<tr><td class="date">18</td><td class="place">Riyadh</td><td class="boxer_1">Oleksandr Usyk</td><td class="vs">SD</td><td class="boxer_2">Tyson Fury</td></tr>
+<tr><td class="date">25</td><td class="place">London</td><td class="boxer_1">Daniel Dubois</td><td class="vs">TKO 9</td><td class="boxer_2">Filip Hrgovic</td></tr>
+</table></body></html>`
+
+// captureStdout runs f with the standard output written to a file and
+// returns what was written
+func captureStdout(t *testing.T, f func()) string {
+	t.Helper()
+
+	out, err := os.Create(filepath.Join(t.TempDir(), "stdout"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer out.Close()
+	stdout := os.Stdout
+	os.Stdout = out
+	defer func() { os.Stdout = stdout }()
+
+	f()
+
+	data, err := os.ReadFile(out.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestRunCheckSource(t *testing.T) {
+	tests := []struct {
+		name   string
+		page   string
+		status int
+		args   []string
+		exit   int
+		output string
+	}{
+		{"compatible", checkPage, http.StatusOK, nil, 0, "Verdict:       compatible"},
+		{"degraded", strings.Replace(checkPage, `<div class="month">Май 2024</div>`, "", 1), http.StatusOK, nil, 1, "Verdict:       degraded"},
+		{"incompatible", `<html><body><table><tr><td>nothing</td></tr></table></body></html>`, http.StatusOK, nil, 2, "Verdict:       incompatible"},
+		{"failed fetch", "", http.StatusNotFound, nil, 2, ""},
+		{"json", checkPage, http.StatusOK, []string{"-format", "json"}, 0, `"verdict": "compatible"`},
+		{"unknown format", checkPage, http.StatusOK, []string{"-format", "yaml"}, 2, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			src := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.status)
+				fmt.Fprint(w, tt.page)
+			}))
+			defer src.Close()
+			p := parser.NewParser(src.URL + "/")
+			p.Clock = clock.Fixed{Time: time.Date(2024, time.June, 10, 12, 0, 0, 0, time.UTC)}
+
+			var exit int
+			output := captureStdout(t, func() { exit = runCheckSource(p, parser.DefaultCompatThresholds(), tt.args) })
+			if exit != tt.exit {
+				t.Errorf("exit code = %d, want %d (output %q)", exit, tt.exit, output)
+			}
+			if !strings.Contains(output, tt.output) {
+				t.Errorf("output = %q, want %q", output, tt.output)
+			}
+			if tt.output == "" && output != "" {
+				t.Errorf("output = %q, want none", output)
+			}
+			if len(tt.args) > 0 && tt.exit == 0 {
+				var report parser.CompatReport
+				if err := json.Unmarshal([]byte(output), &report); err != nil || report.Fights != 2 {
+					t.Errorf("JSON report = %q (%v), want the two fights", output, err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+
+	"easypars/pkg/auth"
+)
+
+// runHashPassword runs the hash-password command and returns its exit code
+// The command reads the admin password from the first line of stdin, so it
+// does not end up in the shell history, and prints its bcrypt hash for
+// jwt.admin_password_hash.
+func runHashPassword(args []string) int {
+	if len(args) > 0 {
+		fmt.Fprintln(os.Stderr, "Usage: easypars hash-password < password.txt")
+		return 2
+	}
+
+	line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && line == "" {
+		fmt.Fprintf(os.Stderr, "Failed to read the password: %v\n", err)
+		return 1
+	}
+	password := strings.TrimRight(line, "\r\n")
+	if password == "" {
+		fmt.Fprintln(os.Stderr, "The password must not be empty")
+		return 1
+	}
+
+	hash, err := auth.HashPassword(password)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to hash the password: %v\n", err)
+		return 1
+	}
+	fmt.Println(hash)
+
+	return 0
+}
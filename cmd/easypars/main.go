@@ -1,51 +1,538 @@
 package main
 
 import (
-	"log"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
+	_ "time/tzdata"
 
 	"easypars/pkg/api"
+	"easypars/pkg/auth"
+	"easypars/pkg/backfill"
+	"easypars/pkg/cache"
+	"easypars/pkg/clock"
 	"easypars/pkg/config"
-	"github.com/gin-gonic/gin"
+	"easypars/pkg/contract"
+	"easypars/pkg/countries"
+	"easypars/pkg/history"
+	"easypars/pkg/locations"
+	"easypars/pkg/logging"
+	"easypars/pkg/newslink"
+	"easypars/pkg/ogcard"
+	"easypars/pkg/parsejob"
+	"easypars/pkg/parser"
+	"easypars/pkg/presets"
+	"easypars/pkg/refresh"
+	"easypars/pkg/retention"
+	"easypars/pkg/snapshot"
+	"easypars/pkg/startup"
+	"easypars/pkg/stats"
+	"easypars/pkg/storage"
+	"easypars/pkg/stream"
+	"easypars/pkg/tracing"
+	"easypars/pkg/webhook"
+
+	"github.com/redis/go-redis/v9"
 )
 
 // Main entry point of the application
 // This function initializes the application, loads configuration, and starts the server
 func main() {
-	// Initialize application logging
-	log.Println("Starting EasyPars application...")
+	// Logs go to stderr as text until the logging configuration is loaded
+	// easypars hash-password hashes the admin password and exits; it needs
+	// no configuration, so it works before the jwt section is complete
+	if len(os.Args) > 1 && os.Args[1] == "hash-password" {
+		os.Exit(runHashPassword(os.Args[2:]))
+	}
+
+	slog.Info("Starting EasyPars application")
 
 	// Load application configuration using Viper
 	// This reads config.yaml and sets up all application settings
 	cfg, err := config.LoadConfig()
 	if err != nil {
-		log.Fatal("Failed to load configuration:", err)
+		fatal("Failed to load configuration", err)
+	}
+
+	// Initialize application logging
+	// Every package logs through the default logger; the standard log
+	// package writes to it as well
+	logger, logFile, err := logging.New(logging.Options{
+		Level:  cfg.Logging.Level,
+		Format: cfg.Logging.Format,
+		File:   cfg.Logging.File,
+	})
+	if err != nil {
+		fatal("Invalid logging configuration", err)
+	}
+	slog.SetDefault(logger)
+
+	// Snapshot aggregate names are defined by the snapshot package
+	if err := snapshot.ValidateAggregates(cfg.Snapshot.Precompute); err != nil {
+		fatal("Invalid snapshot configuration", err)
+	}
+
+	// Extra countries extend the dictionary before any filter is checked
+	for _, country := range cfg.Countries.Extra {
+		if err := countries.Register(country); err != nil {
+			fatal("Invalid countries configuration", err)
+		}
+	}
+
+	// Default filters are checked by the validators of the API parameters
+	if err := api.ValidateDefaultFilters(cfg.API.DefaultFilters); err != nil {
+		fatal("Invalid API configuration", err)
+	}
+
+	// Cost thresholds are checked by the API package
+	costThresholds := api.CostThresholds{
+		ModerateRequests:  cfg.API.CostThresholds.ModerateRequests,
+		ModerateSeconds:   cfg.API.CostThresholds.ModerateSeconds,
+		ExpensiveRequests: cfg.API.CostThresholds.ExpensiveRequests,
+		ExpensiveSeconds:  cfg.API.CostThresholds.ExpensiveSeconds,
+	}
+	if err := costThresholds.Validate(); err != nil {
+		fatal("Invalid API configuration", err)
+	}
+
+	// Widget ancestors are checked by the API package
+	if err := api.ValidateEmbedAncestors(cfg.Embed.AllowedAncestors); err != nil {
+		fatal("Invalid embed configuration", err)
+	}
+
+	// Card colors are parsed by the card renderer package
+	cardColors, err := ogcard.ParseColors(cfg.Cards.Background, cfg.Cards.Text, cfg.Cards.Accent)
+	if err != nil {
+		fatal("Invalid cards configuration", err)
+	}
+
+	// The broadcast start is parsed by the snapshot package
+	broadcastStart, err := snapshot.ParseBroadcastStart(cfg.Cache.BroadcastStart)
+	if err != nil {
+		fatal("Invalid cache configuration", err)
 	}
 
 	// Log successful configuration loading
-	log.Printf("Configuration loaded successfully")
-	log.Printf("Server will start on port: %s", cfg.Server.Port)
-
-	// Initialize parser (future implementation)
-	// Future steps: Initialize parser with configuration and setup goroutines
-	// parser := parser.New(cfg.Parser)
-	// parser.Start()
-	log.Println("Parser initialization - pending implementation")
-
-	// Initialize database connection (future implementation)
-	// Future steps: Connect to PostgreSQL using GORM with loaded database config
-	// db, err := database.Connect(cfg.Database)
-	// if err != nil {
-	//     log.Fatal("Failed to connect to database:", err)
-	// }
-	// defer db.Close()
-	log.Println("Database connection - pending implementation")
+	slog.Info("Configuration loaded", "port", cfg.Server.Port)
+
+	// Initialize parse history
+	// The parser logger tees records of each run into the history
+	parseHistory := history.New(cfg.History.MaxRuns, cfg.History.MaxLogEntries)
+	parserLogger := slog.New(history.NewRunLogHandler(logger.Handler(), parseHistory))
+
+	// Initialize parser with the configured source and HTTP timeout
+	parserLocation, err := time.LoadLocation(cfg.Parser.Timezone)
+	if err != nil {
+		fatal("Invalid parser timezone", err)
+	}
+	fightParser := parser.NewParser(cfg.Parser.BaseURL)
+	fightParser.MonthURL = cfg.Parser.MonthURL
+	fightParser.PageURL = cfg.Parser.PageURL
+	fightParser.Location = parserLocation
+	fightParser.AllowedHosts = cfg.Parser.AllowedHosts
+	fightParser.HTTPClient.Timeout = time.Duration(cfg.Parser.Timeout) * time.Second
+	fightParser.ParseComments = cfg.Parser.ParseComments
+	fightParser.Logger = parserLogger
+	fightParser.StaleTBDDays = cfg.Parser.StaleTBDDays
+	fightParser.RateLimitPause = time.Duration(cfg.Parser.RateLimitPauseSeconds) * time.Second
+	fightParser.MaxBodySize = int64(cfg.Parser.MaxBodyMB) << 20
+	fightParser.RequestsPerSecond = cfg.Parser.RequestsPerSecond
+	fightParser.RetryAttempts = cfg.Parser.RetryAttempts
+	fightParser.RetryBaseDelay = time.Duration(cfg.Parser.RetryBaseDelayMs) * time.Millisecond
+	fightParser.SourceWorkers = cfg.Parser.SourceWorkers
+	fightParser.ClockSkew = clock.NewSkewMonitor(
+		time.Duration(cfg.Clock.MaxSkewSeconds)*time.Second,
+		time.Duration(cfg.Clock.CriticalSkewSeconds)*time.Second,
+	)
+	fightParser.ColumnInvalidThreshold = cfg.Parser.ColumnInvalidThreshold
+	fightParser.FingerprintFile = cfg.Parser.FingerprintFile
+	fightParser.FighterExternalIDs = cfg.Parser.FighterExternalIDs()
+	// Permanent redirects of the extra sources update their stored URL
+	fightParser.OnSourceMoved = fightParser.RelocateSources
+	if err := fightParser.SetVolatilePatterns(cfg.Parser.VolatilePatterns...); err != nil {
+		fatal("Invalid parser configuration", err)
+	}
+	fightParser.FailOnPostProcessError = cfg.Parser.PostProcessors.OnError == "fail"
+	if err := fightParser.DisablePostProcessors(cfg.Parser.PostProcessors.Disabled...); err != nil {
+		fatal("Invalid parser configuration", err)
+	}
+	slog.Info("Parser initialized", "url", cfg.Parser.BaseURL)
+
+	// Source check thresholds are checked by the parser package
+	compatThresholds := parser.CompatThresholds{
+		MinFights:              cfg.CheckSource.MinFights,
+		DegradedValidShare:     cfg.CheckSource.DegradedValidShare,
+		IncompatibleValidShare: cfg.CheckSource.IncompatibleValidShare,
+	}
+	if err := compatThresholds.Validate(); err != nil {
+		fatal("Invalid check_source configuration", err)
+	}
+
+	// easypars check-source checks the live source and exits without
+	// starting the server
+	if len(os.Args) > 1 && os.Args[1] == "check-source" {
+		os.Exit(runCheckSource(fightParser, compatThresholds, os.Args[2:]))
+	}
+
+	// easypars parse -file page.html parses a saved page offline and exits
+	if len(os.Args) > 1 && os.Args[1] == "parse" {
+		os.Exit(runParse(fightParser, os.Args[2:]))
+	}
+
+	// Prepare server address using the configured port
+	// Ensures the port format is correct (adds : if not present)
+	serverAddr := cfg.Server.Port
+	if serverAddr[0] != ':' {
+		serverAddr = ":" + serverAddr
+	}
+
+	// Open the HTTP listener before initializing the components
+	// /healthz answers right away and /readyz reports the initialization;
+	// API requests get 503 until every required component is up
+	// Requests run on a base context cancelled when the shutdown grace
+	// period is over
+	readiness := startup.NewReadiness()
+	requestsCtx, cancelRequests := context.WithCancel(context.Background())
+	server := &http.Server{
+		Addr:        serverAddr,
+		Handler:     readiness,
+		BaseContext: func(net.Listener) context.Context { return requestsCtx },
+	}
+	serverErr := make(chan error, 1)
+	go func() {
+		slog.Info("Server starting", "port", cfg.Server.Port)
+		serverErr <- server.ListenAndServe()
+	}()
+
+	// Initialize the components in parallel along their dependencies:
+	// sources, storage, presets and retention are independent, backfill needs storage
+	var (
+		repo              storage.FightRepository
+		presetStore       *presets.Store
+		newsStore         *newslink.Store
+		webhooks          *webhook.Dispatcher
+		backfillScheduler *backfill.Scheduler
+		retentionRunner   *retention.Runner
+		locationAliases   *locations.Dictionary
+		fightCache        cache.Cache[*parser.ParseResult]
+	)
+	snapshots := snapshot.NewStore(snapshot.Guard{
+		MinRatio:       cfg.Snapshot.MinRatio,
+		MaxQualityDrop: cfg.Snapshot.MaxQualityDrop,
+	})
+	components := []startup.Component{
+		{
+			// Extra sources added through the admin API
+			Name:     "sources",
+			Required: true,
+			Init: func(ctx context.Context) error {
+				registry, err := parser.NewSourceRegistry(cfg.Parser.SourcesFile)
+				if err != nil {
+					return err
+				}
+				fightParser.Sources = registry
+				return nil
+			},
+		},
+		{
+			// Persistent storage, a nil repository when disabled in the configuration
+			Name:     "storage",
+			Required: true,
+			Init: func(ctx context.Context) error {
+				opened, err := storage.Open(cfg.Storage, cfg.Database)
+				if err != nil {
+					return err
+				}
+				if opened == nil {
+					slog.Info("Persistent storage disabled")
+				}
+				repo = opened
+				return nil
+			},
+		},
+		{
+			// Cache of the parses of the source, in memory or shared in Redis
+			Name:     "cache",
+			Required: true,
+			Init: func(ctx context.Context) error {
+				opened, err := openFightCache(ctx, cfg)
+				if err != nil {
+					return err
+				}
+				fightCache = opened
+				return nil
+			},
+		},
+		{
+			// Dictionary of known locations
+			Name:     "locations",
+			Required: true,
+			Init: func(ctx context.Context) error {
+				dict, err := locations.LoadDictionary(cfg.Snapshot.LocationAliasesFile)
+				if err != nil {
+					return err
+				}
+				slog.Info("Location aliases loaded", "location_count", dict.Len())
+				locationAliases = dict
+				return nil
+			},
+		},
+		{
+			// Saved query presets
+			Name:     "presets",
+			Required: true,
+			Init: func(ctx context.Context) error {
+				store, err := presets.NewStore(cfg.Presets.MaxPresets, cfg.Presets.File)
+				if err != nil {
+					return err
+				}
+				presetStore = store
+				return nil
+			},
+		},
+		{
+			// News items linked to their fights
+			Name:     "news",
+			Required: true,
+			Init: func(ctx context.Context) error {
+				store, err := newslink.NewStore(cfg.News.File)
+				if err != nil {
+					return err
+				}
+				slog.Info("News loaded", "news_count", len(store.Items()))
+				newsStore = store
+				return nil
+			},
+		},
+		{
+			// Webhooks notified of new results
+			Name:     "webhooks",
+			Required: true,
+			Init: func(ctx context.Context) error {
+				if !cfg.Webhooks.Enabled {
+					return nil
+				}
+				store, err := webhook.NewStore(cfg.Webhooks.File)
+				if err != nil {
+					return err
+				}
+				webhooks = webhook.NewDispatcher(store, webhook.Config{
+					Timeout:      time.Duration(cfg.Webhooks.TimeoutMs) * time.Millisecond,
+					MaxAttempts:  cfg.Webhooks.MaxAttempts,
+					RetryBackoff: time.Duration(cfg.Webhooks.RetryBackoffMs) * time.Millisecond,
+					LogSize:      cfg.Webhooks.LogSize,
+					AllowedHosts: cfg.Webhooks.AllowedHosts,
+				})
+				slog.Info("Webhooks enabled", "webhook_count", len(store.List()))
+				return nil
+			},
+		},
+		{
+			// Archive backfill scheduler
+			// It can be started later through the admin API when not enabled on start
+			Name:      "backfill",
+			DependsOn: []string{"storage"},
+			Init: func(ctx context.Context) error {
+				if repo == nil || cfg.Parser.MonthURL == "" {
+					return nil
+				}
+				window, err := backfill.ParseWindow(cfg.Backfill.Window)
+				if err != nil {
+					return err
+				}
+				scheduler := backfill.New(backfill.Config{
+					MonthsBack: cfg.Backfill.MonthsBack,
+					Pace:       time.Duration(cfg.Backfill.PaceMinutes) * time.Minute,
+					Window:     window,
+					Location:   parserLocation,
+				}, fightParser, repo, parseHistory)
+
+				if cfg.Backfill.Enabled {
+					if err := scheduler.Start(); err != nil {
+						return err
+					}
+				}
+				backfillScheduler = scheduler
+				return nil
+			},
+		},
+		{
+			// Daily cleanup of expired parse runs and pending snapshots
+			Name: "retention",
+			Init: func(ctx context.Context) error {
+				window, err := backfill.ParseWindow(cfg.Retention.Window)
+				if err != nil {
+					return err
+				}
+				day := 24 * time.Hour
+				runner := retention.New(retention.Config{
+					Policy: retention.Policy{
+						retention.CategorySnapshots:    time.Duration(cfg.Retention.SnapshotsDays) * day,
+						retention.CategoryParseHistory: time.Duration(cfg.Retention.ParseHistoryDays) * day,
+					},
+					Window:   window,
+					Location: parserLocation,
+				}, []retention.Target{
+					retention.SnapshotTarget(snapshots),
+					retention.HistoryTarget(parseHistory),
+				}, parseHistory)
+
+				if cfg.Retention.Enabled {
+					if err := runner.Start(); err != nil {
+						return err
+					}
+				}
+				retentionRunner = runner
+				return nil
+			},
+		},
+	}
+
+	initTimeout := time.Duration(cfg.Server.InitTimeoutSeconds) * time.Second
+	report, err := startup.Run(context.Background(), components, initTimeout, readiness.Record)
+	if err != nil {
+		readiness.Fail(err)
+		slog.Error("Startup failed", "duration_ms", report.DurationMs, "error", err)
+		os.Exit(1)
+	}
+	slog.Info("Components initialized", "duration_ms", report.DurationMs)
+	if degraded := report.Degraded(); len(degraded) > 0 {
+		slog.Warn("Running without optional components", "components", degraded)
+	}
+
+	// The background refresher gets its refresh from the API and starts
+	// once the router is set up
+	var refresher *refresh.Scheduler
+	if cfg.Refresh.IntervalMinutes > 0 {
+		refresher = refresh.New(time.Duration(cfg.Refresh.IntervalMinutes) * time.Minute)
+	}
+
+	// Manual parses requested by operators run one at a time
+	parseJobs := parsejob.NewManager()
+
+	// Traces of the requests are exported once tracing is enabled; otherwise
+	// the spans of the API and the parser are no-ops
+	var flushTraces func(context.Context) error
+	if cfg.Tracing.Enabled {
+		flushTraces, err = tracing.Setup(context.Background(), tracing.Config{
+			Endpoint:    cfg.Tracing.Endpoint,
+			Insecure:    cfg.Tracing.Insecure,
+			SampleRatio: cfg.Tracing.SampleRatio,
+			ServiceName: cfg.Tracing.ServiceName,
+		})
+		if err != nil {
+			fatal("Failed to set up tracing", err)
+		}
+		slog.Info("Tracing enabled", "endpoint", cfg.Tracing.Endpoint, "sample_ratio", cfg.Tracing.SampleRatio)
+	}
+
+	// Consumers of the read endpoints are identified by their API keys
+	apiKeys := make([]api.APIKey, 0, len(cfg.API.APIKeys))
+	for _, apiKey := range cfg.API.APIKeys {
+		apiKeys = append(apiKeys, api.APIKey{
+			Key:               apiKey.Key,
+			Name:              apiKey.Name,
+			RequestsPerMinute: apiKey.RequestsPerMinute,
+		})
+	}
+
+	// Admin endpoints require a token from /api/auth/login and are
+	// disabled until a JWT secret is configured
+	var authManager *auth.Manager
+	if cfg.JWT.Secret != "" {
+		authManager = auth.NewManager(auth.Config{
+			Secret:            cfg.JWT.Secret,
+			Issuer:            cfg.JWT.Issuer,
+			Expire:            time.Duration(cfg.JWT.ExpireHours) * time.Hour,
+			AdminUsername:     cfg.JWT.AdminUsername,
+			AdminPasswordHash: cfg.JWT.AdminPasswordHash,
+		})
+	} else {
+		slog.Warn("No JWT secret configured, admin endpoints are disabled")
+	}
+
+	// Live fight updates; the streams are ended when the server shuts down,
+	// so they do not hold the grace period
+	updates := stream.NewHub(stream.Config{
+		MaxClients:   cfg.Stream.MaxClients,
+		ClientBuffer: cfg.Stream.ClientBuffer,
+	})
+	server.RegisterOnShutdown(updates.Close)
 
 	// Initialize API server with loaded configuration
 	// This sets up all REST API endpoints using the Gin framework
-	router := api.SetupRouter()
+	router := api.SetupRouter(api.Dependencies{
+		Parser:                fightParser,
+		Repository:            repo,
+		MissingGrace:          time.Duration(cfg.Storage.MissingGraceDays) * 24 * time.Hour,
+		History:               parseHistory,
+		Presets:               presetStore,
+		News:                  newsStore,
+		Backfill:              backfillScheduler,
+		Retention:             retentionRunner,
+		LocationAliases:       locationAliases,
+		Contract:              contract.NewChecker(cfg.Contract.Enforce),
+		PrecomputeAggregates:  cfg.Snapshot.Precompute,
+		FastResponseBudget:    time.Duration(cfg.API.FastResponseBudgetMs) * time.Millisecond,
+		MaxConcurrentRequests: cfg.API.MaxConcurrentRequests,
+		MaxConcurrentProbes:   cfg.API.MaxConcurrentProbes,
+		QueueTimeout:          time.Duration(cfg.API.QueueTimeoutMs) * time.Millisecond,
+		DefaultFilters:        cfg.API.DefaultFilters,
+		APIKey:                cfg.API.APIKey,
+		APIKeys:               apiKeys,
+		RequireAPIKey:         !cfg.API.AllowAnonymous,
+		AnonymousRateLimit:    cfg.API.AnonymousRequestsPerMinute,
+		ExportMaxRows:         cfg.API.ExportMaxRows,
+		FeedItems:             cfg.API.FeedItems,
+		IPRateLimit:           cfg.API.RateLimit.RequestsPerSecond,
+		IPRateBurst:           cfg.API.RateLimit.Burst,
+		TrustedProxies:        cfg.Server.TrustedProxies,
+		CostThresholds:        costThresholds,
+		CompatThresholds:      compatThresholds,
+		CardColors:            &cardColors,
+		CardCache:             ogcard.NewCache(cfg.Cards.MaxCached, cfg.Cards.CacheDir),
+		FightCache:            fightCache,
+		Refresher:             refresher,
+		ParseJobs:             parseJobs,
+		Webhooks:              webhooks,
+		Stream:                updates,
+		StreamHeartbeat:       time.Duration(cfg.Stream.HeartbeatSeconds) * time.Second,
+		EmbedAllowedAncestors: cfg.Embed.AllowedAncestors,
+		EmbedRateLimit:        cfg.Embed.RateLimitPerMinute,
+		ServeMetrics:          cfg.Metrics.Enabled,
+		Tracing:               cfg.Tracing.Enabled,
+		MaxParseAge:           time.Duration(cfg.Health.MaxParseAgeMinutes) * time.Minute,
+		ReadyCheckTimeout:     time.Duration(cfg.Health.CheckTimeoutMs) * time.Millisecond,
+		ReadyCacheTTL:         time.Duration(cfg.Health.CacheSeconds) * time.Second,
+		ChangeHints: snapshot.ChangeHints{
+			TTL:            time.Duration(cfg.Cache.TTLSeconds) * time.Second,
+			BroadcastStart: broadcastStart,
+			Location:       parserLocation,
+		},
+		Scoring: &stats.Weights{
+			Wins:       cfg.Scoring.Weights.Wins,
+			Title:      cfg.Scoring.Weights.Title,
+			MainEvent:  cfg.Scoring.Weights.MainEvent,
+			Unbeaten:   cfg.Scoring.Weights.Unbeaten,
+			Rematch:    cfg.Scoring.Weights.Rematch,
+			Popularity: cfg.Scoring.Weights.Popularity,
+		},
+		Snapshots: snapshots,
+		Auth:      authManager,
+	})
+	if refresher != nil {
+		if err := refresher.Start(); err != nil {
+			fatal("Failed to start the refresher", err)
+		}
+	}
 
 	// Configure Gin mode based on environment
 	// Future steps: Add environment-specific configuration
@@ -55,30 +542,33 @@ func main() {
 
 	// Add graceful shutdown handling
 	// This ensures the application shuts down cleanly when receiving termination signals
-	setupGracefulShutdown(router, cfg)
+	shutdownTimeout := time.Duration(cfg.Server.ShutdownTimeoutSeconds) * time.Second
+	shutdownDone := setupGracefulShutdown(server, cancelRequests, shutdownTimeout, repo, fightCache, refresher, parseJobs, webhooks, flushTraces, logFile)
 
-	// Prepare server address using the configured port
-	// Ensures the port format is correct (adds : if not present)
-	serverAddr := cfg.Server.Port
-	if serverAddr[0] != ':' {
-		serverAddr = ":" + serverAddr
-	}
-
-	// Start the HTTP server
+	// Serve the API on the already open listener
 	// Future steps: Add TLS support, custom timeouts, and middleware
-	log.Printf("Server starting on port %s...", cfg.Server.Port)
-	log.Printf("API endpoints available at: http://localhost%s/api/", serverAddr)
-	log.Printf("Web interface available at: http://localhost%s/", serverAddr)
+	readiness.Ready(router)
+	slog.Info("API endpoints available", "url", "http://localhost"+serverAddr+"/api/")
+	slog.Info("Web interface available", "url", "http://localhost"+serverAddr+"/")
 
-	// Start the server with the configured port
-	if err := router.Run(serverAddr); err != nil {
-		log.Fatal("Failed to start server:", err)
+	// Block until the server stops, then until the shutdown has drained the
+	// in-flight requests and released the components
+	if err := <-serverErr; err != nil && !errors.Is(err, http.ErrServerClosed) {
+		fatal("Failed to start server", err)
 	}
+	<-shutdownDone
 }
 
 // setupGracefulShutdown configures graceful shutdown for the application
-// This function handles OS signals and ensures clean application termination
-func setupGracefulShutdown(router *gin.Engine, cfg *config.Config) {
+// This function handles OS signals and ensures clean application termination:
+// the server stops accepting connections and waits up to timeout for the
+// in-flight requests, then cancels the requests still running through
+// cancelRequests. The returned channel is closed once the components are
+// stopped and the traces and the logs are flushed; flushTraces is nil
+// without tracing.
+func setupGracefulShutdown(server *http.Server, cancelRequests context.CancelFunc, timeout time.Duration, repo storage.FightRepository, fightCache cache.Cache[*parser.ParseResult], refresher *refresh.Scheduler, parseJobs *parsejob.Manager, webhooks *webhook.Dispatcher, flushTraces func(context.Context) error, logFile io.Closer) <-chan struct{} {
+	done := make(chan struct{})
+
 	// Create a channel to receive OS signals
 	sigChan := make(chan os.Signal, 1)
 
@@ -89,29 +579,110 @@ func setupGracefulShutdown(router *gin.Engine, cfg *config.Config) {
 	go func() {
 		// Wait for a signal
 		sig := <-sigChan
-		log.Printf("Received signal: %v", sig)
+		slog.Info("Received signal", "signal", sig.String())
 
 		// Perform cleanup operations
-		log.Println("Performing graceful shutdown...")
+		slog.Info("Performing graceful shutdown", "grace_period", timeout.String())
+
+		// Let the in-flight requests complete within the grace period
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		if err := server.Shutdown(ctx); err != nil {
+			slog.Warn("Grace period over, cancelling in-flight requests", "error", err)
+			cancelRequests()
+			server.Close()
+		}
+		cancel()
+		cancelRequests()
+
+		// Stop the scheduled refresh before the storage it writes to
+		if refresher != nil {
+			if err := refresher.Stop(); err != nil {
+				slog.Error("Failed to stop the refresher", "error", err)
+			}
+		}
+		parseJobs.Stop()
+
+		// Give the webhook deliveries in flight the grace period
+		if webhooks != nil {
+			ctx, cancel := context.WithTimeout(context.Background(), timeout)
+			if err := webhooks.Stop(ctx); err != nil {
+				slog.Warn("Webhook deliveries still running at shutdown", "error", err)
+			}
+			cancel()
+		}
+
+		// Close the storage so sqlite checkpoints its WAL file
+		if repo != nil {
+			if err := repo.Close(); err != nil {
+				slog.Error("Failed to close storage", "error", err)
+			}
+		}
+
+		// Close the connections of a shared fight cache
+		if closer, ok := fightCache.(io.Closer); ok {
+			if err := closer.Close(); err != nil {
+				slog.Error("Failed to close the fight cache", "error", err)
+			}
+		}
+
+		// Export the spans still buffered
+		if flushTraces != nil {
+			ctx, cancel := context.WithTimeout(context.Background(), timeout)
+			if err := flushTraces(ctx); err != nil {
+				slog.Error("Failed to flush the traces", "error", err)
+			}
+			cancel()
+		}
 
 		// Future cleanup steps:
-		// - Close database connections
-		// - Stop parser goroutines
-		// - Flush logs
 		// - Save application state
-		// - Close Redis connections
 		// - Clean up temporary files
 
-		log.Println("Shutdown complete")
-		os.Exit(0)
+		// Flush the logs last
+		slog.Info("Shutdown complete")
+		logFile.Close()
+		close(done)
 	}()
+
+	return done
 }
 
 // Future functions to be implemented:
 // - initializeDatabase(cfg *config.Config) (*gorm.DB, error)
 // - initializeParser(cfg *config.Config) (*parser.Parser, error)
-// - initializeRedis(cfg *config.Config) (*redis.Client, error)
 // - setupMiddleware(router *gin.Engine, cfg *config.Config)
 // - setupRoutes(router *gin.Engine, cfg *config.Config)
 // - initializeLogging(cfg *config.Config) error
 // - validateEnvironment(cfg *config.Config) error
+
+// openFightCache creates the fight cache of the configured backend
+// A Redis server that does not answer fails the start, like the storage.
+func openFightCache(ctx context.Context, cfg *config.Config) (cache.Cache[*parser.ParseResult], error) {
+	ttl := time.Duration(cfg.Cache.TTLSeconds) * time.Second
+	if cfg.Cache.Backend != "redis" {
+		return cache.NewMemory[*parser.ParseResult](ttl, nil), nil
+	}
+
+	client := redis.NewClient(&redis.Options{
+		Addr:     cfg.Redis.Addr,
+		Password: cfg.Redis.Password,
+		DB:       cfg.Redis.DB,
+	})
+	if err := client.Ping(ctx).Err(); err != nil {
+		client.Close()
+		return nil, fmt.Errorf("error connecting to redis %s: %w", cfg.Redis.Addr, err)
+	}
+	slog.Info("Redis fight cache connected", "addr", cfg.Redis.Addr, "db", cfg.Redis.DB)
+
+	return cache.NewRedis[*parser.ParseResult](client, cache.RedisOptions{
+		Prefix:  cfg.Redis.KeyPrefix,
+		TTL:     ttl,
+		LockTTL: time.Duration(cfg.Redis.LockTTLSeconds) * time.Second,
+	}), nil
+}
+
+// fatal logs an error the application cannot start with and exits
+func fatal(msg string, err error) {
+	slog.Error(msg, "error", err)
+	os.Exit(1)
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+
+	"easypars/pkg/parser"
+)
+
+// runParse runs the parse command and returns its exit code
+// The command parses a saved results page (-file, "-" for stdin) without
+// any network request and prints the fights as JSON, for debugging the
+// extraction offline. The exit code is 1 when the page cannot be parsed.
+func runParse(p *parser.Parser, args []string) int {
+	flags := flag.NewFlagSet("parse", flag.ContinueOnError)
+	file := flags.String("file", "", "saved HTML results page, - for stdin")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if *file == "" {
+		fmt.Fprintln(os.Stderr, "Missing -file, the path of a saved results page")
+		return 2
+	}
+
+	input := os.Stdin
+	if *file != "-" {
+		f, err := os.Open(*file)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to open %s: %v\n", *file, err)
+			return 1
+		}
+		defer f.Close()
+		input = f
+	}
+
+	fights, err := p.ParseFromReader(input)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to parse %s: %v\n", *file, err)
+		return 1
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(fights); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to write the fights: %v\n", err)
+		return 1
+	}
+
+	return 0
+}
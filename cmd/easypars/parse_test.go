@@ -0,0 +1,72 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"easypars/models"
+	"easypars/pkg/clock"
+	"easypars/pkg/parser"
+)
+
+func TestRunParse(t *testing.T) {
+	dir := t.TempDir()
+	saved := filepath.Join(dir, "results.html")
+	invalid := filepath.Join(dir, "invalid.html")
+	for path, page := range map[string]string{saved: checkPage, invalid: "<html><body>not a results page</body></html>"} {
+		if err := os.WriteFile(path, []byte(page), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name   string
+		args   []string
+		stdin  string
+		exit   int
+		fights int
+	}{
+		{"saved page", []string{"-file", saved}, "", 0, 2},
+		{"stdin", []string{"-file", "-"}, saved, 0, 2},
+		{"missing -file", nil, "", 2, 0},
+		{"unknown flag", []string{"-url", "https://vringe.example"}, "", 2, 0},
+		{"missing file", []string{"-file", filepath.Join(dir, "missing.html")}, "", 1, 0},
+		{"invalid page", []string{"-file", invalid}, "", 1, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.stdin != "" {
+				in, err := os.Open(tt.stdin)
+				if err != nil {
+					t.Fatal(err)
+				}
+				defer in.Close()
+				stdin := os.Stdin
+				os.Stdin = in
+				defer func() { os.Stdin = stdin }()
+			}
+			// The parser has no reachable source: the command must not need one
+			p := parser.NewParser("http://127.0.0.1:1/")
+			p.Clock = clock.Fixed{Time: time.Date(2024, time.June, 10, 12, 0, 0, 0, time.UTC)}
+
+			var exit int
+			output := captureStdout(t, func() { exit = runParse(p, tt.args) })
+			if exit != tt.exit {
+				t.Fatalf("exit code = %d, want %d (output %q)", exit, tt.exit, output)
+			}
+			if tt.exit != 0 {
+				if output != "" {
+					t.Errorf("output = %q, want none", output)
+				}
+				return
+			}
+			var fights []models.Fight
+			if err := json.Unmarshal([]byte(output), &fights); err != nil || len(fights) != tt.fights {
+				t.Errorf("output = %q (%v), want %d fights as JSON", output, err, tt.fights)
+			}
+		})
+	}
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"time"
+
+	"easypars/pkg/api"
+	"easypars/pkg/clock"
+	"easypars/pkg/parsejob"
+	"easypars/pkg/parser"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestGracefulShutdown(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name string
+		// sourceDelay is how long the source takes to answer
+		sourceDelay time.Duration
+		grace       time.Duration
+		// completed tells whether the slow request gets its response
+		completed bool
+	}{
+		{"request within the grace period", 300 * time.Millisecond, 5 * time.Second, true},
+		{"request past the grace period", time.Minute, 200 * time.Millisecond, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// The source answers after the delay or when its request is aborted
+			requested := make(chan struct{}, 1)
+			var aborted atomic.Bool
+			src := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				requested <- struct{}{}
+				select {
+				case <-time.After(tt.sourceDelay):
+					w.Header().Set("Content-Type", "text/html; charset=utf-8")
+					fmt.Fprint(w, checkPage)
+				case <-r.Context().Done():
+					aborted.Store(true)
+				}
+			}))
+			defer src.Close()
+			p := parser.NewParser(src.URL + "/")
+			p.Clock = clock.Fixed{Time: time.Date(2024, time.June, 10, 12, 0, 0, 0, time.UTC)}
+
+			// The server of main: requests run on a context cancelled after
+			// the grace period
+			listener, err := net.Listen("tcp", "127.0.0.1:0")
+			if err != nil {
+				t.Fatal(err)
+			}
+			requestsCtx, cancelRequests := context.WithCancel(context.Background())
+			server := &http.Server{
+				Handler:     api.SetupRouter(api.Dependencies{Parser: p}),
+				BaseContext: func(net.Listener) context.Context { return requestsCtx },
+			}
+			serverErr := make(chan error, 1)
+			go func() { serverErr <- server.Serve(listener) }()
+			logFile, err := os.Create(filepath.Join(t.TempDir(), "easypars.log"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			done := setupGracefulShutdown(server, cancelRequests, tt.grace, nil, nil, nil, parsejob.NewManager(), nil, nil, logFile)
+
+			// A slow request is in flight when the signal arrives
+			type response struct {
+				status int
+				err    error
+			}
+			responses := make(chan response, 1)
+			go func() {
+				resp, err := http.Get("http://" + listener.Addr().String() + "/api/fights")
+				if err != nil {
+					responses <- response{err: err}
+					return
+				}
+				resp.Body.Close()
+				responses <- response{status: resp.StatusCode}
+			}()
+			<-requested
+			begin := time.Now()
+			if err := syscall.Kill(os.Getpid(), syscall.SIGTERM); err != nil {
+				t.Fatal(err)
+			}
+
+			select {
+			case <-done:
+			case <-time.After(10 * time.Second):
+				t.Fatal("the shutdown did not complete")
+			}
+			if elapsed := time.Since(begin); elapsed > tt.grace+2*time.Second {
+				t.Errorf("the shutdown took %s with a grace period of %s", elapsed, tt.grace)
+			}
+			if err := <-serverErr; !errors.Is(err, http.ErrServerClosed) {
+				t.Errorf("Serve = %v, want http.ErrServerClosed", err)
+			}
+
+			got := <-responses
+			if tt.completed && (got.err != nil || got.status != http.StatusOK) {
+				t.Errorf("slow request = %d (%v), want its 200 before the shutdown completes", got.status, got.err)
+			}
+			if !tt.completed && got.status == http.StatusOK {
+				t.Error("the request past the grace period completed")
+			}
+			if got := aborted.Load(); got == tt.completed {
+				t.Errorf("source request aborted = %v, want %v", got, !tt.completed)
+			}
+
+			// New connections are refused once the server is shut down
+			if _, err := http.Get("http://" + listener.Addr().String() + "/api/health"); err == nil {
+				t.Error("the server still accepts requests after the shutdown")
+			}
+		})
+	}
+}
@@ -1,23 +1,137 @@
 package models
 
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// Fight statuses
+const (
+	// StatusScheduled is an announced fight without a result
+	StatusScheduled = "scheduled"
+	// StatusCompleted is a fight with a result
+	StatusCompleted = "completed"
+	// StatusResultUnknown is a past fight whose result never appeared
+	StatusResultUnknown = "result_unknown"
+	// StatusCancelled is a fight marked as cancelled in the source
+	StatusCancelled = "cancelled"
+)
+
+// Result types of a fight, see Fight.ResultType
+const (
+	ResultKO        = "KO"
+	ResultTKO       = "TKO"
+	ResultUD        = "UD"
+	ResultSD        = "SD"
+	ResultMD        = "MD"
+	ResultDraw      = "Draw"
+	ResultNC        = "NC"
+	ResultScheduled = "Scheduled"
+	// ResultCancelled is a fight the source marks as cancelled
+	ResultCancelled = "Cancelled"
+	// ResultUnknown is a result text in a format the parser does not know
+	ResultUnknown = "Unknown"
+)
+
 // Fight represents a fight record
-// Future steps: Add GORM tags, validation tags, and additional fields
+// Future steps: Add validation tags and additional fields
 type Fight struct {
 	// Basic fields
-	ID       uint   `json:"id"`
-	Date     string `json:"date"`
+	// ID is the public identifier of the fight, derived from the natural key
+	// (see FightID), so the same fight keeps its ID across parses and
+	// restarts; it is not stored
+	ID       string `json:"id" gorm:"-"`
+	Date     string `json:"date" gorm:"index"`
 	Fighter1 string `json:"fighter1"`
 	Fighter2 string `json:"fighter2"`
 	Result   string `json:"result"`
 	Location string `json:"location"`
 
+	// ResultType classifies Result as one of the Result* constants and
+	// Round is the round it names (the last round of a decision), 0 when
+	// the text has none; Result keeps the raw text
+	ResultType string `json:"result_type,omitempty"`
+	Round      int    `json:"round,omitempty"`
+
+	// Key is the natural key of the fight (date, normalized fighter names and
+	// location, see NaturalKey)
+	// Storage backends use it as the unique upsert key
+	Key string `json:"key" gorm:"uniqueIndex;not null"`
+	// RowID is the primary key of the SQL backends, internal to storage
+	RowID uint `json:"-" gorm:"column:id;primaryKey"`
+
+	// Status is one of the Status* constants
+	Status string `json:"status" gorm:"index"`
+
+	// Confidence tells how reliable the extracted record is (0..1)
+	Confidence float64 `json:"confidence"`
+	// HiddenInSource marks fights found in commented-out HTML of the source page
+	HiddenInSource bool `json:"hidden_in_source,omitempty"`
+	// YearAdjusted marks fights whose year was corrected by the consistency checks
+	YearAdjusted bool `json:"year_adjusted,omitempty"`
+	// Warnings lists data quality warnings attached to the fight
+	Warnings []string `json:"warnings,omitempty" gorm:"serializer:json"`
+
+	// External IDs of the fighters by source ("boxrec" -> "447121")
+	Fighter1ExternalIDs map[string]string `json:"fighter1_external_ids,omitempty" gorm:"serializer:json"`
+	Fighter2ExternalIDs map[string]string `json:"fighter2_external_ids,omitempty" gorm:"serializer:json"`
+
+	// CardPosition is the 1-based position of the fight on its card (same date
+	// and location) in source order, 0 when unknown
+	CardPosition int `json:"card_position,omitempty"`
+
+	// Records of the fighters before the fight, nil when the source shows none
+	Fighter1Record *FighterRecord `json:"fighter1_record,omitempty" gorm:"serializer:json"`
+	Fighter2Record *FighterRecord `json:"fighter2_record,omitempty" gorm:"serializer:json"`
+
+	// Raw keeps the source text the fight was parsed from, for reparsing
+	Raw *RawFields `json:"raw,omitempty" gorm:"serializer:json"`
+
+	// SourceURL is the page the fight was last seen on, e.g. the results
+	// page or the archive of its month
+	SourceURL string `json:"source_url,omitempty"`
+	// MissingSince is set when the fight disappeared from its page; it is
+	// kept until an archive confirms it or the grace period ends (see
+	// storage.TrackMissing)
+	MissingSince *time.Time `json:"missing_since,omitempty"`
+	// ParsedAt is when the fight was last stored from a parse of the
+	// source, set by the storage backends; nil for fights not stored yet
+	ParsedAt *time.Time `json:"parsed_at,omitempty"`
+
+	// Rematch fields are derived when a snapshot is built and are not stored
+	Rematch          bool              `json:"rematch" gorm:"-"`
+	MeetingNumber    int               `json:"meeting_number,omitempty" gorm:"-"`
+	PreviousMeetings []PreviousMeeting `json:"previous_meetings,omitempty" gorm:"-"`
+	// LocationID identifies the canonical location of differently spelled
+	// locations, derived when a snapshot is built
+	LocationID string `json:"location_id,omitempty" gorm:"-"`
+	// InterestScore rates upcoming fights, derived when a snapshot is published
+	InterestScore float64 `json:"interest_score,omitempty" gorm:"-"`
+	// Slug is the human readable permalink of the fight ("usyk-vs-fury-2024-05-18"),
+	// unique within a snapshot and derived when the snapshot is built
+	Slug string `json:"slug,omitempty" gorm:"-"`
+	// Fighter1Country, Fighter2Country and LocationCountry are ISO 3166-1
+	// alpha-2 codes, derived when a snapshot is built from the flags of the
+	// boxers and the location; empty when unknown
+	Fighter1Country string `json:"fighter1_country,omitempty" gorm:"-"`
+	Fighter2Country string `json:"fighter2_country,omitempty" gorm:"-"`
+	LocationCountry string `json:"location_country,omitempty" gorm:"-"`
+	// Country names in the language of the request (Accept-Language), set
+	// by the API when the code is known
+	Fighter1CountryName string `json:"fighter1_country_name,omitempty" gorm:"-"`
+	Fighter2CountryName string `json:"fighter2_country_name,omitempty" gorm:"-"`
+	LocationCountryName string `json:"location_country_name,omitempty" gorm:"-"`
+	// RelatedNews lists the URLs of news items about the fight, linked when
+	// the news are published (see pkg/newslink)
+	RelatedNews []string `json:"related_news,omitempty" gorm:"-"`
+
 	// Future fields to be added:
-	// ID          uint      `json:"id" gorm:"primaryKey"`
-	// Date        time.Time `json:"date" gorm:"not null"`
 	// Fighter1ID  uint      `json:"fighter1_id" gorm:"not null"`
 	// Fighter2ID  uint      `json:"fighter2_id" gorm:"not null"`
-	// ResultType  string    `json:"result_type"` // KO, TKO, Decision, etc.
-	// Round       int       `json:"round"`
 	// Time        string    `json:"time"`
 	// Weight      float64   `json:"weight"`
 	// Title       string    `json:"title"`
@@ -26,12 +140,154 @@ type Fight struct {
 	// DeletedAt   *time.Time `json:"deleted_at" gorm:"index"`
 }
 
+// MaxRawTextLength bounds every raw text field in runes
+const MaxRawTextLength = 200
+
+// RawFields holds the exact cell texts of the source row
+// Stored fights can be parsed again from them when the parsing rules improve
+type RawFields struct {
+	DateText     string `json:"date_text,omitempty"`
+	ResultText   string `json:"result_text,omitempty"`
+	LocationText string `json:"location_text,omitempty"`
+	Boxer1Text   string `json:"boxer1_text,omitempty"`
+	Boxer2Text   string `json:"boxer2_text,omitempty"`
+	// Boxer1Country and Boxer2Country are the flags of the boxer cells: the
+	// title of the flag image or its address, kept even when unrecognized
+	Boxer1Country string `json:"boxer1_country,omitempty"`
+	Boxer2Country string `json:"boxer2_country,omitempty"`
+	// RefMonth is the month (YYYY-MM, source time zone) incomplete dates
+	// were resolved in
+	RefMonth string `json:"ref_month,omitempty"`
+}
+
+// TruncateRaw shortens a raw text to MaxRawTextLength runes
+func TruncateRaw(text string) string {
+	if len(text) <= MaxRawTextLength {
+		return text
+	}
+	if runes := []rune(text); len(runes) > MaxRawTextLength {
+		return string(runes[:MaxRawTextLength])
+	}
+	return text
+}
+
+// PreviousMeeting references an earlier fight between the same pair of fighters
+type PreviousMeeting struct {
+	Key    string `json:"key"`
+	Date   string `json:"date"`
+	Result string `json:"result"`
+}
+
+// NaturalKey builds the natural key of the fight
+// The key is the date, the fighters and the venue. It does not depend on the
+// order of the fighters or on the case and punctuation of the names, so the
+// same bout parsed twice always maps to the same record, while the same pair
+// on the same date at two venues are two records.
+func (f Fight) NaturalKey() string {
+	name1 := NormalizeName(f.Fighter1)
+	name2 := NormalizeName(f.Fighter2)
+	if name2 < name1 {
+		name1, name2 = name2, name1
+	}
+
+	return f.Date + "|" + name1 + "|" + name2 + "|" + NormalizeLocation(f.Location)
+}
+
+// AssignKey sets the natural key of the fight and the ID derived from it
+func (f *Fight) AssignKey() {
+	f.Key = f.NaturalKey()
+	f.ID = FightID(f.Key)
+}
+
+// FightID derives the public ID of a fight from its natural key
+// The ID is the first 12 hex digits of the SHA-256 of the key, so it is
+// stable for the same fight and short enough for URLs
+func FightID(key string) string {
+	if key == "" {
+		return ""
+	}
+	sum := sha256.Sum256([]byte(key))
+
+	return hex.EncodeToString(sum[:6])
+}
+
+// NormalizeName normalizes a fighter name for comparisons
+// Trims and collapses whitespace and lowercases the name
+func NormalizeName(name string) string {
+	return strings.ToLower(strings.Join(strings.Fields(name), " "))
+}
+
+// NormalizeLocation normalizes a venue for the natural key
+// Lowercases the location and keeps only its words, so "Kingdom Arena,
+// Riyadh" and "kingdom arena  riyadh" are the same venue
+func NormalizeLocation(location string) string {
+	words := strings.FieldsFunc(strings.ToLower(location), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	return strings.Join(words, " ")
+}
+
+// External ID sources
+const (
+	// ExternalSourceBoxRec is the BoxRec boxer database (boxrec.com)
+	ExternalSourceBoxRec = "boxrec"
+)
+
+// ParseExternalID splits a "source:id" reference such as "boxrec:447121"
+func ParseExternalID(ref string) (source, id string, ok bool) {
+	source, id, ok = strings.Cut(ref, ":")
+	source = strings.ToLower(strings.TrimSpace(source))
+	id = strings.TrimSpace(id)
+	if !ok || source == "" || id == "" {
+		return "", "", false
+	}
+
+	return source, id, true
+}
+
+// FighterRecord is the professional record of a fighter as shown next to
+// the name on the source ("(27-1, 24 KO)", "(30-0-1)")
+type FighterRecord struct {
+	Wins   int `json:"wins"`
+	Losses int `json:"losses"`
+	Draws  int `json:"draws"`
+	// KOs is the number of wins by knockout, nil when the source omits it
+	KOs *int `json:"kos,omitempty"`
+}
+
+// String formats the record like the source, e.g. "27-1-0, 24 KO"
+func (r *FighterRecord) String() string {
+	if r == nil {
+		return ""
+	}
+	text := fmt.Sprintf("%d-%d-%d", r.Wins, r.Losses, r.Draws)
+	if r.KOs != nil {
+		text += fmt.Sprintf(", %d KO", *r.KOs)
+	}
+
+	return text
+}
+
 // Fighter represents a fighter record
 // Future steps: Add comprehensive fighter information
 type Fighter struct {
-	ID   uint   `json:"id"`
+	ID   uint   `json:"id,omitempty"`
 	Name string `json:"name"`
 
+	// Key is the normalized name the fighter is identified by
+	Key string `json:"key"`
+	// ExternalIDs maps external sources to the fighter ID in them
+	// It is never nil, a fighter without external IDs has an empty map
+	ExternalIDs map[string]string `json:"external_ids"`
+	// FightCount is the number of fights of the fighter in the data set
+	FightCount int `json:"fight_count"`
+	// LastFightDate is the latest date of the fights of the fighter
+	LastFightDate string `json:"last_fight_date,omitempty"`
+	// Opponents are the names of the opponents sorted by normalized name
+	// It is never nil; placeholder opponents are left out
+	Opponents []string `json:"opponents"`
+
 	// Future fields to be added:
 	// ID          uint      `json:"id" gorm:"primaryKey"`
 	// Name        string    `json:"name" gorm:"not null"`
@@ -49,9 +305,44 @@ type Fighter struct {
 	// DeletedAt   *time.Time `json:"deleted_at" gorm:"index"`
 }
 
+// NewsItem is a news article or announcement from a news source
+type NewsItem struct {
+	Title   string `json:"title"`
+	Summary string `json:"summary,omitempty"`
+	URL     string `json:"url"`
+	// PublishedAt is the publication time of the item
+	PublishedAt time.Time `json:"published_at"`
+	// RelatedFightKey is the natural key of the fight the item is about,
+	// empty when it could not be linked to a single fight
+	RelatedFightKey string `json:"related_fight_key,omitempty"`
+}
+
+// Event is a fight card: the fights on the same date at the same location
+type Event struct {
+	// Key identifies the card: the date and the normalized location
+	Key      string `json:"key"`
+	Date     string `json:"date"`
+	Location string `json:"location"`
+	// MainEvent is the headliner of the card, see parser.GroupEvents
+	MainEvent *Fight  `json:"main_event,omitempty"`
+	Fights    []Fight `json:"fights"`
+}
+
+// FightChange is a change of a stored fight detected when it was parsed
+// again, e.g. a scheduled fight getting its result or a corrected location
+// Fights are identified by their natural key, which survives reparses of
+// the storage; Old and New are the field values as text.
+type FightChange struct {
+	ID         uint      `json:"-" gorm:"primaryKey"`
+	FightKey   string    `json:"fight_key" gorm:"index;not null"`
+	Field      string    `json:"field"`
+	Old        string    `json:"old"`
+	New        string    `json:"new"`
+	DetectedAt time.Time `json:"detected_at"`
+}
+
 // Future models to be implemented:
 // - User (for authentication)
-// - Event (for fight events)
 // - WeightClass
 // - Organization (UFC, Bellator, etc.)
 // - Venue
@@ -0,0 +1,97 @@
+package models
+
+import (
+	"fmt"
+	"regexp"
+	"testing"
+)
+
+// idPattern matches the IDs returned by FightID
+var idPattern = regexp.MustCompile(`^[0-9a-f]{12}$`)
+
+func TestFightIDIsDeterministic(t *testing.T) {
+	parsed := Fight{Date: "2024-05-18", Fighter1: "Oleksandr Usyk", Fighter2: "Tyson Fury", Location: "Kingdom Arena, Riyadh"}
+	parsed.AssignKey()
+	if !idPattern.MatchString(parsed.ID) {
+		t.Fatalf("ID = %q, want 12 hex digits", parsed.ID)
+	}
+	if want := "2024-05-18|oleksandr usyk|tyson fury|kingdom arena riyadh"; parsed.Key != want {
+		t.Errorf("key = %q, want %q", parsed.Key, want)
+	}
+
+	// The same bout parsed again, with swapped, respelled fighters and a
+	// differently punctuated location, keeps its ID
+	again := Fight{Date: "2024-05-18", Fighter1: " tyson  FURY", Fighter2: "oleksandr usyk", Location: " kingdom arena  RIYADH."}
+	again.AssignKey()
+	if again.ID != parsed.ID {
+		t.Errorf("ID of the same fight = %q, want %q", again.ID, parsed.ID)
+	}
+	if got := FightID(parsed.NaturalKey()); got != parsed.ID {
+		t.Errorf("FightID of the key = %q, want %q", got, parsed.ID)
+	}
+
+	if got := FightID(""); got != "" {
+		t.Errorf("FightID of an empty key = %q, want empty", got)
+	}
+}
+
+func TestFightIDSeparatesFightsOfACard(t *testing.T) {
+	card := []Fight{
+		{Date: "2024-06-01", Fighter1: "Dmitry Bivol", Fighter2: "Artur Beterbiev"},
+		{Date: "2024-06-01", Fighter1: "Zhilei Zhang", Fighter2: "Deontay Wilder"},
+		{Date: "2024-06-01", Fighter1: "Dmitry Bivol", Fighter2: "Zhilei Zhang"},
+		// The same pair on another date is another fight
+		{Date: "2024-10-12", Fighter1: "Dmitry Bivol", Fighter2: "Artur Beterbiev"},
+		// The same pair on the same date at other venues are other fights
+		{Date: "2024-06-01", Fighter1: "Dmitry Bivol", Fighter2: "Artur Beterbiev", Location: "Riyadh"},
+		{Date: "2024-06-01", Fighter1: "Dmitry Bivol", Fighter2: "Artur Beterbiev", Location: "Las Vegas"},
+	}
+
+	seen := make(map[string]string, len(card))
+	for _, fight := range card {
+		fight.AssignKey()
+		if other, ok := seen[fight.ID]; ok {
+			t.Errorf("%s and %s share the ID %s", fight.Key, other, fight.ID)
+		}
+		seen[fight.ID] = fight.Key
+	}
+}
+
+func TestFightIDHasNoCollisions(t *testing.T) {
+	// 48 bits leave the chance of any collision among these keys below 1e-4
+	const dates, pairs = 366, 200
+	seen := make(map[string]string, dates*pairs)
+	for d := 0; d < dates; d++ {
+		date := fmt.Sprintf("2024-%02d-%02d", d/31+1, d%31+1)
+		for p := 0; p < pairs; p++ {
+			key := Fight{Date: date, Fighter1: fmt.Sprintf("Boxer %d", p), Fighter2: fmt.Sprintf("Rival %d", p%7)}.NaturalKey()
+			id := FightID(key)
+			if other, ok := seen[id]; ok && other != key {
+				t.Fatalf("%q and %q share the ID %s", key, other, id)
+			}
+			seen[id] = key
+		}
+	}
+	if len(seen) != dates*pairs {
+		t.Errorf("got %d distinct IDs, want %d", len(seen), dates*pairs)
+	}
+}
+
+func TestNormalizeLocation(t *testing.T) {
+	tests := []struct {
+		location string
+		want     string
+	}{
+		{"Kingdom Arena, Riyadh", "kingdom arena riyadh"},
+		{"  T-Mobile Arena;  Las Vegas ", "t mobile arena las vegas"},
+		{"Эр-Рияд, Саудовская Аравия", "эр рияд саудовская аравия"},
+		{"O2 Arena", "o2 arena"},
+		{" , ", ""},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := NormalizeLocation(tt.location); got != tt.want {
+			t.Errorf("NormalizeLocation(%q) = %q, want %q", tt.location, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,58 @@
+package models
+
+import "sort"
+
+// CompareCanonical orders fights in the canonical order of the API
+// Newest date first (fights without a date last), then the position on the
+// card (unknown positions last), then the natural key, which is unique and
+// makes the order total. Returns a negative number when a goes first.
+func CompareCanonical(a, b Fight) int {
+	if a.Date != b.Date {
+		switch {
+		case a.Date == "":
+			return 1
+		case b.Date == "":
+			return -1
+		case a.Date > b.Date:
+			return -1
+		default:
+			return 1
+		}
+	}
+
+	if a.CardPosition != b.CardPosition {
+		switch {
+		case a.CardPosition == 0:
+			return 1
+		case b.CardPosition == 0:
+			return -1
+		case a.CardPosition < b.CardPosition:
+			return -1
+		default:
+			return 1
+		}
+	}
+
+	keyA, keyB := a.Key, b.Key
+	if keyA == "" {
+		keyA = a.NaturalKey()
+	}
+	if keyB == "" {
+		keyB = b.NaturalKey()
+	}
+	switch {
+	case keyA < keyB:
+		return -1
+	case keyA > keyB:
+		return 1
+	}
+
+	return 0
+}
+
+// SortCanonical sorts fights in the canonical order
+func SortCanonical(fights []Fight) {
+	sort.SliceStable(fights, func(i, j int) bool {
+		return CompareCanonical(fights[i], fights[j]) < 0
+	})
+}
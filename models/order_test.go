@@ -0,0 +1,36 @@
+package models
+
+import "testing"
+
+func TestCompareCanonical(t *testing.T) {
+	fight := func(date string, position int, fighter1 string) Fight {
+		f := Fight{Date: date, CardPosition: position, Fighter1: fighter1, Fighter2: "Opponent"}
+		f.AssignKey()
+		return f
+	}
+
+	tests := []struct {
+		name  string
+		first Fight
+		then  Fight
+	}{
+		{"newer date first", fight("2024-06-01", 3, "B"), fight("2024-05-01", 1, "A")},
+		{"undated last", fight("2020-01-01", 0, "B"), fight("", 1, "A")},
+		{"card position within a date", fight("2024-06-01", 1, "B"), fight("2024-06-01", 2, "A")},
+		{"unknown position last", fight("2024-06-01", 5, "B"), fight("2024-06-01", 0, "A")},
+		{"key breaks the remaining ties", fight("2024-06-01", 1, "A"), fight("2024-06-01", 1, "B")},
+	}
+	for _, tt := range tests {
+		if got := CompareCanonical(tt.first, tt.then); got >= 0 {
+			t.Errorf("%s: CompareCanonical = %d, want negative", tt.name, got)
+		}
+		if got := CompareCanonical(tt.then, tt.first); got <= 0 {
+			t.Errorf("%s: reversed CompareCanonical = %d, want positive", tt.name, got)
+		}
+	}
+
+	same := fight("2024-06-01", 1, "A")
+	if got := CompareCanonical(same, same); got != 0 {
+		t.Errorf("CompareCanonical of a fight with itself = %d, want 0", got)
+	}
+}
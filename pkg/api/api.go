@@ -1,22 +1,368 @@
 package api
 
 import (
-	"github.com/gin-gonic/gin"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
 	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"easypars/models"
+	"easypars/pkg/apitypes"
+	"easypars/pkg/auth"
+	"easypars/pkg/backfill"
+	"easypars/pkg/cache"
+	"easypars/pkg/clock"
+	"easypars/pkg/contract"
+	"easypars/pkg/history"
+	"easypars/pkg/locations"
+	"easypars/pkg/metrics"
+	"easypars/pkg/newslink"
+	"easypars/pkg/ogcard"
+	"easypars/pkg/parsejob"
+	"easypars/pkg/parser"
+	"easypars/pkg/pipeline"
+	"easypars/pkg/presets"
+	"easypars/pkg/refresh"
+	"easypars/pkg/render"
+	"easypars/pkg/requestid"
+	"easypars/pkg/retention"
+	"easypars/pkg/safeexec"
+	"easypars/pkg/searchstats"
+	"easypars/pkg/snapshot"
+	"easypars/pkg/stats"
+	"easypars/pkg/storage"
+	"easypars/pkg/stream"
+	"easypars/pkg/webhook"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Dependencies groups the services used by the API handlers
+// Nil fields mean the corresponding feature is disabled
+type Dependencies struct {
+	// Parser fetches live data from the source site
+	Parser *parser.Parser
+	// Repository is the persistent fight storage (optional)
+	Repository storage.FightRepository
+	// History records parse runs and their logs (optional)
+	History *history.History
+	// Snapshots holds the published snapshot, a default store is used when nil
+	Snapshots *snapshot.Store
+	// Presets stores saved /api/fights queries (optional)
+	Presets *presets.Store
+	// News holds the published news items, linked to their fights in every
+	// snapshot (optional)
+	News *newslink.Store
+	// Backfill fills gaps in the stored archive (optional)
+	Backfill *backfill.Scheduler
+	// Retention deletes expired records of the auxiliary stores (optional)
+	Retention *retention.Runner
+	// SearchStats counts search terms, an in-memory tracker is used when nil
+	SearchStats *searchstats.Tracker
+	// Scoring holds the interest score weights, defaults are used when nil
+	Scoring *stats.Weights
+	// Contract checks fights at the layer boundaries, a logging checker is
+	// used when nil
+	Contract *contract.Checker
+	// LocationAliases is the dictionary of known locations (optional)
+	LocationAliases *locations.Dictionary
+	// PrecomputeAggregates lists the snapshot aggregates built right away
+	// instead of on first use
+	PrecomputeAggregates []string
+	// FastResponseBudget is how long ?fallback=accepted requests wait for
+	// data before answering 202, DefaultFastResponseBudget when zero
+	FastResponseBudget time.Duration
+	// MaxConcurrentRequests bounds the requests handled at once, no limit
+	// when zero
+	MaxConcurrentRequests int
+	// MaxConcurrentProbes bounds the health and metric requests handled at
+	// once, DefaultMaxConcurrentProbes when zero
+	MaxConcurrentProbes int
+	// QueueTimeout is how long a request over the limit waits for a slot
+	// before getting 503, DefaultQueueTimeout when zero
+	QueueTimeout time.Duration
+	// DefaultFilters are /api/fights filter parameters applied to every
+	// list request before the request parameters (see ValidateDefaultFilters)
+	DefaultFilters map[string]string
+	// CardColors are the colors of the fight preview cards, defaults when nil
+	CardColors *ogcard.Colors
+	// CardCache keeps rendered fight cards, an in-memory cache is used when nil
+	CardCache *ogcard.Cache
+	// APIKey unlocks the admin options of public endpoints such as
+	// ?ignore_defaults=1, sent in the X-API-Key header; none when empty
+	APIKey string
+	// APIKeys are the keys of the consumers of the read endpoints with
+	// their rate limits (see apiKeyGuard)
+	APIKeys []APIKey
+	// RequireAPIKey refuses read requests without an API key
+	RequireAPIKey bool
+	// IPRateLimit is the number of API requests per second and client IP,
+	// with bursts of up to IPRateBurst requests; no limit when zero
+	IPRateLimit float64
+	IPRateBurst int
+	// TrustedProxies are the addresses (IPs or CIDRs) of the reverse
+	// proxies whose X-Forwarded-For gives the client IP; without them the
+	// client IP is the address of the connection
+	TrustedProxies []string
+	// ExportMaxRows bounds the fights of an XLSX export of
+	// /api/fights/export, DefaultExportMaxRows when zero
+	ExportMaxRows int
+	// FeedItems is the number of results in /api/fights/feed.xml,
+	// DefaultFeedItems when zero
+	FeedItems int
+	// AnonymousRateLimit is the number of read requests per minute and
+	// client IP without an API key, no limit when zero
+	AnonymousRateLimit int
+	// MissingGrace is how long a fight that disappeared from its page is
+	// kept before it is deleted, storage.DefaultMissingGrace when zero
+	MissingGrace time.Duration
+	// EmbedAllowedAncestors are the origins allowed to embed the widget of
+	// /embed/upcoming (CSP frame-ancestors); no site when empty
+	EmbedAllowedAncestors []string
+	// EmbedRateLimit is the number of widget requests per minute and client
+	// IP, DefaultEmbedRateLimit when zero
+	EmbedRateLimit int
+	// ChangeHints tell when the fights of a published snapshot are expected
+	// to change (see snapshot.NextExpectedChange)
+	ChangeHints snapshot.ChangeHints
+	// CostThresholds are the bounds of the request cost classes,
+	// DefaultCostThresholds when zero
+	CostThresholds CostThresholds
+	// CompatThresholds decide the verdict of /api/admin/check-source,
+	// parser.DefaultCompatThresholds when zero
+	CompatThresholds parser.CompatThresholds
+	// FightCache keeps parse results of the source between requests, keyed
+	// by the source URL; every request parses the source when nil
+	FightCache cache.Cache[*parser.ParseResult]
+	// Refresher re-parses the source on a schedule (optional); while it
+	// runs, requests are served the published snapshot without parsing
+	Refresher *refresh.Scheduler
+	// ParseJobs runs the parses requested with POST /api/parse (optional)
+	ParseJobs *parsejob.Manager
+	// Webhooks posts new results to the registered webhooks (optional)
+	Webhooks *webhook.Dispatcher
+	// Stream broadcasts the fights added or changed by new snapshots to the
+	// clients of /api/fights/stream and /api/ws; a hub with the default
+	// settings is created when nil
+	Stream *stream.Hub
+	// StreamHeartbeat is the pause between the heartbeat comments of the
+	// stream and the pings of the WebSockets, DefaultStreamHeartbeat when
+	// zero
+	StreamHeartbeat time.Duration
+	// MaxParseAge is how old the last successful parse may be before
+	// /api/health/ready fails, DefaultMaxParseAge when zero
+	MaxParseAge time.Duration
+	// ReadyCheckTimeout bounds every readiness check,
+	// DefaultReadyCheckTimeout when zero
+	ReadyCheckTimeout time.Duration
+	// ReadyCacheTTL is how long a readiness result is reused,
+	// DefaultReadyCacheTTL when zero
+	ReadyCacheTTL time.Duration
+	// Tracing opens a span for every request (see httpTracing); enable it
+	// once a tracer provider is installed (see tracing.Setup)
+	Tracing bool
+	// ServeMetrics serves the Prometheus metrics at /metrics
+	ServeMetrics bool
+	// Auth issues the tokens of POST /api/auth/login and protects the admin
+	// endpoints with them; the admin endpoints answer 503 when nil
+	Auth *auth.Manager
+}
+
+// Preset creation limits per client IP
+const (
+	presetCreateLimit  = 10
+	presetCreateWindow = time.Hour
 )
 
+// handler holds the dependencies shared by the API handlers
+type handler struct {
+	deps Dependencies
+
+	// presetLimiter limits preset creation per client IP
+	presetLimiter *windowLimiter
+
+	// loginLimiter limits login attempts per client IP
+	loginLimiter *windowLimiter
+
+	// keyLimiter limits the read requests per API key and, without a key,
+	// per client IP
+	keyLimiter *tokenBucketLimiter
+
+	// ipLimiter limits the API requests per client IP, nil when disabled
+	ipLimiter *tokenBucketLimiter
+
+	// embedLimiter limits widget requests per client IP; embeds keeps the
+	// rendered widgets
+	embedLimiter *windowLimiter
+	embeds       embedCache
+
+	// refresher runs the background refreshes of fallback requests
+	refresher backgroundRefresher
+
+	// flights coalesces the snapshot refreshes of concurrent requests
+	flights snapshotFlights
+
+	// limits bounds the requests handled at once, nil when disabled
+	limits *concurrencyLimits
+
+	// errors counts the error responses by origin
+	errors errorMetrics
+
+	// costs counts the expensive requests
+	costs costMetrics
+
+	// cardRenderer draws the fight preview cards, nil when the fonts could
+	// not be loaded; cardCache keeps the drawn cards
+	cardRenderer *ogcard.Renderer
+	cardCache    *ogcard.Cache
+
+	// recovery describes the damaged storage file the stored fights were
+	// recovered from, nil once a recovery parse completed them
+	recovery atomic.Pointer[storage.Recovery]
+
+	// reconciledAt is the Unix time of the last aggregate reconciliation
+	reconciledAt atomic.Int64
+
+	// cachedAt is the UnixNano StoredAt of the cached parse result the
+	// active snapshot was built from
+	cachedAt atomic.Int64
+
+	// parsedAt is the UnixNano time of the last successful parse
+	parsedAt atomic.Int64
+
+	// startedAt is when the router was set up; ready caches the result of
+	// the readiness checks
+	startedAt time.Time
+	ready     readinessCache
+	// notified and streamed are the last snapshots compared for the webhooks
+	// and the update stream (see notifyCompleted and streamUpdates)
+	notified snapshotCursor
+	streamed snapshotCursor
+}
+
+// snapshotCursor remembers the last snapshot a consumer of the published
+// snapshots compared
+// Snapshots are compared with the last one seen rather than the one
+// published before, so changes published by a request between two
+// scheduled refreshes are not missed.
+type snapshotCursor struct {
+	mu   sync.Mutex
+	last *snapshot.Snapshot
+}
+
+// advance moves the cursor to snap and returns the snapshot to compare it
+// with; ok is false when snap was already seen or is older than the last
+// one, and prev is nil for the first snapshot
+func (sc *snapshotCursor) advance(snap *snapshot.Snapshot) (prev *snapshot.Snapshot, ok bool) {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+
+	prev = sc.last
+	if prev == snap || (prev != nil && snap.BuiltAt.Before(prev.BuiltAt)) {
+		return nil, false
+	}
+	sc.last = snap
+
+	return prev, true
+}
+
+// reconcileInterval is how often incrementally updated aggregates are
+// compared with a full rebuild
+const reconcileInterval = 24 * time.Hour
+
 // SetupRouter configures and returns the Gin router with all API endpoints
 // This function sets up the main router for the REST API
-func SetupRouter() *gin.Engine {
-	// Create Gin router with default middleware (Logger and Recovery)
-	router := gin.Default()
+func SetupRouter(deps Dependencies) *gin.Engine {
+	if deps.Snapshots == nil {
+		deps.Snapshots = snapshot.NewStore(snapshot.DefaultGuard())
+	}
+	if deps.SearchStats == nil {
+		deps.SearchStats = searchstats.New()
+	}
+	if deps.Contract == nil {
+		deps.Contract = contract.NewChecker(false)
+	}
+	if deps.Stream == nil {
+		deps.Stream = stream.NewHub(stream.Config{})
+	}
+	if deps.Scoring == nil {
+		weights := stats.DefaultWeights()
+		deps.Scoring = &weights
+	}
+	embedRateLimit := deps.EmbedRateLimit
+	if embedRateLimit <= 0 {
+		embedRateLimit = DefaultEmbedRateLimit
+	}
+	h := &handler{
+		deps:          deps,
+		presetLimiter: newWindowLimiter(presetCreateLimit, presetCreateWindow),
+		loginLimiter:  newWindowLimiter(loginLimit, loginWindow),
+		embedLimiter:  newWindowLimiter(embedRateLimit, time.Minute),
+		keyLimiter:    newTokenBucketLimiter(),
+		limits:        newConcurrencyLimits(deps),
+		cardCache:     deps.CardCache,
+		startedAt:     time.Now(),
+	}
+	if deps.IPRateLimit > 0 {
+		if h.deps.IPRateBurst < 1 {
+			h.deps.IPRateBurst = int(math.Ceil(deps.IPRateLimit))
+		}
+		h.ipLimiter = newTokenBucketLimiter()
+	}
+	if h.cardCache == nil {
+		h.cardCache = ogcard.NewCache(ogcard.DefaultMaxCached, "")
+	}
+	cardColors := ogcard.DefaultColors()
+	if deps.CardColors != nil {
+		cardColors = *deps.CardColors
+	}
+	if renderer, err := ogcard.NewRenderer(cardColors); err != nil {
+		slog.Warn("Fight cards disabled", "error", err)
+	} else {
+		h.cardRenderer = renderer
+	}
+
+	// The scheduled refresh publishes snapshots like API requests do
+	if deps.Refresher != nil {
+		deps.Refresher.SetFunc(h.scheduledRefresh)
+	}
+
+	// Stored fights recovered from a damaged file may be incomplete:
+	// parse the source right away to restore them
+	if recovery := storage.RecoveryOf(deps.Repository); recovery != nil {
+		h.recovery.Store(recovery)
+		if deps.Parser != nil {
+			go h.runRecoveryParse(context.Background())
+		}
+	}
+
+	// Create Gin router with the request ID, the access log carrying it and
+	// recovery from panics
+	router := gin.New()
+	router.Use(requestIDMiddleware, accessLog, httpMetrics, gin.Recovery())
+	if deps.Tracing {
+		router.Use(httpTracing)
+	}
+
+	// The client IP of the limits and the logs comes from X-Forwarded-For
+	// only when the request came through a trusted proxy
+	if err := router.SetTrustedProxies(deps.TrustedProxies); err != nil {
+		slog.Warn("Invalid trusted proxies, X-Forwarded-For is ignored", "error", err)
+		_ = router.SetTrustedProxies(nil)
+	}
 
 	// Enable CORS for frontend integration
 	// Future steps: Configure CORS properly for production
 	router.Use(func(c *gin.Context) {
 		c.Header("Access-Control-Allow-Origin", "*")
-		c.Header("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		c.Header("Access-Control-Allow-Headers", "Content-Type, Authorization")
+		c.Header("Access-Control-Allow-Methods", "GET, POST, PUT, PATCH, DELETE, OPTIONS")
+		c.Header("Access-Control-Allow-Headers", "Content-Type, Authorization, X-API-Key, "+confirmExpensiveHeader+", "+requestid.Header)
+		c.Header("Access-Control-Expose-Headers", requestid.Header+", Retry-After, X-RateLimit-Limit, X-RateLimit-Remaining, Content-Disposition")
 
 		if c.Request.Method == "OPTIONS" {
 			c.AbortWithStatus(204)
@@ -26,27 +372,146 @@ func SetupRouter() *gin.Engine {
 		c.Next()
 	})
 
+	// Backpressure: requests over the concurrency limit wait in a queue and
+	// get 503 when it times out; probes have a separate limit so they keep
+	// answering under load
+	if h.limits != nil {
+		router.Use(h.limits.middleware())
+	}
+
 	// API route group
-	// Future steps: Add versioning (v1, v2), authentication middleware
+	// Future steps: Add versioning (v1, v2)
 	api := router.Group("/api")
+	if h.ipLimiter != nil {
+		api.Use(h.ipRateLimit)
+	}
 	{
 		// Health check endpoint
 		// Future steps: Add database health check, system status
-		api.GET("/health", handleHealth)
+		api.GET("/health", h.handleHealth)
+
+		// Probes: the process is up, and the service can serve fresh data
+		api.GET("/health/live", h.handleHealthLive)
+		api.GET("/health/ready", h.handleHealthReady)
+
+		// Data endpoints refresh the fights from every source; expensive
+		// requests must be confirmed (see costGuard). Their consumers are
+		// identified and rate limited by API key (see apiKeyGuard)
 
 		// Fights endpoint - main functionality
-		// Future steps: Add pagination, filtering, search capabilities
-		api.GET("/fights", handleGetFights)
+		// Future steps: Add filtering, search capabilities
+		api.GET("/fights", h.apiKeyGuard, h.costGuard, h.handleGetFights)
+
+		// Announced fights and fights with a result, /api/fights?status=
+		// scheduled and ?status=completed
+		api.GET("/fights/upcoming", h.apiKeyGuard, fightsSubset(models.StatusScheduled), h.costGuard, h.handleGetFights)
+		api.GET("/fights/results", h.apiKeyGuard, fightsSubset(models.StatusCompleted), h.costGuard, h.handleGetFights)
+
+		// Calendar feed of the announced fights, /api/fights/upcoming?format=ics
+		api.GET("/fights/upcoming.ics", h.apiKeyGuard, fightsSubset(models.StatusScheduled), fightsFormat("ics"), h.costGuard, h.handleGetFights)
+
+		// The filtered fights as a file download (CSV or XLSX)
+		api.GET("/fights/export", h.apiKeyGuard, h.costGuard, h.handleExportFights)
+
+		// RSS or Atom feed of the latest results
+		api.GET("/fights/feed.xml", h.apiKeyGuard, h.costGuard, h.handleGetFeed)
+
+		// Live updates of the fights as Server-Sent Events; the stream is
+		// fed by the published snapshots and does not parse
+		api.GET("/fights/stream", h.apiKeyGuard, h.handleStreamFights)
+
+		// The same updates over a WebSocket, with filters changed by the
+		// client
+		api.GET("/ws", h.apiKeyGuard, h.handleWebSocket)
+
+		// Single fight by its slug, ID or natural key
+		api.GET("/fights/:id", h.apiKeyGuard, h.costGuard, h.handleGetFight)
+
+		// Single fight by its human readable permalink
+		api.GET("/fights/by-slug/:slug", h.apiKeyGuard, h.costGuard, h.handleGetFightBySlug)
+
+		// Webhooks notified of new results (admin)
+		api.POST("/webhooks", h.requireAuth, h.handleCreateWebhook)
+		api.GET("/webhooks", h.requireAuth, h.handleGetWebhooks)
+		api.DELETE("/webhooks/:id", h.requireAuth, h.handleDeleteWebhook)
+		api.GET("/webhooks/:id/deliveries", h.requireAuth, h.handleGetWebhookDeliveries)
+
+		// Token of the admin endpoints for the configured administrator
+		api.POST("/auth/login", h.handleLogin)
+
+		// Manual parse in the background, polled by its job ID
+		api.POST("/parse", h.requireAuth, h.handleStartParse)
+		api.GET("/parse/:jobID", h.apiKeyGuard, h.costGuard, h.handleGetParseJob)
+
+		// State of the scheduled refresh of the fights
+		api.GET("/fights/refresh-status", h.handleGetRefreshStatus)
+
+		// OpenGraph preview image of a fight, for links shared in messengers
+		api.GET("/fights/:id/card.png", h.apiKeyGuard, h.costGuard, h.handleGetFightCard)
+
+		// Changes of a fight detected when it was parsed again
+		api.GET("/fights/:id/history", h.apiKeyGuard, h.costGuard, h.handleGetFightHistory)
+
+		// Fight cards: the fights grouped by date and location
+		api.GET("/events", h.apiKeyGuard, h.costGuard, h.handleGetEvents)
+
+		// Fighters of the current data set, with lookup by external ID
+		api.GET("/fighters", h.apiKeyGuard, h.costGuard, h.handleGetFighters)
+
+		// Canonical locations with their spellings
+		api.GET("/locations", h.apiKeyGuard, h.costGuard, h.handleGetLocations)
+
+		// Summary statistics with the most interesting upcoming fights
+		api.GET("/stats", h.apiKeyGuard, h.costGuard, h.handleGetStats)
+
+		// oEmbed discovery of the embeddable widget
+		api.GET("/oembed", h.handleOEmbed)
+
+		// Saved query presets
+		api.POST("/presets", h.apiKeyGuard, h.costGuard, h.handleCreatePreset)
+		api.GET("/presets/:slug", h.apiKeyGuard, h.costGuard, h.handleGetPreset)
+
+		// Parse history endpoints
+		api.GET("/parse-history", h.apiKeyGuard, h.costGuard, h.handleGetParseHistory)
+		api.GET("/parse-history/:run_id/log", h.apiKeyGuard, h.costGuard, h.handleGetParseRunLog)
+
+		// Admin endpoints, protected by a bearer token and unavailable
+		// without configured authentication
+		admin := api.Group("/admin", h.requireAuth)
+		{
+			admin.GET("/snapshots/pending", h.handleGetPendingSnapshot)
+			admin.POST("/snapshots/publish-pending", h.handlePublishPendingSnapshot)
+			admin.POST("/backfill/start", h.handleStartBackfill)
+			admin.POST("/backfill/stop", h.handleStopBackfill)
+			admin.GET("/backfill/status", h.handleGetBackfillStatus)
+			admin.GET("/search-stats", h.handleGetSearchStats)
+			admin.POST("/reparse", h.handleReparse)
+			admin.POST("/retention/run", h.handleRunRetention)
+			admin.POST("/worker-pools/reset", h.handleResetWorkerPoolStats)
+			admin.GET("/data-quality", h.handleGetDataQuality)
+			admin.GET("/cache", h.handleGetCache)
+			admin.GET("/sources", h.handleGetSources)
+			admin.POST("/sources", h.handleCreateSource)
+			admin.PATCH("/sources/:name", h.handleUpdateSource)
+			admin.DELETE("/sources/:name", h.handleDeleteSource)
+			admin.POST("/check-source", h.handleCheckSource)
+		}
 
 		// Future endpoints to be added:
-		// api.GET("/fights/:id", handleGetFight)      // Get single fight
 		// api.POST("/fights", handleCreateFight)      // Create new fight (admin)
 		// api.PUT("/fights/:id", handleUpdateFight)   // Update fight (admin)
 		// api.DELETE("/fights/:id", handleDeleteFight) // Delete fight (admin)
-		// api.GET("/fighters", handleGetFighters)     // Get all fighters
 		// api.GET("/fighters/:id", handleGetFighter)  // Get single fighter
 	}
 
+	// Prometheus metrics of the API, the parser and the caches
+	if deps.ServeMetrics {
+		router.GET(metricsPath, gin.WrapH(metrics.Handler()))
+	}
+
+	// Widget with the upcoming fights for iframes of other sites
+	router.GET(embedPath, h.handleEmbedUpcoming)
+
 	// Serve static files for frontend
 	// Future steps: Use proper static file server in production
 	router.Static("/static", "./frontend")
@@ -57,58 +522,520 @@ func SetupRouter() *gin.Engine {
 
 // handleHealth handles GET requests to /api/health
 // Returns the health status of the application
-func handleHealth(c *gin.Context) {
+func (h *handler) handleHealth(c *gin.Context) {
 	// Future steps: Add database connectivity check, parser status
-	c.JSON(http.StatusOK, gin.H{
-		"status":  "healthy",
-		"message": "EasyPars API is running",
-		"version": "1.0.0",
-	})
+	response := apitypes.HealthResponse{
+		Status:  "healthy",
+		Message: "EasyPars API is running",
+		Version: "1.0.0",
+	}
+	if h.deps.Parser != nil {
+		response.EffectiveBaseURL = h.deps.Parser.EffectiveBaseURL()
+
+		// Requests to the source are paused after a rate limit response
+		if until := h.deps.Parser.SourcePausedUntil(); !until.IsZero() {
+			response.Status = "degraded"
+			response.Reasons = append(response.Reasons, "source_paused")
+			response.SourcePausedUntil = &until
+		}
+
+		// A server clock far off the source time gives wrong dates; a
+		// smaller skew is only shown
+		if h.deps.Parser.ClockSkew != nil {
+			if skew, checked := h.deps.Parser.ClockSkew.Status(); checked {
+				response.ClockSkew = &skew
+				if skew.Level == clock.SkewCritical {
+					response.Status = "degraded"
+					response.Reasons = append(response.Reasons, "clock_skew")
+				}
+			}
+		}
+	}
+
+	// Broken configuration elements do not stop the service, but are reported
+	if degraded := safeexec.Default.Degraded(); len(degraded) > 0 {
+		response.Status = "degraded"
+		response.Reasons = append(response.Reasons, "degraded_config")
+		response.DegradedConfig = degraded
+	}
+
+	// Stored fights recovered from a damaged file may be incomplete
+	if recovery := h.recovery.Load(); recovery != nil {
+		response.Status = "degraded"
+		response.Reasons = append(response.Reasons, "storage_recovery")
+		storageRecovery := apitypes.StorageRecovery(*recovery)
+		response.StorageRecovery = &storageRecovery
+	}
+
+	// Verbose health adds the load of the worker pools and of the
+	// concurrency limits and the error counts by origin
+	if c.Query("verbose") == "1" {
+		response.WorkerPools = pipeline.GetAllPoolStats()
+		response.Concurrency = h.limits.stats()
+		response.Errors, response.UnclassifiedErrors = h.errors.stats()
+		response.ExpensiveRequests = h.costs.stats()
+	}
+
+	c.JSON(http.StatusOK, response)
 }
 
 // handleGetFights handles GET requests for fight data
-// Returns a list of fights (currently hardcoded)
-func handleGetFights(c *gin.Context) {
+// Returns freshly parsed fights, falling back to stored ones when parsing fails
+func (h *handler) handleGetFights(c *gin.Context) {
 	// Future steps:
-	// 1. Integrate with parser to get real data
-	// 2. Add database queries using GORM
-	// 3. Implement pagination with query parameters
-	// 4. Add filtering by date, fighter, location
-	// 5. Add caching for performance
-
-	// Mock data for initial testing
-	fights := []map[string]interface{}{
-		{
-			"id":       1,
-			"date":     "2024-01-15",
-			"fighter1": "John Doe",
-			"fighter2": "Jane Smith",
-			"result":   "John Doe wins by KO",
-			"location": "Las Vegas, NV",
-			"round":    3,
-			"time":     "2:45",
-		},
-		{
-			"id":       2,
-			"date":     "2024-01-20",
-			"fighter1": "Mike Johnson",
-			"fighter2": "Sarah Connor",
-			"result":   "Sarah Connor wins by Decision",
-			"location": "New York, NY",
-			"round":    5,
-			"time":     "5:00",
-		},
-	}
-
-	c.JSON(http.StatusOK, gin.H{
-		"message": "List of fights retrieved successfully",
-		"data":    fights,
-		"count":   len(fights),
+	// 1. Add filtering by date, fighter, location
+
+	// Apply a saved preset before reading any other parameter
+	if err := h.applyPreset(c); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "invalid_preset",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	if err := validateFightsParams(c.Request.URL.Query(), false); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "invalid_params",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	// ?pages needs the URL template of the later pages
+	if resultPages(c.Request.URL.Query()) > 1 && (h.deps.Parser == nil || h.deps.Parser.PageURL == "") {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "invalid_params",
+			"message": `invalid parameter "pages": result pages are not configured (parser.page_url)`,
+		})
+		return
+	}
+
+	// ?ignore_defaults=1 is checked before any data is loaded
+	filters, err := h.filterLayers(c)
+	if err != nil {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error":   "forbidden",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	// Check the response format before loading any data
+	if !render.Acceptable(c) {
+		return
+	}
+
+	// With ?fallback=accepted a slow source gets a 202 instead of a long wait,
+	// the started refresh keeps running and serves the retried request
+	// Otherwise a fresh parse result of the fight cache is served, unless
+	// ?refresh=true asks for a new parse
+	var snap *snapshot.Snapshot
+	var cached cacheStatus
+	if c.Query("fallback") == "accepted" {
+		var ready bool
+		snap, ready, err = h.fallbackSnapshot(c)
+		if err == nil && !ready {
+			return
+		}
+	} else {
+		snap, cached, err = h.cachedSnapshot(c.Request.Context(), flagSet(c.Query("refresh")))
+	}
+	// ?pages=N adds the later result pages to the response
+	if err == nil {
+		snap, err = h.withResultPages(c.Request.Context(), snap, resultPages(c.Request.URL.Query()))
+	}
+	if abortIfClientGone(c, err) {
+		return
+	}
+	if err != nil {
+		slog.ErrorContext(c.Request.Context(), "Failed to load fights", "error", err)
+		h.respondError(c, err, "parse_error", "Failed to load fight data")
+		return
+	}
+	setServerTiming(c, snap)
+	cached.setHeaders(c)
+
+	// Filters: the operator defaults first, then the request parameters
+	// Searches of the request are counted for the search statistics report
+	// The filtered fights are a copy, the snapshot view stays untouched
+	// Fights a date range excluded for an unparseable date are counted in
+	// applied_filters
+	fights, undated := filters.apply(snap.View())
+	if term := filters.searchTerm(); term != "" {
+		h.deps.SearchStats.Record(term, len(fights))
+	}
+	applied := filters.applied()
+	if applied != nil {
+		applied.UndatedExcluded = undated
+	}
+
+	// Optional ordering by the expected interest of upcoming fights;
+	// paginated lists otherwise keep the canonical order, newest first
+	page, limit, paginated := listPage(c.Request.URL.Query())
+	if c.Query("sort") == "interest" {
+		fights = sortedByInterest(fights)
+	} else if paginated && c.Query("group_by") == "" {
+		fights = sortedCanonical(fights)
+	}
+
+	// Country names follow the language of the client
+	fights = localizeCountries(fights, preferredLanguage(c.GetHeader("Accept-Language")))
+
+	// The serialized fights must keep the model invariants
+	if err := h.deps.Contract.Check(contract.BoundaryAPI, fights); err != nil {
+		h.respondError(c, err, "parse_error", "Failed to load fight data")
+		return
+	}
+
+	// Grouped output for the frontend feed
+	if groupBy := c.Query("group_by"); groupBy != "" {
+		respondGroupedFights(c, fights, groupBy, applied, snap.BuiltAt)
+		return
+	}
+
+	// ?page and ?limit select a page of the list, without them the whole
+	// list is page 1
+	fights, pagination := paginate(fights, page, limit, "fights")
+
+	message := "List of fights retrieved successfully"
+	body := apitypes.FightsResponse{
+		Message:    message,
+		Data:       fights,
+		Count:      len(fights),
+		Warnings:   snapshotWarnings(snap),
+		Pagination: &pagination,
+
+		AppliedFilters: applied,
+	}
+
+	// Polling clients revalidate with the ETag of the payload or the build
+	// time of the snapshot. The build time and the cache age are left out
+	// of the ETag: they change without a change of the fights, e.g. when
+	// the cached parse expires and the source has the same data.
+	if etag, err := payloadETag(c, body); err == nil && notModified(c, etag, snap.BuiltAt) {
+		return
+	}
+	body.LastUpdated = snap.BuiltAt
+	body.Cached = cached.Cached
+	body.CacheAgeSeconds = cached.ageSeconds()
+
+	// The format is selected by ?format= or the Accept header
+	render.Negotiate(c, http.StatusOK, render.ResponsePayload{
+		Message: message,
+		Fights:  fights,
+		Body:    body,
+	})
+}
+
+// snapshotWarnings returns data quality warnings shown in list responses
+func snapshotWarnings(snap *snapshot.Snapshot) []apitypes.Warning {
+	var warnings []apitypes.Warning
+	for _, role := range parser.DegradedColumns(snap.Columns) {
+		warnings = append(warnings, apitypes.Warning{Code: parser.IssueColumn, Role: role})
+	}
+
+	return warnings
+}
+
+// refreshSnapshot returns the active snapshot, refreshed unless the fight
+// cache holds a fresh result (see cachedSnapshot)
+func (h *handler) refreshSnapshot(ctx context.Context) (*snapshot.Snapshot, error) {
+	snap, _, err := h.cachedSnapshot(ctx, false)
+	return snap, err
+}
+
+// loadSnapshot loads fresh data, publishes it and returns the active snapshot
+// When loading fails or the guard rejects the new snapshot, the previously
+// published snapshot keeps being served. A result parsed from the source
+// is stored in the fight cache.
+func (h *handler) loadSnapshot(ctx context.Context) (*snapshot.Snapshot, error) {
+	result, err := h.loadFights(ctx)
+	if err != nil {
+		// Enforced contract violations are reported instead of being hidden
+		// behind the previous snapshot
+		var violationErr *contract.ViolationError
+		if errors.As(err, &violationErr) {
+			return nil, err
+		}
+		// A cancelled caller does not need the previous snapshot either
+		if ctx.Err() != nil {
+			return nil, err
+		}
+		if active := h.deps.Snapshots.Active(); active != nil {
+			slog.WarnContext(ctx, "Serving the active snapshot, loading failed", "error", err)
+			return active, nil
+		}
+		return nil, err
+	}
+
+	return h.publishLoaded(ctx, result), nil
+}
+
+// publishLoaded stores a loaded result in the fight cache, publishes its
+// snapshot and returns the active snapshot
+func (h *handler) publishLoaded(ctx context.Context, result *parser.ParseResult) *snapshot.Snapshot {
+	storedAt := h.cacheResult(ctx, result)
+	snap := h.publishResult(result)
+	if !storedAt.IsZero() {
+		h.cachedAt.Store(storedAt.UnixNano())
+	}
+
+	return snap
+}
+
+// publishResult builds the snapshot of a load result, publishes it and
+// returns the active snapshot
+func (h *handler) publishResult(result *parser.ParseResult) *snapshot.Snapshot {
+	// Build the snapshot to derive cross-fight fields (rematches, etc.)
+	// Aggregates are built lazily on first use, interest scores included, so
+	// they are computed once per published snapshot and not per request.
+	// Fighter and location aggregates are updated from the previous snapshot
+	// when it has them built.
+	prev := h.deps.Snapshots.Active()
+	h.maybeReconcile(prev)
+	snap := snapshot.BuildWith(result.Fights, snapshot.BuildOptions{
+		Previous:        prev,
+		LocationAliases: h.deps.LocationAliases,
+		Scoring:         h.deps.Scoring,
+		SearchCounts:    h.searchCounts,
+		Precompute:      h.deps.PrecomputeAggregates,
+		News:            h.deps.News.Items(),
 	})
+	snap.Columns = result.Columns
+	snap.Fingerprint = result.Provenance.Fingerprint
+	snap.NextExpectedChange = snapshot.NextExpectedChange(snap.Fights, h.parserClock(), h.deps.ChangeHints)
+	if result.Provenance.Origin == parser.OriginRecovered {
+		snap.Warnings = append(snap.Warnings, "stored fights were recovered from a damaged storage file, some may be missing")
+	}
+	for _, warning := range snap.Warnings {
+		slog.Warn("Snapshot warning", "warning", warning)
+	}
+
+	if err := h.deps.Snapshots.Publish(snap); err != nil {
+		slog.Warn("Snapshot not published", "fight_count", len(snap.Fights), "error", err)
+		h.recordIncident("guard_rejected", len(snap.Fights), err)
+	}
+
+	active := h.deps.Snapshots.Active()
+	h.notifyCompleted(active)
+	h.streamUpdates(active)
+
+	return active
+}
+
+// maybeReconcile compares the incremental aggregates of a snapshot with a
+// full rebuild, at most once per reconcileInterval
+// The check runs in the background and only logs differences: they point to
+// a bug in the incremental update, the served data is not changed.
+func (h *handler) maybeReconcile(snap *snapshot.Snapshot) {
+	if snap == nil || !snap.HasIncrementalAggregates() {
+		return
+	}
+	now := time.Now()
+	last := h.reconciledAt.Load()
+	if now.Sub(time.Unix(last, 0)) < reconcileInterval || !h.reconciledAt.CompareAndSwap(last, now.Unix()) {
+		return
+	}
+
+	go func() {
+		for _, difference := range snap.Reconcile() {
+			slog.Warn("Aggregate reconciliation", "difference", difference)
+		}
+	}()
+}
+
+// recordIncident writes a finished run describing an incident into the parse history
+func (h *handler) recordIncident(trigger string, fightCount int, err error) {
+	if h.deps.History == nil {
+		return
+	}
+
+	run := h.deps.History.Start(trigger)
+	h.deps.History.Finish(run.ID, history.RunResult{FightCount: fightCount, Err: err})
+}
+
+// loadFights returns the current fight data
+// Live parsing is preferred; parsed fights are persisted when storage is
+// configured, and stored fights are served if the source is unavailable
+func (h *handler) loadFights(ctx context.Context) (*parser.ParseResult, error) {
+	var parseErr error
+	if h.deps.Parser != nil {
+		result, err := h.parseFights(ctx, "api")
+		if err == nil {
+			return result, nil
+		}
+		var violationErr *contract.ViolationError
+		if errors.As(err, &violationErr) {
+			return nil, err
+		}
+		parseErr = err
+	}
+
+	// A cancelled parse is not a failed one, the stored fights are not needed
+	if ctx.Err() != nil && parseErr != nil {
+		return nil, parseErr
+	}
+
+	if h.deps.Repository != nil {
+		stored, err := h.deps.Repository.List(ctx, storage.FightFilter{})
+		if err != nil {
+			return nil, parser.Classify(parser.ErrorOriginInternal, fmt.Errorf("error loading stored fights: %w", err))
+		}
+		if err := h.deps.Contract.Check(contract.BoundaryStorage, stored); err != nil {
+			return nil, err
+		}
+		if len(stored) > 0 || parseErr == nil {
+			if parseErr != nil {
+				slog.WarnContext(ctx, "Serving stored fights, parsing failed", "fight_count", len(stored), "error", parseErr)
+			}
+			result := &parser.ParseResult{Fights: stored}
+			if h.recovery.Load() != nil {
+				result.Provenance.Origin = parser.OriginRecovered
+			}
+			return result, nil
+		}
+	}
+
+	if parseErr != nil {
+		return nil, parseErr
+	}
+
+	return &parser.ParseResult{}, nil
+}
+
+// parseFights parses the source, checks the fights against the contract and
+// persists them
+func (h *handler) parseFights(ctx context.Context, trigger string) (*parser.ParseResult, error) {
+	result, err := h.parseWithHistory(ctx, trigger)
+	if err != nil {
+		return nil, err
+	}
+	if err := h.deps.Contract.Check(contract.BoundaryParser, result.Fights); err != nil {
+		return nil, err
+	}
+	h.persistFights(ctx, result.Fights)
+
+	return h.keepMissing(ctx, result), nil
+}
+
+// parseWithHistory runs the parser and records the run in the parse history
+// Log records of the run are captured through the run ID bound to the context.
+// API requests are interactive: while the source is paused they fail at once
+// and the caller serves stored data instead of waiting. Scheduled refreshes
+// and manual parse jobs run in the background and wait like other
+// background parses.
+func (h *handler) parseWithHistory(ctx context.Context, trigger string) (*parser.ParseResult, error) {
+	if trigger != refresh.TriggerScheduled && trigger != parsejob.TriggerManual {
+		ctx = parser.WithInteractive(ctx)
+	}
+
+	var result *parser.ParseResult
+	var err error
+	if h.deps.History == nil {
+		result, err = h.deps.Parser.ParseAll(ctx)
+	} else {
+		run := h.deps.History.Start(trigger)
+		result, err = h.deps.Parser.ParseAll(history.WithRunID(ctx, run.ID))
+		h.deps.History.Finish(run.ID, history.ResultOf(result, err))
+	}
+
+	// The readiness probe checks the age of the last successful parse
+	if err == nil {
+		h.parsedAt.Store(time.Now().UnixNano())
+	}
+
+	return result, err
+}
+
+// runRecoveryParse parses the source after the stored fights were recovered
+// from a damaged file and persists the result
+// The recovery warning is cleared once the fights are persisted; when the
+// run fails, the warning stays and later parses persist the fights as usual.
+func (h *handler) runRecoveryParse(ctx context.Context) {
+	result, err := h.parseWithHistory(ctx, "recovery")
+	if err != nil {
+		slog.ErrorContext(ctx, "Recovery parse failed", "error", err)
+		return
+	}
+	if err := h.deps.Contract.Check(contract.BoundaryParser, result.Fights); err != nil {
+		slog.ErrorContext(ctx, "Recovery parse rejected", "error", err)
+		return
+	}
+
+	upserted, err := h.deps.Repository.UpsertFights(ctx, result.Fights)
+	if err != nil {
+		slog.ErrorContext(ctx, "Recovery parse not persisted", "error", err)
+		return
+	}
+	h.recovery.Store(nil)
+	slog.InfoContext(ctx, "Recovery parse persisted fights", "inserted", upserted.Inserted, "updated", upserted.Updated)
+}
+
+// persistFights stores parsed fights when storage is configured
+// Storage errors are logged and do not fail the request
+func (h *handler) persistFights(ctx context.Context, fights []models.Fight) {
+	if h.deps.Repository == nil || len(fights) == 0 {
+		return
+	}
+
+	result, err := h.deps.Repository.UpsertFights(ctx, fights)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to persist fights", "fight_count", len(fights), "error", err)
+		return
+	}
+
+	slog.InfoContext(ctx, "Persisted fights", "fight_count", len(fights), "inserted", result.Inserted, "updated", result.Updated, "changes", result.Changes)
+}
+
+// filterByFighter returns fights where either fighter name contains the term
+// Names and the term are compared after search folding (see nameSearch)
+func filterByFighter(fights []models.Fight, term string) []models.Fight {
+	search := newNameSearch(term)
+	filtered := make([]models.Fight, 0, len(fights))
+	for _, fight := range fights {
+		if search.matches(fight.Fighter1) || search.matches(fight.Fighter2) {
+			filtered = append(filtered, fight)
+		}
+	}
+
+	return filtered
+}
+
+// filterRematches returns only the fights marked as rematches
+func filterRematches(fights []models.Fight) []models.Fight {
+	filtered := make([]models.Fight, 0, len(fights))
+	for _, fight := range fights {
+		if fight.Rematch {
+			filtered = append(filtered, fight)
+		}
+	}
+
+	return filtered
+}
+
+// sortedByInterest returns a copy of the fights ordered by interest score
+// The snapshot slice is shared between requests and must not be reordered
+func sortedByInterest(fights []models.Fight) []models.Fight {
+	sorted := make([]models.Fight, len(fights))
+	copy(sorted, fights)
+	stats.SortByInterest(sorted)
+
+	return sorted
+}
+
+// searchCounts returns the weekly search counts used for the popularity factor
+func (h *handler) searchCounts() map[string]int {
+	report := h.deps.SearchStats.Top(searchstats.MaxTerms)
+	counts := make(map[string]int, len(report.Week))
+	for _, stat := range report.Week {
+		counts[stat.Term] = stat.Count
+	}
+
+	return counts
 }
 
 // Future functions to be implemented:
-// - JWT authentication middleware
 // - Input validation functions
 // - Error handling middleware
 // - Rate limiting middleware
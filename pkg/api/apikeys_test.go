@@ -0,0 +1,164 @@
+package api
+
+import (
+	"net/http"
+	"sync"
+	"testing"
+)
+
+// newAPIKeyRouter returns a router with a reader key of 5 requests per
+// minute, an unlimited batch key and the privileged key
+func newAPIKeyRouter(t *testing.T, deps Dependencies) http.Handler {
+	t.Helper()
+
+	deps.APIKey = "admin-secret"
+	deps.APIKeys = []APIKey{
+		{Key: "reader-key", Name: "reader", RequestsPerMinute: 5},
+		{Key: "batch-key", Name: "batch"},
+	}
+
+	return newTestRouter(t, readTestdata(t, "upcoming.html"), deps)
+}
+
+func TestAPIKeyRateLimitUnderConcurrency(t *testing.T) {
+	router := newAPIKeyRouter(t, Dependencies{})
+
+	const requests = 100
+	var wg sync.WaitGroup
+	codes := make([]int, requests)
+	for i := range codes {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			codes[i] = serve(router, http.MethodGet, "/api/fighters", "", "X-API-Key", "reader-key").Code
+		}()
+	}
+	wg.Wait()
+
+	// 5 per minute is a token every 12 seconds: only the burst is served
+	served, limited := 0, 0
+	for _, code := range codes {
+		switch code {
+		case http.StatusOK:
+			served++
+		case http.StatusTooManyRequests:
+			limited++
+		}
+	}
+	if served != 5 || limited != requests-5 {
+		t.Errorf("reader key got %d served and %d limited, want 5 and %d", served, limited, requests-5)
+	}
+
+	rec := serve(router, http.MethodGet, "/api/fighters", "", "X-API-Key", "reader-key")
+	if rec.Code != http.StatusTooManyRequests || errorCode(t, rec) != "rate_limited" {
+		t.Fatalf("reader key over the limit = %d %s, want 429 rate_limited", rec.Code, rec.Body)
+	}
+	if got := rec.Header().Get("Retry-After"); got != "12" {
+		t.Errorf("Retry-After = %q, want 12", got)
+	}
+	if limit, remaining := rec.Header().Get("X-RateLimit-Limit"), rec.Header().Get("X-RateLimit-Remaining"); limit != "5" || remaining != "0" {
+		t.Errorf("X-RateLimit-Limit, Remaining = %s, %s, want 5, 0", limit, remaining)
+	}
+
+	// The exhausted key does not affect the others
+	for _, key := range []string{"batch-key", "admin-secret"} {
+		for i := 0; i < 10; i++ {
+			if rec := serve(router, http.MethodGet, "/api/fighters", "", "X-API-Key", key); rec.Code != http.StatusOK {
+				t.Fatalf("request %d with the key %s = %d, want 200", i, key, rec.Code)
+			}
+		}
+	}
+}
+
+func TestAPIKeyGuard(t *testing.T) {
+	tests := []struct {
+		name      string
+		deps      Dependencies
+		key       string
+		status    int
+		code      string
+		remaining string
+	}{
+		{"reader key", Dependencies{}, "reader-key", http.StatusOK, "", "4"},
+		{"unlimited key", Dependencies{}, "batch-key", http.StatusOK, "", ""},
+		{"privileged key", Dependencies{}, "admin-secret", http.StatusOK, "", ""},
+		{"unknown key", Dependencies{}, "guessed-key", http.StatusUnauthorized, "invalid_api_key", ""},
+		{"anonymous", Dependencies{}, "", http.StatusOK, "", ""},
+		{"anonymous limited", Dependencies{AnonymousRateLimit: 2}, "", http.StatusOK, "", "1"},
+		{"key required", Dependencies{RequireAPIKey: true}, "", http.StatusUnauthorized, "api_key_required", ""},
+		{"key required with a key", Dependencies{RequireAPIKey: true}, "reader-key", http.StatusOK, "", "4"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := newAPIKeyRouter(t, tt.deps)
+			rec := serve(router, http.MethodGet, "/api/fighters", "", "X-API-Key", tt.key)
+			if rec.Code != tt.status {
+				t.Fatalf("GET /api/fighters = %d %s, want %d", rec.Code, rec.Body, tt.status)
+			}
+			if tt.code != "" && errorCode(t, rec) != tt.code {
+				t.Errorf("error = %q, want %q", errorCode(t, rec), tt.code)
+			}
+			if got := rec.Header().Get("X-RateLimit-Remaining"); got != tt.remaining {
+				t.Errorf("X-RateLimit-Remaining = %q, want %q", got, tt.remaining)
+			}
+		})
+	}
+}
+
+func TestAnonymousRateLimitIsPerClientIP(t *testing.T) {
+	router := newAPIKeyRouter(t, Dependencies{AnonymousRateLimit: 2})
+
+	for i := 0; i < 2; i++ {
+		if rec := serveFrom(router, "/api/fighters", "198.51.100.1:40000"); rec.Code != http.StatusOK {
+			t.Fatalf("anonymous request %d = %d, want 200", i, rec.Code)
+		}
+	}
+	if rec := serveFrom(router, "/api/fighters", "198.51.100.1:40000"); rec.Code != http.StatusTooManyRequests {
+		t.Errorf("third anonymous request = %d, want 429", rec.Code)
+	}
+	if rec := serveFrom(router, "/api/fighters", "198.51.100.2:40000"); rec.Code != http.StatusOK {
+		t.Errorf("anonymous request of another IP = %d, want 200", rec.Code)
+	}
+	// A key is limited by its own bucket, not by the IP sending it
+	if rec := serveFrom(router, "/api/fighters", "198.51.100.1:40000", "X-API-Key", "reader-key"); rec.Code != http.StatusOK {
+		t.Errorf("keyed request from the limited IP = %d, want 200", rec.Code)
+	}
+}
+
+func TestGuardedRoutes(t *testing.T) {
+	routes := []struct {
+		method string
+		target string
+		body   string
+	}{
+		{http.MethodGet, "/api/fights/abc/card.png", ""},
+		{http.MethodPost, "/api/presets", `{"name":"heavyweights","query":{"q":"usyk"}}`},
+		{http.MethodGet, "/api/presets/heavyweights", ""},
+		{http.MethodGet, "/api/parse-history", ""},
+		{http.MethodGet, "/api/parse-history/1/log", ""},
+		{http.MethodGet, "/api/parse/job-1", ""},
+	}
+	for _, route := range routes {
+		t.Run(route.method+" "+route.target, func(t *testing.T) {
+			// apiKeyGuard refuses unknown and missing keys
+			router := newAPIKeyRouter(t, Dependencies{RequireAPIKey: true})
+			rec := serve(router, route.method, route.target, route.body, "X-API-Key", "guessed-key")
+			if rec.Code != http.StatusUnauthorized || errorCode(t, rec) != "invalid_api_key" {
+				t.Errorf("unknown key = %d %s, want 401 invalid_api_key", rec.Code, rec.Body)
+			}
+			rec = serve(router, route.method, route.target, route.body)
+			if rec.Code != http.StatusUnauthorized || errorCode(t, rec) != "api_key_required" {
+				t.Errorf("no key = %d %s, want 401 api_key_required", rec.Code, rec.Body)
+			}
+
+			// costGuard reports the estimate of a keyed request
+			rec = serve(router, route.method, route.target+"?show_cost=1", route.body, "X-API-Key", "reader-key")
+			if rec.Header().Get("X-Cost-Class") == "" {
+				t.Errorf("keyed request = %d without X-Cost-Class, want the cost estimate", rec.Code)
+			}
+			if got := rec.Header().Get("X-RateLimit-Remaining"); got != "4" {
+				t.Errorf("X-RateLimit-Remaining = %q, want 4", got)
+			}
+		})
+	}
+}
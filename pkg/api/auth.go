@@ -0,0 +1,144 @@
+package api
+
+import (
+	"errors"
+	"log/slog"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"easypars/pkg/apitypes"
+	"easypars/pkg/auth"
+
+	"github.com/gin-gonic/gin"
+)
+
+// authClaimsKey is the context key of the claims of an authenticated request
+const authClaimsKey = "auth_claims"
+
+// Login attempts per client IP, failed or not
+const (
+	loginLimit  = 10
+	loginWindow = 15 * time.Minute
+)
+
+// loginRequest is the body of POST /api/auth/login
+type loginRequest struct {
+	Username string `json:"username" binding:"required"`
+	Password string `json:"password" binding:"required"`
+}
+
+// handleLogin handles POST requests to /api/auth/login
+// The configured administrator gets a signed token for the admin endpoints.
+// Attempts are limited per client IP against password guessing.
+func (h *handler) handleLogin(c *gin.Context) {
+	if h.deps.Auth == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{
+			"error":   "auth_disabled",
+			"message": "Authentication is not configured",
+		})
+		return
+	}
+
+	if allowed, retryAfter := h.loginLimiter.Allow(c.ClientIP()); !allowed {
+		c.Header("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+		c.JSON(http.StatusTooManyRequests, gin.H{
+			"error":   "rate_limited",
+			"message": "Too many login attempts, try again later",
+		})
+		return
+	}
+
+	var req loginRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "invalid_body",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	token, expiresAt, err := h.deps.Auth.Login(req.Username, req.Password)
+	if errors.Is(err, auth.ErrInvalidCredentials) {
+		slog.WarnContext(c.Request.Context(), "Login failed", "username", req.Username, "client_ip", c.ClientIP())
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error":   "invalid_credentials",
+			"message": "Invalid username or password",
+		})
+		return
+	}
+	if err != nil {
+		slog.ErrorContext(c.Request.Context(), "Token not issued", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "auth_error",
+			"message": "Failed to issue a token",
+		})
+		return
+	}
+
+	slog.InfoContext(c.Request.Context(), "Login succeeded", "username", req.Username, "client_ip", c.ClientIP())
+	c.JSON(http.StatusOK, apitypes.LoginResponse{
+		Message:   "Login successful",
+		Token:     token,
+		TokenType: "Bearer",
+		ExpiresAt: expiresAt,
+	})
+}
+
+// requireAuth lets through requests with a valid bearer token and stores
+// its claims in the context (see authClaimsKey)
+// Requests without a token or with an invalid one get 401 unauthorized,
+// expired tokens 401 token_expired, so clients know to log in again.
+// Without configured authentication the protected endpoints fail closed
+// with 503 auth_disabled rather than serving anyone.
+func (h *handler) requireAuth(c *gin.Context) {
+	if h.deps.Auth == nil {
+		c.AbortWithStatusJSON(http.StatusServiceUnavailable, gin.H{
+			"error":   "auth_disabled",
+			"message": "Admin endpoints require authentication, configure jwt.secret",
+		})
+		return
+	}
+
+	token, ok := bearerToken(c.GetHeader("Authorization"))
+	if !ok {
+		respondUnauthorized(c, "unauthorized", "A bearer token is required")
+		return
+	}
+
+	claims, err := h.deps.Auth.Validate(token)
+	if errors.Is(err, auth.ErrTokenExpired) {
+		respondUnauthorized(c, "token_expired", "The token has expired, log in again")
+		return
+	}
+	if err != nil {
+		slog.WarnContext(c.Request.Context(), "Invalid token rejected", "client_ip", c.ClientIP(), "error", err)
+		respondUnauthorized(c, "unauthorized", "The token is invalid")
+		return
+	}
+
+	c.Set(authClaimsKey, claims)
+	c.Next()
+}
+
+// bearerToken extracts the token of an "Authorization: Bearer" header
+func bearerToken(header string) (string, bool) {
+	scheme, token, ok := strings.Cut(strings.TrimSpace(header), " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return "", false
+	}
+	token = strings.TrimSpace(token)
+
+	return token, token != ""
+}
+
+// respondUnauthorized aborts the request with 401 and the bearer challenge
+func respondUnauthorized(c *gin.Context, code, message string) {
+	c.Header("WWW-Authenticate", `Bearer realm="easypars"`)
+	c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+		"error":   code,
+		"message": message,
+	})
+}
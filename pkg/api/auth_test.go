@@ -0,0 +1,132 @@
+package api
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"easypars/pkg/apitypes"
+	"easypars/pkg/auth"
+
+	"github.com/golang-jwt/jwt/v5"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const testJWTSecret = "0123456789abcdef0123456789abcdef"
+
+// adminRoutes are protected endpoints of every kind, as method and path
+var adminRoutes = [][2]string{
+	{http.MethodPost, "/api/parse"},
+	{http.MethodGet, "/api/admin/cache"},
+	{http.MethodPost, "/api/admin/reparse"},
+	{http.MethodGet, "/api/webhooks"},
+	{http.MethodPost, "/api/webhooks"},
+}
+
+// newTestAuth returns a token manager for the password "secret password"
+func newTestAuth(t *testing.T) *auth.Manager {
+	t.Helper()
+
+	hash, err := bcrypt.GenerateFromPassword([]byte("secret password"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return auth.NewManager(auth.Config{
+		Secret:            testJWTSecret,
+		Issuer:            "easypars",
+		Expire:            time.Hour,
+		AdminUsername:     "admin",
+		AdminPasswordHash: string(hash),
+	})
+}
+
+// signTestToken signs admin claims issued at issuedAt with the secret
+func signTestToken(t *testing.T, secret string, issuedAt time.Time) string {
+	t.Helper()
+
+	claims := auth.Claims{
+		Role: auth.RoleAdmin,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    "easypars",
+			Subject:   "admin",
+			IssuedAt:  jwt.NewNumericDate(issuedAt),
+			ExpiresAt: jwt.NewNumericDate(issuedAt.Add(time.Hour)),
+		},
+	}
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(secret))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return token
+}
+
+func TestAdminEndpointsFailClosedWithoutAuth(t *testing.T) {
+	router := newTestRouter(t, readTestdata(t, "results.html"), Dependencies{})
+
+	for _, route := range adminRoutes {
+		rec := serve(router, route[0], route[1], "{}", "Content-Type", "application/json")
+		if rec.Code != http.StatusServiceUnavailable || errorCode(t, rec) != "auth_disabled" {
+			t.Errorf("%s %s without auth = %d %s, want 503 auth_disabled", route[0], route[1], rec.Code, rec.Body)
+		}
+	}
+
+	// A forged token does not help either
+	token := signTestToken(t, testJWTSecret, time.Now())
+	rec := serve(router, http.MethodGet, "/api/admin/cache", "", "Authorization", "Bearer "+token)
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("GET /api/admin/cache with a token and no auth = %d, want 503", rec.Code)
+	}
+}
+
+func TestAdminEndpointsRequireAValidToken(t *testing.T) {
+	router := newTestRouter(t, readTestdata(t, "results.html"), Dependencies{Auth: newTestAuth(t)})
+
+	tests := []struct {
+		name   string
+		header string
+		code   string
+	}{
+		{"no token", "", "unauthorized"},
+		{"basic auth", "Basic YWRtaW46c2VjcmV0", "unauthorized"},
+		{"forged token", "Bearer " + signTestToken(t, "another secret of thirty-two bytes", time.Now()), "unauthorized"},
+		{"expired token", "Bearer " + signTestToken(t, testJWTSecret, time.Now().Add(-2*time.Hour)), "token_expired"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, route := range adminRoutes {
+				rec := serve(router, route[0], route[1], "{}", "Content-Type", "application/json", "Authorization", tt.header)
+				if rec.Code != http.StatusUnauthorized || errorCode(t, rec) != tt.code {
+					t.Errorf("%s %s = %d %s, want 401 %s", route[0], route[1], rec.Code, rec.Body, tt.code)
+				}
+				if rec.Header().Get("WWW-Authenticate") == "" {
+					t.Errorf("%s %s has no WWW-Authenticate challenge", route[0], route[1])
+				}
+			}
+		})
+	}
+}
+
+func TestLoginTokenOpensAdminEndpoints(t *testing.T) {
+	router := newTestRouter(t, readTestdata(t, "results.html"), Dependencies{Auth: newTestAuth(t)})
+
+	rec := serve(router, http.MethodPost, "/api/auth/login", `{"username":"admin","password":"wrong"}`, "Content-Type", "application/json")
+	if rec.Code != http.StatusUnauthorized || errorCode(t, rec) != "invalid_credentials" {
+		t.Errorf("login with a wrong password = %d %s, want 401 invalid_credentials", rec.Code, rec.Body)
+	}
+
+	rec = serve(router, http.MethodPost, "/api/auth/login", `{"username":"admin","password":"secret password"}`, "Content-Type", "application/json")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("login = %d %s, want 200", rec.Code, rec.Body)
+	}
+	var login apitypes.LoginResponse
+	decodeJSON(t, rec, &login)
+
+	// The cache report needs a published snapshot
+	serve(router, http.MethodGet, "/api/fights", "")
+	rec = serve(router, http.MethodGet, "/api/admin/cache", "", "Authorization", "Bearer "+login.Token)
+	if rec.Code != http.StatusOK {
+		t.Errorf("GET /api/admin/cache with the login token = %d %s, want 200", rec.Code, rec.Body)
+	}
+}
@@ -0,0 +1,87 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"easypars/pkg/backfill"
+
+	"github.com/gin-gonic/gin"
+)
+
+// requireBackfill responds with an error when backfill is not configured
+func (h *handler) requireBackfill(c *gin.Context) bool {
+	if h.deps.Backfill != nil {
+		return true
+	}
+
+	c.JSON(http.StatusServiceUnavailable, gin.H{
+		"error":   "backfill_unavailable",
+		"message": "Backfill requires persistent storage and parser.month_url",
+	})
+	return false
+}
+
+// handleStartBackfill handles POST requests to /api/admin/backfill/start
+func (h *handler) handleStartBackfill(c *gin.Context) {
+	if !h.requireBackfill(c) {
+		return
+	}
+
+	if err := h.deps.Backfill.Start(); err != nil {
+		if errors.Is(err, backfill.ErrRunning) {
+			c.JSON(http.StatusConflict, gin.H{
+				"error":   "backfill_running",
+				"message": err.Error(),
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "backfill_error",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Backfill started",
+		"status":  h.deps.Backfill.Status(),
+	})
+}
+
+// handleStopBackfill handles POST requests to /api/admin/backfill/stop
+func (h *handler) handleStopBackfill(c *gin.Context) {
+	if !h.requireBackfill(c) {
+		return
+	}
+
+	if err := h.deps.Backfill.Stop(); err != nil {
+		if errors.Is(err, backfill.ErrNotRunning) {
+			c.JSON(http.StatusConflict, gin.H{
+				"error":   "backfill_not_running",
+				"message": err.Error(),
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "backfill_error",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Backfill stopped",
+		"status":  h.deps.Backfill.Status(),
+	})
+}
+
+// handleGetBackfillStatus handles GET requests to /api/admin/backfill/status
+// Returns the scheduler state, the queue of months and recent results
+func (h *handler) handleGetBackfillStatus(c *gin.Context) {
+	if !h.requireBackfill(c) {
+		return
+	}
+
+	c.JSON(http.StatusOK, h.deps.Backfill.Status())
+}
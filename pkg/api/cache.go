@@ -0,0 +1,39 @@
+package api
+
+import (
+	"net/http"
+
+	"easypars/pkg/clock"
+
+	"github.com/gin-gonic/gin"
+)
+
+// handleGetCache handles GET requests to /api/admin/cache
+// Returns the freshness of the published snapshot: the standard TTL and the
+// next expected change of the fights, which comes earlier on fight days
+func (h *handler) handleGetCache(c *gin.Context) {
+	active := h.deps.Snapshots.Active()
+	if active == nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error":   "no_snapshot",
+			"message": "No snapshot has been published yet",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"ttl_seconds":          h.deps.ChangeHints.TTL.Seconds(),
+		"built_at":             active.BuiltAt,
+		"fight_count":          len(active.Fights),
+		"next_expected_change": active.NextExpectedChange,
+		"stale":                active.Stale(h.now()),
+	})
+}
+
+// parserClock returns the clock of the parser, nil for the system clock
+func (h *handler) parserClock() clock.Clock {
+	if h.deps.Parser == nil {
+		return nil
+	}
+	return h.deps.Parser.Clock
+}
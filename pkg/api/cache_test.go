@@ -0,0 +1,44 @@
+package api
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"easypars/pkg/snapshot"
+)
+
+func TestCacheEndpoint(t *testing.T) {
+	token := "Bearer " + signTestToken(t, testJWTSecret, time.Now())
+	hints := snapshot.ChangeHints{TTL: 30 * 24 * time.Hour}
+	router := newTestRouter(t, readTestdata(t, "results.html"), Dependencies{Auth: newTestAuth(t), ChangeHints: hints})
+
+	rec := serve(router, http.MethodGet, "/api/admin/cache", "", "Authorization", token)
+	if rec.Code != http.StatusNotFound || errorCode(t, rec) != "no_snapshot" {
+		t.Fatalf("GET /api/admin/cache before a parse = %d %s, want 404 no_snapshot", rec.Code, rec.Body)
+	}
+
+	if rec := serve(router, http.MethodGet, "/api/fights", ""); rec.Code != http.StatusOK {
+		t.Fatalf("GET /api/fights = %d %s, want 200", rec.Code, rec.Body)
+	}
+	rec = serve(router, http.MethodGet, "/api/admin/cache", "", "Authorization", token)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("GET /api/admin/cache = %d %s, want 200", rec.Code, rec.Body)
+	}
+	var body struct {
+		TTLSeconds         float64                 `json:"ttl_seconds"`
+		FightCount         int                     `json:"fight_count"`
+		NextExpectedChange snapshot.ExpectedChange `json:"next_expected_change"`
+		Stale              bool                    `json:"stale"`
+	}
+	decodeJSON(t, rec, &body)
+
+	// Canelo - Munguia on June 22 is the only scheduled fight
+	want := snapshot.ExpectedChange{At: time.Date(2024, time.June, 22, 19, 0, 0, 0, time.UTC), Reason: snapshot.ChangeUpcomingFight}
+	if !body.NextExpectedChange.At.Equal(want.At) || body.NextExpectedChange.Reason != want.Reason {
+		t.Errorf("next_expected_change = %+v, want %+v", body.NextExpectedChange, want)
+	}
+	if body.TTLSeconds != hints.TTL.Seconds() || body.FightCount != 6 || body.Stale {
+		t.Errorf("cache = %+v, want the TTL, 6 fights and fresh data", body)
+	}
+}
@@ -0,0 +1,95 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"easypars/pkg/clock"
+	"easypars/pkg/parser"
+	"easypars/pkg/storage"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestClientDisconnectStopsTheParse(t *testing.T) {
+	started, aborted := make(chan struct{}, 1), make(chan struct{}, 1)
+	src := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		started <- struct{}{}
+		<-r.Context().Done()
+		aborted <- struct{}{}
+	}))
+	defer src.Close()
+
+	// Stored fights would be served if the parse had failed on its own
+	repo, err := storage.OpenFile(filepath.Join(t.TempDir(), "fights.json"), false, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stored := storedFights()
+	if _, err := repo.UpsertFights(context.Background(), stored); err != nil {
+		t.Fatal(err)
+	}
+	p := parser.NewParser(src.URL + "/")
+	p.Clock = clock.Fixed{Time: testNow}
+	router := SetupRouter(Dependencies{Parser: p, Repository: repo})
+
+	for _, target := range []string{"/api/fights", "/api/fighters", "/api/locations"} {
+		ctx, cancel := context.WithCancel(context.Background())
+		rec := httptest.NewRecorder()
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, target, nil).WithContext(ctx))
+		}()
+
+		<-started
+		cancel()
+		select {
+		case <-done:
+		case <-time.After(5 * time.Second):
+			t.Fatalf("GET %s still runs after the client went away", target)
+		}
+		select {
+		case <-aborted:
+		case <-time.After(5 * time.Second):
+			t.Fatalf("GET %s: the request to the source was not aborted", target)
+		}
+
+		if rec.Code != statusClientClosedRequest || rec.Body.Len() != 0 {
+			t.Errorf("GET %s by a gone client = %d %s, want %d without a body", target, rec.Code, rec.Body, statusClientClosedRequest)
+		}
+	}
+}
+
+func TestAbortIfClientGone(t *testing.T) {
+	gone, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	tests := []struct {
+		name  string
+		ctx   context.Context
+		err   error
+		abort bool
+	}{
+		{"client gone", gone, parser.Classify(parser.ErrorOriginNetwork, context.Canceled), true},
+		{"client still there", context.Background(), parser.Classify(parser.ErrorOriginNetwork, context.Canceled), false},
+		{"client gone, other error", gone, parser.Classify(parser.ErrorOriginSource, errors.New("unexpected status code 503")), false},
+		{"no error", context.Background(), nil, false},
+	}
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(rec)
+		c.Request = httptest.NewRequest(http.MethodGet, "/api/fights", nil).WithContext(tt.ctx)
+		if got := abortIfClientGone(c, tt.err); got != tt.abort {
+			t.Errorf("%s: abortIfClientGone = %v, want %v", tt.name, got, tt.abort)
+		}
+		if tt.abort && !c.IsAborted() {
+			t.Errorf("%s: the request was not aborted", tt.name)
+		}
+	}
+}
@@ -0,0 +1,118 @@
+package api
+
+import (
+	"log/slog"
+	"net/http"
+	"net/url"
+
+	"easypars/models"
+	"easypars/pkg/metrics"
+	"easypars/pkg/ogcard"
+	"easypars/pkg/snapshot"
+
+	"github.com/gin-gonic/gin"
+)
+
+// cardCacheControl lets messengers and CDNs keep a card for an hour; the
+// ETag changes with the card, so revalidation is cheap
+const cardCacheControl = "public, max-age=3600"
+
+// handleGetFightCard handles GET requests to /api/fights/:id/card.png
+// Returns the OpenGraph preview image of the fight. The id is the fight
+// slug or its ID; a slug the fight had before a fighter was renamed
+// answers 308 with the card URL of the current slug. Cards are cached by
+// fight and card version, so a changed result draws a new card.
+func (h *handler) handleGetFightCard(c *gin.Context) {
+	id := c.Param("id")
+	if !validFightID(id) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "invalid_params",
+			"message": `invalid parameter "id": must be a fight slug or a fight ID`,
+		})
+		return
+	}
+	if h.cardRenderer == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{
+			"error":   "cards_unavailable",
+			"message": "Fight cards are not available",
+		})
+		return
+	}
+
+	snap, err := h.refreshSnapshot(c.Request.Context())
+	if abortIfClientGone(c, err) {
+		return
+	}
+	if err != nil {
+		slog.ErrorContext(c.Request.Context(), "Failed to load fights", "error", err)
+		h.respondError(c, err, "parse_error", "Failed to load fight data")
+		return
+	}
+
+	fight, ok := fightByID(snap, id)
+	if !ok {
+		if current, renamed := snap.RenamedSlug(id); renamed {
+			c.Header("Location", "/api/fights/"+url.PathEscape(current)+"/card.png")
+			c.JSON(http.StatusPermanentRedirect, gin.H{
+				"message": "The fight has a new slug",
+				"slug":    current,
+			})
+			return
+		}
+		c.JSON(http.StatusNotFound, gin.H{
+			"error":   "not_found",
+			"message": "Fight not found",
+		})
+		return
+	}
+
+	card := ogcard.CardOf(fight)
+	version := h.cardRenderer.Version(card)
+	etag := `"` + version + `"`
+	c.Header("Cache-Control", cardCacheControl)
+	c.Header("ETag", etag)
+	if c.GetHeader("If-None-Match") == etag {
+		c.Status(http.StatusNotModified)
+		return
+	}
+
+	// Cards are cached by the natural key, so both ids share the entry
+	png, cached := h.cardCache.Get(fight.Key, version)
+	metrics.CacheLookup(metrics.CacheCards, cached)
+	if !cached {
+		png, err = h.cardRenderer.Render(card)
+		if err != nil {
+			slog.ErrorContext(c.Request.Context(), "Failed to render the card", "fight_key", fight.Key, "error", err)
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error":   "render_error",
+				"message": "Failed to render the fight card",
+			})
+			return
+		}
+		h.cardCache.Put(fight.Key, version, png)
+	}
+
+	setServerTiming(c, snap)
+	c.Data(http.StatusOK, "image/png", png)
+}
+
+// validFightID reports whether id looks like a fight slug or a fight ID
+func validFightID(id string) bool {
+	return len(id) <= maxSlugLength && slugPattern.MatchString(id)
+}
+
+// fightByID finds a fight by its slug or its ID (see models.FightID)
+func fightByID(snap *snapshot.Snapshot, id string) (models.Fight, bool) {
+	if fight, ok := snap.FightBySlug(id); ok {
+		return fight, true
+	}
+
+	view := snap.View()
+	for i := 0; i < view.Len(); i++ {
+		if fight := view.At(i); fight.ID == id {
+			return fight, true
+		}
+	}
+
+	return models.Fight{}, false
+}
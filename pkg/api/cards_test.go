@@ -0,0 +1,97 @@
+package api
+
+import (
+	"bytes"
+	"image/png"
+	"net/http"
+	"strings"
+	"testing"
+
+	"easypars/models"
+	"easypars/pkg/ogcard"
+	"easypars/pkg/parser"
+)
+
+func TestGetFightCard(t *testing.T) {
+	fights := storedFights()
+	cards := ogcard.NewCache(10, "")
+	store := &stubFightStore{result: &parser.ParseResult{Fights: fights}}
+	router, _ := newStoreRouter(t, store, Dependencies{CardCache: cards})
+	usyk := fights[0]
+
+	tests := []struct {
+		name   string
+		id     string
+		status int
+		code   string
+	}{
+		{"by ID", usyk.ID, http.StatusOK, ""},
+		{"unknown fight", models.FightID("2000-01-01|a|b|"), http.StatusNotFound, "not_found"},
+		{"unknown slug", "nobody-vs-nobody-2000-01-01", http.StatusNotFound, "not_found"},
+		{"invalid id", strings.Repeat("a", maxSlugLength+1), http.StatusBadRequest, "invalid_params"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := serve(router, http.MethodGet, "/api/fights/"+tt.id+"/card.png", "")
+			if rec.Code != tt.status {
+				t.Fatalf("GET card = %d %s, want %d", rec.Code, rec.Body, tt.status)
+			}
+			if tt.code != "" {
+				if code := errorCode(t, rec); code != tt.code {
+					t.Errorf("error = %q, want %q", code, tt.code)
+				}
+				return
+			}
+
+			if ct := rec.Header().Get("Content-Type"); ct != "image/png" {
+				t.Errorf("Content-Type = %q, want image/png", ct)
+			}
+			if cc := rec.Header().Get("Cache-Control"); cc != cardCacheControl {
+				t.Errorf("Cache-Control = %q, want %q", cc, cardCacheControl)
+			}
+			if _, err := png.Decode(bytes.NewReader(rec.Body.Bytes())); err != nil {
+				t.Errorf("the card is not a PNG: %v", err)
+			}
+		})
+	}
+
+	// The second request is served from the cache with the same bytes and ETag
+	first := serve(router, http.MethodGet, "/api/fights/"+usyk.ID+"/card.png", "")
+	hits := cards.Stats().Hits
+	second := serve(router, http.MethodGet, "/api/fights/"+usyk.ID+"/card.png", "")
+	if cards.Stats().Hits != hits+1 {
+		t.Error("the second request did not hit the card cache")
+	}
+	if !bytes.Equal(first.Body.Bytes(), second.Body.Bytes()) || first.Header().Get("ETag") != second.Header().Get("ETag") {
+		t.Error("the same fight returned different cards")
+	}
+
+	// A client holding the current card gets 304 without a body
+	etag := first.Header().Get("ETag")
+	rec := serve(router, http.MethodGet, "/api/fights/"+usyk.ID+"/card.png", "", "If-None-Match", etag)
+	if rec.Code != http.StatusNotModified || rec.Body.Len() != 0 {
+		t.Errorf("GET with If-None-Match %s = %d with %d bytes, want 304 without a body", etag, rec.Code, rec.Body.Len())
+	}
+	rec = serve(router, http.MethodGet, "/api/fights/"+usyk.ID+"/card.png", "", "If-None-Match", `"outdated"`)
+	if rec.Code != http.StatusOK {
+		t.Errorf("GET with an outdated ETag = %d, want 200", rec.Code)
+	}
+}
+
+func TestFightCardChangesWithTheResult(t *testing.T) {
+	fights := storedFights()
+	fights[0].Result, fights[0].Status = "vs", models.StatusScheduled
+	store := &stubFightStore{result: &parser.ParseResult{Fights: fights}}
+	scheduled, _ := newStoreRouter(t, store, Dependencies{})
+	before := serve(scheduled, http.MethodGet, "/api/fights/"+fights[0].ID+"/card.png", "")
+
+	completed, _ := newStoreRouter(t, &stubFightStore{result: &parser.ParseResult{Fights: storedFights()}}, Dependencies{})
+	after := serve(completed, http.MethodGet, "/api/fights/"+fights[0].ID+"/card.png", "")
+
+	if before.Code != http.StatusOK || after.Code != http.StatusOK {
+		t.Fatalf("GET card = %d and %d, want 200", before.Code, after.Code)
+	}
+	if before.Header().Get("ETag") == after.Header().Get("ETag") {
+		t.Error("the ETag did not change with the result")
+	}
+}
@@ -0,0 +1,107 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/url"
+
+	"easypars/models"
+	"easypars/pkg/apitypes"
+	"easypars/pkg/parser"
+	"easypars/pkg/storage"
+
+	"github.com/gin-gonic/gin"
+)
+
+// handleGetFightHistory handles GET requests to /api/fights/:id/history
+// The id is resolved like on /api/fights/:id; the ID or the natural key of
+// a stored fight that is no longer served (e.g. a fight deleted from the
+// source) is looked up in storage. The changes are recorded by storage when a fight is
+// stored again with different values, so the history needs persistent
+// storage.
+func (h *handler) handleGetFightHistory(c *gin.Context) {
+	id := c.Param("id")
+	if id == "" || len(id) > maxSlugLength {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "invalid_params",
+			"message": `invalid parameter "id": must be a fight slug, a fight ID or a fight key`,
+		})
+		return
+	}
+	if h.deps.Repository == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{
+			"error":   "history_unavailable",
+			"message": "Fight history requires persistent storage",
+		})
+		return
+	}
+
+	ctx := c.Request.Context()
+	snap, err := h.refreshSnapshot(ctx)
+	if abortIfClientGone(c, err) {
+		return
+	}
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to load fights", "error", err)
+		h.respondError(c, err, "parse_error", "Failed to load fight data")
+		return
+	}
+
+	// Step 1: Resolve the id to the natural key of the fight
+	var key string
+	if fight, ok := fightByID(snap, id); ok {
+		key = fight.Key
+	} else if fight, ok := fightByKey(snap, id); ok {
+		key = fight.Key
+	} else if current, renamed := snap.RenamedSlug(id); renamed {
+		c.Header("Location", "/api/fights/"+url.PathEscape(current)+"/history")
+		c.JSON(http.StatusPermanentRedirect, gin.H{
+			"message": "The fight has a new slug",
+			"slug":    current,
+		})
+		return
+	} else if stored, err := h.lookupStored(ctx, id); err == nil {
+		key = stored.Key
+	} else if !errors.Is(err, storage.ErrNotFound) {
+		slog.ErrorContext(ctx, "Failed to load stored fight", "fight_key", id, "error", err)
+		h.respondError(c, parser.Classify(parser.ErrorOriginInternal, err), "history_error", "Failed to load the fight history")
+		return
+	}
+	if key == "" {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error":   "not_found",
+			"message": "No fight with id " + id,
+		})
+		return
+	}
+
+	// Step 2: Load the changes
+	changes, err := h.deps.Repository.Changes(ctx, key)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to load fight history", "fight_key", key, "error", err)
+		h.respondError(c, parser.Classify(parser.ErrorOriginInternal, err), "history_error", "Failed to load the fight history")
+		return
+	}
+	if changes == nil {
+		changes = []models.FightChange{}
+	}
+
+	c.JSON(http.StatusOK, apitypes.FightHistoryResponse{
+		Message: "Fight history retrieved successfully",
+		Key:     key,
+		Data:    changes,
+		Count:   len(changes),
+	})
+}
+
+// lookupStored finds a stored fight by its ID, then by its natural key
+func (h *handler) lookupStored(ctx context.Context, id string) (*models.Fight, error) {
+	fight, err := h.deps.Repository.GetByID(ctx, id)
+	if errors.Is(err, storage.ErrNotFound) {
+		return h.deps.Repository.GetByKey(ctx, id)
+	}
+
+	return fight, err
+}
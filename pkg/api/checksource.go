@@ -0,0 +1,73 @@
+package api
+
+import (
+	"log/slog"
+	"net/http"
+
+	"easypars/pkg/history"
+	"easypars/pkg/parser"
+
+	"github.com/gin-gonic/gin"
+)
+
+// handleCheckSource handles POST requests to /api/admin/check-source
+// Fetches the live source once and reports whether the parser still
+// understands it (see parser.CheckSource). The run is recorded in the
+// parse history with the "check" trigger. ?format=text returns the report
+// as plain text, as printed by the check-source command.
+func (h *handler) handleCheckSource(c *gin.Context) {
+	if h.deps.Parser == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{
+			"error":   "parser_unavailable",
+			"message": "The parser is not configured",
+		})
+		return
+	}
+	format := c.DefaultQuery("format", "json")
+	if err := validateOneOf("json", "text")(format); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "invalid_params",
+			"message": `invalid parameter "format": ` + err.Error(),
+		})
+		return
+	}
+
+	thresholds := h.deps.CompatThresholds
+	if thresholds == (parser.CompatThresholds{}) {
+		thresholds = parser.DefaultCompatThresholds()
+	}
+
+	ctx := c.Request.Context()
+	var run history.ParseRun
+	if h.deps.History != nil {
+		run = h.deps.History.Start("check")
+		ctx = history.WithRunID(ctx, run.ID)
+	}
+	report, err := h.deps.Parser.CheckSource(ctx, thresholds)
+	if h.deps.History != nil {
+		result := history.RunResult{Err: err}
+		if report != nil {
+			result.FightCount = report.Fights
+			result.IssueCodes = report.IssueCodes
+			for _, count := range report.IssueCodes {
+				result.IssueCount += count
+			}
+		}
+		h.deps.History.Finish(run.ID, result)
+	}
+	if err != nil {
+		slog.ErrorContext(c.Request.Context(), "Source check failed", "error", err)
+		h.respondError(c, err, "check_error", "Failed to check the source")
+		return
+	}
+
+	slog.InfoContext(c.Request.Context(), "Source check finished", "url", report.URL, "verdict", report.Verdict, "fight_count", report.Fights, "duration_ms", report.DurationMs)
+	if format == "text" {
+		c.String(http.StatusOK, report.Text())
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Source check finished",
+		"data":    report,
+	})
+}
@@ -0,0 +1,71 @@
+package api
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"easypars/pkg/history"
+	"easypars/pkg/parser"
+)
+
+func TestCheckSourceEndpoint(t *testing.T) {
+	token := "Bearer " + signTestToken(t, testJWTSecret, time.Now())
+	hist := history.New(10, 100)
+	router := newTestRouter(t, readTestdata(t, "results.html"), Dependencies{Auth: newTestAuth(t), History: hist})
+	src, _ := failingSource(t)
+	failing := SetupRouter(Dependencies{Parser: parser.NewParser(src.URL + "/"), Auth: newTestAuth(t)})
+
+	tests := []struct {
+		name   string
+		failed bool
+		query  string
+		status int
+		code   string
+	}{
+		{"json report", false, "", http.StatusOK, ""},
+		{"text report", false, "?format=text", http.StatusOK, ""},
+		{"unknown format", false, "?format=xml", http.StatusBadRequest, "invalid_params"},
+		{"failing source", true, "", http.StatusBadGateway, "upstream_status"},
+	}
+	for _, tt := range tests {
+		target := router
+		if tt.failed {
+			target = failing
+		}
+		rec := serve(target, http.MethodPost, "/api/admin/check-source"+tt.query, "", "Authorization", token)
+		if rec.Code != tt.status {
+			t.Fatalf("%s: POST /api/admin/check-source%s = %d %s, want %d", tt.name, tt.query, rec.Code, rec.Body, tt.status)
+		}
+		switch {
+		case tt.code != "":
+			if code := errorCode(t, rec); code != tt.code {
+				t.Errorf("%s: error = %q, want %q", tt.name, code, tt.code)
+			}
+		case tt.query == "?format=text":
+			if body := rec.Body.String(); !strings.Contains(body, "Verdict:       compatible") {
+				t.Errorf("%s: report = %q, want the compatible verdict", tt.name, body)
+			}
+		default:
+			var body struct {
+				Data parser.CompatReport `json:"data"`
+			}
+			decodeJSON(t, rec, &body)
+			if body.Data.Verdict != parser.VerdictCompatible || body.Data.Fights != 6 || body.Data.FightRows != 6 {
+				t.Errorf("%s: report = %+v, want 6 compatible fights", tt.name, body.Data)
+			}
+		}
+	}
+
+	// Both successful checks are in the parse history
+	checks := 0
+	for _, run := range hist.List() {
+		if run.Trigger == "check" && run.Status == history.StatusSucceeded && run.FightCount == 6 {
+			checks++
+		}
+	}
+	if checks != 2 {
+		t.Errorf("parse history has %d successful check runs, want 2", checks)
+	}
+}
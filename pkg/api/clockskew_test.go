@@ -0,0 +1,50 @@
+package api
+
+import (
+	"net/http"
+	"slices"
+	"testing"
+	"time"
+
+	"easypars/pkg/apitypes"
+	"easypars/pkg/clock"
+)
+
+func TestHealthReportsClockSkew(t *testing.T) {
+	p := newTestParser(t, readTestdata(t, "results.html"))
+	p.ClockSkew = clock.NewSkewMonitor(10*time.Minute, 24*time.Hour)
+	router := SetupRouter(Dependencies{Parser: p})
+
+	tests := []struct {
+		name string
+		// local is the server clock against the source time testNow, zero
+		// for no check yet
+		local    time.Time
+		level    string
+		degraded bool
+	}{
+		{"not checked", time.Time{}, "", false},
+		{"in sync", testNow, clock.SkewOK, false},
+		{"medium skew", testNow.Add(time.Hour), clock.SkewWarning, false},
+		{"critical skew", testNow.AddDate(-1, 0, 0), clock.SkewCritical, true},
+		{"recovered", testNow.Add(time.Second), clock.SkewOK, false},
+	}
+	for _, tt := range tests {
+		if !tt.local.IsZero() {
+			p.ClockSkew.Observe("vringe.example", tt.local, testNow)
+		}
+
+		var body apitypes.HealthResponse
+		decodeJSON(t, serve(router, http.MethodGet, "/api/health", ""), &body)
+		if degraded := slices.Contains(body.Reasons, "clock_skew"); degraded != tt.degraded || degraded && body.Status != "degraded" {
+			t.Errorf("%s: health = %s %q, want clock_skew %v", tt.name, body.Status, body.Reasons, tt.degraded)
+		}
+		level := ""
+		if body.ClockSkew != nil {
+			level = body.ClockSkew.Level
+		}
+		if level != tt.level {
+			t.Errorf("%s: clock_skew level = %q, want %q", tt.name, level, tt.level)
+		}
+	}
+}
@@ -0,0 +1,194 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"easypars/pkg/apitypes"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Concurrency limit defaults
+const (
+	// DefaultQueueTimeout is how long a request over the limit waits for a slot
+	DefaultQueueTimeout = time.Second
+	// DefaultMaxConcurrentProbes is the separate limit of the probe endpoints
+	DefaultMaxConcurrentProbes = 512
+)
+
+// probePaths are served by their own limiter, so health checks and metric
+// scrapes still answer when the API limit is exhausted
+// /healthz and /readyz are normally answered by startup.Readiness before the
+// router; they are listed for routers served without it.
+var probePaths = map[string]bool{
+	"/healthz":          true,
+	"/readyz":           true,
+	metricsPath:         true,
+	"/api/health":       true,
+	"/api/health/live":  true,
+	"/api/health/ready": true,
+}
+
+// ErrQueueTimeout is returned when no slot was freed within the queue timeout
+var ErrQueueTimeout = errors.New("concurrency limit reached")
+
+// ConcurrencyLimiter bounds the number of requests handled at once
+// Requests over the limit wait in a queue for a free slot; when none is freed
+// within the queue timeout they are rejected. A request whose client goes
+// away while queued leaves the queue without taking a slot.
+type ConcurrencyLimiter struct {
+	name         string
+	slots        chan struct{}
+	queueTimeout time.Duration
+
+	queued    atomic.Int64
+	rejected  atomic.Int64
+	cancelled atomic.Int64
+}
+
+// NewConcurrencyLimiter creates a limiter handling max requests at once
+// A non-positive queueTimeout means DefaultQueueTimeout
+func NewConcurrencyLimiter(name string, max int, queueTimeout time.Duration) *ConcurrencyLimiter {
+	if queueTimeout <= 0 {
+		queueTimeout = DefaultQueueTimeout
+	}
+
+	return &ConcurrencyLimiter{
+		name:         name,
+		slots:        make(chan struct{}, max),
+		queueTimeout: queueTimeout,
+	}
+}
+
+// Acquire takes a slot, waiting in the queue when all slots are busy
+// The returned function frees the slot and must be called exactly once.
+// ErrQueueTimeout is returned when the wait times out and the context error
+// when the context ends first.
+func (l *ConcurrencyLimiter) Acquire(ctx context.Context) (func(), error) {
+	release := func() { <-l.slots }
+
+	// Fast path: a free slot, no queueing
+	select {
+	case l.slots <- struct{}{}:
+		return release, nil
+	default:
+	}
+
+	l.queued.Add(1)
+	defer l.queued.Add(-1)
+
+	timer := time.NewTimer(l.queueTimeout)
+	defer timer.Stop()
+
+	select {
+	case l.slots <- struct{}{}:
+		return release, nil
+	case <-timer.C:
+		l.rejected.Add(1)
+		return nil, ErrQueueTimeout
+	case <-ctx.Done():
+		l.cancelled.Add(1)
+		return nil, ctx.Err()
+	}
+}
+
+// Middleware limits the requests passing through it
+// Rejected requests get 503 with Retry-After; requests cancelled by the
+// client while queued are aborted without a response.
+func (l *ConcurrencyLimiter) Middleware() gin.HandlerFunc {
+	retryAfter := strconv.Itoa(max(1, int(math.Ceil(l.queueTimeout.Seconds()))))
+
+	return func(c *gin.Context) {
+		release, err := l.Acquire(c.Request.Context())
+		if err != nil {
+			if errors.Is(err, ErrQueueTimeout) {
+				c.Header("Retry-After", retryAfter)
+				c.AbortWithStatusJSON(http.StatusServiceUnavailable, gin.H{
+					"error":   "overloaded",
+					"message": "Too many concurrent requests, try again later",
+				})
+				return
+			}
+			c.Abort()
+			return
+		}
+		// The slot is freed even when the handler panics
+		defer release()
+
+		c.Next()
+	}
+}
+
+// Stats returns the current load of the limiter
+func (l *ConcurrencyLimiter) Stats() apitypes.ConcurrencyStats {
+	return apitypes.ConcurrencyStats{
+		Name:      l.name,
+		Limit:     cap(l.slots),
+		InFlight:  len(l.slots),
+		Queued:    l.queued.Load(),
+		Rejected:  l.rejected.Load(),
+		Cancelled: l.cancelled.Load(),
+	}
+}
+
+// ConcurrencyLimit returns a middleware handling at most max requests at once
+// Requests over the limit wait up to queueTimeout and then get 503.
+func ConcurrencyLimit(max int, queueTimeout time.Duration) gin.HandlerFunc {
+	return NewConcurrencyLimiter("requests", max, queueTimeout).Middleware()
+}
+
+// concurrencyLimits holds the request and probe limiters of the router
+type concurrencyLimits struct {
+	requests *ConcurrencyLimiter
+	probes   *ConcurrencyLimiter
+}
+
+// newConcurrencyLimits creates the limiters from the dependencies
+// It returns nil when the limit is disabled
+func newConcurrencyLimits(deps Dependencies) *concurrencyLimits {
+	if deps.MaxConcurrentRequests <= 0 {
+		return nil
+	}
+	probes := deps.MaxConcurrentProbes
+	if probes <= 0 {
+		probes = DefaultMaxConcurrentProbes
+	}
+
+	return &concurrencyLimits{
+		requests: NewConcurrencyLimiter("requests", deps.MaxConcurrentRequests, deps.QueueTimeout),
+		probes:   NewConcurrencyLimiter("probes", probes, deps.QueueTimeout),
+	}
+}
+
+// middleware sends probes to the probe limiter and the rest to the request limiter
+// The streams are left out, the stream hub bounds them (see streamPaths).
+func (l *concurrencyLimits) middleware() gin.HandlerFunc {
+	requests, probes := l.requests.Middleware(), l.probes.Middleware()
+
+	return func(c *gin.Context) {
+		if probePaths[c.Request.URL.Path] {
+			probes(c)
+			return
+		}
+		if streamPaths[c.Request.URL.Path] {
+			c.Next()
+			return
+		}
+		requests(c)
+	}
+}
+
+// stats returns the load of both limiters, nil when the limit is disabled
+func (l *concurrencyLimits) stats() []apitypes.ConcurrencyStats {
+	if l == nil {
+		return nil
+	}
+
+	return []apitypes.ConcurrencyStats{l.requests.Stats(), l.probes.Stats()}
+}
@@ -0,0 +1,156 @@
+package api
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// newLimitedRouter returns a router serving GET /work through the limiter
+// The handler of /work waits for the release channel to close; /panic
+// panics while holding its slot.
+func newLimitedRouter(limiter *ConcurrencyLimiter, release <-chan struct{}, handled *atomic.Int32) *gin.Engine {
+	router := gin.New()
+	router.Use(gin.RecoveryWithWriter(io.Discard), limiter.Middleware())
+	router.GET("/work", func(c *gin.Context) {
+		handled.Add(1)
+		<-release
+		c.JSON(http.StatusOK, gin.H{"message": "done"})
+	})
+	router.GET("/panic", func(c *gin.Context) {
+		handled.Add(1)
+		panic("handler failure")
+	})
+
+	return router
+}
+
+// waitFor polls the condition until it holds, failing the test after a second
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+
+	deadline := time.Now().Add(time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestConcurrencyLimitRejectsWhenSaturated(t *testing.T) {
+	limiter := NewConcurrencyLimiter("requests", 2, 50*time.Millisecond)
+	release := make(chan struct{})
+	var handled atomic.Int32
+	router := newLimitedRouter(limiter, release, &handled)
+
+	var wg sync.WaitGroup
+	codes := make([]int, 2)
+	for i := range codes {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			codes[i] = serve(router, http.MethodGet, "/work", "").Code
+		}()
+	}
+	waitFor(t, "both slots to be taken", func() bool { return limiter.Stats().InFlight == 2 })
+
+	rec := serve(router, http.MethodGet, "/work", "")
+	if rec.Code != http.StatusServiceUnavailable || errorCode(t, rec) != "overloaded" {
+		t.Errorf("request over the limit = %d %s, want 503 overloaded", rec.Code, rec.Body)
+	}
+	if got := rec.Header().Get("Retry-After"); got != "1" {
+		t.Errorf("Retry-After = %q, want 1", got)
+	}
+
+	close(release)
+	wg.Wait()
+	for i, code := range codes {
+		if code != http.StatusOK {
+			t.Errorf("request %d holding a slot = %d, want 200", i, code)
+		}
+	}
+	if stats := limiter.Stats(); stats.InFlight != 0 || stats.Rejected != 1 || stats.Queued != 0 {
+		t.Errorf("stats = %+v, want no request in flight and 1 rejected", stats)
+	}
+	if got := handled.Load(); got != 2 {
+		t.Errorf("handler ran %d times, want 2", got)
+	}
+}
+
+func TestConcurrencyLimitReleasesTheSlotOnPanic(t *testing.T) {
+	limiter := NewConcurrencyLimiter("requests", 1, 50*time.Millisecond)
+	release := make(chan struct{})
+	close(release)
+	var handled atomic.Int32
+	router := newLimitedRouter(limiter, release, &handled)
+
+	for i := 0; i < 3; i++ {
+		if rec := serve(router, http.MethodGet, "/panic", ""); rec.Code != http.StatusInternalServerError {
+			t.Fatalf("panicking request %d = %d, want 500", i, rec.Code)
+		}
+		if stats := limiter.Stats(); stats.InFlight != 0 {
+			t.Fatalf("after panic %d, %d requests in flight, want 0", i, stats.InFlight)
+		}
+	}
+
+	// The only slot is free again: the next request is served, not rejected
+	if rec := serve(router, http.MethodGet, "/work", ""); rec.Code != http.StatusOK {
+		t.Errorf("request after the panics = %d, want 200", rec.Code)
+	}
+	if stats := limiter.Stats(); stats.Rejected != 0 {
+		t.Errorf("%d requests rejected, want none", stats.Rejected)
+	}
+}
+
+func TestConcurrencyLimitClientCancellation(t *testing.T) {
+	limiter := NewConcurrencyLimiter("requests", 1, time.Minute)
+	release := make(chan struct{})
+	var handled atomic.Int32
+	router := newLimitedRouter(limiter, release, &handled)
+
+	holder := make(chan int)
+	go func() { holder <- serve(router, http.MethodGet, "/work", "").Code }()
+	waitFor(t, "the slot to be taken", func() bool { return limiter.Stats().InFlight == 1 })
+
+	ctx, cancel := context.WithCancel(context.Background())
+	req := httptest.NewRequest(http.MethodGet, "/work", nil).WithContext(ctx)
+	rec := httptest.NewRecorder()
+	done := make(chan struct{})
+	go func() {
+		router.ServeHTTP(rec, req)
+		close(done)
+	}()
+	waitFor(t, "the request to queue", func() bool { return limiter.Stats().Queued == 1 })
+
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("the cancelled request is still queued")
+	}
+	if rec.Body.Len() != 0 || rec.Header().Get("Retry-After") != "" {
+		t.Errorf("cancelled request got a response: %d %s", rec.Code, rec.Body)
+	}
+	if stats := limiter.Stats(); stats.Cancelled != 1 || stats.Rejected != 0 || stats.Queued != 0 || stats.InFlight != 1 {
+		t.Errorf("stats = %+v, want 1 cancelled and the holder still in flight", stats)
+	}
+
+	close(release)
+	if code := <-holder; code != http.StatusOK {
+		t.Errorf("request holding the slot = %d, want 200", code)
+	}
+	if got := handled.Load(); got != 1 {
+		t.Errorf("handler ran %d times, want only for the holder", got)
+	}
+	if stats := limiter.Stats(); stats.InFlight != 0 {
+		t.Errorf("%d requests in flight after the holder finished, want 0", stats.InFlight)
+	}
+}
@@ -0,0 +1,83 @@
+package api
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// payloadETag returns a strong ETag of a response payload
+// The payload is hashed in its JSON form together with the parameters that
+// select its representation (?format= and the Accept and Accept-Language
+// headers), so every representation has its own tag. Fields that change
+// without a change of the data, such as the cache age, must be left out of
+// the payload by the caller.
+func payloadETag(c *gin.Context, payload any) (string, error) {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return "", err
+	}
+
+	hash := sha256.New()
+	for _, selector := range []string{c.Query("format"), c.GetHeader("Accept"), c.GetHeader("Accept-Language")} {
+		hash.Write([]byte(selector))
+		hash.Write([]byte{0})
+	}
+	hash.Write(body)
+
+	return `"` + hex.EncodeToString(hash.Sum(nil)[:16]) + `"`, nil
+}
+
+// notModified sets the ETag and Last-Modified headers of a response and
+// answers 304 without a body when the client copy is still current
+// If-None-Match wins over If-Modified-Since, as in RFC 9110; a zero
+// lastModified sends no Last-Modified. Handlers return at once when it
+// reports true.
+func notModified(c *gin.Context, etag string, lastModified time.Time) bool {
+	if c.Request.Method != http.MethodGet && c.Request.Method != http.MethodHead {
+		return false
+	}
+
+	if etag != "" {
+		c.Header("ETag", etag)
+	}
+	lastModified = lastModified.UTC().Truncate(time.Second)
+	if !lastModified.IsZero() {
+		c.Header("Last-Modified", lastModified.Format(http.TimeFormat))
+	}
+	c.Header("Vary", "Accept, Accept-Language")
+
+	current := false
+	if match := c.GetHeader("If-None-Match"); match != "" {
+		current = etag != "" && etagListMatches(match, etag)
+	} else if since, err := http.ParseTime(c.GetHeader("If-Modified-Since")); err == nil && !lastModified.IsZero() {
+		current = !lastModified.After(since)
+	}
+	if !current {
+		return false
+	}
+
+	c.Status(http.StatusNotModified)
+	c.Writer.WriteHeaderNow()
+	return true
+}
+
+// etagListMatches reports whether an If-None-Match list holds the ETag
+// The comparison is weak: a W/ prefix is ignored, as RFC 9110 requires for
+// If-None-Match.
+func etagListMatches(list, etag string) bool {
+	etag = strings.TrimPrefix(etag, "W/")
+	for _, candidate := range strings.Split(list, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == etag {
+			return true
+		}
+	}
+
+	return false
+}
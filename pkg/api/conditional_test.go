@@ -0,0 +1,135 @@
+package api
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"easypars/pkg/clock"
+	"easypars/pkg/parser"
+
+	"github.com/gin-gonic/gin"
+)
+
+// newChangingRouter returns the router of a source whose page can be
+// replaced with the returned function
+func newChangingRouter(t *testing.T, page string) (*gin.Engine, func(string)) {
+	t.Helper()
+
+	var current atomic.Value
+	current.Store(page)
+	src := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		io.WriteString(w, current.Load().(string))
+	}))
+	t.Cleanup(src.Close)
+
+	p := parser.NewParser(src.URL + "/")
+	p.Clock = clock.Fixed{Time: testNow}
+
+	return SetupRouter(Dependencies{Parser: p}), func(page string) { current.Store(page) }
+}
+
+func TestFightsConditionalGet(t *testing.T) {
+	page := readTestdata(t, "upcoming.html")
+	router, setPage := newChangingRouter(t, page)
+
+	first := serve(router, http.MethodGet, "/api/fights", "")
+	etag := first.Header().Get("ETag")
+	if first.Code != http.StatusOK || etag == "" || first.Header().Get("Last-Modified") == "" {
+		t.Fatalf("first request = %d with ETag %q and Last-Modified %q, want 200 with both", first.Code, etag, first.Header().Get("Last-Modified"))
+	}
+
+	tests := []struct {
+		name    string
+		headers []string
+		status  int
+	}{
+		{"matching ETag", []string{"If-None-Match", etag}, http.StatusNotModified},
+		{"weak form of the ETag", []string{"If-None-Match", "W/" + etag}, http.StatusNotModified},
+		{"ETag in a list", []string{"If-None-Match", `"stale", ` + etag}, http.StatusNotModified},
+		{"any ETag", []string{"If-None-Match", "*"}, http.StatusNotModified},
+		{"other ETag", []string{"If-None-Match", `"stale"`}, http.StatusOK},
+		{"other representation", []string{"If-None-Match", etag, "Accept", "application/xml"}, http.StatusOK},
+	}
+	for _, tt := range tests {
+		rec := serve(router, http.MethodGet, "/api/fights", "", tt.headers...)
+		if rec.Code != tt.status {
+			t.Errorf("%s: status = %d, want %d", tt.name, rec.Code, tt.status)
+			continue
+		}
+		if tt.status == http.StatusNotModified {
+			if rec.Body.Len() != 0 {
+				t.Errorf("%s: 304 with a body of %d bytes", tt.name, rec.Body.Len())
+			}
+			if got := rec.Header().Get("ETag"); got != etag {
+				t.Errorf("%s: 304 ETag = %q, want %q", tt.name, got, etag)
+			}
+		}
+	}
+
+	// The same fights parsed again keep the ETag
+	if again := serve(router, http.MethodGet, "/api/fights", ""); again.Header().Get("ETag") != etag {
+		t.Errorf("ETag of an unchanged source = %q, want %q", again.Header().Get("ETag"), etag)
+	}
+
+	// A change of the source invalidates the ETag
+	setPage(readTestdata(t, "archive.html"))
+	changed := serve(router, http.MethodGet, "/api/fights", "", "If-None-Match", etag)
+	if changed.Code != http.StatusOK {
+		t.Fatalf("request after a source change = %d, want 200", changed.Code)
+	}
+	if got := changed.Header().Get("ETag"); got == "" || got == etag {
+		t.Errorf("ETag after a source change = %q, want a new one", got)
+	}
+	if again := serve(router, http.MethodGet, "/api/fights", "", "If-None-Match", changed.Header().Get("ETag")); again.Code != http.StatusNotModified {
+		t.Errorf("request with the new ETag = %d, want 304", again.Code)
+	}
+}
+
+func TestNotModified(t *testing.T) {
+	modified := time.Date(2024, time.June, 10, 12, 0, 0, 0, time.UTC)
+	const etag = `"abc"`
+
+	tests := []struct {
+		name         string
+		method       string
+		headers      []string
+		lastModified time.Time
+		want         bool
+	}{
+		{"no condition", http.MethodGet, nil, modified, false},
+		{"matching ETag", http.MethodGet, []string{"If-None-Match", etag}, modified, true},
+		{"matching ETag on HEAD", http.MethodHead, []string{"If-None-Match", etag}, modified, true},
+		{"POST is never 304", http.MethodPost, []string{"If-None-Match", etag}, modified, false},
+		{"unchanged since", http.MethodGet, []string{"If-Modified-Since", modified.Format(http.TimeFormat)}, modified, true},
+		{"unchanged since later", http.MethodGet, []string{"If-Modified-Since", modified.Add(time.Hour).Format(http.TimeFormat)}, modified, true},
+		{"changed since", http.MethodGet, []string{"If-Modified-Since", modified.Add(-time.Second).Format(http.TimeFormat)}, modified, false},
+		{"sub-second change is not newer", http.MethodGet, []string{"If-Modified-Since", modified.Format(http.TimeFormat)}, modified.Add(500 * time.Millisecond), true},
+		{"If-None-Match wins", http.MethodGet, []string{"If-None-Match", `"other"`, "If-Modified-Since", modified.Format(http.TimeFormat)}, modified, false},
+		{"no Last-Modified", http.MethodGet, []string{"If-Modified-Since", modified.Format(http.TimeFormat)}, time.Time{}, false},
+		{"malformed date", http.MethodGet, []string{"If-Modified-Since", "yesterday"}, modified, false},
+	}
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(rec)
+		c.Request = httptest.NewRequest(tt.method, "/api/fights", nil)
+		for i := 0; i+1 < len(tt.headers); i += 2 {
+			c.Request.Header.Set(tt.headers[i], tt.headers[i+1])
+		}
+
+		got := notModified(c, etag, tt.lastModified)
+		if got != tt.want {
+			t.Errorf("%s: notModified = %v, want %v", tt.name, got, tt.want)
+		}
+		if got && rec.Code != http.StatusNotModified {
+			t.Errorf("%s: status = %d, want 304", tt.name, rec.Code)
+		}
+		if tt.method != http.MethodPost && rec.Header().Get("ETag") != etag {
+			t.Errorf("%s: ETag = %q, want %q", tt.name, rec.Header().Get("ETag"), etag)
+		}
+	}
+}
@@ -0,0 +1,30 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"easypars/pkg/contract"
+
+	"github.com/gin-gonic/gin"
+)
+
+// respondContractViolation answers 500 with the violation details when err
+// is a contract violation of the enforcing checker
+// Returns false for any other error, which the caller handles itself
+func respondContractViolation(c *gin.Context, err error) bool {
+	var violationErr *contract.ViolationError
+	if !errors.As(err, &violationErr) {
+		return false
+	}
+
+	c.JSON(http.StatusInternalServerError, gin.H{
+		"error":      "contract_violation",
+		"message":    violationErr.Error(),
+		"boundary":   violationErr.Boundary,
+		"total":      violationErr.Total,
+		"violations": violationErr.Violations,
+		"request_id": c.GetString(requestIDKey),
+	})
+	return true
+}
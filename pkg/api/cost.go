@@ -0,0 +1,210 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"easypars/pkg/apitypes"
+	"easypars/pkg/history"
+	"easypars/pkg/parser"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Cost classes of a request
+const (
+	CostCheap     = "cheap"
+	CostModerate  = "moderate"
+	CostExpensive = "expensive"
+)
+
+// confirmExpensiveHeader is the header confirming an expensive request
+const confirmExpensiveHeader = "X-Confirm-Expensive"
+
+// defaultPageSeconds is the expected fetch time of one source page when no
+// parse run has finished yet
+const defaultPageSeconds = 1.0
+
+// CostThresholds are the bounds of the cost classes
+// A request reaching either bound of a class belongs to it.
+type CostThresholds struct {
+	ModerateRequests  int
+	ModerateSeconds   float64
+	ExpensiveRequests int
+	ExpensiveSeconds  float64
+}
+
+// DefaultCostThresholds returns the thresholds used when none are configured
+func DefaultCostThresholds() CostThresholds {
+	return CostThresholds{
+		ModerateRequests:  3,
+		ModerateSeconds:   5,
+		ExpensiveRequests: 10,
+		ExpensiveSeconds:  30,
+	}
+}
+
+// Validate checks that the expensive class starts above the moderate one
+func (t CostThresholds) Validate() error {
+	if t.ModerateRequests <= 0 || t.ModerateSeconds <= 0 {
+		return fmt.Errorf("moderate cost thresholds must be positive, got %d requests and %g seconds", t.ModerateRequests, t.ModerateSeconds)
+	}
+	if t.ExpensiveRequests <= t.ModerateRequests || t.ExpensiveSeconds <= t.ModerateSeconds {
+		return fmt.Errorf("expensive cost thresholds (%d requests, %g seconds) must be above the moderate ones (%d requests, %g seconds)",
+			t.ExpensiveRequests, t.ExpensiveSeconds, t.ModerateRequests, t.ModerateSeconds)
+	}
+
+	return nil
+}
+
+// costState is what the cost of a request depends on besides its parameters
+type costState struct {
+	// Sources is the number of result pages a refresh fetches, the main
+	// page included; 0 without a parser
+	Sources int
+	// Workers is the number of extra sources fetched at the same time
+	Workers int
+	// RefreshSeconds is the duration of the last successful refresh, zero
+	// when none finished yet
+	RefreshSeconds float64
+	// FreshFallback is set while a background refresh result is fresh
+	FreshFallback bool
+	// FreshCache is set while the fight cache holds a fresh parse result or
+	// the scheduled refresh keeps the published snapshot current
+	FreshCache bool
+	// SourcePaused is set while requests to the source are paused
+	SourcePaused bool
+}
+
+// estimateCost estimates the cost of a data request before it runs
+// Every data request refreshes the fights from all sources, unless the
+// fight cache is fresh and ?refresh=true is not given, ?fallback=accepted
+// can be answered from a fresh background refresh or the source is paused
+// and the stored fights are served.
+func estimateCost(params url.Values, state costState, thresholds CostThresholds) apitypes.CostEstimate {
+	var estimate apitypes.CostEstimate
+
+	switch {
+	case state.Sources == 0, state.SourcePaused:
+	case params.Get("fallback") == "accepted" && state.FreshFallback:
+	case state.FreshCache && !flagSet(params.Get("refresh")):
+	default:
+		estimate.OutboundRequests = state.Sources
+		estimate.EstimatedSeconds = state.RefreshSeconds
+		if estimate.EstimatedSeconds <= 0 {
+			// The main page comes first, the extra sources in batches
+			workers := max(1, state.Workers)
+			batches := math.Ceil(float64(state.Sources-1) / float64(workers))
+			estimate.EstimatedSeconds = defaultPageSeconds * (1 + batches)
+		}
+	}
+
+	// Later result pages (?pages) are fetched even for a fresh fallback
+	if extra := resultPages(params) - 1; extra > 0 && state.Sources > 0 && !state.SourcePaused {
+		workers := max(1, state.Workers)
+		estimate.OutboundRequests += extra
+		estimate.EstimatedSeconds += defaultPageSeconds * math.Ceil(float64(extra)/float64(workers))
+	}
+
+	switch {
+	case estimate.OutboundRequests >= thresholds.ExpensiveRequests || estimate.EstimatedSeconds >= thresholds.ExpensiveSeconds:
+		estimate.Class = CostExpensive
+	case estimate.OutboundRequests >= thresholds.ModerateRequests || estimate.EstimatedSeconds >= thresholds.ModerateSeconds:
+		estimate.Class = CostModerate
+	default:
+		estimate.Class = CostCheap
+	}
+
+	return estimate
+}
+
+// costState returns the current state the cost estimate depends on
+func (h *handler) costState(ctx context.Context) costState {
+	var state costState
+	if p := h.deps.Parser; p != nil {
+		state.Sources = 1
+		if p.Sources != nil {
+			for _, source := range p.Sources.List() {
+				if source.Enabled && source.Type == parser.SourceTypeResults {
+					state.Sources++
+				}
+			}
+		}
+		state.Workers = p.SourceWorkers
+		if state.Workers <= 0 {
+			state.Workers = parser.DefaultSourceWorkers
+		}
+		state.SourcePaused = !p.SourcePausedUntil().IsZero()
+	}
+
+	if h.deps.History != nil {
+		for _, run := range h.deps.History.List() {
+			if run.Trigger == "api" && run.Status == history.StatusSucceeded {
+				state.RefreshSeconds = float64(run.DurationMs) / 1000
+				break
+			}
+		}
+	}
+	_, state.FreshFallback = h.refresher.fresh(time.Now())
+	_, refreshed := h.refreshedSnapshot()
+	state.FreshCache = refreshed || h.freshCache(ctx)
+
+	return state
+}
+
+// costMetrics counts the expensive requests by outcome
+type costMetrics struct {
+	confirmed atomic.Int64
+	rejected  atomic.Int64
+}
+
+// stats returns the counts for the verbose health report
+func (m *costMetrics) stats() *apitypes.ExpensiveRequestCounts {
+	return &apitypes.ExpensiveRequestCounts{
+		Confirmed: m.confirmed.Load(),
+		Rejected:  m.rejected.Load(),
+	}
+}
+
+// costGuard estimates the cost of a data request before it runs
+// Expensive requests need the X-Confirm-Expensive: true header, otherwise
+// they are refused with 409 and the estimate, so clients do not start
+// hundreds of outbound requests by accident. With ?show_cost=1 the
+// estimate is returned in the X-Cost-* headers of any response.
+func (h *handler) costGuard(c *gin.Context) {
+	thresholds := h.deps.CostThresholds
+	if thresholds == (CostThresholds{}) {
+		thresholds = DefaultCostThresholds()
+	}
+	estimate := estimateCost(c.Request.URL.Query(), h.costState(c.Request.Context()), thresholds)
+
+	if flagSet(c.Query("show_cost")) {
+		c.Header("X-Cost-Class", estimate.Class)
+		c.Header("X-Cost-Outbound-Requests", strconv.Itoa(estimate.OutboundRequests))
+		c.Header("X-Cost-Estimated-Seconds", strconv.FormatFloat(estimate.EstimatedSeconds, 'f', 1, 64))
+	}
+
+	if estimate.Class != CostExpensive {
+		c.Next()
+		return
+	}
+	if c.GetHeader(confirmExpensiveHeader) != "true" {
+		h.costs.rejected.Add(1)
+		c.AbortWithStatusJSON(http.StatusConflict, gin.H{
+			"error": "expensive_operation",
+			"message": fmt.Sprintf("The request needs about %d outbound requests and %.0f seconds; repeat it with the header %s: true to run it",
+				estimate.OutboundRequests, math.Ceil(estimate.EstimatedSeconds), confirmExpensiveHeader),
+			"cost": estimate,
+		})
+		return
+	}
+
+	h.costs.confirmed.Add(1)
+	c.Next()
+}
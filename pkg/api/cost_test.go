@@ -0,0 +1,135 @@
+package api
+
+import (
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"easypars/pkg/apitypes"
+	"easypars/pkg/parser"
+)
+
+func TestEstimateCost(t *testing.T) {
+	thresholds := DefaultCostThresholds()
+
+	tests := []struct {
+		name     string
+		query    string
+		state    costState
+		requests int
+		seconds  float64
+		class    string
+	}{
+		{"no parser", "", costState{}, 0, 0, CostCheap},
+		{"main page", "", costState{Sources: 1, Workers: 4}, 1, 1, CostCheap},
+		{"measured refresh", "", costState{Sources: 1, Workers: 4, RefreshSeconds: 0.4}, 1, 0.4, CostCheap},
+		{"some sources", "", costState{Sources: 5, Workers: 4}, 5, 2, CostModerate},
+		{"many sources", "", costState{Sources: 12, Workers: 4}, 12, 4, CostExpensive},
+		{"slow refresh", "", costState{Sources: 1, Workers: 4, RefreshSeconds: 45}, 1, 45, CostExpensive},
+		{"later pages", "pages=10", costState{Sources: 1, Workers: 4}, 10, 4, CostExpensive},
+		{"warm cache", "", costState{Sources: 12, Workers: 4, FreshCache: true}, 0, 0, CostCheap},
+		{"warm cache forced refresh", "refresh=true", costState{Sources: 12, Workers: 4, FreshCache: true}, 12, 4, CostExpensive},
+		{"warm cache, later pages", "pages=4", costState{Sources: 1, Workers: 4, FreshCache: true}, 3, 1, CostModerate},
+		{"fresh fallback", "fallback=accepted", costState{Sources: 12, Workers: 4, FreshFallback: true}, 0, 0, CostCheap},
+		{"fallback without a fresh result", "fallback=accepted", costState{Sources: 12, Workers: 4}, 12, 4, CostExpensive},
+		{"paused source", "pages=10", costState{Sources: 12, Workers: 4, SourcePaused: true}, 0, 0, CostCheap},
+	}
+	for _, tt := range tests {
+		params, err := url.ParseQuery(tt.query)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got := estimateCost(params, tt.state, thresholds)
+		want := apitypes.CostEstimate{OutboundRequests: tt.requests, EstimatedSeconds: tt.seconds, Class: tt.class}
+		if got != want {
+			t.Errorf("%s: estimate = %+v, want %+v", tt.name, got, want)
+		}
+	}
+}
+
+func TestCostThresholdsValidate(t *testing.T) {
+	tests := []struct {
+		name       string
+		thresholds CostThresholds
+		wantErr    bool
+	}{
+		{"defaults", DefaultCostThresholds(), false},
+		{"zero", CostThresholds{}, true},
+		{"expensive below moderate", CostThresholds{ModerateRequests: 5, ModerateSeconds: 5, ExpensiveRequests: 3, ExpensiveSeconds: 30}, true},
+		{"equal seconds", CostThresholds{ModerateRequests: 3, ModerateSeconds: 5, ExpensiveRequests: 10, ExpensiveSeconds: 5}, true},
+	}
+	for _, tt := range tests {
+		if err := tt.thresholds.Validate(); (err != nil) != tt.wantErr {
+			t.Errorf("%s: Validate = %v, want error %v", tt.name, err, tt.wantErr)
+		}
+	}
+}
+
+func TestCostGuard(t *testing.T) {
+	// Two outbound requests are expensive: the main page and one later page
+	thresholds := CostThresholds{ModerateRequests: 1, ModerateSeconds: 100, ExpensiveRequests: 2, ExpensiveSeconds: 200}
+	page := readTestdata(t, "results.html")
+	p := newTestParser(t, page)
+	p.PageURL = p.BaseURL + "page/{page}"
+	cold := SetupRouter(Dependencies{Parser: p, CostThresholds: thresholds})
+	warmStore := &stubFightStore{result: &parser.ParseResult{Fights: storedFights()}}
+	warm, _ := newStoreRouter(t, warmStore, Dependencies{CostThresholds: thresholds})
+
+	tests := []struct {
+		name    string
+		warm    bool
+		query   string
+		confirm string
+		status  int
+		class   string
+	}{
+		{"cheap without confirmation", true, "", "", http.StatusOK, CostCheap},
+		{"moderate without confirmation", false, "", "", http.StatusOK, CostModerate},
+		{"expensive without confirmation", false, "pages=2", "", http.StatusConflict, CostExpensive},
+		{"expensive with a wrong confirmation", false, "pages=2", "yes", http.StatusConflict, CostExpensive},
+		{"expensive confirmed", false, "pages=2", "true", http.StatusOK, CostExpensive},
+	}
+	for _, tt := range tests {
+		router := cold
+		if tt.warm {
+			router = warm
+		}
+		rec := serve(router, http.MethodGet, "/api/fights?show_cost=1&"+tt.query, "", confirmExpensiveHeader, tt.confirm)
+		if rec.Code != tt.status {
+			t.Fatalf("%s: GET /api/fights?%s = %d %s, want %d", tt.name, tt.query, rec.Code, rec.Body, tt.status)
+		}
+		if class := rec.Header().Get("X-Cost-Class"); class != tt.class {
+			t.Errorf("%s: X-Cost-Class = %q, want %q", tt.name, class, tt.class)
+		}
+		if tt.status != http.StatusConflict {
+			continue
+		}
+
+		var body struct {
+			Error   string                `json:"error"`
+			Message string                `json:"message"`
+			Cost    apitypes.CostEstimate `json:"cost"`
+		}
+		decodeJSON(t, rec, &body)
+		if body.Error != "expensive_operation" || body.Cost.OutboundRequests != 2 || body.Cost.Class != CostExpensive || body.Message == "" {
+			t.Errorf("%s: 409 body = %+v, want expensive_operation with the estimate", tt.name, body)
+		}
+	}
+
+	// The warm cache lowers the class of the same request
+	rec := serve(warm, http.MethodGet, "/api/fights?show_cost=1&pages=2", "")
+	if class := rec.Header().Get("X-Cost-Class"); class != CostModerate || rec.Code == http.StatusConflict {
+		t.Errorf("GET ?pages=2 with a warm cache = %d of class %q, want it run as %s", rec.Code, class, CostModerate)
+	}
+
+	token := "Bearer " + signTestToken(t, testJWTSecret, time.Now())
+	withAuth := SetupRouter(Dependencies{Parser: newTestParser(t, page), Auth: newTestAuth(t), CostThresholds: thresholds})
+	serve(withAuth, http.MethodGet, "/api/fights?pages=2", "")
+	serve(withAuth, http.MethodGet, "/api/fights?pages=2", "", confirmExpensiveHeader, "true")
+	var health apitypes.HealthResponse
+	decodeJSON(t, serve(withAuth, http.MethodGet, "/api/health?verbose=1", "", "Authorization", token), &health)
+	if got := health.ExpensiveRequests; got == nil || got.Rejected != 1 || got.Confirmed != 1 {
+		t.Errorf("expensive_requests = %+v, want 1 rejected and 1 confirmed", got)
+	}
+}
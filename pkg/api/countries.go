@@ -0,0 +1,50 @@
+package api
+
+import (
+	"fmt"
+	"strings"
+
+	"easypars/models"
+	"easypars/pkg/countries"
+)
+
+// validateCountry accepts a country as an ISO code or a Russian or English
+// name; an unknown one is rejected with the countries it may have meant
+func validateCountry(value string) error {
+	if _, ok := countries.Resolve(value); ok {
+		return nil
+	}
+
+	suggestions := countries.Suggest(value)
+	if len(suggestions) == 0 {
+		return fmt.Errorf("unknown country %q, use an ISO 3166-1 code such as UA or a country name", value)
+	}
+	hints := make([]string, 0, len(suggestions))
+	for _, iso2 := range suggestions {
+		hints = append(hints, fmt.Sprintf("%s (%s, %s)", iso2,
+			countries.Localize(iso2, countries.LangRU), countries.Localize(iso2, countries.LangEN)))
+	}
+
+	return fmt.Errorf("unknown country %q, did you mean: %s", value, strings.Join(hints, ", "))
+}
+
+// localizeCountries returns the fights with the names of their countries in
+// the language ("ru" or "en")
+// The fights are copied, the snapshot is shared by all requests.
+func localizeCountries(fights []models.Fight, lang string) []models.Fight {
+	localized := make([]models.Fight, len(fights))
+	for i, fight := range fights {
+		if fight.Fighter1Country != "" {
+			fight.Fighter1CountryName = countries.Localize(fight.Fighter1Country, lang)
+		}
+		if fight.Fighter2Country != "" {
+			fight.Fighter2CountryName = countries.Localize(fight.Fighter2Country, lang)
+		}
+		if fight.LocationCountry != "" {
+			fight.LocationCountryName = countries.Localize(fight.LocationCountry, lang)
+		}
+		localized[i] = fight
+	}
+
+	return localized
+}
@@ -0,0 +1,110 @@
+package api
+
+import (
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"testing"
+
+	"easypars/pkg/apitypes"
+)
+
+// flagsPage has fights with the flags of the fighters and located countries
+const flagsPage = `<html><body><div class="month">Май 2024</div><table>
+<tr><td class="date">18</td><td class="place">Эр-Рияд, Саудовская Аравия</td><td class="boxer_1"><img title="Украина">Oleksandr Usyk</td><td class="vs">SD</td><td class="boxer_2"><img src="/flags/gb.png">Tyson Fury</td></tr>
+<tr><td class="date">25</td><td class="place">London, UK</td><td class="boxer_1"><img title="Великобритания">Daniel Dubois</td><td class="vs">TKO 9</td><td class="boxer_2"><img title="Хорватия">Filip Hrgovic</td></tr>
+<tr><td class="date">4</td><td class="place">Las Vegas</td><td class="boxer_1"><img title="Атлантида">Nobody</td><td class="vs">KO 1</td><td class="boxer_2">Someone</td></tr>
+</table></body></html>`
+
+func TestCountryFilters(t *testing.T) {
+	router := newTestRouter(t, flagsPage, Dependencies{})
+
+	tests := []struct {
+		name  string
+		query string
+		want  []string
+	}{
+		{"fighter ISO code", "fighter_country=ua", []string{"Oleksandr Usyk"}},
+		{"fighter alpha-3 code", "fighter_country=GBR", []string{"Daniel Dubois", "Oleksandr Usyk"}},
+		{"fighter Russian name", "fighter_country=Хорватия", []string{"Daniel Dubois"}},
+		{"fighter English name", "fighter_country=Ukraine", []string{"Oleksandr Usyk"}},
+		{"location alias", "country=Англия", []string{"Daniel Dubois"}},
+		{"location and fighter", "country=SA&fighter_country=UK", []string{"Oleksandr Usyk"}},
+	}
+	for _, tt := range tests {
+		rec := serve(router, http.MethodGet, "/api/fights?"+tt.query, "")
+		if rec.Code != http.StatusOK {
+			t.Fatalf("%s: GET /api/fights?%s = %d %s, want 200", tt.name, tt.query, rec.Code, rec.Body)
+		}
+		var body apitypes.FightsResponse
+		decodeJSON(t, rec, &body)
+		var got []string
+		for _, fight := range body.Data {
+			got = append(got, fight.Fighter1)
+		}
+		slices.Sort(got)
+		if strings.Join(got, ", ") != strings.Join(tt.want, ", ") {
+			t.Errorf("%s: fights of %s, want %s", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestUnknownCountryFilterSuggests(t *testing.T) {
+	router := newTestRouter(t, flagsPage, Dependencies{})
+
+	tests := []struct {
+		value   string
+		message string
+	}{
+		{"Ukrai", "did you mean: UA (Украина, Ukraine)"},
+		{"Атлантида", "use an ISO 3166-1 code"},
+	}
+	for _, tt := range tests {
+		rec := serve(router, http.MethodGet, "/api/fights?fighter_country="+url.QueryEscape(tt.value), "")
+		var body struct {
+			Error   string `json:"error"`
+			Message string `json:"message"`
+		}
+		decodeJSON(t, rec, &body)
+		if rec.Code != http.StatusBadRequest || body.Error != "invalid_params" || !strings.Contains(body.Message, tt.message) {
+			t.Errorf("fighter_country=%s = %d %s %q, want 400 invalid_params with %q", tt.value, rec.Code, body.Error, body.Message, tt.message)
+		}
+	}
+}
+
+func TestCountryNamesFollowTheLanguage(t *testing.T) {
+	router := newTestRouter(t, flagsPage, Dependencies{})
+
+	tests := []struct {
+		language string
+		want     [3]string
+	}{
+		{"ru-RU,ru;q=0.9", [3]string{"Украина", "Великобритания", "Саудовская Аравия"}},
+		{"en-US", [3]string{"Ukraine", "United Kingdom", "Saudi Arabia"}},
+		{"", [3]string{"Ukraine", "United Kingdom", "Saudi Arabia"}},
+	}
+	for _, tt := range tests {
+		rec := serve(router, http.MethodGet, "/api/fights?fighter_country=UA", "", "Accept-Language", tt.language)
+		var body apitypes.FightsResponse
+		decodeJSON(t, rec, &body)
+		if len(body.Data) != 1 {
+			t.Fatalf("Accept-Language %q: %d fights, want Usyk - Fury", tt.language, len(body.Data))
+		}
+		fight := body.Data[0]
+		if fight.Fighter1Country != "UA" || fight.Fighter2Country != "GB" || fight.LocationCountry != "SA" {
+			t.Errorf("country codes = %s, %s, %s; want UA, GB, SA", fight.Fighter1Country, fight.Fighter2Country, fight.LocationCountry)
+		}
+		if got := [3]string{fight.Fighter1CountryName, fight.Fighter2CountryName, fight.LocationCountryName}; got != tt.want {
+			t.Errorf("Accept-Language %q: names = %q, want %q", tt.language, got, tt.want)
+		}
+	}
+
+	// The unknown flag is kept raw
+	rec := serve(router, http.MethodGet, "/api/fights?search=Nobody", "")
+	var body apitypes.FightsResponse
+	decodeJSON(t, rec, &body)
+	if len(body.Data) != 1 || body.Data[0].Fighter1Country != "" || body.Data[0].Raw == nil || body.Data[0].Raw.Boxer1Country != "Атлантида" {
+		t.Errorf("fights = %+v, want Nobody without a country and with the raw flag", body.Data)
+	}
+}
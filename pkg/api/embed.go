@@ -0,0 +1,269 @@
+package api
+
+import (
+	"fmt"
+	"html"
+	"log/slog"
+	"math"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"easypars/models"
+	"easypars/pkg/clock"
+	"easypars/pkg/metrics"
+	"easypars/pkg/snapshot"
+	"easypars/pkg/widget"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Embedded widget settings
+const (
+	// embedPath is the address of the widget, also accepted by /api/oembed
+	embedPath = "/embed/upcoming"
+	// defaultEmbedLimit and maxEmbedLimit bound the number of fights shown
+	defaultEmbedLimit = 5
+	maxEmbedLimit     = 20
+	// embedCacheTTL is how long a rendered widget is served from memory
+	embedCacheTTL = time.Minute
+	// DefaultEmbedRateLimit is the number of widget requests per minute and
+	// client IP when none is configured; widgets of popular pages are
+	// loaded often, so the limit is generous
+	DefaultEmbedRateLimit = 120
+)
+
+// embedParams lists the /embed/upcoming query parameters in the order
+// they are checked
+var embedParams = []string{"limit", "theme"}
+
+// embedParamValidators validates the /embed/upcoming query parameters
+var embedParamValidators = map[string]func(string) error{
+	"limit": validateIntRange(1, maxEmbedLimit),
+	"theme": validateOneOf(widget.ThemeLight, widget.ThemeDark),
+}
+
+// embedCache keeps rendered widgets for embedCacheTTL by their parameters
+type embedCache struct {
+	mu      sync.Mutex
+	entries map[string]embedEntry
+}
+
+// embedEntry is a rendered widget
+type embedEntry struct {
+	html       []byte
+	renderedAt time.Time
+}
+
+// get returns the widget rendered less than embedCacheTTL ago
+func (c *embedCache) get(key string, now time.Time) ([]byte, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.entries[key]
+	if !ok || now.Sub(entry.renderedAt) >= embedCacheTTL {
+		return nil, false
+	}
+
+	return entry.html, true
+}
+
+// put stores a rendered widget; the cache holds one entry per limit and
+// theme, so it stays small
+func (c *embedCache) put(key string, data []byte, now time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.entries == nil {
+		c.entries = make(map[string]embedEntry)
+	}
+	c.entries[key] = embedEntry{html: data, renderedAt: now}
+}
+
+// ValidateEmbedAncestors checks the origins allowed to embed the widget
+// Each must be a single CSP source expression, so a value cannot add
+// directives to the Content-Security-Policy header.
+func ValidateEmbedAncestors(ancestors []string) error {
+	for _, ancestor := range ancestors {
+		if ancestor == "" || strings.ContainsAny(ancestor, " \t\r\n;,'\"") {
+			return fmt.Errorf("invalid embed ancestor %q: must be a single origin such as https://example.com or *", ancestor)
+		}
+	}
+
+	return nil
+}
+
+// embedPolicy returns the Content-Security-Policy of the widget
+// Only inline styles are allowed; frame-ancestors lists who may embed it.
+func embedPolicy(ancestors []string) string {
+	frameAncestors := "'none'"
+	if len(ancestors) > 0 {
+		frameAncestors = strings.Join(ancestors, " ")
+	}
+
+	return "default-src 'none'; style-src 'unsafe-inline'; base-uri 'none'; form-action 'none'; frame-ancestors " + frameAncestors
+}
+
+// handleEmbedUpcoming handles GET requests to /embed/upcoming
+// Returns the HTML widget with the nearest upcoming fights for iframes of
+// other sites (?limit=1..20, ?theme=light|dark). The widget is rendered at
+// most once a minute per parameters and has its own rate limit.
+func (h *handler) handleEmbedUpcoming(c *gin.Context) {
+	if allowed, retryAfter := h.embedLimiter.Allow(c.ClientIP()); !allowed {
+		c.Header("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+		c.String(http.StatusTooManyRequests, "Too many requests, try again later")
+		return
+	}
+
+	for _, key := range embedParams {
+		if value, ok := c.GetQuery(key); ok {
+			if err := embedParamValidators[key](value); err != nil {
+				c.String(http.StatusBadRequest, "Invalid parameter %q: %v", key, err)
+				return
+			}
+		}
+	}
+	limit := defaultEmbedLimit
+	if value := c.Query("limit"); value != "" {
+		limit, _ = strconv.Atoi(value)
+	}
+	theme := c.DefaultQuery("theme", widget.ThemeLight)
+
+	// Step 1: Serve the widget rendered within the last minute
+	now := time.Now()
+	cacheKey := strconv.Itoa(limit) + "/" + theme
+	page, cached := h.embeds.get(cacheKey, now)
+	metrics.CacheLookup(metrics.CacheEmbeds, cached)
+
+	// Step 2: Otherwise render it from the current fights
+	if !cached {
+		snap, err := h.refreshSnapshot(c.Request.Context())
+		if abortIfClientGone(c, err) {
+			return
+		}
+		if err != nil {
+			slog.ErrorContext(c.Request.Context(), "Failed to load fights for the widget", "error", err)
+			c.String(http.StatusBadGateway, "Fights are temporarily unavailable")
+			return
+		}
+
+		data := widget.Data{
+			Fights: h.upcomingFights(snap.View(), limit),
+			Theme:  theme,
+		}
+		if h.deps.Parser != nil {
+			data.SourceURL = h.deps.Parser.EffectiveBaseURL()
+		}
+		page, err = widget.Render(data)
+		if err != nil {
+			slog.ErrorContext(c.Request.Context(), "Failed to render the widget", "error", err)
+			c.String(http.StatusInternalServerError, "Failed to render the widget")
+			return
+		}
+		h.embeds.put(cacheKey, page, now)
+	}
+
+	// Step 3: Allow embedding by the configured sites only
+	c.Writer.Header().Del("X-Frame-Options")
+	c.Header("Content-Security-Policy", embedPolicy(h.deps.EmbedAllowedAncestors))
+	c.Header("X-Content-Type-Options", "nosniff")
+	c.Header("Cache-Control", "public, max-age=60")
+	c.Data(http.StatusOK, "text/html; charset=utf-8", page)
+}
+
+// upcomingFights returns the first limit scheduled fights from today on,
+// nearest first
+func (h *handler) upcomingFights(view snapshot.FightsView, limit int) []models.Fight {
+	location := time.UTC
+	var now clock.Clock = clock.Real{}
+	if h.deps.Parser != nil {
+		if h.deps.Parser.Location != nil {
+			location = h.deps.Parser.Location
+		}
+		if h.deps.Parser.Clock != nil {
+			now = h.deps.Parser.Clock
+		}
+	}
+	today := clock.Today(clock.Fixed{Time: now.Now().In(location)}).Format("2006-01-02")
+
+	upcoming := view.Filter(func(fight models.Fight) bool {
+		return fight.Status == models.StatusScheduled && fight.Date >= today
+	})
+	// The view is newest first; the stable sort keeps the card order of a day
+	slices.SortStableFunc(upcoming, func(a, b models.Fight) int {
+		return strings.Compare(a.Date, b.Date)
+	})
+	if len(upcoming) > limit {
+		upcoming = upcoming[:limit]
+	}
+
+	return upcoming
+}
+
+// handleOEmbed handles GET requests to /api/oembed
+// Implements the oEmbed JSON endpoint for widget URLs: ?url= must point to
+// /embed/upcoming on the host the request was sent to. The answer is a
+// "rich" embed whose HTML is an iframe of the widget. Only format=json is
+// supported (501 otherwise), and unknown URLs answer 404, as the oEmbed
+// specification asks.
+func (h *handler) handleOEmbed(c *gin.Context) {
+	if format := c.DefaultQuery("format", "json"); format != "json" {
+		c.JSON(http.StatusNotImplemented, gin.H{
+			"error":   "unsupported_format",
+			"message": "Only the json format is supported",
+		})
+		return
+	}
+
+	rawURL := c.Query("url")
+	target, err := url.Parse(rawURL)
+	if rawURL == "" || err != nil || (target.Scheme != "http" && target.Scheme != "https") || target.Host == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "invalid_params",
+			"message": `invalid parameter "url": must be an absolute http(s) URL`,
+		})
+		return
+	}
+	if target.Host != c.Request.Host || strings.TrimSuffix(target.Path, "/") != embedPath {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error":   "not_found",
+			"message": "The URL is not an embeddable resource",
+		})
+		return
+	}
+
+	// Only the known widget parameters are passed on to the iframe
+	query := url.Values{}
+	for _, key := range embedParams {
+		if value := target.Query().Get(key); value != "" && embedParamValidators[key](value) == nil {
+			query.Set(key, value)
+		}
+	}
+	src := url.URL{Scheme: target.Scheme, Host: target.Host, Path: embedPath, RawQuery: query.Encode()}
+
+	width, height := widget.Width, widget.Height
+	if maxWidth, err := strconv.Atoi(c.Query("maxwidth")); err == nil && maxWidth > 0 && maxWidth < width {
+		width = maxWidth
+	}
+	if maxHeight, err := strconv.Atoi(c.Query("maxheight")); err == nil && maxHeight > 0 && maxHeight < height {
+		height = maxHeight
+	}
+
+	iframe := fmt.Sprintf(`<iframe src="%s" width="%d" height="%d" style="border:0" loading="lazy" title="Ближайшие бои"></iframe>`,
+		html.EscapeString(src.String()), width, height)
+	c.JSON(http.StatusOK, gin.H{
+		"version":       "1.0",
+		"type":          "rich",
+		"title":         "Ближайшие бои",
+		"provider_name": "EasyPars",
+		"provider_url":  (&url.URL{Scheme: target.Scheme, Host: target.Host, Path: "/"}).String(),
+		"cache_age":     int(embedCacheTTL.Seconds()),
+		"html":          iframe,
+		"width":         width,
+		"height":        height,
+	})
+}
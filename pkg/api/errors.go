@@ -0,0 +1,167 @@
+package api
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"sort"
+	"sync"
+
+	"easypars/pkg/apitypes"
+	"easypars/pkg/contract"
+	"easypars/pkg/parser"
+
+	"github.com/gin-gonic/gin"
+)
+
+// errorOrigin returns the origin of an error reaching the API
+// Contract violations are internal. Any other error without an origin is
+// counted as internal and logged, a missing classification is a gap to fix
+// where the error occurs.
+func errorOrigin(err error) (parser.ErrorOrigin, bool) {
+	if origin, ok := parser.OriginOf(err); ok {
+		return origin, true
+	}
+	var violationErr *contract.ViolationError
+	if errors.As(err, &violationErr) {
+		return parser.ErrorOriginInternal, true
+	}
+
+	return parser.ErrorOriginInternal, false
+}
+
+// errorStatus maps the origin of an error to the HTTP status and error code
+// Errors of the source and of the network answer 502, or 504 when the
+// source timed out, so they are not mistaken for failures of the service.
+// An error status of the source and an oversized page have their own
+// codes; a page whose structure changed answers 500, since the parser
+// needs a fix on our side.
+// internalCode is the code of internal errors, which differs per endpoint.
+func errorStatus(err error, origin parser.ErrorOrigin, internalCode string) (int, string) {
+	var statusErr *parser.StatusError
+	switch {
+	case errors.Is(err, parser.ErrStructureChanged):
+		return http.StatusInternalServerError, "source_structure_changed"
+	case errors.As(err, &statusErr):
+		return http.StatusBadGateway, "upstream_status"
+	case errors.Is(err, parser.ErrResponseTooLarge):
+		return http.StatusBadGateway, "upstream_too_large"
+	}
+
+	switch origin {
+	case parser.ErrorOriginSource, parser.ErrorOriginNetwork:
+		if parser.IsTimeout(err) {
+			return http.StatusGatewayTimeout, "upstream_error"
+		}
+		return http.StatusBadGateway, "upstream_error"
+	case parser.ErrorOriginConfig:
+		return http.StatusInternalServerError, "misconfiguration"
+	default:
+		return http.StatusInternalServerError, internalCode
+	}
+}
+
+// statusClientClosedRequest is the nginx status of requests the client
+// abandoned; it only shows in logs, the client is gone
+const statusClientClosedRequest = 499
+
+// abortIfClientGone ends a request whose client disconnected while the
+// fights were loaded
+// Nobody reads the answer, so it is neither sent nor counted as an error.
+func abortIfClientGone(c *gin.Context, err error) bool {
+	if c.Request.Context().Err() == nil || !errors.Is(err, c.Request.Context().Err()) {
+		return false
+	}
+
+	slog.InfoContext(c.Request.Context(), "Request cancelled by the client", "error", err)
+	c.AbortWithStatus(statusClientClosedRequest)
+	return true
+}
+
+// respondError answers a failed load or parse with the status of its origin
+// and counts it in the error metrics
+// Contract violations keep their detailed response. The message is shown
+// to the client with the request ID, which finds the logs of the failure;
+// the error itself is logged by the caller.
+func (h *handler) respondError(c *gin.Context, err error, internalCode, message string) {
+	origin, classified := errorOrigin(err)
+	if !classified {
+		slog.WarnContext(c.Request.Context(), "Unclassified error counted as internal, classify it where it occurs", "error", err)
+	}
+	status, code := http.StatusInternalServerError, "contract_violation"
+	if !respondContractViolation(c, err) {
+		status, code = errorStatus(err, origin, internalCode)
+		body := gin.H{
+			"error":      code,
+			"message":    message,
+			"origin":     origin,
+			"request_id": c.GetString(requestIDKey),
+		}
+		// A changed page structure comes with the selectors it lacks
+		if report, ok := parser.StructureOf(err); ok {
+			body["details"] = report
+		}
+		c.JSON(status, body)
+	}
+
+	h.errors.record(origin, code, status, classified)
+}
+
+// errorKey identifies a series of the error metrics
+type errorKey struct {
+	origin parser.ErrorOrigin
+	code   string
+	status int
+}
+
+// errorMetrics counts the error responses by origin, code and status
+// The series are few and fixed, which keeps alerts on them cheap: a rise of
+// "source" errors pages nobody on our side, a rise of "internal" does.
+type errorMetrics struct {
+	mu     sync.Mutex
+	counts map[errorKey]int64
+	// unclassified counts errors that reached the API without an origin
+	unclassified int64
+}
+
+// record counts an error response
+func (m *errorMetrics) record(origin parser.ErrorOrigin, code string, status int, classified bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.counts == nil {
+		m.counts = make(map[errorKey]int64)
+	}
+	m.counts[errorKey{origin: origin, code: code, status: status}]++
+	if !classified {
+		m.unclassified++
+	}
+}
+
+// stats returns the counts ordered by origin, code and status
+func (m *errorMetrics) stats() ([]apitypes.ErrorCount, int64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	counts := make([]apitypes.ErrorCount, 0, len(m.counts))
+	for key, count := range m.counts {
+		counts = append(counts, apitypes.ErrorCount{
+			Origin: string(key.origin),
+			Code:   key.code,
+			Status: key.status,
+			Count:  count,
+		})
+	}
+	sort.Slice(counts, func(i, j int) bool {
+		a, b := counts[i], counts[j]
+		if a.Origin != b.Origin {
+			return a.Origin < b.Origin
+		}
+		if a.Code != b.Code {
+			return a.Code < b.Code
+		}
+		return a.Status < b.Status
+	})
+
+	return counts, m.unclassified
+}
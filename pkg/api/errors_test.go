@@ -0,0 +1,141 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+
+	"easypars/pkg/apitypes"
+	"easypars/pkg/contract"
+	"easypars/pkg/parser"
+)
+
+func TestErrorStatus(t *testing.T) {
+	refused := errors.New("connection refused")
+	tests := []struct {
+		name       string
+		err        error
+		origin     parser.ErrorOrigin
+		classified bool
+		status     int
+		code       string
+	}{
+		{"source", parser.Classify(parser.ErrorOriginSource, errors.New("undecodable page")), parser.ErrorOriginSource, true, http.StatusBadGateway, "upstream_error"},
+		{"network", parser.Classify(parser.ErrorOriginNetwork, refused), parser.ErrorOriginNetwork, true, http.StatusBadGateway, "upstream_error"},
+		{"network timeout", parser.Classify(parser.ErrorOriginNetwork, fmt.Errorf("fetch: %w", context.DeadlineExceeded)), parser.ErrorOriginNetwork, true, http.StatusGatewayTimeout, "upstream_error"},
+		{"internal", parser.Classify(parser.ErrorOriginInternal, errors.New("invariant")), parser.ErrorOriginInternal, true, http.StatusInternalServerError, "parse_error"},
+		{"config", parser.Classify(parser.ErrorOriginConfig, errors.New("invalid selector")), parser.ErrorOriginConfig, true, http.StatusInternalServerError, "misconfiguration"},
+		{"wrapped config", fmt.Errorf("loading fights: %w", parser.Classify(parser.ErrorOriginConfig, errors.New("no URL"))), parser.ErrorOriginConfig, true, http.StatusInternalServerError, "misconfiguration"},
+		{"source status", parser.Classify(parser.ErrorOriginSource, &parser.StatusError{URL: "https://vringe.example/", StatusCode: 503}), parser.ErrorOriginSource, true, http.StatusBadGateway, "upstream_status"},
+		{"oversized page", parser.Classify(parser.ErrorOriginSource, fmt.Errorf("read: %w", parser.ErrResponseTooLarge)), parser.ErrorOriginSource, true, http.StatusBadGateway, "upstream_too_large"},
+		{"structure changed", parser.Classify(parser.ErrorOriginSource, parser.ErrStructureChanged), parser.ErrorOriginSource, true, http.StatusInternalServerError, "source_structure_changed"},
+		{"contract violation", &contract.ViolationError{Boundary: contract.BoundaryParser, Total: 1}, parser.ErrorOriginInternal, true, http.StatusInternalServerError, "parse_error"},
+		{"unclassified", refused, parser.ErrorOriginInternal, false, http.StatusInternalServerError, "parse_error"},
+	}
+	for _, tt := range tests {
+		origin, classified := errorOrigin(tt.err)
+		if origin != tt.origin || classified != tt.classified {
+			t.Errorf("%s: origin = %q (classified %v), want %q (%v)", tt.name, origin, classified, tt.origin, tt.classified)
+		}
+		status, code := errorStatus(tt.err, origin, "parse_error")
+		if status != tt.status || code != tt.code {
+			t.Errorf("%s: status = %d %s, want %d %s", tt.name, status, code, tt.status, tt.code)
+		}
+	}
+}
+
+func TestErrorResponsesAreCountedByOrigin(t *testing.T) {
+	src, _ := failingSource(t)
+	router := SetupRouter(Dependencies{Parser: parser.NewParser(src.URL + "/"), ServeMetrics: true})
+
+	rec := serve(router, http.MethodGet, "/api/fights", "")
+	if rec.Code != http.StatusBadGateway {
+		t.Fatalf("GET /api/fights with a failing source = %d %s, want 502", rec.Code, rec.Body)
+	}
+	var body struct {
+		Error  string `json:"error"`
+		Origin string `json:"origin"`
+	}
+	decodeJSON(t, rec, &body)
+	if body.Error != "upstream_status" || body.Origin != string(parser.ErrorOriginSource) {
+		t.Errorf("error = %s from %s, want upstream_status from the source", body.Error, body.Origin)
+	}
+
+	var health apitypes.HealthResponse
+	decodeJSON(t, serve(router, http.MethodGet, "/api/health?verbose=1", ""), &health)
+	want := apitypes.ErrorCount{Origin: "source", Code: "upstream_status", Status: http.StatusBadGateway, Count: 1}
+	if len(health.Errors) != 1 || health.Errors[0] != want || health.UnclassifiedErrors != 0 {
+		t.Errorf("error counts = %+v (%d unclassified), want %+v", health.Errors, health.UnclassifiedErrors, want)
+	}
+
+	scrape := serve(router, http.MethodGet, "/metrics", "").Body.String()
+	if want := `easypars_parse_errors_total{type="source"}`; !strings.Contains(scrape, want) {
+		t.Errorf("scrape has no %s", want)
+	}
+}
+
+func TestFightsErrorCodes(t *testing.T) {
+	tests := []struct {
+		name   string
+		status int
+		page   string
+		want   int
+		code   string
+	}{
+		{"structure changed", http.StatusOK, `<html><body><ul class="fights"><li>Usyk - Fury</li></ul></body></html>`, http.StatusInternalServerError, "source_structure_changed"},
+		{"error status", http.StatusServiceUnavailable, "unavailable", http.StatusBadGateway, "upstream_status"},
+		{"not found", http.StatusNotFound, "gone", http.StatusBadGateway, "upstream_status"},
+	}
+	for _, tt := range tests {
+		src := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "text/html; charset=utf-8")
+			w.WriteHeader(tt.status)
+			io.WriteString(w, tt.page)
+		}))
+		defer src.Close()
+		router := SetupRouter(Dependencies{Parser: parser.NewParser(src.URL + "/")})
+
+		rec := serve(router, http.MethodGet, "/api/fights", "")
+		if rec.Code != tt.want {
+			t.Errorf("%s: GET /api/fights = %d %s, want %d", tt.name, rec.Code, rec.Body, tt.want)
+			continue
+		}
+		if code := errorCode(t, rec); code != tt.code {
+			t.Errorf("%s: error = %q, want %q", tt.name, code, tt.code)
+		}
+	}
+}
+
+func TestStructureChangeDetails(t *testing.T) {
+	page := `<html><body><div class="month">Май 2024</div><table><tr><td class="day">18</td><td class="fighter">Usyk</td></tr></table></body></html>`
+	router := newTestRouter(t, page, Dependencies{})
+
+	rec := serve(router, http.MethodGet, "/api/fights", "")
+	if rec.Code != http.StatusInternalServerError {
+		t.Fatalf("GET /api/fights of a changed page = %d %s, want 500", rec.Code, rec.Body)
+	}
+	var body struct {
+		Error   string                 `json:"error"`
+		Details parser.StructureReport `json:"details"`
+	}
+	decodeJSON(t, rec, &body)
+	if body.Error != "source_structure_changed" {
+		t.Errorf("error = %q, want source_structure_changed", body.Error)
+	}
+	if !slices.Contains(body.Details.Missing, "td.boxer_1") || body.Details.Counts["table"] != 1 {
+		t.Errorf("details = %+v, want the missing fight cells and the counted table", body.Details)
+	}
+
+	// Other errors come without details
+	src, _ := failingSource(t)
+	failing := SetupRouter(Dependencies{Parser: parser.NewParser(src.URL + "/")})
+	if rec := serve(failing, http.MethodGet, "/api/fights", ""); strings.Contains(rec.Body.String(), `"details"`) {
+		t.Errorf("error of a failing source = %s, want no details", rec.Body)
+	}
+}
@@ -0,0 +1,76 @@
+package api
+
+import (
+	"log/slog"
+	"net/http"
+
+	"easypars/pkg/apitypes"
+	"easypars/pkg/contract"
+	"easypars/pkg/parser"
+
+	"github.com/gin-gonic/gin"
+)
+
+// handleGetEvents handles GET requests to /api/events
+// Returns the fight cards of the current data set (see parser.GroupEvents)
+// The filter parameters of /api/fights, the date range included, select
+// the fights before they are grouped; ?page and ?limit paginate the cards.
+func (h *handler) handleGetEvents(c *gin.Context) {
+	if err := validateFightsParams(c.Request.URL.Query(), false); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "invalid_params",
+			"message": err.Error(),
+		})
+		return
+	}
+	filters, err := h.filterLayers(c)
+	if err != nil {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error":   "forbidden",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	snap, err := h.refreshSnapshot(c.Request.Context())
+	if abortIfClientGone(c, err) {
+		return
+	}
+	if err != nil {
+		slog.ErrorContext(c.Request.Context(), "Failed to load events", "error", err)
+		h.respondError(c, err, "parse_error", "Failed to load fight data")
+		return
+	}
+	setServerTiming(c, snap)
+
+	fights, undated := filters.apply(snap.View())
+	applied := filters.applied()
+	if applied != nil {
+		applied.UndatedExcluded = undated
+	}
+	fights = localizeCountries(fights, preferredLanguage(c.GetHeader("Accept-Language")))
+
+	// The serialized fights must keep the model invariants
+	if err := h.deps.Contract.Check(contract.BoundaryAPI, fights); err != nil {
+		h.respondError(c, err, "parse_error", "Failed to load fight data")
+		return
+	}
+
+	page, limit, _ := listPage(c.Request.URL.Query())
+	events, pagination := paginate(parser.GroupEvents(fights), page, limit, "events")
+
+	fightCount := 0
+	for _, event := range events {
+		fightCount += len(event.Fights)
+	}
+
+	c.JSON(http.StatusOK, apitypes.EventsResponse{
+		Message:    "List of events retrieved successfully",
+		Data:       events,
+		Count:      len(events),
+		FightCount: fightCount,
+		Pagination: pagination,
+
+		AppliedFilters: applied,
+	})
+}
@@ -0,0 +1,75 @@
+package api
+
+import (
+	"net/http"
+	"reflect"
+	"testing"
+
+	"easypars/pkg/apitypes"
+)
+
+func TestGetEvents(t *testing.T) {
+	router := newTestRouter(t, readTestdata(t, "results.html"), Dependencies{})
+
+	tests := []struct {
+		name   string
+		query  string
+		status int
+		// cards are the date, location and main event of the served cards
+		cards  [][3]string
+		fights int
+		total  int
+	}{
+		{
+			name: "all cards", status: http.StatusOK,
+			cards: [][3]string{
+				{"2024-05-18", "Riyadh", "Usyk"},
+				{"2024-05-25", "London", "Dubois"},
+				{"2024-06-01", "Riyadh", "Bivol"},
+				{"2024-06-08", "London", "Joshua"},
+				{"2024-06-22", "Las Vegas", "Canelo"},
+			},
+			fights: 6, total: 5,
+		},
+		{
+			name: "date range", query: "?from=2024-06-01&to=2024-06-08", status: http.StatusOK,
+			cards:  [][3]string{{"2024-06-01", "Riyadh", "Bivol"}, {"2024-06-08", "London", "Joshua"}},
+			fights: 3, total: 2,
+		},
+		{
+			name: "paginated", query: "?limit=2&page=2", status: http.StatusOK,
+			cards:  [][3]string{{"2024-06-01", "Riyadh", "Bivol"}, {"2024-06-08", "London", "Joshua"}},
+			fights: 3, total: 5,
+		},
+		{name: "invalid date", query: "?from=June", status: http.StatusBadRequest},
+		{name: "from after to", query: "?from=2024-06-08&to=2024-06-01", status: http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := serve(router, http.MethodGet, "/api/events"+tt.query, "")
+			if rec.Code != tt.status {
+				t.Fatalf("GET /api/events%s = %d %s, want %d", tt.query, rec.Code, rec.Body, tt.status)
+			}
+			if tt.status != http.StatusOK {
+				if code := errorCode(t, rec); code != "invalid_params" {
+					t.Errorf("error = %q, want invalid_params", code)
+				}
+				return
+			}
+
+			var body apitypes.EventsResponse
+			decodeJSON(t, rec, &body)
+			var cards [][3]string
+			for _, event := range body.Data {
+				cards = append(cards, [3]string{event.Date, event.Location, event.MainEvent.Fighter1})
+			}
+			if !reflect.DeepEqual(cards, tt.cards) {
+				t.Errorf("cards = %q, want %q", cards, tt.cards)
+			}
+			if body.Count != len(tt.cards) || body.FightCount != tt.fights || body.Pagination.Total != tt.total {
+				t.Errorf("count = %d, fight_count = %d, total = %d; want %d, %d, %d",
+					body.Count, body.FightCount, body.Pagination.Total, len(tt.cards), tt.fights, tt.total)
+			}
+		})
+	}
+}
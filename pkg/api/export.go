@@ -0,0 +1,219 @@
+package api
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"easypars/models"
+	"easypars/pkg/contract"
+
+	"github.com/gin-gonic/gin"
+)
+
+// defaultExportFormat is used when /api/fights/export has no ?format=
+const defaultExportFormat = "csv"
+
+// exportFlushRows is how many rows are written between two flushes of the
+// response, so large exports reach the client while they are written
+const exportFlushRows = 500
+
+// utf8BOM is written before the CSV header with ?bom=1; Excel needs it to
+// read the file as UTF-8 rather than in the codepage of the system
+const utf8BOM = "\ufeff"
+
+// fightExporter writes the fights of an export in one file format
+type fightExporter struct {
+	contentType string
+	extension   string
+	// limited formats are built in memory and refused with 413 above the
+	// export row limit (see exportMaxRows)
+	limited bool
+	// write encodes the fights; builtAt stands in for the parse time of
+	// fights not stored yet
+	write func(c *gin.Context, fights []models.Fight, builtAt time.Time) error
+}
+
+// fightExporters holds the formats of /api/fights/export by ?format= name
+var fightExporters = map[string]fightExporter{
+	"csv": {contentType: "text/csv; charset=utf-8", extension: "csv", write: writeFightsCSV},
+	"xlsx": {
+		contentType: "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+		extension:   "xlsx",
+		limited:     true,
+		write:       writeFightsXLSX,
+	},
+}
+
+// exportFormats returns the supported export formats in alphabetical order
+func exportFormats() []string {
+	names := make([]string, 0, len(fightExporters))
+	for name := range fightExporters {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// handleExportFights handles GET requests to /api/fights/export
+// Returns the fights as a file download in the canonical order of the list
+// The filter parameters of /api/fights, saved presets included, select the
+// fights; the whole filtered list is exported, ?page and ?limit are ignored.
+// ?format= selects the file format (csv by default); an XLSX export of more
+// fights than the row limit is refused with 413.
+func (h *handler) handleExportFights(c *gin.Context) {
+	if err := h.applyPreset(c); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "invalid_preset",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	// Step 1: Check the parameters before any data is loaded
+	query := c.Request.URL.Query()
+	if err := validateFightsParams(query, false); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "invalid_params",
+			"message": err.Error(),
+		})
+		return
+	}
+	if bom := query.Get("bom"); bom != "" {
+		if err := validateFlag(bom); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "invalid_params",
+				"message": fmt.Sprintf("invalid parameter %q: %v", "bom", err),
+			})
+			return
+		}
+	}
+	format := c.DefaultQuery("format", defaultExportFormat)
+	exporter, ok := fightExporters[format]
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "invalid_params",
+			"message": `invalid parameter "format": supported export formats: ` + strings.Join(exportFormats(), ", "),
+		})
+		return
+	}
+	filters, err := h.filterLayers(c)
+	if err != nil {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error":   "forbidden",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	// Step 2: Load and filter the fights like /api/fights
+	snap, _, err := h.cachedSnapshot(c.Request.Context(), flagSet(c.Query("refresh")))
+	if abortIfClientGone(c, err) {
+		return
+	}
+	if err != nil {
+		slog.ErrorContext(c.Request.Context(), "Failed to load fights for export", "error", err)
+		h.respondError(c, err, "parse_error", "Failed to load fight data")
+		return
+	}
+	setServerTiming(c, snap)
+
+	fights, _ := filters.apply(snap.View())
+	fights = sortedCanonical(fights)
+
+	// The exported fights must keep the model invariants
+	if err := h.deps.Contract.Check(contract.BoundaryAPI, fights); err != nil {
+		h.respondError(c, err, "parse_error", "Failed to load fight data")
+		return
+	}
+
+	if exporter.limited && len(fights) > h.exportMaxRows() {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{
+			"error": "export_too_large",
+			"message": fmt.Sprintf("%d fights match, the %s export is limited to %d rows; narrow the filters or export as csv",
+				len(fights), format, h.exportMaxRows()),
+		})
+		return
+	}
+
+	// Step 3: Stream the file
+	filename := fmt.Sprintf("fights-%s.%s", snap.BuiltAt.UTC().Format("20060102"), exporter.extension)
+	c.Header("Content-Type", exporter.contentType)
+	c.Header("Content-Disposition", `attachment; filename="`+filename+`"`)
+	c.Status(http.StatusOK)
+	if err := exporter.write(c, fights, snap.BuiltAt); err != nil {
+		// The status line is already written, the error can only be recorded
+		slog.WarnContext(c.Request.Context(), "Fight export interrupted", "format", format, "error", err)
+		_ = c.Error(fmt.Errorf("error writing %s export: %w", format, err))
+	}
+}
+
+// exportCSVHeader lists the columns of the CSV export
+var exportCSVHeader = []string{"id", "date", "fighter1", "fighter2", "result", "location", "parsed_at"}
+
+// writeFightsCSV writes the fights as CSV with a header row, flushing the
+// response every exportFlushRows rows
+// The id column is never empty (see exportID) and parsed_at falls back to
+// builtAt for fights not stored yet. ?bom=1 starts the file with a
+// UTF-8 byte order mark.
+func writeFightsCSV(c *gin.Context, fights []models.Fight, builtAt time.Time) error {
+	if flagSet(c.Query("bom")) {
+		if _, err := io.WriteString(c.Writer, utf8BOM); err != nil {
+			return err
+		}
+	}
+
+	writer := csv.NewWriter(c.Writer)
+	if err := writer.Write(exportCSVHeader); err != nil {
+		return err
+	}
+
+	for i, fight := range fights {
+		if err := writer.Write(exportCSVRecord(fight, builtAt)); err != nil {
+			return err
+		}
+		if (i+1)%exportFlushRows == 0 {
+			writer.Flush()
+			if err := writer.Error(); err != nil {
+				return err
+			}
+			c.Writer.Flush()
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
+
+// exportID returns the ID of an exported fight
+// Fights of the snapshot always carry one; it is derived from the natural
+// key for any other fight, so the id column of an export is never empty.
+func exportID(fight models.Fight) string {
+	if fight.ID != "" {
+		return fight.ID
+	}
+	if fight.Key != "" {
+		return models.FightID(fight.Key)
+	}
+
+	return models.FightID(fight.NaturalKey())
+}
+
+// exportCSVRecord returns the CSV row of a fight
+func exportCSVRecord(fight models.Fight, builtAt time.Time) []string {
+	parsedAt := builtAt
+	if fight.ParsedAt != nil {
+		parsedAt = *fight.ParsedAt
+	}
+
+	return []string{
+		exportID(fight), fight.Date, fight.Fighter1, fight.Fighter2,
+		fight.Result, fight.Location, parsedAt.UTC().Format(time.RFC3339),
+	}
+}
@@ -0,0 +1,94 @@
+package api
+
+import (
+	"encoding/csv"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"easypars/models"
+	"easypars/pkg/apitypes"
+)
+
+func TestExportCSVRoundTrip(t *testing.T) {
+	router := newTestRouter(t, readTestdata(t, "export.html"), Dependencies{})
+
+	var served apitypes.FightsResponse
+	decodeJSON(t, serve(router, http.MethodGet, "/api/fights?limit=100", ""), &served)
+	if len(served.Data) != 3 {
+		t.Fatalf("served %d fights, want 3", len(served.Data))
+	}
+
+	rec := serve(router, http.MethodGet, "/api/fights/export?format=csv&bom=1", "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("export = %d %s, want 200", rec.Code, rec.Body)
+	}
+	if got := rec.Header().Get("Content-Disposition"); !strings.HasPrefix(got, `attachment; filename="fights-`) {
+		t.Errorf("Content-Disposition = %q, want an attachment", got)
+	}
+	body := rec.Body.String()
+	if !strings.HasPrefix(body, utf8BOM) {
+		t.Fatalf("export does not start with the BOM: %q", body[:min(len(body), 20)])
+	}
+
+	records, err := csv.NewReader(strings.NewReader(strings.TrimPrefix(body, utf8BOM))).ReadAll()
+	if err != nil {
+		t.Fatalf("parsing the export back: %v", err)
+	}
+	if got := strings.Join(records[0], ","); got != strings.Join(exportCSVHeader, ",") {
+		t.Errorf("header = %q, want %q", got, strings.Join(exportCSVHeader, ","))
+	}
+	rows := records[1:]
+	if len(rows) != len(served.Data) {
+		t.Fatalf("exported %d rows, want %d", len(rows), len(served.Data))
+	}
+	for i, fight := range served.Data {
+		want := []string{fight.ID, fight.Date, fight.Fighter1, fight.Fighter2, fight.Result, fight.Location}
+		if got := rows[i][:6]; strings.Join(got, "\x00") != strings.Join(want, "\x00") {
+			t.Errorf("row %d = %q, want %q", i, got, want)
+		}
+		if rows[i][0] == "" {
+			t.Errorf("row %d has an empty id", i)
+		}
+		if _, err := time.Parse(time.RFC3339, rows[i][6]); err != nil {
+			t.Errorf("row %d parsed_at %q: %v", i, rows[i][6], err)
+		}
+	}
+}
+
+func TestExportCSVRecordFillsID(t *testing.T) {
+	builtAt := time.Date(2024, time.June, 10, 12, 0, 0, 0, time.UTC)
+	fight := models.Fight{
+		Date:     "2024-06-01",
+		Fighter1: "Juan \"El Gallo\" Estrada",
+		Fighter2: "Harrison,\nJr.",
+		Location: "Riyadh",
+	}
+
+	// A fight without key nor ID gets the ID of its natural key
+	record := exportCSVRecord(fight, builtAt)
+	if want := models.FightID(fight.NaturalKey()); record[0] != want {
+		t.Errorf("id = %q, want %q", record[0], want)
+	}
+	fight.AssignKey()
+	if got := exportCSVRecord(fight, builtAt)[0]; got != fight.ID {
+		t.Errorf("id = %q, want the fight ID %q", got, fight.ID)
+	}
+
+	// Quotes, commas and newlines survive the round trip
+	var out strings.Builder
+	writer := csv.NewWriter(&out)
+	writer.Write(record)
+	writer.Flush()
+	parsed, err := csv.NewReader(strings.NewReader(out.String())).Read()
+	if err != nil {
+		t.Fatalf("parsing the record back: %v", err)
+	}
+	if strings.Join(parsed, "\x00") != strings.Join(record, "\x00") {
+		t.Errorf("round trip = %q, want %q", parsed, record)
+	}
+	if record[6] != "2024-06-10T12:00:00Z" {
+		t.Errorf("parsed_at = %q, want the build time", record[6])
+	}
+}
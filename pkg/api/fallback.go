@@ -0,0 +1,151 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"easypars/pkg/snapshot"
+
+	"github.com/gin-gonic/gin"
+)
+
+// DefaultFastResponseBudget is how long ?fallback=accepted waits for data
+const DefaultFastResponseBudget = 2 * time.Second
+
+// fallbackFreshness is how long the result of a background refresh is
+// served to fallback requests without starting a new one
+const fallbackFreshness = time.Minute
+
+// refreshJob is a snapshot refresh running in the background
+type refreshJob struct {
+	ref     string
+	started time.Time
+	done    chan struct{}
+
+	// Set before done is closed
+	snap     *snapshot.Snapshot
+	err      error
+	finished time.Time
+}
+
+// backgroundRefresher runs at most one background refresh at a time
+// Requests arriving while a refresh runs join it instead of starting another
+type backgroundRefresher struct {
+	mu      sync.Mutex
+	seq     int
+	current *refreshJob
+	// last is the most recently finished job
+	last *refreshJob
+}
+
+// join returns the running job or starts a new one
+// The refresh runs on a context detached from the request, so it completes
+// and fills the snapshot store even when the client goes away
+func (r *backgroundRefresher) join(ctx context.Context, refresh func(context.Context) (*snapshot.Snapshot, error)) *refreshJob {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.current != nil {
+		return r.current
+	}
+
+	r.seq++
+	job := &refreshJob{
+		ref:     fmt.Sprintf("refresh-%d-%d", time.Now().Unix(), r.seq),
+		started: time.Now(),
+		done:    make(chan struct{}),
+	}
+	r.current = job
+
+	go func() {
+		snap, err := refresh(context.WithoutCancel(ctx))
+
+		r.mu.Lock()
+		job.snap, job.err, job.finished = snap, err, time.Now()
+		r.current = nil
+		r.last = job
+		r.mu.Unlock()
+		close(job.done)
+	}()
+
+	return job
+}
+
+// fresh returns the snapshot of the last finished job when it is recent
+// and no other refresh is running
+func (r *backgroundRefresher) fresh(now time.Time) (*snapshot.Snapshot, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.current != nil || r.last == nil || r.last.err != nil || r.last.snap == nil {
+		return nil, false
+	}
+	if now.Sub(r.last.finished) > fallbackFreshness {
+		return nil, false
+	}
+
+	return r.last.snap, true
+}
+
+// retryAfter estimates the seconds until the job finishes
+// The duration of the previous refresh is the estimate, at least one second
+func (r *backgroundRefresher) retryAfter(job *refreshJob, now time.Time) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	expected := DefaultFastResponseBudget
+	if r.last != nil && !r.last.finished.IsZero() {
+		expected = r.last.finished.Sub(r.last.started)
+	}
+	remaining := expected - now.Sub(job.started)
+
+	return max(1, int(math.Ceil(remaining.Seconds())))
+}
+
+// fallbackSnapshot returns the snapshot for a ?fallback=accepted request
+// When the data is not ready within the fast response budget it writes a
+// 202 response pointing to the background refresh and returns false.
+func (h *handler) fallbackSnapshot(c *gin.Context) (*snapshot.Snapshot, bool, error) {
+	now := time.Now()
+	if snap, ok := h.refresher.fresh(now); ok {
+		return snap, true, nil
+	}
+
+	job := h.refresher.join(c.Request.Context(), h.refreshSnapshot)
+
+	timer := time.NewTimer(h.fastResponseBudget())
+	defer timer.Stop()
+
+	select {
+	case <-job.done:
+		return job.snap, true, job.err
+	case <-timer.C:
+	case <-c.Request.Context().Done():
+		return nil, false, c.Request.Context().Err()
+	}
+
+	retryAfter := h.refresher.retryAfter(job, time.Now())
+	c.Header("Retry-After", strconv.Itoa(retryAfter))
+	c.JSON(http.StatusAccepted, gin.H{
+		"status":      "parsing",
+		"message":     "Fight data is being loaded, retry later",
+		"retry_after": retryAfter,
+		"job_ref":     job.ref,
+	})
+
+	return nil, false, nil
+}
+
+// fastResponseBudget returns the configured wait of fallback requests
+func (h *handler) fastResponseBudget() time.Duration {
+	if h.deps.FastResponseBudget > 0 {
+		return h.deps.FastResponseBudget
+	}
+
+	return DefaultFastResponseBudget
+}
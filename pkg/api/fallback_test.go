@@ -0,0 +1,143 @@
+package api
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"easypars/pkg/clock"
+	"easypars/pkg/parser"
+
+	"github.com/gin-gonic/gin"
+)
+
+// slowSource serves the page once it is opened, counting the requests
+type slowSource struct {
+	*httptest.Server
+	hits    atomic.Int32
+	release chan struct{}
+	once    sync.Once
+}
+
+// open lets the waiting and later requests through
+func (s *slowSource) open() {
+	s.once.Do(func() { close(s.release) })
+}
+
+func newSlowSource(t *testing.T, page string) *slowSource {
+	t.Helper()
+
+	src := &slowSource{release: make(chan struct{})}
+	src.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		src.hits.Add(1)
+		<-src.release
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		io.WriteString(w, page)
+	}))
+	// Unblock a handler still waiting, or Close waits for it forever
+	t.Cleanup(func() {
+		src.open()
+		src.Close()
+	})
+
+	return src
+}
+
+// newFallbackRouter returns the router of the source with a short fast
+// response budget
+func newFallbackRouter(url string) *gin.Engine {
+	p := parser.NewParser(url + "/")
+	p.Clock = clock.Fixed{Time: testNow}
+
+	return SetupRouter(Dependencies{Parser: p, FastResponseBudget: 20 * time.Millisecond})
+}
+
+// acceptedBody is the body of a 202 fallback response
+type acceptedBody struct {
+	Status     string `json:"status"`
+	RetryAfter int    `json:"retry_after"`
+	JobRef     string `json:"job_ref"`
+}
+
+func TestFallbackSlowSourceAnswersAcceptedThenData(t *testing.T) {
+	src := newSlowSource(t, readTestdata(t, "upcoming.html"))
+	router := newFallbackRouter(src.URL)
+
+	// The client of the first request goes away after its 202; the parse
+	// it started keeps running
+	ctx, cancel := context.WithCancel(context.Background())
+	req := httptest.NewRequest(http.MethodGet, "/api/fights?fallback=accepted", nil).WithContext(ctx)
+	first := httptest.NewRecorder()
+	router.ServeHTTP(first, req)
+	cancel()
+
+	if first.Code != http.StatusAccepted {
+		t.Fatalf("first request = %d %s, want 202", first.Code, first.Body)
+	}
+	var accepted acceptedBody
+	decodeJSON(t, first, &accepted)
+	if accepted.Status != "parsing" || accepted.JobRef == "" || accepted.RetryAfter < 1 {
+		t.Errorf("202 body = %+v, want the parsing status, a job reference and retry_after", accepted)
+	}
+	if first.Header().Get("Retry-After") == "" {
+		t.Error("202 without Retry-After")
+	}
+
+	// A retry before the data is ready joins the same run
+	second := serve(router, http.MethodGet, "/api/fights?fallback=accepted", "")
+	var again acceptedBody
+	decodeJSON(t, second, &again)
+	if second.Code != http.StatusAccepted || again.JobRef != accepted.JobRef {
+		t.Errorf("second request = %d with job %q, want 202 with job %q", second.Code, again.JobRef, accepted.JobRef)
+	}
+
+	src.open()
+	var ready *httptest.ResponseRecorder
+	waitFor(t, "the background parse to finish", func() bool {
+		ready = serve(router, http.MethodGet, "/api/fights?fallback=accepted", "")
+		return ready.Code != http.StatusAccepted
+	})
+	if ready.Code != http.StatusOK {
+		t.Fatalf("request after the parse = %d %s, want 200", ready.Code, ready.Body)
+	}
+	var body struct {
+		Count int `json:"count"`
+	}
+	decodeJSON(t, ready, &body)
+	if body.Count == 0 {
+		t.Error("200 without fights")
+	}
+	if hits := src.hits.Load(); hits != 1 {
+		t.Errorf("the source got %d requests, want a single background run", hits)
+	}
+}
+
+func TestFallbackFastSourceAnswersAtOnce(t *testing.T) {
+	src := newSlowSource(t, readTestdata(t, "upcoming.html"))
+	src.open()
+	router := newFallbackRouter(src.URL)
+
+	rec := serve(router, http.MethodGet, "/api/fights?fallback=accepted", "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("request to a fast source = %d %s, want 200", rec.Code, rec.Body)
+	}
+	if hits := src.hits.Load(); hits != 1 {
+		t.Errorf("the source got %d requests, want 1", hits)
+	}
+}
+
+func TestWithoutFallbackTheRequestWaits(t *testing.T) {
+	src := newSlowSource(t, readTestdata(t, "upcoming.html"))
+	router := newFallbackRouter(src.URL)
+
+	// Released well after the fast response budget
+	time.AfterFunc(100*time.Millisecond, src.open)
+	if rec := serve(router, http.MethodGet, "/api/fights", ""); rec.Code != http.StatusOK {
+		t.Errorf("request without fallback = %d, want 200 once the source answers", rec.Code)
+	}
+}
@@ -0,0 +1,146 @@
+package api
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"easypars/pkg/contract"
+	"easypars/pkg/feed"
+	"easypars/pkg/render"
+
+	"github.com/gin-gonic/gin"
+)
+
+// DefaultFeedItems is the number of results in /api/fights/feed.xml when
+// none is configured
+const DefaultFeedItems = 50
+
+// feedPath is the path of the results feed
+const feedPath = "/api/fights/feed.xml"
+
+// feedItems returns the number of results of the feed
+func (h *handler) feedItems() int {
+	if h.deps.FeedItems > 0 {
+		return h.deps.FeedItems
+	}
+
+	return DefaultFeedItems
+}
+
+// handleGetFeed handles GET requests to /api/fights/feed.xml
+// Returns the latest fight results as an RSS 2.0 feed, or as Atom when the
+// Accept header prefers application/atom+xml; ?format=rss or ?format=atom
+// overrides the header. The filter parameters of /api/fights select the
+// fights, the newest results make the feed (see feed.Latest).
+func (h *handler) handleGetFeed(c *gin.Context) {
+	if err := validateFightsParams(c.Request.URL.Query(), false); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "invalid_params",
+			"message": err.Error(),
+		})
+		return
+	}
+	atom, ok := feedFormat(c.Query("format"), c.GetHeader("Accept"))
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "invalid_params",
+			"message": `invalid parameter "format": must be one of [rss atom]`,
+		})
+		return
+	}
+	filters, err := h.filterLayers(c)
+	if err != nil {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error":   "forbidden",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	snap, cached, err := h.cachedSnapshot(c.Request.Context(), flagSet(c.Query("refresh")))
+	if abortIfClientGone(c, err) {
+		return
+	}
+	if err != nil {
+		slog.ErrorContext(c.Request.Context(), "Failed to load fights for the feed", "error", err)
+		h.respondError(c, err, "parse_error", "Failed to load fight data")
+		return
+	}
+	setServerTiming(c, snap)
+	cached.setHeaders(c)
+
+	fights, _ := filters.apply(snap.View())
+	fights = feed.Latest(fights, h.feedItems())
+
+	// The published fights must keep the model invariants
+	if err := h.deps.Contract.Check(contract.BoundaryAPI, fights); err != nil {
+		h.respondError(c, err, "parse_error", "Failed to load fight data")
+		return
+	}
+
+	origin := requestOrigin(c)
+	channel := feed.Channel{
+		Title:       "EasyPars fight results",
+		Description: "Latest boxing and MMA fight results",
+		Link:        origin + "/",
+		SelfURL:     origin + feedPath,
+		Updated:     snap.BuiltAt,
+	}
+	items := make([]feed.Item, 0, len(fights))
+	for _, fight := range fights {
+		link := origin + "/api/fights/" + url.PathEscape(fight.Key)
+		if fight.Slug != "" {
+			link = origin + "/api/fights/by-slug/" + url.PathEscape(fight.Slug)
+		}
+		items = append(items, feed.NewItem(fight, link))
+	}
+
+	write, contentType := feed.WriteRSS, feed.RSSContentType
+	if atom {
+		write, contentType = feed.WriteAtom, feed.AtomContentType
+	}
+	c.Header("Vary", "Accept")
+	c.Header("Content-Type", contentType)
+	c.Status(http.StatusOK)
+	if err := write(c.Writer, channel, items); err != nil {
+		// The status line is already written, the error can only be recorded
+		_ = c.Error(fmt.Errorf("error writing the results feed: %w", err))
+	}
+}
+
+// feedFormat reports whether the feed is served as Atom rather than RSS
+// ?format= takes priority; without it Atom is served when the Accept header
+// rates application/atom+xml above application/rss+xml. ok is false for an
+// unknown ?format=.
+func feedFormat(format, accept string) (atom, ok bool) {
+	switch format {
+	case "rss":
+		return false, true
+	case "atom":
+		return true, true
+	case "":
+	default:
+		return false, false
+	}
+
+	preferred, _ := render.Preferred(accept, "application/rss+xml", "application/atom+xml")
+	return preferred == "application/atom+xml", true
+}
+
+// requestOrigin returns the scheme and host the request was sent to, for
+// the absolute links of the feed
+// Behind a TLS-terminating proxy the scheme comes from X-Forwarded-Proto.
+func requestOrigin(c *gin.Context) string {
+	scheme := "http"
+	if c.Request.TLS != nil {
+		scheme = "https"
+	}
+	if proto := strings.ToLower(c.GetHeader("X-Forwarded-Proto")); proto == "http" || proto == "https" {
+		scheme = proto
+	}
+
+	return scheme + "://" + c.Request.Host
+}
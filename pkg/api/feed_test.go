@@ -0,0 +1,84 @@
+package api
+
+import (
+	"encoding/xml"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// feedTitles returns the item titles of an RSS or Atom feed
+func feedTitles(t *testing.T, body string) []string {
+	t.Helper()
+
+	var doc struct {
+		Items   []string `xml:"channel>item>title"`
+		Entries []string `xml:"entry>title"`
+	}
+	if err := xml.Unmarshal([]byte(body), &doc); err != nil {
+		t.Fatalf("decoding the feed: %v\n%s", err, body)
+	}
+
+	return append(doc.Items, doc.Entries...)
+}
+
+func TestGetFeed(t *testing.T) {
+	tests := []struct {
+		name        string
+		target      string
+		accept      string
+		items       int
+		status      int
+		contentType string
+		root        string
+		first       int
+	}{
+		{"rss by default", "/api/fights/feed.xml", "", 0, http.StatusOK, "application/rss+xml", "<rss", 5},
+		{"atom by Accept", "/api/fights/feed.xml", "application/atom+xml", 0, http.StatusOK, "application/atom+xml", "<feed", 5},
+		{"rss preferred", "/api/fights/feed.xml", "application/rss+xml, application/atom+xml;q=0.5", 0, http.StatusOK, "application/rss+xml", "<rss", 5},
+		{"format overrides Accept", "/api/fights/feed.xml?format=rss", "application/atom+xml", 0, http.StatusOK, "application/rss+xml", "<rss", 5},
+		{"atom by format", "/api/fights/feed.xml?format=atom", "", 0, http.StatusOK, "application/atom+xml", "<feed", 5},
+		{"configured size", "/api/fights/feed.xml", "", 2, http.StatusOK, "application/rss+xml", "<rss", 2},
+		{"filtered", "/api/fights/feed.xml?search=Usyk", "", 0, http.StatusOK, "application/rss+xml", "<rss", 1},
+		{"unknown format", "/api/fights/feed.xml?format=json", "", 0, http.StatusBadRequest, "", "", 0},
+		{"invalid filter", "/api/fights/feed.xml?limit=abc", "", 0, http.StatusBadRequest, "", "", 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := newTestRouter(t, readTestdata(t, "results.html"), Dependencies{FeedItems: tt.items})
+			var headers []string
+			if tt.accept != "" {
+				headers = []string{"Accept", tt.accept}
+			}
+			rec := serve(router, http.MethodGet, tt.target, "", headers...)
+			if rec.Code != tt.status {
+				t.Fatalf("GET %s = %d %s, want %d", tt.target, rec.Code, rec.Body, tt.status)
+			}
+			if tt.status != http.StatusOK {
+				return
+			}
+			if got := rec.Header().Get("Content-Type"); !strings.HasPrefix(got, tt.contentType) {
+				t.Errorf("Content-Type = %q, want %s", got, tt.contentType)
+			}
+			if !strings.Contains(rec.Body.String(), tt.root) {
+				t.Errorf("body = %s, want a %s document", rec.Body, tt.root)
+			}
+			if titles := feedTitles(t, rec.Body.String()); len(titles) != tt.first {
+				t.Errorf("feed has %d items %q, want %d", len(titles), titles, tt.first)
+			}
+		})
+	}
+}
+
+func TestGetFeedNewestFirst(t *testing.T) {
+	router := newTestRouter(t, readTestdata(t, "results.html"), Dependencies{FeedItems: 3})
+
+	rec := serve(router, http.MethodGet, "/api/fights/feed.xml", "")
+	titles := feedTitles(t, rec.Body.String())
+	// The Canelo fight of the 22nd is announced, not a result
+	want := []string{"Joshua def. Ngannou (KO 2)", "Bivol def. Beterbiev (UD)", "Zhang def. Wilder (KO 5)"}
+	if !reflect.DeepEqual(titles, want) {
+		t.Errorf("feed items of %q, want %q", titles, want)
+	}
+}
@@ -0,0 +1,101 @@
+package api
+
+import (
+	"log/slog"
+	"net/http"
+	"net/url"
+
+	"easypars/models"
+	"easypars/pkg/apitypes"
+	"easypars/pkg/contract"
+	"easypars/pkg/snapshot"
+
+	"github.com/gin-gonic/gin"
+)
+
+// handleGetFight handles GET requests to /api/fights/:id
+// The id is the fight slug, its ID or its natural key
+// ("2024-05-18|tyson fury|oleksandr usyk", URL-escaped). The fight is looked
+// up in the served snapshot, which is parsed first when there is none. A
+// slug the fight had before a fighter was renamed answers 308.
+func (h *handler) handleGetFight(c *gin.Context) {
+	id := c.Param("id")
+	if id == "" || len(id) > maxSlugLength {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "invalid_params",
+			"message": `invalid parameter "id": must be a fight slug, a fight ID or a fight key`,
+		})
+		return
+	}
+
+	snap, err := h.refreshSnapshot(c.Request.Context())
+	if abortIfClientGone(c, err) {
+		return
+	}
+	if err != nil {
+		slog.ErrorContext(c.Request.Context(), "Failed to load fights", "error", err)
+		h.respondError(c, err, "parse_error", "Failed to load fight data")
+		return
+	}
+
+	fight, ok := fightByID(snap, id)
+	if !ok {
+		fight, ok = fightByKey(snap, id)
+	}
+	if !ok {
+		if current, renamed := snap.RenamedSlug(id); renamed {
+			location := "/api/fights/" + url.PathEscape(current)
+			if query := c.Request.URL.RawQuery; query != "" {
+				location += "?" + query
+			}
+			c.Header("Location", location)
+			c.JSON(http.StatusPermanentRedirect, gin.H{
+				"message": "The fight has a new slug",
+				"slug":    current,
+			})
+			return
+		}
+		c.JSON(http.StatusNotFound, gin.H{
+			"error":   "not_found",
+			"message": "No fight with id " + id,
+		})
+		return
+	}
+
+	h.respondFight(c, snap, fight)
+}
+
+// fightByKey finds a fight by its natural key
+func fightByKey(snap *snapshot.Snapshot, key string) (models.Fight, bool) {
+	view := snap.View()
+	for i := 0; i < view.Len(); i++ {
+		if fight := view.At(i); fight.Key == key {
+			return fight, true
+		}
+	}
+
+	return models.Fight{}, false
+}
+
+// respondFight writes a single fight with the page it was parsed from and
+// the time the served data was built
+func (h *handler) respondFight(c *gin.Context, snap *snapshot.Snapshot, fight models.Fight) {
+	// The serialized fight must keep the model invariants
+	if err := h.deps.Contract.Check(contract.BoundaryAPI, []models.Fight{fight}); err != nil {
+		h.respondError(c, err, "parse_error", "Failed to load fight data")
+		return
+	}
+
+	source := fight.SourceURL
+	if source == "" && h.deps.Parser != nil {
+		source = h.deps.Parser.EffectiveBaseURL()
+	}
+
+	setServerTiming(c, snap)
+	c.JSON(http.StatusOK, apitypes.FightResponse{
+		Message:  "Fight retrieved successfully",
+		Data:     localizeCountries([]models.Fight{fight}, preferredLanguage(c.GetHeader("Accept-Language")))[0],
+		Source:   source,
+		ParsedAt: snap.BuiltAt,
+	})
+}
@@ -0,0 +1,192 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"easypars/models"
+	"easypars/pkg/apitypes"
+	"easypars/pkg/cache"
+	"easypars/pkg/clock"
+	"easypars/pkg/newslink"
+	"easypars/pkg/parser"
+
+	"github.com/gin-gonic/gin"
+)
+
+// stubFightStore is a fight cache holding a fixed parse result, counting
+// the lookups; an empty stub reports every key as missing
+type stubFightStore struct {
+	result *parser.ParseResult
+	gets   atomic.Int32
+}
+
+func (s *stubFightStore) Get(_ context.Context, _ string) (cache.Entry[*parser.ParseResult], bool, error) {
+	s.gets.Add(1)
+	if s.result == nil {
+		return cache.Entry[*parser.ParseResult]{}, false, nil
+	}
+	return cache.Entry[*parser.ParseResult]{Value: s.result, StoredAt: testNow}, true, nil
+}
+
+func (s *stubFightStore) Set(context.Context, string, cache.Entry[*parser.ParseResult]) error {
+	return nil
+}
+
+func (s *stubFightStore) Delete(context.Context, string) error {
+	return nil
+}
+
+// storedFights are the fights of the stubbed store
+func storedFights() []models.Fight {
+	fights := []models.Fight{
+		{Date: "2024-05-18", Fighter1: "Oleksandr Usyk", Fighter2: "Tyson Fury", Location: "Riyadh", Result: "SD", Status: models.StatusCompleted, SourceURL: "https://vringe.example/results/2024-05"},
+		{Date: "2024-06-01", Fighter1: "Dmitry Bivol", Fighter2: "Malik Zinad", Location: "Riyadh", Result: "UD", Status: models.StatusCompleted},
+	}
+	for i := range fights {
+		fights[i].AssignKey()
+	}
+
+	return fights
+}
+
+// failingSource counts its requests and fails them, so a test notices
+// when the handler goes past the stubbed store
+func failingSource(t *testing.T) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+
+	var hits atomic.Int32
+	src := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+	}))
+	t.Cleanup(src.Close)
+
+	return src, &hits
+}
+
+// newStoreRouter returns a router serving the fights of the stubbed store
+func newStoreRouter(t *testing.T, store *stubFightStore, deps Dependencies) (*gin.Engine, *atomic.Int32) {
+	t.Helper()
+
+	src, hits := failingSource(t)
+	deps.Parser = parser.NewParser(src.URL + "/")
+	deps.Parser.Clock = clock.Fixed{Time: testNow}
+	deps.FightCache = store
+
+	return SetupRouter(deps), hits
+}
+
+func TestGetFightFromStore(t *testing.T) {
+	fights := storedFights()
+	store := &stubFightStore{result: &parser.ParseResult{Fights: fights}}
+	router, hits := newStoreRouter(t, store, Dependencies{})
+	usyk := fights[0]
+
+	tests := []struct {
+		name   string
+		id     string
+		status int
+		code   string
+		key    string
+	}{
+		{"by ID", usyk.ID, http.StatusOK, "", usyk.Key},
+		{"by key", url.PathEscape(usyk.Key), http.StatusOK, "", usyk.Key},
+		{"unknown ID", models.FightID("2000-01-01|a|b|"), http.StatusNotFound, "not_found", ""},
+		{"unknown slug", "nobody-vs-nobody-2000-01-01", http.StatusNotFound, "not_found", ""},
+		{"not an ID", "zzzzzzzzzzzz", http.StatusNotFound, "not_found", ""},
+		{"blank", "%20", http.StatusNotFound, "not_found", ""},
+		{"key of another date", url.PathEscape(strings.Replace(usyk.Key, "2024-05-18", "2024-05-19", 1)), http.StatusNotFound, "not_found", ""},
+		{"overlong", strings.Repeat("a", maxSlugLength+1), http.StatusBadRequest, "invalid_params", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := serve(router, http.MethodGet, "/api/fights/"+tt.id, "")
+			if rec.Code != tt.status {
+				t.Fatalf("GET /api/fights/%s = %d %s, want %d", tt.id, rec.Code, rec.Body, tt.status)
+			}
+			if tt.code != "" {
+				if code := errorCode(t, rec); code != tt.code {
+					t.Errorf("error = %q, want %q", code, tt.code)
+				}
+				return
+			}
+
+			var body apitypes.FightResponse
+			decodeJSON(t, rec, &body)
+			if body.Data.Key != tt.key {
+				t.Errorf("fight = %s, want %s", body.Data.Key, tt.key)
+			}
+			if body.Source != usyk.SourceURL {
+				t.Errorf("source = %q, want %q", body.Source, usyk.SourceURL)
+			}
+			if body.ParsedAt.IsZero() {
+				t.Error("parsed_at is missing")
+			}
+		})
+	}
+
+	if got := hits.Load(); got != 0 {
+		t.Errorf("the source got %d requests, want every fight served from the store", got)
+	}
+}
+
+func TestGetFightSourceDefaultsToTheParsedPage(t *testing.T) {
+	fights := storedFights()
+	store := &stubFightStore{result: &parser.ParseResult{Fights: fights}}
+	router, _ := newStoreRouter(t, store, Dependencies{})
+
+	rec := serve(router, http.MethodGet, "/api/fights/"+fights[1].ID, "")
+	var body apitypes.FightResponse
+	decodeJSON(t, rec, &body)
+	if rec.Code != http.StatusOK || !strings.HasPrefix(body.Source, "http://127.0.0.1:") {
+		t.Errorf("GET of a fight without a source page = %d with source %q, want 200 with the parser base URL", rec.Code, body.Source)
+	}
+}
+
+func TestGetFightFromStoreWithRelatedNews(t *testing.T) {
+	news, err := newslink.NewStore("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	news.Publish([]models.NewsItem{
+		{Title: "Usyk and Fury meet on May 18 in Riyadh", URL: "https://news.example/preview", PublishedAt: testNow.AddDate(0, -1, 0)},
+		{Title: "Bivol returns in June", URL: "https://news.example/bivol", PublishedAt: testNow.AddDate(0, -1, 0)},
+	})
+	fights := storedFights()
+	store := &stubFightStore{result: &parser.ParseResult{Fights: fights}}
+	router, _ := newStoreRouter(t, store, Dependencies{News: news})
+
+	rec := serve(router, http.MethodGet, "/api/fights/"+fights[0].ID, "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("GET /api/fights/%s = %d %s, want 200", fights[0].ID, rec.Code, rec.Body)
+	}
+	var body apitypes.FightResponse
+	decodeJSON(t, rec, &body)
+	if want := []string{"https://news.example/preview"}; !reflect.DeepEqual(body.Data.RelatedNews, want) {
+		t.Errorf("related_news = %q, want %q", body.Data.RelatedNews, want)
+	}
+}
+
+func TestGetFightWithAnEmptyStoreParses(t *testing.T) {
+	store := &stubFightStore{}
+	router, hits := newStoreRouter(t, store, Dependencies{})
+
+	// The store is empty: the handler parses the source, which fails
+	rec := serve(router, http.MethodGet, "/api/fights/"+storedFights()[0].ID, "")
+	if rec.Code == http.StatusOK || rec.Code == http.StatusNotFound {
+		t.Errorf("GET with an empty store and a failing source = %d, want an error", rec.Code)
+	}
+	if hits.Load() == 0 {
+		t.Error("the source was not requested although the store is empty")
+	}
+	if store.gets.Load() == 0 {
+		t.Error("the store was not asked")
+	}
+}
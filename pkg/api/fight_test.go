@@ -0,0 +1,83 @@
+package api
+
+import (
+	"net/http"
+	"net/url"
+	"reflect"
+	"testing"
+
+	"easypars/models"
+	"easypars/pkg/apitypes"
+	"easypars/pkg/newslink"
+)
+
+func TestFightIDsAreStableAcrossParses(t *testing.T) {
+	page := readTestdata(t, "results.html")
+
+	var first, second apitypes.FightsResponse
+	decodeJSON(t, serve(newTestRouter(t, page, Dependencies{}), http.MethodGet, "/api/fights?limit=100", ""), &first)
+	decodeJSON(t, serve(newTestRouter(t, page, Dependencies{}), http.MethodGet, "/api/fights?limit=100", ""), &second)
+	if len(first.Data) == 0 || len(first.Data) != len(second.Data) {
+		t.Fatalf("parsed %d and %d fights, want the same non-empty list", len(first.Data), len(second.Data))
+	}
+
+	ids := make(map[string]string, len(first.Data))
+	for i, fight := range first.Data {
+		if fight.ID != models.FightID(fight.Key) {
+			t.Errorf("fight %s has the ID %q, want %q", fight.Key, fight.ID, models.FightID(fight.Key))
+		}
+		if other, ok := ids[fight.ID]; ok {
+			t.Errorf("%s and %s share the ID %s", fight.Key, other, fight.ID)
+		}
+		ids[fight.ID] = fight.Key
+		if again := second.Data[i]; again.Key != fight.Key || again.ID != fight.ID {
+			t.Errorf("fight %d is %s (%s) after a new parse, want %s (%s)", i, again.Key, again.ID, fight.Key, fight.ID)
+		}
+	}
+}
+
+func TestGetFightByID(t *testing.T) {
+	router := newTestRouter(t, readTestdata(t, "results.html"), Dependencies{})
+	want := models.Fight{Date: "2024-05-18", Fighter1: "Fury", Fighter2: "Usyk", Location: "Riyadh"}
+	want.AssignKey()
+
+	for _, id := range []string{want.ID, url.PathEscape(want.Key)} {
+		rec := serve(router, http.MethodGet, "/api/fights/"+id, "")
+		if rec.Code != http.StatusOK {
+			t.Fatalf("GET /api/fights/%s = %d %s, want 200", id, rec.Code, rec.Body)
+		}
+		var body apitypes.FightResponse
+		decodeJSON(t, rec, &body)
+		if body.Data.Key != want.Key || body.Data.ID != want.ID {
+			t.Errorf("GET /api/fights/%s = %s (%s), want %s (%s)", id, body.Data.Key, body.Data.ID, want.Key, want.ID)
+		}
+	}
+
+	// The unknown ID of a well formed fight is not found
+	unknown := models.FightID("2000-01-01|a|b|")
+	if rec := serve(router, http.MethodGet, "/api/fights/"+unknown, ""); rec.Code != http.StatusNotFound {
+		t.Errorf("GET of an unknown ID = %d, want 404", rec.Code)
+	}
+}
+
+func TestGetFightRelatedNews(t *testing.T) {
+	news, err := newslink.NewStore("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	news.Publish([]models.NewsItem{
+		{Title: "Usyk and Fury meet on May 18 in Riyadh", URL: "https://news.example/preview", PublishedAt: testNow.AddDate(0, -1, 0)},
+		{Title: "Fury arrives in Riyadh", URL: "https://news.example/arrival", PublishedAt: testNow.AddDate(0, -1, 0)},
+	})
+	router := newTestRouter(t, readTestdata(t, "results.html"), Dependencies{News: news})
+
+	rec := serve(router, http.MethodGet, "/api/fights/usyk-vs-fury-2024-05-18", "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("GET /api/fights/usyk-vs-fury-2024-05-18 = %d %s, want 200", rec.Code, rec.Body)
+	}
+	var body apitypes.FightResponse
+	decodeJSON(t, rec, &body)
+	if want := []string{"https://news.example/preview"}; !reflect.DeepEqual(body.Data.RelatedNews, want) {
+		t.Errorf("related_news = %v, want %v", body.Data.RelatedNews, want)
+	}
+}
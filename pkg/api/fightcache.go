@@ -0,0 +1,181 @@
+package api
+
+import (
+	"context"
+	"log/slog"
+	"math"
+	"strconv"
+	"time"
+
+	"easypars/pkg/cache"
+	"easypars/pkg/metrics"
+	"easypars/pkg/parser"
+	"easypars/pkg/snapshot"
+
+	"github.com/gin-gonic/gin"
+)
+
+// cacheStatus tells whether a snapshot was served from the fight cache
+type cacheStatus struct {
+	Cached bool
+	// Age is the age of the cached parse result
+	Age time.Duration
+}
+
+// ageSeconds returns the age in whole seconds
+func (s cacheStatus) ageSeconds() int64 {
+	return int64(math.Floor(s.Age.Seconds()))
+}
+
+// setHeaders sets the standard Age header of a cached response
+func (s cacheStatus) setHeaders(c *gin.Context) {
+	if s.Cached {
+		c.Header("Age", strconv.FormatInt(s.ageSeconds(), 10))
+	}
+}
+
+// fightCacheKey returns the key of the source in the fight cache
+func (h *handler) fightCacheKey() string {
+	return h.deps.Parser.BaseURL
+}
+
+// cachedSnapshot returns the snapshot of the cached parse result while it
+// is fresh, otherwise refreshes the snapshot (see sharedLoad)
+// While the scheduled refresh runs, the published snapshot is served as it
+// is; only an empty store makes a request parse the source.
+// The active snapshot is served as long as it was built from the cached
+// result; a result cached elsewhere, e.g. by another instance sharing the
+// cache, is built and published first. A snapshot whose expected change
+// has come (see expectedChangeCame) is refreshed even with a fresh cache
+// entry, so fight days are not served outdated results. force skips the
+// cache (?refresh=true).
+func (h *handler) cachedSnapshot(ctx context.Context, force bool) (*snapshot.Snapshot, cacheStatus, error) {
+	// The scheduled refresh keeps the published snapshot current
+	if snap, ok := h.refreshedSnapshot(); ok && !force {
+		return snap, cacheStatus{Cached: true, Age: time.Since(snap.BuiltAt)}, nil
+	}
+
+	if h.deps.FightCache == nil || h.deps.Parser == nil || force {
+		snap, err := h.sharedLoad(ctx)
+		return snap, cacheStatus{}, err
+	}
+
+	entry, ok, err := h.deps.FightCache.Get(ctx, h.fightCacheKey())
+	if err != nil {
+		slog.WarnContext(ctx, "Fight cache unavailable, parsing the source", "url", h.fightCacheKey(), "error", err)
+	}
+	metrics.CacheLookup(metrics.CacheFights, ok && entry.Value != nil)
+	if ok && entry.Value != nil {
+		snap := h.deps.Snapshots.Active()
+		if snap == nil || entry.StoredAt.UnixNano() != h.cachedAt.Load() {
+			snap = h.publishResult(entry.Value)
+			h.cachedAt.Store(entry.StoredAt.UnixNano())
+		}
+		if snap != nil && !expectedChangeCame(snap, h.now()) {
+			return snap, cacheStatus{Cached: true, Age: entry.Age(time.Now())}, nil
+		}
+	}
+
+	snap, err := h.sharedLoad(ctx)
+	return snap, cacheStatus{}, err
+}
+
+// sharedLoad refreshes the snapshot, joining a refresh of the same source
+// already running for another request (see snapshotFlights)
+func (h *handler) sharedLoad(ctx context.Context) (*snapshot.Snapshot, error) {
+	var key string
+	if h.deps.Parser != nil {
+		key = h.fightCacheKey()
+	}
+
+	return h.flights.do(ctx, key, h.lockedLoad)
+}
+
+// lockPollInterval is how often an instance waiting for the load lock of a
+// shared fight cache checks the cache
+const lockPollInterval = 200 * time.Millisecond
+
+// lockedLoad loads the snapshot under the load lock of a shared fight cache
+// (see cache.Locker), so a single instance of a deployment parses the
+// source while the others wait for its result in the cache
+// An instance that cannot get the lock within its TTL, e.g. because the
+// holder crashed, or cannot reach the lock at all parses the source itself.
+// Without a shared cache the snapshot is loaded directly.
+func (h *handler) lockedLoad(ctx context.Context) (*snapshot.Snapshot, error) {
+	locker, ok := h.deps.FightCache.(cache.Locker)
+	if !ok || h.deps.Parser == nil {
+		return h.loadSnapshot(ctx)
+	}
+
+	key := h.fightCacheKey()
+	waitStart := time.Now()
+	deadline := waitStart.Add(locker.LockTTL())
+	for waited := false; ; waited = true {
+		// The instance that held the lock has cached its result
+		if waited {
+			entry, ok, err := h.deps.FightCache.Get(ctx, key)
+			if err == nil && ok && entry.Value != nil && !entry.StoredAt.Before(waitStart) {
+				snap := h.publishResult(entry.Value)
+				h.cachedAt.Store(entry.StoredAt.UnixNano())
+				return snap, nil
+			}
+		}
+
+		release, acquired, err := locker.Lock(ctx, key)
+		if err != nil {
+			slog.WarnContext(ctx, "Fight cache lock unavailable, parsing the source", "url", key, "error", err)
+			return h.loadSnapshot(ctx)
+		}
+		if acquired {
+			defer release()
+			return h.loadSnapshot(ctx)
+		}
+		if time.Now().After(deadline) {
+			slog.WarnContext(ctx, "Fight cache lock not released, parsing the source", "url", key)
+			return h.loadSnapshot(ctx)
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(lockPollInterval):
+		}
+	}
+}
+
+// expectedChangeCame reports whether a change of the fights is expected
+// since the snapshot was built, e.g. the broadcast of a fight started
+// Expiry by the standard TTL is left to the cache.
+func expectedChangeCame(snap *snapshot.Snapshot, now time.Time) bool {
+	return snap.NextExpectedChange.Reason != snapshot.ChangeTTL && snap.Stale(now)
+}
+
+// cacheResult stores a result parsed from the source in the fight cache
+// and returns its StoredAt, zero when it was not stored
+// Stored fights served after a failed parse are not cached, so the source
+// is tried again by the next request.
+func (h *handler) cacheResult(ctx context.Context, result *parser.ParseResult) time.Time {
+	if h.deps.FightCache == nil || h.deps.Parser == nil {
+		return time.Time{}
+	}
+	if origin := result.Provenance.Origin; origin != parser.OriginSource && origin != parser.OriginCache {
+		return time.Time{}
+	}
+
+	entry := cache.Entry[*parser.ParseResult]{Value: result, StoredAt: time.Now()}
+	if err := h.deps.FightCache.Set(ctx, h.fightCacheKey(), entry); err != nil {
+		slog.WarnContext(ctx, "Parse result not cached", "url", h.fightCacheKey(), "error", err)
+		return time.Time{}
+	}
+
+	return entry.StoredAt
+}
+
+// freshCache reports whether the fight cache holds a fresh parse result
+func (h *handler) freshCache(ctx context.Context) bool {
+	if h.deps.FightCache == nil || h.deps.Parser == nil {
+		return false
+	}
+	_, ok, err := h.deps.FightCache.Get(ctx, h.fightCacheKey())
+	return ok && err == nil
+}
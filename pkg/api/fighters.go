@@ -0,0 +1,67 @@
+package api
+
+import (
+	"log/slog"
+	"net/http"
+
+	"easypars/models"
+	"easypars/pkg/apitypes"
+
+	"github.com/gin-gonic/gin"
+)
+
+// handleGetFighters handles GET requests to /api/fighters
+// Returns the fighters of the current data set with their fight count,
+// latest fight date and opponents; ?external_id=boxrec:NNNN looks a fighter
+// up by its ID in an external database, ?search= filters the names like
+// the fighter search of /api/fights
+// Future steps: Add pagination
+func (h *handler) handleGetFighters(c *gin.Context) {
+	source, id, byExternalID := "", "", c.Query("external_id") != ""
+	if byExternalID {
+		var ok bool
+		source, id, ok = models.ParseExternalID(c.Query("external_id"))
+		if !ok {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "invalid_params",
+				"message": `invalid parameter "external_id": must be "source:id", e.g. boxrec:447121`,
+			})
+			return
+		}
+	}
+
+	snap, err := h.refreshSnapshot(c.Request.Context())
+	if abortIfClientGone(c, err) {
+		return
+	}
+	if err != nil {
+		slog.ErrorContext(c.Request.Context(), "Failed to load fighters", "error", err)
+		h.respondError(c, err, "parse_error", "Failed to load fighter data")
+		return
+	}
+
+	fighters := snap.Fighters()
+	if byExternalID {
+		fighters = []models.Fighter{}
+		if fighter, ok := snap.FighterByExternalID(source, id); ok {
+			fighters = append(fighters, fighter)
+		}
+	}
+	if term := c.Query("search"); term != "" {
+		search := newNameSearch(term)
+		matched := make([]models.Fighter, 0)
+		for _, fighter := range fighters {
+			if search.matches(fighter.Name) {
+				matched = append(matched, fighter)
+			}
+		}
+		fighters = matched
+	}
+
+	setServerTiming(c, snap)
+	c.JSON(http.StatusOK, apitypes.FightersResponse{
+		Message: "List of fighters retrieved successfully",
+		Data:    fighters,
+		Count:   len(fighters),
+	})
+}
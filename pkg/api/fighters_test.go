@@ -0,0 +1,116 @@
+package api
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"easypars/pkg/apitypes"
+)
+
+// boxrecPage is a results page where some fighters link to their BoxRec page
+const boxrecPage = `<html><body><div class="month">Май 2024</div><table>
+<tr><td class="date">18</td><td class="place">Riyadh</td>
+<td class="boxer_1"><a href="https://boxrec.com/en/proboxer/447121">Oleksandr Usyk</a></td><td class="vs">SD</td>
+<td class="boxer_2"><a href="https://www.boxrec.com/proboxer/356831">Tyson Fury</a></td></tr>
+<tr><td class="date">25</td><td class="place">London</td>
+<td class="boxer_1">Daniel Dubois</td><td class="vs">KO 5</td>
+<td class="boxer_2"><a href="http://boxrec.com/boxer/659461">Filip Hrgovic</a></td></tr>
+</table></body></html>`
+
+func TestGetFighterByExternalID(t *testing.T) {
+	router := newTestRouter(t, boxrecPage, Dependencies{})
+
+	tests := []struct {
+		name   string
+		query  string
+		status int
+		want   []string
+	}{
+		{"boxrec ID", "external_id=boxrec:447121", http.StatusOK, []string{"Oleksandr Usyk"}},
+		{"legacy link", "external_id=boxrec:659461", http.StatusOK, []string{"Filip Hrgovic"}},
+		{"source in capitals", "external_id=BoxRec:356831", http.StatusOK, []string{"Tyson Fury"}},
+		{"unknown ID", "external_id=boxrec:1", http.StatusOK, []string{}},
+		{"unknown source", "external_id=other:447121", http.StatusOK, []string{}},
+		{"no source", "external_id=447121", http.StatusBadRequest, nil},
+		{"no ID", "external_id=boxrec:", http.StatusBadRequest, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := serve(router, http.MethodGet, "/api/fighters?"+tt.query, "")
+			if rec.Code != tt.status {
+				t.Fatalf("GET /api/fighters?%s = %d %s, want %d", tt.query, rec.Code, rec.Body, tt.status)
+			}
+			if tt.status != http.StatusOK {
+				if code := errorCode(t, rec); code != "invalid_params" {
+					t.Errorf("error = %q, want invalid_params", code)
+				}
+				return
+			}
+			if len(tt.want) == 0 && !strings.Contains(rec.Body.String(), `"data":[]`) {
+				t.Errorf("body = %s, want an empty data list", rec.Body)
+			}
+			var body apitypes.FightersResponse
+			decodeJSON(t, rec, &body)
+			names := []string{}
+			for _, fighter := range body.Data {
+				names = append(names, fighter.Name)
+			}
+			if strings.Join(names, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("fighters = %q, want %q", names, tt.want)
+			}
+		})
+	}
+}
+
+func TestFighterWithoutExternalIDs(t *testing.T) {
+	router := newTestRouter(t, boxrecPage, Dependencies{})
+
+	rec := serve(router, http.MethodGet, "/api/fighters?search=Dubois", "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("GET /api/fighters?search=Dubois = %d %s", rec.Code, rec.Body)
+	}
+	// The map is empty rather than null, so clients can index it
+	if !strings.Contains(rec.Body.String(), `"external_ids":{}`) {
+		t.Errorf("body = %s, want an empty external_ids object", rec.Body)
+	}
+
+	var fights apitypes.FightsResponse
+	decodeJSON(t, serve(router, http.MethodGet, "/api/fights", ""), &fights)
+	if len(fights.Data) == 0 || fights.Data[len(fights.Data)-1].Fighter1ExternalIDs["boxrec"] != "447121" {
+		t.Errorf("fights = %+v, want the BoxRec ID of Usyk attached to his fight", fights.Data)
+	}
+}
+
+func TestSearchFighters(t *testing.T) {
+	router := newTestRouter(t, boxrecPage, Dependencies{})
+
+	tests := []struct {
+		query string
+		want  []string
+	}{
+		{"", []string{"Daniel Dubois", "Filip Hrgovic", "Oleksandr Usyk", "Tyson Fury"}},
+		{"?search=usyk", []string{"Oleksandr Usyk"}},
+		{"?search=FURY", []string{"Tyson Fury"}},
+		{"?search=hrgović", []string{"Filip Hrgovic"}},
+		{"?search=wilder", []string{}},
+	}
+	for _, tt := range tests {
+		rec := serve(router, http.MethodGet, "/api/fighters"+tt.query, "")
+		var body apitypes.FightersResponse
+		decodeJSON(t, rec, &body)
+		names := []string{}
+		for _, fighter := range body.Data {
+			names = append(names, fighter.Name)
+		}
+		if rec.Code != http.StatusOK || strings.Join(names, ",") != strings.Join(tt.want, ",") || body.Count != len(tt.want) {
+			t.Errorf("GET /api/fighters%s = %d with %q (count %d), want %q", tt.query, rec.Code, names, body.Count, tt.want)
+		}
+	}
+
+	var body apitypes.FightersResponse
+	decodeJSON(t, serve(router, http.MethodGet, "/api/fighters?search=Dubois", ""), &body)
+	if len(body.Data) != 1 || body.Data[0].LastFightDate != "2024-05-25" || strings.Join(body.Data[0].Opponents, ",") != "Filip Hrgovic" {
+		t.Errorf("fighter = %+v, want Dubois with the last fight date and the opponent", body.Data)
+	}
+}
@@ -0,0 +1,93 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+// responseKeys returns the fight keys of a /api/fights response in order,
+// the fights of grouped responses in group order
+func responseKeys(t *testing.T, body []byte) ([]string, error) {
+	var response struct {
+		Data []struct {
+			Key    string `json:"key"`
+			Fights []struct {
+				Key string `json:"key"`
+			} `json:"fights"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(body, &response); err != nil {
+		return nil, err
+	}
+
+	var keys []string
+	for _, item := range response.Data {
+		if item.Fights == nil {
+			keys = append(keys, item.Key)
+			continue
+		}
+		for _, fight := range item.Fights {
+			keys = append(keys, fight.Key)
+		}
+	}
+
+	return keys, nil
+}
+
+// TestConcurrentFightOrders sends concurrent /api/fights requests ordering
+// the same snapshot differently; run with -race, a handler reordering the
+// shared snapshot fights shows up as a data race and as responses in the
+// order of another request
+func TestConcurrentFightOrders(t *testing.T) {
+	router := newTestRouter(t, readTestdata(t, "upcoming.html"), Dependencies{})
+	targets := []string{
+		"/api/fights",
+		"/api/fights?sort=interest",
+		"/api/fights?sort=date",
+		"/api/fights?page=1&limit=8",
+		"/api/fights?group_by=location",
+		"/api/fights?group_by=date&group_order=asc",
+	}
+
+	// The order every request must get, from sequential requests
+	want := make(map[string][]string, len(targets))
+	for _, target := range targets {
+		rec := serve(router, http.MethodGet, target, "")
+		if rec.Code != http.StatusOK {
+			t.Fatalf("%s: status = %d: %s", target, rec.Code, rec.Body.String())
+		}
+		keys, err := responseKeys(t, rec.Body.Bytes())
+		if err != nil || len(keys) == 0 {
+			t.Fatalf("%s: no fights (%v)", target, err)
+		}
+		want[target] = keys
+	}
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 20*len(targets))
+	for i := 0; i < 20; i++ {
+		for _, target := range targets {
+			wg.Add(1)
+			go func(target string) {
+				defer wg.Done()
+				rec := serve(router, http.MethodGet, target, "")
+				keys, err := responseKeys(t, rec.Body.Bytes())
+				switch {
+				case err != nil:
+					errs <- fmt.Errorf("%s: %v", target, err)
+				case !reflect.DeepEqual(keys, want[target]):
+					errs <- fmt.Errorf("%s: order %v, want %v", target, keys, want[target])
+				}
+			}(target)
+		}
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+}
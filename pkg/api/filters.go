@@ -0,0 +1,265 @@
+package api
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"easypars/models"
+	"easypars/pkg/apitypes"
+	"easypars/pkg/countries"
+	"easypars/pkg/snapshot"
+
+	"github.com/gin-gonic/gin"
+)
+
+// filterParams are the /api/fights parameters that select fights
+// Only they can be default filters; the other parameters shape the response.
+var filterParams = map[string]bool{
+	"include_hidden":  true,
+	"rematch":         true,
+	"search":          true,
+	"q":               true,
+	"status":          true,
+	"min_confidence":  true,
+	"fighter_country": true,
+	"country":         true,
+	"from":            true,
+	"to":              true,
+}
+
+// ValidateDefaultFilters checks the operator default filters with the
+// validators of the request parameters
+// An invalid default would silently change every response, so the service
+// refuses to start with one.
+func ValidateDefaultFilters(filters map[string]string) error {
+	values := url.Values{}
+	for key, value := range filters {
+		if !filterParams[key] {
+			return fmt.Errorf("unsupported default filter %q, supported: %s", key, strings.Join(sortedFilterParams(), ", "))
+		}
+		values.Set(key, value)
+	}
+	if err := validateFightsParams(values, true); err != nil {
+		return fmt.Errorf("invalid default filters: %w", err)
+	}
+
+	return nil
+}
+
+// sortedFilterParams returns the filter parameter names in alphabetical order
+func sortedFilterParams() []string {
+	names := make([]string, 0, len(filterParams))
+	for name := range filterParams {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// filterLayers holds the two layers of fight filters of a request
+// The operator defaults are applied first and the request parameters on top
+// of them, so a client can narrow the default selection but not widen it.
+type filterLayers struct {
+	defaults  url.Values
+	requested url.Values
+	// ignored is set when the defaults were turned off with ?ignore_defaults=1
+	ignored bool
+}
+
+// errDefaultsForbidden is returned for ?ignore_defaults=1 without a valid API key
+var errDefaultsForbidden = fmt.Errorf("ignore_defaults requires a valid API key in the X-API-Key header")
+
+// filterLayers returns the filter layers of the request
+// ?ignore_defaults=1 drops the operator defaults; it is meant for admins
+// and only honored with the configured API key.
+func (h *handler) filterLayers(c *gin.Context) (filterLayers, error) {
+	layers := filterLayers{defaults: url.Values{}, requested: url.Values{}}
+	for key, value := range h.deps.DefaultFilters {
+		layers.defaults.Set(key, value)
+	}
+
+	query := c.Request.URL.Query()
+	for key := range filterParams {
+		if query.Has(key) {
+			layers.requested.Set(key, query.Get(key))
+		}
+	}
+
+	if flagSet(query.Get("ignore_defaults")) {
+		if !h.validAPIKey(c.GetHeader("X-API-Key")) {
+			return filterLayers{}, errDefaultsForbidden
+		}
+		layers.defaults = url.Values{}
+		layers.ignored = true
+	}
+
+	return layers, nil
+}
+
+// validAPIKey reports whether key is the configured API key
+// Without a configured key no request is privileged.
+func (h *handler) validAPIKey(key string) bool {
+	if h.deps.APIKey == "" || key == "" {
+		return false
+	}
+
+	return subtle.ConstantTimeCompare([]byte(key), []byte(h.deps.APIKey)) == 1
+}
+
+// apply returns the fights of the snapshot view passing both layers and the
+// number of fights a date range excluded because their date does not parse
+// Hidden fights are excluded unless a layer includes them; a default that
+// excludes them explicitly cannot be overridden by the request. The result
+// is a new slice the handler may reorder.
+func (l filterLayers) apply(view snapshot.FightsView) ([]models.Fight, int) {
+	includeHidden := l.includeHidden()
+	fights := view.Filter(func(fight models.Fight) bool {
+		return includeHidden || !fight.HiddenInSource
+	})
+
+	undated := 0
+	fights = filterByValues(filterByValues(fights, l.defaults, &undated), l.requested, &undated)
+
+	return fights, undated
+}
+
+// match reports whether a single fight passes the layers, e.g. a streamed
+// update that is not read from a snapshot view
+func (l filterLayers) match(fight models.Fight) bool {
+	if fight.HiddenInSource && !l.includeHidden() {
+		return false
+	}
+
+	undated := 0
+	fights := filterByValues(filterByValues([]models.Fight{fight}, l.defaults, &undated), l.requested, &undated)
+
+	return len(fights) == 1
+}
+
+// includeHidden reports whether the fights hidden in the source pass the
+// layers; a default turning them off cannot be overridden by the request
+func (l filterLayers) includeHidden() bool {
+	if l.defaults.Has("include_hidden") && !flagSet(l.defaults.Get("include_hidden")) {
+		return false
+	}
+	if l.requested.Has("include_hidden") {
+		return flagSet(l.requested.Get("include_hidden"))
+	}
+
+	return flagSet(l.defaults.Get("include_hidden"))
+}
+
+// searchTerm returns the search term of the request layer, ?search= or its
+// alias ?q=
+func (l filterLayers) searchTerm() string {
+	return layerSearchTerm(l.requested)
+}
+
+// applied describes the layers for the applied_filters field of responses
+// nil when no filter is in effect
+func (l filterLayers) applied() *apitypes.AppliedFilters {
+	if len(l.defaults) == 0 && len(l.requested) == 0 && !l.ignored {
+		return nil
+	}
+
+	return &apitypes.AppliedFilters{
+		Default:         flattenValues(l.defaults),
+		Requested:       flattenValues(l.requested),
+		DefaultsIgnored: l.ignored,
+	}
+}
+
+// filterByValues applies the filters of one layer
+// The values are validated, a parameter that does not parse is not applied.
+// Fights a date range excludes because their date does not parse are added
+// to undated.
+func filterByValues(fights []models.Fight, values url.Values, undated *int) []models.Fight {
+	// Optional filter: only fights that are rematches
+	if flagSet(values.Get("rematch")) {
+		fights = filterRematches(fights)
+	}
+
+	// Optional filter: fighter name search
+	if term := layerSearchTerm(values); term != "" {
+		fights = filterByFighter(fights, term)
+	}
+
+	// Optional filter: a single status
+	if status := values.Get("status"); status != "" {
+		fights = filterFights(fights, func(fight *models.Fight) bool { return fight.Status == status })
+	}
+
+	// Optional filter: minimal parse confidence
+	if minConfidence, err := strconv.ParseFloat(values.Get("min_confidence"), 64); err == nil {
+		fights = filterFights(fights, func(fight *models.Fight) bool { return fight.Confidence >= minConfidence })
+	}
+
+	// Optional filter: a country of either fighter, any spelling of it
+	if iso2, ok := countries.Resolve(values.Get("fighter_country")); ok {
+		fights = filterFights(fights, func(fight *models.Fight) bool {
+			return fight.Fighter1Country == iso2 || fight.Fighter2Country == iso2
+		})
+	}
+
+	// Optional filter: the country of the location
+	if iso2, ok := countries.Resolve(values.Get("country")); ok {
+		fights = filterFights(fights, func(fight *models.Fight) bool { return fight.LocationCountry == iso2 })
+	}
+
+	// Optional filter: a date range, both bounds inclusive and optional
+	from, fromErr := time.Parse("2006-01-02", values.Get("from"))
+	to, toErr := time.Parse("2006-01-02", values.Get("to"))
+	if fromErr == nil || toErr == nil {
+		fights = filterFights(fights, func(fight *models.Fight) bool {
+			day, err := time.Parse("2006-01-02", fight.Date)
+			if err != nil {
+				*undated++
+				return false
+			}
+			return (fromErr != nil || !day.Before(from)) && (toErr != nil || !day.After(to))
+		})
+	}
+
+	return fights
+}
+
+// layerSearchTerm returns ?search= or its alias ?q= of a filter layer
+func layerSearchTerm(values url.Values) string {
+	if term := strings.TrimSpace(values.Get("search")); term != "" {
+		return term
+	}
+	return strings.TrimSpace(values.Get("q"))
+}
+
+// filterFights returns the fights matching keep
+func filterFights(fights []models.Fight, keep func(*models.Fight) bool) []models.Fight {
+	filtered := make([]models.Fight, 0, len(fights))
+	for i := range fights {
+		if keep(&fights[i]) {
+			filtered = append(filtered, fights[i])
+		}
+	}
+
+	return filtered
+}
+
+// flagSet reports whether a flag parameter is on (1 or true)
+func flagSet(value string) bool {
+	return value == "1" || value == "true"
+}
+
+// flattenValues converts single valued parameters to a map
+func flattenValues(values url.Values) map[string]string {
+	flat := make(map[string]string, len(values))
+	for key := range values {
+		flat[key] = values.Get(key)
+	}
+
+	return flat
+}
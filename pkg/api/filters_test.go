@@ -0,0 +1,201 @@
+package api
+
+import (
+	"net/http"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"easypars/models"
+	"easypars/pkg/apitypes"
+)
+
+func TestValidateDefaultFilters(t *testing.T) {
+	tests := []struct {
+		name    string
+		filters map[string]string
+		want    string
+	}{
+		{"none", nil, ""},
+		{"status", map[string]string{"status": models.StatusCompleted}, ""},
+		{"several filters", map[string]string{"min_confidence": "0.5", "search": "Usyk", "from": "2024-01-01"}, ""},
+		{"unsupported parameter", map[string]string{"weight_class": "heavyweight"}, "unsupported default filter"},
+		{"response parameter", map[string]string{"limit": "10"}, "unsupported default filter"},
+		{"invalid status", map[string]string{"status": "finished"}, "invalid default filters"},
+		{"invalid confidence", map[string]string{"min_confidence": "high"}, "invalid default filters"},
+		{"invalid date", map[string]string{"from": "2024-13-01"}, "invalid default filters"},
+	}
+	for _, tt := range tests {
+		err := ValidateDefaultFilters(tt.filters)
+		if tt.want == "" && err != nil || tt.want != "" && (err == nil || !strings.Contains(err.Error(), tt.want)) {
+			t.Errorf("%s: ValidateDefaultFilters(%v) = %v, want %q", tt.name, tt.filters, err, tt.want)
+		}
+	}
+}
+
+func TestDefaultFilters(t *testing.T) {
+	const apiKey = "operator key"
+	router := newTestRouter(t, readTestdata(t, "results.html"), Dependencies{
+		DefaultFilters: map[string]string{"status": models.StatusCompleted},
+		APIKey:         apiKey,
+	})
+	defaults := map[string]string{"status": models.StatusCompleted}
+
+	tests := []struct {
+		name   string
+		query  string
+		key    string
+		status int
+		code   string
+		// fighters are the first fighters of the served fights
+		fighters []string
+		applied  *apitypes.AppliedFilters
+	}{
+		{
+			name: "defaults applied", status: http.StatusOK,
+			fighters: []string{"Bivol", "Dubois", "Joshua", "Usyk", "Zhang"},
+			applied:  &apitypes.AppliedFilters{Default: defaults, Requested: map[string]string{}},
+		},
+		{
+			name: "request narrows", query: "?search=Usyk", status: http.StatusOK,
+			fighters: []string{"Usyk"},
+			applied:  &apitypes.AppliedFilters{Default: defaults, Requested: map[string]string{"search": "Usyk"}},
+		},
+		{
+			name: "request cannot widen", query: "?status=scheduled", status: http.StatusOK,
+			fighters: nil,
+			applied:  &apitypes.AppliedFilters{Default: defaults, Requested: map[string]string{"status": models.StatusScheduled}},
+		},
+		{name: "ignore_defaults without a key", query: "?ignore_defaults=1", status: http.StatusForbidden, code: "forbidden"},
+		{name: "ignore_defaults with a wrong key", query: "?ignore_defaults=1", key: "guess", status: http.StatusUnauthorized, code: "invalid_api_key"},
+		{
+			name: "ignore_defaults with the key", query: "?ignore_defaults=1", key: apiKey, status: http.StatusOK,
+			fighters: []string{"Bivol", "Canelo", "Dubois", "Joshua", "Usyk", "Zhang"},
+			applied:  &apitypes.AppliedFilters{Default: map[string]string{}, Requested: map[string]string{}, DefaultsIgnored: true},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var headers []string
+			if tt.key != "" {
+				headers = []string{"X-API-Key", tt.key}
+			}
+			rec := serve(router, http.MethodGet, "/api/fights"+tt.query, "", headers...)
+			if rec.Code != tt.status {
+				t.Fatalf("GET /api/fights%s = %d %s, want %d", tt.query, rec.Code, rec.Body, tt.status)
+			}
+			if tt.status != http.StatusOK {
+				if code := errorCode(t, rec); code != tt.code {
+					t.Errorf("error = %q, want %q", code, tt.code)
+				}
+				return
+			}
+
+			var body apitypes.FightsResponse
+			decodeJSON(t, rec, &body)
+			var fighters []string
+			for _, fight := range body.Data {
+				fighters = append(fighters, fight.Fighter1)
+			}
+			sort.Strings(fighters)
+			if !reflect.DeepEqual(fighters, tt.fighters) {
+				t.Errorf("fights of %q, want %q", fighters, tt.fighters)
+			}
+			if !reflect.DeepEqual(body.AppliedFilters, tt.applied) {
+				t.Errorf("applied_filters = %+v, want %+v", body.AppliedFilters, tt.applied)
+			}
+		})
+	}
+}
+
+func TestDefaultFiltersApplyToStats(t *testing.T) {
+	page := readTestdata(t, "results.html")
+	filtered := newTestRouter(t, page, Dependencies{DefaultFilters: map[string]string{"search": "Usyk"}})
+	plain := newTestRouter(t, page, Dependencies{})
+
+	var withDefaults, without apitypes.StatsResponse
+	decodeJSON(t, serve(filtered, http.MethodGet, "/api/stats", ""), &withDefaults)
+	decodeJSON(t, serve(plain, http.MethodGet, "/api/stats", ""), &without)
+	if withDefaults.FightCount != 1 || without.FightCount <= 1 {
+		t.Errorf("total fights = %d with the default filter and %d without, want 1 and more", withDefaults.FightCount, without.FightCount)
+	}
+	if withDefaults.AppliedFilters == nil || withDefaults.AppliedFilters.Default["search"] != "Usyk" {
+		t.Errorf("applied_filters = %+v, want the default search", withDefaults.AppliedFilters)
+	}
+}
+
+func TestDateRange(t *testing.T) {
+	// The results page with a fight whose date cell has no day
+	page := strings.Replace(readTestdata(t, "results.html"), "</table>",
+		`<tr><td class="date">TBA</td><td class="place">Paris</td><td class="boxer_1">Yoka</td><td class="vs">vs</td><td class="boxer_2">Bakole</td></tr></table>`, 1)
+	router := newTestRouter(t, page, Dependencies{})
+
+	tests := []struct {
+		name     string
+		query    string
+		status   int
+		param    string
+		fighters []string
+		undated  int
+	}{
+		{"no range", "", http.StatusOK, "", []string{"Bivol", "Canelo", "Dubois", "Joshua", "Usyk", "Yoka", "Zhang"}, 0},
+		{"closed range", "?from=2024-06-01&to=2024-06-08", http.StatusOK, "", []string{"Bivol", "Joshua", "Zhang"}, 1},
+		{"one day", "?from=2024-05-18&to=2024-05-18", http.StatusOK, "", []string{"Usyk"}, 1},
+		{"only from", "?from=2024-06-08", http.StatusOK, "", []string{"Canelo", "Joshua"}, 1},
+		{"only to", "?to=2024-05-31", http.StatusOK, "", []string{"Dubois", "Usyk"}, 1},
+		{"empty range", "?from=2024-07-01", http.StatusOK, "", nil, 1},
+		{"range with another filter", "?from=2024-06-01&search=Bivol", http.StatusOK, "", []string{"Bivol"}, 0},
+		{"invalid from", "?from=2024-06-31", http.StatusBadRequest, `"from"`, nil, 0},
+		{"invalid to", "?to=18.05.2024", http.StatusBadRequest, `"to"`, nil, 0},
+		{"from after to", "?from=2024-06-08&to=2024-06-01", http.StatusBadRequest, `"from"`, nil, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := serve(router, http.MethodGet, "/api/fights"+tt.query, "")
+			if rec.Code != tt.status {
+				t.Fatalf("GET /api/fights%s = %d %s, want %d", tt.query, rec.Code, rec.Body, tt.status)
+			}
+			if tt.status != http.StatusOK {
+				var body struct {
+					Error   string `json:"error"`
+					Message string `json:"message"`
+				}
+				decodeJSON(t, rec, &body)
+				if body.Error != "invalid_params" || !strings.Contains(body.Message, tt.param) {
+					t.Errorf("error = %s %q, want invalid_params naming %s", body.Error, body.Message, tt.param)
+				}
+				return
+			}
+
+			var body apitypes.FightsResponse
+			decodeJSON(t, rec, &body)
+			var fighters []string
+			for _, fight := range body.Data {
+				fighters = append(fighters, fight.Fighter1)
+			}
+			sort.Strings(fighters)
+			if !reflect.DeepEqual(fighters, tt.fighters) {
+				t.Errorf("fights of %q, want %q", fighters, tt.fighters)
+			}
+			undated := 0
+			if body.AppliedFilters != nil {
+				undated = body.AppliedFilters.UndatedExcluded
+			}
+			if undated != tt.undated {
+				t.Errorf("undated_excluded = %d, want %d", undated, tt.undated)
+			}
+		})
+	}
+}
+
+func TestDateRangeIsAppliedBeforePagination(t *testing.T) {
+	router := newTestRouter(t, readTestdata(t, "results.html"), Dependencies{})
+
+	rec := serve(router, http.MethodGet, "/api/fights?from=2024-06-01&limit=2", "")
+	var body apitypes.FightsResponse
+	decodeJSON(t, rec, &body)
+	if rec.Code != http.StatusOK || body.Pagination == nil || body.Pagination.Total != 4 || len(body.Data) != 2 {
+		t.Errorf("GET ?from=2024-06-01&limit=2 = %d with %d fights and pagination %+v, want 2 of 4", rec.Code, len(body.Data), body.Pagination)
+	}
+}
@@ -0,0 +1,78 @@
+package api
+
+import (
+	"context"
+	"sync"
+
+	"easypars/pkg/snapshot"
+)
+
+// snapshotFlight is a snapshot load shared by concurrent requests
+type snapshotFlight struct {
+	done   chan struct{}
+	cancel context.CancelFunc
+	// waiters counts the requests waiting for the load
+	waiters int
+
+	// Set before done is closed
+	snap *snapshot.Snapshot
+	err  error
+}
+
+// snapshotFlights coalesces concurrent snapshot loads by source URL
+// Requests arriving while a load of the same source runs wait for it and
+// get its snapshot or error, so a burst of requests on a cold cache makes
+// a single parse of the source.
+type snapshotFlights struct {
+	mu      sync.Mutex
+	flights map[string]*snapshotFlight
+}
+
+// do runs load for the key, or joins the load already running for it
+// The load runs on a context detached from the request that started it and
+// is cancelled once every waiting request is gone; a request arriving after
+// that starts a new load. A request whose context ends stops waiting and
+// gets the context error.
+func (g *snapshotFlights) do(ctx context.Context, key string, load func(context.Context) (*snapshot.Snapshot, error)) (*snapshot.Snapshot, error) {
+	g.mu.Lock()
+	if g.flights == nil {
+		g.flights = make(map[string]*snapshotFlight)
+	}
+	flight, ok := g.flights[key]
+	if !ok {
+		loadCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+		flight = &snapshotFlight{done: make(chan struct{}), cancel: cancel}
+		g.flights[key] = flight
+
+		go func() {
+			snap, err := load(loadCtx)
+
+			g.mu.Lock()
+			if g.flights[key] == flight {
+				delete(g.flights, key)
+			}
+			flight.snap, flight.err = snap, err
+			g.mu.Unlock()
+			cancel()
+			close(flight.done)
+		}()
+	}
+	flight.waiters++
+	g.mu.Unlock()
+
+	select {
+	case <-flight.done:
+		return flight.snap, flight.err
+	case <-ctx.Done():
+		g.mu.Lock()
+		flight.waiters--
+		if flight.waiters == 0 {
+			flight.cancel()
+			if g.flights[key] == flight {
+				delete(g.flights, key)
+			}
+		}
+		g.mu.Unlock()
+		return nil, ctx.Err()
+	}
+}
@@ -0,0 +1,183 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"easypars/pkg/clock"
+	"easypars/pkg/parser"
+	"easypars/pkg/snapshot"
+)
+
+// countingFetcher is a snapshot load counting its calls and blocking until
+// release is closed
+type countingFetcher struct {
+	calls   atomic.Int32
+	release chan struct{}
+	snap    *snapshot.Snapshot
+}
+
+func newCountingFetcher() *countingFetcher {
+	return &countingFetcher{release: make(chan struct{}), snap: &snapshot.Snapshot{}}
+}
+
+func (f *countingFetcher) load(ctx context.Context) (*snapshot.Snapshot, error) {
+	f.calls.Add(1)
+	select {
+	case <-f.release:
+		return f.snap, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// waiters returns the number of requests waiting for the load of the key
+func (g *snapshotFlights) waiters(key string) int {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if flight, ok := g.flights[key]; ok {
+		return flight.waiters
+	}
+	return 0
+}
+
+func TestSnapshotFlightsCoalesceConcurrentLoads(t *testing.T) {
+	var flights snapshotFlights
+	fetcher := newCountingFetcher()
+
+	const requests = 20
+	var wg sync.WaitGroup
+	snaps := make([]*snapshot.Snapshot, requests)
+	errs := make([]error, requests)
+	for i := 0; i < requests; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			snaps[i], errs[i] = flights.do(context.Background(), "source", fetcher.load)
+		}()
+	}
+	waitFor(t, "every request to wait for the load", func() bool { return flights.waiters("source") == requests })
+	close(fetcher.release)
+	wg.Wait()
+
+	if calls := fetcher.calls.Load(); calls != 1 {
+		t.Errorf("the upstream was loaded %d times, want once", calls)
+	}
+	for i := range snaps {
+		if errs[i] != nil || snaps[i] != fetcher.snap {
+			t.Errorf("request %d = %p, %v, want the shared snapshot", i, snaps[i], errs[i])
+		}
+	}
+
+	// The finished load is forgotten, the next request loads again
+	if _, err := flights.do(context.Background(), "source", fetcher.load); err != nil || fetcher.calls.Load() != 2 {
+		t.Errorf("request after the load = %v with %d loads, want a second load", err, fetcher.calls.Load())
+	}
+}
+
+func TestSnapshotFlightsKeepSourcesApart(t *testing.T) {
+	var flights snapshotFlights
+	first, second := newCountingFetcher(), newCountingFetcher()
+	close(first.release)
+	close(second.release)
+
+	if snap, _ := flights.do(context.Background(), "first", first.load); snap != first.snap {
+		t.Error("the first source got another snapshot")
+	}
+	if snap, _ := flights.do(context.Background(), "second", second.load); snap != second.snap {
+		t.Error("the second source got another snapshot")
+	}
+	if first.calls.Load() != 1 || second.calls.Load() != 1 {
+		t.Errorf("loads = %d, %d, want one per source", first.calls.Load(), second.calls.Load())
+	}
+}
+
+func TestSnapshotFlightsCancelWhenEveryRequestIsGone(t *testing.T) {
+	var flights snapshotFlights
+	fetcher := newCountingFetcher()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	var wg sync.WaitGroup
+	errs := make([]error, 3)
+	for i := range errs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, errs[i] = flights.do(ctx, "source", fetcher.load)
+		}()
+	}
+	waitFor(t, "the requests to wait for the load", func() bool { return flights.waiters("source") == len(errs) })
+	cancel()
+	wg.Wait()
+
+	for i, err := range errs {
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("request %d = %v, want context.Canceled", i, err)
+		}
+	}
+
+	// The abandoned load was cancelled: a new request starts its own
+	done := make(chan error, 1)
+	go func() {
+		_, err := flights.do(context.Background(), "source", fetcher.load)
+		done <- err
+	}()
+	waitFor(t, "the second load", func() bool { return fetcher.calls.Load() == 2 })
+	close(fetcher.release)
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("request after the cancellation = %v, want the snapshot", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("the request after the cancellation got no snapshot")
+	}
+}
+
+func TestConcurrentColdRequestsParseTheSourceOnce(t *testing.T) {
+	page := readTestdata(t, "upcoming.html")
+	var hits atomic.Int32
+	release := make(chan struct{})
+	src := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		<-release
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		io.WriteString(w, page)
+	}))
+	t.Cleanup(src.Close)
+
+	p := parser.NewParser(src.URL + "/")
+	p.Clock = clock.Fixed{Time: testNow}
+	router := SetupRouter(Dependencies{Parser: p})
+
+	const requests = 20
+	var wg sync.WaitGroup
+	codes := make([]int, requests)
+	for i := range codes {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			codes[i] = serve(router, http.MethodGet, "/api/fights", "").Code
+		}()
+	}
+	waitFor(t, "the source to be requested", func() bool { return hits.Load() > 0 })
+	close(release)
+	wg.Wait()
+
+	for i, code := range codes {
+		if code != http.StatusOK {
+			t.Errorf("request %d = %d, want 200", i, code)
+		}
+	}
+	if got := hits.Load(); got != 1 {
+		t.Errorf("the source was requested %d times, want once", got)
+	}
+}
@@ -0,0 +1,196 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"easypars/models"
+	"easypars/pkg/apitypes"
+	"easypars/pkg/render"
+
+	"github.com/gin-gonic/gin"
+)
+
+// russianMonths holds genitive month names used in date labels ("1 июня 2024")
+var russianMonths = [...]string{
+	"января", "февраля", "марта", "апреля", "мая", "июня",
+	"июля", "августа", "сентября", "октября", "ноября", "декабря",
+}
+
+// groupKeyFuncs returns the grouping key of a fight for each supported group_by value
+var groupKeyFuncs = map[string]func(models.Fight) string{
+	"date":     func(f models.Fight) string { return f.Date },
+	"location": func(f models.Fight) string { return f.Location },
+	"event":    func(f models.Fight) string { return f.Date + "|" + f.Location },
+}
+
+// groupFights groups fights by the given key and sorts the groups by key
+// Fights keep their original relative order inside a group; fights with an
+// empty key are skipped so no empty groups are created
+func groupFights(fights []models.Fight, groupBy string, descending bool, lang string) []apitypes.FightGroup {
+	keyFunc := groupKeyFuncs[groupBy]
+	index := make(map[string]int)
+	var groups []apitypes.FightGroup
+
+	for _, fight := range fights {
+		key := keyFunc(fight)
+		if key == "" || key == "|" {
+			continue
+		}
+
+		idx, ok := index[key]
+		if !ok {
+			idx = len(groups)
+			index[key] = idx
+			groups = append(groups, apitypes.FightGroup{Key: key, Label: groupLabel(groupBy, fight, lang)})
+		}
+		groups[idx].Fights = append(groups[idx].Fights, fight)
+	}
+
+	sort.SliceStable(groups, func(i, j int) bool {
+		if descending {
+			return groups[i].Key > groups[j].Key
+		}
+		return groups[i].Key < groups[j].Key
+	})
+
+	for i := range groups {
+		groups[i].Count = len(groups[i].Fights)
+	}
+
+	return groups
+}
+
+// groupLabel returns the human readable label of a group
+func groupLabel(groupBy string, fight models.Fight, lang string) string {
+	switch groupBy {
+	case "date":
+		return formatDateLabel(fight.Date, lang)
+	case "event":
+		if fight.Location == "" {
+			return formatDateLabel(fight.Date, lang)
+		}
+		return formatDateLabel(fight.Date, lang) + ", " + fight.Location
+	default:
+		return fight.Location
+	}
+}
+
+// formatDateLabel formats a YYYY-MM-DD date for display in the given language
+func formatDateLabel(date, lang string) string {
+	t, err := time.Parse("2006-01-02", date)
+	if err != nil {
+		return date
+	}
+
+	if lang == "ru" {
+		return fmt.Sprintf("%d %s %d", t.Day(), russianMonths[t.Month()-1], t.Year())
+	}
+
+	return t.Format("January 2, 2006")
+}
+
+// preferredLanguage picks "ru" or "en" from the Accept-Language header
+// The first supported language in the header wins, English is the default
+func preferredLanguage(header string) string {
+	for _, part := range strings.Split(header, ",") {
+		tag := strings.ToLower(strings.TrimSpace(strings.SplitN(part, ";", 2)[0]))
+		switch {
+		case strings.HasPrefix(tag, "ru"):
+			return "ru"
+		case strings.HasPrefix(tag, "en"):
+			return "en"
+		}
+	}
+
+	return "en"
+}
+
+// respondGroupedFights writes the grouped fights response
+// Pagination counts groups, not fights: page/limit select a window of groups.
+// builtAt is the build time of the snapshot, for conditional requests.
+func respondGroupedFights(c *gin.Context, fights []models.Fight, groupBy string, applied *apitypes.AppliedFilters, builtAt time.Time) {
+	if _, ok := groupKeyFuncs[groupBy]; !ok {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "invalid_group_by",
+			"message": "group_by must be one of: date, location, event",
+		})
+		return
+	}
+
+	page, err := parsePositiveInt(c.DefaultQuery("page", "1"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "invalid_page",
+			"message": "page must be a positive integer",
+		})
+		return
+	}
+	limit, err := parsePositiveInt(c.DefaultQuery("limit", strconv.Itoa(defaultPageLimit)))
+	if err != nil || limit > maxPageLimit {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "invalid_limit",
+			"message": fmt.Sprintf("limit must be an integer between 1 and %d", maxPageLimit),
+		})
+		return
+	}
+
+	// Dates are shown newest first by default, other keys alphabetically
+	descending := groupBy == "date" || groupBy == "event"
+	switch c.Query("group_order") {
+	case "asc":
+		descending = false
+	case "desc":
+		descending = true
+	}
+
+	groups := groupFights(fights, groupBy, descending, preferredLanguage(c.GetHeader("Accept-Language")))
+
+	pageGroups, pagination := paginate(groups, page, limit, "groups")
+
+	fightCount := 0
+	var pageFights []models.Fight
+	for _, group := range pageGroups {
+		fightCount += group.Count
+		pageFights = append(pageFights, group.Fights...)
+	}
+
+	message := "List of fights retrieved successfully"
+	body := apitypes.GroupedFightsResponse{
+		Message:    message,
+		GroupBy:    groupBy,
+		Data:       pageGroups,
+		Count:      len(pageGroups),
+		FightCount: fightCount,
+		Pagination: pagination,
+
+		AppliedFilters: applied,
+	}
+	if etag, err := payloadETag(c, body); err == nil && notModified(c, etag, builtAt) {
+		return
+	}
+
+	// Row based formats get the fights of the page groups in group order
+	render.Negotiate(c, http.StatusOK, render.ResponsePayload{
+		Message: message,
+		Fights:  pageFights,
+		Body:    body,
+	})
+}
+
+// parsePositiveInt parses a strictly positive integer query value
+func parsePositiveInt(value string) (int, error) {
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, err
+	}
+	if n < 1 {
+		return 0, fmt.Errorf("value %d is not positive", n)
+	}
+
+	return n, nil
+}
@@ -0,0 +1,161 @@
+package api
+
+import (
+	"net/http"
+	"testing"
+
+	"easypars/models"
+	"easypars/pkg/apitypes"
+)
+
+func TestGroupFightsByKey(t *testing.T) {
+	fights := []models.Fight{
+		{Date: "2024-06-01", Location: "Riyadh", Fighter1: "Bivol"},
+		{Date: "2024-06-08", Location: "London", Fighter1: "Joshua"},
+		{Date: "2024-06-01", Location: "Riyadh", Fighter1: "Zhang"},
+		{Date: "2024-05-25", Location: "London", Fighter1: "Dubois"},
+		{Date: "2024-06-01", Location: "Las Vegas", Fighter1: "Canelo"},
+	}
+
+	tests := []struct {
+		groupBy    string
+		descending bool
+		want       []string
+		counts     []int
+	}{
+		{"date", true, []string{"2024-06-08", "2024-06-01", "2024-05-25"}, []int{1, 3, 1}},
+		{"location", false, []string{"Las Vegas", "London", "Riyadh"}, []int{1, 2, 2}},
+		{"event", true, []string{"2024-06-08|London", "2024-06-01|Riyadh", "2024-06-01|Las Vegas", "2024-05-25|London"}, []int{1, 2, 1, 1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.groupBy, func(t *testing.T) {
+			groups := groupFights(fights, tt.groupBy, tt.descending, "en")
+			if len(groups) != len(tt.want) {
+				t.Fatalf("got %d groups %v, want %v", len(groups), groupKeys(groups), tt.want)
+			}
+			for i, group := range groups {
+				if group.Key != tt.want[i] || group.Count != tt.counts[i] || len(group.Fights) != group.Count {
+					t.Errorf("group %d = %q with %d fights (count %d), want %q with %d", i, group.Key, len(group.Fights), group.Count, tt.want[i], tt.counts[i])
+				}
+			}
+		})
+	}
+
+	// Fights keep their relative order inside a group
+	groups := groupFights(fights, "date", true, "en")
+	if got := groups[1].Fights; got[0].Fighter1 != "Bivol" || got[1].Fighter1 != "Zhang" || got[2].Fighter1 != "Canelo" {
+		t.Errorf("fights of 2024-06-01 reordered: %v", got)
+	}
+}
+
+func TestGroupFightsSkipsEmptyKeys(t *testing.T) {
+	fights := []models.Fight{
+		{Date: "", Location: ""},
+		{Date: "2024-06-01", Location: ""},
+		{Date: "", Location: "London"},
+	}
+
+	for groupBy, want := range map[string]int{"date": 1, "location": 1, "event": 2} {
+		groups := groupFights(fights, groupBy, true, "en")
+		if len(groups) != want {
+			t.Errorf("group_by=%s: %d groups %v, want %d", groupBy, len(groups), groupKeys(groups), want)
+		}
+		for _, group := range groups {
+			if group.Count == 0 || group.Key == "" || group.Key == "|" {
+				t.Errorf("group_by=%s: empty group %+v", groupBy, group)
+			}
+		}
+	}
+	if groups := groupFights(nil, "date", true, "en"); len(groups) != 0 {
+		t.Errorf("groups of no fights = %v", groups)
+	}
+}
+
+func TestGroupLabelLocalization(t *testing.T) {
+	fight := models.Fight{Date: "2024-06-01", Location: "Riyadh"}
+
+	tests := []struct {
+		groupBy, lang, want string
+	}{
+		{"date", "ru", "1 июня 2024"},
+		{"date", "en", "June 1, 2024"},
+		{"event", "ru", "1 июня 2024, Riyadh"},
+		{"location", "ru", "Riyadh"},
+	}
+	for _, tt := range tests {
+		if got := groupLabel(tt.groupBy, fight, tt.lang); got != tt.want {
+			t.Errorf("groupLabel(%s, %s) = %q, want %q", tt.groupBy, tt.lang, got, tt.want)
+		}
+	}
+
+	for header, want := range map[string]string{
+		"":                        "en",
+		"ru-RU,ru;q=0.9,en;q=0.8": "ru",
+		"de-DE, en-US;q=0.8, ru":  "en",
+		"fr":                      "en",
+	} {
+		if got := preferredLanguage(header); got != want {
+			t.Errorf("preferredLanguage(%q) = %q, want %q", header, got, want)
+		}
+	}
+}
+
+func TestGroupedFightsEndpoint(t *testing.T) {
+	router := newTestRouter(t, readTestdata(t, "results.html"), Dependencies{})
+
+	// Pagination counts groups: the fixture has four dates over two pages
+	var body apitypes.GroupedFightsResponse
+	rec := serve(router, http.MethodGet, "/api/fights?group_by=date&limit=3&page=2", "", "Accept-Language", "ru")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", rec.Code, rec.Body.String())
+	}
+	decodeJSON(t, rec, &body)
+	if body.Pagination != (apitypes.Pagination{Unit: "groups", Page: 2, Limit: 3, Total: 5, TotalPages: 2}) {
+		t.Errorf("pagination = %+v", body.Pagination)
+	}
+	if got := groupKeys(body.Data); len(got) != 2 || got[0] != "2024-05-25" || got[1] != "2024-05-18" {
+		t.Errorf("second page groups = %v, want 2024-05-25 and 2024-05-18", got)
+	}
+	if body.Count != 2 || body.FightCount != 2 {
+		t.Errorf("count = %d, fight_count = %d, want 2 and 2", body.Count, body.FightCount)
+	}
+	if body.Data[0].Label != "25 мая 2024" {
+		t.Errorf("label = %q, want the Russian date", body.Data[0].Label)
+	}
+
+	// The first page holds the card of 2024-06-01 as a single group
+	rec = serve(router, http.MethodGet, "/api/fights?group_by=date&group_order=desc&limit=3", "")
+	decodeJSON(t, rec, &body)
+	if body.Data[2].Key != "2024-06-01" || body.Data[2].Count != 2 || body.Data[2].Label != "June 1, 2024" {
+		t.Errorf("group 2024-06-01 = %+v", body.Data[2])
+	}
+	if body.FightCount != 4 {
+		t.Errorf("fight_count = %d, want 4", body.FightCount)
+	}
+}
+
+func TestGroupedFightsRejectsCompact(t *testing.T) {
+	router := newTestRouter(t, readTestdata(t, "results.html"), Dependencies{})
+
+	for _, target := range []string{
+		"/api/fights?group_by=date&compact=1",
+		"/api/fights?group_by=location&compact=true",
+	} {
+		rec := serve(router, http.MethodGet, target, "")
+		if rec.Code != http.StatusBadRequest || errorCode(t, rec) != "invalid_params" {
+			t.Errorf("%s: status = %d, body = %s, want 400 invalid_params", target, rec.Code, rec.Body.String())
+		}
+	}
+	if rec := serve(router, http.MethodGet, "/api/fights?group_by=date&compact=0", ""); rec.Code != http.StatusOK {
+		t.Errorf("compact=0: status = %d, want 200", rec.Code)
+	}
+}
+
+func groupKeys(groups []apitypes.FightGroup) []string {
+	keys := make([]string, 0, len(groups))
+	for _, group := range groups {
+		keys = append(keys, group.Key)
+	}
+
+	return keys
+}
@@ -0,0 +1,226 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"easypars/pkg/apitypes"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Readiness defaults used when the dependencies leave them zero
+const (
+	// DefaultMaxParseAge is how old the last successful parse may be
+	DefaultMaxParseAge = 6 * time.Hour
+	// DefaultReadyCheckTimeout bounds every readiness check
+	DefaultReadyCheckTimeout = time.Second
+	// DefaultReadyCacheTTL is how long a readiness result is reused
+	DefaultReadyCacheTTL = 5 * time.Second
+)
+
+// Readiness check states
+const (
+	checkOK     = "ok"
+	checkFailed = "failed"
+)
+
+// pinger is implemented by the dependencies backed by a server, such as
+// the SQL repositories and the Redis fight cache
+type pinger interface {
+	Ping(ctx context.Context) error
+}
+
+// readyCheck is a single readiness check
+type readyCheck struct {
+	name string
+	// run returns what was checked, and an error when it failed
+	run func(ctx context.Context) (string, error)
+}
+
+// readinessCache keeps the last readiness result, so a storm of probes
+// runs the checks once
+type readinessCache struct {
+	mu       sync.Mutex
+	response apitypes.ReadinessResponse
+	expires  time.Time
+}
+
+// handleHealthLive handles GET requests to /api/health/live
+// The process is up when it answers; nothing else is checked, so a broken
+// dependency does not make the orchestrator restart the service.
+func (h *handler) handleHealthLive(c *gin.Context) {
+	c.JSON(http.StatusOK, apitypes.LivenessResponse{
+		Status:        "alive",
+		UptimeSeconds: int64(time.Since(h.startedAt).Seconds()),
+	})
+}
+
+// handleHealthReady handles GET requests to /api/health/ready
+// The service is ready when the last successful parse is recent enough and
+// the configured storage and fight cache answer. The checks run in
+// parallel, each within the check timeout, and their result is reused for
+// a few seconds. Any failed check makes the response 503 with the outcome
+// of every check.
+func (h *handler) handleHealthReady(c *gin.Context) {
+	response := h.readiness(c.Request.Context())
+
+	status := http.StatusOK
+	if response.Status != "ready" {
+		status = http.StatusServiceUnavailable
+	}
+	c.JSON(status, response)
+}
+
+// readiness returns the cached readiness result or runs the checks
+// Probes arriving while the checks run wait for them and share the result.
+func (h *handler) readiness(ctx context.Context) apitypes.ReadinessResponse {
+	h.ready.mu.Lock()
+	defer h.ready.mu.Unlock()
+
+	now := time.Now()
+	if now.Before(h.ready.expires) {
+		return h.ready.response
+	}
+
+	// The checks are not cancelled with the probe that happens to run them
+	ctx = context.WithoutCancel(ctx)
+	h.ready.response = runReadyChecks(ctx, h.readyChecks(), h.readyCheckTimeout())
+	h.ready.expires = time.Now().Add(h.readyCacheTTL())
+
+	return h.ready.response
+}
+
+// readyChecks returns the checks that apply to the configured dependencies
+func (h *handler) readyChecks() []readyCheck {
+	checks := []readyCheck{{name: "parse", run: h.checkParseAge}}
+	if repo, ok := h.deps.Repository.(pinger); ok {
+		checks = append(checks, readyCheck{name: "storage", run: pingCheck(repo)})
+	}
+	if fightCache, ok := h.deps.FightCache.(pinger); ok {
+		checks = append(checks, readyCheck{name: "cache", run: pingCheck(fightCache)})
+	}
+
+	return checks
+}
+
+// runReadyChecks runs the checks in parallel, each within timeout
+func runReadyChecks(ctx context.Context, checks []readyCheck, timeout time.Duration) apitypes.ReadinessResponse {
+	results := make([]apitypes.ReadinessCheck, len(checks))
+	var wg sync.WaitGroup
+	for i, check := range checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i] = runReadyCheck(ctx, check, timeout)
+		}()
+	}
+	wg.Wait()
+	sort.Slice(results, func(i, j int) bool { return results[i].Name < results[j].Name })
+
+	response := apitypes.ReadinessResponse{Status: "ready", CheckedAt: time.Now(), Checks: results}
+	for _, result := range results {
+		if result.Status != checkOK {
+			response.Status = "not_ready"
+		}
+	}
+
+	return response
+}
+
+// runReadyCheck runs a single check within timeout
+// A check still running at the timeout is reported failed without waiting
+// for it.
+func runReadyCheck(ctx context.Context, check readyCheck, timeout time.Duration) apitypes.ReadinessCheck {
+	start := time.Now()
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	type outcome struct {
+		detail string
+		err    error
+	}
+	done := make(chan outcome, 1)
+	go func() {
+		detail, err := check.run(ctx)
+		done <- outcome{detail: detail, err: err}
+	}()
+
+	var result outcome
+	select {
+	case result = <-done:
+	case <-ctx.Done():
+		result.err = fmt.Errorf("timed out after %s", timeout)
+	}
+
+	checkResult := apitypes.ReadinessCheck{
+		Name:       check.name,
+		Status:     checkOK,
+		DurationMs: time.Since(start).Milliseconds(),
+		Detail:     result.detail,
+	}
+	if result.err != nil {
+		checkResult.Status = checkFailed
+		checkResult.Error = result.err.Error()
+	}
+
+	return checkResult
+}
+
+// pingCheck checks that a dependency answers
+func pingCheck(dependency pinger) func(context.Context) (string, error) {
+	return func(ctx context.Context) (string, error) {
+		return "", dependency.Ping(ctx)
+	}
+}
+
+// checkParseAge checks that the last successful parse is recent enough
+// A parse result cached by another instance sharing the fight cache counts
+// as well. Before the first parse the age is counted from the start, so a
+// new instance is ready until it had the time for one.
+func (h *handler) checkParseAge(_ context.Context) (string, error) {
+	if h.deps.Parser == nil {
+		return "no parser configured", nil
+	}
+
+	last := h.startedAt
+	if parsedAt := max(h.parsedAt.Load(), h.cachedAt.Load()); parsedAt > 0 {
+		last = time.Unix(0, parsedAt)
+	}
+	age := time.Since(last).Truncate(time.Second)
+	maxAge := h.deps.MaxParseAge
+	if maxAge <= 0 {
+		maxAge = DefaultMaxParseAge
+	}
+
+	detail := fmt.Sprintf("last successful parse %s ago", age)
+	if h.parsedAt.Load() == 0 && h.cachedAt.Load() == 0 {
+		detail = fmt.Sprintf("no successful parse in the %s since the start", age)
+	}
+	if age > maxAge {
+		return detail, errors.New("the last successful parse is older than " + maxAge.String())
+	}
+
+	return detail, nil
+}
+
+// readyCheckTimeout returns the timeout of every readiness check
+func (h *handler) readyCheckTimeout() time.Duration {
+	if h.deps.ReadyCheckTimeout > 0 {
+		return h.deps.ReadyCheckTimeout
+	}
+	return DefaultReadyCheckTimeout
+}
+
+// readyCacheTTL returns how long a readiness result is reused
+func (h *handler) readyCacheTTL() time.Duration {
+	if h.deps.ReadyCacheTTL > 0 {
+		return h.deps.ReadyCacheTTL
+	}
+	return DefaultReadyCacheTTL
+}
@@ -0,0 +1,219 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"easypars/pkg/apitypes"
+	"easypars/pkg/parser"
+	"easypars/pkg/safeexec"
+	"easypars/pkg/storage"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestHealthReportsDegradedConfig(t *testing.T) {
+	router := newTestRouter(t, readTestdata(t, "results.html"), Dependencies{})
+	const source = "volatile pattern 'health test'"
+	health := func() apitypes.HealthResponse {
+		t.Helper()
+		var body apitypes.HealthResponse
+		decodeJSON(t, serve(router, http.MethodGet, "/api/health", ""), &body)
+		return body
+	}
+
+	safeexec.Default.Exec(source, func() error { panic("broken pattern") })
+	t.Cleanup(func() { safeexec.Default.Exec(source, func() error { return nil }) })
+
+	body := health()
+	if body.Status != "degraded" || !slices.Contains(body.Reasons, "degraded_config") {
+		t.Errorf("health with a broken element = %s %q, want degraded with degraded_config", body.Status, body.Reasons)
+	}
+	if len(body.DegradedConfig) != 1 || body.DegradedConfig[0].Source != source {
+		t.Errorf("degraded_config = %+v, want %s", body.DegradedConfig, source)
+	}
+
+	// A later failure is counted, the element stays listed
+	safeexec.Default.Exec(source, func() error { return errors.New("still broken") })
+	if body := health(); len(body.DegradedConfig) != 1 || body.DegradedConfig[0].Errors != 2 {
+		t.Errorf("degraded_config = %+v, want two errors", body.DegradedConfig)
+	}
+
+	// The element runs successfully again once the configuration is fixed
+	safeexec.Default.Exec(source, func() error { return nil })
+	body = health()
+	if slices.Contains(body.Reasons, "degraded_config") || len(body.DegradedConfig) != 0 {
+		t.Errorf("health after the fix = %s %q %+v, want no degraded config", body.Status, body.Reasons, body.DegradedConfig)
+	}
+}
+
+// stubPinger answers pings with err after delay, counting them
+type stubPinger struct {
+	err   error
+	delay time.Duration
+	pings atomic.Int32
+}
+
+func (p *stubPinger) Ping(ctx context.Context) error {
+	p.pings.Add(1)
+	select {
+	case <-time.After(p.delay):
+		return p.err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// pingingRepository is a fight repository behind a database server
+type pingingRepository struct {
+	storage.FightRepository
+	*stubPinger
+}
+
+// pingingCache is a fight cache behind a cache server
+type pingingCache struct {
+	*stubFightStore
+	*stubPinger
+}
+
+// newReadyRouter returns a router with a repository and a fight cache
+// answering pings like the stubs
+func newReadyRouter(t *testing.T, db, cacheServer *stubPinger) *gin.Engine {
+	t.Helper()
+
+	repo, err := storage.OpenFile(filepath.Join(t.TempDir(), "fights.json"), false, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return newTestRouter(t, readTestdata(t, "results.html"), Dependencies{
+		Repository:        pingingRepository{repo, db},
+		FightCache:        pingingCache{&stubFightStore{}, cacheServer},
+		ReadyCheckTimeout: 100 * time.Millisecond,
+	})
+}
+
+// checkStatuses returns the status of every readiness check by name
+func checkStatuses(body apitypes.ReadinessResponse) map[string]string {
+	statuses := make(map[string]string, len(body.Checks))
+	for _, check := range body.Checks {
+		statuses[check.Name] = check.Status
+	}
+
+	return statuses
+}
+
+func TestHealthReady(t *testing.T) {
+	down := errors.New("connection refused")
+	tests := []struct {
+		name    string
+		db      *stubPinger
+		cache   *stubPinger
+		status  int
+		checks  map[string]string
+		errText string
+	}{
+		{"healthy", &stubPinger{}, &stubPinger{}, http.StatusOK,
+			map[string]string{"parse": "ok", "storage": "ok", "cache": "ok"}, ""},
+		{"database down", &stubPinger{err: down}, &stubPinger{}, http.StatusServiceUnavailable,
+			map[string]string{"parse": "ok", "storage": "failed", "cache": "ok"}, "connection refused"},
+		{"cache down", &stubPinger{}, &stubPinger{err: down}, http.StatusServiceUnavailable,
+			map[string]string{"parse": "ok", "storage": "ok", "cache": "failed"}, "connection refused"},
+		{"cache hanging", &stubPinger{}, &stubPinger{delay: time.Hour}, http.StatusServiceUnavailable,
+			map[string]string{"parse": "ok", "storage": "ok", "cache": "failed"}, "timed out"},
+		{"everything down", &stubPinger{delay: time.Hour}, &stubPinger{err: down}, http.StatusServiceUnavailable,
+			map[string]string{"parse": "ok", "storage": "failed", "cache": "failed"}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := newReadyRouter(t, tt.db, tt.cache)
+
+			start := time.Now()
+			rec := serve(router, http.MethodGet, "/api/health/ready", "")
+			if elapsed := time.Since(start); elapsed > time.Second {
+				t.Errorf("readiness took %v, want the checks bounded by their timeout", elapsed)
+			}
+			if rec.Code != tt.status {
+				t.Fatalf("GET /api/health/ready = %d %s, want %d", rec.Code, rec.Body, tt.status)
+			}
+			var body apitypes.ReadinessResponse
+			decodeJSON(t, rec, &body)
+			if got := checkStatuses(body); !reflect.DeepEqual(got, tt.checks) {
+				t.Errorf("checks = %v, want %v", got, tt.checks)
+			}
+			if tt.errText != "" && !strings.Contains(rec.Body.String(), tt.errText) {
+				t.Errorf("body = %s, want the error %q", rec.Body, tt.errText)
+			}
+
+			// Liveness does not depend on the dependencies
+			if rec := serve(router, http.MethodGet, "/api/health/live", ""); rec.Code != http.StatusOK {
+				t.Errorf("GET /api/health/live = %d, want 200", rec.Code)
+			}
+		})
+	}
+}
+
+func TestHealthReadyParseAge(t *testing.T) {
+	tests := []struct {
+		name   string
+		stored bool
+		status int
+		detail string
+	}{
+		{"recent parse", false, http.StatusOK, "last successful parse"},
+		{"cached parse of an old day", true, http.StatusServiceUnavailable, "last successful parse"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// The stubbed store holds a parse stored at testNow, long ago
+			store := &stubFightStore{}
+			if tt.stored {
+				store.result = &parser.ParseResult{Fights: storedFights()}
+			}
+			router := newTestRouter(t, readTestdata(t, "results.html"), Dependencies{FightCache: store})
+			if rec := serve(router, http.MethodGet, "/api/fights", ""); rec.Code != http.StatusOK {
+				t.Fatalf("GET /api/fights = %d %s, want 200", rec.Code, rec.Body)
+			}
+
+			rec := serve(router, http.MethodGet, "/api/health/ready", "")
+			if rec.Code != tt.status {
+				t.Fatalf("GET /api/health/ready = %d %s, want %d", rec.Code, rec.Body, tt.status)
+			}
+			var body apitypes.ReadinessResponse
+			decodeJSON(t, rec, &body)
+			if len(body.Checks) != 1 || !strings.HasPrefix(body.Checks[0].Detail, tt.detail) {
+				t.Errorf("checks = %+v, want the parse check with %q", body.Checks, tt.detail)
+			}
+		})
+	}
+}
+
+func TestHealthReadyIsCached(t *testing.T) {
+	db, cacheServer := &stubPinger{delay: 50 * time.Millisecond}, &stubPinger{}
+	router := newReadyRouter(t, db, cacheServer)
+
+	// A storm of probes runs the checks once
+	var wg sync.WaitGroup
+	for range 20 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			serve(router, http.MethodGet, "/api/health/ready", "")
+		}()
+	}
+	wg.Wait()
+	serve(router, http.MethodGet, "/api/health/ready", "")
+
+	if db.pings.Load() != 1 || cacheServer.pings.Load() != 1 {
+		t.Errorf("pings = %d to the database and %d to the cache, want one each", db.pings.Load(), cacheServer.pings.Load())
+	}
+}
@@ -0,0 +1,110 @@
+package api
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"easypars/pkg/clock"
+	"easypars/pkg/parser"
+
+	"github.com/gin-gonic/gin"
+)
+
+func init() {
+	gin.SetMode(gin.TestMode)
+	gin.DefaultWriter = io.Discard
+}
+
+// testNow is the parser clock of the API tests
+var testNow = time.Date(2024, time.June, 10, 12, 0, 0, 0, time.UTC)
+
+// newTestSource serves the page as the source site
+func newTestSource(t *testing.T, page string) *httptest.Server {
+	t.Helper()
+
+	src := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		io.WriteString(w, page)
+	}))
+	t.Cleanup(src.Close)
+
+	return src
+}
+
+// newTestParser returns a parser of the page with the clock at testNow
+func newTestParser(t *testing.T, page string) *parser.Parser {
+	t.Helper()
+
+	src := newTestSource(t, page)
+	p := parser.NewParser(src.URL + "/")
+	p.Clock = clock.Fixed{Time: testNow}
+
+	return p
+}
+
+// newTestRouter returns the router serving the page, deps.Parser is
+// replaced with a parser of the page
+func newTestRouter(t *testing.T, page string, deps Dependencies) *gin.Engine {
+	t.Helper()
+
+	deps.Parser = newTestParser(t, page)
+
+	return SetupRouter(deps)
+}
+
+// readTestdata returns a file of the testdata directory
+func readTestdata(t *testing.T, name string) string {
+	t.Helper()
+
+	data, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return string(data)
+}
+
+// serve performs a request against the router
+// headers are given as name, value pairs.
+func serve(router http.Handler, method, target, body string, headers ...string) *httptest.ResponseRecorder {
+	var reader io.Reader
+	if body != "" {
+		reader = strings.NewReader(body)
+	}
+	req := httptest.NewRequest(method, target, reader)
+	for i := 0; i+1 < len(headers); i += 2 {
+		req.Header.Set(headers[i], headers[i+1])
+	}
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	return rec
+}
+
+// decodeJSON decodes the response body into v
+func decodeJSON(t *testing.T, rec *httptest.ResponseRecorder, v any) {
+	t.Helper()
+
+	if err := json.Unmarshal(rec.Body.Bytes(), v); err != nil {
+		t.Fatalf("decoding %q: %v", rec.Body.String(), err)
+	}
+}
+
+// errorCode returns the error code of an error response
+func errorCode(t *testing.T, rec *httptest.ResponseRecorder) string {
+	t.Helper()
+
+	var body struct {
+		Error string `json:"error"`
+	}
+	decodeJSON(t, rec, &body)
+
+	return body.Error
+}
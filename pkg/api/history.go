@@ -0,0 +1,82 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// handleGetParseHistory handles GET requests to /api/parse-history
+// Returns the retained parse runs, newest first
+func (h *handler) handleGetParseHistory(c *gin.Context) {
+	if h.deps.History == nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error":   "history_disabled",
+			"message": "Parse history is not enabled",
+		})
+		return
+	}
+
+	runs := h.deps.History.List()
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Parse history retrieved successfully",
+		"data":    runs,
+		"count":   len(runs),
+		"summary": h.deps.History.Summary(),
+	})
+}
+
+// handleGetParseRunLog handles GET requests to /api/parse-history/:run_id/log
+// Returns the log records captured for a single run as JSON or plain text (?format=text)
+func (h *handler) handleGetParseRunLog(c *gin.Context) {
+	runID := c.Param("run_id")
+
+	if h.deps.History == nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error":   "history_disabled",
+			"message": "Parse history is not enabled",
+		})
+		return
+	}
+
+	entries, ok := h.deps.History.Logs(runID)
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error":   "run_not_found",
+			"message": fmt.Sprintf("Parse run %s not found", runID),
+		})
+		return
+	}
+
+	switch c.DefaultQuery("format", "json") {
+	case "json":
+		c.JSON(http.StatusOK, gin.H{
+			"run_id": runID,
+			"data":   entries,
+			"count":  len(entries),
+		})
+	case "text":
+		var b strings.Builder
+		for _, entry := range entries {
+			fmt.Fprintf(&b, "%s %s %s", entry.Time.Format("2006-01-02T15:04:05.000Z07:00"), entry.Level, entry.Message)
+			keys := make([]string, 0, len(entry.Attrs))
+			for key := range entry.Attrs {
+				keys = append(keys, key)
+			}
+			sort.Strings(keys)
+			for _, key := range keys {
+				fmt.Fprintf(&b, " %s=%q", key, entry.Attrs[key])
+			}
+			b.WriteString("\n")
+		}
+		c.String(http.StatusOK, b.String())
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "invalid_format",
+			"message": "format must be json or text",
+		})
+	}
+}
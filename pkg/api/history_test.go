@@ -0,0 +1,93 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"easypars/models"
+	"easypars/pkg/apitypes"
+	"easypars/pkg/storage"
+)
+
+func TestFightHistoryOfAStoredFight(t *testing.T) {
+	repo, err := storage.OpenFile(filepath.Join(t.TempDir(), "fights.json"), false, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// A fight no longer on the source page, stored twice with different results
+	gone := models.Fight{Date: "2023-12-23", Fighter1: "Anthony Joshua", Fighter2: "Otto Wallin", Result: "vs", Location: "Riyadh", Status: models.StatusScheduled}
+	gone.AssignKey()
+	ctx := context.Background()
+	if _, err := repo.UpsertFights(ctx, []models.Fight{gone}); err != nil {
+		t.Fatal(err)
+	}
+	gone.Result, gone.Status = "RTD 5", models.StatusCompleted
+	if _, err := repo.UpsertFights(ctx, []models.Fight{gone}); err != nil {
+		t.Fatal(err)
+	}
+	router := newTestRouter(t, readTestdata(t, "results.html"), Dependencies{Repository: repo})
+
+	tests := []struct {
+		name   string
+		id     string
+		status int
+	}{
+		{"stored ID", gone.ID, http.StatusOK},
+		{"stored key", url.PathEscape(gone.Key), http.StatusOK},
+		{"unknown ID", models.FightID("2000-01-01|a|b|"), http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		rec := serve(router, http.MethodGet, "/api/fights/"+tt.id+"/history", "")
+		if rec.Code != tt.status {
+			t.Errorf("%s: GET history = %d %s, want %d", tt.name, rec.Code, rec.Body, tt.status)
+			continue
+		}
+		if tt.status != http.StatusOK {
+			continue
+		}
+		var body apitypes.FightHistoryResponse
+		decodeJSON(t, rec, &body)
+		if body.Key != gone.Key || body.Count == 0 {
+			t.Errorf("%s: history of %s with %d changes, want the changes of %s", tt.name, body.Key, body.Count, gone.Key)
+		}
+	}
+}
+
+func TestFightHistoryErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		id      string
+		storage bool
+		status  int
+		code    string
+	}{
+		{"without storage", storedFights()[0].ID, false, http.StatusServiceUnavailable, "history_unavailable"},
+		{"overlong id", strings.Repeat("a", maxSlugLength+1), true, http.StatusBadRequest, "invalid_params"},
+		{"unknown id", models.FightID("2000-01-01|a|b|"), true, http.StatusNotFound, "not_found"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var deps Dependencies
+			if tt.storage {
+				repo, err := storage.OpenFile(filepath.Join(t.TempDir(), "fights.json"), false, false)
+				if err != nil {
+					t.Fatal(err)
+				}
+				deps.Repository = repo
+			}
+			router := newTestRouter(t, readTestdata(t, "results.html"), deps)
+
+			rec := serve(router, http.MethodGet, "/api/fights/"+tt.id+"/history", "")
+			if rec.Code != tt.status {
+				t.Fatalf("GET history = %d %s, want %d", rec.Code, rec.Body, tt.status)
+			}
+			if code := errorCode(t, rec); code != tt.code {
+				t.Errorf("error = %q, want %q", code, tt.code)
+			}
+		})
+	}
+}
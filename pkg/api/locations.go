@@ -0,0 +1,70 @@
+package api
+
+import (
+	"log/slog"
+	"net/http"
+
+	"easypars/pkg/apitypes"
+	"easypars/pkg/locations"
+
+	"github.com/gin-gonic/gin"
+)
+
+// handleGetLocations handles GET requests to /api/locations
+// Returns the canonical locations with the spellings found in the data;
+// fights reference them through location_id
+func (h *handler) handleGetLocations(c *gin.Context) {
+	snap, err := h.refreshSnapshot(c.Request.Context())
+	if abortIfClientGone(c, err) {
+		return
+	}
+	if err != nil {
+		slog.ErrorContext(c.Request.Context(), "Failed to load locations", "error", err)
+		h.respondError(c, err, "parse_error", "Failed to load location data")
+		return
+	}
+
+	data := snap.Locations()
+	if data == nil {
+		data = []locations.Location{}
+	}
+
+	setServerTiming(c, snap)
+	c.JSON(http.StatusOK, apitypes.LocationsResponse{
+		Message: "List of locations retrieved successfully",
+		Data:    data,
+		Count:   len(data),
+	})
+}
+
+// handleGetDataQuality handles GET requests to /api/admin/data-quality
+// Reports data problems that need manual attention, such as location
+// spellings to add to the alias dictionary
+func (h *handler) handleGetDataQuality(c *gin.Context) {
+	snap, err := h.refreshSnapshot(c.Request.Context())
+	if abortIfClientGone(c, err) {
+		return
+	}
+	if err != nil {
+		slog.ErrorContext(c.Request.Context(), "Failed to load data quality report", "error", err)
+		h.respondError(c, err, "parse_error", "Failed to load fight data")
+		return
+	}
+
+	response := apitypes.DataQualityResponse{
+		Message:            "Data quality report retrieved successfully",
+		QualityScore:       snap.QualityScore(),
+		Warnings:           snap.AllWarnings(),
+		LocationCandidates: snap.LocationCandidates(),
+	}
+	if response.Warnings == nil {
+		response.Warnings = []string{}
+	}
+	if response.LocationCandidates == nil {
+		response.LocationCandidates = []locations.Candidate{}
+	}
+	response.MissingFights = h.missingFights(snap.Fights)
+
+	setServerTiming(c, snap)
+	c.JSON(http.StatusOK, response)
+}
@@ -0,0 +1,21 @@
+package api
+
+import (
+	"time"
+
+	"easypars/pkg/metrics"
+
+	"github.com/gin-gonic/gin"
+)
+
+// metricsPath serves the Prometheus metrics (see probePaths)
+const metricsPath = "/metrics"
+
+// httpMetrics records every handled request in the Prometheus metrics by
+// its route pattern and status
+func httpMetrics(c *gin.Context) {
+	start := time.Now()
+	c.Next()
+
+	metrics.ObserveHTTPRequest(c.Request.Method, c.FullPath(), c.Writer.Status(), time.Since(start))
+}
@@ -0,0 +1,79 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"easypars/pkg/cache"
+	"easypars/pkg/clock"
+	"easypars/pkg/parser"
+)
+
+func TestMetricsScrape(t *testing.T) {
+	// Building routers again must not register the collectors twice
+	deps := Dependencies{
+		ServeMetrics: true,
+		FightCache:   cache.NewMemory[*parser.ParseResult](time.Hour, clock.Real{}),
+	}
+	router := newTestRouter(t, readTestdata(t, "results.html"), deps)
+	newTestRouter(t, readTestdata(t, "results.html"), Dependencies{ServeMetrics: true})
+
+	// A parse and a cached read of the fights, then a request of no route
+	serve(router, http.MethodGet, "/api/fights", "")
+	serve(router, http.MethodGet, "/api/fights", "")
+	serve(router, http.MethodGet, "/no/such/path", "")
+
+	// A source answering 500 for the parse errors and upstream codes
+	broken := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "down", http.StatusInternalServerError)
+	}))
+	defer broken.Close()
+	failing := SetupRouter(Dependencies{Parser: parser.NewParser(broken.URL + "/"), ServeMetrics: true})
+	serve(failing, http.MethodGet, "/api/fights", "")
+
+	rec := serve(router, http.MethodGet, "/metrics", "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("GET /metrics = %d, want 200", rec.Code)
+	}
+	if got := rec.Header().Get("Content-Type"); !strings.HasPrefix(got, "text/plain") {
+		t.Errorf("Content-Type = %q, want the text exposition format", got)
+	}
+	scrape := rec.Body.String()
+
+	for _, want := range []string{
+		"# TYPE easypars_http_requests_total counter",
+		`easypars_http_requests_total{method="GET",route="/api/fights",status="200"}`,
+		`easypars_http_requests_total{method="GET",route="unmatched",status="404"}`,
+		"# TYPE easypars_http_request_duration_seconds histogram",
+		`easypars_http_request_duration_seconds_bucket{method="GET",route="/api/fights",le="+Inf"}`,
+		"# TYPE easypars_parse_duration_seconds histogram",
+		"# TYPE easypars_parse_fights histogram",
+		"# TYPE easypars_parse_errors_total counter",
+		`easypars_parse_errors_total{type="`,
+		`easypars_cache_requests_total{cache="fights",result="hit"}`,
+		`easypars_cache_requests_total{cache="fights",result="miss"}`,
+		`easypars_upstream_responses_total{code="200"}`,
+		`easypars_upstream_responses_total{code="500"}`,
+		"go_goroutines",
+		"process_cpu_seconds_total",
+	} {
+		if !strings.Contains(scrape, want) {
+			t.Errorf("scrape has no %s", want)
+		}
+	}
+
+	// The unmatched route keeps the scanned paths out of the labels
+	if strings.Contains(scrape, "/no/such/path") {
+		t.Error("scrape has a series of an unmatched path")
+	}
+}
+
+func TestMetricsDisabled(t *testing.T) {
+	router := newTestRouter(t, readTestdata(t, "results.html"), Dependencies{})
+	if rec := serve(router, http.MethodGet, "/metrics", ""); rec.Code != http.StatusNotFound {
+		t.Errorf("GET /metrics without ServeMetrics = %d, want 404", rec.Code)
+	}
+}
@@ -0,0 +1,78 @@
+package api
+
+import (
+	"context"
+	"log/slog"
+	"slices"
+	"time"
+
+	"easypars/models"
+	"easypars/pkg/apitypes"
+	"easypars/pkg/parser"
+	"easypars/pkg/storage"
+)
+
+// keepMissing checks which stored fights of the parsed page disappeared
+// from it and adds the ones still in their grace period to the result
+// A fight leaving the results page has usually moved to the archive of its
+// month, so it is served with missing_since instead of vanishing from the
+// data; see storage.TrackMissing. Without storage the result is unchanged.
+func (h *handler) keepMissing(ctx context.Context, result *parser.ParseResult) *parser.ParseResult {
+	if h.deps.Repository == nil {
+		return result
+	}
+
+	report, err := storage.TrackMissing(ctx, h.deps.Repository, result.Provenance.URL, result.Fights, h.now(), h.deps.MissingGrace)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to track missing fights", "url", result.Provenance.URL, "error", err)
+		return result
+	}
+	if len(report.Marked) > 0 {
+		slog.InfoContext(ctx, "Fights missing from the page, kept until confirmed", "url", result.Provenance.URL, "fight_keys", report.Marked)
+	}
+	if len(report.Deleted) > 0 {
+		slog.InfoContext(ctx, "Missing fights deleted after the grace period", "url", result.Provenance.URL, "fight_keys", report.Deleted)
+	}
+	if len(report.Missing) == 0 {
+		return result
+	}
+
+	// The parse result may be shared with the parser cache, so it is copied
+	kept := *result
+	kept.Fights = append(slices.Clip(result.Fights), report.Missing...)
+	return &kept
+}
+
+// missingFights lists the fights marked as missing for the data quality report
+func (h *handler) missingFights(fights []models.Fight) []apitypes.MissingFight {
+	grace := h.deps.MissingGrace
+	if grace <= 0 {
+		grace = storage.DefaultMissingGrace
+	}
+
+	missing := []apitypes.MissingFight{}
+	for _, fight := range fights {
+		if fight.MissingSince == nil {
+			continue
+		}
+		missing = append(missing, apitypes.MissingFight{
+			Key:          fight.Key,
+			Date:         fight.Date,
+			Fighter1:     fight.Fighter1,
+			Fighter2:     fight.Fighter2,
+			SourceURL:    fight.SourceURL,
+			MissingSince: *fight.MissingSince,
+			DeleteAfter:  fight.MissingSince.Add(grace),
+		})
+	}
+
+	return missing
+}
+
+// now returns the current time of the parser clock
+func (h *handler) now() time.Time {
+	if parserClock := h.parserClock(); parserClock != nil {
+		return parserClock.Now()
+	}
+	return time.Now()
+}
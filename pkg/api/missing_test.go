@@ -0,0 +1,50 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"easypars/models"
+	"easypars/pkg/apitypes"
+	"easypars/pkg/storage"
+)
+
+func TestMissingFightsAreKeptAndReported(t *testing.T) {
+	repo, err := storage.OpenFile(filepath.Join(t.TempDir(), "fights.json"), false, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := newTestParser(t, readTestdata(t, "results.html"))
+	// A fight last seen on the results page, no longer on it
+	gone := models.Fight{Date: "2024-05-11", Fighter1: "Anthony Joshua", Fighter2: "Otto Wallin", Result: "RTD 5", Location: "Riyadh", Status: models.StatusCompleted, SourceURL: p.BaseURL}
+	gone.AssignKey()
+	if _, err := repo.UpsertFights(context.Background(), []models.Fight{gone}); err != nil {
+		t.Fatal(err)
+	}
+	router := SetupRouter(Dependencies{Parser: p, Repository: repo, Auth: newTestAuth(t), MissingGrace: 3 * 24 * time.Hour})
+
+	rec := serve(router, http.MethodGet, "/api/fights?search=Wallin", "")
+	var fights apitypes.FightsResponse
+	decodeJSON(t, rec, &fights)
+	if rec.Code != http.StatusOK || len(fights.Data) != 1 || fights.Data[0].MissingSince == nil {
+		t.Fatalf("GET /api/fights = %d with %+v, want the missing fight with missing_since", rec.Code, fights.Data)
+	}
+	if !fights.Data[0].MissingSince.Equal(testNow) {
+		t.Errorf("missing_since = %s, want %s", fights.Data[0].MissingSince, testNow)
+	}
+
+	token := "Bearer " + signTestToken(t, testJWTSecret, time.Now())
+	rec = serve(router, http.MethodGet, "/api/admin/data-quality", "", "Authorization", token)
+	var quality apitypes.DataQualityResponse
+	decodeJSON(t, rec, &quality)
+	if rec.Code != http.StatusOK || len(quality.MissingFights) != 1 {
+		t.Fatalf("GET /api/admin/data-quality = %d with %+v, want the missing fight", rec.Code, quality.MissingFights)
+	}
+	missing := quality.MissingFights[0]
+	if missing.Key != gone.Key || missing.SourceURL != p.BaseURL || !missing.DeleteAfter.Equal(testNow.Add(3*24*time.Hour)) {
+		t.Errorf("missing fight = %+v, want %s deleted after the grace period", missing, gone.Key)
+	}
+}
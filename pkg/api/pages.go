@@ -0,0 +1,88 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strconv"
+
+	"easypars/models"
+	"easypars/pkg/contract"
+	"easypars/pkg/history"
+	"easypars/pkg/parser"
+	"easypars/pkg/snapshot"
+)
+
+// maxResultPages bounds ?pages, every page is an outbound request
+const maxResultPages = 10
+
+// resultPages returns the number of result pages requested with ?pages,
+// 1 when absent or invalid; invalid values are rejected by
+// validateFightsParams, which runs after the cost guard
+func resultPages(params url.Values) int {
+	pages, err := strconv.Atoi(params.Get("pages"))
+	if err != nil || pages < 1 || pages > maxResultPages {
+		return 1
+	}
+	return pages
+}
+
+// withResultPages adds the fights of result pages 2 to pages to a snapshot
+// The extra pages are parsed on every request and persisted like the main
+// page; the returned snapshot is built for the request and not published,
+// so other clients keep seeing the main page only. A fight on the main
+// page wins over the same fight on a later page.
+func (h *handler) withResultPages(ctx context.Context, snap *snapshot.Snapshot, pages int) (*snapshot.Snapshot, error) {
+	if pages <= 1 {
+		return snap, nil
+	}
+	if h.deps.Parser == nil || h.deps.Parser.PageURL == "" {
+		return nil, parser.Classify(parser.ErrorOriginConfig, fmt.Errorf("result pages are not configured (parser.page_url)"))
+	}
+
+	// Step 1: Parse the later pages like an API refresh
+	ctx = parser.WithInteractive(ctx)
+	var result *parser.ParseResult
+	var err error
+	if h.deps.History == nil {
+		result, err = h.deps.Parser.ParseWithPagination(ctx, 2, pages)
+	} else {
+		run := h.deps.History.Start("pages")
+		result, err = h.deps.Parser.ParseWithPagination(history.WithRunID(ctx, run.ID), 2, pages)
+		h.deps.History.Finish(run.ID, history.ResultOf(result, err))
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := h.deps.Contract.Check(contract.BoundaryParser, result.Fights); err != nil {
+		return nil, err
+	}
+	h.persistFights(ctx, result.Fights)
+
+	// Step 2: Build a snapshot of the merged fights for this request
+	seen := make(map[string]bool, len(snap.Fights))
+	merged := make([]models.Fight, 0, len(snap.Fights)+len(result.Fights))
+	for _, fight := range snap.Fights {
+		seen[fight.Key] = true
+		merged = append(merged, fight)
+	}
+	for _, fight := range result.Fights {
+		if !seen[fight.Key] {
+			seen[fight.Key] = true
+			merged = append(merged, fight)
+		}
+	}
+
+	extended := snapshot.BuildWith(merged, snapshot.BuildOptions{
+		LocationAliases: h.deps.LocationAliases,
+		Scoring:         h.deps.Scoring,
+		SearchCounts:    h.searchCounts,
+		News:            h.deps.News.Items(),
+	})
+	extended.Columns = snap.Columns
+	extended.Fingerprint = snap.Fingerprint
+	extended.NextExpectedChange = snap.NextExpectedChange
+	extended.Warnings = append(append([]string(nil), snap.Warnings...), extended.Warnings...)
+
+	return extended, nil
+}
@@ -0,0 +1,91 @@
+package api
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"easypars/pkg/apitypes"
+	"easypars/pkg/clock"
+	"easypars/pkg/parser"
+)
+
+// aprilPage is a later result page with two April fights
+const aprilPage = `<html><body><div class="month">Апрель 2024</div><table>` +
+	`<tr><td class="date">6</td><td class="place">London</td><td class="boxer_1">Leigh Wood</td><td class="vs">TKO 7</td><td class="boxer_2">Josh Warrington</td></tr>` +
+	`<tr><td class="date">20</td><td class="place">Riyadh</td><td class="boxer_1">Artur Beterbiev</td><td class="vs">KO 7</td><td class="boxer_2">Callum Smith</td></tr>` +
+	`</table></body></html>`
+
+// newPagedParser returns a parser of results.html with aprilPage as page 2,
+// the pages from 3 on answer 404
+func newPagedParser(t *testing.T) *parser.Parser {
+	t.Helper()
+
+	results := readTestdata(t, "results.html")
+	src := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/":
+			w.Header().Set("Content-Type", "text/html; charset=utf-8")
+			io.WriteString(w, results)
+		case "/page/2":
+			w.Header().Set("Content-Type", "text/html; charset=utf-8")
+			io.WriteString(w, aprilPage)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(src.Close)
+
+	p := parser.NewParser(src.URL + "/")
+	p.PageURL = src.URL + "/page/{page}"
+	p.Clock = clock.Fixed{Time: testNow}
+
+	return p
+}
+
+func TestFightsWithResultPages(t *testing.T) {
+	router := SetupRouter(Dependencies{Parser: newPagedParser(t)})
+
+	tests := []struct {
+		name   string
+		query  string
+		status int
+		count  int
+	}{
+		{"main page only", "", http.StatusOK, 6},
+		{"two pages", "?pages=2", http.StatusOK, 8},
+		{"results end before the last page", "?pages=5", http.StatusOK, 8},
+		{"main page again", "", http.StatusOK, 6},
+		{"zero pages", "?pages=0", http.StatusBadRequest, 0},
+		{"too many pages", "?pages=11", http.StatusBadRequest, 0},
+		{"not a number", "?pages=all", http.StatusBadRequest, 0},
+	}
+	for _, tt := range tests {
+		rec := serve(router, http.MethodGet, "/api/fights"+tt.query, "", confirmExpensiveHeader, "true")
+		if rec.Code != tt.status {
+			t.Errorf("%s: GET /api/fights%s = %d %s, want %d", tt.name, tt.query, rec.Code, rec.Body, tt.status)
+			continue
+		}
+		if tt.status != http.StatusOK {
+			if code := errorCode(t, rec); code != "invalid_params" {
+				t.Errorf("%s: error = %q, want invalid_params", tt.name, code)
+			}
+			continue
+		}
+		var body apitypes.FightsResponse
+		decodeJSON(t, rec, &body)
+		if body.Count != tt.count {
+			t.Errorf("%s: count = %d, want %d", tt.name, body.Count, tt.count)
+		}
+	}
+}
+
+func TestFightsWithResultPagesNeedsThePageURL(t *testing.T) {
+	router := newTestRouter(t, readTestdata(t, "results.html"), Dependencies{})
+
+	rec := serve(router, http.MethodGet, "/api/fights?pages=2", "", confirmExpensiveHeader, "true")
+	if rec.Code != http.StatusBadRequest || errorCode(t, rec) != "invalid_params" {
+		t.Errorf("GET ?pages=2 without a page URL = %d %s, want 400 invalid_params", rec.Code, rec.Body)
+	}
+}
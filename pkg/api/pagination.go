@@ -0,0 +1,78 @@
+package api
+
+import (
+	"net/url"
+	"strconv"
+
+	"easypars/models"
+	"easypars/pkg/apitypes"
+)
+
+// Pagination limits of fight lists and groups
+const (
+	defaultPageLimit = 20
+	maxPageLimit     = 100
+)
+
+// listPage returns the page and limit of a fight list request and whether
+// the list is paginated at all
+// Without ?page and ?limit the list is not paginated: the whole list is
+// page 1, as before pagination existed. Values are validated with the other
+// parameters (see fightsParamValidators).
+func listPage(params url.Values) (page, limit int, paginated bool) {
+	if !params.Has("page") && !params.Has("limit") {
+		return 1, 0, false
+	}
+
+	page, limit = 1, defaultPageLimit
+	if n, err := strconv.Atoi(params.Get("page")); err == nil && n > 0 {
+		page = n
+	}
+	if n, err := strconv.Atoi(params.Get("limit")); err == nil && n > 0 {
+		limit = min(n, maxPageLimit)
+	}
+
+	return page, limit, true
+}
+
+// paginate returns the items of the page and its description
+// A page past the end is empty, not an error. A non-positive limit puts
+// every item on page 1. The page is compared with the page count before
+// the offset is computed, so a huge page cannot overflow into a valid one.
+func paginate[T any](items []T, page, limit int, unit string) ([]T, apitypes.Pagination) {
+	total := len(items)
+	if limit <= 0 {
+		return items, apitypes.Pagination{Unit: unit, Page: 1, Limit: total, Total: total, TotalPages: min(total, 1)}
+	}
+
+	totalPages := (total + limit - 1) / limit
+	start := total
+	if page >= 1 && page <= totalPages {
+		start = (page - 1) * limit
+	}
+	end := min(start+limit, total)
+	pageItems := items[start:end]
+	if pageItems == nil {
+		pageItems = []T{}
+	}
+
+	return pageItems, apitypes.Pagination{
+		Unit:       unit,
+		Page:       page,
+		Limit:      limit,
+		Total:      total,
+		TotalPages: totalPages,
+	}
+}
+
+// sortedCanonical returns a copy of the fights in the canonical order
+// (models.CompareCanonical), the order of the unpaginated list, so pages of
+// a list do not overlap or skip fights and follow each other
+// The snapshot slice is shared between requests and must not be reordered
+func sortedCanonical(fights []models.Fight) []models.Fight {
+	sorted := make([]models.Fight, len(fights))
+	copy(sorted, fights)
+	models.SortCanonical(sorted)
+
+	return sorted
+}
@@ -0,0 +1,175 @@
+package api
+
+import (
+	"encoding/csv"
+	"fmt"
+	"math"
+	"net/http"
+	"reflect"
+	"testing"
+
+	"easypars/pkg/apitypes"
+)
+
+func TestPaginate(t *testing.T) {
+	items := []int{1, 2, 3, 4, 5}
+
+	tests := []struct {
+		page, limit int
+		want        string
+		totalPages  int
+	}{
+		{1, 2, "[1 2]", 3},
+		{3, 2, "[5]", 3},
+		{4, 2, "[]", 3},
+		{1, 0, "[1 2 3 4 5]", 1},
+		{math.MaxInt, 20, "[]", 1},
+		{math.MaxInt / 20, 20, "[]", 1},
+		{math.MaxInt, maxPageLimit, "[]", 1},
+	}
+	for _, tt := range tests {
+		got, pagination := paginate(items, tt.page, tt.limit, "fights")
+		if fmt.Sprint(got) != tt.want || pagination.TotalPages != tt.totalPages || pagination.Total != len(items) {
+			t.Errorf("paginate(page %d, limit %d) = %v %+v, want %s with %d pages", tt.page, tt.limit, got, pagination, tt.want, tt.totalPages)
+		}
+	}
+}
+
+func TestHugePageIsEmpty(t *testing.T) {
+	router := newTestRouter(t, readTestdata(t, "results.html"), Dependencies{})
+
+	for _, target := range []string{
+		"/api/fights?page=9223372036854775807&limit=20",
+		"/api/fights?page=461168601842738791&limit=20",
+		"/api/fights?group_by=date&page=9223372036854775807&limit=20",
+		"/api/events?page=9223372036854775807&limit=100",
+	} {
+		rec := serve(router, http.MethodGet, target, "")
+		if rec.Code != http.StatusOK {
+			t.Errorf("GET %s = %d %s, want 200", target, rec.Code, rec.Body)
+			continue
+		}
+		var body struct {
+			Data       []any               `json:"data"`
+			Pagination apitypes.Pagination `json:"pagination"`
+		}
+		decodeJSON(t, rec, &body)
+		if len(body.Data) != 0 || body.Pagination.Total == 0 {
+			t.Errorf("GET %s = %d items of %d, want an empty page past the end", target, len(body.Data), body.Pagination.Total)
+		}
+	}
+}
+
+func TestInvalidPageIsRejected(t *testing.T) {
+	router := newTestRouter(t, readTestdata(t, "results.html"), Dependencies{})
+
+	for _, target := range []string{
+		"/api/fights?page=abc&limit=20",
+		"/api/fights?page=0",
+		"/api/fights?page=99999999999999999999",
+		"/api/fights?group_by=date&page=abc",
+		"/api/fights?group_by=date&page=99999999999999999999",
+	} {
+		if rec := serve(router, http.MethodGet, target, ""); rec.Code != http.StatusBadRequest {
+			t.Errorf("GET %s = %d %s, want 400", target, rec.Code, rec.Body)
+		}
+	}
+}
+
+// fightKeys returns the keys of the fights of a /api/fights response
+func fightKeys(t *testing.T, router http.Handler, target string) []string {
+	t.Helper()
+
+	rec := serve(router, http.MethodGet, target, "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("GET %s = %d %s, want 200", target, rec.Code, rec.Body)
+	}
+	var body apitypes.FightsResponse
+	decodeJSON(t, rec, &body)
+	keys := make([]string, len(body.Data))
+	for i, fight := range body.Data {
+		keys[i] = fight.Key
+	}
+
+	return keys
+}
+
+func TestPagesFollowTheUnpaginatedOrder(t *testing.T) {
+	router := newTestRouter(t, readTestdata(t, "archive.html"), Dependencies{})
+
+	all := fightKeys(t, router, "/api/fights")
+	if len(all) < 25 {
+		t.Fatalf("parsed %d fights, want at least 25", len(all))
+	}
+	pages := append(fightKeys(t, router, "/api/fights?page=1&limit=10"), fightKeys(t, router, "/api/fights?page=2&limit=10")...)
+	if !reflect.DeepEqual(pages, all[:20]) {
+		t.Errorf("pages 1 and 2 =\n%v\nwant the first 20 fights of the list\n%v", pages, all[:20])
+	}
+	if first := fightKeys(t, router, "/api/fights?page=1"); !reflect.DeepEqual(first, all[:20]) {
+		t.Errorf("page 1 =\n%v\nwant the first 20 fights of the list\n%v", first, all[:20])
+	}
+
+	// The list itself is newest first
+	for i := 1; i < len(all); i++ {
+		if all[i-1][:10] < all[i][:10] {
+			t.Errorf("%s is listed before the newer %s", all[i-1], all[i])
+		}
+	}
+}
+
+func TestExportFollowsTheListOrder(t *testing.T) {
+	router := newTestRouter(t, readTestdata(t, "archive.html"), Dependencies{})
+
+	var served apitypes.FightsResponse
+	decodeJSON(t, serve(router, http.MethodGet, "/api/fights", ""), &served)
+	rec := serve(router, http.MethodGet, "/api/fights/export?format=csv", "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("GET /api/fights/export = %d %s, want 200", rec.Code, rec.Body)
+	}
+	records, err := csv.NewReader(rec.Body).ReadAll()
+	if err != nil {
+		t.Fatalf("parsing the export: %v", err)
+	}
+
+	var want, got []string
+	for _, fight := range served.Data {
+		want = append(want, fight.ID)
+	}
+	for _, record := range records[1:] {
+		got = append(got, record[0])
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("exported IDs =\n%v\nwant the order of the list\n%v", got, want)
+	}
+}
+
+func TestPagingBy10LosesAndDuplicatesNothing(t *testing.T) {
+	router := newTestRouter(t, readTestdata(t, "archive.html"), Dependencies{})
+
+	// The default order, then every value of ?sort=
+	for _, query := range []string{"", "sort=date&", "sort=interest&"} {
+		t.Run(query, func(t *testing.T) {
+			all := fightKeys(t, router, "/api/fights?"+query)
+
+			var paged []string
+			for page := 1; ; page++ {
+				keys := fightKeys(t, router, fmt.Sprintf("/api/fights?%slimit=10&page=%d", query, page))
+				if len(keys) == 0 {
+					break
+				}
+				paged = append(paged, keys...)
+			}
+
+			seen := make(map[string]bool, len(paged))
+			for _, key := range paged {
+				if seen[key] {
+					t.Errorf("%s is on two pages", key)
+				}
+				seen[key] = true
+			}
+			if !reflect.DeepEqual(paged, all) {
+				t.Errorf("pages =\n%v\nwant the unpaginated list\n%v", paged, all)
+			}
+		})
+	}
+}
@@ -0,0 +1,155 @@
+package api
+
+import (
+	"fmt"
+	"math"
+	"net/url"
+	"sort"
+	"strconv"
+	"time"
+	"unicode/utf8"
+
+	"easypars/models"
+)
+
+// fightsParamValidators validates each supported /api/fights query parameter
+// The same validators are used for request queries and saved presets
+var fightsParamValidators = map[string]func(string) error{
+	"include_hidden": validateFlag,
+	"rematch":        validateFlag,
+	"group_by":       validateOneOf("date", "location", "event"),
+	"group_order":    validateOneOf("asc", "desc"),
+	"compact":        validateFlag,
+	"sort":           validateOneOf("date", "interest"),
+	"fallback":       validateOneOf("accepted"),
+	"format":         validateMaxLength(maxFormatLength),
+	"page":           validateIntRange(1, 0),
+	"pages":          validateIntRange(1, maxResultPages),
+	"limit":          validateIntRange(1, maxPageLimit),
+	"search":         validateMaxLength(maxSearchLength),
+	"q":              validateMaxLength(maxSearchLength),
+	"status": validateOneOf(models.StatusScheduled, models.StatusCompleted,
+		models.StatusResultUnknown, models.StatusCancelled),
+	"min_confidence":  validateFloatRange(0, 1),
+	"ignore_defaults": validateFlag,
+	"show_cost":       validateFlag,
+	"refresh":         validateFlag,
+	"fighter_country": validateCountry,
+	"country":         validateCountry,
+	"from":            validateDate,
+	"to":              validateDate,
+}
+
+// maxSearchLength bounds the length of a search query in characters
+const maxSearchLength = 100
+
+// maxFormatLength bounds the ?format= value; supported formats are checked
+// when the response is rendered
+const maxFormatLength = 20
+
+// validateFightsParams checks /api/fights query parameters
+// Unknown parameters are rejected only when strict is set
+func validateFightsParams(values url.Values, strict bool) error {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		validate, ok := fightsParamValidators[key]
+		if !ok {
+			if strict {
+				return fmt.Errorf("unknown parameter %q", key)
+			}
+			continue
+		}
+		if err := validate(values.Get(key)); err != nil {
+			return fmt.Errorf("invalid parameter %q: %w", key, err)
+		}
+	}
+
+	// A date range must not end before it starts; the dates compare as text
+	if from, to := values.Get("from"), values.Get("to"); from != "" && to != "" && from > to {
+		return fmt.Errorf(`invalid parameter "from": %s is after "to" %s`, from, to)
+	}
+	// Compact responses are flat rows, they have no grouped form
+	if values.Get("group_by") != "" && flagSet(values.Get("compact")) {
+		return fmt.Errorf(`invalid parameter "compact": cannot be combined with "group_by"`)
+	}
+
+	return nil
+}
+
+// validateFlag accepts boolean flags written as 0/1 or true/false
+func validateFlag(value string) error {
+	switch value {
+	case "0", "1", "true", "false":
+		return nil
+	}
+
+	return fmt.Errorf("must be 0, 1, true or false")
+}
+
+// validateDate accepts a YYYY-MM-DD date
+func validateDate(value string) error {
+	if _, err := time.Parse("2006-01-02", value); err != nil {
+		return fmt.Errorf("must be a date in the format YYYY-MM-DD")
+	}
+
+	return nil
+}
+
+// validateOneOf returns a validator accepting only the listed values
+func validateOneOf(allowed ...string) func(string) error {
+	return func(value string) error {
+		for _, candidate := range allowed {
+			if value == candidate {
+				return nil
+			}
+		}
+		return fmt.Errorf("must be one of %v", allowed)
+	}
+}
+
+// validateMaxLength returns a validator limiting the value length in characters
+func validateMaxLength(limit int) func(string) error {
+	return func(value string) error {
+		if utf8.RuneCountInString(value) > limit {
+			return fmt.Errorf("must be at most %d characters", limit)
+		}
+		return nil
+	}
+}
+
+// validateFloatRange returns a validator for numbers within [min, max]
+func validateFloatRange(min, max float64) func(string) error {
+	return func(value string) error {
+		n, err := strconv.ParseFloat(value, 64)
+		if err != nil || math.IsNaN(n) {
+			return fmt.Errorf("must be a number")
+		}
+		if n < min || n > max {
+			return fmt.Errorf("must be between %g and %g", min, max)
+		}
+		return nil
+	}
+}
+
+// validateIntRange returns a validator for integers within [min, max]
+// A zero max means no upper bound
+func validateIntRange(min, max int) func(string) error {
+	return func(value string) error {
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("must be an integer")
+		}
+		if n < min || (max > 0 && n > max) {
+			if max > 0 {
+				return fmt.Errorf("must be between %d and %d", min, max)
+			}
+			return fmt.Errorf("must be at least %d", min)
+		}
+		return nil
+	}
+}
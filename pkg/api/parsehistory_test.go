@@ -0,0 +1,56 @@
+package api
+
+import (
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+	"testing"
+
+	"easypars/pkg/history"
+)
+
+func TestParseRunLog(t *testing.T) {
+	runs := history.New(10, 500)
+	run := runs.Start("manual")
+	logger := slog.New(history.NewRunLogHandler(slog.NewTextHandler(io.Discard, nil), runs))
+	logger.Info("Fetching fights page", "run_id", run.ID, "url", "https://vringe.example/")
+	logger.Warn("Source answered slowly", "run_id", run.ID)
+	runs.Finish(run.ID, history.RunResult{})
+	router := newTestRouter(t, readTestdata(t, "upcoming.html"), Dependencies{History: runs})
+
+	tests := []struct {
+		name   string
+		target string
+		status int
+		code   string
+		body   []string
+	}{
+		{"json", "/api/parse-history/" + run.ID + "/log", http.StatusOK, "", []string{`"count":2`, `"message":"Fetching fights page"`}},
+		{"text", "/api/parse-history/" + run.ID + "/log?format=text", http.StatusOK, "", []string{"INFO Fetching fights page url=\"https://vringe.example/\"\n", "WARN Source answered slowly\n"}},
+		{"unknown run", "/api/parse-history/run_0_0/log", http.StatusNotFound, "run_not_found", nil},
+		{"unknown format", "/api/parse-history/" + run.ID + "/log?format=xml", http.StatusBadRequest, "invalid_format", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := serve(router, http.MethodGet, tt.target, "")
+			if rec.Code != tt.status {
+				t.Fatalf("GET %s = %d %s, want %d", tt.target, rec.Code, rec.Body, tt.status)
+			}
+			if tt.code != "" && errorCode(t, rec) != tt.code {
+				t.Errorf("error = %q, want %q", errorCode(t, rec), tt.code)
+			}
+			for _, want := range tt.body {
+				if !strings.Contains(rec.Body.String(), want) {
+					t.Errorf("body %s does not contain %q", rec.Body, want)
+				}
+			}
+		})
+	}
+
+	// Without a history the endpoints are disabled
+	disabled := newTestRouter(t, readTestdata(t, "upcoming.html"), Dependencies{})
+	if rec := serve(disabled, http.MethodGet, "/api/parse-history/"+run.ID+"/log", ""); rec.Code != http.StatusNotFound || errorCode(t, rec) != "history_disabled" {
+		t.Errorf("log without a history = %d %s, want 404 history_disabled", rec.Code, rec.Body)
+	}
+}
@@ -0,0 +1,90 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"easypars/pkg/parsejob"
+
+	"github.com/gin-gonic/gin"
+)
+
+// requireParseJobs responds with an error when parse jobs are not configured
+func (h *handler) requireParseJobs(c *gin.Context) bool {
+	if h.deps.ParseJobs != nil && h.deps.Parser != nil {
+		return true
+	}
+
+	c.JSON(http.StatusServiceUnavailable, gin.H{
+		"error":   "parse_jobs_unavailable",
+		"message": "Manual parses require a configured parser",
+	})
+	return false
+}
+
+// manualParse parses the source for a parse job and publishes the result
+// Like the scheduled refresh it does not fall back to stored fights; the
+// result replaces the cached one, so the next requests serve it at once.
+func (h *handler) manualParse(ctx context.Context) (int, error) {
+	result, err := h.parseFights(ctx, parsejob.TriggerManual)
+	if err != nil {
+		return 0, err
+	}
+	h.publishLoaded(ctx, result)
+
+	return len(result.Fights), nil
+}
+
+// handleStartParse handles POST requests to /api/parse
+// Starts a parse of the source in the background and answers 202 with the
+// job; while another job runs, 409 with the running job.
+func (h *handler) handleStartParse(c *gin.Context) {
+	if !h.requireParseJobs(c) {
+		return
+	}
+
+	job, err := h.deps.ParseJobs.Start(h.manualParse)
+	if errors.Is(err, parsejob.ErrRunning) {
+		c.JSON(http.StatusConflict, gin.H{
+			"error":   "parse_running",
+			"message": err.Error(),
+			"job_id":  job.ID,
+			"job":     job,
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{
+			"error":   "parse_jobs_unavailable",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.Header("Location", "/api/parse/"+job.ID)
+	c.JSON(http.StatusAccepted, gin.H{
+		"message": "Parse started",
+		"job_id":  job.ID,
+		"job":     job,
+	})
+}
+
+// handleGetParseJob handles GET requests to /api/parse/:jobID
+// Returns the state of a parse job; finished jobs are kept for a while
+func (h *handler) handleGetParseJob(c *gin.Context) {
+	if !h.requireParseJobs(c) {
+		return
+	}
+
+	job, ok := h.deps.ParseJobs.Get(c.Param("jobID"))
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error":   "not_found",
+			"message": "No parse job with id " + c.Param("jobID"),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"job": job})
+}
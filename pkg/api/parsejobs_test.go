@@ -0,0 +1,92 @@
+package api
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"easypars/pkg/clock"
+	"easypars/pkg/parsejob"
+	"easypars/pkg/parser"
+)
+
+// jobResponse is the body of the parse job endpoints
+type jobResponse struct {
+	Error string          `json:"error"`
+	JobID string          `json:"job_id"`
+	Job   parsejob.Status `json:"job"`
+}
+
+func TestParseJobs(t *testing.T) {
+	// The source answers once release is closed, so the first job stays running
+	page := readTestdata(t, "results.html")
+	release := make(chan struct{})
+	var once sync.Once
+	t.Cleanup(func() { once.Do(func() { close(release) }) })
+	src := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		io.WriteString(w, page)
+	}))
+	t.Cleanup(src.Close)
+	p := parser.NewParser(src.URL + "/")
+	p.Clock = clock.Fixed{Time: testNow}
+	jobs := parsejob.NewManager()
+	t.Cleanup(jobs.Stop)
+	router := SetupRouter(Dependencies{Parser: p, ParseJobs: jobs, Auth: newTestAuth(t)})
+	token := "Bearer " + signTestToken(t, testJWTSecret, time.Now())
+
+	if rec := serve(router, http.MethodPost, "/api/parse", ""); rec.Code != http.StatusUnauthorized {
+		t.Errorf("POST /api/parse without a token = %d, want 401", rec.Code)
+	}
+
+	rec := serve(router, http.MethodPost, "/api/parse", "", "Authorization", token)
+	if rec.Code != http.StatusAccepted {
+		t.Fatalf("POST /api/parse = %d %s, want 202", rec.Code, rec.Body)
+	}
+	var started jobResponse
+	decodeJSON(t, rec, &started)
+	if started.JobID == "" || started.Job.State != parsejob.StateRunning || rec.Header().Get("Location") != "/api/parse/"+started.JobID {
+		t.Fatalf("started job = %+v at %q, want a running job with its location", started, rec.Header().Get("Location"))
+	}
+
+	// A second parse while the first one runs is refused with the running job
+	rec = serve(router, http.MethodPost, "/api/parse", "", "Authorization", token)
+	var conflict jobResponse
+	decodeJSON(t, rec, &conflict)
+	if rec.Code != http.StatusConflict || conflict.Error != "parse_running" || conflict.JobID != started.JobID {
+		t.Errorf("second POST /api/parse = %d %+v, want 409 with job %s", rec.Code, conflict, started.JobID)
+	}
+
+	once.Do(func() { close(release) })
+	var polled jobResponse
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		rec = serve(router, http.MethodGet, "/api/parse/"+started.JobID, "")
+		decodeJSON(t, rec, &polled)
+		if polled.Job.State != parsejob.StateRunning || time.Now().After(deadline) {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if rec.Code != http.StatusOK || polled.Job.State != parsejob.StateSucceeded || polled.Job.FightCount != 6 {
+		t.Errorf("GET /api/parse/%s = %d %+v, want a succeeded job with 6 fights", started.JobID, rec.Code, polled.Job)
+	}
+
+	if rec := serve(router, http.MethodGet, "/api/parse/parse-0-0", ""); rec.Code != http.StatusNotFound || errorCode(t, rec) != "not_found" {
+		t.Errorf("GET of an unknown job = %d %s, want 404 not_found", rec.Code, rec.Body)
+	}
+}
+
+func TestParseJobsNotConfigured(t *testing.T) {
+	router := newTestRouter(t, readTestdata(t, "results.html"), Dependencies{Auth: newTestAuth(t)})
+	token := "Bearer " + signTestToken(t, testJWTSecret, time.Now())
+
+	rec := serve(router, http.MethodPost, "/api/parse", "", "Authorization", token)
+	if rec.Code != http.StatusServiceUnavailable || errorCode(t, rec) != "parse_jobs_unavailable" {
+		t.Errorf("POST /api/parse without parse jobs = %d %s, want 503 parse_jobs_unavailable", rec.Code, rec.Body)
+	}
+}
@@ -0,0 +1,164 @@
+package api
+
+import (
+	"fmt"
+	"log/slog"
+	"math"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// createPresetRequest is the body of POST /api/presets
+type createPresetRequest struct {
+	Name   string                 `json:"name" binding:"required"`
+	Params map[string]interface{} `json:"params" binding:"required"`
+}
+
+// handleCreatePreset handles POST requests to /api/presets
+// Validates the parameters with the /api/fights validators and returns a short slug
+func (h *handler) handleCreatePreset(c *gin.Context) {
+	if h.deps.Presets == nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error":   "presets_disabled",
+			"message": "Presets are not enabled",
+		})
+		return
+	}
+
+	if allowed, retryAfter := h.presetLimiter.Allow(c.ClientIP()); !allowed {
+		c.Header("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+		c.JSON(http.StatusTooManyRequests, gin.H{
+			"error":   "rate_limited",
+			"message": "Too many presets created, try again later",
+		})
+		return
+	}
+
+	var req createPresetRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "invalid_body",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	params, err := presetParams(req.Params)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "invalid_params",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	preset, err := h.deps.Presets.Create(req.Name, params)
+	if err != nil {
+		slog.ErrorContext(c.Request.Context(), "Failed to create preset", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "preset_error",
+			"message": "Failed to create preset",
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Preset created successfully",
+		"data":    preset,
+		"url":     "/api/fights?preset=" + preset.Slug,
+	})
+}
+
+// handleGetPreset handles GET requests to /api/presets/:slug
+func (h *handler) handleGetPreset(c *gin.Context) {
+	if h.deps.Presets == nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error":   "presets_disabled",
+			"message": "Presets are not enabled",
+		})
+		return
+	}
+
+	preset, ok := h.deps.Presets.Get(c.Param("slug"))
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error":   "preset_not_found",
+			"message": fmt.Sprintf("Preset %s not found", c.Param("slug")),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": preset,
+	})
+}
+
+// presetParams converts JSON preset parameters into query values and validates them
+func presetParams(raw map[string]interface{}) (map[string]string, error) {
+	values := url.Values{}
+	params := make(map[string]string, len(raw))
+
+	for key, value := range raw {
+		var str string
+		switch v := value.(type) {
+		case string:
+			str = v
+		case bool:
+			str = "0"
+			if v {
+				str = "1"
+			}
+		case float64:
+			str = strconv.FormatFloat(v, 'f', -1, 64)
+		default:
+			return nil, fmt.Errorf("parameter %q must be a string, number or boolean", key)
+		}
+		params[key] = str
+		values.Set(key, str)
+	}
+
+	if values.Has("preset") {
+		return nil, fmt.Errorf("presets cannot reference other presets")
+	}
+	// The defaults are only turned off with the API key of each request
+	if values.Has("ignore_defaults") {
+		return nil, fmt.Errorf("presets cannot ignore the default filters")
+	}
+	if err := validateFightsParams(values, true); err != nil {
+		return nil, err
+	}
+
+	return params, nil
+}
+
+// applyPreset merges the parameters of the requested preset into the query
+// Parameters given explicitly in the request override the preset ones.
+// Must run before the query is read through gin, which caches it.
+func (h *handler) applyPreset(c *gin.Context) error {
+	query := c.Request.URL.Query()
+	slug := query.Get("preset")
+	if slug == "" {
+		return nil
+	}
+
+	if h.deps.Presets == nil {
+		return fmt.Errorf("presets are not enabled")
+	}
+
+	preset, ok := h.deps.Presets.Get(slug)
+	if !ok {
+		return fmt.Errorf("preset %s not found", slug)
+	}
+
+	for key, value := range preset.Params {
+		if !query.Has(key) {
+			query.Set(key, value)
+		}
+	}
+	c.Request.URL.RawQuery = query.Encode()
+
+	return nil
+}
@@ -0,0 +1,149 @@
+package api
+
+import (
+	"net/http"
+	"testing"
+
+	"easypars/pkg/apitypes"
+	"easypars/pkg/presets"
+
+	"github.com/gin-gonic/gin"
+)
+
+// newPresetRouter returns a router of the results page with an empty preset store
+func newPresetRouter(t *testing.T) *gin.Engine {
+	t.Helper()
+
+	store, err := presets.NewStore(10, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return newTestRouter(t, readTestdata(t, "results.html"), Dependencies{Presets: store})
+}
+
+// createPreset creates a preset and returns its slug
+func createPreset(t *testing.T, router *gin.Engine, body string) string {
+	t.Helper()
+
+	rec := serve(router, http.MethodPost, "/api/presets", body, "Content-Type", "application/json")
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("POST /api/presets %s = %d %s, want 201", body, rec.Code, rec.Body)
+	}
+	var created struct {
+		Data presets.Preset `json:"data"`
+	}
+	decodeJSON(t, rec, &created)
+
+	return created.Data.Slug
+}
+
+func TestCreatePresetValidation(t *testing.T) {
+	tests := []struct {
+		name   string
+		body   string
+		status int
+		code   string
+	}{
+		{"valid", `{"name":"Completed","params":{"status":"completed","limit":5,"compact":true}}`, http.StatusCreated, ""},
+		{"date range", `{"name":"2024","params":{"from":"2024-01-01","to":"2024-12-31"}}`, http.StatusCreated, ""},
+		{"no name", `{"params":{"limit":5}}`, http.StatusBadRequest, "invalid_body"},
+		{"not JSON", `limit=5`, http.StatusBadRequest, "invalid_body"},
+		{"unknown parameter", `{"name":"x","params":{"weight":"heavy"}}`, http.StatusBadRequest, "invalid_params"},
+		{"invalid value", `{"name":"x","params":{"status":"postponed"}}`, http.StatusBadRequest, "invalid_params"},
+		{"limit out of range", `{"name":"x","params":{"limit":0}}`, http.StatusBadRequest, "invalid_params"},
+		{"reversed dates", `{"name":"x","params":{"from":"2024-12-31","to":"2024-01-01"}}`, http.StatusBadRequest, "invalid_params"},
+		{"nested value", `{"name":"x","params":{"status":["completed"]}}`, http.StatusBadRequest, "invalid_params"},
+		{"preset reference", `{"name":"x","params":{"preset":"abcdefgh"}}`, http.StatusBadRequest, "invalid_params"},
+		{"ignore defaults", `{"name":"x","params":{"ignore_defaults":true}}`, http.StatusBadRequest, "invalid_params"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Rejected requests count against the creation limit as well
+			router := newPresetRouter(t)
+			rec := serve(router, http.MethodPost, "/api/presets", tt.body, "Content-Type", "application/json")
+			if rec.Code != tt.status {
+				t.Fatalf("POST /api/presets = %d %s, want %d", rec.Code, rec.Body, tt.status)
+			}
+			if tt.code != "" {
+				if code := errorCode(t, rec); code != tt.code {
+					t.Errorf("error = %q, want %q", code, tt.code)
+				}
+			}
+		})
+	}
+}
+
+func TestApplyPreset(t *testing.T) {
+	router := newPresetRouter(t)
+	slug := createPreset(t, router, `{"name":"First fight","params":{"limit":1}}`)
+
+	rec := serve(router, http.MethodGet, "/api/presets/"+slug, "")
+	var shown struct {
+		Data presets.Preset `json:"data"`
+	}
+	decodeJSON(t, rec, &shown)
+	if rec.Code != http.StatusOK || shown.Data.Name != "First fight" || shown.Data.Params["limit"] != "1" {
+		t.Errorf("GET /api/presets/%s = %d %+v, want the saved preset", slug, rec.Code, shown.Data)
+	}
+
+	tests := []struct {
+		name   string
+		query  string
+		status int
+		count  int
+	}{
+		{"preset applied", "preset=" + slug, http.StatusOK, 1},
+		{"explicit parameter overrides", "preset=" + slug + "&limit=2", http.StatusOK, 2},
+		{"unknown preset", "preset=zzzzzzzz", http.StatusBadRequest, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := serve(router, http.MethodGet, "/api/fights?"+tt.query, "")
+			if rec.Code != tt.status {
+				t.Fatalf("GET /api/fights?%s = %d %s, want %d", tt.query, rec.Code, rec.Body, tt.status)
+			}
+			if tt.status != http.StatusOK {
+				if code := errorCode(t, rec); code != "invalid_preset" {
+					t.Errorf("error = %q, want invalid_preset", code)
+				}
+				return
+			}
+			var body apitypes.FightsResponse
+			decodeJSON(t, rec, &body)
+			if len(body.Data) != tt.count {
+				t.Errorf("fights = %d, want %d", len(body.Data), tt.count)
+			}
+		})
+	}
+
+	if rec := serve(router, http.MethodGet, "/api/presets/zzzzzzzz", ""); rec.Code != http.StatusNotFound {
+		t.Errorf("GET of an unknown preset = %d, want 404", rec.Code)
+	}
+}
+
+func TestCreatePresetRateLimit(t *testing.T) {
+	router := newPresetRouter(t)
+	body := `{"name":"Completed","params":{"status":"completed"}}`
+	for i := 0; i < presetCreateLimit; i++ {
+		createPreset(t, router, body)
+	}
+
+	rec := serve(router, http.MethodPost, "/api/presets", body, "Content-Type", "application/json")
+	if rec.Code != http.StatusTooManyRequests || rec.Header().Get("Retry-After") == "" {
+		t.Errorf("POST /api/presets over the limit = %d with Retry-After %q, want 429 with Retry-After",
+			rec.Code, rec.Header().Get("Retry-After"))
+	}
+}
+
+func TestPresetsDisabled(t *testing.T) {
+	router := newTestRouter(t, readTestdata(t, "results.html"), Dependencies{})
+
+	rec := serve(router, http.MethodPost, "/api/presets", `{"name":"x","params":{}}`, "Content-Type", "application/json")
+	if rec.Code != http.StatusNotFound || errorCode(t, rec) != "presets_disabled" {
+		t.Errorf("POST /api/presets without a store = %d %s, want 404 presets_disabled", rec.Code, rec.Body)
+	}
+	if rec := serve(router, http.MethodGet, "/api/fights?preset=abcdefgh", ""); rec.Code != http.StatusBadRequest {
+		t.Errorf("GET /api/fights with a preset and no store = %d, want 400", rec.Code)
+	}
+}
@@ -0,0 +1,208 @@
+package api
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// windowLimiter allows a fixed number of events per key within a time window
+// It is used for low-volume actions such as creating presets
+type windowLimiter struct {
+	mu     sync.Mutex
+	limit  int
+	window time.Duration
+	events map[string][]time.Time
+}
+
+// newWindowLimiter creates a limiter allowing limit events per window for each key
+func newWindowLimiter(limit int, window time.Duration) *windowLimiter {
+	return &windowLimiter{
+		limit:  limit,
+		window: window,
+		events: make(map[string][]time.Time),
+	}
+}
+
+// Allow records an event for the key and reports whether it is within the limit
+// When the limit is exceeded the second value tells when the next event is allowed
+func (l *windowLimiter) Allow(key string) (bool, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	cutoff := now.Add(-l.window)
+
+	// Drop events that left the window
+	recent := l.events[key][:0]
+	for _, t := range l.events[key] {
+		if t.After(cutoff) {
+			recent = append(recent, t)
+		}
+	}
+
+	if len(recent) >= l.limit {
+		l.events[key] = recent
+		return false, recent[0].Sub(cutoff)
+	}
+
+	l.events[key] = append(recent, now)
+	l.prune(cutoff)
+	return true, 0
+}
+
+// prune removes keys without recent events so the map does not grow forever
+// The caller must hold the lock
+func (l *windowLimiter) prune(cutoff time.Time) {
+	for key, times := range l.events {
+		if len(times) == 0 || !times[len(times)-1].After(cutoff) {
+			delete(l.events, key)
+		}
+	}
+}
+
+// tokenBucket is the state of the bucket of one key
+type tokenBucket struct {
+	tokens  float64
+	updated time.Time
+	// rate (tokens per second) and burst are those of the last request,
+	// used to tell when the idle bucket is full again
+	rate  float64
+	burst float64
+}
+
+// fullAt returns when the bucket holds burst tokens again
+func (b *tokenBucket) fullAt() time.Time {
+	missing := b.burst - b.tokens
+	return b.updated.Add(time.Duration(missing / b.rate * float64(time.Second)))
+}
+
+// limiterShards is the number of independently locked parts of a
+// tokenBucketLimiter
+const limiterShards = 32
+
+// bucketShard holds the buckets of the keys hashed to it
+type bucketShard struct {
+	mu      sync.Mutex
+	buckets map[string]*tokenBucket
+}
+
+// tokenBucketLimiter limits the request rate of each key with a token bucket
+// A bucket holds up to burst tokens and refills at rate tokens per second,
+// so a client may send a burst at once and then continues at the steady
+// rate. The buckets are spread over shards with their own locks, so
+// requests of different clients rarely wait for each other, and a bucket
+// idle long enough to be full again is dropped, since it is the same as a
+// new one: memory stays bounded by the recently active keys.
+type tokenBucketLimiter struct {
+	now    func() time.Time
+	shards [limiterShards]bucketShard
+	// swept is the UnixNano time full buckets were last removed
+	swept atomic.Int64
+}
+
+// bucketSweepInterval is how often the full buckets are removed
+const bucketSweepInterval = time.Minute
+
+// newTokenBucketLimiter creates an empty limiter
+func newTokenBucketLimiter() *tokenBucketLimiter {
+	l := &tokenBucketLimiter{now: time.Now}
+	for i := range l.shards {
+		l.shards[i].buckets = make(map[string]*tokenBucket)
+	}
+
+	return l
+}
+
+// shard returns the shard holding the bucket of the key (FNV-1a hash)
+func (l *tokenBucketLimiter) shard(key string) *bucketShard {
+	hash := uint32(2166136261)
+	for i := 0; i < len(key); i++ {
+		hash ^= uint32(key[i])
+		hash *= 16777619
+	}
+
+	return &l.shards[hash%limiterShards]
+}
+
+// Allow takes a token from the bucket of the key, holding up to burst
+// tokens refilled at rate tokens per second, and reports whether there was
+// one
+// remaining is the number of whole tokens left; when the bucket is empty
+// retryAfter tells when the next token arrives.
+func (l *tokenBucketLimiter) Allow(key string, rate float64, burst int) (allowed bool, remaining int, retryAfter time.Duration) {
+	now := l.now()
+	l.sweep(now)
+
+	shard := l.shard(key)
+	shard.mu.Lock()
+	defer shard.mu.Unlock()
+
+	capacity := float64(burst)
+	bucket, ok := shard.buckets[key]
+	if !ok {
+		bucket = &tokenBucket{tokens: capacity, updated: now}
+		shard.buckets[key] = bucket
+	}
+
+	// Refill for the time since the last request
+	if elapsed := now.Sub(bucket.updated).Seconds(); elapsed > 0 {
+		bucket.tokens = math.Min(capacity, bucket.tokens+elapsed*rate)
+	}
+	bucket.updated = now
+	bucket.rate, bucket.burst = rate, capacity
+
+	if bucket.tokens < 1 {
+		wait := (1 - bucket.tokens) / rate
+		return false, 0, time.Duration(math.Ceil(wait * float64(time.Second)))
+	}
+	bucket.tokens--
+
+	return true, int(bucket.tokens), 0
+}
+
+// sweep removes the buckets full again from every shard, at most once per
+// bucketSweepInterval
+// The request arriving first after the interval sweeps; the shards are
+// locked one at a time, so the others are not held up.
+func (l *tokenBucketLimiter) sweep(now time.Time) {
+	last := l.swept.Load()
+	if now.UnixNano()-last < int64(bucketSweepInterval) || !l.swept.CompareAndSwap(last, now.UnixNano()) {
+		return
+	}
+
+	for i := range l.shards {
+		shard := &l.shards[i]
+		shard.mu.Lock()
+		for key, bucket := range shard.buckets {
+			if !now.Before(bucket.fullAt()) {
+				delete(shard.buckets, key)
+			}
+		}
+		shard.mu.Unlock()
+	}
+}
+
+// ipRateLimit limits the requests of every client IP to the configured
+// rate and burst (Dependencies.IPRateLimit), so a single client cannot
+// exhaust the server and, through the data endpoints, the source
+// Requests over the limit get 429 with Retry-After. The client IP comes
+// from X-Forwarded-For only behind the trusted proxies.
+func (h *handler) ipRateLimit(c *gin.Context) {
+	allowed, _, retryAfter := h.ipLimiter.Allow(c.ClientIP(), h.deps.IPRateLimit, h.deps.IPRateBurst)
+	if !allowed {
+		c.Header("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+		c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{
+			"error":   "rate_limited",
+			"message": "Too many requests from this IP address, try again later",
+		})
+		return
+	}
+
+	c.Next()
+}
@@ -0,0 +1,197 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeNow is a settable clock for the token bucket limiter
+type fakeNow struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (f *fakeNow) Now() time.Time {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.now
+}
+
+func (f *fakeNow) Advance(d time.Duration) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.now = f.now.Add(d)
+}
+
+// bucketCount returns the number of buckets held by the limiter
+func (l *tokenBucketLimiter) bucketCount() int {
+	count := 0
+	for i := range l.shards {
+		l.shards[i].mu.Lock()
+		count += len(l.shards[i].buckets)
+		l.shards[i].mu.Unlock()
+	}
+
+	return count
+}
+
+// serveFrom performs a GET request from the remote address
+// headers are given as name, value pairs.
+func serveFrom(router http.Handler, target, remoteAddr string, headers ...string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, target, nil)
+	req.RemoteAddr = remoteAddr
+	for i := 0; i+1 < len(headers); i += 2 {
+		req.Header.Set(headers[i], headers[i+1])
+	}
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	return rec
+}
+
+func TestTokenBucketMath(t *testing.T) {
+	clock := &fakeNow{now: testNow}
+	l := newTokenBucketLimiter()
+	l.now = clock.Now
+
+	type step struct {
+		advance    time.Duration
+		allowed    bool
+		remaining  int
+		retryAfter time.Duration
+	}
+	// Burst of 3, refilled at 2 tokens per second
+	steps := []step{
+		{0, true, 2, 0},
+		{0, true, 1, 0},
+		{0, true, 0, 0},
+		{0, false, 0, 500 * time.Millisecond},
+		{200 * time.Millisecond, false, 0, 300 * time.Millisecond},
+		{300 * time.Millisecond, true, 0, 0},
+		{time.Second, true, 1, 0},
+		// A long pause refills the bucket to the burst, not beyond
+		{time.Hour, true, 2, 0},
+	}
+	for i, s := range steps {
+		clock.Advance(s.advance)
+		allowed, remaining, retryAfter := l.Allow("client", 2, 3)
+		if allowed != s.allowed || remaining != s.remaining || retryAfter != s.retryAfter {
+			t.Errorf("step %d: Allow = %v, %d, %v, want %v, %d, %v", i, allowed, remaining, retryAfter, s.allowed, s.remaining, s.retryAfter)
+		}
+	}
+
+	// Keys have their own buckets
+	if allowed, remaining, _ := l.Allow("other", 2, 3); !allowed || remaining != 2 {
+		t.Errorf("first request of another key = %v, %d remaining, want allowed with 2 remaining", allowed, remaining)
+	}
+}
+
+func TestTokenBucketEvictsIdleKeys(t *testing.T) {
+	clock := &fakeNow{now: testNow}
+	l := newTokenBucketLimiter()
+	l.now = clock.Now
+
+	for i := 0; i < 100; i++ {
+		l.Allow(fmt.Sprintf("idle-%d", i), 1, 10)
+	}
+	l.Allow("busy", 0.001, 10)
+	if got := l.bucketCount(); got != 101 {
+		t.Fatalf("%d buckets, want 101", got)
+	}
+
+	// After the sweep interval the idle buckets are full again and dropped;
+	// the slowly refilling one is still missing a token and kept
+	clock.Advance(bucketSweepInterval)
+	l.Allow("new", 1, 10)
+	if got := l.bucketCount(); got != 2 {
+		t.Errorf("%d buckets after the sweep, want the busy and the new one", got)
+	}
+}
+
+func TestIPRateLimitThrottlesOnlyTheHotClient(t *testing.T) {
+	const burst = 5
+	router := newTestRouter(t, readTestdata(t, "upcoming.html"), Dependencies{
+		// A token every 100 seconds: the hot client cannot get a refill
+		// while the test runs
+		IPRateLimit: 0.01,
+		IPRateBurst: burst,
+	})
+
+	const uniqueClients, hotRequests = 1000, 200
+	var wg sync.WaitGroup
+	uniqueCodes := make([]int, uniqueClients)
+	hotCodes := make([]int, hotRequests)
+	for i := range uniqueCodes {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			addr := fmt.Sprintf("198.51.%d.%d:40000", i/250, i%250+1)
+			uniqueCodes[i] = serveFrom(router, "/api/health", addr).Code
+		}()
+	}
+	for i := range hotCodes {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			hotCodes[i] = serveFrom(router, "/api/health", "203.0.113.7:40000").Code
+		}()
+	}
+	wg.Wait()
+
+	for i, code := range uniqueCodes {
+		if code != http.StatusOK {
+			t.Errorf("client %d with a single request = %d, want 200", i, code)
+		}
+	}
+	served, limited := 0, 0
+	for _, code := range hotCodes {
+		switch code {
+		case http.StatusOK:
+			served++
+		case http.StatusTooManyRequests:
+			limited++
+		}
+	}
+	if served != burst || limited != hotRequests-burst {
+		t.Errorf("hot client got %d served and %d limited, want %d and %d", served, limited, burst, hotRequests-burst)
+	}
+
+	rec := serveFrom(router, "/api/health", "203.0.113.7:40000")
+	if rec.Code != http.StatusTooManyRequests || errorCode(t, rec) != "rate_limited" {
+		t.Errorf("hot client = %d %s, want 429 rate_limited", rec.Code, rec.Body)
+	}
+	if got := rec.Header().Get("Retry-After"); got != "100" {
+		t.Errorf("Retry-After = %q, want 100", got)
+	}
+}
+
+func TestIPRateLimitForwardedFor(t *testing.T) {
+	router := newTestRouter(t, readTestdata(t, "upcoming.html"), Dependencies{
+		IPRateLimit:    0.01,
+		IPRateBurst:    1,
+		TrustedProxies: []string{"10.0.0.1"},
+	})
+
+	// Behind the trusted proxy the clients of X-Forwarded-For are limited
+	// separately
+	for _, client := range []string{"198.51.100.1", "198.51.100.2"} {
+		if rec := serveFrom(router, "/api/health", "10.0.0.1:40000", "X-Forwarded-For", client); rec.Code != http.StatusOK {
+			t.Errorf("first request of %s through the proxy = %d, want 200", client, rec.Code)
+		}
+	}
+	if rec := serveFrom(router, "/api/health", "10.0.0.1:40000", "X-Forwarded-For", "198.51.100.1"); rec.Code != http.StatusTooManyRequests {
+		t.Errorf("second request of 198.51.100.1 through the proxy = %d, want 429", rec.Code)
+	}
+
+	// Any other peer is limited by its own address, whatever it forwards
+	if rec := serveFrom(router, "/api/health", "203.0.113.7:40000", "X-Forwarded-For", "198.51.100.3"); rec.Code != http.StatusOK {
+		t.Errorf("first request of an untrusted peer = %d, want 200", rec.Code)
+	}
+	if rec := serveFrom(router, "/api/health", "203.0.113.7:40000", "X-Forwarded-For", "198.51.100.4"); rec.Code != http.StatusTooManyRequests {
+		t.Errorf("untrusted peer with another X-Forwarded-For = %d, want 429", rec.Code)
+	}
+}
@@ -0,0 +1,103 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"easypars/models"
+	"easypars/pkg/apitypes"
+	"easypars/pkg/clock"
+	"easypars/pkg/parser"
+	"easypars/pkg/storage"
+)
+
+// openDamagedStore writes storedFights to a file store, truncates the file
+// inside the second fight and opens it again
+func openDamagedStore(t *testing.T) *storage.FileStore {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "fights.json")
+	store, err := storage.OpenFile(path, false, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.UpsertFights(context.Background(), storedFights()); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, data[:len(data)*3/4], 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	damaged, err := storage.OpenFile(path, false, false)
+	if err != nil {
+		t.Fatalf("OpenFile of the truncated file: %v", err)
+	}
+	if damaged.Recovery() == nil {
+		t.Fatal("the truncated file was not recovered")
+	}
+
+	return damaged
+}
+
+func TestRecoveredStorageDegradesHealth(t *testing.T) {
+	repo := openDamagedStore(t)
+	src, hits := failingSource(t)
+	p := parser.NewParser(src.URL + "/")
+	p.Clock = clock.Fixed{Time: testNow}
+	router := SetupRouter(Dependencies{Parser: p, Repository: repo})
+
+	// The recovery parse fails against the source, the warning stays
+	deadline := time.Now().Add(time.Second)
+	for hits.Load() == 0 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	var health apitypes.HealthResponse
+	decodeJSON(t, serve(router, http.MethodGet, "/api/health", ""), &health)
+	if health.Status != "degraded" || health.StorageRecovery == nil {
+		t.Fatalf("health = %s with recovery %+v, want degraded with the storage recovery", health.Status, health.StorageRecovery)
+	}
+	if health.StorageRecovery.LoadedRecords != 1 || health.StorageRecovery.LostRecords != 1 {
+		t.Errorf("storage recovery = %+v, want one fight loaded and one lost", health.StorageRecovery)
+	}
+}
+
+func TestRecoveryParseCompletesTheStore(t *testing.T) {
+	repo := openDamagedStore(t)
+	router := newTestRouter(t, readTestdata(t, "results.html"), Dependencies{Repository: repo})
+
+	var health apitypes.HealthResponse
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		health = apitypes.HealthResponse{}
+		decodeJSON(t, serve(router, http.MethodGet, "/api/health", ""), &health)
+		if health.StorageRecovery == nil || time.Now().After(deadline) {
+			break
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	if health.StorageRecovery != nil {
+		t.Fatalf("storage recovery = %+v after the recovery parse, want it cleared", health.StorageRecovery)
+	}
+	fights, err := repo.List(context.Background(), storage.FightFilter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(fights) <= 1 {
+		t.Errorf("%d stored fights after the recovery parse, want the parsed fights added", len(fights))
+	}
+	var parsed bool
+	for _, fight := range fights {
+		parsed = parsed || fight.Status == models.StatusCompleted && fight.Fighter1 == "Usyk"
+	}
+	if !parsed {
+		t.Error("the fights of the source were not persisted")
+	}
+}
@@ -0,0 +1,125 @@
+package api
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"easypars/pkg/cache"
+	"easypars/pkg/clock"
+	"easypars/pkg/parser"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+)
+
+// delayedSource serves the page after a delay and counts its requests, so
+// concurrent loads overlap
+func delayedSource(t *testing.T, page string, delay time.Duration) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+
+	var hits atomic.Int32
+	src := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		time.Sleep(delay)
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		io.WriteString(w, page)
+	}))
+	t.Cleanup(src.Close)
+
+	return src, &hits
+}
+
+// newRedisInstance returns the router of an API instance of a deployment
+// sharing the fight cache in the Redis server
+func newRedisInstance(t *testing.T, server *miniredis.Miniredis, sourceURL string, lockTTL time.Duration) *gin.Engine {
+	t.Helper()
+
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	t.Cleanup(func() { client.Close() })
+	p := parser.NewParser(sourceURL + "/")
+	p.Clock = clock.Fixed{Time: testNow}
+
+	return SetupRouter(Dependencies{
+		Parser:     p,
+		FightCache: cache.NewRedis[*parser.ParseResult](client, cache.RedisOptions{Prefix: "easypars:", LockTTL: lockTTL}),
+	})
+}
+
+func TestRedisFightCacheIsSharedBetweenInstances(t *testing.T) {
+	tests := []struct {
+		name      string
+		instances int
+	}{
+		{"one instance", 1},
+		{"three instances", 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := miniredis.RunT(t)
+			src, hits := delayedSource(t, readTestdata(t, "results.html"), 300*time.Millisecond)
+			routers := make([]*gin.Engine, tt.instances)
+			for i := range routers {
+				routers[i] = newRedisInstance(t, server, src.URL, time.Minute)
+			}
+
+			// A concurrent cold start: one instance parses, the others wait
+			// for its result in the cache
+			var wg sync.WaitGroup
+			codes := make([]int, len(routers))
+			for i, router := range routers {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					codes[i] = serve(router, http.MethodGet, "/api/fights", "").Code
+				}()
+			}
+			wg.Wait()
+
+			for i, code := range codes {
+				if code != http.StatusOK {
+					t.Errorf("instance %d: GET /api/fights = %d, want 200", i, code)
+				}
+			}
+			if got := hits.Load(); got != 1 {
+				t.Errorf("the source got %d requests, want 1", got)
+			}
+			if !server.Exists("easypars:" + src.URL + "/") {
+				t.Errorf("keys in Redis = %q, want the parse of %s/", server.Keys(), src.URL)
+			}
+		})
+	}
+}
+
+func TestRedisFightCacheLockNotReleased(t *testing.T) {
+	server := miniredis.RunT(t)
+	src, hits := delayedSource(t, readTestdata(t, "results.html"), 0)
+	const lockTTL = time.Second
+	router := newRedisInstance(t, server, src.URL, lockTTL)
+
+	// An instance that crashed while loading holds the lock until it expires
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	defer client.Close()
+	crashed := cache.NewRedis[*parser.ParseResult](client, cache.RedisOptions{Prefix: "easypars:", LockTTL: time.Hour})
+	if _, acquired, err := crashed.Lock(context.Background(), src.URL+"/"); err != nil || !acquired {
+		t.Fatalf("Lock = %v (%v), want the lock", acquired, err)
+	}
+
+	start := time.Now()
+	rec := serve(router, http.MethodGet, "/api/fights", "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("GET /api/fights = %d %s, want 200", rec.Code, rec.Body)
+	}
+	if waited := time.Since(start); waited < lockTTL {
+		t.Errorf("the instance parsed after %v, want it to wait for the lock TTL of %v", waited, lockTTL)
+	}
+	if got := hits.Load(); got != 1 {
+		t.Errorf("the source got %d requests, want 1", got)
+	}
+}
@@ -0,0 +1,59 @@
+package api
+
+import (
+	"context"
+	"net/http"
+
+	"easypars/pkg/refresh"
+	"easypars/pkg/snapshot"
+
+	"github.com/gin-gonic/gin"
+)
+
+// scheduledRefresh parses the source for the refresher and publishes the
+// result
+// Unlike a request it does not fall back to stored fights: a failed parse
+// is reported in the refresh status and the published snapshot stays.
+func (h *handler) scheduledRefresh(ctx context.Context) (int, error) {
+	if h.deps.Parser == nil {
+		return 0, nil
+	}
+
+	result, err := h.parseFights(ctx, refresh.TriggerScheduled)
+	if err != nil {
+		return 0, err
+	}
+	h.publishLoaded(ctx, result)
+
+	return len(result.Fights), nil
+}
+
+// refreshedSnapshot returns the published snapshot while the refresher runs
+func (h *handler) refreshedSnapshot() (*snapshot.Snapshot, bool) {
+	if h.deps.Refresher == nil || !h.deps.Refresher.Running() {
+		return nil, false
+	}
+	snap := h.deps.Snapshots.Active()
+
+	return snap, snap != nil
+}
+
+// handleGetRefreshStatus handles GET requests to /api/fights/refresh-status
+// Returns the last run, its error and the next run of the scheduled refresh
+func (h *handler) handleGetRefreshStatus(c *gin.Context) {
+	if h.deps.Refresher == nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error":   "refresher_disabled",
+			"message": "Scheduled refresh is not configured (refresh.interval_minutes)",
+		})
+		return
+	}
+
+	response := gin.H{"refresher": h.deps.Refresher.Status()}
+	if active := h.deps.Snapshots.Active(); active != nil {
+		response["last_updated"] = active.BuiltAt
+		response["fight_count"] = len(active.Fights)
+	}
+
+	c.JSON(http.StatusOK, response)
+}
@@ -0,0 +1,47 @@
+package api
+
+import (
+	"log/slog"
+	"net/http"
+
+	"easypars/pkg/reparse"
+
+	"github.com/gin-gonic/gin"
+)
+
+// handleReparse handles POST requests to /api/admin/reparse
+// Stored fights are parsed again from their raw source text with the current
+// parsing rules. With ?dry_run=1 changes are only counted and sampled,
+// otherwise they are written and logged into a parse history run.
+func (h *handler) handleReparse(c *gin.Context) {
+	if h.deps.Repository == nil || h.deps.Parser == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{
+			"error":   "reparse_unavailable",
+			"message": "Reparse requires persistent storage",
+		})
+		return
+	}
+
+	dryRun := c.Query("dry_run")
+	if err := validateFlag(dryRun); dryRun != "" && err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "invalid_params",
+			"message": `invalid parameter "dry_run": ` + err.Error(),
+		})
+		return
+	}
+
+	report, err := reparse.Run(c.Request.Context(), h.deps.Parser, h.deps.Repository, h.deps.History, dryRun == "1" || dryRun == "true")
+	if err != nil {
+		slog.ErrorContext(c.Request.Context(), "Reparse failed", "error", err)
+		h.respondError(c, err, "reparse_error", err.Error())
+		return
+	}
+
+	slog.InfoContext(c.Request.Context(), "Reparse finished", "run_id", report.RunID, "fight_count", report.Total,
+		"changed", report.Changed, "unchanged", report.Unchanged, "skipped", report.Skipped, "dry_run", report.DryRun)
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Reparse finished",
+		"data":    report,
+	})
+}
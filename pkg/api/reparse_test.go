@@ -0,0 +1,78 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"easypars/models"
+	"easypars/pkg/reparse"
+	"easypars/pkg/storage"
+)
+
+func TestReparseEndpoint(t *testing.T) {
+	repo, err := storage.OpenFile(filepath.Join(t.TempDir(), "fights.json"), false, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// A fight stored before the method dictionary knew the Russian knockout
+	fight := models.Fight{
+		Date: "2024-05-18", Fighter1: "Oleksandr Usyk", Fighter2: "Tyson Fury", Location: "Riyadh",
+		Result: "нокаут в 3 раунде", ResultType: models.ResultUnknown, Status: models.StatusCompleted,
+		Raw: &models.RawFields{DateText: "18", ResultText: "нокаут в 3 раунде", LocationText: "Riyadh", Boxer1Text: "Oleksandr Usyk", Boxer2Text: "Tyson Fury", RefMonth: "2024-05"},
+	}
+	fight.AssignKey()
+	if _, err := repo.UpsertFights(context.Background(), []models.Fight{fight}); err != nil {
+		t.Fatal(err)
+	}
+	token := "Bearer " + signTestToken(t, testJWTSecret, time.Now())
+	page := readTestdata(t, "results.html")
+	withRepo := newTestRouter(t, page, Dependencies{Auth: newTestAuth(t), Repository: repo})
+	withoutRepo := newTestRouter(t, page, Dependencies{Auth: newTestAuth(t)})
+
+	tests := []struct {
+		name    string
+		repo    bool
+		query   string
+		status  int
+		code    string
+		dryRun  bool
+		changed int
+	}{
+		{"without storage", false, "?dry_run=1", http.StatusServiceUnavailable, "reparse_unavailable", false, 0},
+		{"invalid dry_run", true, "?dry_run=maybe", http.StatusBadRequest, "invalid_params", false, 0},
+		{"dry run", true, "?dry_run=1", http.StatusOK, "", true, 1},
+		{"applied", true, "", http.StatusOK, "", false, 1},
+		{"nothing left to change", true, "", http.StatusOK, "", false, 0},
+	}
+	for _, tt := range tests {
+		router := withoutRepo
+		if tt.repo {
+			router = withRepo
+		}
+		rec := serve(router, http.MethodPost, "/api/admin/reparse"+tt.query, "", "Authorization", token)
+		if rec.Code != tt.status {
+			t.Fatalf("%s: POST /api/admin/reparse%s = %d %s, want %d", tt.name, tt.query, rec.Code, rec.Body, tt.status)
+		}
+		if tt.code != "" {
+			if code := errorCode(t, rec); code != tt.code {
+				t.Errorf("%s: error = %q, want %q", tt.name, code, tt.code)
+			}
+			continue
+		}
+		var body struct {
+			Data reparse.Report `json:"data"`
+		}
+		decodeJSON(t, rec, &body)
+		if body.Data.DryRun != tt.dryRun || body.Data.Changed != tt.changed {
+			t.Errorf("%s: report = %+v, want dry_run %v with %d changed", tt.name, body.Data, tt.dryRun, tt.changed)
+		}
+	}
+
+	stored, err := repo.GetByKey(context.Background(), fight.Key)
+	if err != nil || stored.ResultType != models.ResultKO || stored.Round != 3 {
+		t.Errorf("stored fight = %+v (%v), want KO in round 3", stored, err)
+	}
+}
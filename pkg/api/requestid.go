@@ -0,0 +1,52 @@
+package api
+
+import (
+	"log/slog"
+	"time"
+
+	"easypars/pkg/requestid"
+
+	"github.com/gin-gonic/gin"
+)
+
+// requestIDKey is the gin context key holding the request ID
+const requestIDKey = "request_id"
+
+// requestIDMiddleware assigns every request its ID
+// An ID sent by the client in X-Request-ID is kept when it is well formed,
+// so IDs of a proxy or the frontend continue through the service; otherwise
+// a new one is generated. The ID is echoed in the response header, bound to
+// the request context for the logs of the request (see
+// requestid.LogHandler) and stored in the gin context.
+func requestIDMiddleware(c *gin.Context) {
+	id := c.GetHeader(requestid.Header)
+	if !requestid.Valid(id) {
+		id = requestid.New()
+	}
+
+	c.Set(requestIDKey, id)
+	c.Request = c.Request.WithContext(requestid.WithID(c.Request.Context(), id))
+	c.Header(requestid.Header, id)
+
+	c.Next()
+}
+
+// accessLog logs every handled request with its status and duration
+// The record is logged with the request context, so it carries the
+// request ID like the other records of the request.
+func accessLog(c *gin.Context) {
+	start := time.Now()
+	c.Next()
+
+	attrs := []any{
+		"method", c.Request.Method,
+		"path", c.Request.URL.Path,
+		"status", c.Writer.Status(),
+		"duration_ms", time.Since(start).Milliseconds(),
+		"client_ip", c.ClientIP(),
+	}
+	if errs := c.Errors.String(); errs != "" {
+		attrs = append(attrs, "error", errs)
+	}
+	slog.InfoContext(c.Request.Context(), "Request handled", attrs...)
+}
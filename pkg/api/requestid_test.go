@@ -0,0 +1,163 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"easypars/pkg/parser"
+	"easypars/pkg/requestid"
+)
+
+// syncBuffer is a buffer safe for the concurrent log writes of requests
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+// records returns the JSON log records written so far
+func (b *syncBuffer) records(t *testing.T) []map[string]any {
+	t.Helper()
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	var records []map[string]any
+	for _, line := range strings.Split(strings.TrimSpace(b.buf.String()), "\n") {
+		var record map[string]any
+		if err := json.Unmarshal([]byte(line), &record); err != nil {
+			t.Fatalf("log line %q: %v", line, err)
+		}
+		records = append(records, record)
+	}
+	return records
+}
+
+// captureLogs sends the default logger to a buffer of JSON records with
+// request IDs until the test ends
+func captureLogs(t *testing.T) *syncBuffer {
+	t.Helper()
+
+	out := &syncBuffer{}
+	previous := slog.Default()
+	slog.SetDefault(slog.New(requestid.NewLogHandler(slog.NewJSONHandler(out, nil))))
+	t.Cleanup(func() { slog.SetDefault(previous) })
+
+	return out
+}
+
+func TestRequestIDHeader(t *testing.T) {
+	router := newTestRouter(t, readTestdata(t, "results.html"), Dependencies{})
+
+	tests := []struct {
+		name string
+		sent string
+		kept bool
+	}{
+		{"client ID", "frontend-7f3a.2", true},
+		{"no ID", "", false},
+		{"ID with spaces", "two words", false},
+		{"overlong ID", strings.Repeat("a", 129), false},
+	}
+	for _, tt := range tests {
+		var headers []string
+		if tt.sent != "" {
+			headers = []string{requestid.Header, tt.sent}
+		}
+		rec := serve(router, http.MethodGet, "/api/health", "", headers...)
+		got := rec.Header().Get(requestid.Header)
+		if tt.kept && got != tt.sent || !tt.kept && (got == tt.sent || !requestid.Valid(got)) {
+			t.Errorf("%s: %s = %q, want the sent ID kept %v", tt.name, requestid.Header, got, tt.kept)
+		}
+	}
+}
+
+func TestErrorsCarryTheRequestID(t *testing.T) {
+	logs := captureLogs(t)
+	src, _ := failingSource(t)
+	router := SetupRouter(Dependencies{Parser: parser.NewParser(src.URL + "/")})
+
+	rec := serve(router, http.MethodGet, "/api/fights", "", requestid.Header, "failing-1")
+	var body struct {
+		Error     string `json:"error"`
+		RequestID string `json:"request_id"`
+	}
+	decodeJSON(t, rec, &body)
+	if rec.Code != http.StatusBadGateway || body.RequestID != "failing-1" {
+		t.Errorf("GET /api/fights of a failing source = %d with request_id %q, want 502 with failing-1", rec.Code, body.RequestID)
+	}
+
+	// The records of the parser carry the ID of the request it parses for
+	messages := make(map[string]bool)
+	for _, record := range logs.records(t) {
+		if record["request_id"] == "failing-1" {
+			messages[record["msg"].(string)] = true
+		}
+	}
+	if !messages["Failed to fetch fights page"] || !messages["Request handled"] {
+		t.Errorf("records with the request ID = %v, want the parser and access records", messages)
+	}
+}
+
+func TestConcurrentRequestsKeepTheirIDs(t *testing.T) {
+	logs := captureLogs(t)
+	src, _ := failingSource(t)
+	router := SetupRouter(Dependencies{Parser: parser.NewParser(src.URL + "/")})
+
+	// Every client has its own address, so the access log tells the
+	// requests apart and each record must carry the ID of its client
+	const clients = 10
+	var wg sync.WaitGroup
+	for i := 0; i < clients; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			id := fmt.Sprintf("client-%d", i)
+			req := httptest.NewRequest(http.MethodGet, "/api/fights", nil).WithContext(context.Background())
+			req.RemoteAddr = fmt.Sprintf("10.0.0.%d:4000", i+1)
+			req.Header.Set(requestid.Header, id)
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+
+			if got := rec.Header().Get(requestid.Header); got != id {
+				t.Errorf("%s: response header = %q", id, got)
+			}
+			if !strings.Contains(rec.Body.String(), `"request_id":"`+id+`"`) {
+				t.Errorf("%s: error body = %s, want its request ID", id, rec.Body)
+			}
+		}()
+	}
+	wg.Wait()
+
+	handled := 0
+	for _, record := range logs.records(t) {
+		id, _ := record["request_id"].(string)
+		if record["msg"] != "Request handled" {
+			if record["level"] == "ERROR" && id == "" {
+				t.Errorf("error record without a request ID: %v", record)
+			}
+			continue
+		}
+		handled++
+		var n int
+		fmt.Sscanf(record["client_ip"].(string), "10.0.0.%d", &n)
+		if want := fmt.Sprintf("client-%d", n-1); id != want {
+			t.Errorf("access record of %s has request_id %q, want %q", record["client_ip"], id, want)
+		}
+	}
+	if handled != clients {
+		t.Errorf("access records = %d, want %d", handled, clients)
+	}
+}
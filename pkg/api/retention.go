@@ -0,0 +1,44 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// handleRunRetention handles POST requests to /api/admin/retention/run
+// Deletes the expired records of the auxiliary stores right away. With
+// ?dry_run=1 the expired records are only counted.
+func (h *handler) handleRunRetention(c *gin.Context) {
+	if h.deps.Retention == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{
+			"error":   "retention_unavailable",
+			"message": "Retention is not configured",
+		})
+		return
+	}
+
+	dryRun := c.Query("dry_run")
+	if err := validateFlag(dryRun); dryRun != "" && err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "invalid_params",
+			"message": `invalid parameter "dry_run": ` + err.Error(),
+		})
+		return
+	}
+
+	report, err := h.deps.Retention.Run(c.Request.Context(), dryRun == "1" || dryRun == "true")
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "retention_error",
+			"message": err.Error(),
+			"data":    report,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Retention finished",
+		"data":    report,
+	})
+}
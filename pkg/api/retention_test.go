@@ -0,0 +1,65 @@
+package api
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"easypars/pkg/history"
+	"easypars/pkg/retention"
+)
+
+func TestRetentionEndpoint(t *testing.T) {
+	hist := history.New(10, 10)
+	run := hist.Start("scheduler")
+	hist.Finish(run.ID, history.RunResult{})
+	runner := retention.New(retention.Config{Policy: retention.Policy{retention.CategoryParseHistory: time.Nanosecond}},
+		[]retention.Target{retention.HistoryTarget(hist)}, hist)
+	token := "Bearer " + signTestToken(t, testJWTSecret, time.Now())
+	page := readTestdata(t, "results.html")
+	withRetention := newTestRouter(t, page, Dependencies{Auth: newTestAuth(t), Retention: runner})
+	withoutRetention := newTestRouter(t, page, Dependencies{Auth: newTestAuth(t)})
+	time.Sleep(time.Millisecond)
+
+	tests := []struct {
+		name      string
+		retention bool
+		query     string
+		status    int
+		code      string
+		deleted   int
+	}{
+		{"not configured", false, "", http.StatusServiceUnavailable, "retention_unavailable", 0},
+		{"invalid dry_run", true, "?dry_run=maybe", http.StatusBadRequest, "invalid_params", 0},
+		{"dry run", true, "?dry_run=1", http.StatusOK, "", 1},
+		{"applied", true, "", http.StatusOK, "", 1},
+	}
+	for _, tt := range tests {
+		router := withoutRetention
+		if tt.retention {
+			router = withRetention
+		}
+		rec := serve(router, http.MethodPost, "/api/admin/retention/run"+tt.query, "", "Authorization", token)
+		if rec.Code != tt.status {
+			t.Fatalf("%s: POST /api/admin/retention/run%s = %d %s, want %d", tt.name, tt.query, rec.Code, rec.Body, tt.status)
+		}
+		if tt.code != "" {
+			if code := errorCode(t, rec); code != tt.code {
+				t.Errorf("%s: error = %q, want %q", tt.name, code, tt.code)
+			}
+			continue
+		}
+		var body struct {
+			Data retention.Report `json:"data"`
+		}
+		decodeJSON(t, rec, &body)
+		if body.Data.Deleted != tt.deleted {
+			t.Errorf("%s: deleted = %d, want %d", tt.name, body.Data.Deleted, tt.deleted)
+		}
+	}
+
+	// The real run replaced the expired run by its own record
+	if runs := hist.List(); len(runs) != 1 || runs[0].Trigger != retention.TriggerRetention {
+		t.Errorf("parse runs = %+v, want the retention run only", runs)
+	}
+}
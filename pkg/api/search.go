@@ -0,0 +1,53 @@
+package api
+
+import (
+	"strings"
+	"unicode"
+
+	"easypars/pkg/locations"
+
+	"golang.org/x/text/cases"
+	"golang.org/x/text/unicode/norm"
+)
+
+// nameSearch matches fighter names against a search term
+// Both sides are folded with the Unicode case folding rules and stripped of
+// diacritics, so "усик" matches "Усик" and "Alvarez" matches "Álvarez".
+// A name also matches through its Latin transliteration, so "usik" finds
+// "Усик". A nameSearch is used by a single request: the caser is not safe
+// for concurrent use.
+type nameSearch struct {
+	caser  cases.Caser
+	needle string
+}
+
+// newNameSearch prepares the search of a term
+func newNameSearch(term string) *nameSearch {
+	search := &nameSearch{caser: cases.Fold()}
+	search.needle = search.fold(term)
+
+	return search
+}
+
+// matches reports whether the name or its transliteration contains the term
+func (s *nameSearch) matches(name string) bool {
+	if s.needle == "" {
+		return true
+	}
+
+	return strings.Contains(s.fold(name), s.needle) ||
+		strings.Contains(s.fold(locations.Transliterate(name)), s.needle)
+}
+
+// fold case folds the text, drops the combining marks of decomposed
+// letters ("á" -> "a", "ё" -> "е") and collapses whitespace
+func (s *nameSearch) fold(text string) string {
+	var b strings.Builder
+	for _, r := range norm.NFD.String(s.caser.String(text)) {
+		if !unicode.Is(unicode.Mn, r) {
+			b.WriteRune(r)
+		}
+	}
+
+	return strings.Join(strings.Fields(b.String()), " ")
+}
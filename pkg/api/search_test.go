@@ -0,0 +1,91 @@
+package api
+
+import (
+	"net/http"
+	"net/url"
+	"reflect"
+	"sort"
+	"testing"
+
+	"easypars/pkg/apitypes"
+)
+
+func TestNameSearch(t *testing.T) {
+	tests := []struct {
+		term string
+		name string
+		want bool
+	}{
+		{"usyk", "Oleksandr Usyk", true},
+		{"USYK", "Oleksandr Usyk", true},
+		{"sandr us", "Oleksandr  Usyk", true},
+		{"усик", "Александр Усик", true},
+		{"УСИК", "Александр Усик", true},
+		{"usik", "Александр Усик", true},
+		{"елкин", "Ёлкин", true},
+		{"Alvarez", "Saúl Álvarez", true},
+		{"álvarez", "Saul Alvarez", true},
+		{"straße", "STRASSE", true},
+		{"", "Anyone", true},
+		{"fury", "Oleksandr Usyk", false},
+		{"усик", "Тайсон Фьюри", false},
+	}
+	for _, tt := range tests {
+		if got := newNameSearch(tt.term).matches(tt.name); got != tt.want {
+			t.Errorf("search %q in %q = %v, want %v", tt.term, tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestSearchFights(t *testing.T) {
+	page := `<html><body><div class="month">Июнь 2024</div><table>
+<tr><td class="date">01</td><td class="place">Riyadh</td><td class="boxer_1">Дмитрий Бивол</td><td class="vs">UD</td><td class="boxer_2">Артур Бетербиев</td></tr>
+<tr><td class="date">08</td><td class="place">Las Vegas</td><td class="boxer_1">Saúl Álvarez</td><td class="vs">UD</td><td class="boxer_2">Jaime Munguía</td></tr>
+<tr><td class="date">22</td><td class="place">Las Vegas</td><td class="boxer_1">Jermell Charlo</td><td class="vs">vs</td><td class="boxer_2">Saul Alvarez</td></tr>
+</table><div class="month">Май 2024</div><table>
+<tr><td class="date">18</td><td class="place">Riyadh</td><td class="boxer_1">Александр Усик</td><td class="vs">SD</td><td class="boxer_2">Тайсон Фьюри</td></tr>
+</table></body></html>`
+	router := newTestRouter(t, page, Dependencies{})
+
+	tests := []struct {
+		name     string
+		query    string
+		fighters []string
+		total    int
+	}{
+		{"cyrillic", "search=усик", []string{"Александр Усик"}, 1},
+		{"second fighter", "search=фьюри", []string{"Александр Усик"}, 1},
+		{"transliterated", "search=bivol", []string{"Дмитрий Бивол"}, 1},
+		{"without diacritics", "search=alvarez", []string{"Jermell Charlo", "Saúl Álvarez"}, 2},
+		{"alias", "q=ALVAREZ", []string{"Jermell Charlo", "Saúl Álvarez"}, 2},
+		{"with a date range", "search=alvarez&to=2024-06-08", []string{"Saúl Álvarez"}, 1},
+		{"with pagination", "search=alvarez&limit=1", []string{"Jermell Charlo"}, 2},
+		{"no match", "search=wilder", nil, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query, _ := url.ParseQuery(tt.query)
+			rec := serve(router, http.MethodGet, "/api/fights?"+query.Encode(), "")
+			if rec.Code != http.StatusOK {
+				t.Fatalf("GET /api/fights?%s = %d %s, want 200", tt.query, rec.Code, rec.Body)
+			}
+			var body apitypes.FightsResponse
+			decodeJSON(t, rec, &body)
+			var fighters []string
+			for _, fight := range body.Data {
+				fighters = append(fighters, fight.Fighter1)
+			}
+			sort.Strings(fighters)
+			if !reflect.DeepEqual(fighters, tt.fighters) {
+				t.Errorf("fights of %q, want %q", fighters, tt.fighters)
+			}
+			total := body.Count
+			if body.Pagination != nil {
+				total = body.Pagination.Total
+			}
+			if total != tt.total {
+				t.Errorf("matches = %d, want %d", total, tt.total)
+			}
+		})
+	}
+}
@@ -0,0 +1,36 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Search statistics report limits
+const (
+	defaultSearchStatsLimit = 50
+	maxSearchStatsLimit     = 500
+)
+
+// handleGetSearchStats handles GET requests to /api/admin/search-stats
+// Returns the most searched terms of today and of the last week with the
+// share of searches that found nothing, a hint at missing data
+func (h *handler) handleGetSearchStats(c *gin.Context) {
+	limit, err := parsePositiveInt(c.DefaultQuery("limit", strconv.Itoa(defaultSearchStatsLimit)))
+	if err != nil || limit > maxSearchStatsLimit {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "invalid_limit",
+			"message": fmt.Sprintf("limit must be an integer between 1 and %d", maxSearchStatsLimit),
+		})
+		return
+	}
+
+	report := h.deps.SearchStats.Top(limit)
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Search statistics retrieved successfully",
+		"today":   report.Today,
+		"week":    report.Week,
+	})
+}
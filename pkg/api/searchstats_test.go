@@ -0,0 +1,70 @@
+package api
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"easypars/pkg/searchstats"
+)
+
+func TestSearchStatsCountsFightSearches(t *testing.T) {
+	tracker := searchstats.New()
+	router := newTestRouter(t, readTestdata(t, "results.html"), Dependencies{Auth: newTestAuth(t), SearchStats: tracker})
+	for _, query := range []string{"search=Usyk", "q=usyk", "search=Nobody", "search=Bivol"} {
+		if rec := serve(router, http.MethodGet, "/api/fights?"+query, ""); rec.Code != http.StatusOK {
+			t.Fatalf("GET /api/fights?%s = %d %s", query, rec.Code, rec.Body)
+		}
+	}
+
+	token := "Bearer " + signTestToken(t, testJWTSecret, time.Now())
+	tests := []struct {
+		name   string
+		query  string
+		status int
+		terms  int
+	}{
+		{"default limit", "", http.StatusOK, 3},
+		{"limit", "?limit=1", http.StatusOK, 1},
+		{"zero limit", "?limit=0", http.StatusBadRequest, 0},
+		{"limit too high", "?limit=501", http.StatusBadRequest, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := serve(router, http.MethodGet, "/api/admin/search-stats"+tt.query, "", "Authorization", token)
+			if rec.Code != tt.status {
+				t.Fatalf("GET search-stats%s = %d %s, want %d", tt.query, rec.Code, rec.Body, tt.status)
+			}
+			if tt.status != http.StatusOK {
+				if code := errorCode(t, rec); code != "invalid_limit" {
+					t.Errorf("error = %q, want invalid_limit", code)
+				}
+				return
+			}
+			var body struct {
+				Today []searchstats.TermStat `json:"today"`
+				Week  []searchstats.TermStat `json:"week"`
+			}
+			decodeJSON(t, rec, &body)
+			if len(body.Today) != tt.terms || len(body.Week) != tt.terms {
+				t.Fatalf("terms = %d today and %d this week, want %d", len(body.Today), len(body.Week), tt.terms)
+			}
+			if top := body.Today[0]; top.Term != "usyk" || top.Count != 2 || top.EmptyShare != 0 {
+				t.Errorf("top term = %+v, want usyk searched twice with results", top)
+			}
+		})
+	}
+
+	found := false
+	for _, stat := range tracker.Top(0).Today {
+		if stat.Term == "nobody" {
+			found = true
+			if stat.EmptyShare != 1 {
+				t.Errorf("empty share of a search without results = %v, want 1", stat.EmptyShare)
+			}
+		}
+	}
+	if !found {
+		t.Error("the search without results was not recorded")
+	}
+}
@@ -0,0 +1,75 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+)
+
+// update rewrites the golden files instead of comparing with them
+var update = flag.Bool("update", false, "update the golden files of testdata")
+
+var (
+	// volatileTimes matches the timestamps that change between runs
+	volatileTimes = regexp.MustCompile(`"(last_updated|parsed_at|missing_since)":"[^"]*"`)
+	// sourceHost matches the address of the test source server
+	sourceHost = regexp.MustCompile(`http://127\.0\.0\.1:\d+`)
+)
+
+// normalizeShape replaces the volatile values of a JSON body and indents it
+// The field order is kept, so a renamed, moved or dropped field shows up.
+func normalizeShape(t *testing.T, body []byte) []byte {
+	t.Helper()
+
+	body = volatileTimes.ReplaceAll(body, []byte(`"$1":"<time>"`))
+	body = sourceHost.ReplaceAll(body, []byte("http://source"))
+	var out bytes.Buffer
+	if err := json.Indent(&out, body, "", "  "); err != nil {
+		t.Fatalf("indenting %q: %v", body, err)
+	}
+	out.WriteByte('\n')
+
+	return out.Bytes()
+}
+
+func TestFightsResponseShape(t *testing.T) {
+	router := newTestRouter(t, readTestdata(t, "results.html"), Dependencies{})
+
+	tests := []struct {
+		name   string
+		target string
+		golden string
+	}{
+		{"list", "/api/fights?limit=3", "fights.golden.json"},
+		{"fight", "/api/fights/usyk-vs-fury-2024-05-18", "fight.golden.json"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := serve(router, http.MethodGet, tt.target, "")
+			if rec.Code != http.StatusOK {
+				t.Fatalf("GET %s = %d %s, want 200", tt.target, rec.Code, rec.Body)
+			}
+			got := normalizeShape(t, rec.Body.Bytes())
+
+			path := filepath.Join("testdata", tt.golden)
+			if *update {
+				if err := os.WriteFile(path, got, 0o644); err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+			want, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatalf("reading the golden file (run with -update to create it): %v", err)
+			}
+			if !bytes.Equal(got, want) {
+				t.Errorf("GET %s differs from %s (run with -update after checking the change):\n%s", tt.target, path, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,63 @@
+package api
+
+import (
+	"log/slog"
+	"net/http"
+	"net/url"
+	"regexp"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxSlugLength bounds the length of a requested fight slug
+const maxSlugLength = 200
+
+// slugPattern matches the fight slugs generated by the snapshot
+var slugPattern = regexp.MustCompile(`^[a-z0-9]+(-[a-z0-9]+)*$`)
+
+// handleGetFightBySlug handles GET requests to /api/fights/by-slug/:slug
+// Returns the fight with the given permalink; a slug the fight had before a
+// fighter was renamed answers 308 with the current permalink
+func (h *handler) handleGetFightBySlug(c *gin.Context) {
+	slug := c.Param("slug")
+	if len(slug) > maxSlugLength || !slugPattern.MatchString(slug) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "invalid_params",
+			"message": `invalid parameter "slug": must be lowercase latin letters and digits separated by hyphens`,
+		})
+		return
+	}
+
+	snap, err := h.refreshSnapshot(c.Request.Context())
+	if abortIfClientGone(c, err) {
+		return
+	}
+	if err != nil {
+		slog.ErrorContext(c.Request.Context(), "Failed to load fights", "error", err)
+		h.respondError(c, err, "parse_error", "Failed to load fight data")
+		return
+	}
+
+	fight, ok := snap.FightBySlug(slug)
+	if !ok {
+		if current, renamed := snap.RenamedSlug(slug); renamed {
+			location := "/api/fights/by-slug/" + url.PathEscape(current)
+			if query := c.Request.URL.RawQuery; query != "" {
+				location += "?" + query
+			}
+			c.Header("Location", location)
+			c.JSON(http.StatusPermanentRedirect, gin.H{
+				"message": "The fight has a new slug",
+				"slug":    current,
+			})
+			return
+		}
+		c.JSON(http.StatusNotFound, gin.H{
+			"error":   "not_found",
+			"message": "No fight with slug " + slug,
+		})
+		return
+	}
+
+	h.respondFight(c, snap, fight)
+}
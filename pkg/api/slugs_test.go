@@ -0,0 +1,76 @@
+package api
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"easypars/pkg/apitypes"
+	"easypars/pkg/snapshot"
+)
+
+func TestGetFightBySlug(t *testing.T) {
+	store := snapshot.NewStore(snapshot.DefaultGuard())
+	page := readTestdata(t, "results.html")
+	router := newTestRouter(t, page, Dependencies{Snapshots: store})
+
+	// The first request publishes the snapshot of the page
+	if rec := serve(router, http.MethodGet, "/api/fights/by-slug/nobody-vs-nobody", ""); rec.Code != http.StatusNotFound {
+		t.Fatalf("GET of an unknown slug = %d %s, want 404", rec.Code, rec.Body)
+	}
+	var slug string
+	for _, fight := range store.Active().Fights {
+		if fight.Fighter1 == "Usyk" {
+			slug = fight.Slug
+		}
+	}
+	if !strings.HasPrefix(slug, "usyk-vs-fury-") {
+		t.Fatalf("slug of the Usyk fight = %q, want usyk-vs-fury-<date>", slug)
+	}
+
+	// A corrected name moves the fight to a new slug
+	renamed := newTestRouter(t, strings.Replace(page, ">Fury<", ">Фьюри<", 1), Dependencies{Snapshots: store})
+	newSlug := strings.Replace(slug, "fury", "fyuri", 1)
+
+	tests := []struct {
+		name     string
+		router   http.Handler
+		slug     string
+		status   int
+		code     string
+		location string
+	}{
+		{"current slug", router, slug, http.StatusOK, "", ""},
+		{"unknown slug", router, "nobody-vs-nobody-2000-01-01", http.StatusNotFound, "not_found", ""},
+		{"uppercase", router, strings.ToUpper(slug), http.StatusBadRequest, "invalid_params", ""},
+		{"cyrillic", router, "усик-vs-фьюри", http.StatusBadRequest, "invalid_params", ""},
+		{"double hyphen", router, "usyk--fury", http.StatusBadRequest, "invalid_params", ""},
+		{"overlong", router, strings.Repeat("a", maxSlugLength+1), http.StatusBadRequest, "invalid_params", ""},
+		{"slug before the rename", renamed, slug + "?fields=key", http.StatusPermanentRedirect, "", "/api/fights/by-slug/" + newSlug + "?fields=key"},
+		{"slug after the rename", renamed, newSlug, http.StatusOK, "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := serve(tt.router, http.MethodGet, "/api/fights/by-slug/"+tt.slug, "")
+			if rec.Code != tt.status {
+				t.Fatalf("GET by slug %s = %d %s, want %d", tt.slug, rec.Code, rec.Body, tt.status)
+			}
+			switch {
+			case tt.code != "":
+				if code := errorCode(t, rec); code != tt.code {
+					t.Errorf("error = %q, want %q", code, tt.code)
+				}
+			case tt.location != "":
+				if got := rec.Header().Get("Location"); got != tt.location {
+					t.Errorf("Location = %q, want %q", got, tt.location)
+				}
+			default:
+				var body apitypes.FightResponse
+				decodeJSON(t, rec, &body)
+				if body.Data.Slug != tt.slug {
+					t.Errorf("fight slug = %q, want %q", body.Data.Slug, tt.slug)
+				}
+			}
+		})
+	}
+}
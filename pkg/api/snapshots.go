@@ -0,0 +1,68 @@
+package api
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"easypars/pkg/snapshot"
+
+	"github.com/gin-gonic/gin"
+)
+
+// handleGetPendingSnapshot handles GET requests to /api/admin/snapshots/pending
+// Returns the snapshot rejected by the quality guard for inspection
+func (h *handler) handleGetPendingSnapshot(c *gin.Context) {
+	pending, reason := h.deps.Snapshots.Pending()
+	if pending == nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error":   "no_pending_snapshot",
+			"message": "There is no pending snapshot",
+		})
+		return
+	}
+
+	response := gin.H{
+		"reason":        reason,
+		"built_at":      pending.BuiltAt,
+		"fight_count":   len(pending.Fights),
+		"quality_score": pending.QualityScore(),
+		"data":          pending.Fights,
+	}
+	if active := h.deps.Snapshots.Active(); active != nil {
+		response["active"] = gin.H{
+			"built_at":      active.BuiltAt,
+			"fight_count":   len(active.Fights),
+			"quality_score": active.QualityScore(),
+		}
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// handlePublishPendingSnapshot handles POST requests to /api/admin/snapshots/publish-pending
+// Forces publication of the pending snapshot bypassing the quality guard
+func (h *handler) handlePublishPendingSnapshot(c *gin.Context) {
+	published, err := h.deps.Snapshots.PublishPending()
+	if errors.Is(err, snapshot.ErrNoPending) {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error":   "no_pending_snapshot",
+			"message": "There is no pending snapshot",
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "publish_failed",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	slog.InfoContext(c.Request.Context(), "Pending snapshot published manually", "fight_count", len(published.Fights))
+	c.JSON(http.StatusOK, gin.H{
+		"message":     "Pending snapshot published",
+		"built_at":    published.BuiltAt,
+		"fight_count": len(published.Fights),
+	})
+}
@@ -0,0 +1,66 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"easypars/models"
+	"easypars/pkg/snapshot"
+)
+
+func TestPendingSnapshotEndpoints(t *testing.T) {
+	store := snapshot.NewStore(snapshot.DefaultGuard())
+	fights := storedFights()
+	if err := store.Publish(&snapshot.Snapshot{Fights: fights}); err != nil {
+		t.Fatal(err)
+	}
+	router := newTestRouter(t, readTestdata(t, "results.html"), Dependencies{Auth: newTestAuth(t), Snapshots: store})
+	token := "Bearer " + signTestToken(t, testJWTSecret, time.Now())
+
+	get := func() *httptest.ResponseRecorder {
+		return serve(router, http.MethodGet, "/api/admin/snapshots/pending", "", "Authorization", token)
+	}
+	publish := func() *httptest.ResponseRecorder {
+		return serve(router, http.MethodPost, "/api/admin/snapshots/publish-pending", "", "Authorization", token)
+	}
+
+	// Nothing was rejected yet
+	for name, rec := range map[string]*httptest.ResponseRecorder{"GET pending": get(), "POST publish-pending": publish()} {
+		if rec.Code != http.StatusNotFound || errorCode(t, rec) != "no_pending_snapshot" {
+			t.Errorf("%s without a pending snapshot = %d %s, want 404 no_pending_snapshot", name, rec.Code, rec.Body)
+		}
+	}
+
+	truncated := &snapshot.Snapshot{Fights: []models.Fight{}}
+	if err := store.Publish(truncated); err == nil {
+		t.Fatal("an empty snapshot passed the guard")
+	}
+
+	rec := get()
+	if rec.Code != http.StatusOK {
+		t.Fatalf("GET pending = %d %s, want 200", rec.Code, rec.Body)
+	}
+	var pending struct {
+		Reason     string `json:"reason"`
+		FightCount int    `json:"fight_count"`
+		Active     struct {
+			FightCount int `json:"fight_count"`
+		} `json:"active"`
+	}
+	decodeJSON(t, rec, &pending)
+	if pending.Reason == "" || pending.FightCount != 0 || pending.Active.FightCount != len(fights) {
+		t.Errorf("pending = %+v, want the reason and the counts of both snapshots", pending)
+	}
+
+	if rec := publish(); rec.Code != http.StatusOK {
+		t.Fatalf("POST publish-pending = %d %s, want 200", rec.Code, rec.Body)
+	}
+	if store.Active() != truncated {
+		t.Error("the pending snapshot was not published")
+	}
+	if rec := get(); rec.Code != http.StatusNotFound {
+		t.Errorf("GET pending after the publication = %d, want 404", rec.Code)
+	}
+}
@@ -0,0 +1,67 @@
+package api
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"easypars/pkg/apitypes"
+	"easypars/pkg/clock"
+	"easypars/pkg/parser"
+)
+
+func TestInteractiveRequestDuringSourcePause(t *testing.T) {
+	page := readTestdata(t, "results.html")
+	var limited atomic.Bool
+	var hits atomic.Int32
+	src := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		if limited.Load() {
+			w.Header().Set("Retry-After", "600")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		io.WriteString(w, page)
+	}))
+	defer src.Close()
+	p := parser.NewParser(src.URL + "/")
+	p.Clock = clock.Fixed{Time: testNow}
+	router := SetupRouter(Dependencies{Parser: p})
+
+	var first apitypes.FightsResponse
+	decodeJSON(t, serve(router, http.MethodGet, "/api/fights", ""), &first)
+
+	// The source starts rate limiting: the request that meets the 429
+	// and the requests during the pause are served the active snapshot
+	limited.Store(true)
+	for i := 0; i < 3; i++ {
+		start := time.Now()
+		rec := serve(router, http.MethodGet, "/api/fights", "")
+		if rec.Code != http.StatusOK {
+			t.Fatalf("request %d during the pause = %d %s, want 200", i+1, rec.Code, rec.Body)
+		}
+		if elapsed := time.Since(start); elapsed > 5*time.Second {
+			t.Errorf("request %d waited %s for the pause", i+1, elapsed)
+		}
+		var body apitypes.FightsResponse
+		decodeJSON(t, rec, &body)
+		if body.Count != first.Count {
+			t.Errorf("request %d served %d fights, want the %d fights of the snapshot", i+1, body.Count, first.Count)
+		}
+	}
+	if got := hits.Load(); got != 2 {
+		t.Errorf("source requests = %d, want none after the 429", got)
+	}
+
+	var health apitypes.HealthResponse
+	decodeJSON(t, serve(router, http.MethodGet, "/api/health", ""), &health)
+	until := testNow.Add(10 * time.Minute)
+	if !slices.Contains(health.Reasons, "source_paused") || health.SourcePausedUntil == nil || !health.SourcePausedUntil.Equal(until) {
+		t.Errorf("health = %s %q until %v, want source_paused until %s", health.Status, health.Reasons, health.SourcePausedUntil, until)
+	}
+}
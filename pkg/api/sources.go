@@ -0,0 +1,150 @@
+package api
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"easypars/pkg/parser"
+
+	"github.com/gin-gonic/gin"
+)
+
+// requireSources responds with an error when the source registry is not configured
+func (h *handler) requireSources(c *gin.Context) bool {
+	if h.deps.Parser != nil && h.deps.Parser.Sources != nil {
+		return true
+	}
+
+	c.JSON(http.StatusServiceUnavailable, gin.H{
+		"error":   "sources_unavailable",
+		"message": "Source registry is not configured",
+	})
+	return false
+}
+
+// respondSourceError maps source registry errors to HTTP responses
+func respondSourceError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, parser.ErrInvalidSource):
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "invalid_source",
+			"message": err.Error(),
+		})
+	case errors.Is(err, parser.ErrSourceExists):
+		c.JSON(http.StatusConflict, gin.H{
+			"error":   "source_exists",
+			"message": err.Error(),
+		})
+	case errors.Is(err, parser.ErrSourceNotFound):
+		c.JSON(http.StatusNotFound, gin.H{
+			"error":   "source_not_found",
+			"message": err.Error(),
+		})
+	default:
+		slog.ErrorContext(c.Request.Context(), "Source registry error", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "source_error",
+			"message": "Failed to update the source registry",
+		})
+	}
+}
+
+// handleGetSources handles GET requests to /api/admin/sources
+// Returns every registered source with the outcome of its recent runs
+func (h *handler) handleGetSources(c *gin.Context) {
+	if !h.requireSources(c) {
+		return
+	}
+
+	statuses := h.deps.Parser.Sources.Statuses()
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Sources retrieved successfully",
+		"data":    statuses,
+		"count":   len(statuses),
+	})
+}
+
+// handleCreateSource handles POST requests to /api/admin/sources
+// The source is parsed from the next run on
+func (h *handler) handleCreateSource(c *gin.Context) {
+	if !h.requireSources(c) {
+		return
+	}
+
+	var request struct {
+		Name    string `json:"name"`
+		Type    string `json:"type"`
+		URL     string `json:"url"`
+		Enabled *bool  `json:"enabled"`
+	}
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "invalid_body",
+			"message": "Request body must be a JSON object with name and url",
+		})
+		return
+	}
+
+	// Sources are enabled unless explicitly added disabled
+	source := parser.Source{Name: request.Name, Type: request.Type, URL: request.URL, Enabled: true}
+	if request.Enabled != nil {
+		source.Enabled = *request.Enabled
+	}
+
+	created, err := h.deps.Parser.AddSource(c.Request.Context(), source)
+	if err != nil {
+		respondSourceError(c, err)
+		return
+	}
+
+	slog.InfoContext(c.Request.Context(), "Source added", "source", created.Name, "url", created.URL)
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Source added successfully",
+		"data":    created,
+	})
+}
+
+// handleUpdateSource handles PATCH requests to /api/admin/sources/:name
+// Enables or disables a source or changes its URL
+func (h *handler) handleUpdateSource(c *gin.Context) {
+	if !h.requireSources(c) {
+		return
+	}
+
+	var patch parser.SourcePatch
+	if err := c.ShouldBindJSON(&patch); err != nil || (patch.URL == nil && patch.Enabled == nil) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "invalid_body",
+			"message": "Request body must set url or enabled",
+		})
+		return
+	}
+
+	updated, err := h.deps.Parser.UpdateSource(c.Request.Context(), c.Param("name"), patch)
+	if err != nil {
+		respondSourceError(c, err)
+		return
+	}
+
+	slog.InfoContext(c.Request.Context(), "Source updated", "source", updated.Name, "url", updated.URL, "enabled", updated.Enabled)
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Source updated successfully",
+		"data":    updated,
+	})
+}
+
+// handleDeleteSource handles DELETE requests to /api/admin/sources/:name
+func (h *handler) handleDeleteSource(c *gin.Context) {
+	if !h.requireSources(c) {
+		return
+	}
+
+	if err := h.deps.Parser.Sources.Remove(c.Param("name")); err != nil {
+		respondSourceError(c, err)
+		return
+	}
+
+	slog.InfoContext(c.Request.Context(), "Source removed", "source", c.Param("name"))
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,92 @@
+package api
+
+import (
+	"log/slog"
+	"net/http"
+
+	"easypars/models"
+	"easypars/pkg/apitypes"
+	"easypars/pkg/pipeline"
+
+	"github.com/gin-gonic/gin"
+)
+
+// topInterestCount is the number of most interesting fights in /api/stats
+const topInterestCount = 5
+
+// handleGetStats handles GET requests to /api/stats
+// Returns summary statistics of the current data set
+// Future steps: Add per-location and per-fighter aggregates
+func (h *handler) handleGetStats(c *gin.Context) {
+	// The operator default filters and the filter parameters of the request
+	// select the fights the statistics cover
+	filters, err := h.filterLayers(c)
+	if err != nil {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error":   "forbidden",
+			"message": err.Error(),
+		})
+		return
+	}
+	if err := validateFightsParams(filters.requested, false); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "invalid_params",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	snap, err := h.refreshSnapshot(c.Request.Context())
+	if abortIfClientGone(c, err) {
+		return
+	}
+	if err != nil {
+		slog.ErrorContext(c.Request.Context(), "Failed to load stats", "error", err)
+		h.respondError(c, err, "parse_error", "Failed to load fight data")
+		return
+	}
+
+	// Scores were computed when the snapshot was published
+	fights, _ := filters.apply(snap.View())
+	upcoming := make([]models.Fight, 0)
+	for _, fight := range fights {
+		if fight.Status == models.StatusScheduled {
+			upcoming = append(upcoming, fight)
+		}
+	}
+	// Without filters the count covers the whole data set, hidden fights included
+	fightCount := len(snap.Fights)
+	if filters.applied() != nil {
+		fightCount = len(fights)
+	}
+	setServerTiming(c, snap)
+	top := sortedByInterest(upcoming)
+	if len(top) > topInterestCount {
+		top = top[:topInterestCount]
+	}
+
+	c.JSON(http.StatusOK, apitypes.StatsResponse{
+		Message:       "Statistics retrieved successfully",
+		FightCount:    fightCount,
+		UpcomingCount: len(upcoming),
+		FighterCount:  len(snap.Fighters()),
+		TopInterest:   top,
+		WorkerPools:   pipeline.GetAllPoolStats(),
+
+		SnapshotAggregates: snap.Aggregates(),
+		ContractViolations: h.deps.Contract.Counts(),
+		AppliedFilters:     filters.applied(),
+	})
+}
+
+// handleResetWorkerPoolStats handles POST requests to /api/admin/worker-pools/reset
+// Clears the counters and timings of every worker pool, for sizing experiments
+func (h *handler) handleResetWorkerPoolStats(c *gin.Context) {
+	pipeline.ResetAllPoolStats()
+	slog.InfoContext(c.Request.Context(), "Worker pool statistics reset")
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Worker pool statistics reset",
+		"data":    pipeline.GetAllPoolStats(),
+	})
+}
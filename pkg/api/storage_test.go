@@ -0,0 +1,74 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"path/filepath"
+	"testing"
+
+	"easypars/pkg/apitypes"
+	"easypars/pkg/parser"
+	"easypars/pkg/storage"
+)
+
+func TestFightsFromTheRepository(t *testing.T) {
+	tests := []struct {
+		name string
+		// source is the state of the source: up, failing or not configured
+		source string
+		stored bool
+		status int
+		count  int
+	}{
+		{"live parse is persisted", "up", false, http.StatusOK, 6},
+		{"stored fights when the source fails", "failing", true, http.StatusOK, 2},
+		{"stored fights without a parser", "none", true, http.StatusOK, 2},
+		{"no stored fights when the source fails", "failing", false, http.StatusBadGateway, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo, err := storage.OpenSQLite(filepath.Join(t.TempDir(), "easypars.db"), 5000)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer repo.Close()
+			ctx := context.Background()
+			if tt.stored {
+				if _, err := repo.UpsertFights(ctx, storedFights()); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			deps := Dependencies{Repository: repo}
+			switch tt.source {
+			case "up":
+				deps.Parser = newTestParser(t, readTestdata(t, "results.html"))
+			case "failing":
+				src, _ := failingSource(t)
+				deps.Parser = parser.NewParser(src.URL + "/")
+			}
+			router := SetupRouter(deps)
+
+			rec := serve(router, http.MethodGet, "/api/fights", "")
+			if rec.Code != tt.status {
+				t.Fatalf("GET /api/fights = %d %s, want %d", rec.Code, rec.Body, tt.status)
+			}
+			if tt.status != http.StatusOK {
+				return
+			}
+			var body apitypes.FightsResponse
+			decodeJSON(t, rec, &body)
+			if body.Count != tt.count {
+				t.Errorf("count = %d, want %d", body.Count, tt.count)
+			}
+
+			stored, err := repo.List(ctx, storage.FightFilter{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(stored) != tt.count {
+				t.Errorf("stored fights = %d, want %d", len(stored), tt.count)
+			}
+		})
+	}
+}
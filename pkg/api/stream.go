@@ -0,0 +1,180 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"easypars/models"
+	"easypars/pkg/contract"
+	"easypars/pkg/snapshot"
+	"easypars/pkg/stream"
+
+	"github.com/gin-gonic/gin"
+)
+
+// DefaultStreamHeartbeat is the pause between the comments sent on an idle
+// stream when none is configured
+const DefaultStreamHeartbeat = 15 * time.Second
+
+// streamPaths are the long-lived endpoints: they hold their connection for
+// as long as the client stays, so they are bounded by the clients of the
+// stream hub rather than by the concurrency limit
+var streamPaths = map[string]bool{
+	"/api/fights/stream": true,
+	"/api/ws":            true,
+}
+
+// streamHeartbeat returns the pause between the heartbeats of a stream
+func (h *handler) streamHeartbeat() time.Duration {
+	if h.deps.StreamHeartbeat > 0 {
+		return h.deps.StreamHeartbeat
+	}
+
+	return DefaultStreamHeartbeat
+}
+
+// handleStreamFights handles GET requests to /api/fights/stream
+// Keeps the connection open and pushes a Server-Sent Event for every fight
+// added (fight.added) or changed (fight.updated) by a new snapshot, the
+// data being the fight as JSON. The filter parameters of /api/fights select
+// the fights streamed. A comment is sent every 15 seconds by default so
+// proxies do not close an idle stream; a client too slow to read its
+// events is disconnected and should reconnect and reload /api/fights.
+func (h *handler) handleStreamFights(c *gin.Context) {
+	if err := validateFightsParams(c.Request.URL.Query(), false); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "invalid_params",
+			"message": err.Error(),
+		})
+		return
+	}
+	filters, err := h.filterLayers(c)
+	if err != nil {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error":   "forbidden",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	client, ok := h.subscribeStream(c)
+	if !ok {
+		return
+	}
+	defer h.deps.Stream.Unsubscribe(client)
+	lang := preferredLanguage(c.GetHeader("Accept-Language"))
+
+	// Step 1: Open the stream; nginx buffers responses unless told not to
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+	if !writeStream(c, ": connected\n\n") {
+		return
+	}
+
+	// Step 2: Forward the events until the client or the hub goes away
+	heartbeat := time.NewTicker(h.streamHeartbeat())
+	defer heartbeat.Stop()
+	for {
+		select {
+		case <-c.Request.Context().Done():
+			return
+		case <-heartbeat.C:
+			if !writeStream(c, ": heartbeat\n\n") {
+				return
+			}
+		case event, open := <-client.Events():
+			if !open {
+				if errors.Is(client.Err(), stream.ErrSlowClient) {
+					slog.WarnContext(c.Request.Context(), "Slow stream client disconnected")
+				}
+				return
+			}
+			if !filters.match(event.Fight) {
+				continue
+			}
+			data, err := json.Marshal(localizeCountries([]models.Fight{event.Fight}, lang)[0])
+			if err != nil {
+				_ = c.Error(fmt.Errorf("error encoding a stream event: %w", err))
+				continue
+			}
+			if !writeStream(c, fmt.Sprintf("event: %s\ndata: %s\n\n", event.Type, data)) {
+				return
+			}
+		}
+	}
+}
+
+// subscribeStream connects the request to the stream hub, responding with
+// an error when it cannot
+func (h *handler) subscribeStream(c *gin.Context) (*stream.Client, bool) {
+	client, err := h.deps.Stream.Subscribe()
+	switch {
+	case err == nil:
+		return client, true
+	case errors.Is(err, stream.ErrTooManyClients):
+		c.Header("Retry-After", "30")
+		c.JSON(http.StatusServiceUnavailable, gin.H{
+			"error":   "too_many_streams",
+			"message": "Too many clients are connected to the stream, try again later",
+		})
+	default:
+		c.JSON(http.StatusServiceUnavailable, gin.H{
+			"error":   "stream_closed",
+			"message": "The server is shutting down",
+		})
+	}
+
+	return nil, false
+}
+
+// writeStream writes a chunk of the stream and flushes it to the client
+// It reports false once the client is gone.
+func writeStream(c *gin.Context, chunk string) bool {
+	if _, err := io.WriteString(c.Writer, chunk); err != nil {
+		return false
+	}
+	c.Writer.Flush()
+
+	return true
+}
+
+// streamUpdates publishes the fights added or changed since the last
+// streamed snapshot to the clients of the stream
+// The first snapshot after the start is only the baseline.
+func (h *handler) streamUpdates(snap *snapshot.Snapshot) {
+	if snap == nil {
+		return
+	}
+
+	prev, ok := h.streamed.advance(snap)
+	if !ok || prev == nil {
+		return
+	}
+
+	added, updated := snapshot.FightUpdates(prev, snap)
+	if len(added)+len(updated) == 0 {
+		return
+	}
+	// The streamed fights must keep the model invariants
+	if err := h.deps.Contract.Check(contract.BoundaryAPI, append(append([]models.Fight{}, added...), updated...)); err != nil {
+		slog.Warn("Fight updates not streamed", "error", err)
+		return
+	}
+
+	events := make([]stream.Event, 0, len(added)+len(updated))
+	for _, fight := range added {
+		events = append(events, stream.Event{Type: stream.EventAdded, Fight: fight})
+	}
+	for _, fight := range updated {
+		events = append(events, stream.Event{Type: stream.EventUpdated, Fight: fight})
+	}
+	slog.Info("Streaming fight updates", "added", len(added), "updated", len(updated))
+	h.deps.Stream.Publish(events)
+}
@@ -0,0 +1,242 @@
+package api
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"easypars/models"
+	"easypars/pkg/clock"
+	"easypars/pkg/parser"
+	"easypars/pkg/stream"
+)
+
+// streamEvent is a Server-Sent Event read from the stream
+type streamEvent struct {
+	Type  string
+	Fight models.Fight
+}
+
+// streamClient is a client connected to /api/fights/stream
+type streamClient struct {
+	lines  chan string
+	cancel context.CancelFunc
+}
+
+// connectStream connects a client to the stream of the server and waits
+// for the connected comment
+func connectStream(t *testing.T, server *httptest.Server, query string) *streamClient {
+	t.Helper()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, server.URL+"/api/fights/stream"+query, nil)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("connecting to the stream: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK || resp.Header.Get("Content-Type") != "text/event-stream" {
+		resp.Body.Close()
+		t.Fatalf("stream = %d %s, want 200 text/event-stream", resp.StatusCode, resp.Header.Get("Content-Type"))
+	}
+
+	client := &streamClient{lines: make(chan string, 100), cancel: cancel}
+	go func() {
+		defer resp.Body.Close()
+		defer close(client.lines)
+		scanner := bufio.NewScanner(resp.Body)
+		for scanner.Scan() {
+			client.lines <- scanner.Text()
+		}
+	}()
+	if line := client.next(t); line != ": connected" {
+		t.Fatalf("first line = %q, want the connected comment", line)
+	}
+
+	return client
+}
+
+// next returns the next non-empty line of the stream
+func (c *streamClient) next(t *testing.T) string {
+	t.Helper()
+
+	timeout := time.After(5 * time.Second)
+	for {
+		select {
+		case line, open := <-c.lines:
+			if !open {
+				t.Fatal("the stream ended")
+			}
+			if line != "" {
+				return line
+			}
+		case <-timeout:
+			t.Fatal("no line on the stream within 5s")
+		}
+	}
+}
+
+// nextEvent returns the next event of the stream, skipping the comments
+func (c *streamClient) nextEvent(t *testing.T) streamEvent {
+	t.Helper()
+
+	for {
+		line := c.next(t)
+		eventType, ok := strings.CutPrefix(line, "event: ")
+		if !ok {
+			continue
+		}
+		data, ok := strings.CutPrefix(c.next(t), "data: ")
+		if !ok {
+			t.Fatalf("event %s without data", eventType)
+		}
+		event := streamEvent{Type: eventType}
+		if err := json.Unmarshal([]byte(data), &event.Fight); err != nil {
+			t.Fatalf("decoding the fight of %s: %v", eventType, err)
+		}
+		return event
+	}
+}
+
+// newStreamServer serves the router on a test server, with a source whose
+// page the returned function replaces
+func newStreamServer(t *testing.T, deps Dependencies) (*httptest.Server, func(string)) {
+	t.Helper()
+
+	var page atomic.Value
+	page.Store(readTestdata(t, "results.html"))
+	src := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		io.WriteString(w, page.Load().(string))
+	}))
+	t.Cleanup(src.Close)
+	deps.Parser = parser.NewParser(src.URL + "/")
+	deps.Parser.Clock = clock.Fixed{Time: testNow}
+
+	server := httptest.NewServer(SetupRouter(deps))
+	t.Cleanup(server.Close)
+
+	return server, func(p string) { page.Store(p) }
+}
+
+// refreshFights makes the server parse the source and publish a snapshot
+func refreshFights(t *testing.T, server *httptest.Server) {
+	t.Helper()
+
+	req, _ := http.NewRequest(http.MethodGet, server.URL+"/api/fights?refresh=true", nil)
+	req.Header.Set(confirmExpensiveHeader, "true")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("GET /api/fights?refresh=true = %d, want 200", resp.StatusCode)
+	}
+}
+
+// waitForClients waits until the hub has n clients
+func waitForClients(t *testing.T, hub *stream.Hub, n int) {
+	t.Helper()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for clients, _ := hub.Stats(); clients != n; clients, _ = hub.Stats() {
+		if time.Now().After(deadline) {
+			t.Fatalf("the hub has %d clients, want %d", clients, n)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// updatedResultsPage is results.html where the Canelo fight got its result
+// and a new fight is announced
+func updatedResultsPage(t *testing.T) string {
+	t.Helper()
+
+	page := readTestdata(t, "results.html")
+	page = strings.Replace(page, `<td class="boxer_1">Canelo</td><td class="vs">vs</td>`, `<td class="boxer_1">Canelo</td><td class="vs">UD</td>`, 1)
+	return strings.Replace(page, `</table>`, `<tr><td class="date">29</td><td class="place">Riyadh</td><td class="boxer_1">Beterbiev</td><td class="vs">vs</td><td class="boxer_2">Smith</td></tr></table>`, 1)
+}
+
+func TestStreamFightUpdates(t *testing.T) {
+	hub := stream.NewHub(stream.Config{})
+	server, setPage := newStreamServer(t, Dependencies{Stream: hub})
+	refreshFights(t, server)
+	first := connectStream(t, server, "")
+	second := connectStream(t, server, "")
+
+	setPage(updatedResultsPage(t))
+	refreshFights(t, server)
+
+	want := []struct {
+		eventType string
+		fighter   string
+	}{
+		{stream.EventAdded, "Beterbiev"},
+		{stream.EventUpdated, "Canelo"},
+	}
+	for i, client := range []*streamClient{first, second} {
+		for _, w := range want {
+			event := client.nextEvent(t)
+			if event.Type != w.eventType || event.Fight.Fighter1 != w.fighter {
+				t.Errorf("client %d: event %s of %s, want %s of %s", i, event.Type, event.Fight.Fighter1, w.eventType, w.fighter)
+			}
+		}
+	}
+
+	// Disconnected clients are unsubscribed
+	first.cancel()
+	second.cancel()
+	waitForClients(t, hub, 0)
+}
+
+func TestStreamHeartbeat(t *testing.T) {
+	server, _ := newStreamServer(t, Dependencies{StreamHeartbeat: 20 * time.Millisecond})
+	client := connectStream(t, server, "")
+
+	for range 2 {
+		if line := client.next(t); line != ": heartbeat" {
+			t.Errorf("line = %q, want a heartbeat comment", line)
+		}
+	}
+}
+
+func TestStreamRefused(t *testing.T) {
+	full := stream.NewHub(stream.Config{MaxClients: 1})
+	if _, err := full.Subscribe(); err != nil {
+		t.Fatal(err)
+	}
+	closed := stream.NewHub(stream.Config{})
+	closed.Close()
+
+	tests := []struct {
+		name   string
+		hub    *stream.Hub
+		query  string
+		status int
+		code   string
+	}{
+		{"too many clients", full, "", http.StatusServiceUnavailable, "too_many_streams"},
+		{"shutting down", closed, "", http.StatusServiceUnavailable, "stream_closed"},
+		{"invalid filter", stream.NewHub(stream.Config{}), "?limit=abc", http.StatusBadRequest, "invalid_params"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := newTestRouter(t, readTestdata(t, "results.html"), Dependencies{Stream: tt.hub})
+			rec := serve(router, http.MethodGet, "/api/fights/stream"+tt.query, "")
+			if rec.Code != tt.status {
+				t.Fatalf("GET /api/fights/stream = %d %s, want %d", rec.Code, rec.Body, tt.status)
+			}
+			if code := errorCode(t, rec); code != tt.code {
+				t.Errorf("error = %q, want %q", code, tt.code)
+			}
+		})
+	}
+}
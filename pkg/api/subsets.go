@@ -0,0 +1,42 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// fightsSubset serves /api/fights limited to a single status, for
+// /api/fights/upcoming and /api/fights/results
+// The status is set as the ?status= filter, so the subsets take every other
+// parameter of /api/fights. A request asking for another status is refused
+// rather than silently answered with the subset.
+func fightsSubset(status string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		query := c.Request.URL.Query()
+		if requested := query.Get("status"); requested != "" && requested != status {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{
+				"error":   "invalid_params",
+				"message": `invalid parameter "status": this endpoint only returns ` + status + ` fights`,
+			})
+			return
+		}
+
+		query.Set("status", status)
+		c.Request.URL.RawQuery = query.Encode()
+		c.Next()
+	}
+}
+
+// fightsFormat serves /api/fights in a fixed format, for feeds with a file
+// extension such as /api/fights/upcoming.ics
+// Calendar clients subscribe to the URL as it is, so the format does not
+// depend on their Accept header.
+func fightsFormat(format string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		query := c.Request.URL.Query()
+		query.Set("format", format)
+		c.Request.URL.RawQuery = query.Encode()
+		c.Next()
+	}
+}
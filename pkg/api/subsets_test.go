@@ -0,0 +1,127 @@
+package api
+
+import (
+	"net/http"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"easypars/models"
+	"easypars/pkg/apitypes"
+)
+
+func TestFightSubsets(t *testing.T) {
+	// Today is 2024-06-10: only the Canelo fight of the 22nd is announced
+	router := newTestRouter(t, readTestdata(t, "results.html"), Dependencies{})
+
+	tests := []struct {
+		name     string
+		target   string
+		status   int
+		fighters []string
+	}{
+		{"upcoming", "/api/fights/upcoming", http.StatusOK, []string{"Canelo"}},
+		{"results", "/api/fights/results", http.StatusOK, []string{"Bivol", "Dubois", "Joshua", "Usyk", "Zhang"}},
+		{"results with a search", "/api/fights/results?search=usyk", http.StatusOK, []string{"Usyk"}},
+		{"upcoming with a date range", "/api/fights/upcoming?to=2024-06-21", http.StatusOK, nil},
+		{"the same status", "/api/fights/upcoming?status=scheduled", http.StatusOK, []string{"Canelo"}},
+		{"another status", "/api/fights/upcoming?status=completed", http.StatusBadRequest, nil},
+		{"status filter", "/api/fights?status=scheduled", http.StatusOK, []string{"Canelo"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := serve(router, http.MethodGet, tt.target, "")
+			if rec.Code != tt.status {
+				t.Fatalf("GET %s = %d %s, want %d", tt.target, rec.Code, rec.Body, tt.status)
+			}
+			if tt.status != http.StatusOK {
+				if code := errorCode(t, rec); code != "invalid_params" {
+					t.Errorf("error = %q, want invalid_params", code)
+				}
+				return
+			}
+
+			var body apitypes.FightsResponse
+			decodeJSON(t, rec, &body)
+			var fighters []string
+			for _, fight := range body.Data {
+				fighters = append(fighters, fight.Fighter1)
+				want := models.StatusCompleted
+				if fight.Fighter1 == "Canelo" {
+					want = models.StatusScheduled
+				}
+				if fight.Status != want {
+					t.Errorf("status of %s = %s, want %s", fight.Fighter1, fight.Status, want)
+				}
+			}
+			sort.Strings(fighters)
+			if !reflect.DeepEqual(fighters, tt.fighters) {
+				t.Errorf("fights of %q, want %q", fighters, tt.fighters)
+			}
+		})
+	}
+}
+
+func TestStrayTextIsScheduledUntilTheFight(t *testing.T) {
+	// Today is 2024-06-10
+	page := `<html><body><div class="month">Июнь 2024</div><table>
+<tr><td class="date">09</td><td class="place">London</td><td class="boxer_1">Yesterday</td><td class="vs">см. анонс</td><td class="boxer_2">Opponent</td></tr>
+<tr><td class="date">10</td><td class="place">London</td><td class="boxer_1">Today</td><td class="vs">см. анонс</td><td class="boxer_2">Opponent</td></tr>
+<tr><td class="date">11</td><td class="place">London</td><td class="boxer_1">Tomorrow</td><td class="vs">см. анонс</td><td class="boxer_2">Opponent</td></tr>
+</table></body></html>`
+	router := newTestRouter(t, page, Dependencies{})
+
+	var body apitypes.FightsResponse
+	decodeJSON(t, serve(router, http.MethodGet, "/api/fights/upcoming", ""), &body)
+	var fighters []string
+	for _, fight := range body.Data {
+		fighters = append(fighters, fight.Fighter1)
+	}
+	sort.Strings(fighters)
+	if want := []string{"Today", "Tomorrow"}; !reflect.DeepEqual(fighters, want) {
+		t.Errorf("upcoming fights of %q, want %q", fighters, want)
+	}
+}
+
+func TestUpcomingICS(t *testing.T) {
+	router := newTestRouter(t, readTestdata(t, "results.html"), Dependencies{})
+
+	tests := []struct {
+		name    string
+		target  string
+		headers []string
+		status  int
+		events  int
+	}{
+		{"feed", "/api/fights/upcoming.ics", nil, http.StatusOK, 1},
+		{"json accepted", "/api/fights/upcoming.ics", []string{"Accept", "application/json"}, http.StatusOK, 1},
+		{"format in the query", "/api/fights/upcoming.ics?format=json", nil, http.StatusOK, 1},
+		{"filtered", "/api/fights/upcoming.ics?search=Usyk", nil, http.StatusOK, 0},
+		{"another status", "/api/fights/upcoming.ics?status=completed", nil, http.StatusBadRequest, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := serve(router, http.MethodGet, tt.target, "", tt.headers...)
+			if rec.Code != tt.status {
+				t.Fatalf("GET %s = %d %s, want %d", tt.target, rec.Code, rec.Body, tt.status)
+			}
+			if tt.status != http.StatusOK {
+				return
+			}
+			if got := rec.Header().Get("Content-Type"); !strings.HasPrefix(got, "text/calendar") {
+				t.Errorf("Content-Type = %q, want text/calendar", got)
+			}
+			body := rec.Body.String()
+			if !strings.HasPrefix(body, "BEGIN:VCALENDAR\r\n") || !strings.Contains(body, "X-PUBLISHED-TTL:") {
+				t.Errorf("body = %q, want a published calendar", body)
+			}
+			if got := strings.Count(body, "BEGIN:VEVENT\r\n"); got != tt.events {
+				t.Errorf("calendar has %d events, want %d", got, tt.events)
+			}
+			if tt.events > 0 && !strings.Contains(body, "SUMMARY:Canelo") {
+				t.Errorf("body = %q, want the Canelo fight", body)
+			}
+		})
+	}
+}
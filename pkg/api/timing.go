@@ -0,0 +1,25 @@
+package api
+
+import (
+	"fmt"
+	"strings"
+
+	"easypars/pkg/snapshot"
+
+	"github.com/gin-gonic/gin"
+)
+
+// setServerTiming reports the build time of the built snapshot aggregates
+// in the Server-Timing header ("agg-fighters;dur=1.25, ...")
+// It must be called before the response body is written
+func setServerTiming(c *gin.Context, snap *snapshot.Snapshot) {
+	var entries []string
+	for _, aggregate := range snap.Aggregates() {
+		if aggregate.Built {
+			entries = append(entries, fmt.Sprintf("agg-%s;dur=%.2f", aggregate.Name, aggregate.BuildMs))
+		}
+	}
+	if len(entries) > 0 {
+		c.Header("Server-Timing", strings.Join(entries, ", "))
+	}
+}
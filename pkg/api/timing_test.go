@@ -0,0 +1,42 @@
+package api
+
+import (
+	"net/http"
+	"reflect"
+	"regexp"
+	"testing"
+)
+
+func TestServerTimingReportsTheBuiltAggregates(t *testing.T) {
+	page := readTestdata(t, "results.html")
+	timing := regexp.MustCompile(`^agg-(\w+);dur=\d+\.\d{2}$`)
+
+	tests := []struct {
+		target string
+		// built are the aggregates the request built on a fresh snapshot
+		built []string
+	}{
+		{"/api/locations", []string{"locations"}},
+		{"/api/fighters", []string{"fighters"}},
+		{"/api/stats", []string{"fighters", "interest", "locations", "view"}},
+	}
+	for _, tt := range tests {
+		router := newTestRouter(t, page, Dependencies{})
+		rec := serve(router, http.MethodGet, tt.target, "")
+		if rec.Code != http.StatusOK {
+			t.Fatalf("GET %s = %d %s, want 200", tt.target, rec.Code, rec.Body)
+		}
+		values := rec.Header().Values("Server-Timing")
+		var built []string
+		for _, value := range regexp.MustCompile(`,\s*`).Split(values[0], -1) {
+			match := timing.FindStringSubmatch(value)
+			if match == nil {
+				t.Fatalf("GET %s: Server-Timing entry %q, want agg-<name>;dur=<ms>", tt.target, value)
+			}
+			built = append(built, match[1])
+		}
+		if !reflect.DeepEqual(built, tt.built) {
+			t.Errorf("GET %s: Server-Timing lists %q, want %q", tt.target, built, tt.built)
+		}
+	}
+}
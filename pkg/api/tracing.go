@@ -0,0 +1,49 @@
+package api
+
+import (
+	"net/http"
+
+	"easypars/pkg/metrics"
+	"easypars/pkg/tracing"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// httpTracing opens a server span for every request, named by the method
+// and the route pattern, and passes it to the handlers in the request
+// context, so the spans of the parser become its children
+// A trace started by the caller (traceparent header) is continued. 5xx
+// responses mark the span failed.
+func httpTracing(c *gin.Context) {
+	route := c.FullPath()
+	if route == "" {
+		route = metrics.UnmatchedRoute
+	}
+
+	ctx := otel.GetTextMapPropagator().Extract(c.Request.Context(), propagation.HeaderCarrier(c.Request.Header))
+	ctx, span := tracing.Tracer().Start(ctx, c.Request.Method+" "+route, trace.WithSpanKind(trace.SpanKindServer))
+	defer span.End()
+	if span.IsRecording() {
+		span.SetAttributes(
+			attribute.String("http.request.method", c.Request.Method),
+			attribute.String("http.route", route),
+			attribute.String("url.path", c.Request.URL.Path),
+			attribute.String("client.address", c.ClientIP()),
+			attribute.String("easypars.request_id", c.GetString(requestIDKey)),
+		)
+	}
+
+	c.Request = c.Request.WithContext(ctx)
+	c.Next()
+
+	status := c.Writer.Status()
+	span.SetAttributes(attribute.Int("http.response.status_code", status))
+	if status >= http.StatusInternalServerError {
+		span.SetStatus(codes.Error, http.StatusText(status))
+	}
+}
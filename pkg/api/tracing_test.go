@@ -0,0 +1,187 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// recordSpans installs a global tracer provider recording every span in
+// memory, restoring the previous provider at the end of the test
+func recordSpans(t *testing.T) *tracetest.SpanRecorder {
+	t.Helper()
+
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithSampler(sdktrace.AlwaysSample()),
+		sdktrace.WithSpanProcessor(recorder),
+	)
+	previous, previousPropagator := otel.GetTracerProvider(), otel.GetTextMapPropagator()
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() {
+		provider.Shutdown(context.Background())
+		otel.SetTracerProvider(previous)
+		otel.SetTextMapPropagator(previousPropagator)
+	})
+
+	return recorder
+}
+
+// spansByName indexes the ended spans by name
+func spansByName(recorder *tracetest.SpanRecorder) map[string][]sdktrace.ReadOnlySpan {
+	spans := make(map[string][]sdktrace.ReadOnlySpan)
+	for _, span := range recorder.Ended() {
+		spans[span.Name()] = append(spans[span.Name()], span)
+	}
+
+	return spans
+}
+
+// onlySpan returns the single span of the name
+func onlySpan(t *testing.T, spans map[string][]sdktrace.ReadOnlySpan, name string) sdktrace.ReadOnlySpan {
+	t.Helper()
+
+	if len(spans[name]) != 1 {
+		t.Fatalf("got %d %q spans, want 1 (spans: %v)", len(spans[name]), name, spanNames(spans))
+	}
+
+	return spans[name][0]
+}
+
+func spanNames(spans map[string][]sdktrace.ReadOnlySpan) []string {
+	names := make([]string, 0, len(spans))
+	for name := range spans {
+		names = append(names, name)
+	}
+
+	return names
+}
+
+// assertChild checks that child was started inside parent
+func assertChild(t *testing.T, child, parent sdktrace.ReadOnlySpan) {
+	t.Helper()
+
+	if child.Parent().SpanID() != parent.SpanContext().SpanID() || child.SpanContext().TraceID() != parent.SpanContext().TraceID() {
+		t.Errorf("%q has the parent %s, want %q (%s)", child.Name(), child.Parent().SpanID(), parent.Name(), parent.SpanContext().SpanID())
+	}
+}
+
+// intAttribute returns the value of an int attribute of the span
+func intAttribute(span sdktrace.ReadOnlySpan, key string) (int64, bool) {
+	for _, attr := range span.Attributes() {
+		if string(attr.Key) == key {
+			return attr.Value.AsInt64(), true
+		}
+	}
+
+	return 0, false
+}
+
+func TestTracingSpanParentage(t *testing.T) {
+	recorder := recordSpans(t)
+	router := newTestRouter(t, readTestdata(t, "results.html"), Dependencies{Tracing: true})
+
+	if rec := serve(router, http.MethodGet, "/api/fights", ""); rec.Code != http.StatusOK {
+		t.Fatalf("GET /api/fights = %d, want 200", rec.Code)
+	}
+
+	spans := spansByName(recorder)
+	server := onlySpan(t, spans, "GET /api/fights")
+	if server.Parent().IsValid() {
+		t.Errorf("server span has the parent %s, want a root span", server.Parent().SpanID())
+	}
+	if server.SpanKind() != trace.SpanKindServer {
+		t.Errorf("server span kind = %v, want server", server.SpanKind())
+	}
+	if status, _ := intAttribute(server, "http.response.status_code"); status != http.StatusOK {
+		t.Errorf("server span status code = %d, want 200", status)
+	}
+
+	page := onlySpan(t, spans, "parser.parsePage")
+	assertChild(t, page, server)
+	fetch := onlySpan(t, spans, "parser.fetchHTMLDocument")
+	assertChild(t, fetch, page)
+	if status, _ := intAttribute(fetch, "http.response.status_code"); status != http.StatusOK {
+		t.Errorf("fetch span status code = %d, want 200", status)
+	}
+	assertChild(t, onlySpan(t, spans, "parser.extractFightElements"), page)
+}
+
+func TestTracingPaginationFanOut(t *testing.T) {
+	recorder := recordSpans(t)
+	p := newTestParser(t, readTestdata(t, "results.html"))
+	p.PageURL = p.BaseURL + "page/{page}"
+	router := SetupRouter(Dependencies{Parser: p, Tracing: true})
+
+	if rec := serve(router, http.MethodGet, "/api/fights?pages=3", ""); rec.Code != http.StatusOK {
+		t.Fatalf("GET /api/fights?pages=3 = %d %s, want 200", rec.Code, rec.Body)
+	}
+
+	spans := spansByName(recorder)
+	server := onlySpan(t, spans, "GET /api/fights")
+	fanOut := onlySpan(t, spans, "parser.ParseWithPagination")
+	assertChild(t, fanOut, server)
+
+	// The main page is parsed for the snapshot, pages 2 and 3 each in their
+	// own goroutine under the fan-out span
+	byID := make(map[trace.SpanID]sdktrace.ReadOnlySpan)
+	var underServer, underFanOut int
+	for _, page := range spans["parser.parsePage"] {
+		byID[page.SpanContext().SpanID()] = page
+		switch page.Parent().SpanID() {
+		case server.SpanContext().SpanID():
+			underServer++
+		case fanOut.SpanContext().SpanID():
+			underFanOut++
+		default:
+			t.Errorf("page span has the parent %s, want the request or the fan-out", page.Parent().SpanID())
+		}
+	}
+	if underServer != 1 || underFanOut != 2 {
+		t.Errorf("got %d page spans under the request and %d under the fan-out, want 1 and 2", underServer, underFanOut)
+	}
+	if len(spans["parser.fetchHTMLDocument"]) != 3 {
+		t.Errorf("got %d fetch spans, want 3", len(spans["parser.fetchHTMLDocument"]))
+	}
+	for _, fetch := range spans["parser.fetchHTMLDocument"] {
+		if page, ok := byID[fetch.Parent().SpanID()]; !ok {
+			t.Errorf("fetch span has the parent %s, want a page span", fetch.Parent().SpanID())
+		} else {
+			assertChild(t, fetch, page)
+		}
+	}
+}
+
+func TestTracingContinuesCallerTrace(t *testing.T) {
+	recorder := recordSpans(t)
+	router := newTestRouter(t, readTestdata(t, "results.html"), Dependencies{Tracing: true})
+
+	const traceID, parentID = "4bf92f3577b34da6a3ce929d0e0e4736", "00f067aa0ba902b7"
+	serve(router, http.MethodGet, "/api/fights", "", "traceparent", "00-"+traceID+"-"+parentID+"-01")
+
+	server := onlySpan(t, spansByName(recorder), "GET /api/fights")
+	if got := server.SpanContext().TraceID().String(); got != traceID {
+		t.Errorf("trace ID = %s, want the caller's %s", got, traceID)
+	}
+	if got := server.Parent().SpanID().String(); got != parentID || !server.Parent().IsRemote() {
+		t.Errorf("parent = %s (remote %v), want the caller's span %s", got, server.Parent().IsRemote(), parentID)
+	}
+}
+
+func TestTracingDisabledRecordsNoServerSpan(t *testing.T) {
+	recorder := recordSpans(t)
+	router := newTestRouter(t, readTestdata(t, "results.html"), Dependencies{})
+
+	serve(router, http.MethodGet, "/api/fights", "")
+
+	if spans := spansByName(recorder); len(spans["GET /api/fights"]) != 0 {
+		t.Errorf("got a server span with tracing disabled")
+	}
+}
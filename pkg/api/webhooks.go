@@ -0,0 +1,166 @@
+package api
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"easypars/pkg/snapshot"
+	"easypars/pkg/webhook"
+
+	"github.com/gin-gonic/gin"
+)
+
+// requireWebhooks responds with an error when webhooks are not configured
+func (h *handler) requireWebhooks(c *gin.Context) bool {
+	if h.deps.Webhooks != nil {
+		return true
+	}
+
+	c.JSON(http.StatusServiceUnavailable, gin.H{
+		"error":   "webhooks_unavailable",
+		"message": "Webhooks are not enabled (webhooks.enabled)",
+	})
+	return false
+}
+
+// respondWebhookError maps webhook store errors to HTTP responses
+func respondWebhookError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, webhook.ErrInvalidHook):
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "invalid_webhook",
+			"message": err.Error(),
+		})
+	case errors.Is(err, webhook.ErrTooManyHooks):
+		c.JSON(http.StatusConflict, gin.H{
+			"error":   "too_many_webhooks",
+			"message": err.Error(),
+		})
+	case errors.Is(err, webhook.ErrHookNotFound):
+		c.JSON(http.StatusNotFound, gin.H{
+			"error":   "webhook_not_found",
+			"message": err.Error(),
+		})
+	default:
+		slog.ErrorContext(c.Request.Context(), "Webhook store error", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "webhook_error",
+			"message": "Failed to update the webhooks",
+		})
+	}
+}
+
+// handleCreateWebhook handles POST requests to /api/webhooks
+// Registers a callback URL for new fight results; the optional secret
+// signs the deliveries (see webhook.Sign) and is not returned afterwards.
+// A URL with an internal IP address outside webhooks.allowed_hosts is
+// rejected with 400.
+func (h *handler) handleCreateWebhook(c *gin.Context) {
+	if !h.requireWebhooks(c) {
+		return
+	}
+
+	var request struct {
+		URL    string `json:"url"`
+		Secret string `json:"secret"`
+	}
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "invalid_body",
+			"message": "Request body must be a JSON object with url and an optional secret",
+		})
+		return
+	}
+
+	if err := h.deps.Webhooks.CheckURL(request.URL); err != nil {
+		respondWebhookError(c, err)
+		return
+	}
+	hook, err := h.deps.Webhooks.Hooks().Create(request.URL, request.Secret)
+	if err != nil {
+		respondWebhookError(c, err)
+		return
+	}
+
+	slog.InfoContext(c.Request.Context(), "Webhook registered", "hook_id", hook.ID, "url", hook.URL)
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Webhook registered successfully",
+		"data":    hook,
+	})
+}
+
+// handleGetWebhooks handles GET requests to /api/webhooks
+func (h *handler) handleGetWebhooks(c *gin.Context) {
+	if !h.requireWebhooks(c) {
+		return
+	}
+
+	hooks := h.deps.Webhooks.Hooks().List()
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Webhooks retrieved successfully",
+		"data":    hooks,
+		"count":   len(hooks),
+	})
+}
+
+// handleDeleteWebhook handles DELETE requests to /api/webhooks/:id
+// Deliveries already running are finished
+func (h *handler) handleDeleteWebhook(c *gin.Context) {
+	if !h.requireWebhooks(c) {
+		return
+	}
+
+	if err := h.deps.Webhooks.Hooks().Delete(c.Param("id")); err != nil {
+		respondWebhookError(c, err)
+		return
+	}
+
+	slog.InfoContext(c.Request.Context(), "Webhook removed", "hook_id", c.Param("id"))
+	c.Status(http.StatusNoContent)
+}
+
+// handleGetWebhookDeliveries handles GET requests to
+// /api/webhooks/:id/deliveries
+// Returns the recent deliveries of the webhook with their attempts, newest
+// first
+func (h *handler) handleGetWebhookDeliveries(c *gin.Context) {
+	if !h.requireWebhooks(c) {
+		return
+	}
+
+	id := c.Param("id")
+	if _, ok := h.deps.Webhooks.Hooks().Get(id); !ok {
+		respondWebhookError(c, webhook.ErrHookNotFound)
+		return
+	}
+
+	deliveries := h.deps.Webhooks.Deliveries(id)
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Webhook deliveries retrieved successfully",
+		"data":    deliveries,
+		"count":   len(deliveries),
+	})
+}
+
+// notifyCompleted sends the fights completed since the last notified
+// snapshot to the webhooks
+// The first snapshot after the start is only the baseline: the results it
+// holds were known before.
+func (h *handler) notifyCompleted(snap *snapshot.Snapshot) {
+	if h.deps.Webhooks == nil || snap == nil {
+		return
+	}
+
+	prev, ok := h.notified.advance(snap)
+	if !ok || prev == nil {
+		return
+	}
+
+	fights := snapshot.NewlyCompleted(prev, snap)
+	if len(fights) == 0 {
+		return
+	}
+	slog.Info("Notifying webhooks of new results", "fight_count", len(fights))
+	h.deps.Webhooks.Send(webhook.Event{Type: webhook.EventFightsCompleted, Fights: fights})
+}
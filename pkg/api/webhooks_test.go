@@ -0,0 +1,43 @@
+package api
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"easypars/pkg/webhook"
+)
+
+func TestCreateWebhookRejectsInternalAddresses(t *testing.T) {
+	store, err := webhook.NewStore("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	dispatcher := webhook.NewDispatcher(store, webhook.Config{AllowedHosts: []string{"10.0.0.12"}})
+	router := newTestRouter(t, readTestdata(t, "results.html"), Dependencies{Auth: newTestAuth(t), Webhooks: dispatcher})
+	token := "Bearer " + signTestToken(t, testJWTSecret, time.Now())
+
+	tests := []struct {
+		url    string
+		status int
+	}{
+		{"http://127.0.0.1:8080/hook", http.StatusBadRequest},
+		{"http://169.254.169.254/latest/meta-data/", http.StatusBadRequest},
+		{"http://[::1]/hook", http.StatusBadRequest},
+		{"http://192.168.0.10/hook", http.StatusBadRequest},
+		{"http://10.0.0.12/hook", http.StatusCreated},
+		{"https://example.com/hook", http.StatusCreated},
+	}
+	for _, tt := range tests {
+		rec := serve(router, http.MethodPost, "/api/webhooks", `{"url":"`+tt.url+`"}`, "Content-Type", "application/json", "Authorization", token)
+		if rec.Code != tt.status {
+			t.Errorf("POST /api/webhooks %s = %d %s, want %d", tt.url, rec.Code, rec.Body, tt.status)
+		}
+		if tt.status == http.StatusBadRequest && errorCode(t, rec) != "invalid_webhook" {
+			t.Errorf("POST /api/webhooks %s error = %s, want invalid_webhook", tt.url, errorCode(t, rec))
+		}
+	}
+	if hooks := store.List(); len(hooks) != 2 {
+		t.Errorf("store holds %d webhooks, want the 2 accepted", len(hooks))
+	}
+}
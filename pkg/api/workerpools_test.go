@@ -0,0 +1,65 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"easypars/pkg/apitypes"
+	"easypars/pkg/pipeline"
+)
+
+func TestWorkerPoolStatistics(t *testing.T) {
+	pool := pipeline.NewPool("api test", 1, 1)
+	t.Cleanup(pool.Close)
+	done := make(chan struct{})
+	if err := pool.Submit(context.Background(), func(context.Context) error { close(done); return nil }); err != nil {
+		t.Fatal(err)
+	}
+	<-done
+	router := newTestRouter(t, readTestdata(t, "results.html"), Dependencies{Auth: newTestAuth(t)})
+
+	// poolStats returns the statistics of the test pool in a list
+	poolStats := func(pools []pipeline.PoolStats) *pipeline.PoolStats {
+		for i := range pools {
+			if pools[i].Name == "api test" {
+				return &pools[i]
+			}
+		}
+		return nil
+	}
+	var health apitypes.HealthResponse
+	decodeJSON(t, serve(router, http.MethodGet, "/api/health", ""), &health)
+	if health.WorkerPools != nil {
+		t.Errorf("health without verbose lists %d worker pools", len(health.WorkerPools))
+	}
+	decodeJSON(t, serve(router, http.MethodGet, "/api/health?verbose=1", ""), &health)
+	if stats := poolStats(health.WorkerPools); stats == nil || stats.Workers != 1 {
+		t.Errorf("verbose health worker pools = %+v, want the test pool", health.WorkerPools)
+	}
+
+	// The task finishes right after it signalled done
+	deadline := time.Now().Add(time.Second)
+	for pool.Stats().TasksDone == 0 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	var stats apitypes.StatsResponse
+	decodeJSON(t, serve(router, http.MethodGet, "/api/stats", ""), &stats)
+	if got := poolStats(stats.WorkerPools); got == nil || got.TasksDone != 1 {
+		t.Errorf("stats worker pools = %+v, want the test pool with one task", stats.WorkerPools)
+	}
+
+	token := "Bearer " + signTestToken(t, testJWTSecret, time.Now())
+	rec := serve(router, http.MethodPost, "/api/admin/worker-pools/reset", "", "Authorization", token)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("POST /api/admin/worker-pools/reset = %d %s, want 200", rec.Code, rec.Body)
+	}
+	var reset struct {
+		Data []pipeline.PoolStats `json:"data"`
+	}
+	decodeJSON(t, rec, &reset)
+	if got := poolStats(reset.Data); got == nil || got.TasksDone != 0 {
+		t.Errorf("worker pools after the reset = %+v, want cleared counters", reset.Data)
+	}
+}
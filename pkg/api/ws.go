@@ -0,0 +1,208 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"easypars/models"
+	"easypars/pkg/stream"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+)
+
+// WebSocket limits
+const (
+	// wsWriteWait bounds every write to a WebSocket, and how long the
+	// client has to answer a ping
+	wsWriteWait = 10 * time.Second
+	// wsMaxMessageSize bounds the messages read from a client
+	wsMaxMessageSize = 4096
+)
+
+// wsUpgrader upgrades the requests of /api/ws
+// The API answers every origin (see the CORS headers of SetupRouter) and
+// the socket only serves public data, so every origin may connect.
+var wsUpgrader = websocket.Upgrader{
+	ReadBufferSize:  1024,
+	WriteBufferSize: 4096,
+	CheckOrigin:     func(*http.Request) bool { return true },
+}
+
+// wsMessage is a message sent to a WebSocket client
+// Updates mirror the events of /api/fights/stream: Event is fight.added or
+// fight.updated and Data the fight. Answers to the client messages are
+// "subscribed" with the filter in effect, or "error".
+type wsMessage struct {
+	Event string `json:"event"`
+	Data  any    `json:"data"`
+}
+
+// wsRequest is a message of a WebSocket client, e.g.
+// {"action": "subscribe", "filter": {"search": "usyk"}}
+type wsRequest struct {
+	Action string            `json:"action"`
+	Filter map[string]string `json:"filter"`
+}
+
+// wsSubscription is a filter requested by a client, or the error of an
+// invalid request; it is handed from the reading to the writing goroutine
+type wsSubscription struct {
+	filter url.Values
+	err    error
+}
+
+// handleWebSocket handles GET requests to /api/ws
+// Upgrades the connection to a WebSocket delivering the fight updates of
+// /api/fights/stream as {"event": ..., "data": <fight>} messages. The
+// filter parameters of /api/fights in the URL select the fights; a client
+// changes them with {"action": "subscribe", "filter": {"search": "usyk"}},
+// an empty filter receiving every fight. The server pings the client at
+// the stream heartbeat and drops it when no pong comes back; the socket is
+// closed with 1001 when the server shuts down and with 1013 when the client
+// reads too slowly.
+func (h *handler) handleWebSocket(c *gin.Context) {
+	if err := validateFightsParams(c.Request.URL.Query(), false); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "invalid_params",
+			"message": err.Error(),
+		})
+		return
+	}
+	filters, err := h.filterLayers(c)
+	if err != nil {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error":   "forbidden",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	client, ok := h.subscribeStream(c)
+	if !ok {
+		return
+	}
+	defer h.deps.Stream.Unsubscribe(client)
+
+	// The upgrader answers a failed handshake itself
+	conn, err := wsUpgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	pingPeriod := h.streamHeartbeat()
+	subscriptions := make(chan wsSubscription)
+	closed, stopped := make(chan struct{}), make(chan struct{})
+	defer close(stopped)
+	go readWebSocket(conn, pingPeriod+wsWriteWait, subscriptions, closed, stopped)
+
+	lang := preferredLanguage(c.GetHeader("Accept-Language"))
+	ping := time.NewTicker(pingPeriod)
+	defer ping.Stop()
+	for {
+		var message wsMessage
+		select {
+		case <-closed:
+			return
+		case <-c.Request.Context().Done():
+			closeWebSocket(conn, websocket.CloseGoingAway, "server shutting down")
+			return
+		case <-ping.C:
+			if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(wsWriteWait)); err != nil {
+				return
+			}
+			continue
+		case subscription := <-subscriptions:
+			if subscription.err != nil {
+				message = wsMessage{Event: "error", Data: gin.H{"error": "invalid_message", "message": subscription.err.Error()}}
+			} else {
+				filters.requested = subscription.filter
+				message = wsMessage{Event: "subscribed", Data: gin.H{"filter": flattenValues(subscription.filter)}}
+			}
+		case event, open := <-client.Events():
+			if !open {
+				if errors.Is(client.Err(), stream.ErrSlowClient) {
+					slog.WarnContext(c.Request.Context(), "Slow WebSocket client disconnected")
+					closeWebSocket(conn, websocket.CloseTryAgainLater, "too slow, reload the fights and reconnect")
+				} else {
+					closeWebSocket(conn, websocket.CloseGoingAway, "server shutting down")
+				}
+				return
+			}
+			if !filters.match(event.Fight) {
+				continue
+			}
+			message = wsMessage{Event: event.Type, Data: localizeCountries([]models.Fight{event.Fight}, lang)[0]}
+		}
+
+		_ = conn.SetWriteDeadline(time.Now().Add(wsWriteWait))
+		if err := conn.WriteJSON(message); err != nil {
+			return
+		}
+	}
+}
+
+// readWebSocket reads the messages of a client until the connection fails
+// or closes, then closes closed
+// Every pong extends the read deadline by pongWait; the subscriptions are
+// handed to the writing goroutine, the only one allowed to write, until it
+// closes stopped.
+func readWebSocket(conn *websocket.Conn, pongWait time.Duration, subscriptions chan<- wsSubscription, closed chan<- struct{}, stopped <-chan struct{}) {
+	defer close(closed)
+
+	conn.SetReadLimit(wsMaxMessageSize)
+	_ = conn.SetReadDeadline(time.Now().Add(pongWait))
+	conn.SetPongHandler(func(string) error {
+		return conn.SetReadDeadline(time.Now().Add(pongWait))
+	})
+
+	for {
+		_, data, err := conn.ReadMessage()
+		if err != nil {
+			return
+		}
+		subscription := parseWebSocketRequest(data)
+		select {
+		case subscriptions <- subscription:
+		case <-stopped:
+			return
+		}
+	}
+}
+
+// parseWebSocketRequest parses and validates a client message
+func parseWebSocketRequest(data []byte) wsSubscription {
+	var request wsRequest
+	if err := json.Unmarshal(data, &request); err != nil {
+		return wsSubscription{err: errors.New(`message must be a JSON object like {"action": "subscribe", "filter": {...}}`)}
+	}
+	if request.Action != "subscribe" {
+		return wsSubscription{err: fmt.Errorf(`unknown action %q, must be "subscribe"`, request.Action)}
+	}
+
+	filter := url.Values{}
+	for key, value := range request.Filter {
+		if !filterParams[key] {
+			return wsSubscription{err: fmt.Errorf("unsupported filter %q, supported: %s", key, strings.Join(sortedFilterParams(), ", "))}
+		}
+		filter.Set(key, value)
+	}
+	if err := validateFightsParams(filter, false); err != nil {
+		return wsSubscription{err: err}
+	}
+
+	return wsSubscription{filter: filter}
+}
+
+// closeWebSocket sends a close message to the client before the connection
+// is closed
+func closeWebSocket(conn *websocket.Conn, code int, reason string) {
+	_ = conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(code, reason), time.Now().Add(wsWriteWait))
+}
@@ -0,0 +1,242 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"easypars/models"
+	"easypars/pkg/stream"
+
+	"github.com/gorilla/websocket"
+)
+
+// wsReceived is a message read from /api/ws
+type wsReceived struct {
+	Event string          `json:"event"`
+	Data  json.RawMessage `json:"data"`
+}
+
+// dialWebSocket connects a client to /api/ws of the server
+func dialWebSocket(t *testing.T, server *httptest.Server, query string) *websocket.Conn {
+	t.Helper()
+
+	url := "ws" + strings.TrimPrefix(server.URL, "http") + "/api/ws" + query
+	conn, resp, err := websocket.DefaultDialer.Dial(url, nil)
+	if err != nil {
+		t.Fatalf("dialing %s: %v", url, err)
+	}
+	resp.Body.Close()
+	t.Cleanup(func() { conn.Close() })
+
+	return conn
+}
+
+// readWS returns the next message of the socket
+func readWS(t *testing.T, conn *websocket.Conn) wsReceived {
+	t.Helper()
+
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	var message wsReceived
+	if err := conn.ReadJSON(&message); err != nil {
+		t.Fatalf("reading the socket: %v", err)
+	}
+
+	return message
+}
+
+// readWSFight returns the next fight update of the socket
+func readWSFight(t *testing.T, conn *websocket.Conn) (string, models.Fight) {
+	t.Helper()
+
+	message := readWS(t, conn)
+	var fight models.Fight
+	if err := json.Unmarshal(message.Data, &fight); err != nil {
+		t.Fatalf("decoding the fight of %s: %v", message.Event, err)
+	}
+
+	return message.Event, fight
+}
+
+func TestWebSocketUpdates(t *testing.T) {
+	tests := []struct {
+		name      string
+		query     string
+		subscribe string
+		// want are the first fighters of the updates received
+		want []string
+	}{
+		{"every fight", "", "", []string{"Beterbiev", "Canelo"}},
+		{"subscribed filter", "", `{"action":"subscribe","filter":{"search":"canelo"}}`, []string{"Canelo"}},
+		{"filter in the URL", "?search=beterbiev", "", []string{"Beterbiev"}},
+		{"subscription replaces the URL filter", "?search=beterbiev", `{"action":"subscribe","filter":{"search":"canelo"}}`, []string{"Canelo"}},
+		{"empty filter", "?search=beterbiev", `{"action":"subscribe","filter":{}}`, []string{"Beterbiev", "Canelo"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hub := stream.NewHub(stream.Config{})
+			server, setPage := newStreamServer(t, Dependencies{Stream: hub})
+			refreshFights(t, server)
+			conn := dialWebSocket(t, server, tt.query)
+			// A client of the SSE stream shares the hub
+			sse := connectStream(t, server, "")
+
+			if tt.subscribe != "" {
+				if err := conn.WriteMessage(websocket.TextMessage, []byte(tt.subscribe)); err != nil {
+					t.Fatal(err)
+				}
+				if message := readWS(t, conn); message.Event != "subscribed" {
+					t.Fatalf("answer to the subscription = %s %s, want subscribed", message.Event, message.Data)
+				}
+			}
+			waitForClients(t, hub, 2)
+
+			setPage(updatedResultsPage(t))
+			refreshFights(t, server)
+
+			var got []string
+			for range tt.want {
+				event, fight := readWSFight(t, conn)
+				if event != stream.EventAdded && event != stream.EventUpdated {
+					t.Errorf("event = %q, want a fight update", event)
+				}
+				got = append(got, fight.Fighter1)
+			}
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("updates of %q, want %q", got, tt.want)
+			}
+			if event := sse.nextEvent(t); event.Fight.Fighter1 != "Beterbiev" {
+				t.Errorf("the SSE client got %s of %s, want the same updates", event.Type, event.Fight.Fighter1)
+			}
+		})
+	}
+}
+
+func TestWebSocketInvalidMessages(t *testing.T) {
+	server, _ := newStreamServer(t, Dependencies{})
+	conn := dialWebSocket(t, server, "")
+
+	tests := []struct {
+		name    string
+		message string
+		want    string
+	}{
+		{"not JSON", `subscribe`, "must be a JSON object"},
+		{"unknown action", `{"action":"unsubscribe"}`, "unknown action"},
+		{"unsupported filter", `{"action":"subscribe","filter":{"limit":"10"}}`, `unsupported filter "limit"`},
+		{"invalid value", `{"action":"subscribe","filter":{"from":"yesterday"}}`, "from"},
+	}
+	for _, tt := range tests {
+		if err := conn.WriteMessage(websocket.TextMessage, []byte(tt.message)); err != nil {
+			t.Fatal(err)
+		}
+		message := readWS(t, conn)
+		var body struct {
+			Error   string `json:"error"`
+			Message string `json:"message"`
+		}
+		json.Unmarshal(message.Data, &body)
+		if message.Event != "error" || body.Error != "invalid_message" || !strings.Contains(body.Message, tt.want) {
+			t.Errorf("%s: answer = %s %s, want an invalid_message error about %q", tt.name, message.Event, message.Data, tt.want)
+		}
+	}
+
+	// The socket stays open after an invalid message
+	if err := conn.WriteMessage(websocket.TextMessage, []byte(`{"action":"subscribe"}`)); err != nil {
+		t.Fatal(err)
+	}
+	if message := readWS(t, conn); message.Event != "subscribed" {
+		t.Errorf("answer = %s %s, want subscribed", message.Event, message.Data)
+	}
+}
+
+func TestWebSocketDisconnect(t *testing.T) {
+	tests := []struct {
+		name  string
+		close func(conn *websocket.Conn)
+	}{
+		{"close message", func(conn *websocket.Conn) {
+			conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""), time.Now().Add(time.Second))
+			conn.Close()
+		}},
+		{"dropped connection", func(conn *websocket.Conn) { conn.Close() }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hub := stream.NewHub(stream.Config{})
+			server, _ := newStreamServer(t, Dependencies{Stream: hub})
+			conn := dialWebSocket(t, server, "")
+			other := dialWebSocket(t, server, "")
+			waitForClients(t, hub, 2)
+
+			tt.close(conn)
+			waitForClients(t, hub, 1)
+
+			// The other client is still served
+			if err := other.WriteMessage(websocket.TextMessage, []byte(`{"action":"subscribe"}`)); err != nil {
+				t.Fatal(err)
+			}
+			if message := readWS(t, other); message.Event != "subscribed" {
+				t.Errorf("answer = %s, want subscribed", message.Event)
+			}
+		})
+	}
+}
+
+func TestWebSocketShutdown(t *testing.T) {
+	hub := stream.NewHub(stream.Config{})
+	server, _ := newStreamServer(t, Dependencies{Stream: hub})
+	conn := dialWebSocket(t, server, "")
+	waitForClients(t, hub, 1)
+
+	hub.Close()
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	_, _, err := conn.ReadMessage()
+	var closeErr *websocket.CloseError
+	if !errors.As(err, &closeErr) || closeErr.Code != websocket.CloseGoingAway {
+		t.Errorf("read after the shutdown = %v, want a close with %d", err, websocket.CloseGoingAway)
+	}
+}
+
+func TestWebSocketPing(t *testing.T) {
+	server, _ := newStreamServer(t, Dependencies{StreamHeartbeat: 20 * time.Millisecond})
+	conn := dialWebSocket(t, server, "")
+
+	var pings atomic.Int32
+	conn.SetPingHandler(func(data string) error {
+		pings.Add(1)
+		return conn.WriteControl(websocket.PongMessage, []byte(data), time.Now().Add(time.Second))
+	})
+	// Control messages are handled while the client reads
+	go func() {
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for pings.Load() < 3 {
+		if time.Now().After(deadline) {
+			t.Fatalf("got %d pings, want the server to ping every heartbeat", pings.Load())
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestWebSocketRequiresAnUpgrade(t *testing.T) {
+	router := newTestRouter(t, readTestdata(t, "results.html"), Dependencies{})
+
+	if rec := serve(router, http.MethodGet, "/api/ws", ""); rec.Code != http.StatusBadRequest {
+		t.Errorf("GET /api/ws without an upgrade = %d, want 400", rec.Code)
+	}
+	if rec := serve(router, http.MethodGet, "/api/ws?from=yesterday", ""); rec.Code != http.StatusBadRequest || errorCode(t, rec) != "invalid_params" {
+		t.Errorf("GET /api/ws with an invalid filter = %d %s, want 400 invalid_params", rec.Code, rec.Body)
+	}
+}
@@ -0,0 +1,125 @@
+package api
+
+import (
+	"fmt"
+	"time"
+
+	"easypars/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/xuri/excelize/v2"
+)
+
+// DefaultExportMaxRows bounds the fights of an XLSX export when no limit
+// is configured
+const DefaultExportMaxRows = 100000
+
+// xlsxSheet is the name of the worksheet of the XLSX export
+const xlsxSheet = "Fights"
+
+// xlsxColumnWidths are the widths of the export columns in characters
+var xlsxColumnWidths = []float64{14, 12, 28, 28, 16, 32, 20}
+
+// exportMaxRows returns the row limit of the XLSX export
+func (h *handler) exportMaxRows() int {
+	if h.deps.ExportMaxRows > 0 {
+		return h.deps.ExportMaxRows
+	}
+
+	return DefaultExportMaxRows
+}
+
+// writeFightsXLSX writes the fights as an Excel workbook with a single
+// "Fights" sheet and the columns of the CSV export
+// The header row is frozen, the date column holds Excel dates and parsed_at
+// date-times in UTC; dates that are not YYYY-MM-DD are kept as text. The
+// rows go through the excelize stream writer, which moves them to a
+// temporary file when they get large, and the workbook is written to the
+// response in a single pass.
+func writeFightsXLSX(c *gin.Context, fights []models.Fight, builtAt time.Time) error {
+	f := excelize.NewFile()
+	defer f.Close()
+
+	// Step 1: Set up the sheet
+	if err := f.SetSheetName(f.GetSheetName(0), xlsxSheet); err != nil {
+		return err
+	}
+	headerStyle, err := f.NewStyle(&excelize.Style{Font: &excelize.Font{Bold: true}})
+	if err != nil {
+		return err
+	}
+	dateFormat, dateTimeFormat := "yyyy-mm-dd", "yyyy-mm-dd hh:mm:ss"
+	dateStyle, err := f.NewStyle(&excelize.Style{CustomNumFmt: &dateFormat})
+	if err != nil {
+		return err
+	}
+	dateTimeStyle, err := f.NewStyle(&excelize.Style{CustomNumFmt: &dateTimeFormat})
+	if err != nil {
+		return err
+	}
+
+	sw, err := f.NewStreamWriter(xlsxSheet)
+	if err != nil {
+		return err
+	}
+	// Panes and column widths must be set before the first row
+	if err := sw.SetPanes(&excelize.Panes{
+		Freeze:      true,
+		YSplit:      1,
+		TopLeftCell: "A2",
+		ActivePane:  "bottomLeft",
+	}); err != nil {
+		return err
+	}
+	for i, width := range xlsxColumnWidths {
+		if err := sw.SetColWidth(i+1, i+1, width); err != nil {
+			return err
+		}
+	}
+
+	// Step 2: Write the header and the fights
+	header := make([]interface{}, len(exportCSVHeader))
+	for i, name := range exportCSVHeader {
+		header[i] = excelize.Cell{StyleID: headerStyle, Value: name}
+	}
+	if err := sw.SetRow("A1", header); err != nil {
+		return err
+	}
+
+	for i, fight := range fights {
+		cell, err := excelize.CoordinatesToCellName(1, i+2)
+		if err != nil {
+			return err
+		}
+		if err := sw.SetRow(cell, xlsxRow(fight, builtAt, dateStyle, dateTimeStyle)); err != nil {
+			return err
+		}
+	}
+	if err := sw.Flush(); err != nil {
+		return err
+	}
+
+	// Step 3: Send the workbook
+	if err := f.Write(c.Writer); err != nil {
+		return fmt.Errorf("error writing workbook: %w", err)
+	}
+
+	return nil
+}
+
+// xlsxRow returns the cells of a fight in the columns of the export
+func xlsxRow(fight models.Fight, builtAt time.Time, dateStyle, dateTimeStyle int) []interface{} {
+	var date interface{} = fight.Date
+	if parsed, err := time.Parse("2006-01-02", fight.Date); err == nil {
+		date = excelize.Cell{StyleID: dateStyle, Value: parsed}
+	}
+	parsedAt := builtAt
+	if fight.ParsedAt != nil {
+		parsedAt = *fight.ParsedAt
+	}
+
+	return []interface{}{
+		exportID(fight), date, fight.Fighter1, fight.Fighter2, fight.Result, fight.Location,
+		excelize.Cell{StyleID: dateTimeStyle, Value: parsedAt.UTC()},
+	}
+}
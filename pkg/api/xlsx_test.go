@@ -0,0 +1,125 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"easypars/models"
+	"easypars/pkg/apitypes"
+
+	"github.com/xuri/excelize/v2"
+)
+
+func TestExportXLSX(t *testing.T) {
+	router := newTestRouter(t, readTestdata(t, "export.html"), Dependencies{})
+
+	var served apitypes.FightsResponse
+	decodeJSON(t, serve(router, http.MethodGet, "/api/fights?limit=100", ""), &served)
+
+	rec := serve(router, http.MethodGet, "/api/fights/export?format=xlsx", "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("export = %d %s, want 200", rec.Code, rec.Body)
+	}
+	if got := rec.Header().Get("Content-Type"); got != "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet" {
+		t.Errorf("Content-Type = %q, want the XLSX type", got)
+	}
+
+	f, err := excelize.OpenReader(rec.Body)
+	if err != nil {
+		t.Fatalf("opening the workbook: %v", err)
+	}
+	defer f.Close()
+	if sheets := f.GetSheetList(); len(sheets) != 1 || sheets[0] != xlsxSheet {
+		t.Fatalf("sheets = %q, want a single %s sheet", sheets, xlsxSheet)
+	}
+	panes, err := f.GetPanes(xlsxSheet)
+	if err != nil || !panes.Freeze || panes.YSplit != 1 {
+		t.Errorf("panes = %+v (%v), want the header row frozen", panes, err)
+	}
+
+	rows, err := f.GetRows(xlsxSheet)
+	if err != nil {
+		t.Fatalf("reading the rows: %v", err)
+	}
+	if len(rows) != len(served.Data)+1 {
+		t.Fatalf("workbook has %d rows, want the header and %d fights", len(rows), len(served.Data))
+	}
+	for i, name := range exportCSVHeader {
+		if rows[0][i] != name {
+			t.Errorf("header %d = %q, want %q", i, rows[0][i], name)
+		}
+	}
+	for i, fight := range served.Data {
+		row := i + 2
+		want := map[string]string{"A": fight.ID, "B": fight.Date, "C": fight.Fighter1, "D": fight.Fighter2, "E": fight.Result, "F": fight.Location}
+		for column, value := range want {
+			if got, _ := f.GetCellValue(xlsxSheet, column+strconv.Itoa(row)); got != value {
+				t.Errorf("cell %s%d = %q, want %q", column, row, got, value)
+			}
+		}
+
+		// Dates and parse times are Excel serial numbers, not text
+		date, _ := f.GetCellValue(xlsxSheet, "B"+strconv.Itoa(row), excelize.Options{RawCellValue: true})
+		serial, err := strconv.ParseFloat(date, 64)
+		if err != nil {
+			t.Errorf("date cell B%d = %q, want an Excel date", row, date)
+		} else if got, _ := excelize.ExcelDateToTime(serial, false); got.Format("2006-01-02") != fight.Date {
+			t.Errorf("date cell B%d = %v, want %s", row, got, fight.Date)
+		}
+		parsedAt, _ := f.GetCellValue(xlsxSheet, "G"+strconv.Itoa(row), excelize.Options{RawCellValue: true})
+		if _, err := strconv.ParseFloat(parsedAt, 64); err != nil {
+			t.Errorf("parsed_at cell G%d = %q, want an Excel date-time", row, parsedAt)
+		}
+	}
+}
+
+func TestXLSXRowKeepsAnUntypedDateAsText(t *testing.T) {
+	builtAt := time.Date(2024, time.June, 10, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		date  string
+		typed bool
+	}{
+		{"2024-06-01", true},
+		{"TBA", false},
+		{"", false},
+		{"2024-6-1", false},
+	}
+	for _, tt := range tests {
+		cell := xlsxRow(models.Fight{Date: tt.date}, builtAt, 1, 2)[1]
+		if typed, ok := cell.(excelize.Cell); ok != tt.typed {
+			t.Errorf("date %q = %#v, want typed %v", tt.date, cell, tt.typed)
+		} else if ok && !typed.Value.(time.Time).Equal(time.Date(2024, time.June, 1, 0, 0, 0, 0, time.UTC)) {
+			t.Errorf("date %q = %v, want June 1 2024", tt.date, typed.Value)
+		}
+	}
+}
+
+func TestExportRowLimit(t *testing.T) {
+	tests := []struct {
+		name    string
+		format  string
+		maxRows int
+		status  int
+	}{
+		{"xlsx within the limit", "xlsx", 3, http.StatusOK},
+		{"xlsx above the limit", "xlsx", 2, http.StatusRequestEntityTooLarge},
+		{"xlsx with the default limit", "xlsx", 0, http.StatusOK},
+		{"csv is not limited", "csv", 2, http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := newTestRouter(t, readTestdata(t, "export.html"), Dependencies{ExportMaxRows: tt.maxRows})
+			rec := serve(router, http.MethodGet, "/api/fights/export?format="+tt.format, "")
+			if rec.Code != tt.status {
+				t.Fatalf("export = %d %s, want %d", rec.Code, rec.Body, tt.status)
+			}
+			if tt.status == http.StatusRequestEntityTooLarge {
+				if code := errorCode(t, rec); code != "export_too_large" {
+					t.Errorf("error = %q, want export_too_large", code)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,309 @@
+// Package apitypes holds the JSON structures exchanged by the REST API
+// The server encodes them and the Go client decodes them, so both sides
+// share a single definition.
+package apitypes
+
+import (
+	"time"
+
+	"easypars/models"
+	"easypars/pkg/clock"
+	"easypars/pkg/locations"
+	"easypars/pkg/pipeline"
+	"easypars/pkg/safeexec"
+	"easypars/pkg/snapshot"
+)
+
+// ErrorResponse is the body of every error response
+type ErrorResponse struct {
+	Error   string `json:"error"`
+	Message string `json:"message"`
+	// Origin tells whose fault a failed load is: "source", "network",
+	// "internal" or "config"; empty for errors of the request
+	Origin string `json:"origin,omitempty"`
+}
+
+// HealthResponse is the body of GET /api/health
+type HealthResponse struct {
+	Status  string `json:"status"`
+	Message string `json:"message"`
+	Version string `json:"version"`
+	// Reasons lists the codes of what degrades the service:
+	// "source_paused", "degraded_config", "storage_recovery" and "clock_skew"
+	Reasons []string `json:"reasons,omitempty"`
+	// EffectiveBaseURL is the source address after permanent redirects
+	EffectiveBaseURL string `json:"effective_base_url,omitempty"`
+	// SourcePausedUntil is set while requests to the source are paused
+	// after a rate limit response
+	SourcePausedUntil *time.Time `json:"source_paused_until,omitempty"`
+	// DegradedConfig lists configuration elements whose last execution failed
+	DegradedConfig []safeexec.SourceState `json:"degraded_config,omitempty"`
+	// StorageRecovery is set while the stored fights recovered from a
+	// damaged storage file have not been completed by a parse
+	StorageRecovery *StorageRecovery `json:"storage_recovery,omitempty"`
+	// ClockSkew is the difference between the server clock and the source
+	// time at the last check; a critical skew degrades the service
+	ClockSkew *clock.SkewStatus `json:"clock_skew,omitempty"`
+	// WorkerPools shows the load of the worker pools, only with ?verbose=1
+	WorkerPools []pipeline.PoolStats `json:"worker_pools,omitempty"`
+	// Concurrency shows the load of the concurrency limits, only with
+	// ?verbose=1 and when the limit is enabled
+	Concurrency []ConcurrencyStats `json:"concurrency,omitempty"`
+	// Errors counts the error responses by origin, only with ?verbose=1
+	Errors []ErrorCount `json:"errors,omitempty"`
+	// UnclassifiedErrors counts errors that reached the API without an
+	// origin and were answered as internal, only with ?verbose=1
+	UnclassifiedErrors int64 `json:"unclassified_errors,omitempty"`
+	// ExpensiveRequests counts the requests estimated as expensive, only
+	// with ?verbose=1
+	ExpensiveRequests *ExpensiveRequestCounts `json:"expensive_requests,omitempty"`
+}
+
+// LivenessResponse is the body of GET /api/health/live
+type LivenessResponse struct {
+	Status        string `json:"status"`
+	UptimeSeconds int64  `json:"uptime_seconds"`
+}
+
+// ReadinessResponse is the body of GET /api/health/ready
+// Status is "ready" when every check passed, otherwise "not_ready".
+type ReadinessResponse struct {
+	Status    string           `json:"status"`
+	CheckedAt time.Time        `json:"checked_at"`
+	Checks    []ReadinessCheck `json:"checks"`
+}
+
+// ReadinessCheck is the outcome of a single readiness check
+// Status is "ok" or "failed"; Detail describes what was checked, Error
+// why it failed.
+type ReadinessCheck struct {
+	Name       string `json:"name"`
+	Status     string `json:"status"`
+	DurationMs int64  `json:"duration_ms"`
+	Detail     string `json:"detail,omitempty"`
+	Error      string `json:"error,omitempty"`
+}
+
+// CostEstimate is the expected cost of a request, computed before it runs
+// Class is "cheap", "moderate" or "expensive".
+type CostEstimate struct {
+	// OutboundRequests is the number of pages fetched from the sources
+	OutboundRequests int `json:"outbound_requests"`
+	// EstimatedSeconds is the expected duration of the request
+	EstimatedSeconds float64 `json:"estimated_seconds"`
+	Class            string  `json:"class"`
+}
+
+// ExpensiveRequestCounts counts the expensive requests by outcome
+type ExpensiveRequestCounts struct {
+	// Confirmed counts the requests run with X-Confirm-Expensive: true
+	Confirmed int64 `json:"confirmed"`
+	// Rejected counts the requests refused for the missing confirmation
+	Rejected int64 `json:"rejected"`
+}
+
+// ErrorCount is the number of error responses of one origin, code and status
+// Origin is "source", "network", "internal" or "config".
+type ErrorCount struct {
+	Origin string `json:"origin"`
+	Code   string `json:"code"`
+	Status int    `json:"status"`
+	Count  int64  `json:"count"`
+}
+
+// ConcurrencyStats is a point-in-time view of a concurrency limit
+type ConcurrencyStats struct {
+	Name  string `json:"name"`
+	Limit int    `json:"limit"`
+	// InFlight is the number of requests being handled, Queued the number
+	// waiting for a slot
+	InFlight int   `json:"in_flight"`
+	Queued   int64 `json:"queued"`
+	// Rejected counts requests that timed out in the queue, Cancelled those
+	// whose client went away while queued
+	Rejected  int64 `json:"rejected"`
+	Cancelled int64 `json:"cancelled"`
+}
+
+// StorageRecovery describes a damaged storage file loaded partially
+// It mirrors storage.Recovery field by field
+type StorageRecovery struct {
+	Path          string    `json:"path"`
+	LoadedRecords int       `json:"loaded_records"`
+	LostRecords   int       `json:"lost_records"`
+	LostBytes     int64     `json:"lost_bytes"`
+	Error         string    `json:"error"`
+	LoadedAt      time.Time `json:"loaded_at"`
+}
+
+// Warning is a data quality note attached to list responses
+type Warning struct {
+	Code string `json:"code"`
+	Role string `json:"role,omitempty"`
+}
+
+// Pagination describes the page returned by a paginated endpoint
+type Pagination struct {
+	// Unit tells what is counted: "fights", "groups" or "events"
+	Unit       string `json:"unit"`
+	Page       int    `json:"page"`
+	Limit      int    `json:"limit"`
+	Total      int    `json:"total"`
+	TotalPages int    `json:"total_pages"`
+}
+
+// FightsResponse is the body of GET /api/fights
+type FightsResponse struct {
+	Message    string         `json:"message"`
+	Data       []models.Fight `json:"data"`
+	Count      int            `json:"count"`
+	Warnings   []Warning      `json:"warnings,omitempty"`
+	Pagination *Pagination    `json:"pagination,omitempty"`
+
+	// LastUpdated is when the served data was built
+	LastUpdated time.Time `json:"last_updated"`
+	// Cached is set when the fights come from a cached parse of the source,
+	// CacheAgeSeconds is then the age of that parse
+	Cached          bool  `json:"cached"`
+	CacheAgeSeconds int64 `json:"cache_age_seconds"`
+
+	// AppliedFilters is set when filters were in effect
+	AppliedFilters *AppliedFilters `json:"applied_filters,omitempty"`
+}
+
+// AppliedFilters separates the filters of a list response by their origin
+type AppliedFilters struct {
+	// Default holds the filters configured by the operator
+	// (api.default_filters), applied to every list request
+	Default map[string]string `json:"default"`
+	// Requested holds the filter parameters of the request, applied on top
+	// of the defaults
+	Requested map[string]string `json:"requested"`
+	// DefaultsIgnored is set when the defaults were turned off with
+	// ?ignore_defaults=1
+	DefaultsIgnored bool `json:"defaults_ignored,omitempty"`
+	// UndatedExcluded counts the fights a date range (?from, ?to) excluded
+	// because their date does not parse
+	UndatedExcluded int `json:"undated_excluded,omitempty"`
+}
+
+// FightResponse is the body of GET /api/fights/:id and
+// GET /api/fights/by-slug/:slug
+type FightResponse struct {
+	Message string       `json:"message"`
+	Data    models.Fight `json:"data"`
+	// Source is the page the fight was parsed from
+	Source string `json:"source,omitempty"`
+	// ParsedAt is when the served data was built
+	ParsedAt time.Time `json:"parsed_at"`
+}
+
+// LoginResponse is the body of POST /api/auth/login
+type LoginResponse struct {
+	Message   string    `json:"message"`
+	Token     string    `json:"token"`
+	TokenType string    `json:"token_type"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// FightHistoryResponse is the body of GET /api/fights/:id/history
+type FightHistoryResponse struct {
+	Message string `json:"message"`
+	// Key is the natural key the history is recorded under
+	Key   string               `json:"key"`
+	Data  []models.FightChange `json:"data"`
+	Count int                  `json:"count"`
+}
+
+// FightersResponse is the body of GET /api/fighters
+type FightersResponse struct {
+	Message string           `json:"message"`
+	Data    []models.Fighter `json:"data"`
+	Count   int              `json:"count"`
+}
+
+// LocationsResponse is the body of GET /api/locations
+type LocationsResponse struct {
+	Message string               `json:"message"`
+	Data    []locations.Location `json:"data"`
+	Count   int                  `json:"count"`
+}
+
+// DataQualityResponse is the body of GET /api/admin/data-quality
+type DataQualityResponse struct {
+	Message      string  `json:"message"`
+	QualityScore float64 `json:"quality_score"`
+	// Warnings are the problems noticed while building the snapshot
+	Warnings []string `json:"warnings"`
+	// LocationCandidates are similar locations missing from the alias
+	// dictionary, to be merged by adding aliases
+	LocationCandidates []locations.Candidate `json:"location_candidates"`
+	// MissingFights are fights that disappeared from their page and are
+	// kept until an archive confirms them or the grace period ends
+	MissingFights []MissingFight `json:"missing_fights"`
+}
+
+// MissingFight is a fight that disappeared from its page
+type MissingFight struct {
+	Key          string    `json:"key"`
+	Date         string    `json:"date"`
+	Fighter1     string    `json:"fighter1"`
+	Fighter2     string    `json:"fighter2"`
+	SourceURL    string    `json:"source_url"`
+	MissingSince time.Time `json:"missing_since"`
+	// DeleteAfter is when the fight is deleted unless it is found again
+	DeleteAfter time.Time `json:"delete_after"`
+}
+
+// StatsResponse is the body of GET /api/stats
+type StatsResponse struct {
+	Message       string `json:"message"`
+	FightCount    int    `json:"fight_count"`
+	UpcomingCount int    `json:"upcoming_count"`
+	FighterCount  int    `json:"fighter_count"`
+	// TopInterest lists the upcoming fights with the highest interest score
+	TopInterest []models.Fight `json:"top_interest"`
+	// WorkerPools shows the load of the worker pools
+	WorkerPools []pipeline.PoolStats `json:"worker_pools"`
+	// SnapshotAggregates shows which lazy aggregates of the active snapshot
+	// were built and how long it took
+	SnapshotAggregates []snapshot.AggregateStats `json:"snapshot_aggregates"`
+	// ContractViolations counts broken model invariants per "boundary/code"
+	ContractViolations map[string]int64 `json:"contract_violations"`
+	// AppliedFilters is set when filters were in effect; the fight counts
+	// and the top list only cover the selected fights
+	AppliedFilters *AppliedFilters `json:"applied_filters,omitempty"`
+}
+
+// EventsResponse is the body of GET /api/events
+type EventsResponse struct {
+	Message    string         `json:"message"`
+	Data       []models.Event `json:"data"`
+	Count      int            `json:"count"`
+	FightCount int            `json:"fight_count"`
+	Pagination Pagination     `json:"pagination"`
+
+	// AppliedFilters is set when filters were in effect
+	AppliedFilters *AppliedFilters `json:"applied_filters,omitempty"`
+}
+
+// FightGroup is a set of fights sharing a grouping key
+type FightGroup struct {
+	Key    string         `json:"key"`
+	Label  string         `json:"label"`
+	Count  int            `json:"count"`
+	Fights []models.Fight `json:"fights"`
+}
+
+// GroupedFightsResponse is the body of GET /api/fights?group_by=...
+type GroupedFightsResponse struct {
+	Message    string       `json:"message"`
+	GroupBy    string       `json:"group_by"`
+	Data       []FightGroup `json:"data"`
+	Count      int          `json:"count"`
+	FightCount int          `json:"fight_count"`
+	Pagination Pagination   `json:"pagination"`
+
+	// AppliedFilters is set when filters were in effect
+	AppliedFilters *AppliedFilters `json:"applied_filters,omitempty"`
+}
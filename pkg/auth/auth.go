@@ -0,0 +1,127 @@
+// Package auth issues and checks the JSON Web Tokens of the admin endpoints
+// An administrator logs in with the username and bcrypt password hash of
+// the configuration and gets a token signed with HS256; requests to the
+// protected endpoints send it as "Authorization: Bearer <token>".
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// RoleAdmin is the role of the tokens issued to the configured administrator
+const RoleAdmin = "admin"
+
+var (
+	// ErrInvalidCredentials is returned by Login for a wrong username or password
+	ErrInvalidCredentials = errors.New("invalid username or password")
+	// ErrTokenExpired is returned for a valid token past its expiry
+	ErrTokenExpired = errors.New("token expired")
+	// ErrTokenInvalid is returned for a malformed, forged or foreign token
+	ErrTokenInvalid = errors.New("invalid token")
+)
+
+// Config configures the token manager
+type Config struct {
+	// Secret signs the tokens (HS256)
+	Secret string
+	// Issuer is set in and required from every token
+	Issuer string
+	// Expire is the lifetime of the issued tokens
+	Expire time.Duration
+	// AdminUsername and AdminPasswordHash (bcrypt) are the credentials
+	// accepted by Login
+	AdminUsername     string
+	AdminPasswordHash string
+}
+
+// Claims are the claims of an issued token
+type Claims struct {
+	Role string `json:"role"`
+	jwt.RegisteredClaims
+}
+
+// Manager issues tokens on login and validates them
+type Manager struct {
+	cfg Config
+	// now is replaced in tests
+	now func() time.Time
+}
+
+// NewManager creates a token manager
+func NewManager(cfg Config) *Manager {
+	return &Manager{cfg: cfg, now: time.Now}
+}
+
+// Login checks the credentials and issues a token for the administrator
+// The password is compared even for an unknown username, so the response
+// time does not tell whether the username exists.
+func (m *Manager) Login(username, password string) (string, time.Time, error) {
+	passwordErr := bcrypt.CompareHashAndPassword([]byte(m.cfg.AdminPasswordHash), []byte(password))
+	if username != m.cfg.AdminUsername || passwordErr != nil {
+		return "", time.Time{}, ErrInvalidCredentials
+	}
+
+	return m.Issue(username, RoleAdmin)
+}
+
+// Issue signs a token for the subject with the configured lifetime
+func (m *Manager) Issue(subject, role string) (string, time.Time, error) {
+	now := m.now()
+	expiresAt := now.Add(m.cfg.Expire)
+	claims := Claims{
+		Role: role,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    m.cfg.Issuer,
+			Subject:   subject,
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+		},
+	}
+
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(m.cfg.Secret))
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("error signing token: %w", err)
+	}
+
+	return token, expiresAt, nil
+}
+
+// Validate checks the signature, the algorithm, the issuer and the expiry
+// of a token and returns its claims
+// Tokens signed with another algorithm, including "none", are rejected.
+// Returns ErrTokenExpired for an expired token and ErrTokenInvalid for any
+// other problem.
+func (m *Manager) Validate(token string) (*Claims, error) {
+	var claims Claims
+	_, err := jwt.ParseWithClaims(token, &claims, func(*jwt.Token) (any, error) {
+		return []byte(m.cfg.Secret), nil
+	},
+		jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}),
+		jwt.WithIssuer(m.cfg.Issuer),
+		jwt.WithExpirationRequired(),
+		jwt.WithIssuedAt(),
+		jwt.WithTimeFunc(m.now),
+	)
+	if errors.Is(err, jwt.ErrTokenExpired) {
+		return nil, ErrTokenExpired
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrTokenInvalid, err)
+	}
+
+	return &claims, nil
+}
+
+// HashPassword returns the bcrypt hash of a password for the configuration
+func HashPassword(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return "", fmt.Errorf("error hashing password: %w", err)
+	}
+	return string(hash), nil
+}
@@ -0,0 +1,153 @@
+package auth
+
+import (
+	"encoding/base64"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const testSecret = "0123456789abcdef0123456789abcdef"
+
+// testStart is the clock of the manager when a test begins
+var testStart = time.Date(2024, time.June, 10, 12, 0, 0, 0, time.UTC)
+
+// newTestManager returns a manager with a settable clock and the admin
+// password "secret password"
+func newTestManager(t *testing.T) (*Manager, *time.Time) {
+	t.Helper()
+
+	hash, err := bcrypt.GenerateFromPassword([]byte("secret password"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := NewManager(Config{
+		Secret:            testSecret,
+		Issuer:            "easypars",
+		Expire:            time.Hour,
+		AdminUsername:     "admin",
+		AdminPasswordHash: string(hash),
+	})
+	now := testStart
+	m.now = func() time.Time { return now }
+
+	return m, &now
+}
+
+// sign signs claims with the method and key, bypassing the manager
+func sign(t *testing.T, method jwt.SigningMethod, key any, claims Claims) string {
+	t.Helper()
+
+	token, err := jwt.NewWithClaims(method, claims).SignedString(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return token
+}
+
+// adminClaims are the claims the manager issues at testStart
+func adminClaims() Claims {
+	return Claims{
+		Role: RoleAdmin,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    "easypars",
+			Subject:   "admin",
+			IssuedAt:  jwt.NewNumericDate(testStart),
+			ExpiresAt: jwt.NewNumericDate(testStart.Add(time.Hour)),
+		},
+	}
+}
+
+func TestLogin(t *testing.T) {
+	m, _ := newTestManager(t)
+
+	token, expiresAt, err := m.Login("admin", "secret password")
+	if err != nil {
+		t.Fatalf("Login: %v", err)
+	}
+	if !expiresAt.Equal(testStart.Add(time.Hour)) {
+		t.Errorf("expiresAt = %v, want an hour after the login", expiresAt)
+	}
+	claims, err := m.Validate(token)
+	if err != nil {
+		t.Fatalf("Validate of the issued token: %v", err)
+	}
+	if claims.Subject != "admin" || claims.Role != RoleAdmin {
+		t.Errorf("claims = %+v, want the admin", claims)
+	}
+
+	for _, creds := range [][2]string{{"admin", "wrong"}, {"root", "secret password"}, {"", ""}} {
+		if _, _, err := m.Login(creds[0], creds[1]); !errors.Is(err, ErrInvalidCredentials) {
+			t.Errorf("Login(%q, %q): err = %v, want ErrInvalidCredentials", creds[0], creds[1], err)
+		}
+	}
+}
+
+func TestValidateRejectsForgedTokens(t *testing.T) {
+	m, _ := newTestManager(t)
+	valid, _, err := m.Issue("admin", RoleAdmin)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A token with the role of its payload raised, keeping the signature
+	parts := strings.Split(valid, ".")
+	viewer := adminClaims()
+	viewer.Role = "viewer"
+	signedViewer := strings.Split(sign(t, jwt.SigningMethodHS256, []byte(testSecret), viewer), ".")
+	tampered := signedViewer[0] + "." + base64.RawURLEncoding.EncodeToString([]byte(`{"role":"superadmin","iss":"easypars","sub":"admin"}`)) + "." + parts[2]
+
+	foreign := adminClaims()
+	foreign.Issuer = "someone-else"
+	noExpiry := adminClaims()
+	noExpiry.ExpiresAt = nil
+	future := adminClaims()
+	future.IssuedAt = jwt.NewNumericDate(testStart.Add(time.Hour))
+	future.ExpiresAt = jwt.NewNumericDate(testStart.Add(2 * time.Hour))
+
+	tests := []struct {
+		name  string
+		token string
+	}{
+		{"other secret", sign(t, jwt.SigningMethodHS256, []byte("another secret of thirty-two bytes"), adminClaims())},
+		{"alg none", sign(t, jwt.SigningMethodNone, jwt.UnsafeAllowNoneSignatureType, adminClaims())},
+		{"other algorithm", sign(t, jwt.SigningMethodHS512, []byte(testSecret), adminClaims())},
+		{"tampered payload", tampered},
+		{"foreign issuer", sign(t, jwt.SigningMethodHS256, []byte(testSecret), foreign)},
+		{"no expiry", sign(t, jwt.SigningMethodHS256, []byte(testSecret), noExpiry)},
+		{"issued in the future", sign(t, jwt.SigningMethodHS256, []byte(testSecret), future)},
+		{"garbage", "not.a.token"},
+		{"empty", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims, err := m.Validate(tt.token)
+			if !errors.Is(err, ErrTokenInvalid) {
+				t.Errorf("Validate = %+v, %v, want ErrTokenInvalid", claims, err)
+			}
+		})
+	}
+}
+
+func TestValidateExpiry(t *testing.T) {
+	m, now := newTestManager(t)
+	token, _, err := m.Issue("admin", RoleAdmin)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	*now = testStart.Add(time.Hour - time.Second)
+	if _, err := m.Validate(token); err != nil {
+		t.Errorf("Validate just before the expiry: %v", err)
+	}
+
+	*now = testStart.Add(time.Hour + time.Second)
+	if _, err := m.Validate(token); !errors.Is(err, ErrTokenExpired) {
+		t.Errorf("Validate after the expiry: err = %v, want ErrTokenExpired", err)
+	}
+}
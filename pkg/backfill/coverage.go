@@ -0,0 +1,99 @@
+package backfill
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"easypars/pkg/storage"
+)
+
+// Coverage statuses of a month
+const (
+	CoverageOK         = "ok"
+	CoverageMissing    = "missing"
+	CoverageSuspicious = "suspicious"
+)
+
+// suspiciousShare is the share of the median monthly fight count below which
+// a month is considered incomplete
+const suspiciousShare = 0.25
+
+// MonthCoverage describes how well a month is covered by stored fights
+type MonthCoverage struct {
+	// Month is formatted as YYYY-MM
+	Month      string `json:"month"`
+	FightCount int    `json:"fight_count"`
+	Status     string `json:"status"`
+}
+
+// Coverage reports stored fights per month for the monthsBack months before now
+// The current month is not included, it is covered by the live results page.
+// Months without fights are missing; months with far fewer fights than the
+// median month are suspicious.
+func Coverage(ctx context.Context, repo storage.FightRepository, now time.Time, monthsBack int) ([]MonthCoverage, error) {
+	current := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
+	first := current.AddDate(0, -monthsBack, 0)
+
+	fights, err := repo.List(ctx, storage.FightFilter{
+		From: first.Format("2006-01-02"),
+		To:   current.AddDate(0, 0, -1).Format("2006-01-02"),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error loading stored fights: %w", err)
+	}
+
+	counts := make(map[string]int)
+	for _, fight := range fights {
+		if len(fight.Date) >= 7 {
+			counts[fight.Date[:7]]++
+		}
+	}
+
+	months := make([]MonthCoverage, 0, monthsBack)
+	for m := first; m.Before(current); m = m.AddDate(0, 1, 0) {
+		key := m.Format("2006-01")
+		months = append(months, MonthCoverage{Month: key, FightCount: counts[key]})
+	}
+
+	threshold := float64(medianCount(months)) * suspiciousShare
+	for i := range months {
+		switch {
+		case months[i].FightCount == 0:
+			months[i].Status = CoverageMissing
+		case float64(months[i].FightCount) < threshold:
+			months[i].Status = CoverageSuspicious
+		default:
+			months[i].Status = CoverageOK
+		}
+	}
+
+	return months, nil
+}
+
+// medianCount returns the median fight count of the months that have fights
+func medianCount(months []MonthCoverage) int {
+	var counts []int
+	for _, month := range months {
+		if month.FightCount > 0 {
+			counts = append(counts, month.FightCount)
+		}
+	}
+	if len(counts) == 0 {
+		return 0
+	}
+
+	sort.Ints(counts)
+	return counts[len(counts)/2]
+}
+
+// inCoverage reports whether the month (YYYY-MM) is one of the covered months
+func inCoverage(months []MonthCoverage, month string) bool {
+	for _, covered := range months {
+		if covered.Month == month {
+			return true
+		}
+	}
+	return false
+}
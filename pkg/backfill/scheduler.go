@@ -0,0 +1,435 @@
+package backfill
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"easypars/pkg/clock"
+	"easypars/pkg/history"
+	"easypars/pkg/parser"
+	"easypars/pkg/storage"
+)
+
+// TriggerBackfill marks backfill runs in the parse history
+const TriggerBackfill = "backfill"
+
+// Scheduler states reported by Status
+const (
+	StateStopped = "stopped"
+	StateWaiting = "waiting_window"
+	StateRunning = "running"
+	StatePaused  = "paused"
+	StateIdle    = "idle"
+)
+
+// checkInterval is how often the scheduler wakes up to check the window and pace
+const checkInterval = time.Minute
+
+// maxRecent is the number of processed months kept for the status report
+const maxRecent = 50
+
+var (
+	// ErrRunning is returned when starting a scheduler that is already running
+	ErrRunning = errors.New("backfill is already running")
+	// ErrNotRunning is returned when stopping a scheduler that is not running
+	ErrNotRunning = errors.New("backfill is not running")
+)
+
+// Config holds the backfill schedule
+type Config struct {
+	// MonthsBack is how many months before the current one are checked
+	MonthsBack int
+	// Pace is the minimum time between two month requests
+	Pace time.Duration
+	// Window is the daily time range in which backfill runs
+	Window Window
+	// Location is the time zone of the window, UTC is used when nil
+	Location *time.Location
+}
+
+// MonthResult describes a processed month
+type MonthResult struct {
+	Month      string `json:"month"`
+	RunID      string `json:"run_id,omitempty"`
+	FightCount int    `json:"fight_count"`
+	Inserted   int    `json:"inserted"`
+	Updated    int    `json:"updated"`
+	Error      string `json:"error,omitempty"`
+	// RateLimited marks a month interrupted by the source pause, it is retried
+	RateLimited bool      `json:"rate_limited,omitempty"`
+	FinishedAt  time.Time `json:"finished_at"`
+}
+
+// Status is a snapshot of the scheduler progress
+type Status struct {
+	State         string        `json:"state"`
+	Window        string        `json:"window"`
+	Current       string        `json:"current,omitempty"`
+	Queue         []string      `json:"queue"`
+	Completed     int           `json:"completed"`
+	Failed        int           `json:"failed"`
+	Recent        []MonthResult `json:"recent"`
+	NextAttemptAt *time.Time    `json:"next_attempt_at,omitempty"`
+	PausedUntil   *time.Time    `json:"paused_until,omitempty"`
+	LastError     string        `json:"last_error,omitempty"`
+}
+
+// Scheduler fills gaps in stored data by parsing archive months one by one
+// It only works inside the daily window and requests at most one month per
+// Pace. A failed month pauses the scheduler until the next window, so an
+// unavailable or limiting source is not hammered.
+// Future steps: Respect the daily request quota once it exists
+type Scheduler struct {
+	// Clock provides the current time, the system clock is used when nil
+	Clock clock.Clock
+
+	cfg     Config
+	parser  *parser.Parser
+	repo    storage.FightRepository
+	history *history.History
+
+	mu      sync.Mutex
+	running bool
+	cancel  context.CancelFunc
+	state   string
+	current string
+	queue   []string
+	// attempted holds the months tried in the current window
+	attempted   map[string]bool
+	windowStart time.Time
+	lastAttempt time.Time
+	pausedUntil time.Time
+	recent      []MonthResult
+	completed   int
+	failed      int
+	lastError   string
+
+	// lastAttemptMono is the monotonic time of the last attempt, used for
+	// the pace while the clock is skewed
+	lastAttemptMono time.Time
+}
+
+// New creates a stopped scheduler
+// The history is optional; when set, every month is recorded as a parse run
+func New(cfg Config, p *parser.Parser, repo storage.FightRepository, hist *history.History) *Scheduler {
+	if cfg.Location == nil {
+		cfg.Location = time.UTC
+	}
+
+	return &Scheduler{
+		cfg:     cfg,
+		parser:  p,
+		repo:    repo,
+		history: hist,
+		state:   StateStopped,
+	}
+}
+
+// Start launches the background loop
+func (s *Scheduler) Start() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.running {
+		return ErrRunning
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	s.running = true
+	s.cancel = cancel
+	s.state = StateWaiting
+	s.windowStart = time.Time{}
+	s.pausedUntil = time.Time{}
+
+	go s.loop(ctx)
+
+	slog.Info("Backfill started", "window", s.cfg.Window.String(), "pace", s.cfg.Pace.String(), "months_back", s.cfg.MonthsBack)
+	return nil
+}
+
+// Stop stops the background loop
+// A month being parsed is finished in the background, but no new one starts
+func (s *Scheduler) Stop() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.running {
+		return ErrNotRunning
+	}
+
+	s.cancel()
+	s.running = false
+	s.state = StateStopped
+	s.current = ""
+	s.queue = nil
+
+	slog.Info("Backfill stopped")
+	return nil
+}
+
+// Status returns the current progress of the scheduler
+func (s *Scheduler) Status() Status {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	status := Status{
+		State:     s.state,
+		Window:    s.cfg.Window.String(),
+		Current:   s.current,
+		Queue:     append([]string{}, s.queue...),
+		Completed: s.completed,
+		Failed:    s.failed,
+		Recent:    append([]MonthResult{}, s.recent...),
+		LastError: s.lastError,
+	}
+
+	now := s.clock().Now().In(s.cfg.Location)
+	if now.Before(s.pausedUntil) {
+		pausedUntil := s.pausedUntil
+		status.PausedUntil = &pausedUntil
+	}
+	if sourcePause := s.parser.SourcePausedUntil(); !sourcePause.IsZero() &&
+		(status.PausedUntil == nil || sourcePause.After(*status.PausedUntil)) {
+		status.PausedUntil = &sourcePause
+	}
+	if s.running && !s.lastAttempt.IsZero() && s.cfg.Pace > 0 {
+		next := s.lastAttempt.Add(s.cfg.Pace)
+		status.NextAttemptAt = &next
+	}
+
+	return status
+}
+
+// loop wakes up periodically and processes at most one month per step
+func (s *Scheduler) loop(ctx context.Context) {
+	ticker := time.NewTicker(checkInterval)
+	defer ticker.Stop()
+
+	for {
+		s.step(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// step checks the window, pause and pace, then backfills the next month
+func (s *Scheduler) step(ctx context.Context) {
+	now := s.clock().Now().In(s.cfg.Location)
+
+	s.mu.Lock()
+	if !s.running {
+		s.mu.Unlock()
+		return
+	}
+
+	// Step 1: Only work inside the window
+	if !s.cfg.Window.Contains(now) {
+		s.state = StateWaiting
+		s.mu.Unlock()
+		return
+	}
+
+	// Step 2: A new window gives every month another chance
+	if windowStart := s.cfg.Window.StartOf(now); !windowStart.Equal(s.windowStart) {
+		s.windowStart = windowStart
+		s.attempted = make(map[string]bool)
+		s.queue = nil
+	}
+
+	// Step 3: Stay paused after a failure until the next window, and while
+	// the source pauses all requests after a rate limit response
+	if now.Before(s.pausedUntil) || !s.parser.SourcePausedUntil().IsZero() {
+		s.state = StatePaused
+		s.mu.Unlock()
+		return
+	}
+
+	// Step 4: Keep the pace between two requests to the source; a skewed
+	// clock may jump, so the pace is then measured with the monotonic clock
+	if !s.lastAttempt.IsZero() {
+		elapsed := now.Sub(s.lastAttempt)
+		if s.parser.ClockSkewCritical() {
+			elapsed = time.Since(s.lastAttemptMono)
+		}
+		if elapsed < s.cfg.Pace {
+			s.mu.Unlock()
+			return
+		}
+	}
+
+	needQueue := len(s.queue) == 0
+	s.mu.Unlock()
+
+	// Step 5: Build the queue from the coverage report
+	if needQueue {
+		queue, err := s.buildQueue(ctx, now)
+
+		s.mu.Lock()
+		if err != nil {
+			s.lastError = err.Error()
+			s.mu.Unlock()
+			slog.Error("Backfill failed to build the queue", "error", err)
+			return
+		}
+		s.queue = queue
+		s.mu.Unlock()
+	}
+
+	// Step 6: Take the next month
+	s.mu.Lock()
+	if !s.running {
+		s.mu.Unlock()
+		return
+	}
+	if len(s.queue) == 0 {
+		s.state = StateIdle
+		s.mu.Unlock()
+		return
+	}
+	month := s.queue[0]
+	s.queue = s.queue[1:]
+	s.attempted[month] = true
+	s.current = month
+	s.lastAttempt = now
+	s.lastAttemptMono = time.Now()
+	s.state = StateRunning
+	s.mu.Unlock()
+
+	result := s.backfillMonth(ctx, month)
+
+	// Step 7: Record the outcome
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.recent = append(s.recent, result)
+	if len(s.recent) > maxRecent {
+		s.recent = s.recent[len(s.recent)-maxRecent:]
+	}
+	if s.current == month {
+		s.current = ""
+	}
+
+	// A rate limited month goes back to the queue, the source pause holds
+	// the next attempt
+	if result.RateLimited {
+		delete(s.attempted, month)
+		s.queue = append([]string{month}, s.queue...)
+		if s.running {
+			s.state = StatePaused
+		}
+		slog.Warn("Backfill interrupted by the source rate limit", "month", month, "error", result.Error)
+		return
+	}
+
+	if result.Error != "" {
+		s.failed++
+		s.lastError = result.Error
+		s.pausedUntil = s.cfg.Window.NextStart(now)
+		if s.running {
+			s.state = StatePaused
+		}
+		slog.Error("Backfill failed, paused until the next window", "month", month, "paused_until", s.pausedUntil, "error", result.Error)
+		return
+	}
+
+	s.completed++
+	slog.Info("Backfill of a month finished", "month", month, "fight_count", result.FightCount,
+		"inserted", result.Inserted, "updated", result.Updated)
+}
+
+// buildQueue lists missing and suspicious months not yet attempted in this window
+// Recent months come first, they are the most useful to fill
+func (s *Scheduler) buildQueue(ctx context.Context, now time.Time) ([]string, error) {
+	months, err := Coverage(ctx, s.repo, now, s.cfg.MonthsBack)
+	if err != nil {
+		return nil, err
+	}
+
+	// Months of fights that disappeared from the results page come first:
+	// parsing the archive confirms the fights that moved there
+	missing, err := storage.ListMissing(ctx, s.repo)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var queue []string
+	queued := make(map[string]bool)
+	for _, fight := range missing {
+		if len(fight.Date) < 7 {
+			continue
+		}
+		month := fight.Date[:7]
+		if !queued[month] && !s.attempted[month] && inCoverage(months, month) {
+			queue = append(queue, month)
+			queued[month] = true
+		}
+	}
+	for i := len(months) - 1; i >= 0; i-- {
+		month := months[i]
+		if month.Status == CoverageOK || s.attempted[month.Month] || queued[month.Month] {
+			continue
+		}
+		queue = append(queue, month.Month)
+	}
+
+	return queue, nil
+}
+
+// backfillMonth parses a single month and stores its fights
+func (s *Scheduler) backfillMonth(ctx context.Context, month string) MonthResult {
+	result := MonthResult{Month: month}
+
+	start, err := time.Parse("2006-01", month)
+	if err != nil {
+		result.Error = fmt.Sprintf("invalid month %q", month)
+		result.FinishedAt = s.clock().Now()
+		return result
+	}
+
+	runCtx := ctx
+	if s.history != nil {
+		run := s.history.Start(TriggerBackfill)
+		result.RunID = run.ID
+		runCtx = history.WithRunID(ctx, run.ID)
+	}
+
+	parsed, err := s.parser.ParseMonth(runCtx, start.Year(), start.Month())
+	if err == nil {
+		result.FightCount = len(parsed.Fights)
+
+		var upsert storage.UpsertResult
+		upsert, err = s.repo.UpsertFights(ctx, parsed.Fights)
+		result.Inserted = upsert.Inserted
+		result.Updated = upsert.Updated
+	}
+
+	if s.history != nil {
+		s.history.Finish(result.RunID, history.ResultOf(parsed, err))
+	}
+	if err != nil {
+		result.Error = err.Error()
+		result.RateLimited = errors.Is(err, parser.ErrRateLimited) || errors.Is(err, parser.ErrSourcePaused)
+	}
+	result.FinishedAt = s.clock().Now()
+
+	return result
+}
+
+// clock returns the configured clock or the system clock
+func (s *Scheduler) clock() clock.Clock {
+	if s.Clock != nil {
+		return s.Clock
+	}
+	return clock.Real{}
+}
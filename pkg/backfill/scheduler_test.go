@@ -0,0 +1,319 @@
+package backfill
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"easypars/models"
+	"easypars/pkg/history"
+	"easypars/pkg/parser"
+	"easypars/pkg/storage"
+)
+
+// manualClock is a scheduler clock moved by the test
+type manualClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *manualClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *manualClock) set(t time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = t
+}
+
+// monthSource serves two fights for every archive month, or fails with
+// the status set in fail
+type monthSource struct {
+	*httptest.Server
+	fail     atomic.Int32
+	requests atomic.Int32
+}
+
+// newMonthSource starts an archive served at /{year}/{month}
+func newMonthSource(t *testing.T) *monthSource {
+	t.Helper()
+
+	src := &monthSource{}
+	src.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		src.requests.Add(1)
+		if status := src.fail.Load(); status != 0 {
+			w.WriteHeader(int(status))
+			return
+		}
+		month := strings.Trim(r.URL.Path, "/")
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		fmt.Fprintf(w, `<html><body><table>`+
+			`<tr><td class="date">5</td><td class="place">Arena</td><td class="boxer_1">Red %[1]s</td><td class="vs">UD</td><td class="boxer_2">Blue %[1]s</td></tr>`+
+			`<tr><td class="date">20</td><td class="place">Arena</td><td class="boxer_1">Green %[1]s</td><td class="vs">KO 3</td><td class="boxer_2">Gold %[1]s</td></tr>`+
+			`</table></body></html>`, month)
+	}))
+	t.Cleanup(src.Close)
+
+	return src
+}
+
+// newTestScheduler returns a running scheduler over an empty store with a
+// 02:00-06:00 UTC window, three months back and a 10 minute pace; the test
+// drives it by calling step
+func newTestScheduler(t *testing.T) (*Scheduler, *manualClock, *monthSource, *history.History) {
+	t.Helper()
+
+	// The parser shares the clock, so its source pause follows the test
+	clk := &manualClock{now: at(1, 0)}
+	src := newMonthSource(t)
+	p := parser.NewParser(src.URL + "/")
+	p.MonthURL = src.URL + "/{year}/{month}"
+	p.Clock = clk
+
+	repo, err := storage.OpenFile(filepath.Join(t.TempDir(), "fights.json"), false, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hist := history.New(10, 10)
+	window, _ := ParseWindow("02:00-06:00")
+
+	s := New(Config{MonthsBack: 3, Pace: 10 * time.Minute, Window: window}, p, repo, hist)
+	s.Clock = clk
+
+	// Started without the background loop, the steps are taken by the test
+	s.running = true
+	s.cancel = func() {}
+	s.state = StateWaiting
+
+	return s, clk, src, hist
+}
+
+// months returns the months of the results
+func months(results []MonthResult) []string {
+	var names []string
+	for _, result := range results {
+		names = append(names, result.Month)
+	}
+
+	return names
+}
+
+func TestSchedulerWindowAndPace(t *testing.T) {
+	s, clk, src, _ := newTestScheduler(t)
+	ctx := context.Background()
+
+	steps := []struct {
+		name     string
+		now      time.Time
+		state    string
+		recent   []string
+		requests int32
+	}{
+		{"before the window", at(1, 0), StateWaiting, nil, 0},
+		{"window opens", at(2, 0), StateRunning, []string{"2024-05"}, 1},
+		{"within the pace", at(2, 9), StateRunning, []string{"2024-05"}, 1},
+		{"pace elapsed", at(2, 10), StateRunning, []string{"2024-05", "2024-04"}, 2},
+		{"last month", at(2, 20), StateRunning, []string{"2024-05", "2024-04", "2024-03"}, 3},
+		{"queue empty", at(2, 30), StateIdle, []string{"2024-05", "2024-04", "2024-03"}, 3},
+		{"window closed", at(6, 0), StateWaiting, []string{"2024-05", "2024-04", "2024-03"}, 3},
+		// The stored months are covered now, the next window finds no gap
+		{"next window", at(2, 0).AddDate(0, 0, 1), StateIdle, []string{"2024-05", "2024-04", "2024-03"}, 3},
+	}
+	for _, step := range steps {
+		clk.set(step.now)
+		s.step(ctx)
+
+		status := s.Status()
+		if status.State != step.state {
+			t.Errorf("%s: state = %s, want %s", step.name, status.State, step.state)
+		}
+		if got := months(status.Recent); !reflect.DeepEqual(got, step.recent) {
+			t.Errorf("%s: processed months = %v, want %v", step.name, got, step.recent)
+		}
+		if got := src.requests.Load(); got != step.requests {
+			t.Errorf("%s: source requests = %d, want %d", step.name, got, step.requests)
+		}
+	}
+
+	status := s.Status()
+	if status.Completed != 3 || status.Failed != 0 {
+		t.Errorf("completed %d, failed %d, want 3 and 0", status.Completed, status.Failed)
+	}
+	for _, result := range status.Recent {
+		if result.FightCount != 2 || result.Inserted != 2 || result.Error != "" {
+			t.Errorf("month %s = %+v, want two inserted fights", result.Month, result)
+		}
+	}
+}
+
+func TestSchedulerPausesAfterAFailure(t *testing.T) {
+	s, clk, src, _ := newTestScheduler(t)
+	ctx := context.Background()
+
+	// The source fails inside the window: the scheduler pauses until the
+	// next window instead of trying the other months
+	src.fail.Store(http.StatusServiceUnavailable)
+	clk.set(at(3, 0))
+	s.step(ctx)
+
+	status := s.Status()
+	nextWindow := at(2, 0).AddDate(0, 0, 1)
+	if status.State != StatePaused || status.Failed != 1 || status.LastError == "" {
+		t.Errorf("status after a failure = %+v, want paused with the error", status)
+	}
+	if status.PausedUntil == nil || !status.PausedUntil.Equal(nextWindow) {
+		t.Errorf("paused until %v, want %s", status.PausedUntil, nextWindow)
+	}
+
+	src.fail.Store(0)
+	clk.set(at(4, 0))
+	s.step(ctx)
+	if got := src.requests.Load(); got != 1 {
+		t.Errorf("source requests while paused = %d, want 1", got)
+	}
+
+	// The next window resumes with the failed month
+	clk.set(nextWindow)
+	s.step(ctx)
+	status = s.Status()
+	if status.State != StateRunning || status.Completed != 1 {
+		t.Errorf("status in the next window = %+v, want running with one completed month", status)
+	}
+	if got := months(status.Recent); !reflect.DeepEqual(got, []string{"2024-05", "2024-05"}) {
+		t.Errorf("processed months = %v, want the failed month retried", got)
+	}
+}
+
+func TestSchedulerRequeuesRateLimitedMonth(t *testing.T) {
+	s, clk, src, _ := newTestScheduler(t)
+	src.fail.Store(http.StatusTooManyRequests)
+	clk.set(at(3, 0))
+	s.step(context.Background())
+
+	status := s.Status()
+	if status.State != StatePaused || status.Failed != 0 {
+		t.Errorf("status after a 429 = %+v, want paused without a failure", status)
+	}
+	if len(status.Queue) == 0 || status.Queue[0] != "2024-05" {
+		t.Errorf("queue = %v, want the rate limited month first", status.Queue)
+	}
+	if status.PausedUntil == nil {
+		t.Error("paused_until is missing while the source is paused")
+	}
+	if recent := status.Recent; len(recent) != 1 || !recent[0].RateLimited {
+		t.Errorf("recent = %+v, want the month marked as rate limited", recent)
+	}
+}
+
+func TestSchedulerWaitsForTheSourcePause(t *testing.T) {
+	s, clk, src, _ := newTestScheduler(t)
+	ctx := context.Background()
+
+	// Another subsystem is rate limited: the pause stops the backfill too
+	src.fail.Store(http.StatusTooManyRequests)
+	clk.set(at(3, 0))
+	if _, err := s.parser.ParseDetailed(parser.WithInteractive(ctx)); !errors.Is(err, parser.ErrRateLimited) {
+		t.Fatalf("ParseDetailed = %v, want ErrRateLimited", err)
+	}
+	src.fail.Store(0)
+
+	clk.set(at(3, 1))
+	s.step(ctx)
+	status := s.Status()
+	if status.State != StatePaused || src.requests.Load() != 1 {
+		t.Errorf("state = %s with %d requests, want paused without requests", status.State, src.requests.Load())
+	}
+	until := at(3, 0).Add(parser.DefaultRateLimitPause)
+	if status.PausedUntil == nil || !status.PausedUntil.Equal(until) {
+		t.Errorf("paused until %v, want the source pause until %s", status.PausedUntil, until)
+	}
+
+	// The backfill resumes once the pause is over
+	clk.set(until)
+	s.step(ctx)
+	status = s.Status()
+	if status.State != StateRunning || status.Completed != 1 || status.PausedUntil != nil {
+		t.Errorf("status after the pause = %+v, want running with one completed month", status)
+	}
+}
+
+func TestSchedulerStatus(t *testing.T) {
+	s, clk, _, hist := newTestScheduler(t)
+	clk.set(at(3, 0))
+	s.step(context.Background())
+
+	status := s.Status()
+	if status.Window != "02:00-06:00" {
+		t.Errorf("window = %s, want 02:00-06:00", status.Window)
+	}
+	if want := []string{"2024-04", "2024-03"}; !reflect.DeepEqual(status.Queue, want) {
+		t.Errorf("queue = %v, want %v", status.Queue, want)
+	}
+	if next := at(3, 10); status.NextAttemptAt == nil || !status.NextAttemptAt.Equal(next) {
+		t.Errorf("next attempt at %v, want %s", status.NextAttemptAt, next)
+	}
+
+	// Every month is a parse run triggered by the backfill
+	runs := hist.List()
+	if len(runs) != 1 || runs[0].Trigger != TriggerBackfill || runs[0].ID != status.Recent[0].RunID {
+		t.Errorf("parse runs = %+v, want one backfill run of 2024-05", runs)
+	}
+
+	if err := s.Stop(); err != nil {
+		t.Fatal(err)
+	}
+	status = s.Status()
+	if status.State != StateStopped || len(status.Queue) != 0 || status.NextAttemptAt != nil {
+		t.Errorf("status after Stop = %+v, want stopped with an empty queue", status)
+	}
+	if err := s.Stop(); err != ErrNotRunning {
+		t.Errorf("second Stop = %v, want ErrNotRunning", err)
+	}
+}
+
+func TestCoverage(t *testing.T) {
+	repo, err := storage.OpenFile(filepath.Join(t.TempDir(), "fights.json"), false, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var fights []models.Fight
+	counts := map[string]int{"2024-01": 8, "2024-02": 1, "2024-04": 8, "2024-05": 6, "2024-06": 9}
+	for month, n := range counts {
+		for i := 0; i < n; i++ {
+			fight := models.Fight{Date: fmt.Sprintf("%s-%02d", month, i+1), Fighter1: "Red", Fighter2: "Blue", Result: "UD"}
+			fight.AssignKey()
+			fights = append(fights, fight)
+		}
+	}
+	if _, err := repo.UpsertFights(context.Background(), fights); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := Coverage(context.Background(), repo, at(3, 0), 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The current month is left to the live results page
+	want := []MonthCoverage{
+		{"2024-01", 8, CoverageOK},
+		{"2024-02", 1, CoverageSuspicious},
+		{"2024-03", 0, CoverageMissing},
+		{"2024-04", 8, CoverageOK},
+		{"2024-05", 6, CoverageOK},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Coverage = %+v, want %+v", got, want)
+	}
+}
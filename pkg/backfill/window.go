@@ -0,0 +1,93 @@
+package backfill
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Window is a daily time range in which backfill may run
+// A window whose end is before its start wraps around midnight ("22:00-04:00")
+type Window struct {
+	// Start and End are offsets from midnight
+	Start time.Duration
+	End   time.Duration
+}
+
+// ParseWindow parses a window in the "HH:MM-HH:MM" format
+func ParseWindow(text string) (Window, error) {
+	startText, endText, ok := strings.Cut(text, "-")
+	if !ok {
+		return Window{}, fmt.Errorf("invalid window %q, expected HH:MM-HH:MM", text)
+	}
+
+	start, err := parseClockTime(startText)
+	if err != nil {
+		return Window{}, fmt.Errorf("invalid window %q: %w", text, err)
+	}
+	end, err := parseClockTime(endText)
+	if err != nil {
+		return Window{}, fmt.Errorf("invalid window %q: %w", text, err)
+	}
+	if start == end {
+		return Window{}, fmt.Errorf("invalid window %q: start equals end", text)
+	}
+
+	return Window{Start: start, End: end}, nil
+}
+
+// Contains reports whether t falls into the window
+func (w Window) Contains(t time.Time) bool {
+	offset := t.Sub(midnight(t))
+	if w.Start < w.End {
+		return offset >= w.Start && offset < w.End
+	}
+
+	return offset >= w.Start || offset < w.End
+}
+
+// NextStart returns the first window start strictly after t
+func (w Window) NextStart(t time.Time) time.Time {
+	start := midnight(t).Add(w.Start)
+	if !start.After(t) {
+		start = midnight(t).AddDate(0, 0, 1).Add(w.Start)
+	}
+
+	return start
+}
+
+// StartOf returns the start of the window occurrence containing t
+// For a window wrapping around midnight this may be on the previous day
+func (w Window) StartOf(t time.Time) time.Time {
+	start := midnight(t).Add(w.Start)
+	if start.After(t) {
+		start = midnight(t).AddDate(0, 0, -1).Add(w.Start)
+	}
+
+	return start
+}
+
+// String formats the window as HH:MM-HH:MM
+func (w Window) String() string {
+	return formatClockTime(w.Start) + "-" + formatClockTime(w.End)
+}
+
+// parseClockTime parses "HH:MM" into an offset from midnight
+func parseClockTime(text string) (time.Duration, error) {
+	t, err := time.Parse("15:04", strings.TrimSpace(text))
+	if err != nil {
+		return 0, fmt.Errorf("invalid time %q", text)
+	}
+
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
+
+// formatClockTime formats an offset from midnight as "HH:MM"
+func formatClockTime(offset time.Duration) string {
+	return fmt.Sprintf("%02d:%02d", int(offset.Hours()), int(offset.Minutes())%60)
+}
+
+// midnight returns the start of the day of t in its location
+func midnight(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
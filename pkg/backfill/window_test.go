@@ -0,0 +1,85 @@
+package backfill
+
+import (
+	"testing"
+	"time"
+)
+
+// at returns the time of the day of 2024-06-10 in UTC
+func at(hour, minute int) time.Time {
+	return time.Date(2024, time.June, 10, hour, minute, 0, 0, time.UTC)
+}
+
+func TestParseWindow(t *testing.T) {
+	tests := []struct {
+		text    string
+		want    string
+		wantErr bool
+	}{
+		{"02:00-06:00", "02:00-06:00", false},
+		{" 22:30 - 04:15 ", "22:30-04:15", false},
+		{"02:00", "", true},
+		{"02:00-25:00", "", true},
+		{"two-six", "", true},
+		{"03:00-03:00", "", true},
+	}
+	for _, tt := range tests {
+		window, err := ParseWindow(tt.text)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseWindow(%q) error = %v, wantErr %v", tt.text, err, tt.wantErr)
+			continue
+		}
+		if err == nil && window.String() != tt.want {
+			t.Errorf("ParseWindow(%q) = %s, want %s", tt.text, window, tt.want)
+		}
+	}
+}
+
+func TestWindow(t *testing.T) {
+	night, _ := ParseWindow("02:00-06:00")
+	wrapping, _ := ParseWindow("22:00-04:00")
+
+	tests := []struct {
+		name      string
+		window    Window
+		now       time.Time
+		contains  bool
+		startOf   time.Time
+		nextStart time.Time
+	}{
+		{"before", night, at(1, 59), false, at(2, 0).AddDate(0, 0, -1), at(2, 0)},
+		{"at the start", night, at(2, 0), true, at(2, 0), at(2, 0).AddDate(0, 0, 1)},
+		{"inside", night, at(5, 59), true, at(2, 0), at(2, 0).AddDate(0, 0, 1)},
+		{"at the end", night, at(6, 0), false, at(2, 0), at(2, 0).AddDate(0, 0, 1)},
+		{"wrapping, before midnight", wrapping, at(23, 0), true, at(22, 0), at(22, 0).AddDate(0, 0, 1)},
+		{"wrapping, after midnight", wrapping, at(3, 0), true, at(22, 0).AddDate(0, 0, -1), at(22, 0)},
+		{"wrapping, outside", wrapping, at(12, 0), false, at(22, 0).AddDate(0, 0, -1), at(22, 0)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.window.Contains(tt.now); got != tt.contains {
+				t.Errorf("Contains = %v, want %v", got, tt.contains)
+			}
+			if got := tt.window.StartOf(tt.now); !got.Equal(tt.startOf) {
+				t.Errorf("StartOf = %s, want %s", got, tt.startOf)
+			}
+			if got := tt.window.NextStart(tt.now); !got.Equal(tt.nextStart) {
+				t.Errorf("NextStart = %s, want %s", got, tt.nextStart)
+			}
+		})
+	}
+}
+
+func TestWindowInLocation(t *testing.T) {
+	moscow := time.FixedZone("MSK", 3*60*60)
+	window, _ := ParseWindow("02:00-06:00")
+
+	// 00:30 UTC is 03:30 in Moscow
+	now := time.Date(2024, time.June, 10, 0, 30, 0, 0, time.UTC)
+	if window.Contains(now) {
+		t.Error("00:30 UTC is inside the window in UTC")
+	}
+	if !window.Contains(now.In(moscow)) {
+		t.Error("03:30 MSK is outside the window in Moscow")
+	}
+}
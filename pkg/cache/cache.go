@@ -0,0 +1,133 @@
+// Package cache keeps expensive results, such as parses of the source,
+// between API requests
+// Values are stored under a key with the time they were produced; a cache
+// returns them while they are younger than its TTL. Memory is the in-process
+// implementation, Redis the one shared by the instances of a deployment.
+package cache
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"easypars/pkg/clock"
+)
+
+// DefaultTTL is how long entries are served when no TTL is configured
+const DefaultTTL = 10 * time.Minute
+
+// Entry is a cached value with the time it was produced
+type Entry[V any] struct {
+	Value    V
+	StoredAt time.Time
+}
+
+// Age returns how old the entry is at now
+func (e Entry[V]) Age(now time.Time) time.Duration {
+	return now.Sub(e.StoredAt)
+}
+
+// Cache stores values by key for a limited time
+// Implementations are safe for concurrent use. Get reports a missing or
+// expired entry with ok false; errors are failures of the cache itself,
+// which callers treat as a miss.
+type Cache[V any] interface {
+	Get(ctx context.Context, key string) (entry Entry[V], ok bool, err error)
+	Set(ctx context.Context, key string, entry Entry[V]) error
+	Delete(ctx context.Context, key string) error
+}
+
+// Locker is implemented by caches shared between processes
+// Lock takes the lock of a key, so a single process computes a missing value
+// while the others wait for it in the cache. acquired is false while another
+// process holds the lock; a lock not released expires after LockTTL, so a
+// crashed holder does not block the others.
+type Locker interface {
+	Lock(ctx context.Context, key string) (release func(), acquired bool, err error)
+	LockTTL() time.Duration
+}
+
+// Memory is a Cache held in process memory
+// Expired entries are dropped when they are read and on every Set, so the
+// memory stays bounded by the keys in use.
+type Memory[V any] struct {
+	mu      sync.RWMutex
+	ttl     time.Duration
+	clock   clock.Clock
+	entries map[string]Entry[V]
+}
+
+// NewMemory creates an in-memory cache serving entries for ttl
+// A non-positive ttl means DefaultTTL, a nil clock the system clock.
+func NewMemory[V any](ttl time.Duration, c clock.Clock) *Memory[V] {
+	if ttl <= 0 {
+		ttl = DefaultTTL
+	}
+	if c == nil {
+		c = clock.Real{}
+	}
+
+	return &Memory[V]{
+		ttl:     ttl,
+		clock:   c,
+		entries: make(map[string]Entry[V]),
+	}
+}
+
+// TTL returns how long entries are served
+func (m *Memory[V]) TTL() time.Duration {
+	return m.ttl
+}
+
+// Get returns the entry of the key while it is younger than the TTL
+func (m *Memory[V]) Get(_ context.Context, key string) (Entry[V], bool, error) {
+	now := m.clock.Now()
+
+	m.mu.RLock()
+	entry, ok := m.entries[key]
+	m.mu.RUnlock()
+	if !ok {
+		return Entry[V]{}, false, nil
+	}
+	if entry.Age(now) >= m.ttl {
+		m.mu.Lock()
+		// The entry may have been replaced in the meantime
+		if current, ok := m.entries[key]; ok && current.StoredAt.Equal(entry.StoredAt) {
+			delete(m.entries, key)
+		}
+		m.mu.Unlock()
+		return Entry[V]{}, false, nil
+	}
+
+	return entry, true, nil
+}
+
+// Set stores the entry under the key, replacing any previous one
+// An entry without StoredAt is stamped with the current time.
+func (m *Memory[V]) Set(_ context.Context, key string, entry Entry[V]) error {
+	now := m.clock.Now()
+	if entry.StoredAt.IsZero() {
+		entry.StoredAt = now
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for other, stored := range m.entries {
+		if stored.Age(now) >= m.ttl {
+			delete(m.entries, other)
+		}
+	}
+	m.entries[key] = entry
+
+	return nil
+}
+
+// Delete removes the entry of the key
+func (m *Memory[V]) Delete(_ context.Context, key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.entries, key)
+	return nil
+}
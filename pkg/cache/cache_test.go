@@ -0,0 +1,167 @@
+package cache
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"easypars/pkg/clock"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+// testNow is the time of the clock the caches of the suite run on
+var testNow = time.Date(2024, time.June, 10, 12, 0, 0, 0, time.UTC)
+
+// backend creates an empty cache for the suite
+type backend struct {
+	name string
+	open func(t *testing.T, ttl time.Duration, c clock.Clock) Cache[string]
+}
+
+// backends lists the cache backends the suite runs against
+// Redis runs on an in-process miniredis server, and on the server at
+// REDIS_ADDR when it is set; the keys of a test are prefixed with its name
+// and removed at its end.
+func backends() []backend {
+	return []backend{
+		{name: "memory", open: func(t *testing.T, ttl time.Duration, c clock.Clock) Cache[string] {
+			return NewMemory[string](ttl, c)
+		}},
+		{name: "miniredis", open: func(t *testing.T, ttl time.Duration, c clock.Clock) Cache[string] {
+			client, prefix := miniredisClient(t)
+			return NewRedis[string](client, RedisOptions{Prefix: prefix, TTL: ttl, Clock: c})
+		}},
+		{name: "redis", open: func(t *testing.T, ttl time.Duration, c clock.Clock) Cache[string] {
+			client, prefix := redisClient(t)
+			return NewRedis[string](client, RedisOptions{Prefix: prefix, TTL: ttl, Clock: c})
+		}},
+	}
+}
+
+// forEachBackend runs the test against every available backend
+func forEachBackend(t *testing.T, test func(t *testing.T, b backend)) {
+	for _, b := range backends() {
+		t.Run(b.name, func(t *testing.T) {
+			test(t, b)
+		})
+	}
+}
+
+// miniredisClient returns a client of a fresh miniredis server
+func miniredisClient(t *testing.T) (*redis.Client, string) {
+	t.Helper()
+
+	server := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	t.Cleanup(func() { client.Close() })
+
+	return client, "easypars:"
+}
+
+// redisClient returns a client of the server at REDIS_ADDR and the key
+// prefix of the test, skipping the test without a server
+func redisClient(t *testing.T) (*redis.Client, string) {
+	t.Helper()
+
+	addr := os.Getenv("REDIS_ADDR")
+	if addr == "" {
+		t.Skip("REDIS_ADDR is not set")
+	}
+	client := redis.NewClient(&redis.Options{Addr: addr})
+	ctx := context.Background()
+	if err := client.Ping(ctx).Err(); err != nil {
+		client.Close()
+		t.Fatalf("connecting to redis %s: %v", addr, err)
+	}
+	prefix := "easypars-test:" + t.Name() + ":"
+	t.Cleanup(func() {
+		keys, _ := client.Keys(ctx, prefix+"*").Result()
+		if len(keys) > 0 {
+			client.Del(ctx, keys...)
+		}
+		client.Close()
+	})
+
+	return client, prefix
+}
+
+func TestCacheGet(t *testing.T) {
+	const ttl = 10 * time.Minute
+	tests := []struct {
+		name string
+		age  time.Duration
+		ok   bool
+	}{
+		{"fresh entry", 0, true},
+		{"just below the TTL", ttl - time.Second, true},
+		{"at the TTL", ttl, false},
+		{"older than the TTL", 2 * ttl, false},
+		{"stored in the future", -time.Minute, true},
+	}
+	forEachBackend(t, func(t *testing.T, b backend) {
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				c := b.open(t, ttl, clock.Fixed{Time: testNow})
+				ctx := context.Background()
+				storedAt := testNow.Add(-tt.age)
+				if err := c.Set(ctx, "fights", Entry[string]{Value: "parsed", StoredAt: storedAt}); err != nil {
+					t.Fatalf("Set: %v", err)
+				}
+
+				entry, ok, err := c.Get(ctx, "fights")
+				if err != nil {
+					t.Fatalf("Get: %v", err)
+				}
+				if ok != tt.ok {
+					t.Fatalf("Get ok = %v, want %v", ok, tt.ok)
+				}
+				if ok && (entry.Value != "parsed" || !entry.StoredAt.Equal(storedAt)) {
+					t.Errorf("Get = %+v, want the value stored at %v", entry, storedAt)
+				}
+			})
+		}
+	})
+}
+
+func TestCacheSetReplaceAndDelete(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, b backend) {
+		c := b.open(t, time.Minute, clock.Fixed{Time: testNow})
+		ctx := context.Background()
+
+		if _, ok, err := c.Get(ctx, "fights"); ok || err != nil {
+			t.Errorf("Get of an empty cache = %v (%v), want a miss", ok, err)
+		}
+
+		// An entry without StoredAt is stamped with the clock
+		if err := c.Set(ctx, "fights", Entry[string]{Value: "first"}); err != nil {
+			t.Fatalf("Set: %v", err)
+		}
+		entry, ok, err := c.Get(ctx, "fights")
+		if err != nil || !ok || entry.Value != "first" || !entry.StoredAt.Equal(testNow) {
+			t.Errorf("Get = %+v, %v (%v), want first stored at %v", entry, ok, err, testNow)
+		}
+
+		if err := c.Set(ctx, "fights", Entry[string]{Value: "second"}); err != nil {
+			t.Fatalf("Set: %v", err)
+		}
+		if entry, _, _ := c.Get(ctx, "fights"); entry.Value != "second" {
+			t.Errorf("Get after a second Set = %q, want second", entry.Value)
+		}
+		if _, ok, _ := c.Get(ctx, "other"); ok {
+			t.Error("Get of another key hit the entry")
+		}
+
+		if err := c.Delete(ctx, "fights"); err != nil {
+			t.Fatalf("Delete: %v", err)
+		}
+		if _, ok, err := c.Get(ctx, "fights"); ok || err != nil {
+			t.Errorf("Get after Delete = %v (%v), want a miss", ok, err)
+		}
+		if err := c.Delete(ctx, "fights"); err != nil {
+			t.Errorf("Delete of a missing key: %v", err)
+		}
+	})
+}
@@ -0,0 +1,186 @@
+package cache
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"easypars/pkg/clock"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// DefaultLockTTL is how long a load lock is held when none is configured
+const DefaultLockTTL = time.Minute
+
+// releaseScript deletes a lock only while it still holds the token of the
+// releasing holder, so an expired lock taken over by another process is
+// not released by the previous holder
+var releaseScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0
+`)
+
+// redisEntry is the JSON layout of an entry stored in Redis
+type redisEntry[V any] struct {
+	Value    V         `json:"value"`
+	StoredAt time.Time `json:"stored_at"`
+}
+
+// RedisOptions configures a Redis cache
+type RedisOptions struct {
+	// Prefix is prepended to every key, so several deployments can share a
+	// Redis database
+	Prefix string
+	// TTL is how long entries are served, DefaultTTL when not positive
+	TTL time.Duration
+	// LockTTL bounds how long a load lock is held, DefaultLockTTL when not
+	// positive
+	LockTTL time.Duration
+	// Clock tells the age of the entries, the system clock when nil
+	Clock clock.Clock
+}
+
+// Redis is a Cache stored in Redis, shared by every instance using the
+// same server and prefix
+// Values are stored as JSON and expire in Redis after the TTL. The age of
+// an entry is checked against its StoredAt as well, like in Memory. Redis
+// also implements Locker with SET NX.
+type Redis[V any] struct {
+	client  *redis.Client
+	prefix  string
+	ttl     time.Duration
+	lockTTL time.Duration
+	clock   clock.Clock
+}
+
+// NewRedis creates a cache on the Redis client
+func NewRedis[V any](client *redis.Client, opts RedisOptions) *Redis[V] {
+	if opts.TTL <= 0 {
+		opts.TTL = DefaultTTL
+	}
+	if opts.LockTTL <= 0 {
+		opts.LockTTL = DefaultLockTTL
+	}
+	if opts.Clock == nil {
+		opts.Clock = clock.Real{}
+	}
+
+	return &Redis[V]{
+		client:  client,
+		prefix:  opts.Prefix,
+		ttl:     opts.TTL,
+		lockTTL: opts.LockTTL,
+		clock:   opts.Clock,
+	}
+}
+
+// TTL returns how long entries are served
+func (r *Redis[V]) TTL() time.Duration {
+	return r.ttl
+}
+
+// LockTTL returns how long a load lock is held at most
+func (r *Redis[V]) LockTTL() time.Duration {
+	return r.lockTTL
+}
+
+// Get returns the entry of the key while it is younger than the TTL
+// An entry that does not decode, e.g. written by an older build, is a miss.
+func (r *Redis[V]) Get(ctx context.Context, key string) (Entry[V], bool, error) {
+	data, err := r.client.Get(ctx, r.prefix+key).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return Entry[V]{}, false, nil
+	}
+	if err != nil {
+		return Entry[V]{}, false, fmt.Errorf("error reading cache entry %s: %w", key, err)
+	}
+
+	var stored redisEntry[V]
+	if err := json.Unmarshal(data, &stored); err != nil {
+		return Entry[V]{}, false, nil
+	}
+	entry := Entry[V]{Value: stored.Value, StoredAt: stored.StoredAt}
+	if entry.Age(r.clock.Now()) >= r.ttl {
+		return Entry[V]{}, false, nil
+	}
+
+	return entry, true, nil
+}
+
+// Set stores the entry under the key, replacing any previous one
+// An entry without StoredAt is stamped with the current time.
+func (r *Redis[V]) Set(ctx context.Context, key string, entry Entry[V]) error {
+	if entry.StoredAt.IsZero() {
+		entry.StoredAt = r.clock.Now()
+	}
+
+	data, err := json.Marshal(redisEntry[V]{Value: entry.Value, StoredAt: entry.StoredAt})
+	if err != nil {
+		return fmt.Errorf("error encoding cache entry %s: %w", key, err)
+	}
+	if err := r.client.Set(ctx, r.prefix+key, data, r.ttl).Err(); err != nil {
+		return fmt.Errorf("error writing cache entry %s: %w", key, err)
+	}
+
+	return nil
+}
+
+// Delete removes the entry of the key
+func (r *Redis[V]) Delete(ctx context.Context, key string) error {
+	if err := r.client.Del(ctx, r.prefix+key).Err(); err != nil {
+		return fmt.Errorf("error deleting cache entry %s: %w", key, err)
+	}
+	return nil
+}
+
+// Lock takes the load lock of the key with SET NX for LockTTL
+// The returned release deletes the lock if it is still held by this call;
+// it does not depend on ctx, so a lock is released after a cancelled load.
+func (r *Redis[V]) Lock(ctx context.Context, key string) (func(), bool, error) {
+	token, err := lockToken()
+	if err != nil {
+		return nil, false, err
+	}
+
+	lockKey := r.prefix + "lock:" + key
+	acquired, err := r.client.SetNX(ctx, lockKey, token, r.lockTTL).Result()
+	if err != nil {
+		return nil, false, fmt.Errorf("error taking cache lock %s: %w", key, err)
+	}
+	if !acquired {
+		return nil, false, nil
+	}
+
+	release := func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		releaseScript.Run(ctx, r.client, []string{lockKey}, token)
+	}
+	return release, true, nil
+}
+
+// Ping checks that Redis answers, for the readiness probe
+func (r *Redis[V]) Ping(ctx context.Context) error {
+	return r.client.Ping(ctx).Err()
+}
+
+// Close closes the connections to Redis
+func (r *Redis[V]) Close() error {
+	return r.client.Close()
+}
+
+// lockToken returns a random token identifying a lock holder
+func lockToken() (string, error) {
+	var token [16]byte
+	if _, err := rand.Read(token[:]); err != nil {
+		return "", fmt.Errorf("error generating lock token: %w", err)
+	}
+	return hex.EncodeToString(token[:]), nil
+}
@@ -0,0 +1,175 @@
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+// redisServer opens a Redis cache for the lock tests, with a function
+// moving the time of the server forward
+type redisServer struct {
+	name string
+	open func(t *testing.T, lockTTL time.Duration) (*Redis[string], func(time.Duration))
+}
+
+// redisServers lists the Redis servers the lock tests run against, like
+// backends
+func redisServers() []redisServer {
+	return []redisServer{
+		{name: "miniredis", open: func(t *testing.T, lockTTL time.Duration) (*Redis[string], func(time.Duration)) {
+			server := miniredis.RunT(t)
+			client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+			t.Cleanup(func() { client.Close() })
+			return NewRedis[string](client, RedisOptions{LockTTL: lockTTL}), server.FastForward
+		}},
+		{name: "redis", open: func(t *testing.T, lockTTL time.Duration) (*Redis[string], func(time.Duration)) {
+			client, prefix := redisClient(t)
+			return NewRedis[string](client, RedisOptions{Prefix: prefix, LockTTL: lockTTL}), time.Sleep
+		}},
+	}
+}
+
+func TestRedisLock(t *testing.T) {
+	const lockTTL = time.Second
+	tests := []struct {
+		name string
+		// prepare runs with the lock taken by a first holder
+		prepare  func(release func(), forward func(time.Duration))
+		acquired bool
+	}{
+		{"held", func(func(), func(time.Duration)) {}, false},
+		{"released", func(release func(), _ func(time.Duration)) { release() }, true},
+		{"expired", func(_ func(), forward func(time.Duration)) { forward(lockTTL + 100*time.Millisecond) }, true},
+	}
+	for _, server := range redisServers() {
+		t.Run(server.name, func(t *testing.T) {
+			for _, tt := range tests {
+				t.Run(tt.name, func(t *testing.T) {
+					c, forward := server.open(t, lockTTL)
+					ctx := context.Background()
+
+					release, acquired, err := c.Lock(ctx, "fights")
+					if err != nil || !acquired {
+						t.Fatalf("first Lock = %v (%v), want the lock", acquired, err)
+					}
+					tt.prepare(release, forward)
+
+					again, acquired, err := c.Lock(ctx, "fights")
+					if err != nil {
+						t.Fatalf("second Lock: %v", err)
+					}
+					if acquired != tt.acquired {
+						t.Fatalf("second Lock acquired = %v, want %v", acquired, tt.acquired)
+					}
+					if acquired {
+						again()
+					}
+
+					// Locks of other keys are independent
+					other, acquired, err := c.Lock(ctx, "other")
+					if err != nil || !acquired {
+						t.Errorf("Lock of another key = %v (%v), want the lock", acquired, err)
+					} else {
+						other()
+					}
+				})
+			}
+		})
+	}
+}
+
+func TestRedisReleaseKeepsALockTakenOver(t *testing.T) {
+	for _, server := range redisServers() {
+		t.Run(server.name, func(t *testing.T) {
+			c, forward := server.open(t, time.Second)
+			ctx := context.Background()
+
+			// The first holder is slow: its lock expires and is taken over
+			stale, _, err := c.Lock(ctx, "fights")
+			if err != nil {
+				t.Fatal(err)
+			}
+			forward(1100 * time.Millisecond)
+			release, acquired, err := c.Lock(ctx, "fights")
+			if err != nil || !acquired {
+				t.Fatalf("Lock of an expired lock = %v (%v), want the lock", acquired, err)
+			}
+			defer release()
+
+			stale()
+			if _, acquired, _ := c.Lock(ctx, "fights"); acquired {
+				t.Error("the release of an expired holder freed the lock of the new holder")
+			}
+		})
+	}
+}
+
+func TestRedisSharedBetweenInstances(t *testing.T) {
+	server := miniredis.RunT(t)
+	open := func(prefix string) *Redis[string] {
+		client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+		t.Cleanup(func() { client.Close() })
+		return NewRedis[string](client, RedisOptions{Prefix: prefix})
+	}
+	ctx := context.Background()
+	first, second, other := open("easypars:"), open("easypars:"), open("staging:")
+
+	if err := first.Set(ctx, "fights", Entry[string]{Value: "parsed"}); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	tests := []struct {
+		name string
+		c    *Redis[string]
+		ok   bool
+	}{
+		{"same instance", first, true},
+		{"instance with the same prefix", second, true},
+		{"instance with another prefix", other, false},
+	}
+	for _, tt := range tests {
+		entry, ok, err := tt.c.Get(ctx, "fights")
+		if err != nil || ok != tt.ok || (ok && entry.Value != "parsed") {
+			t.Errorf("%s: Get = %+v, %v (%v), want ok %v", tt.name, entry, ok, err, tt.ok)
+		}
+	}
+	if _, acquired, _ := first.Lock(ctx, "fights"); !acquired {
+		t.Fatal("Lock failed")
+	}
+	if _, acquired, _ := second.Lock(ctx, "fights"); acquired {
+		t.Error("two instances with the same prefix both hold the lock")
+	}
+
+	// Redis drops entries itself after the TTL
+	server.FastForward(DefaultTTL)
+	if server.Exists("easypars:fights") {
+		t.Error("the entry was kept in Redis after the TTL")
+	}
+}
+
+func TestRedisUndecodableEntryIsAMiss(t *testing.T) {
+	server := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	defer client.Close()
+	c := NewRedis[string](client, RedisOptions{Prefix: "easypars:"})
+	ctx := context.Background()
+
+	if err := server.Set("easypars:fights", "not json"); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok, err := c.Get(ctx, "fights"); ok || err != nil {
+		t.Errorf("Get of an entry of another layout = %v (%v), want a miss", ok, err)
+	}
+
+	// A server that is gone is an error, which callers treat as a miss
+	server.Close()
+	if _, _, err := c.Get(ctx, "fights"); err == nil {
+		t.Error("Get without a server succeeded")
+	}
+	if err := c.Ping(ctx); err == nil {
+		t.Error("Ping without a server succeeded")
+	}
+}
@@ -0,0 +1,231 @@
+// Package client is a Go client for the EasyPars REST API
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"easypars/pkg/apitypes"
+)
+
+// Retry defaults
+const (
+	defaultMaxRetries = 3
+	defaultRetryDelay = 500 * time.Millisecond
+	maxRetryDelay     = 30 * time.Second
+)
+
+// APIError is an error response returned by the API
+type APIError struct {
+	// Code is the machine readable error code ("invalid_params", ...)
+	Code       string
+	Message    string
+	HTTPStatus int
+	// Origin tells whether the service or the source failed, see
+	// apitypes.ErrorResponse
+	Origin string
+}
+
+// Error describes the API error
+func (e *APIError) Error() string {
+	if e.Code == "" {
+		return fmt.Sprintf("easypars api: status %d", e.HTTPStatus)
+	}
+	return fmt.Sprintf("easypars api: %s: %s (status %d)", e.Code, e.Message, e.HTTPStatus)
+}
+
+// Client calls the EasyPars API
+// Create clients with New to get the default timeout and retry settings
+type Client struct {
+	// BaseURL is the server address without the /api prefix ("http://localhost:8080")
+	BaseURL string
+	// HTTPClient performs the requests
+	HTTPClient *http.Client
+	// APIKey is sent in the X-API-Key header when set
+	APIKey string
+	// MaxRetries is the number of retries after 429 and 5xx responses
+	MaxRetries int
+	// RetryDelay is the first backoff delay, doubled on each retry;
+	// a Retry-After header of the response takes precedence
+	RetryDelay time.Duration
+
+	// mu guards cache
+	mu sync.Mutex
+	// cache holds the last response per URL for conditional requests
+	cache map[string]cachedResponse
+}
+
+// cachedResponse is a response body stored with its ETag
+type cachedResponse struct {
+	etag string
+	body []byte
+}
+
+// New creates a client for the server at baseURL
+func New(baseURL string) *Client {
+	return &Client{
+		BaseURL:    strings.TrimRight(baseURL, "/"),
+		HTTPClient: &http.Client{Timeout: 30 * time.Second},
+		MaxRetries: defaultMaxRetries,
+		RetryDelay: defaultRetryDelay,
+		cache:      make(map[string]cachedResponse),
+	}
+}
+
+// Health returns the server health status
+func (c *Client) Health(ctx context.Context) (*apitypes.HealthResponse, error) {
+	var health apitypes.HealthResponse
+	if err := c.get(ctx, "/api/health", nil, &health); err != nil {
+		return nil, err
+	}
+
+	return &health, nil
+}
+
+// get performs a GET request and decodes the JSON response into out
+// Responses carrying an ETag are cached; a 304 response returns the cached body
+func (c *Client) get(ctx context.Context, path string, query url.Values, out any) error {
+	endpoint := c.BaseURL + path
+	if len(query) > 0 {
+		endpoint += "?" + query.Encode()
+	}
+
+	body, err := c.do(ctx, endpoint)
+	if err != nil {
+		return err
+	}
+
+	if err := json.Unmarshal(body, out); err != nil {
+		return fmt.Errorf("error decoding response from %s: %w", path, err)
+	}
+
+	return nil
+}
+
+// do sends the request with retries and returns the response body
+func (c *Client) do(ctx context.Context, endpoint string) ([]byte, error) {
+	delay := c.RetryDelay
+	if delay <= 0 {
+		delay = defaultRetryDelay
+	}
+
+	for attempt := 0; ; attempt++ {
+		body, retryAfter, err := c.attempt(ctx, endpoint)
+		if err == nil {
+			return body, nil
+		}
+
+		var apiErr *APIError
+		if !errors.As(err, &apiErr) || !retryable(apiErr.HTTPStatus) || attempt >= c.MaxRetries {
+			return nil, err
+		}
+
+		wait := delay
+		if retryAfter > 0 {
+			wait = retryAfter
+		}
+		delay = min(delay*2, maxRetryDelay)
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(wait):
+		}
+	}
+}
+
+// attempt sends a single request
+// It returns the Retry-After delay of the response when the server sent one
+func (c *Client) attempt(ctx context.Context, endpoint string) ([]byte, time.Duration, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, 0, fmt.Errorf("error creating request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+	if c.APIKey != "" {
+		req.Header.Set("X-API-Key", c.APIKey)
+	}
+
+	c.mu.Lock()
+	cached, hasCached := c.cache[endpoint]
+	c.mu.Unlock()
+	if hasCached {
+		req.Header.Set("If-None-Match", cached.etag)
+	}
+
+	resp, err := c.httpClient().Do(req)
+	if err != nil {
+		return nil, 0, fmt.Errorf("error calling %s: %w", endpoint, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotModified && hasCached {
+		return cached.body, 0, nil
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, 0, fmt.Errorf("error reading response from %s: %w", endpoint, err)
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		apiErr := &APIError{HTTPStatus: resp.StatusCode}
+		var envelope apitypes.ErrorResponse
+		if json.Unmarshal(body, &envelope) == nil {
+			apiErr.Code = envelope.Error
+			apiErr.Message = envelope.Message
+			apiErr.Origin = envelope.Origin
+		}
+		return nil, parseRetryAfter(resp.Header.Get("Retry-After")), apiErr
+	}
+
+	if etag := resp.Header.Get("ETag"); etag != "" {
+		c.mu.Lock()
+		if c.cache == nil {
+			c.cache = make(map[string]cachedResponse)
+		}
+		c.cache[endpoint] = cachedResponse{etag: etag, body: body}
+		c.mu.Unlock()
+	}
+
+	return body, 0, nil
+}
+
+// httpClient returns the configured HTTP client or the default one
+func (c *Client) httpClient() *http.Client {
+	if c.HTTPClient != nil {
+		return c.HTTPClient
+	}
+	return http.DefaultClient
+}
+
+// retryable reports whether a request failed with this status may be retried
+func retryable(status int) bool {
+	return status == http.StatusTooManyRequests || status >= 500
+}
+
+// parseRetryAfter parses a Retry-After value given in seconds or as an HTTP date
+func parseRetryAfter(value string) time.Duration {
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds > 0 {
+		return min(time.Duration(seconds)*time.Second, maxRetryDelay)
+	}
+	if at, err := http.ParseTime(value); err == nil {
+		if wait := time.Until(at); wait > 0 {
+			return min(wait, maxRetryDelay)
+		}
+	}
+
+	return 0
+}
@@ -0,0 +1,289 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"easypars/pkg/api"
+	"easypars/pkg/clock"
+	"easypars/pkg/parser"
+
+	"github.com/gin-gonic/gin"
+)
+
+func init() {
+	gin.SetMode(gin.TestMode)
+	gin.DefaultWriter = io.Discard
+}
+
+// newAPIServer serves the real router over the results page of the API
+// tests and counts the 304 responses
+func newAPIServer(t *testing.T) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+
+	page, err := os.ReadFile(filepath.Join("..", "api", "testdata", "results.html"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	src := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write(page)
+	}))
+	t.Cleanup(src.Close)
+
+	p := parser.NewParser(src.URL + "/")
+	p.Clock = clock.Fixed{Time: time.Date(2024, time.June, 10, 12, 0, 0, 0, time.UTC)}
+	router := api.SetupRouter(api.Dependencies{Parser: p})
+
+	var notModified atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, r)
+		if rec.Code == http.StatusNotModified {
+			notModified.Add(1)
+		}
+		for name, values := range rec.Header() {
+			w.Header()[name] = values
+		}
+		w.WriteHeader(rec.Code)
+		w.Write(rec.Body.Bytes())
+	}))
+	t.Cleanup(srv.Close)
+
+	return srv, &notModified
+}
+
+// scriptedServer answers the requests with the statuses in order, repeating
+// the last one, and counts them; error statuses carry an error envelope
+func scriptedServer(t *testing.T, retryAfter string, statuses ...int) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+
+	var requests atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := int(requests.Add(1))
+		status := statuses[min(n, len(statuses))-1]
+		w.Header().Set("Content-Type", "application/json")
+		if status != http.StatusOK {
+			if retryAfter != "" {
+				w.Header().Set("Retry-After", retryAfter)
+			}
+			w.WriteHeader(status)
+			io.WriteString(w, `{"error":"source_unavailable","message":"Source is down","origin":"source"}`)
+			return
+		}
+		io.WriteString(w, `{"status":"ok","message":"EasyPars is running","version":"test"}`)
+	}))
+	t.Cleanup(srv.Close)
+
+	return srv, &requests
+}
+
+// newTestClient returns a client of the server with short retry delays
+func newTestClient(srv *httptest.Server) *Client {
+	c := New(srv.URL)
+	c.RetryDelay = time.Millisecond
+
+	return c
+}
+
+func TestRoundTrip(t *testing.T) {
+	srv, _ := newAPIServer(t)
+	c := newTestClient(srv)
+	ctx := context.Background()
+
+	health, err := c.Health(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if health.Status == "" || health.Version == "" {
+		t.Errorf("health = %+v, want the status and version", health)
+	}
+
+	all, err := c.GetFights(ctx, FightsQuery{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(all.Fights) < 3 {
+		t.Fatalf("fights = %d, want the fights of the results page", len(all.Fights))
+	}
+	if all.HasNext() {
+		t.Error("an unpaginated list has a next page")
+	}
+	if _, err := all.Next(ctx); !errors.Is(err, ErrNoMorePages) {
+		t.Errorf("Next of an unpaginated list = %v, want ErrNoMorePages", err)
+	}
+
+	// Walking the pages returns every fight once, in the list order
+	var walked []string
+	page, err := c.GetFights(ctx, FightsQuery{Page: 1, Limit: 2})
+	for err == nil {
+		for _, fight := range page.Fights {
+			walked = append(walked, fight.Key)
+		}
+		page, err = page.Next(ctx)
+	}
+	if !errors.Is(err, ErrNoMorePages) {
+		t.Fatalf("walking the pages: %v", err)
+	}
+	if len(walked) != len(all.Fights) {
+		t.Fatalf("walked %d fights, want %d", len(walked), len(all.Fights))
+	}
+	for i, fight := range all.Fights {
+		if walked[i] != fight.Key {
+			t.Errorf("fight %d = %s, want %s", i, walked[i], fight.Key)
+		}
+	}
+
+	fight, err := c.GetFightBySlug(ctx, all.Fights[0].Slug)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fight.Key != all.Fights[0].Key {
+		t.Errorf("GetFightBySlug = %s, want %s", fight.Key, all.Fights[0].Key)
+	}
+}
+
+func TestRoundTripErrors(t *testing.T) {
+	srv, _ := newAPIServer(t)
+	c := newTestClient(srv)
+	ctx := context.Background()
+
+	tests := []struct {
+		name   string
+		call   func() error
+		status int
+		code   string
+	}{
+		{"invalid query", func() error {
+			_, err := c.GetFights(ctx, FightsQuery{Page: 1, Limit: 100000})
+			return err
+		}, http.StatusBadRequest, "invalid_params"},
+		{"unknown slug", func() error {
+			_, err := c.GetFightBySlug(ctx, "nobody-vs-nobody-2000-01-01")
+			return err
+		}, http.StatusNotFound, "not_found"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var apiErr *APIError
+			if err := tt.call(); !errors.As(err, &apiErr) {
+				t.Fatalf("error = %v, want an APIError", err)
+			}
+			if apiErr.HTTPStatus != tt.status || apiErr.Code != tt.code || apiErr.Message == "" {
+				t.Errorf("APIError = %+v, want %d %s with a message", apiErr, tt.status, tt.code)
+			}
+		})
+	}
+}
+
+func TestNotModifiedReturnsTheCachedPage(t *testing.T) {
+	srv, notModified := newAPIServer(t)
+	c := newTestClient(srv)
+	ctx := context.Background()
+
+	first, err := c.GetFights(ctx, FightsQuery{Page: 1, Limit: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := c.GetFights(ctx, FightsQuery{Page: 1, Limit: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if notModified.Load() != 1 {
+		t.Errorf("304 responses = %d, want the second request revalidated", notModified.Load())
+	}
+	if len(second.Fights) != len(first.Fights) || second.Fights[0].Key != first.Fights[0].Key {
+		t.Errorf("cached page = %+v, want the first page", second.Fights)
+	}
+}
+
+func TestRetries(t *testing.T) {
+	tests := []struct {
+		name       string
+		statuses   []int
+		retryAfter string
+		requests   int32
+		status     int
+	}{
+		{"5xx then success", []int{http.StatusServiceUnavailable, http.StatusBadGateway, http.StatusOK}, "", 3, 0},
+		{"429 then success", []int{http.StatusTooManyRequests, http.StatusOK}, "", 2, 0},
+		{"retries exhausted", []int{http.StatusServiceUnavailable}, "", 4, http.StatusServiceUnavailable},
+		{"client error not retried", []int{http.StatusBadRequest}, "", 1, http.StatusBadRequest},
+		{"not found not retried", []int{http.StatusNotFound}, "", 1, http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv, requests := scriptedServer(t, tt.retryAfter, tt.statuses...)
+			_, err := newTestClient(srv).Health(context.Background())
+			if got := requests.Load(); got != tt.requests {
+				t.Errorf("requests = %d, want %d", got, tt.requests)
+			}
+			if tt.status == 0 {
+				if err != nil {
+					t.Errorf("Health = %v, want success", err)
+				}
+				return
+			}
+			var apiErr *APIError
+			if !errors.As(err, &apiErr) || apiErr.HTTPStatus != tt.status || apiErr.Code != "source_unavailable" || apiErr.Origin != "source" {
+				t.Errorf("Health = %v, want an APIError with status %d and the envelope", err, tt.status)
+			}
+		})
+	}
+}
+
+func TestRetryAfterIsHonored(t *testing.T) {
+	srv, requests := scriptedServer(t, "1", http.StatusTooManyRequests, http.StatusOK)
+	start := time.Now()
+	if _, err := newTestClient(srv).Health(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed < time.Second {
+		t.Errorf("retried after %s, want the Retry-After second", elapsed)
+	}
+	if requests.Load() != 2 {
+		t.Errorf("requests = %d, want 2", requests.Load())
+	}
+}
+
+func TestRetryWaitStopsOnCancel(t *testing.T) {
+	srv, requests := scriptedServer(t, "30", http.StatusServiceUnavailable)
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	if _, err := newTestClient(srv).Health(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Health = %v, want the context error", err)
+	}
+	if requests.Load() != 1 {
+		t.Errorf("requests = %d, want no retry after the cancellation", requests.Load())
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	tests := []struct {
+		value string
+		want  time.Duration
+	}{
+		{"", 0},
+		{"5", 5 * time.Second},
+		{"0", 0},
+		{"-3", 0},
+		{"3600", maxRetryDelay},
+		{"soon", 0},
+		{time.Now().Add(-time.Hour).UTC().Format(http.TimeFormat), 0},
+		{time.Now().Add(time.Hour).UTC().Format(http.TimeFormat), maxRetryDelay},
+	}
+	for _, tt := range tests {
+		if got := parseRetryAfter(tt.value); got != tt.want {
+			t.Errorf("parseRetryAfter(%q) = %s, want %s", tt.value, got, tt.want)
+		}
+	}
+}
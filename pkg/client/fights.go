@@ -0,0 +1,112 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"strconv"
+
+	"easypars/models"
+	"easypars/pkg/apitypes"
+)
+
+// ErrNoMorePages is returned by FightsPage.Next after the last page
+var ErrNoMorePages = errors.New("no more pages")
+
+// FightsQuery holds the options of GET /api/fights
+// Zero values are not sent
+type FightsQuery struct {
+	// Preset applies a saved query preset by its slug
+	Preset string
+	// Search matches a part of either fighter name
+	Search string
+	// IncludeHidden includes fights found in commented-out HTML
+	IncludeHidden bool
+	// Rematch returns only rematches
+	Rematch bool
+	// Page and Limit select a page of the list, in the canonical order of
+	// the list (newest first); without them the whole list is returned
+	Page  int
+	Limit int
+}
+
+// values encodes the query parameters
+func (q FightsQuery) values() url.Values {
+	values := url.Values{}
+	if q.Preset != "" {
+		values.Set("preset", q.Preset)
+	}
+	if q.Search != "" {
+		values.Set("search", q.Search)
+	}
+	if q.IncludeHidden {
+		values.Set("include_hidden", "1")
+	}
+	if q.Rematch {
+		values.Set("rematch", "1")
+	}
+	if q.Page > 0 {
+		values.Set("page", strconv.Itoa(q.Page))
+	}
+	if q.Limit > 0 {
+		values.Set("limit", strconv.Itoa(q.Limit))
+	}
+
+	return values
+}
+
+// FightsPage is a page of fights returned by GetFights
+type FightsPage struct {
+	Fights     []models.Fight
+	Warnings   []apitypes.Warning
+	Pagination *apitypes.Pagination
+
+	client *Client
+	query  FightsQuery
+}
+
+// GetFights returns fights matching the query
+func (c *Client) GetFights(ctx context.Context, query FightsQuery) (*FightsPage, error) {
+	var response apitypes.FightsResponse
+	if err := c.get(ctx, "/api/fights", query.values(), &response); err != nil {
+		return nil, err
+	}
+
+	return &FightsPage{
+		Fights:     response.Data,
+		Warnings:   response.Warnings,
+		Pagination: response.Pagination,
+		client:     c,
+		query:      query,
+	}, nil
+}
+
+// GetFightBySlug returns the fight with the given permalink
+// The redirect of a renamed fight's former slug is followed
+func (c *Client) GetFightBySlug(ctx context.Context, slug string) (models.Fight, error) {
+	var response apitypes.FightResponse
+	if err := c.get(ctx, "/api/fights/by-slug/"+url.PathEscape(slug), nil, &response); err != nil {
+		return models.Fight{}, err
+	}
+
+	return response.Data, nil
+}
+
+// HasNext reports whether another page follows this one
+func (p *FightsPage) HasNext() bool {
+	return p.Pagination != nil && p.Pagination.Page < p.Pagination.TotalPages
+}
+
+// Next fetches the following page with the same query
+// Returns ErrNoMorePages after the last page or when the list is not paginated
+func (p *FightsPage) Next(ctx context.Context) (*FightsPage, error) {
+	if !p.HasNext() {
+		return nil, ErrNoMorePages
+	}
+
+	query := p.query
+	query.Page = p.Pagination.Page + 1
+	query.Limit = p.Pagination.Limit
+
+	return p.client.GetFights(ctx, query)
+}
@@ -0,0 +1,36 @@
+package clock
+
+import (
+	"time"
+)
+
+// Clock provides the current time
+// Components that depend on "now" take a Clock so the time can be controlled
+type Clock interface {
+	Now() time.Time
+}
+
+// Real is the system clock
+type Real struct{}
+
+// Now returns the current system time
+func (Real) Now() time.Time {
+	return time.Now()
+}
+
+// Fixed is a clock that always returns the same time
+// Useful for reproducible runs such as reparsing stored data
+type Fixed struct {
+	Time time.Time
+}
+
+// Now returns the fixed time
+func (c Fixed) Now() time.Time {
+	return c.Time
+}
+
+// Today returns the start of the current day of the clock in its location
+func Today(c Clock) time.Time {
+	now := c.Now()
+	return time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+}
@@ -0,0 +1,108 @@
+package clock
+
+import (
+	"sync"
+	"time"
+)
+
+// Skew levels of the local clock
+const (
+	// SkewOK means the local clock agrees with the reference time
+	SkewOK = "ok"
+	// SkewWarning means the difference is above the warning threshold
+	SkewWarning = "warning"
+	// SkewCritical means the local clock cannot be trusted for dates
+	SkewCritical = "critical"
+)
+
+// Default skew thresholds
+const (
+	DefaultMaxSkew      = 10 * time.Minute
+	DefaultCriticalSkew = 24 * time.Hour
+)
+
+// SkewStatus is the result of the last clock check
+type SkewStatus struct {
+	Level string `json:"level"`
+	// SkewSeconds is the local time minus the reference time: positive when
+	// the local clock is ahead, negative when it is behind
+	SkewSeconds float64 `json:"skew_seconds"`
+	// Reference names where the reference time came from, e.g. a host
+	Reference string `json:"reference"`
+	// CheckedAt is the local time of the check
+	CheckedAt time.Time `json:"checked_at"`
+}
+
+// SkewMonitor tracks the difference between the local clock and a trusted
+// reference time, such as the Date header of the source responses
+// A server whose clock jumped (a dead RTC battery, a broken NTP setup)
+// would otherwise date fights in the wrong year without any error.
+type SkewMonitor struct {
+	maxSkew      time.Duration
+	criticalSkew time.Duration
+
+	mu      sync.RWMutex
+	status  SkewStatus
+	checked bool
+}
+
+// NewSkewMonitor creates a monitor with the warning and critical thresholds
+// Non-positive thresholds mean DefaultMaxSkew and DefaultCriticalSkew.
+func NewSkewMonitor(maxSkew, criticalSkew time.Duration) *SkewMonitor {
+	if maxSkew <= 0 {
+		maxSkew = DefaultMaxSkew
+	}
+	if criticalSkew <= 0 {
+		criticalSkew = DefaultCriticalSkew
+	}
+
+	return &SkewMonitor{maxSkew: maxSkew, criticalSkew: criticalSkew}
+}
+
+// Observe compares the local time with the reference time and records the
+// result; changed is set when the level differs from the previous check
+func (m *SkewMonitor) Observe(reference string, local, trusted time.Time) (status SkewStatus, changed bool) {
+	skew := local.Sub(trusted)
+	distance := skew
+	if distance < 0 {
+		distance = -distance
+	}
+
+	status = SkewStatus{
+		Level:       SkewOK,
+		SkewSeconds: skew.Seconds(),
+		Reference:   reference,
+		CheckedAt:   local,
+	}
+	switch {
+	case distance > m.criticalSkew:
+		status.Level = SkewCritical
+	case distance > m.maxSkew:
+		status.Level = SkewWarning
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	previous := SkewOK
+	if m.checked {
+		previous = m.status.Level
+	}
+	m.status, m.checked = status, true
+
+	return status, status.Level != previous
+}
+
+// Status returns the result of the last check, false before the first one
+func (m *SkewMonitor) Status() (SkewStatus, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return m.status, m.checked
+}
+
+// Critical reports whether the last check found a critical skew
+func (m *SkewMonitor) Critical() bool {
+	status, ok := m.Status()
+	return ok && status.Level == SkewCritical
+}
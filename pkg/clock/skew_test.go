@@ -0,0 +1,55 @@
+package clock
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSkewMonitor(t *testing.T) {
+	trusted := time.Date(2024, time.June, 10, 12, 0, 0, 0, time.UTC)
+	m := NewSkewMonitor(10*time.Minute, 24*time.Hour)
+
+	if _, checked := m.Status(); checked {
+		t.Fatal("a new monitor reports a check")
+	}
+
+	// The checks run in order: changed compares with the previous level
+	tests := []struct {
+		name    string
+		local   time.Time
+		level   string
+		changed bool
+	}{
+		{"in sync", trusted.Add(3 * time.Second), SkewOK, false},
+		{"small drift", trusted.Add(-9 * time.Minute), SkewOK, false},
+		{"ahead", trusted.Add(time.Hour), SkewWarning, true},
+		{"still ahead", trusted.Add(2 * time.Hour), SkewWarning, false},
+		{"a year behind", trusted.AddDate(-1, 0, 0), SkewCritical, true},
+		{"a day ahead", trusted.Add(25 * time.Hour), SkewCritical, false},
+		{"recovered", trusted, SkewOK, true},
+	}
+	for _, tt := range tests {
+		status, changed := m.Observe("vringe.example", tt.local, trusted)
+		if status.Level != tt.level || changed != tt.changed {
+			t.Errorf("%s: level = %s changed %v, want %s changed %v", tt.name, status.Level, changed, tt.level, tt.changed)
+		}
+		if want := tt.local.Sub(trusted).Seconds(); status.SkewSeconds != want {
+			t.Errorf("%s: skew = %v s, want %v s", tt.name, status.SkewSeconds, want)
+		}
+		if m.Critical() != (tt.level == SkewCritical) {
+			t.Errorf("%s: Critical = %v with level %s", tt.name, m.Critical(), tt.level)
+		}
+	}
+}
+
+func TestSkewMonitorDefaults(t *testing.T) {
+	m := NewSkewMonitor(0, -time.Second)
+	trusted := time.Date(2024, time.June, 10, 12, 0, 0, 0, time.UTC)
+
+	if status, _ := m.Observe("", trusted.Add(DefaultMaxSkew+time.Second), trusted); status.Level != SkewWarning {
+		t.Errorf("level above the default warning threshold = %s, want %s", status.Level, SkewWarning)
+	}
+	if status, _ := m.Observe("", trusted.Add(DefaultCriticalSkew+time.Second), trusted); status.Level != SkewCritical {
+		t.Errorf("level above the default critical threshold = %s, want %s", status.Level, SkewCritical)
+	}
+}
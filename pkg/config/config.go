@@ -2,11 +2,18 @@ package config
 
 import (
 	"fmt"
-	"log"
+	"log/slog"
+	"net"
 	"os"
 	"path/filepath"
+	"strings"
+	"time"
+
+	"easypars/models"
+	"easypars/pkg/countries"
 
 	"github.com/spf13/viper"
+	"golang.org/x/crypto/bcrypt"
 )
 
 // Config represents the application configuration structure
@@ -15,18 +22,101 @@ type Config struct {
 	// Server configuration section
 	Server ServerConfig `mapstructure:"server" yaml:"server"`
 
-	// Future configuration sections to be added:
-	// Database DatabaseConfig `mapstructure:"database" yaml:"database"`
-	// Parser   ParserConfig   `mapstructure:"parser" yaml:"parser"`
-	// JWT      JWTConfig      `mapstructure:"jwt" yaml:"jwt"`
-	// Redis    RedisConfig    `mapstructure:"redis" yaml:"redis"`
-	// Logging  LoggingConfig  `mapstructure:"logging" yaml:"logging"`
+	// API behaviour configuration section
+	API APIConfig `mapstructure:"api" yaml:"api"`
+
+	// Storage configuration section
+	Storage StorageConfig `mapstructure:"storage" yaml:"storage"`
+
+	// PostgreSQL connection configuration section
+	Database DatabaseConfig `mapstructure:"database" yaml:"database"`
+
+	// Parser configuration section
+	Parser ParserConfig `mapstructure:"parser" yaml:"parser"`
+
+	// Snapshot publication configuration section
+	Snapshot SnapshotConfig `mapstructure:"snapshot" yaml:"snapshot"`
+
+	// Query presets configuration section
+	Presets PresetsConfig `mapstructure:"presets" yaml:"presets"`
+
+	// News items configuration section
+	News NewsConfig `mapstructure:"news" yaml:"news"`
+
+	// Webhooks configuration section
+	Webhooks WebhooksConfig `mapstructure:"webhooks" yaml:"webhooks"`
+
+	// Live update stream configuration section
+	Stream StreamConfig `mapstructure:"stream" yaml:"stream"`
+
+	// Parse history configuration section
+	History HistoryConfig `mapstructure:"history" yaml:"history"`
+
+	// Archive backfill configuration section
+	Backfill BackfillConfig `mapstructure:"backfill" yaml:"backfill"`
+
+	// Scheduled refresh configuration section
+	Refresh RefreshConfig `mapstructure:"refresh" yaml:"refresh"`
+
+	// Logging configuration section
+	Logging LoggingConfig `mapstructure:"logging" yaml:"logging"`
+
+	// Interest scoring configuration section
+	Scoring ScoringConfig `mapstructure:"scoring" yaml:"scoring"`
+
+	// Model contract checks configuration section
+	Contract ContractConfig `mapstructure:"contract" yaml:"contract"`
+
+	// Readiness probe configuration section
+	Health HealthConfig `mapstructure:"health" yaml:"health"`
+
+	// Prometheus metrics configuration section
+	Metrics MetricsConfig `mapstructure:"metrics" yaml:"metrics"`
+
+	// OpenTelemetry tracing configuration section
+	Tracing TracingConfig `mapstructure:"tracing" yaml:"tracing"`
+
+	// Retention of auxiliary data configuration section
+	Retention RetentionConfig `mapstructure:"retention" yaml:"retention"`
+
+	// Fight preview card configuration section
+	Cards CardsConfig `mapstructure:"cards" yaml:"cards"`
+
+	// Server clock check configuration section
+	Clock ClockConfig `mapstructure:"clock" yaml:"clock"`
+
+	// Freshness of the served data configuration section
+	Cache CacheConfig `mapstructure:"cache" yaml:"cache"`
+
+	// Embeddable widget configuration section
+	Embed EmbedConfig `mapstructure:"embed" yaml:"embed"`
+
+	// Source compatibility check configuration section
+	CheckSource CheckSourceConfig `mapstructure:"check_source" yaml:"check_source"`
+
+	// Country dictionary configuration section
+	Countries CountriesConfig `mapstructure:"countries" yaml:"countries"`
+
+	// Redis connection of the shared fight cache configuration section
+	Redis RedisConfig `mapstructure:"redis" yaml:"redis"`
+
+	// Admin authentication configuration section
+	JWT JWTConfig `mapstructure:"jwt" yaml:"jwt"`
 }
 
 // ServerConfig holds server-specific configuration
 // Maps to the "server" section in config.yaml
 type ServerConfig struct {
 	Port string `mapstructure:"port" yaml:"port"`
+	// InitTimeoutSeconds bounds the initialization of every component on start
+	InitTimeoutSeconds int `mapstructure:"init_timeout_seconds" yaml:"init_timeout_seconds"`
+	// ShutdownTimeoutSeconds is the grace period in-flight requests get to
+	// complete on shutdown; requests still running after it are cancelled
+	ShutdownTimeoutSeconds int `mapstructure:"shutdown_timeout_seconds" yaml:"shutdown_timeout_seconds"`
+	// TrustedProxies are the IPs or CIDRs of the reverse proxies whose
+	// X-Forwarded-For header gives the client IP; empty uses the address
+	// of the connection
+	TrustedProxies []string `mapstructure:"trusted_proxies" yaml:"trusted_proxies"`
 
 	// Future server configuration fields:
 	// Host         string `mapstructure:"host" yaml:"host"`
@@ -37,31 +127,494 @@ type ServerConfig struct {
 	// KeyFile      string `mapstructure:"key_file" yaml:"key_file"`
 }
 
-// DatabaseConfig will hold database configuration (future implementation)
-// type DatabaseConfig struct {
-// 	Host     string `mapstructure:"host" yaml:"host"`
-// 	Port     int    `mapstructure:"port" yaml:"port"`
-// 	User     string `mapstructure:"user" yaml:"user"`
-// 	Password string `mapstructure:"password" yaml:"password"`
-// 	DBName   string `mapstructure:"dbname" yaml:"dbname"`
-// 	SSLMode  string `mapstructure:"sslmode" yaml:"sslmode"`
-// }
-
-// ParserConfig will hold parser configuration (future implementation)
-// type ParserConfig struct {
-// 	BaseURL           string `mapstructure:"base_url" yaml:"base_url"`
-// 	RateLimit         int    `mapstructure:"rate_limit" yaml:"rate_limit"`
-// 	Timeout           int    `mapstructure:"timeout" yaml:"timeout"`
-// 	ConcurrentWorkers int    `mapstructure:"concurrent_workers" yaml:"concurrent_workers"`
-// 	RetryAttempts     int    `mapstructure:"retry_attempts" yaml:"retry_attempts"`
-// }
-
-// JWTConfig will hold JWT configuration (future implementation)
-// type JWTConfig struct {
-// 	Secret      string `mapstructure:"secret" yaml:"secret"`
-// 	ExpireHours int    `mapstructure:"expire_hours" yaml:"expire_hours"`
-// 	Issuer      string `mapstructure:"issuer" yaml:"issuer"`
-// }
+// APIConfig holds REST API behaviour settings
+// Maps to the "api" section in config.yaml
+type APIConfig struct {
+	// FastResponseBudgetMs is how long /api/fights?fallback=accepted waits
+	// for data before answering 202 and finishing the parse in the background
+	FastResponseBudgetMs int `mapstructure:"fast_response_budget_ms" yaml:"fast_response_budget_ms"`
+	// MaxConcurrentRequests bounds the requests handled at once; requests
+	// over it wait up to QueueTimeoutMs and then get 503 (0 disables the limit)
+	MaxConcurrentRequests int `mapstructure:"max_concurrent_requests" yaml:"max_concurrent_requests"`
+	// MaxConcurrentProbes is the separate limit of /api/health and the probes
+	MaxConcurrentProbes int `mapstructure:"max_concurrent_probes" yaml:"max_concurrent_probes"`
+	// QueueTimeoutMs is how long a request over the limit waits for a slot
+	QueueTimeoutMs int `mapstructure:"queue_timeout_ms" yaml:"queue_timeout_ms"`
+	// DefaultFilters are /api/fights filter parameters applied to every list
+	// request before the request parameters, e.g. status: scheduled
+	DefaultFilters map[string]string `mapstructure:"default_filters" yaml:"default_filters"`
+	// APIKey is the key of privileged requests (X-API-Key header), e.g.
+	// ?ignore_defaults=1; empty disables them
+	APIKey string `mapstructure:"api_key" yaml:"api_key"`
+	// APIKeys are the keys handed out to programmatic consumers of the read
+	// endpoints, each with its own rate limit
+	APIKeys []APIKeyConfig `mapstructure:"api_keys" yaml:"api_keys"`
+	// AllowAnonymous allows read requests without an API key
+	AllowAnonymous bool `mapstructure:"allow_anonymous" yaml:"allow_anonymous"`
+	// AnonymousRequestsPerMinute limits the read requests without an API
+	// key per client IP (0 disables the limit)
+	AnonymousRequestsPerMinute int `mapstructure:"anonymous_requests_per_minute" yaml:"anonymous_requests_per_minute"`
+	// ExportMaxRows bounds the fights of an XLSX export of
+	// /api/fights/export; larger exports get 413
+	ExportMaxRows int `mapstructure:"export_max_rows" yaml:"export_max_rows"`
+	// FeedItems is the number of results in /api/fights/feed.xml
+	FeedItems int `mapstructure:"feed_items" yaml:"feed_items"`
+	// RateLimit limits the API requests of every client IP
+	RateLimit RateLimitConfig `mapstructure:"rate_limit" yaml:"rate_limit"`
+	// CostThresholds are the bounds of the request cost classes; expensive
+	// requests need the X-Confirm-Expensive: true header
+	CostThresholds CostThresholdsConfig `mapstructure:"cost_thresholds" yaml:"cost_thresholds"`
+}
+
+// RateLimitConfig holds the rate limit of the API requests per client IP
+type RateLimitConfig struct {
+	// RequestsPerSecond is the steady rate of a client (0 disables the limit)
+	RequestsPerSecond float64 `mapstructure:"requests_per_second" yaml:"requests_per_second"`
+	// Burst is the number of requests a client may send at once
+	Burst int `mapstructure:"burst" yaml:"burst"`
+}
+
+// APIKeyConfig is an API key of a consumer of the read endpoints
+type APIKeyConfig struct {
+	Key string `mapstructure:"key" yaml:"key"`
+	// Name identifies the consumer in the logs and the rate limiter
+	Name string `mapstructure:"name" yaml:"name"`
+	// RequestsPerMinute is the rate limit of the key (0 disables the limit)
+	RequestsPerMinute int `mapstructure:"requests_per_minute" yaml:"requests_per_minute"`
+}
+
+// CostThresholdsConfig holds the bounds of the request cost classes
+// A request reaching either the request count or the duration of a class
+// belongs to it; checked by the API package
+type CostThresholdsConfig struct {
+	ModerateRequests  int     `mapstructure:"moderate_requests" yaml:"moderate_requests"`
+	ModerateSeconds   float64 `mapstructure:"moderate_seconds" yaml:"moderate_seconds"`
+	ExpensiveRequests int     `mapstructure:"expensive_requests" yaml:"expensive_requests"`
+	ExpensiveSeconds  float64 `mapstructure:"expensive_seconds" yaml:"expensive_seconds"`
+}
+
+// StorageConfig holds persistent storage configuration
+// Maps to the "storage" section in config.yaml
+type StorageConfig struct {
+	// Type selects the storage backend: "none", "sqlite", "postgres" or
+	// "file"; postgres connects with the database section
+	Type string `mapstructure:"type" yaml:"type"`
+	// Path is the database file of the sqlite backend or the JSON file of
+	// the file backend
+	Path string `mapstructure:"path" yaml:"path"`
+	// BusyTimeoutMs is how long sqlite waits for a locked database
+	BusyTimeoutMs int `mapstructure:"busy_timeout_ms" yaml:"busy_timeout_ms"`
+	// Checksum protects the file of the file backend with a checksum
+	Checksum bool `mapstructure:"checksum" yaml:"checksum"`
+	// AllowRecovery allows loading the readable part of a damaged file
+	// protected by a checksum; files without one are always recovered
+	AllowRecovery bool `mapstructure:"allow_recovery" yaml:"allow_recovery"`
+	// MissingGraceDays is how long a fight that disappeared from its page
+	// is kept, waiting for an archive to confirm it, before it is deleted
+	MissingGraceDays int `mapstructure:"missing_grace_days" yaml:"missing_grace_days"`
+}
+
+// PostProcessorsConfig holds post-processing pipeline configuration
+type PostProcessorsConfig struct {
+	// Disabled lists stage names that are skipped
+	Disabled []string `mapstructure:"disabled" yaml:"disabled"`
+	// OnError is "skip" (ignore the failing stage) or "fail" (fail the run)
+	OnError string `mapstructure:"on_error" yaml:"on_error"`
+}
+
+// SnapshotConfig holds snapshot publication configuration
+// Maps to the "snapshot" section in config.yaml
+type SnapshotConfig struct {
+	// MinRatio is the minimal share of the previous fight count a new snapshot
+	// must have to be published
+	MinRatio float64 `mapstructure:"min_ratio" yaml:"min_ratio"`
+	// MaxQualityDrop is the maximal allowed drop of the quality score in points
+	MaxQualityDrop float64 `mapstructure:"max_quality_drop" yaml:"max_quality_drop"`
+	// LocationAliasesFile is the YAML dictionary of known locations and their
+	// spellings; a missing file means no known locations
+	LocationAliasesFile string `mapstructure:"location_aliases_file" yaml:"location_aliases_file"`
+	// Precompute lists the snapshot aggregates built right away instead of on
+	// first use: fighters, locations, interest, view
+	Precompute []string `mapstructure:"precompute" yaml:"precompute"`
+}
+
+// PresetsConfig holds saved query preset configuration
+// Maps to the "presets" section in config.yaml
+type PresetsConfig struct {
+	// MaxPresets is the number of presets kept before LRU eviction
+	MaxPresets int `mapstructure:"max_presets" yaml:"max_presets"`
+	// File stores presets between restarts, empty keeps them in memory only
+	File string `mapstructure:"file" yaml:"file"`
+}
+
+// NewsConfig holds the configuration of the news items linked to fights
+// Maps to the "news" section in config.yaml
+type NewsConfig struct {
+	// File is the JSON array of news items, empty or missing means no news
+	File string `mapstructure:"file" yaml:"file"`
+}
+
+// WebhooksConfig holds the configuration of the webhooks notified of new
+// results
+// Maps to the "webhooks" section in config.yaml
+type WebhooksConfig struct {
+	Enabled bool `mapstructure:"enabled" yaml:"enabled"`
+	// File stores the webhooks between restarts, empty keeps them in memory only
+	File string `mapstructure:"file" yaml:"file"`
+	// TimeoutMs bounds every attempt of a delivery
+	TimeoutMs int `mapstructure:"timeout_ms" yaml:"timeout_ms"`
+	// MaxAttempts is the number of attempts of a delivery, the first included
+	MaxAttempts int `mapstructure:"max_attempts" yaml:"max_attempts"`
+	// RetryBackoffMs is the pause before the first retry, doubled for every
+	// further retry
+	RetryBackoffMs int `mapstructure:"retry_backoff_ms" yaml:"retry_backoff_ms"`
+	// LogSize is the number of deliveries kept for the delivery log
+	LogSize int `mapstructure:"log_size" yaml:"log_size"`
+	// AllowedHosts are host names and IP addresses deliveries may reach on
+	// loopback, private or link-local addresses, e.g. a bot on the LAN;
+	// such addresses are refused otherwise
+	AllowedHosts []string `mapstructure:"allowed_hosts" yaml:"allowed_hosts"`
+}
+
+// StreamConfig holds the configuration of the live fight updates of
+// /api/fights/stream and /api/ws
+// Maps to the "stream" section in config.yaml
+type StreamConfig struct {
+	// MaxClients bounds the clients connected at once
+	MaxClients int `mapstructure:"max_clients" yaml:"max_clients"`
+	// ClientBuffer is the number of updates buffered for every client; a
+	// client that falls further behind is disconnected
+	ClientBuffer int `mapstructure:"client_buffer" yaml:"client_buffer"`
+	// HeartbeatSeconds is the pause between the comments and pings keeping
+	// idle connections open
+	HeartbeatSeconds int `mapstructure:"heartbeat_seconds" yaml:"heartbeat_seconds"`
+}
+
+// HistoryConfig holds parse history configuration
+// Maps to the "history" section in config.yaml
+type HistoryConfig struct {
+	// MaxRuns is the number of parse runs kept in memory
+	MaxRuns int `mapstructure:"max_runs" yaml:"max_runs"`
+	// MaxLogEntries is the number of log records captured per run
+	MaxLogEntries int `mapstructure:"max_log_entries" yaml:"max_log_entries"`
+}
+
+// BackfillConfig holds the archive backfill schedule
+// Maps to the "backfill" section in config.yaml
+type BackfillConfig struct {
+	// Enabled starts the backfill scheduler on application start
+	Enabled bool `mapstructure:"enabled" yaml:"enabled"`
+	// MonthsBack is how many months before the current one are kept complete
+	MonthsBack int `mapstructure:"months_back" yaml:"months_back"`
+	// PaceMinutes is the minimum number of minutes between two month requests
+	PaceMinutes int `mapstructure:"pace_minutes" yaml:"pace_minutes"`
+	// Window is the daily time range of backfill runs ("02:00-06:00"),
+	// interpreted in the parser time zone
+	Window string `mapstructure:"window" yaml:"window"`
+}
+
+// RefreshConfig holds the schedule of the background refresh of the fights
+// Maps to the "refresh" section in config.yaml
+type RefreshConfig struct {
+	// IntervalMinutes is the time between the end of a refresh and the start
+	// of the next one; zero disables the refresher and requests parse the
+	// source on demand
+	IntervalMinutes int `mapstructure:"interval_minutes" yaml:"interval_minutes"`
+}
+
+// LoggingConfig holds the output of the application logs
+// Maps to the "logging" section in config.yaml
+type LoggingConfig struct {
+	// Level is the minimal level logged: debug, info, warn or error
+	Level string `mapstructure:"level" yaml:"level"`
+	// Format is text for development or json for log aggregation
+	Format string `mapstructure:"format" yaml:"format"`
+	// File receives the logs, appended; empty logs to stderr
+	File string `mapstructure:"file" yaml:"file"`
+}
+
+// ContractConfig holds the checks of the fight invariants between the layers
+// Maps to the "contract" section in config.yaml
+type ContractConfig struct {
+	// Enforce fails requests with 500 when fights break an invariant,
+	// otherwise violations are only logged and counted in /api/stats
+	Enforce bool `mapstructure:"enforce" yaml:"enforce"`
+}
+
+// CardsConfig holds the settings of the fight preview cards
+// (/api/fights/:id/card.png)
+// Maps to the "cards" section in config.yaml
+type CardsConfig struct {
+	// Background, Text and Accent are "#rrggbb" colors, checked when the
+	// renderer is created
+	Background string `mapstructure:"background" yaml:"background"`
+	Text       string `mapstructure:"text" yaml:"text"`
+	Accent     string `mapstructure:"accent" yaml:"accent"`
+	// MaxCached is the number of rendered cards kept in memory
+	MaxCached int `mapstructure:"max_cached" yaml:"max_cached"`
+	// CacheDir keeps rendered cards between restarts, empty keeps them in
+	// memory only
+	CacheDir string `mapstructure:"cache_dir" yaml:"cache_dir"`
+}
+
+// EmbedConfig holds the settings of the widget embedded by other sites
+// (/embed/upcoming, /api/oembed)
+// Maps to the "embed" section in config.yaml
+type EmbedConfig struct {
+	// AllowedAncestors are the origins allowed to show the widget in an
+	// iframe (CSP frame-ancestors), e.g. "https://example.com" or "*";
+	// checked by the API package
+	AllowedAncestors []string `mapstructure:"allowed_ancestors" yaml:"allowed_ancestors"`
+	// RateLimitPerMinute is the number of widget requests per client IP
+	RateLimitPerMinute int `mapstructure:"rate_limit_per_minute" yaml:"rate_limit_per_minute"`
+}
+
+// CheckSourceConfig holds the thresholds of the source compatibility check
+// (easypars check-source, /api/admin/check-source); checked by the parser
+// package
+// Maps to the "check_source" section in config.yaml
+type CheckSourceConfig struct {
+	// MinFights is the number of fights below which the source is incompatible
+	MinFights int `mapstructure:"min_fights" yaml:"min_fights"`
+	// DegradedValidShare and IncompatibleValidShare are the shares of valid
+	// fights below which the source is degraded or incompatible
+	DegradedValidShare     float64 `mapstructure:"degraded_valid_share" yaml:"degraded_valid_share"`
+	IncompatibleValidShare float64 `mapstructure:"incompatible_valid_share" yaml:"incompatible_valid_share"`
+}
+
+// CountriesConfig extends the built-in country dictionary used by the
+// country filters
+// Maps to the "countries" section in config.yaml
+type CountriesConfig struct {
+	// Extra adds countries or spellings of known ones (matched by iso2);
+	// checked when registered at startup
+	Extra []countries.Country `mapstructure:"extra" yaml:"extra"`
+}
+
+// CacheConfig holds how long the served fight data is considered fresh
+// Maps to the "cache" section in config.yaml
+type CacheConfig struct {
+	// TTLSeconds is the standard lifetime of the data and how long parses
+	// of the source are served from the fight cache
+	TTLSeconds int `mapstructure:"ttl_seconds" yaml:"ttl_seconds"`
+	// BroadcastStart is the typical "HH:MM" start of a fight broadcast in
+	// the source time zone; data of fight days expires at that time,
+	// checked by the snapshot package
+	BroadcastStart string `mapstructure:"broadcast_start" yaml:"broadcast_start"`
+	// Backend keeps the cached parses in process "memory" or in "redis",
+	// shared by the instances of a deployment (see the redis section)
+	Backend string `mapstructure:"backend" yaml:"backend"`
+}
+
+// RedisConfig holds the Redis connection of the redis cache backend
+// Maps to the "redis" section in config.yaml
+type RedisConfig struct {
+	Addr     string `mapstructure:"addr" yaml:"addr"`
+	Password string `mapstructure:"password" yaml:"password"`
+	DB       int    `mapstructure:"db" yaml:"db"`
+	// KeyPrefix is prepended to the cache keys, so deployments can share a
+	// Redis database
+	KeyPrefix string `mapstructure:"key_prefix" yaml:"key_prefix"`
+	// LockTTLSeconds bounds how long an instance parsing the source holds
+	// the lock the other instances wait on
+	LockTTLSeconds int `mapstructure:"lock_ttl_seconds" yaml:"lock_ttl_seconds"`
+}
+
+// ClockConfig holds the thresholds of the server clock check against the
+// Date header of the source responses
+// Maps to the "clock" section in config.yaml
+type ClockConfig struct {
+	// MaxSkewSeconds is the difference logged and reported as a warning
+	MaxSkewSeconds int `mapstructure:"max_skew_seconds" yaml:"max_skew_seconds"`
+	// CriticalSkewSeconds is the difference above which the clock is not
+	// used for dates and health reports degraded
+	CriticalSkewSeconds int `mapstructure:"critical_skew_seconds" yaml:"critical_skew_seconds"`
+}
+
+// HealthConfig holds the configuration of the readiness probe
+// Maps to the "health" section in config.yaml
+type HealthConfig struct {
+	// MaxParseAgeMinutes is how old the last successful parse may be before
+	// /api/health/ready fails
+	MaxParseAgeMinutes int `mapstructure:"max_parse_age_minutes" yaml:"max_parse_age_minutes"`
+	// CheckTimeoutMs bounds every readiness check
+	CheckTimeoutMs int `mapstructure:"check_timeout_ms" yaml:"check_timeout_ms"`
+	// CacheSeconds is how long a readiness result is reused
+	CacheSeconds int `mapstructure:"cache_seconds" yaml:"cache_seconds"`
+}
+
+// MetricsConfig holds the Prometheus metrics configuration
+// Maps to the "metrics" section in config.yaml
+type MetricsConfig struct {
+	// Enabled serves the metrics at /metrics
+	Enabled bool `mapstructure:"enabled" yaml:"enabled"`
+}
+
+// TracingConfig holds the OpenTelemetry tracing configuration
+// Maps to the "tracing" section in config.yaml
+type TracingConfig struct {
+	// Enabled exports the spans of the API and the parser
+	Enabled bool `mapstructure:"enabled" yaml:"enabled"`
+	// Endpoint is the host:port of the OTLP/HTTP collector
+	Endpoint string `mapstructure:"endpoint" yaml:"endpoint"`
+	// Insecure sends the spans over plain HTTP instead of HTTPS
+	Insecure bool `mapstructure:"insecure" yaml:"insecure"`
+	// SampleRatio is the share of the requests traced (0..1)
+	SampleRatio float64 `mapstructure:"sample_ratio" yaml:"sample_ratio"`
+	// ServiceName is the service.name of the spans
+	ServiceName string `mapstructure:"service_name" yaml:"service_name"`
+}
+
+// RetentionConfig holds the retention periods of auxiliary data
+// Maps to the "retention" section in config.yaml
+// Fight records and the active snapshot are never deleted by retention
+type RetentionConfig struct {
+	// Enabled starts the daily cleanup on application start
+	Enabled bool `mapstructure:"enabled" yaml:"enabled"`
+	// Window is the daily time range of the cleanup ("03:00-05:00"),
+	// interpreted in the parser time zone
+	Window string `mapstructure:"window" yaml:"window"`
+	// SnapshotsDays is how long a pending (rejected) snapshot is kept
+	SnapshotsDays int `mapstructure:"snapshots_days" yaml:"snapshots_days"`
+	// ParseHistoryDays is how long finished parse runs are kept
+	ParseHistoryDays int `mapstructure:"parse_history_days" yaml:"parse_history_days"`
+}
+
+// ScoringConfig holds the interest score settings of upcoming fights
+// Maps to the "scoring" section in config.yaml
+type ScoringConfig struct {
+	Weights ScoringWeights `mapstructure:"weights" yaml:"weights"`
+}
+
+// ScoringWeights sets the contribution of each interest factor
+// Every factor is scaled to 0..1, so the weights are the maximum points
+type ScoringWeights struct {
+	Wins       float64 `mapstructure:"wins" yaml:"wins"`
+	Title      float64 `mapstructure:"title" yaml:"title"`
+	MainEvent  float64 `mapstructure:"main_event" yaml:"main_event"`
+	Unbeaten   float64 `mapstructure:"unbeaten" yaml:"unbeaten"`
+	Rematch    float64 `mapstructure:"rematch" yaml:"rematch"`
+	Popularity float64 `mapstructure:"popularity" yaml:"popularity"`
+}
+
+// DatabaseConfig holds the PostgreSQL connection of the postgres storage
+// Maps to the "database" section in config.yaml
+type DatabaseConfig struct {
+	Host     string `mapstructure:"host" yaml:"host"`
+	Port     int    `mapstructure:"port" yaml:"port"`
+	User     string `mapstructure:"user" yaml:"user"`
+	Password string `mapstructure:"password" yaml:"password"`
+	DBName   string `mapstructure:"dbname" yaml:"dbname"`
+	// SSLMode is the libpq sslmode, e.g. disable, require or verify-full
+	SSLMode string `mapstructure:"sslmode" yaml:"sslmode"`
+	// MaxConnections bounds the open connections of the pool
+	MaxConnections int `mapstructure:"max_connections" yaml:"max_connections"`
+	// ConnectTimeoutSeconds bounds the connection check on start
+	ConnectTimeoutSeconds int `mapstructure:"connect_timeout_seconds" yaml:"connect_timeout_seconds"`
+}
+
+// ParserConfig holds parser configuration
+// Maps to the "parser" section in config.yaml
+type ParserConfig struct {
+	BaseURL string `mapstructure:"base_url" yaml:"base_url"`
+	// MonthURL is the monthly archive URL template with {year} and {month}
+	MonthURL string `mapstructure:"month_url" yaml:"month_url"`
+	// PageURL is the URL template of the later result pages with {page},
+	// needed by ?pages on /api/fights
+	PageURL string `mapstructure:"page_url" yaml:"page_url"`
+	// AllowedHosts lists the hosts the source may redirect to,
+	// the hosts of base_url, month_url and page_url are allowed when empty
+	AllowedHosts []string `mapstructure:"allowed_hosts" yaml:"allowed_hosts"`
+	// Timezone is the time zone of the source site (IANA name)
+	Timezone string `mapstructure:"timezone" yaml:"timezone"`
+	// Timeout is the HTTP timeout in seconds
+	Timeout int `mapstructure:"timeout" yaml:"timeout"`
+	// VolatilePatterns are regular expressions of page fragments that change
+	// on every request and are ignored by the content fingerprint
+	VolatilePatterns []string `mapstructure:"volatile_patterns" yaml:"volatile_patterns"`
+	// FingerprintFile keeps the last page fingerprints between restarts,
+	// empty keeps them in memory only
+	FingerprintFile string `mapstructure:"fingerprint_file" yaml:"fingerprint_file"`
+	// SourcesFile keeps the extra sources added through the admin API,
+	// empty keeps them in memory only
+	SourcesFile string `mapstructure:"sources_file" yaml:"sources_file"`
+	// ParseComments enables extraction of fights hidden in HTML comments
+	ParseComments bool `mapstructure:"parse_comments" yaml:"parse_comments"`
+	// ColumnInvalidThreshold is the share of unexpected cell values above
+	// which a column is reported as degraded
+	ColumnInvalidThreshold float64 `mapstructure:"column_invalid_threshold" yaml:"column_invalid_threshold"`
+	// StaleTBDDays is the age in days after which a fight without a result
+	// is reported as result_unknown instead of scheduled
+	StaleTBDDays int `mapstructure:"stale_tbd_days" yaml:"stale_tbd_days"`
+	// RateLimitPauseSeconds is how long all requests to the source pause after
+	// a 429 response without Retry-After; repeated 429 responses double it
+	RateLimitPauseSeconds int `mapstructure:"rate_limit_pause_seconds" yaml:"rate_limit_pause_seconds"`
+	// MaxBodyMB bounds a decoded page in megabytes, so a compressed
+	// response cannot blow up memory
+	MaxBodyMB int `mapstructure:"max_body_mb" yaml:"max_body_mb"`
+	// RequestsPerSecond limits the requests of the parser to the source,
+	// 0 disables the limit
+	RequestsPerSecond float64 `mapstructure:"requests_per_second" yaml:"requests_per_second"`
+	// RetryAttempts is the number of retries of a fetch failing with a
+	// network error, a 5xx or a 429 response; 0 disables retries
+	RetryAttempts int `mapstructure:"retry_attempts" yaml:"retry_attempts"`
+	// RetryBaseDelayMs is the first backoff delay between retries, doubled
+	// on every retry
+	RetryBaseDelayMs int `mapstructure:"retry_base_delay_ms" yaml:"retry_base_delay_ms"`
+	// SourceWorkers is the number of extra sources fetched at the same time
+	SourceWorkers int `mapstructure:"source_workers" yaml:"source_workers"`
+	// PostProcessors configures the post-processing stages
+	PostProcessors PostProcessorsConfig `mapstructure:"postprocessors" yaml:"postprocessors"`
+	// ExternalIDs maps fighters the source does not link to external databases
+	ExternalIDs []ExternalIDMapping `mapstructure:"external_ids" yaml:"external_ids"`
+
+	// Future parser configuration fields:
+	// ConcurrentWorkers int `mapstructure:"concurrent_workers" yaml:"concurrent_workers"`
+}
+
+// ExternalIDMapping assigns an external ID to a fighter by name
+type ExternalIDMapping struct {
+	// Name is the fighter name as written in the source
+	Name string `mapstructure:"name" yaml:"name"`
+	// ID is the external reference as "source:id" (boxrec:447121)
+	ID string `mapstructure:"id" yaml:"id"`
+}
+
+// FighterExternalIDs returns the external ID mappings by normalized fighter name
+func (c ParserConfig) FighterExternalIDs() map[string]map[string]string {
+	mapped := make(map[string]map[string]string)
+	for _, mapping := range c.ExternalIDs {
+		source, id, ok := models.ParseExternalID(mapping.ID)
+		if !ok {
+			continue
+		}
+		name := models.NormalizeName(mapping.Name)
+		if mapped[name] == nil {
+			mapped[name] = make(map[string]string)
+		}
+		mapped[name][source] = id
+	}
+
+	return mapped
+}
+
+// JWTConfig holds the authentication of the admin endpoints
+// Maps to the "jwt" section in config.yaml
+// An empty secret disables the admin endpoints.
+type JWTConfig struct {
+	// Secret signs the tokens, at least minJWTSecretLength bytes
+	Secret      string `mapstructure:"secret" yaml:"secret"`
+	ExpireHours int    `mapstructure:"expire_hours" yaml:"expire_hours"`
+	Issuer      string `mapstructure:"issuer" yaml:"issuer"`
+	// AdminUsername and AdminPasswordHash (bcrypt, see easypars
+	// hash-password) are the credentials of POST /api/auth/login
+	AdminUsername     string `mapstructure:"admin_username" yaml:"admin_username"`
+	AdminPasswordHash string `mapstructure:"admin_password_hash" yaml:"admin_password_hash"`
+}
+
+// minJWTSecretLength is the shortest accepted JWT secret, the size of the
+// HS256 hash
+const minJWTSecretLength = 32
 
 // LoadConfig loads configuration from config.yaml using Viper
 // This function initializes Viper, sets up configuration sources, and loads the config
@@ -88,6 +641,7 @@ func LoadConfig() (*Config, error) {
 	// This allows overriding config values with environment variables
 	// Format: EASYPARS_SERVER_PORT will override server.port
 	v.SetEnvPrefix("EASYPARS")
+	v.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
 	v.AutomaticEnv()
 
 	// Set default values for critical configurations
@@ -100,14 +654,14 @@ func LoadConfig() (*Config, error) {
 		// Handle different types of configuration errors
 		if _, ok := err.(viper.ConfigFileNotFoundError); ok {
 			// Config file not found - use defaults and log a warning
-			log.Printf("Warning: Config file not found, using default values")
+			slog.Warn("Config file not found, using default values")
 		} else {
 			// Config file found but there's an error reading it
 			return nil, fmt.Errorf("error reading config file: %w", err)
 		}
 	} else {
 		// Successfully loaded config file
-		log.Printf("Config file loaded: %s", v.ConfigFileUsed())
+		slog.Info("Config file loaded", "path", v.ConfigFileUsed())
 	}
 
 	// Create a new Config instance to hold the loaded configuration
@@ -126,7 +680,7 @@ func LoadConfig() (*Config, error) {
 	}
 
 	// Log successful configuration loading
-	log.Printf("Configuration loaded successfully - Server will start on port: %s", config.Server.Port)
+	slog.Info("Configuration loaded successfully", "port", config.Server.Port)
 
 	return &config, nil
 }
@@ -136,18 +690,187 @@ func LoadConfig() (*Config, error) {
 func setDefaultValues(v *viper.Viper) {
 	// Server defaults
 	v.SetDefault("server.port", "8080")
+	v.SetDefault("server.init_timeout_seconds", 30)
+	v.SetDefault("server.shutdown_timeout_seconds", 20)
+	v.SetDefault("server.trusted_proxies", []string{})
+
+	// API defaults
+	v.SetDefault("api.fast_response_budget_ms", 2000)
+	v.SetDefault("api.max_concurrent_requests", 256)
+	v.SetDefault("api.max_concurrent_probes", 512)
+	v.SetDefault("api.queue_timeout_ms", 1000)
+	// Registered so EASYPARS_API_API_KEY overrides it without a config entry
+	v.SetDefault("api.api_key", "")
+	v.SetDefault("api.rate_limit.requests_per_second", 10)
+	v.SetDefault("api.rate_limit.burst", 30)
+	v.SetDefault("api.api_keys", []APIKeyConfig{})
+	v.SetDefault("api.allow_anonymous", true)
+	v.SetDefault("api.anonymous_requests_per_minute", 0)
+	// Same as api.DefaultExportMaxRows
+	v.SetDefault("api.export_max_rows", 100000)
+	// Same as api.DefaultFeedItems
+	v.SetDefault("api.feed_items", 50)
+	// Same as api.DefaultCostThresholds
+	v.SetDefault("api.cost_thresholds.moderate_requests", 3)
+	v.SetDefault("api.cost_thresholds.moderate_seconds", 5)
+	v.SetDefault("api.cost_thresholds.expensive_requests", 10)
+	v.SetDefault("api.cost_thresholds.expensive_seconds", 30)
+
+	// Storage defaults
+	v.SetDefault("storage.type", "sqlite")
+	v.SetDefault("storage.path", "./easypars.db")
+	v.SetDefault("storage.busy_timeout_ms", 5000)
+	v.SetDefault("storage.checksum", false)
+	v.SetDefault("storage.allow_recovery", false)
+	v.SetDefault("storage.missing_grace_days", 7)
+
+	// Set database defaults
+	v.SetDefault("database.host", "localhost")
+	v.SetDefault("database.port", 5432)
+	v.SetDefault("database.user", "easypars")
+	v.SetDefault("database.password", "")
+	v.SetDefault("database.dbname", "easypars")
+	v.SetDefault("database.sslmode", "disable")
+	v.SetDefault("database.max_connections", 10)
+	v.SetDefault("database.connect_timeout_seconds", 5)
+
+	// Parser defaults
+	v.SetDefault("parser.base_url", "https://vringe.com/results/")
+	v.SetDefault("parser.timezone", "Europe/Moscow")
+	v.SetDefault("parser.timeout", 30)
+	v.SetDefault("parser.fingerprint_file", "fingerprints.json")
+	v.SetDefault("parser.sources_file", "sources.json")
+	v.SetDefault("parser.parse_comments", false)
+	v.SetDefault("parser.stale_tbd_days", 14)
+	v.SetDefault("parser.rate_limit_pause_seconds", 300)
+	v.SetDefault("parser.max_body_mb", 10)
+	v.SetDefault("parser.requests_per_second", 2)
+	v.SetDefault("parser.retry_attempts", 2)
+	v.SetDefault("parser.retry_base_delay_ms", 500)
+	v.SetDefault("parser.source_workers", 4)
+	v.SetDefault("parser.column_invalid_threshold", 0.3)
+	v.SetDefault("parser.postprocessors.on_error", "skip")
+
+	// Snapshot guard defaults
+	v.SetDefault("snapshot.min_ratio", 0.5)
+	v.SetDefault("snapshot.max_quality_drop", 20)
+	v.SetDefault("snapshot.location_aliases_file", "location_aliases.yaml")
+	v.SetDefault("snapshot.precompute", []string{})
+
+	// Preset defaults
+	v.SetDefault("presets.max_presets", 1000)
+	v.SetDefault("presets.file", "presets.json")
+	v.SetDefault("news.file", "")
+
+	// Webhooks defaults, the same as the webhook package defaults
+	v.SetDefault("webhooks.enabled", false)
+	v.SetDefault("webhooks.file", "webhooks.json")
+	v.SetDefault("webhooks.timeout_ms", 5000)
+	v.SetDefault("webhooks.max_attempts", 3)
+	v.SetDefault("webhooks.retry_backoff_ms", 1000)
+	v.SetDefault("webhooks.log_size", 500)
+	v.SetDefault("webhooks.allowed_hosts", []string{})
+
+	// Stream defaults, the same as the stream package defaults
+	v.SetDefault("stream.max_clients", 1000)
+	v.SetDefault("stream.client_buffer", 256)
+	v.SetDefault("stream.heartbeat_seconds", 15)
+
+	// Parse history defaults
+	v.SetDefault("history.max_runs", 50)
+	v.SetDefault("history.max_log_entries", 500)
+
+	// Backfill defaults
+	v.SetDefault("backfill.enabled", false)
+	v.SetDefault("backfill.months_back", 24)
+	v.SetDefault("backfill.pace_minutes", 30)
+	v.SetDefault("backfill.window", "02:00-06:00")
+
+	// Refresh defaults
+	v.SetDefault("refresh.interval_minutes", 10)
+
+	// Logging defaults
+	v.SetDefault("logging.level", "info")
+	v.SetDefault("logging.format", "text")
+	v.SetDefault("logging.file", "")
+
+	// Contract defaults
+	v.SetDefault("contract.enforce", false)
+
+	// Health defaults
+	v.SetDefault("health.max_parse_age_minutes", 360)
+	v.SetDefault("health.check_timeout_ms", 1000)
+	v.SetDefault("health.cache_seconds", 5)
+
+	// Metrics defaults
+	v.SetDefault("metrics.enabled", true)
+
+	// Tracing defaults
+	v.SetDefault("tracing.enabled", false)
+	v.SetDefault("tracing.endpoint", "localhost:4318")
+	v.SetDefault("tracing.insecure", true)
+	v.SetDefault("tracing.sample_ratio", 0.1)
+	v.SetDefault("tracing.service_name", "easypars")
+
+	// Retention defaults
+	v.SetDefault("retention.enabled", true)
+	v.SetDefault("retention.window", "03:00-05:00")
+	v.SetDefault("retention.snapshots_days", 7)
+	v.SetDefault("retention.parse_history_days", 30)
+
+	// Card defaults (same as ogcard.DefaultColors)
+	v.SetDefault("cards.background", "#1b1f3b")
+	v.SetDefault("cards.text", "#ffffff")
+	v.SetDefault("cards.accent", "#e63946")
+	v.SetDefault("cards.max_cached", 500)
+	v.SetDefault("cards.cache_dir", "")
+
+	// Cache defaults (same as snapshot.DefaultBroadcastStart)
+	v.SetDefault("cache.ttl_seconds", 600)
+	v.SetDefault("cache.broadcast_start", "19:00")
+	v.SetDefault("cache.backend", "memory")
+
+	// JWT defaults, authentication stays disabled without a secret
+	v.SetDefault("jwt.secret", "")
+	v.SetDefault("jwt.expire_hours", 24)
+	v.SetDefault("jwt.issuer", "easypars")
+	v.SetDefault("jwt.admin_username", "admin")
+	v.SetDefault("jwt.admin_password_hash", "")
+
+	// Redis defaults
+	v.SetDefault("redis.addr", "localhost:6379")
+	v.SetDefault("redis.password", "")
+	v.SetDefault("redis.db", 0)
+	v.SetDefault("redis.key_prefix", "easypars:")
+	v.SetDefault("redis.lock_ttl_seconds", 60)
+
+	// Source check defaults (same as parser.DefaultCompatThresholds)
+	v.SetDefault("check_source.min_fights", 1)
+	v.SetDefault("check_source.degraded_valid_share", 0.9)
+	v.SetDefault("check_source.incompatible_valid_share", 0.5)
+
+	// Embed defaults: any site may show the widget
+	v.SetDefault("embed.allowed_ancestors", []string{"*"})
+	v.SetDefault("embed.rate_limit_per_minute", 120)
+
+	// Clock check defaults (same as clock.DefaultMaxSkew and DefaultCriticalSkew)
+	v.SetDefault("clock.max_skew_seconds", 600)
+	v.SetDefault("clock.critical_skew_seconds", 86400)
+
+	// Scoring defaults (same as stats.DefaultWeights)
+	v.SetDefault("scoring.weights.wins", 30)
+	v.SetDefault("scoring.weights.title", 25)
+	v.SetDefault("scoring.weights.main_event", 15)
+	v.SetDefault("scoring.weights.unbeaten", 15)
+	v.SetDefault("scoring.weights.rematch", 10)
+	v.SetDefault("scoring.weights.popularity", 5)
 
 	// Future default values to be added:
 	// v.SetDefault("server.host", "localhost")
 	// v.SetDefault("server.read_timeout", 30)
 	// v.SetDefault("server.write_timeout", 30)
-	// v.SetDefault("database.host", "localhost")
-	// v.SetDefault("database.port", 5432)
-	// v.SetDefault("parser.base_url", "https://vringe.com/results/")
 	// v.SetDefault("parser.rate_limit", 5)
-	// v.SetDefault("parser.timeout", 30)
 	// v.SetDefault("parser.concurrent_workers", 3)
-	// v.SetDefault("jwt.expire_hours", 24)
 }
 
 // validateConfig validates the loaded configuration
@@ -162,16 +885,340 @@ func validateConfig(config *Config) error {
 	if !isValidPort(config.Server.Port) {
 		return fmt.Errorf("invalid server port format: %s", config.Server.Port)
 	}
+	if config.Server.InitTimeoutSeconds <= 0 {
+		return fmt.Errorf("server init_timeout_seconds must be positive, got %d", config.Server.InitTimeoutSeconds)
+	}
+	if config.Server.ShutdownTimeoutSeconds <= 0 {
+		return fmt.Errorf("server shutdown_timeout_seconds must be positive, got %d", config.Server.ShutdownTimeoutSeconds)
+	}
+	for _, proxy := range config.Server.TrustedProxies {
+		if _, _, err := net.ParseCIDR(proxy); err != nil && net.ParseIP(proxy) == nil {
+			return fmt.Errorf("server trusted_proxies: %s is not an IP or CIDR", proxy)
+		}
+	}
+
+	// Validate API configuration
+	if config.API.FastResponseBudgetMs <= 0 {
+		return fmt.Errorf("api fast_response_budget_ms must be positive, got %d", config.API.FastResponseBudgetMs)
+	}
+	if config.API.MaxConcurrentRequests < 0 {
+		return fmt.Errorf("api max_concurrent_requests must not be negative, got %d", config.API.MaxConcurrentRequests)
+	}
+	if config.API.MaxConcurrentProbes <= 0 {
+		return fmt.Errorf("api max_concurrent_probes must be positive, got %d", config.API.MaxConcurrentProbes)
+	}
+	if config.API.QueueTimeoutMs <= 0 {
+		return fmt.Errorf("api queue_timeout_ms must be positive, got %d", config.API.QueueTimeoutMs)
+	}
+	if config.API.RateLimit.RequestsPerSecond < 0 {
+		return fmt.Errorf("api rate_limit requests_per_second must not be negative, got %v", config.API.RateLimit.RequestsPerSecond)
+	}
+	if config.API.RateLimit.RequestsPerSecond > 0 && config.API.RateLimit.Burst < 1 {
+		return fmt.Errorf("api rate_limit burst must be positive, got %d", config.API.RateLimit.Burst)
+	}
+	if err := validateAPIKeys(config.API); err != nil {
+		return err
+	}
+	// An XLSX sheet holds 1048576 rows, the header included
+	if config.API.ExportMaxRows < 1 || config.API.ExportMaxRows > 1048575 {
+		return fmt.Errorf("api export_max_rows must be between 1 and 1048575, got %d", config.API.ExportMaxRows)
+	}
+	if config.API.FeedItems < 1 || config.API.FeedItems > 1000 {
+		return fmt.Errorf("api feed_items must be between 1 and 1000, got %d", config.API.FeedItems)
+	}
+
+	// Validate storage configuration
+	switch config.Storage.Type {
+	case "none":
+	case "sqlite":
+		if config.Storage.Path == "" {
+			return fmt.Errorf("storage path is required for sqlite storage")
+		}
+	case "postgres":
+		if err := validateDatabase(config.Database); err != nil {
+			return err
+		}
+	case "file":
+		if config.Storage.Path == "" {
+			return fmt.Errorf("storage path is required for file storage")
+		}
+	default:
+		return fmt.Errorf("unsupported storage type: %s", config.Storage.Type)
+	}
+	if config.Storage.MissingGraceDays <= 0 {
+		return fmt.Errorf("storage missing_grace_days must be positive, got %d", config.Storage.MissingGraceDays)
+	}
+
+	// Validate parser configuration
+	if config.Parser.BaseURL == "" {
+		return fmt.Errorf("parser base_url is required")
+	}
+	if config.Parser.Timeout <= 0 {
+		return fmt.Errorf("parser timeout must be positive, got %d", config.Parser.Timeout)
+	}
+	if _, err := time.LoadLocation(config.Parser.Timezone); err != nil {
+		return fmt.Errorf("invalid parser timezone %s: %w", config.Parser.Timezone, err)
+	}
+
+	if config.Parser.PageURL != "" && !strings.Contains(config.Parser.PageURL, "{page}") {
+		return fmt.Errorf("parser page_url must contain {page}, got %s", config.Parser.PageURL)
+	}
+	if t := config.Parser.ColumnInvalidThreshold; t <= 0 || t > 1 {
+		return fmt.Errorf("parser column_invalid_threshold must be in (0, 1], got %v", t)
+	}
+	if config.Parser.StaleTBDDays <= 0 {
+		return fmt.Errorf("parser stale_tbd_days must be positive, got %d", config.Parser.StaleTBDDays)
+	}
+	if config.Parser.RateLimitPauseSeconds <= 0 {
+		return fmt.Errorf("parser rate_limit_pause_seconds must be positive, got %d", config.Parser.RateLimitPauseSeconds)
+	}
+	if config.Parser.MaxBodyMB <= 0 {
+		return fmt.Errorf("parser max_body_mb must be positive, got %d", config.Parser.MaxBodyMB)
+	}
+	if config.Parser.RequestsPerSecond < 0 {
+		return fmt.Errorf("parser requests_per_second must not be negative, got %v", config.Parser.RequestsPerSecond)
+	}
+	if config.Parser.RetryAttempts < 0 {
+		return fmt.Errorf("parser retry_attempts must not be negative, got %d", config.Parser.RetryAttempts)
+	}
+	if config.Parser.RetryBaseDelayMs <= 0 {
+		return fmt.Errorf("parser retry_base_delay_ms must be positive, got %d", config.Parser.RetryBaseDelayMs)
+	}
+	if config.Parser.SourceWorkers <= 0 {
+		return fmt.Errorf("parser source_workers must be positive, got %d", config.Parser.SourceWorkers)
+	}
+	if onError := config.Parser.PostProcessors.OnError; onError != "skip" && onError != "fail" {
+		return fmt.Errorf("parser postprocessors on_error must be skip or fail, got %s", onError)
+	}
+	for i, mapping := range config.Parser.ExternalIDs {
+		if models.NormalizeName(mapping.Name) == "" {
+			return fmt.Errorf("parser external_ids[%d] name is required", i)
+		}
+		if _, _, ok := models.ParseExternalID(mapping.ID); !ok {
+			return fmt.Errorf("parser external_ids[%d] id must be source:id, got %q", i, mapping.ID)
+		}
+	}
+
+	// Validate snapshot guard configuration
+	if config.Snapshot.MinRatio < 0 || config.Snapshot.MinRatio > 1 {
+		return fmt.Errorf("snapshot min_ratio must be between 0 and 1, got %v", config.Snapshot.MinRatio)
+	}
+	if config.Snapshot.MaxQualityDrop < 0 {
+		return fmt.Errorf("snapshot max_quality_drop must not be negative, got %v", config.Snapshot.MaxQualityDrop)
+	}
+
+	// Validate presets configuration
+	if config.Presets.MaxPresets <= 0 {
+		return fmt.Errorf("presets max_presets must be positive, got %d", config.Presets.MaxPresets)
+	}
+
+	// Validate webhooks configuration
+	if config.Webhooks.Enabled {
+		if config.Webhooks.TimeoutMs <= 0 {
+			return fmt.Errorf("webhooks timeout_ms must be positive, got %d", config.Webhooks.TimeoutMs)
+		}
+		if config.Webhooks.MaxAttempts < 1 || config.Webhooks.MaxAttempts > 10 {
+			return fmt.Errorf("webhooks max_attempts must be between 1 and 10, got %d", config.Webhooks.MaxAttempts)
+		}
+		if config.Webhooks.RetryBackoffMs <= 0 {
+			return fmt.Errorf("webhooks retry_backoff_ms must be positive, got %d", config.Webhooks.RetryBackoffMs)
+		}
+		if config.Webhooks.LogSize <= 0 {
+			return fmt.Errorf("webhooks log_size must be positive, got %d", config.Webhooks.LogSize)
+		}
+		for _, host := range config.Webhooks.AllowedHosts {
+			if strings.TrimSpace(host) == "" {
+				return fmt.Errorf("webhooks allowed_hosts must not contain empty entries")
+			}
+		}
+	}
+
+	// Validate stream configuration
+	if config.Stream.MaxClients <= 0 {
+		return fmt.Errorf("stream max_clients must be positive, got %d", config.Stream.MaxClients)
+	}
+	if config.Stream.ClientBuffer <= 0 {
+		return fmt.Errorf("stream client_buffer must be positive, got %d", config.Stream.ClientBuffer)
+	}
+	if config.Stream.HeartbeatSeconds <= 0 {
+		return fmt.Errorf("stream heartbeat_seconds must be positive, got %d", config.Stream.HeartbeatSeconds)
+	}
+
+	// Validate history configuration
+	if config.History.MaxRuns <= 0 {
+		return fmt.Errorf("history max_runs must be positive, got %d", config.History.MaxRuns)
+	}
+	if config.History.MaxLogEntries < 0 {
+		return fmt.Errorf("history max_log_entries must not be negative, got %d", config.History.MaxLogEntries)
+	}
+
+	// Validate backfill configuration
+	// The window format is checked when the scheduler is created
+	if config.Backfill.Enabled {
+		if config.Storage.Type == "none" {
+			return fmt.Errorf("backfill requires persistent storage")
+		}
+		if config.Parser.MonthURL == "" {
+			return fmt.Errorf("backfill requires parser month_url")
+		}
+	}
+	if config.Backfill.MonthsBack <= 0 {
+		return fmt.Errorf("backfill months_back must be positive, got %d", config.Backfill.MonthsBack)
+	}
+	if config.Backfill.PaceMinutes < 0 {
+		return fmt.Errorf("backfill pace_minutes must not be negative, got %d", config.Backfill.PaceMinutes)
+	}
+
+	// Validate the refresh schedule
+	if config.Refresh.IntervalMinutes < 0 {
+		return fmt.Errorf("refresh interval_minutes must not be negative, got %d", config.Refresh.IntervalMinutes)
+	}
+
+	// Validate the log output
+	switch config.Logging.Level {
+	case "debug", "info", "warn", "error":
+	default:
+		return fmt.Errorf("logging level must be debug, info, warn or error, got %q", config.Logging.Level)
+	}
+	if config.Logging.Format != "text" && config.Logging.Format != "json" {
+		return fmt.Errorf("logging format must be text or json, got %q", config.Logging.Format)
+	}
+
+	// Validate readiness probe configuration
+	if config.Health.MaxParseAgeMinutes <= 0 {
+		return fmt.Errorf("health max_parse_age_minutes must be positive, got %d", config.Health.MaxParseAgeMinutes)
+	}
+	if config.Health.CheckTimeoutMs <= 0 {
+		return fmt.Errorf("health check_timeout_ms must be positive, got %d", config.Health.CheckTimeoutMs)
+	}
+	if config.Health.CacheSeconds <= 0 {
+		return fmt.Errorf("health cache_seconds must be positive, got %d", config.Health.CacheSeconds)
+	}
+
+	// Validate tracing configuration
+	if config.Tracing.Enabled {
+		if config.Tracing.Endpoint == "" {
+			return fmt.Errorf("tracing endpoint is required when tracing is enabled")
+		}
+		if r := config.Tracing.SampleRatio; r < 0 || r > 1 {
+			return fmt.Errorf("tracing sample_ratio must be in [0, 1], got %v", r)
+		}
+		if config.Tracing.ServiceName == "" {
+			return fmt.Errorf("tracing service_name is required when tracing is enabled")
+		}
+	}
+
+	// Validate retention periods, zero keeps the data forever
+	// The window format is checked when the runner is created
+	if config.Retention.SnapshotsDays < 0 {
+		return fmt.Errorf("retention snapshots_days must not be negative, got %d", config.Retention.SnapshotsDays)
+	}
+	if config.Retention.ParseHistoryDays < 0 {
+		return fmt.Errorf("retention parse_history_days must not be negative, got %d", config.Retention.ParseHistoryDays)
+	}
+
+	// Validate the card cache size
+	if config.Cards.MaxCached <= 0 {
+		return fmt.Errorf("cards max_cached must be positive, got %d", config.Cards.MaxCached)
+	}
+
+	// Validate the data lifetime
+	if config.Cache.TTLSeconds <= 0 {
+		return fmt.Errorf("cache ttl_seconds must be positive, got %d", config.Cache.TTLSeconds)
+	}
+	switch config.Cache.Backend {
+	case "memory":
+	case "redis":
+		if config.Redis.Addr == "" {
+			return fmt.Errorf("redis addr is required for the redis cache backend")
+		}
+		if config.Redis.DB < 0 {
+			return fmt.Errorf("redis db must not be negative, got %d", config.Redis.DB)
+		}
+		if config.Redis.LockTTLSeconds <= 0 {
+			return fmt.Errorf("redis lock_ttl_seconds must be positive, got %d", config.Redis.LockTTLSeconds)
+		}
+	default:
+		return fmt.Errorf("unsupported cache backend: %s", config.Cache.Backend)
+	}
+
+	// Validate the widget rate limit
+	if config.Embed.RateLimitPerMinute <= 0 {
+		return fmt.Errorf("embed rate_limit_per_minute must be positive, got %d", config.Embed.RateLimitPerMinute)
+	}
+
+	// Validate the clock check thresholds
+	if config.Clock.MaxSkewSeconds <= 0 {
+		return fmt.Errorf("clock max_skew_seconds must be positive, got %d", config.Clock.MaxSkewSeconds)
+	}
+	if config.Clock.CriticalSkewSeconds <= config.Clock.MaxSkewSeconds {
+		return fmt.Errorf("clock critical_skew_seconds must be greater than max_skew_seconds (%d), got %d",
+			config.Clock.MaxSkewSeconds, config.Clock.CriticalSkewSeconds)
+	}
+
+	// Validate scoring weights
+	weights := config.Scoring.Weights
+	for name, weight := range map[string]float64{
+		"wins": weights.Wins, "title": weights.Title, "main_event": weights.MainEvent,
+		"unbeaten": weights.Unbeaten, "rematch": weights.Rematch, "popularity": weights.Popularity,
+	} {
+		if weight < 0 {
+			return fmt.Errorf("scoring weight %s must not be negative, got %g", name, weight)
+		}
+	}
+
+	// Validate the admin authentication
+	if config.JWT.Secret != "" {
+		if len(config.JWT.Secret) < minJWTSecretLength {
+			return fmt.Errorf("jwt secret must be at least %d bytes long", minJWTSecretLength)
+		}
+		if config.JWT.ExpireHours <= 0 {
+			return fmt.Errorf("jwt expire_hours must be positive, got %d", config.JWT.ExpireHours)
+		}
+		if config.JWT.Issuer == "" {
+			return fmt.Errorf("jwt issuer is required")
+		}
+		if config.JWT.AdminUsername == "" {
+			return fmt.Errorf("jwt admin_username is required")
+		}
+		if _, err := bcrypt.Cost([]byte(config.JWT.AdminPasswordHash)); err != nil {
+			return fmt.Errorf("jwt admin_password_hash must be a bcrypt hash: %w", err)
+		}
+	}
 
 	// Future validation to be added:
-	// - Database connection parameters
 	// - Parser URL format validation
-	// - JWT secret strength validation
 	// - File path existence checks
 
 	return nil
 }
 
+// validateDatabase checks the connection parameters of the postgres storage
+func validateDatabase(db DatabaseConfig) error {
+	if db.Host == "" {
+		return fmt.Errorf("database host is required for postgres storage")
+	}
+	if db.Port <= 0 || db.Port > 65535 {
+		return fmt.Errorf("database port must be within 1..65535, got %d", db.Port)
+	}
+	if db.User == "" || db.DBName == "" {
+		return fmt.Errorf("database user and dbname are required for postgres storage")
+	}
+	switch db.SSLMode {
+	case "disable", "allow", "prefer", "require", "verify-ca", "verify-full":
+	default:
+		return fmt.Errorf("unsupported database sslmode: %s", db.SSLMode)
+	}
+	if db.MaxConnections <= 0 {
+		return fmt.Errorf("database max_connections must be positive, got %d", db.MaxConnections)
+	}
+	if db.ConnectTimeoutSeconds <= 0 {
+		return fmt.Errorf("database connect_timeout_seconds must be positive, got %d", db.ConnectTimeoutSeconds)
+	}
+
+	return nil
+}
+
 // isValidPort checks if the port string is in a valid format
 // Accepts formats like "8080", ":8080", or "localhost:8080"
 func isValidPort(port string) bool {
@@ -220,3 +1267,39 @@ func ReloadConfig() (*Config, error) {
 // - ExportConfig(*Config) - for exporting current config to file
 // - EncryptSensitiveFields(*Config) - for encrypting passwords/secrets
 // - ValidateEnvironment() - for environment-specific validations
+
+// validateAPIKeys checks the API keys of the read endpoints
+// Keys and names must be unique, since the name identifies the rate limit
+// of a key.
+func validateAPIKeys(api APIConfig) error {
+	names := make(map[string]bool)
+	keys := make(map[string]bool)
+	for i, apiKey := range api.APIKeys {
+		if apiKey.Key == "" {
+			return fmt.Errorf("api api_keys[%d] key is required", i)
+		}
+		if apiKey.Name == "" {
+			return fmt.Errorf("api api_keys[%d] name is required", i)
+		}
+		if names[apiKey.Name] {
+			return fmt.Errorf("api api_keys[%d] name %s is used twice", i, apiKey.Name)
+		}
+		if keys[apiKey.Key] || apiKey.Key == api.APIKey {
+			return fmt.Errorf("api api_keys[%d] (%s) key is used twice", i, apiKey.Name)
+		}
+		if apiKey.RequestsPerMinute < 0 {
+			return fmt.Errorf("api api_keys[%d] (%s) requests_per_minute must not be negative, got %d", i, apiKey.Name, apiKey.RequestsPerMinute)
+		}
+		names[apiKey.Name] = true
+		keys[apiKey.Key] = true
+	}
+
+	if api.AnonymousRequestsPerMinute < 0 {
+		return fmt.Errorf("api anonymous_requests_per_minute must not be negative, got %d", api.AnonymousRequestsPerMinute)
+	}
+	if !api.AllowAnonymous && len(api.APIKeys) == 0 && api.APIKey == "" {
+		return fmt.Errorf("api allow_anonymous is false but no api_keys are configured")
+	}
+
+	return nil
+}
@@ -0,0 +1,96 @@
+package contract
+
+import (
+	"fmt"
+	"log/slog"
+	"sync"
+
+	"easypars/models"
+)
+
+// Layer boundaries where fights are checked
+const (
+	// BoundaryParser is the output of the parser
+	BoundaryParser = "parser"
+	// BoundaryStorage is fights read from the persistent storage
+	BoundaryStorage = "storage"
+	// BoundaryAPI is fights about to be serialized by the API
+	BoundaryAPI = "api"
+)
+
+// maxReported bounds the number of violations logged and returned per check
+const maxReported = 10
+
+// ViolationError is returned by an enforcing checker when fights break invariants
+type ViolationError struct {
+	Boundary string
+	// Total is the number of violations, Violations lists the first of them
+	Total      int
+	Violations []Violation
+}
+
+// Error describes the violations
+func (e *ViolationError) Error() string {
+	return fmt.Sprintf("%d contract violations after %s, first: %s", e.Total, e.Boundary, e.Violations[0])
+}
+
+// Checker validates fights at the layer boundaries and counts violations
+// Without Enforce violations are only logged and counted, with it the check
+// also fails. It is safe for concurrent use.
+type Checker struct {
+	// Enforce turns violations into errors
+	Enforce bool
+
+	mu     sync.Mutex
+	counts map[string]int64
+}
+
+// NewChecker creates a checker
+func NewChecker(enforce bool) *Checker {
+	return &Checker{Enforce: enforce, counts: make(map[string]int64)}
+}
+
+// Check validates fights crossing the boundary
+// Violations are counted and logged; a *ViolationError is returned only
+// when the checker enforces the contract
+func (c *Checker) Check(boundary string, fights []models.Fight) error {
+	violations := ValidateFights(fights)
+	if len(violations) == 0 {
+		return nil
+	}
+
+	c.mu.Lock()
+	for _, violation := range violations {
+		c.counts[boundary+"/"+violation.Code]++
+	}
+	c.mu.Unlock()
+
+	reported := violations[:min(len(violations), maxReported)]
+	slog.Warn("Contract violations", "boundary", boundary, "violation_count", len(violations), "fight_count", len(fights))
+	for _, violation := range reported {
+		slog.Warn("Contract violation", "boundary", boundary, "violation", violation)
+	}
+
+	if !c.Enforce {
+		return nil
+	}
+
+	return &ViolationError{
+		Boundary:   boundary,
+		Total:      len(violations),
+		Violations: append([]Violation{}, reported...),
+	}
+}
+
+// Counts returns the number of violations per "boundary/code"
+func (c *Checker) Counts() map[string]int64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	counts := make(map[string]int64, len(c.counts))
+	for key, count := range c.counts {
+		counts[key] = count
+	}
+
+	return counts
+}
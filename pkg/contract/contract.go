@@ -0,0 +1,128 @@
+// Package contract checks the invariants of fight records shared by the
+// parser, the storage and the API
+// The layers rely on the same implicit agreements (date format, natural key,
+// status values, clean strings). Checking them at every boundary reports a
+// broken record where it appears instead of on the frontend.
+package contract
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"easypars/models"
+)
+
+// Violation codes
+const (
+	CodeInvalidDate   = "invalid_date"
+	CodeDateRange     = "date_out_of_range"
+	CodeInvalidKey    = "invalid_key"
+	CodeKeyMismatch   = "key_mismatch"
+	CodeInvalidStatus = "invalid_status"
+	CodeInvalidUTF8   = "invalid_utf8"
+	CodeControlChar   = "control_character"
+)
+
+// Allowed date range: no boxing records before MinYear, and no fight is
+// announced more than MaxYearsAhead years in advance
+const (
+	MinYear       = 1880
+	MaxYearsAhead = 5
+)
+
+// dateLayout is the date format of every fight (YYYY-MM-DD)
+const dateLayout = "2006-01-02"
+
+// keyPattern matches the natural key: optional date, two normalized names
+// and the normalized location
+var keyPattern = regexp.MustCompile(`^(\d{4}-\d{2}-\d{2})?\|[^|]*\|[^|]*\|[^|]*$`)
+
+// statuses are the allowed fight statuses
+var statuses = map[string]bool{
+	models.StatusScheduled:     true,
+	models.StatusCompleted:     true,
+	models.StatusResultUnknown: true,
+	models.StatusCancelled:     true,
+}
+
+// Violation describes a broken invariant of a fight
+type Violation struct {
+	Key     string `json:"key"`
+	Field   string `json:"field"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// String formats the violation for logs
+func (v Violation) String() string {
+	return fmt.Sprintf("%s %s (%s): %s", v.Key, v.Field, v.Code, v.Message)
+}
+
+// ValidateFightInvariants checks a single fight and returns every broken invariant
+// An empty date is allowed, it marks a fight whose date could not be read
+func ValidateFightInvariants(f models.Fight) []Violation {
+	var violations []Violation
+	add := func(field, code, format string, args ...any) {
+		violations = append(violations, Violation{
+			Key:     f.Key,
+			Field:   field,
+			Code:    code,
+			Message: fmt.Sprintf(format, args...),
+		})
+	}
+
+	// Step 1: The date is a real calendar date in the allowed range
+	if f.Date != "" {
+		date, err := time.Parse(dateLayout, f.Date)
+		if err != nil {
+			add("date", CodeInvalidDate, "%q is not a YYYY-MM-DD date", f.Date)
+		} else if maxYear := time.Now().Year() + MaxYearsAhead; date.Year() < MinYear || date.Year() > maxYear {
+			add("date", CodeDateRange, "year %d is outside %d..%d", date.Year(), MinYear, maxYear)
+		}
+	}
+
+	// Step 2: The key has the natural key format and matches the fields
+	if !keyPattern.MatchString(f.Key) {
+		add("key", CodeInvalidKey, "%q is not a natural key", f.Key)
+	} else if expected := f.NaturalKey(); f.Key != expected {
+		add("key", CodeKeyMismatch, "expected %q from the fields", expected)
+	}
+
+	// Step 3: The status is one of the known values
+	if !statuses[f.Status] {
+		add("status", CodeInvalidStatus, "unknown status %q", f.Status)
+	}
+
+	// Step 4: Texts are valid UTF-8 without control characters
+	texts := []struct{ field, value string }{
+		{"fighter1", f.Fighter1},
+		{"fighter2", f.Fighter2},
+		{"result", f.Result},
+		{"location", f.Location},
+	}
+	for _, text := range texts {
+		if !utf8.ValidString(text.value) {
+			add(text.field, CodeInvalidUTF8, "text is not valid UTF-8")
+			continue
+		}
+		if idx := strings.IndexFunc(text.value, unicode.IsControl); idx >= 0 {
+			add(text.field, CodeControlChar, "control character at byte %d", idx)
+		}
+	}
+
+	return violations
+}
+
+// ValidateFights checks every fight and returns all broken invariants
+func ValidateFights(fights []models.Fight) []Violation {
+	var violations []Violation
+	for _, fight := range fights {
+		violations = append(violations, ValidateFightInvariants(fight)...)
+	}
+
+	return violations
+}
@@ -0,0 +1,102 @@
+package contract
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"easypars/models"
+)
+
+// validFight returns a fight keeping every invariant
+func validFight() models.Fight {
+	fight := models.Fight{
+		Date:     "2024-05-18",
+		Fighter1: "Oleksandr Usyk",
+		Fighter2: "Tyson Fury",
+		Result:   "SD",
+		Location: "Riyadh",
+		Status:   models.StatusCompleted,
+	}
+	fight.Key = fight.NaturalKey()
+
+	return fight
+}
+
+func TestValidateFightInvariants(t *testing.T) {
+	farYear := strconv.Itoa(time.Now().Year() + MaxYearsAhead + 1)
+
+	tests := []struct {
+		name   string
+		change func(*models.Fight)
+		field  string
+		code   string
+	}{
+		{"impossible date", func(f *models.Fight) { f.Date = "2024-02-31" }, "date", CodeInvalidDate},
+		{"date format", func(f *models.Fight) { f.Date = "18.05.2024" }, "date", CodeInvalidDate},
+		{"date before boxing records", func(f *models.Fight) { f.Date = "1850-01-01" }, "date", CodeDateRange},
+		{"date too far ahead", func(f *models.Fight) { f.Date = farYear + "-01-01" }, "date", CodeDateRange},
+		{"key format", func(f *models.Fight) { f.Key = "usyk-fury" }, "key", CodeInvalidKey},
+		{"key of other fields", func(f *models.Fight) { f.Key = "2024-05-18|fury|joshua|riyadh" }, "key", CodeKeyMismatch},
+		{"unknown status", func(f *models.Fight) { f.Status = "finished" }, "status", CodeInvalidStatus},
+		{"empty status", func(f *models.Fight) { f.Status = "" }, "status", CodeInvalidStatus},
+		{"invalid UTF-8", func(f *models.Fight) { f.Result = "K\xffO" }, "result", CodeInvalidUTF8},
+		{"control character", func(f *models.Fight) { f.Location = "Riyadh\x00" }, "location", CodeControlChar},
+	}
+
+	if violations := ValidateFightInvariants(validFight()); len(violations) != 0 {
+		t.Fatalf("valid fight has violations: %v", violations)
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fight := validFight()
+			tt.change(&fight)
+
+			// A changed date also changes the expected key, which is
+			// reported after it
+			violations := ValidateFightInvariants(fight)
+			if len(violations) == 0 {
+				t.Fatalf("no violations, want %s on %s", tt.code, tt.field)
+			}
+			if violations[0].Field != tt.field || violations[0].Code != tt.code {
+				t.Errorf("violation = %s, want %s on %s", violations[0], tt.code, tt.field)
+			}
+			if len(violations) > 1 && (tt.field != "date" || len(violations) > 2 || violations[1].Field != "key") {
+				t.Errorf("unexpected violations %v", violations[1:])
+			}
+		})
+	}
+}
+
+func TestValidateFightInvariantsAllowsUndatedFights(t *testing.T) {
+	fight := validFight()
+	fight.Date = ""
+	fight.Key = fight.NaturalKey()
+
+	if violations := ValidateFightInvariants(fight); len(violations) != 0 {
+		t.Errorf("undated fight has violations: %v", violations)
+	}
+}
+
+func TestCheckerEnforce(t *testing.T) {
+	broken := validFight()
+	broken.Status = "finished"
+	fights := []models.Fight{validFight(), broken}
+
+	lenient := NewChecker(false)
+	if err := lenient.Check(BoundaryStorage, fights); err != nil {
+		t.Errorf("lenient checker failed: %v", err)
+	}
+	if got := lenient.Counts()[BoundaryStorage+"/"+CodeInvalidStatus]; got != 1 {
+		t.Errorf("counted %d violations, want 1", got)
+	}
+
+	err := NewChecker(true).Check(BoundaryAPI, fights)
+	violationErr, ok := err.(*ViolationError)
+	if !ok {
+		t.Fatalf("enforcing checker returned %v, want a *ViolationError", err)
+	}
+	if violationErr.Boundary != BoundaryAPI || violationErr.Total != 1 || violationErr.Violations[0].Code != CodeInvalidStatus {
+		t.Errorf("error = %+v", violationErr)
+	}
+}
@@ -0,0 +1,104 @@
+package contract_test
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"testing/quick"
+	"time"
+
+	"easypars/pkg/contract"
+	"easypars/pkg/parser"
+)
+
+// pageServer serves the page currently stored in it
+type pageServer struct {
+	mu   sync.Mutex
+	page string
+}
+
+func (s *pageServer) set(page string) {
+	s.mu.Lock()
+	s.page = page
+	s.mu.Unlock()
+}
+
+func (s *pageServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	fmt.Fprint(w, s.page)
+}
+
+// parseMay parses the page as the archive of May 2024
+func parseMay(t *testing.T, server *httptest.Server) *parser.ParseResult {
+	t.Helper()
+
+	p := parser.NewParser(server.URL + "/")
+	p.MonthURL = server.URL + "/{year}/{month}"
+	result, err := p.ParseMonth(context.Background(), 2024, time.May)
+	if err != nil {
+		t.Fatalf("parsing: %v", err)
+	}
+
+	return result
+}
+
+// TestParserFixturesKeepInvariants parses every fixture page of the parser
+func TestParserFixturesKeepInvariants(t *testing.T) {
+	paths, err := filepath.Glob(filepath.Join("..", "parser", "testdata", "*.html"))
+	if err != nil || len(paths) == 0 {
+		t.Fatalf("no parser fixtures: %v", err)
+	}
+
+	pages := &pageServer{}
+	server := httptest.NewServer(pages)
+	defer server.Close()
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		pages.set(string(data))
+
+		result := parseMay(t, server)
+		if len(result.Fights) == 0 {
+			t.Errorf("%s: no fights", filepath.Base(path))
+		}
+		for _, violation := range contract.ValidateFights(result.Fights) {
+			t.Errorf("%s: %s", filepath.Base(path), violation)
+		}
+	}
+}
+
+// TestParserOutputKeepsInvariants feeds rows of arbitrary cell texts to the
+// parser: whatever the source holds, no parsed fight may break the contract
+func TestParserOutputKeepsInvariants(t *testing.T) {
+	pages := &pageServer{}
+	server := httptest.NewServer(pages)
+	defer server.Close()
+
+	property := func(date, place, boxer1, result, boxer2 string) bool {
+		pages.set(fmt.Sprintf(`<div class="month">Май 2024</div><table><tr>`+
+			`<td class="date">%s</td><td class="place">%s</td><td class="boxer_1">%s</td>`+
+			`<td class="vs">%s</td><td class="boxer_2">%s</td></tr></table>`,
+			html.EscapeString(date), html.EscapeString(place), html.EscapeString(boxer1),
+			html.EscapeString(result), html.EscapeString(boxer2)))
+
+		violations := contract.ValidateFights(parseMay(t, server).Fights)
+		for _, violation := range violations {
+			t.Logf("cells %q %q %q %q %q: %s", date, place, boxer1, result, boxer2, violation)
+		}
+		return len(violations) == 0
+	}
+	if err := quick.Check(property, &quick.Config{MaxCount: 300}); err != nil {
+		t.Error(err)
+	}
+}
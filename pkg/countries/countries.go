@@ -0,0 +1,230 @@
+// Package countries resolves country names and codes to ISO 3166-1 alpha-2
+// Any spelling a client or the source may use is accepted: alpha-2 and
+// alpha-3 codes in any case, Russian and English names and common short
+// forms ("США", "UK"). The built-in dictionary can be extended with
+// Register, e.g. from the configuration.
+package countries
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// Supported languages of Localize
+const (
+	LangRU = "ru"
+	LangEN = "en"
+)
+
+// maxSuggestions bounds the suggestions of Suggest
+const maxSuggestions = 5
+
+// Country is an entry of the dictionary
+type Country struct {
+	// ISO2 and ISO3 are the ISO 3166-1 alpha-2 and alpha-3 codes
+	ISO2 string `mapstructure:"iso2" yaml:"iso2"`
+	ISO3 string `mapstructure:"iso3" yaml:"iso3"`
+	// RU and EN are the names shown to clients
+	RU string `mapstructure:"ru" yaml:"ru"`
+	EN string `mapstructure:"en" yaml:"en"`
+	// Aliases are other accepted spellings
+	Aliases []string `mapstructure:"aliases" yaml:"aliases"`
+}
+
+// registry holds the dictionary
+var registry = struct {
+	sync.RWMutex
+	// byISO2 maps an alpha-2 code to its country
+	byISO2 map[string]*Country
+	// index maps every normalized spelling to an alpha-2 code
+	index map[string]string
+}{byISO2: make(map[string]*Country), index: make(map[string]string)}
+
+func init() {
+	for _, country := range builtin {
+		if err := Register(country); err != nil {
+			panic(err)
+		}
+	}
+}
+
+// Register adds a country or extends a known one
+// For a known alpha-2 code the aliases are added and non-empty codes and
+// names replace the built-in ones; a new country needs both names. A
+// spelling already used by another country is rejected, so an alias can
+// never silently move fights from one country to another.
+func Register(country Country) error {
+	iso2 := strings.ToUpper(strings.TrimSpace(country.ISO2))
+	if len(iso2) != 2 || !isLatin(iso2) {
+		return fmt.Errorf("invalid country code %q: must be ISO 3166-1 alpha-2", country.ISO2)
+	}
+
+	registry.Lock()
+	defer registry.Unlock()
+
+	merged := Country{ISO2: iso2}
+	if known, ok := registry.byISO2[iso2]; ok {
+		merged = *known
+		merged.Aliases = append([]string(nil), known.Aliases...)
+	}
+	if country.ISO3 != "" {
+		merged.ISO3 = strings.ToUpper(strings.TrimSpace(country.ISO3))
+	}
+	if country.RU != "" {
+		merged.RU = strings.TrimSpace(country.RU)
+	}
+	if country.EN != "" {
+		merged.EN = strings.TrimSpace(country.EN)
+	}
+	merged.Aliases = append(merged.Aliases, country.Aliases...)
+	if merged.RU == "" || merged.EN == "" {
+		return fmt.Errorf("country %s needs a Russian and an English name", iso2)
+	}
+
+	spellings := append([]string{merged.ISO2, merged.ISO3, merged.RU, merged.EN}, merged.Aliases...)
+	for _, spelling := range spellings {
+		key := normalize(spelling)
+		if key == "" {
+			continue
+		}
+		if owner, ok := registry.index[key]; ok && owner != iso2 {
+			return fmt.Errorf("country spelling %q of %s is already used by %s", spelling, iso2, owner)
+		}
+	}
+	for _, spelling := range spellings {
+		if key := normalize(spelling); key != "" {
+			registry.index[key] = iso2
+		}
+	}
+	registry.byISO2[iso2] = &merged
+
+	return nil
+}
+
+// Resolve returns the alpha-2 code of a country name or code
+func Resolve(input string) (string, bool) {
+	key := normalize(input)
+	if key == "" {
+		return "", false
+	}
+
+	registry.RLock()
+	defer registry.RUnlock()
+
+	iso2, ok := registry.index[key]
+	return iso2, ok
+}
+
+// ResolveFlag returns the alpha-2 code of a flag image of the source, given
+// its title or alt text or, without them, its address ("/flags/ua.png")
+func ResolveFlag(flag string) (string, bool) {
+	if iso2, ok := Resolve(flag); ok {
+		return iso2, true
+	}
+	name := strings.TrimSuffix(path.Base(flag), path.Ext(flag))
+	if len(name) == 2 || len(name) == 3 {
+		return Resolve(name)
+	}
+
+	return "", false
+}
+
+// FromLocation returns the alpha-2 code of the country named in a location
+// such as "Лас-Вегас, США"; the comma separated parts are tried from the last
+func FromLocation(location string) (string, bool) {
+	parts := strings.Split(location, ",")
+	for i := len(parts) - 1; i >= 0; i-- {
+		if iso2, ok := Resolve(parts[i]); ok {
+			return iso2, true
+		}
+	}
+
+	return "", false
+}
+
+// Localize returns the name of the country in the language ("ru" or
+// "en", English for any other); unknown codes are returned as they are
+func Localize(iso2, lang string) string {
+	registry.RLock()
+	defer registry.RUnlock()
+
+	country, ok := registry.byISO2[strings.ToUpper(iso2)]
+	if !ok {
+		return iso2
+	}
+	if lang == LangRU {
+		return country.RU
+	}
+	return country.EN
+}
+
+// Suggest returns the alpha-2 codes of up to five countries whose code,
+// name or alias starts with the input, as hints for an unresolved value
+func Suggest(input string) []string {
+	prefix := normalize(input)
+	if prefix == "" {
+		return nil
+	}
+
+	registry.RLock()
+	matched := make(map[string]bool)
+	for key, iso2 := range registry.index {
+		if strings.HasPrefix(key, prefix) {
+			matched[iso2] = true
+		}
+	}
+	registry.RUnlock()
+
+	codes := make([]string, 0, len(matched))
+	for iso2 := range matched {
+		codes = append(codes, iso2)
+	}
+	sort.Strings(codes)
+	if len(codes) > maxSuggestions {
+		codes = codes[:maxSuggestions]
+	}
+
+	return codes
+}
+
+// Len returns the number of known countries
+func Len() int {
+	registry.RLock()
+	defer registry.RUnlock()
+
+	return len(registry.byISO2)
+}
+
+// normalize lowercases the spelling, drops dots, replaces ё with е and
+// collapses whitespace and dashes, so "U.S.A." matches "USA" and
+// "Коста Рика" matches "Коста-Рика"
+func normalize(spelling string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(spelling) {
+		switch {
+		case r == '.':
+		case r == 'ё':
+			b.WriteRune('е')
+		case r == '-' || unicode.IsSpace(r):
+			b.WriteRune(' ')
+		default:
+			b.WriteRune(r)
+		}
+	}
+
+	return strings.Join(strings.Fields(b.String()), " ")
+}
+
+// isLatin reports whether the code only has Latin letters
+func isLatin(code string) bool {
+	for _, r := range code {
+		if r < 'A' || r > 'Z' {
+			return false
+		}
+	}
+	return true
+}
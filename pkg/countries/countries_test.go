@@ -0,0 +1,154 @@
+package countries
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestResolve(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+		ok    bool
+	}{
+		{"UA", "UA", true},
+		{"ua", "UA", true},
+		{"UKR", "UA", true},
+		{"Украина", "UA", true},
+		{"украина", "UA", true},
+		{"Ukraine", "UA", true},
+		{"  UKRAINE ", "UA", true},
+		{"США", "US", true},
+		{"U.S.A.", "US", true},
+		{"UK", "GB", true},
+		{"Англия", "GB", true},
+		{"Белоруссия", "BY", true},
+		{"Российская  Федерация", "RU", true},
+		{"Ukrain", "", false},
+		{"Atlantis", "", false},
+		{"", "", false},
+		{"...", "", false},
+	}
+	for _, tt := range tests {
+		got, ok := Resolve(tt.input)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("Resolve(%q) = %q, %v; want %q, %v", tt.input, got, ok, tt.want, tt.ok)
+		}
+	}
+
+	if n := Len(); n < 80 {
+		t.Errorf("the dictionary has %d countries, want at least 80", n)
+	}
+}
+
+func TestResolveFlag(t *testing.T) {
+	tests := []struct {
+		flag string
+		want string
+		ok   bool
+	}{
+		{"Китай", "CN", true},
+		{"/images/flags/ua.png", "UA", true},
+		{"https://vringe.example/flags/GBR.gif", "GB", true},
+		{"/images/flags/unknown.png", "", false},
+		{"/images/flags/zz.png", "", false},
+		{"Атлантида", "", false},
+	}
+	for _, tt := range tests {
+		got, ok := ResolveFlag(tt.flag)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("ResolveFlag(%q) = %q, %v; want %q, %v", tt.flag, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestFromLocation(t *testing.T) {
+	tests := []struct {
+		location string
+		want     string
+	}{
+		{"Лас-Вегас, США", "US"},
+		{"Riyadh, Saudi Arabia", "SA"},
+		{"Лондон, Англия, Великобритания", "GB"},
+		{"Киев, Украина, Arena", "UA"},
+		{"Riyadh", ""},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got, _ := FromLocation(tt.location); got != tt.want {
+			t.Errorf("FromLocation(%q) = %q, want %q", tt.location, got, tt.want)
+		}
+	}
+}
+
+func TestLocalize(t *testing.T) {
+	tests := []struct {
+		iso2, lang string
+		want       string
+	}{
+		{"UA", LangRU, "Украина"},
+		{"UA", LangEN, "Ukraine"},
+		{"ua", LangRU, "Украина"},
+		{"US", "de", "United States"},
+		{"ZZ", LangRU, "ZZ"},
+	}
+	for _, tt := range tests {
+		if got := Localize(tt.iso2, tt.lang); got != tt.want {
+			t.Errorf("Localize(%q, %q) = %q, want %q", tt.iso2, tt.lang, got, tt.want)
+		}
+	}
+}
+
+func TestSuggest(t *testing.T) {
+	tests := []struct {
+		input string
+		want  []string
+	}{
+		{"Ukrai", []string{"UA"}},
+		{"укр", []string{"UA"}},
+		{"Qaz", nil},
+		{"", nil},
+	}
+	for _, tt := range tests {
+		if got := Suggest(tt.input); strings.Join(got, ",") != strings.Join(tt.want, ",") {
+			t.Errorf("Suggest(%q) = %q, want %q", tt.input, got, tt.want)
+		}
+	}
+
+	if got := Suggest("a"); len(got) != maxSuggestions {
+		t.Errorf("Suggest(\"a\") = %q, want %d suggestions", got, maxSuggestions)
+	}
+}
+
+func TestRegister(t *testing.T) {
+	tests := []struct {
+		name    string
+		country Country
+		wantErr bool
+	}{
+		{"new country", Country{ISO2: "xk", ISO3: "XKX", RU: "Косово", EN: "Kosovo"}, false},
+		{"alias of a known country", Country{ISO2: "UA", Aliases: []string{"Україна"}}, false},
+		{"invalid code", Country{ISO2: "UKR", RU: "Украина", EN: "Ukraine"}, true},
+		{"code with digits", Country{ISO2: "U1", RU: "Страна", EN: "Country"}, true},
+		{"new country without names", Country{ISO2: "XA"}, true},
+		{"spelling of another country", Country{ISO2: "XB", RU: "Страна Б", EN: "Country B", Aliases: []string{"UK"}}, true},
+	}
+	for _, tt := range tests {
+		if err := Register(tt.country); (err != nil) != tt.wantErr {
+			t.Errorf("%s: Register = %v, want error %v", tt.name, err, tt.wantErr)
+		}
+	}
+
+	if got, ok := Resolve("Kosovo"); !ok || got != "XK" {
+		t.Errorf("Resolve of a registered country = %q, %v; want XK", got, ok)
+	}
+	if got, ok := Resolve("україна"); !ok || got != "UA" {
+		t.Errorf("Resolve of a registered alias = %q, %v; want UA", got, ok)
+	}
+	if got, _ := Resolve("UK"); got != "GB" {
+		t.Errorf("a rejected alias moved UK to %q", got)
+	}
+	if _, ok := Resolve("Country B"); ok {
+		t.Error("a rejected country was partly registered")
+	}
+}
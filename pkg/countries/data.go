@@ -0,0 +1,107 @@
+package countries
+
+// builtin is the built-in country dictionary
+// Aliases hold common short forms and spellings seen on boxing sites; the
+// codes and both names are always matched and need not be repeated there.
+var builtin = []Country{
+	{ISO2: "AF", ISO3: "AFG", RU: "Афганистан", EN: "Afghanistan"},
+	{ISO2: "AL", ISO3: "ALB", RU: "Албания", EN: "Albania"},
+	{ISO2: "DZ", ISO3: "DZA", RU: "Алжир", EN: "Algeria"},
+	{ISO2: "AR", ISO3: "ARG", RU: "Аргентина", EN: "Argentina"},
+	{ISO2: "AM", ISO3: "ARM", RU: "Армения", EN: "Armenia"},
+	{ISO2: "AU", ISO3: "AUS", RU: "Австралия", EN: "Australia"},
+	{ISO2: "AT", ISO3: "AUT", RU: "Австрия", EN: "Austria"},
+	{ISO2: "AZ", ISO3: "AZE", RU: "Азербайджан", EN: "Azerbaijan"},
+	{ISO2: "BS", ISO3: "BHS", RU: "Багамские Острова", EN: "Bahamas", Aliases: []string{"Багамы", "The Bahamas"}},
+	{ISO2: "BY", ISO3: "BLR", RU: "Беларусь", EN: "Belarus", Aliases: []string{"Белоруссия"}},
+	{ISO2: "BE", ISO3: "BEL", RU: "Бельгия", EN: "Belgium"},
+	{ISO2: "BA", ISO3: "BIH", RU: "Босния и Герцеговина", EN: "Bosnia and Herzegovina", Aliases: []string{"Босния", "Bosnia"}},
+	{ISO2: "BR", ISO3: "BRA", RU: "Бразилия", EN: "Brazil"},
+	{ISO2: "BG", ISO3: "BGR", RU: "Болгария", EN: "Bulgaria"},
+	{ISO2: "CM", ISO3: "CMR", RU: "Камерун", EN: "Cameroon"},
+	{ISO2: "CA", ISO3: "CAN", RU: "Канада", EN: "Canada"},
+	{ISO2: "CL", ISO3: "CHL", RU: "Чили", EN: "Chile"},
+	{ISO2: "CN", ISO3: "CHN", RU: "Китай", EN: "China", Aliases: []string{"КНР"}},
+	{ISO2: "CO", ISO3: "COL", RU: "Колумбия", EN: "Colombia"},
+	{ISO2: "CD", ISO3: "COD", RU: "ДР Конго", EN: "DR Congo", Aliases: []string{"Демократическая Республика Конго", "Democratic Republic of the Congo"}},
+	{ISO2: "CR", ISO3: "CRI", RU: "Коста-Рика", EN: "Costa Rica"},
+	{ISO2: "HR", ISO3: "HRV", RU: "Хорватия", EN: "Croatia"},
+	{ISO2: "CU", ISO3: "CUB", RU: "Куба", EN: "Cuba"},
+	{ISO2: "CZ", ISO3: "CZE", RU: "Чехия", EN: "Czech Republic", Aliases: []string{"Czechia"}},
+	{ISO2: "DK", ISO3: "DNK", RU: "Дания", EN: "Denmark"},
+	{ISO2: "DO", ISO3: "DOM", RU: "Доминиканская Республика", EN: "Dominican Republic", Aliases: []string{"Доминикана"}},
+	{ISO2: "EC", ISO3: "ECU", RU: "Эквадор", EN: "Ecuador"},
+	{ISO2: "EG", ISO3: "EGY", RU: "Египет", EN: "Egypt"},
+	{ISO2: "EE", ISO3: "EST", RU: "Эстония", EN: "Estonia"},
+	{ISO2: "FI", ISO3: "FIN", RU: "Финляндия", EN: "Finland"},
+	{ISO2: "FR", ISO3: "FRA", RU: "Франция", EN: "France"},
+	{ISO2: "GE", ISO3: "GEO", RU: "Грузия", EN: "Georgia"},
+	{ISO2: "DE", ISO3: "DEU", RU: "Германия", EN: "Germany", Aliases: []string{"ФРГ"}},
+	{ISO2: "GH", ISO3: "GHA", RU: "Гана", EN: "Ghana"},
+	{ISO2: "GB", ISO3: "GBR", RU: "Великобритания", EN: "United Kingdom", Aliases: []string{"UK", "Англия", "England", "Шотландия", "Scotland", "Уэльс", "Wales", "Great Britain", "Британия", "Соединённое Королевство"}},
+	{ISO2: "GR", ISO3: "GRC", RU: "Греция", EN: "Greece"},
+	{ISO2: "HU", ISO3: "HUN", RU: "Венгрия", EN: "Hungary"},
+	{ISO2: "IN", ISO3: "IND", RU: "Индия", EN: "India"},
+	{ISO2: "ID", ISO3: "IDN", RU: "Индонезия", EN: "Indonesia"},
+	{ISO2: "IR", ISO3: "IRN", RU: "Иран", EN: "Iran"},
+	{ISO2: "IE", ISO3: "IRL", RU: "Ирландия", EN: "Ireland", Aliases: []string{"Северная Ирландия", "Northern Ireland"}},
+	{ISO2: "IL", ISO3: "ISR", RU: "Израиль", EN: "Israel"},
+	{ISO2: "IT", ISO3: "ITA", RU: "Италия", EN: "Italy"},
+	{ISO2: "JM", ISO3: "JAM", RU: "Ямайка", EN: "Jamaica"},
+	{ISO2: "JP", ISO3: "JPN", RU: "Япония", EN: "Japan"},
+	{ISO2: "KZ", ISO3: "KAZ", RU: "Казахстан", EN: "Kazakhstan"},
+	{ISO2: "KE", ISO3: "KEN", RU: "Кения", EN: "Kenya"},
+	{ISO2: "KR", ISO3: "KOR", RU: "Южная Корея", EN: "South Korea", Aliases: []string{"Корея", "Korea", "Республика Корея"}},
+	{ISO2: "KG", ISO3: "KGZ", RU: "Киргизия", EN: "Kyrgyzstan", Aliases: []string{"Кыргызстан"}},
+	{ISO2: "LV", ISO3: "LVA", RU: "Латвия", EN: "Latvia"},
+	{ISO2: "LT", ISO3: "LTU", RU: "Литва", EN: "Lithuania"},
+	{ISO2: "MY", ISO3: "MYS", RU: "Малайзия", EN: "Malaysia"},
+	{ISO2: "MX", ISO3: "MEX", RU: "Мексика", EN: "Mexico"},
+	{ISO2: "MD", ISO3: "MDA", RU: "Молдавия", EN: "Moldova", Aliases: []string{"Молдова"}},
+	{ISO2: "MC", ISO3: "MCO", RU: "Монако", EN: "Monaco"},
+	{ISO2: "MN", ISO3: "MNG", RU: "Монголия", EN: "Mongolia"},
+	{ISO2: "ME", ISO3: "MNE", RU: "Черногория", EN: "Montenegro"},
+	{ISO2: "MA", ISO3: "MAR", RU: "Марокко", EN: "Morocco"},
+	{ISO2: "NA", ISO3: "NAM", RU: "Намибия", EN: "Namibia"},
+	{ISO2: "NL", ISO3: "NLD", RU: "Нидерланды", EN: "Netherlands", Aliases: []string{"Голландия", "Holland"}},
+	{ISO2: "NZ", ISO3: "NZL", RU: "Новая Зеландия", EN: "New Zealand"},
+	{ISO2: "NI", ISO3: "NIC", RU: "Никарагуа", EN: "Nicaragua"},
+	{ISO2: "NG", ISO3: "NGA", RU: "Нигерия", EN: "Nigeria"},
+	{ISO2: "MK", ISO3: "MKD", RU: "Северная Македония", EN: "North Macedonia", Aliases: []string{"Македония", "Macedonia"}},
+	{ISO2: "NO", ISO3: "NOR", RU: "Норвегия", EN: "Norway"},
+	{ISO2: "PA", ISO3: "PAN", RU: "Панама", EN: "Panama"},
+	{ISO2: "PE", ISO3: "PER", RU: "Перу", EN: "Peru"},
+	{ISO2: "PH", ISO3: "PHL", RU: "Филиппины", EN: "Philippines"},
+	{ISO2: "PL", ISO3: "POL", RU: "Польша", EN: "Poland"},
+	{ISO2: "PT", ISO3: "PRT", RU: "Португалия", EN: "Portugal"},
+	{ISO2: "PR", ISO3: "PRI", RU: "Пуэрто-Рико", EN: "Puerto Rico"},
+	{ISO2: "QA", ISO3: "QAT", RU: "Катар", EN: "Qatar"},
+	{ISO2: "RO", ISO3: "ROU", RU: "Румыния", EN: "Romania"},
+	{ISO2: "RU", ISO3: "RUS", RU: "Россия", EN: "Russia", Aliases: []string{"РФ", "Российская Федерация", "Russian Federation"}},
+	{ISO2: "SA", ISO3: "SAU", RU: "Саудовская Аравия", EN: "Saudi Arabia", Aliases: []string{"КСА", "KSA"}},
+	{ISO2: "RS", ISO3: "SRB", RU: "Сербия", EN: "Serbia"},
+	{ISO2: "SG", ISO3: "SGP", RU: "Сингапур", EN: "Singapore"},
+	{ISO2: "SK", ISO3: "SVK", RU: "Словакия", EN: "Slovakia"},
+	{ISO2: "SI", ISO3: "SVN", RU: "Словения", EN: "Slovenia"},
+	{ISO2: "ZA", ISO3: "ZAF", RU: "ЮАР", EN: "South Africa", Aliases: []string{"Южно-Африканская Республика", "Южная Африка"}},
+	{ISO2: "ES", ISO3: "ESP", RU: "Испания", EN: "Spain"},
+	{ISO2: "SE", ISO3: "SWE", RU: "Швеция", EN: "Sweden"},
+	{ISO2: "CH", ISO3: "CHE", RU: "Швейцария", EN: "Switzerland"},
+	{ISO2: "TJ", ISO3: "TJK", RU: "Таджикистан", EN: "Tajikistan"},
+	{ISO2: "TZ", ISO3: "TZA", RU: "Танзания", EN: "Tanzania"},
+	{ISO2: "TH", ISO3: "THA", RU: "Таиланд", EN: "Thailand", Aliases: []string{"Тайланд"}},
+	{ISO2: "TT", ISO3: "TTO", RU: "Тринидад и Тобаго", EN: "Trinidad and Tobago"},
+	{ISO2: "TN", ISO3: "TUN", RU: "Тунис", EN: "Tunisia"},
+	{ISO2: "TR", ISO3: "TUR", RU: "Турция", EN: "Turkey", Aliases: []string{"Türkiye"}},
+	{ISO2: "TM", ISO3: "TKM", RU: "Туркменистан", EN: "Turkmenistan", Aliases: []string{"Туркмения"}},
+	{ISO2: "UG", ISO3: "UGA", RU: "Уганда", EN: "Uganda"},
+	{ISO2: "UA", ISO3: "UKR", RU: "Украина", EN: "Ukraine"},
+	{ISO2: "AE", ISO3: "ARE", RU: "ОАЭ", EN: "United Arab Emirates", Aliases: []string{"Объединённые Арабские Эмираты", "UAE", "Эмираты"}},
+	{ISO2: "US", ISO3: "USA", RU: "США", EN: "United States", Aliases: []string{"Соединённые Штаты", "Соединённые Штаты Америки", "Америка", "United States of America", "America"}},
+	{ISO2: "UY", ISO3: "URY", RU: "Уругвай", EN: "Uruguay"},
+	{ISO2: "UZ", ISO3: "UZB", RU: "Узбекистан", EN: "Uzbekistan"},
+	{ISO2: "VE", ISO3: "VEN", RU: "Венесуэла", EN: "Venezuela"},
+	{ISO2: "VN", ISO3: "VNM", RU: "Вьетнам", EN: "Vietnam"},
+	{ISO2: "ZM", ISO3: "ZMB", RU: "Замбия", EN: "Zambia"},
+	{ISO2: "ZW", ISO3: "ZWE", RU: "Зимбабве", EN: "Zimbabwe"},
+}
@@ -0,0 +1,78 @@
+// Package db manages the connection to the PostgreSQL database
+// The schema and the queries belong to the storage package, which builds
+// its repository on the connection opened here.
+package db
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/url"
+	"strconv"
+	"time"
+
+	"easypars/pkg/config"
+
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// DSN returns the connection URL of the configuration
+// The credentials are escaped, so passwords may contain any character.
+func DSN(cfg config.DatabaseConfig) string {
+	query := url.Values{}
+	query.Set("sslmode", cfg.SSLMode)
+	if cfg.ConnectTimeoutSeconds > 0 {
+		query.Set("connect_timeout", strconv.Itoa(cfg.ConnectTimeoutSeconds))
+	}
+
+	dsn := url.URL{
+		Scheme:   "postgres",
+		User:     url.UserPassword(cfg.User, cfg.Password),
+		Host:     net.JoinHostPort(cfg.Host, strconv.Itoa(cfg.Port)),
+		Path:     "/" + cfg.DBName,
+		RawQuery: query.Encode(),
+	}
+	return dsn.String()
+}
+
+// OpenPostgres opens a connection pool to the configured database and
+// checks that the database answers
+// A database that is down fails the start instead of the first request.
+func OpenPostgres(cfg config.DatabaseConfig) (*gorm.DB, error) {
+	address := net.JoinHostPort(cfg.Host, strconv.Itoa(cfg.Port))
+
+	// Step 1: Open the pool, the connection is checked below with a timeout
+	gormDB, err := gorm.Open(postgres.Open(DSN(cfg)), &gorm.Config{
+		Logger:               logger.Default.LogMode(logger.Warn),
+		DisableAutomaticPing: true,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error opening postgres database %s/%s: %w", address, cfg.DBName, err)
+	}
+	sqlDB, err := gormDB.DB()
+	if err != nil {
+		return nil, fmt.Errorf("error opening postgres database %s/%s: %w", address, cfg.DBName, err)
+	}
+	if cfg.MaxConnections > 0 {
+		sqlDB.SetMaxOpenConns(cfg.MaxConnections)
+		sqlDB.SetMaxIdleConns(cfg.MaxConnections)
+	}
+
+	// Step 2: Ping, so a wrong address or credentials are reported on start
+	timeout := time.Duration(cfg.ConnectTimeoutSeconds) * time.Second
+	if timeout <= 0 {
+		timeout = 5 * time.Second
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	if err := sqlDB.PingContext(ctx); err != nil {
+		sqlDB.Close()
+		return nil, fmt.Errorf("error connecting to postgres database %s/%s: %w", address, cfg.DBName, err)
+	}
+
+	slog.Info("PostgreSQL connected", "address", address, "dbname", cfg.DBName, "max_connections", cfg.MaxConnections)
+	return gormDB, nil
+}
@@ -0,0 +1,264 @@
+// Package feed renders fight results as RSS 2.0 and Atom 1.0 feeds
+// Each completed fight is an item titled like "Usyk def. Fury (SD 12)",
+// dated by the day of the fight. Items are identified by a URN derived
+// from the natural key of the fight, so feed readers do not show a fight
+// again when the feed is rebuilt.
+package feed
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"time"
+
+	"easypars/models"
+)
+
+// Content types of the feed formats
+const (
+	RSSContentType  = "application/rss+xml; charset=utf-8"
+	AtomContentType = "application/atom+xml; charset=utf-8"
+)
+
+// Channel describes the feed as a whole
+type Channel struct {
+	Title       string
+	Description string
+	// Link is the site of the feed, SelfURL the URL the feed is served at
+	Link    string
+	SelfURL string
+	// Updated is when the data of the feed was last built
+	Updated time.Time
+}
+
+// Item is a single fight result of a feed
+type Item struct {
+	ID          string
+	Title       string
+	Link        string
+	Description string
+	Published   time.Time
+}
+
+// Latest returns the most recent completed fights, newest first
+// Fights without a YYYY-MM-DD date cannot be placed in time and are left
+// out; fights of the same day are ordered by their key, so the order is
+// stable between builds. n bounds the number of fights.
+func Latest(fights []models.Fight, n int) []models.Fight {
+	latest := make([]models.Fight, 0, len(fights))
+	for _, fight := range fights {
+		if fight.Status != models.StatusCompleted {
+			continue
+		}
+		if _, err := time.Parse("2006-01-02", fight.Date); err != nil {
+			continue
+		}
+		latest = append(latest, fight)
+	}
+	sort.SliceStable(latest, func(i, j int) bool {
+		if latest[i].Date != latest[j].Date {
+			return latest[i].Date > latest[j].Date
+		}
+		return latest[i].Key < latest[j].Key
+	})
+	if len(latest) > n {
+		latest = latest[:n]
+	}
+
+	return latest
+}
+
+// NewItem returns the feed item of a completed fight with its page link
+func NewItem(fight models.Fight, link string) Item {
+	published, _ := time.Parse("2006-01-02", fight.Date)
+
+	return Item{
+		ID:          ItemID(fight.Key),
+		Title:       Title(fight),
+		Link:        link,
+		Description: fight.Location,
+		Published:   published,
+	}
+}
+
+// ItemID returns the stable identifier of a fight in the feeds, a URN
+// derived from its natural key
+// The key itself holds spaces and Cyrillic letters, which are not allowed
+// in the IRI of an Atom id.
+func ItemID(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return "urn:easypars:fight:" + hex.EncodeToString(sum[:16])
+}
+
+// Title returns the headline of a fight result
+// The source lists the winner of a decided fight first, so a stoppage or a
+// decision reads "Fighter1 def. Fighter2 (UD 12)"; draws, no contests and
+// results in an unknown format read "Fighter1 vs Fighter2 (<result>)".
+func Title(fight models.Fight) string {
+	outcome := fight.ResultType
+	if fight.Round > 0 {
+		outcome += " " + strconv.Itoa(fight.Round)
+	}
+
+	switch fight.ResultType {
+	case models.ResultKO, models.ResultTKO, models.ResultUD, models.ResultSD, models.ResultMD:
+		return fmt.Sprintf("%s def. %s (%s)", fight.Fighter1, fight.Fighter2, outcome)
+	case models.ResultDraw, models.ResultNC:
+		return fmt.Sprintf("%s vs %s (%s)", fight.Fighter1, fight.Fighter2, outcome)
+	}
+	if fight.Result == "" {
+		return fight.Fighter1 + " vs " + fight.Fighter2
+	}
+
+	return fmt.Sprintf("%s vs %s (%s)", fight.Fighter1, fight.Fighter2, fight.Result)
+}
+
+// rss is the document of an RSS 2.0 feed
+type rss struct {
+	XMLName xml.Name   `xml:"rss"`
+	Version string     `xml:"version,attr"`
+	Channel rssChannel `xml:"channel"`
+}
+
+// rssChannel is the channel of an RSS feed
+type rssChannel struct {
+	Title         string    `xml:"title"`
+	Link          string    `xml:"link"`
+	Description   string    `xml:"description"`
+	SelfLink      atomLink  `xml:"http://www.w3.org/2005/Atom link"`
+	LastBuildDate string    `xml:"lastBuildDate,omitempty"`
+	Items         []rssItem `xml:"item"`
+}
+
+// rssItem is an item of an RSS feed
+type rssItem struct {
+	Title       string  `xml:"title"`
+	Link        string  `xml:"link,omitempty"`
+	Description string  `xml:"description,omitempty"`
+	GUID        rssGUID `xml:"guid"`
+	PubDate     string  `xml:"pubDate"`
+}
+
+// rssGUID is the identifier of an RSS item; it is not a URL
+type rssGUID struct {
+	IsPermaLink bool   `xml:"isPermaLink,attr"`
+	Value       string `xml:",chardata"`
+}
+
+// WriteRSS writes the items as an RSS 2.0 feed
+func WriteRSS(w io.Writer, channel Channel, items []Item) error {
+	doc := rss{
+		Version: "2.0",
+		Channel: rssChannel{
+			Title:       channel.Title,
+			Link:        channel.Link,
+			Description: channel.Description,
+			SelfLink:    atomLink{Href: channel.SelfURL, Rel: "self", Type: "application/rss+xml"},
+		},
+	}
+	if !channel.Updated.IsZero() {
+		doc.Channel.LastBuildDate = channel.Updated.UTC().Format(time.RFC1123Z)
+	}
+	for _, item := range items {
+		doc.Channel.Items = append(doc.Channel.Items, rssItem{
+			Title:       item.Title,
+			Link:        item.Link,
+			Description: item.Description,
+			GUID:        rssGUID{Value: item.ID},
+			PubDate:     item.Published.UTC().Format(time.RFC1123Z),
+		})
+	}
+
+	return writeXML(w, doc)
+}
+
+// atom is the document of an Atom 1.0 feed
+type atom struct {
+	XMLName  xml.Name    `xml:"http://www.w3.org/2005/Atom feed"`
+	Title    string      `xml:"title"`
+	Subtitle string      `xml:"subtitle,omitempty"`
+	ID       string      `xml:"id"`
+	Updated  string      `xml:"updated"`
+	Author   atomAuthor  `xml:"author"`
+	Links    []atomLink  `xml:"link"`
+	Entries  []atomEntry `xml:"entry"`
+}
+
+// atomAuthor is the author of an Atom feed, required when the entries
+// have none
+type atomAuthor struct {
+	Name string `xml:"name"`
+}
+
+// atomLink is a link element of Atom, also used for the self link of RSS
+type atomLink struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr,omitempty"`
+	Type string `xml:"type,attr,omitempty"`
+}
+
+// atomEntry is an entry of an Atom feed
+type atomEntry struct {
+	Title     string    `xml:"title"`
+	ID        string    `xml:"id"`
+	Updated   string    `xml:"updated"`
+	Published string    `xml:"published"`
+	Link      *atomLink `xml:"link,omitempty"`
+	Summary   string    `xml:"summary,omitempty"`
+}
+
+// WriteAtom writes the items as an Atom 1.0 feed
+// The feed is updated when its data was built, an entry on the day of its
+// fight.
+func WriteAtom(w io.Writer, channel Channel, items []Item) error {
+	updated := channel.Updated
+	if updated.IsZero() {
+		updated = time.Now()
+	}
+	doc := atom{
+		Title:    channel.Title,
+		Subtitle: channel.Description,
+		ID:       channel.SelfURL,
+		Updated:  updated.UTC().Format(time.RFC3339),
+		Author:   atomAuthor{Name: channel.Title},
+		Links: []atomLink{
+			{Href: channel.SelfURL, Rel: "self", Type: "application/atom+xml"},
+			{Href: channel.Link, Rel: "alternate"},
+		},
+	}
+	for _, item := range items {
+		entry := atomEntry{
+			Title:     item.Title,
+			ID:        item.ID,
+			Updated:   item.Published.UTC().Format(time.RFC3339),
+			Published: item.Published.UTC().Format(time.RFC3339),
+			Summary:   item.Description,
+		}
+		if item.Link != "" {
+			entry.Link = &atomLink{Href: item.Link, Rel: "alternate"}
+		}
+		doc.Entries = append(doc.Entries, entry)
+	}
+
+	return writeXML(w, doc)
+}
+
+// writeXML writes an XML document with its declaration
+// Text is written as UTF-8, Cyrillic included; encoding/xml escapes the
+// markup characters.
+func writeXML(w io.Writer, doc any) error {
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(doc); err != nil {
+		return err
+	}
+
+	return encoder.Close()
+}
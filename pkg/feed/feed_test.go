@@ -0,0 +1,277 @@
+package feed
+
+import (
+	"bytes"
+	"encoding/xml"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"easypars/models"
+)
+
+// result returns a completed fight with its key
+func result(date, fighter1, fighter2, resultType string, round int) models.Fight {
+	fight := models.Fight{
+		Date:       date,
+		Fighter1:   fighter1,
+		Fighter2:   fighter2,
+		Result:     resultType,
+		ResultType: resultType,
+		Round:      round,
+		Location:   "Riyadh",
+		Status:     models.StatusCompleted,
+	}
+	if round > 0 {
+		fight.Result += " " + strconv.Itoa(round)
+	}
+	fight.AssignKey()
+
+	return fight
+}
+
+func TestTitle(t *testing.T) {
+	tests := []struct {
+		name  string
+		fight models.Fight
+		want  string
+	}{
+		{"decision", result("2024-05-18", "Usyk", "Fury", models.ResultSD, 12), "Usyk def. Fury (SD 12)"},
+		{"stoppage", result("2024-06-01", "Zhang", "Wilder", models.ResultKO, 5), "Zhang def. Wilder (KO 5)"},
+		{"decision without a round", result("2024-06-01", "Bivol", "Zinad", models.ResultUD, 0), "Bivol def. Zinad (UD)"},
+		{"draw", result("2024-06-01", "Fury", "Wilder", models.ResultDraw, 12), "Fury vs Wilder (Draw 12)"},
+		{"no contest", result("2024-06-01", "Fury", "Wilder", models.ResultNC, 3), "Fury vs Wilder (NC 3)"},
+		{"unknown format", models.Fight{Fighter1: "Fury", Fighter2: "Wilder", Result: "DQ 6"}, "Fury vs Wilder (DQ 6)"},
+		{"no result", models.Fight{Fighter1: "Fury", Fighter2: "Wilder"}, "Fury vs Wilder"},
+		{"Cyrillic names", result("2024-05-18", "Александр Усик", "Тайсон Фьюри", models.ResultSD, 12), "Александр Усик def. Тайсон Фьюри (SD 12)"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Title(tt.fight); got != tt.want {
+				t.Errorf("Title = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLatest(t *testing.T) {
+	scheduled := result("2024-06-22", "Canelo", "Berlanga", "", 0)
+	scheduled.Status = models.StatusScheduled
+	undated := result("TBA", "Yoka", "Hrgovic", models.ResultUD, 10)
+	fights := []models.Fight{
+		result("2024-05-18", "Usyk", "Fury", models.ResultSD, 12),
+		scheduled,
+		result("2024-06-01", "Zhang", "Wilder", models.ResultKO, 5),
+		undated,
+		result("2024-06-01", "Bivol", "Zinad", models.ResultUD, 12),
+		result("2024-06-08", "Joshua", "Ngannou", models.ResultKO, 2),
+	}
+
+	tests := []struct {
+		name string
+		n    int
+		want []string
+	}{
+		{"all", 50, []string{"Joshua", "Bivol", "Zhang", "Usyk"}},
+		{"limited", 2, []string{"Joshua", "Bivol"}},
+		{"none", 0, []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := []string{}
+			for _, fight := range Latest(fights, tt.n) {
+				got = append(got, fight.Fighter1)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Latest = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestItemID(t *testing.T) {
+	usyk := result("2024-05-18", "Usyk", "Fury", models.ResultSD, 12)
+	swapped := result("2024-05-18", "fury", "USYK", models.ResultSD, 12)
+	other := result("2024-12-21", "Usyk", "Fury", models.ResultUD, 12)
+
+	if ItemID(usyk.Key) != ItemID(swapped.Key) {
+		t.Errorf("the same fight has the IDs %s and %s", ItemID(usyk.Key), ItemID(swapped.Key))
+	}
+	if ItemID(usyk.Key) == ItemID(other.Key) {
+		t.Errorf("the rematch has the ID of the first fight %s", ItemID(usyk.Key))
+	}
+	id := ItemID(result("2024-05-18", "Александр Усик", "Тайсон Фьюри", models.ResultSD, 12).Key)
+	if !strings.HasPrefix(id, "urn:easypars:fight:") || strings.ContainsAny(id, " |") || len(id) != len("urn:easypars:fight:")+32 {
+		t.Errorf("ItemID = %q, want a URN without spaces", id)
+	}
+}
+
+// parsedRSS is an RSS 2.0 document as a feed reader sees it
+type parsedRSS struct {
+	XMLName xml.Name `xml:"rss"`
+	Version string   `xml:"version,attr"`
+	Channel struct {
+		Title string `xml:"title"`
+		// Links are the link of the channel and its atom:link to itself
+		Links []struct {
+			XMLName xml.Name
+			Value   string `xml:",chardata"`
+		} `xml:"link"`
+		Description string `xml:"description"`
+		Items       []struct {
+			Title       string `xml:"title"`
+			Link        string `xml:"link"`
+			Description string `xml:"description"`
+			GUID        struct {
+				IsPermaLink string `xml:"isPermaLink,attr"`
+				Value       string `xml:",chardata"`
+			} `xml:"guid"`
+			PubDate string `xml:"pubDate"`
+		} `xml:"item"`
+	} `xml:"channel"`
+}
+
+// parsedAtom is an Atom 1.0 document as a feed reader sees it
+type parsedAtom struct {
+	XMLName xml.Name `xml:"http://www.w3.org/2005/Atom feed"`
+	Title   string   `xml:"title"`
+	ID      string   `xml:"id"`
+	Updated string   `xml:"updated"`
+	Author  struct {
+		Name string `xml:"name"`
+	} `xml:"author"`
+	Entries []struct {
+		Title   string `xml:"title"`
+		ID      string `xml:"id"`
+		Updated string `xml:"updated"`
+		Summary string `xml:"summary"`
+		Link    struct {
+			Href string `xml:"href,attr"`
+		} `xml:"link"`
+	} `xml:"entry"`
+}
+
+// feedItem is what a reader shows of an item, with the publication time
+// in RFC 3339
+type feedItem struct {
+	ID          string
+	Title       string
+	Link        string
+	Description string
+	Published   string
+}
+
+func TestWriteFeeds(t *testing.T) {
+	channel := Channel{
+		Title:       "EasyPars fight results",
+		Description: "Latest results",
+		Link:        "https://easypars.example/",
+		SelfURL:     "https://easypars.example/api/fights/feed.xml",
+		Updated:     time.Date(2024, time.June, 10, 12, 0, 0, 0, time.UTC),
+	}
+	fights := []models.Fight{
+		result("2024-05-18", "Александр Усик", "Тайсон Фьюри", models.ResultSD, 12),
+		result("2024-06-01", `Juan "El Gallo" <Estrada>`, "Smith & Sons", models.ResultUD, 12),
+	}
+	fights[0].Location = "Эр-Рияд"
+	var items []Item
+	for _, fight := range fights {
+		items = append(items, NewItem(fight, "https://easypars.example/api/fights/"+fight.ID))
+	}
+
+	tests := []struct {
+		name  string
+		write func(*bytes.Buffer) error
+		read  func(t *testing.T, data []byte) []feedItem
+	}{
+		{"rss", func(buf *bytes.Buffer) error { return WriteRSS(buf, channel, items) }, readRSS},
+		{"atom", func(buf *bytes.Buffer) error { return WriteAtom(buf, channel, items) }, readAtom},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := tt.write(&buf); err != nil {
+				t.Fatalf("writing the feed: %v", err)
+			}
+			data := buf.Bytes()
+			if !bytes.HasPrefix(data, []byte(xml.Header)) {
+				t.Errorf("the feed does not start with the XML declaration")
+			}
+			// Cyrillic is written as UTF-8, not as character references
+			if !bytes.Contains(data, []byte("Александр Усик def. Тайсон Фьюри (SD 12)")) || bytes.Contains(data, []byte("&#x4")) {
+				t.Errorf("the feed does not hold the Cyrillic title as UTF-8:\n%s", data)
+			}
+
+			got := tt.read(t, data)
+			if len(got) != len(items) {
+				t.Fatalf("the feed has %d items, want %d", len(got), len(items))
+			}
+			for i, item := range items {
+				want := feedItem{item.ID, item.Title, item.Link, item.Description, item.Published.Format(time.RFC3339)}
+				if !reflect.DeepEqual(got[i], want) {
+					t.Errorf("item %d = %+v, want %+v", i, got[i], want)
+				}
+			}
+		})
+	}
+}
+
+// readRSS decodes an RSS feed and checks the elements RSS 2.0 requires
+func readRSS(t *testing.T, data []byte) []feedItem {
+	t.Helper()
+
+	var doc parsedRSS
+	if err := xml.Unmarshal(data, &doc); err != nil {
+		t.Fatalf("decoding the RSS feed: %v", err)
+	}
+	var link string
+	for _, l := range doc.Channel.Links {
+		if l.XMLName.Space == "" {
+			link = l.Value
+		}
+	}
+	if doc.Version != "2.0" || doc.Channel.Title == "" || link == "" || doc.Channel.Description == "" {
+		t.Errorf("channel = %+v, want version 2.0 with a title, link and description", doc.Channel)
+	}
+	var items []feedItem
+	for _, item := range doc.Channel.Items {
+		if item.GUID.IsPermaLink != "false" {
+			t.Errorf("guid %s isPermaLink = %q, want false", item.GUID.Value, item.GUID.IsPermaLink)
+		}
+		published, err := time.Parse(time.RFC1123Z, item.PubDate)
+		if err != nil {
+			t.Errorf("pubDate %q is not an RFC 822 date: %v", item.PubDate, err)
+		}
+		items = append(items, feedItem{item.GUID.Value, item.Title, item.Link, item.Description, published.UTC().Format(time.RFC3339)})
+	}
+
+	return items
+}
+
+// readAtom decodes an Atom feed and checks the elements Atom 1.0 requires
+func readAtom(t *testing.T, data []byte) []feedItem {
+	t.Helper()
+
+	var doc parsedAtom
+	if err := xml.Unmarshal(data, &doc); err != nil {
+		t.Fatalf("decoding the Atom feed: %v", err)
+	}
+	if doc.Title == "" || doc.ID == "" || doc.Author.Name == "" {
+		t.Errorf("feed = %+v, want a title, an id and an author", doc)
+	}
+	if _, err := time.Parse(time.RFC3339, doc.Updated); err != nil {
+		t.Errorf("updated %q is not an RFC 3339 date: %v", doc.Updated, err)
+	}
+	var items []feedItem
+	for _, entry := range doc.Entries {
+		updated, err := time.Parse(time.RFC3339, entry.Updated)
+		if err != nil {
+			t.Errorf("entry updated %q is not an RFC 3339 date: %v", entry.Updated, err)
+		}
+		items = append(items, feedItem{entry.ID, entry.Title, entry.Link.Href, entry.Summary, updated.UTC().Format(time.RFC3339)})
+	}
+
+	return items
+}
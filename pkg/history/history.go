@@ -0,0 +1,275 @@
+package history
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"easypars/pkg/parser"
+)
+
+// Run statuses
+const (
+	StatusRunning   = "running"
+	StatusSucceeded = "succeeded"
+	StatusFailed    = "failed"
+)
+
+// ParseRun describes a single parse run
+type ParseRun struct {
+	ID         string     `json:"id"`
+	Trigger    string     `json:"trigger"`
+	Status     string     `json:"status"`
+	StartedAt  time.Time  `json:"started_at"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+	DurationMs int64      `json:"duration_ms"`
+	FightCount int        `json:"fight_count"`
+	IssueCount int        `json:"issue_count"`
+	Error      string     `json:"error,omitempty"`
+	// ErrorOrigin tells whether a failed run was our fault or the source's
+	// (see parser.ErrorOrigin), empty when the error is not classified
+	ErrorOrigin parser.ErrorOrigin `json:"error_origin,omitempty"`
+
+	// IssueCodes counts the data quality issues of the run by code
+	IssueCodes map[string]int `json:"issue_codes,omitempty"`
+
+	// Stages holds post-processing statistics of the run
+	Stages []parser.StageStats `json:"stages,omitempty"`
+	// Columns holds the column diagnostics of the run
+	Columns []parser.ColumnDiagnostics `json:"columns,omitempty"`
+	// ContentUnchanged is set when the page was unchanged and parsing was skipped
+	ContentUnchanged bool `json:"content_unchanged,omitempty"`
+	// Pruned counts the records deleted per data type by a retention run
+	Pruned map[string]int `json:"pruned,omitempty"`
+}
+
+// RunResult describes the outcome of a finished run
+type RunResult struct {
+	FightCount int
+	IssueCount int
+	IssueCodes map[string]int
+	Stages     []parser.StageStats
+	Columns    []parser.ColumnDiagnostics
+	Unchanged  bool
+	Pruned     map[string]int
+	Err        error
+}
+
+// ResultOf builds the run outcome from a parser result
+// The parser result may be nil when the run failed
+func ResultOf(result *parser.ParseResult, err error) RunResult {
+	runResult := RunResult{Err: err}
+	if result != nil {
+		runResult.FightCount = len(result.Fights)
+		runResult.IssueCount = len(result.Issues)
+		runResult.IssueCodes = parser.CountIssues(result.Issues)
+		runResult.Stages = result.Stages
+		runResult.Columns = result.Columns
+		runResult.Unchanged = result.Provenance.ContentUnchanged
+	}
+
+	return runResult
+}
+
+// History keeps the most recent parse runs together with their logs
+// Old runs are rotated out once the limit is reached and their log buffers
+// are released with them
+type History struct {
+	mu sync.RWMutex
+
+	// runs holds the runs ordered from oldest to newest
+	runs    []*ParseRun
+	logs    map[string]*logBuffer
+	maxRuns int
+	maxLogs int
+	seq     int64
+}
+
+// New creates a history keeping up to maxRuns runs and maxLogs log entries per run
+func New(maxRuns, maxLogs int) *History {
+	if maxRuns <= 0 {
+		maxRuns = 1
+	}
+
+	return &History{
+		logs:    make(map[string]*logBuffer),
+		maxRuns: maxRuns,
+		maxLogs: maxLogs,
+	}
+}
+
+// Start registers a new running parse run and returns a copy of it
+func (h *History) Start(trigger string) ParseRun {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.seq++
+	now := time.Now()
+	run := &ParseRun{
+		ID:        fmt.Sprintf("run_%d_%d", now.Unix(), h.seq),
+		Trigger:   trigger,
+		Status:    StatusRunning,
+		StartedAt: now,
+	}
+
+	h.runs = append(h.runs, run)
+	h.logs[run.ID] = newLogBuffer(h.maxLogs)
+
+	// Rotate old runs and release their log buffers
+	for len(h.runs) > h.maxRuns {
+		delete(h.logs, h.runs[0].ID)
+		h.runs = h.runs[1:]
+	}
+
+	return *run
+}
+
+// Finish marks a run as completed with the given outcome
+func (h *History) Finish(id string, result RunResult) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	run := h.find(id)
+	if run == nil {
+		return
+	}
+
+	now := time.Now()
+	run.FinishedAt = &now
+	run.DurationMs = now.Sub(run.StartedAt).Milliseconds()
+	run.FightCount = result.FightCount
+	run.IssueCount = result.IssueCount
+	run.IssueCodes = result.IssueCodes
+	run.Stages = result.Stages
+	run.Columns = result.Columns
+	run.ContentUnchanged = result.Unchanged
+	run.Pruned = result.Pruned
+	run.Status = StatusSucceeded
+	if result.Err != nil {
+		run.Status = StatusFailed
+		run.Error = result.Err.Error()
+		run.ErrorOrigin, _ = parser.OriginOf(result.Err)
+	}
+}
+
+// List returns copies of all retained runs, newest first
+func (h *History) List() []ParseRun {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	runs := make([]ParseRun, 0, len(h.runs))
+	for i := len(h.runs) - 1; i >= 0; i-- {
+		runs = append(runs, *h.runs[i])
+	}
+
+	return runs
+}
+
+// Prune removes finished runs started before the given time together with
+// their logs and returns how many runs were (or with dryRun would be) removed
+// Running runs are always kept
+func (h *History) Prune(before time.Time, dryRun bool) int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	kept := h.runs[:0:0]
+	pruned := 0
+	for _, run := range h.runs {
+		if run.Status == StatusRunning || !run.StartedAt.Before(before) {
+			kept = append(kept, run)
+			continue
+		}
+		pruned++
+		if !dryRun {
+			delete(h.logs, run.ID)
+		}
+	}
+	if !dryRun {
+		h.runs = kept
+	}
+
+	return pruned
+}
+
+// Summary aggregates the retained finished runs
+type Summary struct {
+	Runs             int `json:"runs"`
+	Failed           int `json:"failed"`
+	ContentUnchanged int `json:"content_unchanged"`
+	// UnchangedShare is the share of successful runs that skipped parsing
+	// because the page content was unchanged
+	UnchangedShare float64 `json:"unchanged_share"`
+}
+
+// Summary returns counters over the retained finished runs
+func (h *History) Summary() Summary {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	var summary Summary
+	for _, run := range h.runs {
+		if run.Status == StatusRunning {
+			continue
+		}
+		summary.Runs++
+		if run.Status == StatusFailed {
+			summary.Failed++
+		}
+		if run.ContentUnchanged {
+			summary.ContentUnchanged++
+		}
+	}
+
+	if succeeded := summary.Runs - summary.Failed; succeeded > 0 {
+		summary.UnchangedShare = float64(summary.ContentUnchanged) / float64(succeeded)
+	}
+
+	return summary
+}
+
+// Get returns a copy of the run with the given ID
+func (h *History) Get(id string) (ParseRun, bool) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	run := h.find(id)
+	if run == nil {
+		return ParseRun{}, false
+	}
+
+	return *run, true
+}
+
+// Logs returns the captured log entries of a run
+// The second value is false when the run is unknown or already rotated out
+func (h *History) Logs(id string) ([]LogEntry, bool) {
+	h.mu.RLock()
+	buf, ok := h.logs[id]
+	h.mu.RUnlock()
+	if !ok {
+		return nil, false
+	}
+
+	return buf.entries(), true
+}
+
+// appendLog stores a log entry for a run if the run is still retained
+func (h *History) appendLog(id string, entry LogEntry) {
+	h.mu.RLock()
+	buf, ok := h.logs[id]
+	h.mu.RUnlock()
+	if ok {
+		buf.add(entry)
+	}
+}
+
+// find returns the run with the given ID, the caller must hold the lock
+func (h *History) find(id string) *ParseRun {
+	for _, run := range h.runs {
+		if run.ID == id {
+			return run
+		}
+	}
+
+	return nil
+}
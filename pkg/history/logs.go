@@ -0,0 +1,152 @@
+package history
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// LogEntry is a captured log record of a parse run
+type LogEntry struct {
+	Time    time.Time         `json:"time"`
+	Level   string            `json:"level"`
+	Message string            `json:"message"`
+	Attrs   map[string]string `json:"attrs,omitempty"`
+}
+
+// logBuffer is a bounded, concurrency-safe list of log entries
+// Once the limit is reached further entries are counted but not kept
+type logBuffer struct {
+	mu      sync.Mutex
+	items   []LogEntry
+	limit   int
+	dropped int
+}
+
+// newLogBuffer creates a buffer keeping up to limit entries
+func newLogBuffer(limit int) *logBuffer {
+	return &logBuffer{limit: limit}
+}
+
+// add appends an entry unless the buffer is full
+func (b *logBuffer) add(entry LogEntry) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if len(b.items) >= b.limit {
+		b.dropped++
+		return
+	}
+	b.items = append(b.items, entry)
+}
+
+// entries returns a copy of the buffered entries
+func (b *logBuffer) entries() []LogEntry {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	entries := make([]LogEntry, len(b.items))
+	copy(entries, b.items)
+	return entries
+}
+
+// runIDKey is the context key holding the current run ID
+type runIDKey struct{}
+
+// WithRunID returns a context that binds log records to the given run
+func WithRunID(ctx context.Context, runID string) context.Context {
+	return context.WithValue(ctx, runIDKey{}, runID)
+}
+
+// RunIDFromContext returns the run ID bound to the context, if any
+func RunIDFromContext(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	runID, _ := ctx.Value(runIDKey{}).(string)
+	return runID
+}
+
+// RunLogHandler is a slog handler that tees records into the run history
+// Records of level info and above that belong to a run (run ID in the
+// context or a run_id attribute) are captured in the run's log buffer;
+// every record is also passed on to the next handler
+type RunLogHandler struct {
+	next    slog.Handler
+	history *History
+	attrs   []slog.Attr
+}
+
+// NewRunLogHandler wraps next and captures run records into history
+func NewRunLogHandler(next slog.Handler, history *History) *RunLogHandler {
+	return &RunLogHandler{next: next, history: history}
+}
+
+// Enabled reports whether the record should be handled
+func (h *RunLogHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return level >= slog.LevelInfo || h.next.Enabled(ctx, level)
+}
+
+// Handle captures the record for its run and forwards it to the next handler
+func (h *RunLogHandler) Handle(ctx context.Context, record slog.Record) error {
+	if record.Level >= slog.LevelInfo {
+		h.capture(ctx, record)
+	}
+
+	if h.next.Enabled(ctx, record.Level) {
+		return h.next.Handle(ctx, record)
+	}
+
+	return nil
+}
+
+// WithAttrs returns a handler that includes the attributes in every record
+func (h *RunLogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	combined := make([]slog.Attr, 0, len(h.attrs)+len(attrs))
+	combined = append(combined, h.attrs...)
+	combined = append(combined, attrs...)
+
+	return &RunLogHandler{next: h.next.WithAttrs(attrs), history: h.history, attrs: combined}
+}
+
+// WithGroup returns a handler that nests attributes under the group
+func (h *RunLogHandler) WithGroup(name string) slog.Handler {
+	return &RunLogHandler{next: h.next.WithGroup(name), history: h.history, attrs: h.attrs}
+}
+
+// capture stores the record in the buffer of the run it belongs to
+func (h *RunLogHandler) capture(ctx context.Context, record slog.Record) {
+	runID := RunIDFromContext(ctx)
+	attrs := make(map[string]string, len(h.attrs)+record.NumAttrs())
+
+	collect := func(attr slog.Attr) bool {
+		if attr.Key == "run_id" {
+			if runID == "" {
+				runID = attr.Value.String()
+			}
+			return true
+		}
+		attrs[attr.Key] = attr.Value.String()
+		return true
+	}
+	for _, attr := range h.attrs {
+		collect(attr)
+	}
+	record.Attrs(collect)
+
+	if runID == "" {
+		return
+	}
+
+	entry := LogEntry{
+		Time:    record.Time,
+		Level:   record.Level.String(),
+		Message: record.Message,
+	}
+	if len(attrs) > 0 {
+		entry.Attrs = attrs
+	}
+
+	h.history.appendLog(runID, entry)
+}
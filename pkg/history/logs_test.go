@@ -0,0 +1,125 @@
+package history
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"testing"
+)
+
+// newRunLogger returns a logger capturing run records into h
+func newRunLogger(h *History) *slog.Logger {
+	return slog.New(NewRunLogHandler(slog.NewTextHandler(io.Discard, nil), h))
+}
+
+func TestRunLogsAreKeptPerRun(t *testing.T) {
+	h := New(10, 500)
+	logger := newRunLogger(h)
+
+	first := h.Start("scheduler")
+	logger.InfoContext(WithRunID(context.Background(), first.ID), "Fetching fights page", "url", "https://vringe.example/")
+	h.Finish(first.ID, RunResult{})
+	second := h.Start("manual")
+	ctx := WithRunID(context.Background(), second.ID)
+	logger.WarnContext(ctx, "Source answered slowly")
+	logger.ErrorContext(ctx, "Parse failed", "error", "timeout")
+
+	tests := []struct {
+		run      string
+		messages []string
+	}{
+		{first.ID, []string{"Fetching fights page"}},
+		{second.ID, []string{"Source answered slowly", "Parse failed"}},
+	}
+	for _, tt := range tests {
+		entries, ok := h.Logs(tt.run)
+		if !ok {
+			t.Fatalf("Logs(%s) not found", tt.run)
+		}
+		if len(entries) != len(tt.messages) {
+			t.Fatalf("run %s has %d entries %+v, want %q", tt.run, len(entries), entries, tt.messages)
+		}
+		for i, entry := range entries {
+			if entry.Message != tt.messages[i] {
+				t.Errorf("run %s entry %d = %q, want %q", tt.run, i, entry.Message, tt.messages[i])
+			}
+		}
+	}
+
+	entries, _ := h.Logs(first.ID)
+	if entries[0].Level != "INFO" || entries[0].Attrs["url"] != "https://vringe.example/" {
+		t.Errorf("entry = %+v, want the level and the attributes", entries[0])
+	}
+}
+
+func TestRunLogCapture(t *testing.T) {
+	tests := []struct {
+		name  string
+		log   func(logger *slog.Logger, runID string)
+		count int
+	}{
+		{"run in the context", func(logger *slog.Logger, runID string) {
+			logger.InfoContext(WithRunID(context.Background(), runID), "captured")
+		}, 1},
+		{"run_id attribute", func(logger *slog.Logger, runID string) {
+			logger.Info("captured", "run_id", runID)
+		}, 1},
+		{"run_id of a derived logger", func(logger *slog.Logger, runID string) {
+			logger.With("run_id", runID).Info("captured")
+		}, 1},
+		{"debug records", func(logger *slog.Logger, runID string) {
+			logger.DebugContext(WithRunID(context.Background(), runID), "not captured")
+		}, 0},
+		{"records of no run", func(logger *slog.Logger, runID string) {
+			logger.Info("not captured")
+		}, 0},
+		{"records of an unknown run", func(logger *slog.Logger, runID string) {
+			logger.Info("not captured", "run_id", "run_0_0")
+		}, 0},
+		{"over the limit", func(logger *slog.Logger, runID string) {
+			for i := 0; i < 5; i++ {
+				logger.Info("captured", "run_id", runID)
+			}
+		}, 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := New(10, 3)
+			run := h.Start("test")
+			tt.log(newRunLogger(h), run.ID)
+
+			entries, _ := h.Logs(run.ID)
+			if len(entries) != tt.count {
+				t.Errorf("captured %d entries %+v, want %d", len(entries), entries, tt.count)
+			}
+		})
+	}
+}
+
+func TestRotationReleasesRunLogs(t *testing.T) {
+	h := New(2, 500)
+	logger := newRunLogger(h)
+
+	var ids []string
+	for i := 0; i < 3; i++ {
+		run := h.Start("scheduler")
+		logger.Info("run started", "run_id", run.ID)
+		h.Finish(run.ID, RunResult{})
+		ids = append(ids, run.ID)
+	}
+
+	if _, ok := h.Logs(ids[0]); ok {
+		t.Error("the log of the rotated run is still available")
+	}
+	if _, ok := h.Get(ids[0]); ok {
+		t.Error("the rotated run is still listed")
+	}
+	if len(h.logs) != 2 {
+		t.Errorf("%d log buffers are kept, want those of the 2 retained runs", len(h.logs))
+	}
+	for _, id := range ids[1:] {
+		if entries, ok := h.Logs(id); !ok || len(entries) != 1 {
+			t.Errorf("log of the retained run %s = %+v (%v), want its entry", id, entries, ok)
+		}
+	}
+}
@@ -0,0 +1,116 @@
+package locations
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Entry is a known location with its spellings
+type Entry struct {
+	// ID is the stable identifier, derived from the name when empty
+	ID string `yaml:"id"`
+	// Name is the canonical spelling shown by the API
+	Name string `yaml:"name"`
+	// Aliases are other spellings of the same location
+	Aliases []string `yaml:"aliases"`
+}
+
+// Dictionary holds the known locations
+// A nil dictionary is valid and knows no locations
+type Dictionary struct {
+	entries []Entry
+	// aliases holds the tokens of every spelling with the entry index
+	aliases []aliasTokens
+}
+
+// aliasTokens are the tokens of one spelling of an entry
+type aliasTokens struct {
+	entry  int
+	tokens []string
+}
+
+// NewDictionary validates the entries and builds the dictionary
+func NewDictionary(entries []Entry) (*Dictionary, error) {
+	d := &Dictionary{}
+	ids := make(map[string]bool)
+	for _, entry := range entries {
+		entry.Name = strings.TrimSpace(entry.Name)
+		if entry.Name == "" {
+			return nil, fmt.Errorf("location alias entry without a name")
+		}
+		if entry.ID == "" {
+			entry.ID = ID(strings.Join(Tokens(entry.Name), " "))
+		}
+		if ids[entry.ID] {
+			return nil, fmt.Errorf("duplicate location id %q", entry.ID)
+		}
+		ids[entry.ID] = true
+
+		idx := len(d.entries)
+		d.entries = append(d.entries, entry)
+		for _, spelling := range append([]string{entry.Name}, entry.Aliases...) {
+			if tokens := Tokens(spelling); len(tokens) > 0 {
+				d.aliases = append(d.aliases, aliasTokens{entry: idx, tokens: tokens})
+			}
+		}
+	}
+
+	return d, nil
+}
+
+// LoadDictionary reads the alias dictionary from a YAML file
+// A missing file gives an empty dictionary
+func LoadDictionary(path string) (*Dictionary, error) {
+	if path == "" {
+		return nil, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading location aliases: %w", err)
+	}
+
+	var file struct {
+		Locations []Entry `yaml:"locations"`
+	}
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("error parsing location aliases %s: %w", path, err)
+	}
+
+	return NewDictionary(file.Locations)
+}
+
+// Len returns the number of known locations
+func (d *Dictionary) Len() int {
+	if d == nil {
+		return 0
+	}
+	return len(d.entries)
+}
+
+// match returns the entry best matching the tokens above the dictionary threshold
+func (d *Dictionary) match(tokens []string) (Entry, bool) {
+	if d == nil {
+		return Entry{}, false
+	}
+
+	best, bestScore := -1, 0.0
+	for _, alias := range d.aliases {
+		score := Similarity(tokens, alias.tokens)
+		if score >= DictionaryThreshold && score > bestScore {
+			best, bestScore = alias.entry, score
+		}
+	}
+	if best < 0 {
+		return Entry{}, false
+	}
+
+	return d.entries[best], true
+}
@@ -0,0 +1,294 @@
+package locations
+
+import (
+	"sort"
+	"strings"
+)
+
+// Location is a canonical location with its spellings in the data
+type Location struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+	// Variants lists the spellings found in the data, sorted
+	Variants []string `json:"variants"`
+	// FightCount is the number of fights at the location
+	FightCount int `json:"fight_count"`
+	// Known tells whether the location comes from the alias dictionary
+	Known bool `json:"known"`
+}
+
+// Candidate is a pair of locations that look alike but were not merged
+// Candidates are meant for extending the alias dictionary by hand
+type Candidate struct {
+	LocationID      string   `json:"location_id"`
+	Name            string   `json:"name"`
+	OtherLocationID string   `json:"other_location_id"`
+	OtherName       string   `json:"other_name"`
+	Similarity      float64  `json:"similarity"`
+	Variants        []string `json:"variants"`
+}
+
+// Grouping is the result of canonicalizing a set of location spellings
+type Grouping struct {
+	// Locations are the canonical locations sorted by name, then ID
+	Locations []Location
+	// Candidates are the possible merges left for the dictionary
+	Candidates []Candidate
+	// byVariant maps every spelling to its location index
+	byVariant map[string]int
+	// ignored holds the spellings without any token
+	ignored map[string]bool
+}
+
+// LocationID returns the canonical location ID of a spelling
+func (g *Grouping) LocationID(spelling string) string {
+	if idx, ok := g.byVariant[spelling]; ok {
+		return g.Locations[idx].ID
+	}
+	return ""
+}
+
+// Recount returns the grouping with new fight counts of the same spellings
+// Clusters only depend on the spellings, so when no spelling appeared or
+// disappeared the clusters are kept and only the counts, the names of
+// locations outside the dictionary and the order are updated. The result
+// equals Group over the same counts. False is returned when the spellings
+// differ and Group has to run again.
+func (g *Grouping) Recount(counts map[string]int) (*Grouping, bool) {
+	spellings := 0
+	for spelling := range counts {
+		if strings.TrimSpace(spelling) == "" {
+			continue
+		}
+		if _, ok := g.byVariant[spelling]; !ok && !g.ignored[spelling] {
+			return nil, false
+		}
+		spellings++
+	}
+	if spellings != len(g.byVariant)+len(g.ignored) {
+		return nil, false
+	}
+
+	// Step 1: Recount the locations and rename those named after a spelling
+	recounted := &Grouping{
+		Locations: append([]Location(nil), g.Locations...),
+		byVariant: make(map[string]int, len(g.byVariant)),
+		ignored:   g.ignored,
+	}
+	for i, location := range recounted.Locations {
+		location.FightCount = 0
+		for _, variant := range location.Variants {
+			location.FightCount += counts[variant]
+		}
+		if !location.Known {
+			location.Name = ""
+			for _, variant := range location.Variants {
+				if location.Name == "" || counts[variant] > counts[location.Name] {
+					location.Name = variant
+				}
+			}
+		}
+		recounted.Locations[i] = location
+	}
+	sortLocations(recounted.Locations)
+
+	position := make(map[string]int, len(recounted.Locations))
+	for i, location := range recounted.Locations {
+		position[location.ID] = i
+		for _, variant := range location.Variants {
+			recounted.byVariant[variant] = i
+		}
+	}
+
+	// Step 2: Keep the candidate pairs, oriented and ordered the way
+	// findCandidates lists them for the new order of the locations
+	recounted.Candidates = append([]Candidate(nil), g.Candidates...)
+	for i, candidate := range recounted.Candidates {
+		if position[candidate.LocationID] > position[candidate.OtherLocationID] {
+			candidate.LocationID, candidate.OtherLocationID = candidate.OtherLocationID, candidate.LocationID
+		}
+		candidate.Name = recounted.Locations[position[candidate.LocationID]].Name
+		candidate.OtherName = recounted.Locations[position[candidate.OtherLocationID]].Name
+		recounted.Candidates[i] = candidate
+	}
+	sort.Slice(recounted.Candidates, func(i, j int) bool {
+		a, b := recounted.Candidates[i], recounted.Candidates[j]
+		if a.Similarity != b.Similarity {
+			return a.Similarity > b.Similarity
+		}
+		if position[a.LocationID] != position[b.LocationID] {
+			return position[a.LocationID] < position[b.LocationID]
+		}
+		return position[a.OtherLocationID] < position[b.OtherLocationID]
+	})
+
+	return recounted, true
+}
+
+// sortLocations orders locations by name, then ID
+func sortLocations(locations []Location) {
+	sort.Slice(locations, func(i, j int) bool {
+		if locations[i].Name != locations[j].Name {
+			return locations[i].Name < locations[j].Name
+		}
+		return locations[i].ID < locations[j].ID
+	})
+}
+
+// cluster is a set of spellings being merged
+type cluster struct {
+	entry    *Entry
+	tokens   []string
+	variants map[string]int
+}
+
+// Group canonicalizes the spellings, counts maps spelling -> fight count
+// Spellings matching the dictionary join its entry. The remaining ones are
+// merged when their token similarity reaches AutoMergeThreshold; pairs
+// between DictionaryThreshold and AutoMergeThreshold become candidates.
+// Spellings are processed in sorted order, so the result is deterministic.
+func Group(counts map[string]int, dict *Dictionary) *Grouping {
+	spellings := make([]string, 0, len(counts))
+	for spelling := range counts {
+		if strings.TrimSpace(spelling) != "" {
+			spellings = append(spellings, spelling)
+		}
+	}
+	sort.Strings(spellings)
+
+	g := &Grouping{byVariant: make(map[string]int), ignored: make(map[string]bool)}
+	known := make(map[string]*cluster)
+	var unknown []*cluster
+	for _, spelling := range spellings {
+		tokens := Tokens(spelling)
+		if len(tokens) == 0 {
+			g.ignored[spelling] = true
+			continue
+		}
+
+		if entry, ok := dict.match(tokens); ok {
+			c, exists := known[entry.ID]
+			if !exists {
+				entry := entry
+				c = &cluster{entry: &entry, tokens: Tokens(entry.Name), variants: make(map[string]int)}
+				known[entry.ID] = c
+			}
+			c.variants[spelling] = counts[spelling]
+			continue
+		}
+
+		var target *cluster
+		for _, c := range unknown {
+			if Similarity(tokens, c.tokens) >= AutoMergeThreshold {
+				target = c
+				break
+			}
+		}
+		if target == nil {
+			target = &cluster{tokens: tokens, variants: make(map[string]int)}
+			unknown = append(unknown, target)
+		}
+		target.variants[spelling] = counts[spelling]
+	}
+
+	for _, c := range known {
+		g.Locations = append(g.Locations, c.location())
+	}
+	for _, c := range unknown {
+		g.Locations = append(g.Locations, c.location())
+	}
+	sortLocations(g.Locations)
+	for i, location := range g.Locations {
+		for _, variant := range location.Variants {
+			g.byVariant[variant] = i
+		}
+	}
+
+	g.Candidates = findCandidates(g.Locations)
+
+	return g
+}
+
+// location converts the cluster into a canonical location
+// Clusters without a dictionary entry are named after their most frequent
+// spelling (alphabetically first on ties)
+func (c *cluster) location() Location {
+	location := Location{}
+	for variant, count := range c.variants {
+		location.Variants = append(location.Variants, variant)
+		location.FightCount += count
+	}
+	sort.Strings(location.Variants)
+
+	if c.entry != nil {
+		location.ID = c.entry.ID
+		location.Name = c.entry.Name
+		location.Known = true
+		return location
+	}
+
+	for _, variant := range location.Variants {
+		if location.Name == "" || c.variants[variant] > c.variants[location.Name] {
+			location.Name = variant
+		}
+	}
+	location.ID = ID(strings.Join(c.tokens, " "))
+
+	return location
+}
+
+// findCandidates lists the pairs of locations outside the dictionary that
+// look alike without reaching the auto-merge threshold
+func findCandidates(locations []Location) []Candidate {
+	tokens := make([][]string, len(locations))
+	for i, location := range locations {
+		for _, variant := range location.Variants {
+			tokens[i] = mergeTokens(tokens[i], Tokens(variant))
+		}
+	}
+
+	var candidates []Candidate
+	for i := range locations {
+		if locations[i].Known {
+			continue
+		}
+		for j := i + 1; j < len(locations); j++ {
+			if locations[j].Known {
+				continue
+			}
+			score := Similarity(tokens[i], tokens[j])
+			if score < DictionaryThreshold {
+				continue
+			}
+			variants := append(append([]string(nil), locations[i].Variants...), locations[j].Variants...)
+			sort.Strings(variants)
+			candidates = append(candidates, Candidate{
+				LocationID:      locations[i].ID,
+				Name:            locations[i].Name,
+				OtherLocationID: locations[j].ID,
+				OtherName:       locations[j].Name,
+				Similarity:      score,
+				Variants:        variants,
+			})
+		}
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool { return candidates[i].Similarity > candidates[j].Similarity })
+
+	return candidates
+}
+
+// mergeTokens returns the sorted union of two sorted token lists
+func mergeTokens(a, b []string) []string {
+	set := make(map[string]bool, len(a)+len(b))
+	for _, token := range append(append([]string(nil), a...), b...) {
+		set[token] = true
+	}
+	merged := make([]string, 0, len(set))
+	for token := range set {
+		merged = append(merged, token)
+	}
+	sort.Strings(merged)
+
+	return merged
+}
@@ -0,0 +1,108 @@
+// Package locations merges different spellings of the same location
+// Locations are normalized (lowercase, no punctuation, Cyrillic
+// transliterated to Latin) and compared as token sets. Known arenas are
+// matched through an alias dictionary; unknown spellings are merged only
+// when they are nearly identical and otherwise reported as candidates.
+package locations
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// Similarity thresholds of the token Jaccard index
+const (
+	// DictionaryThreshold is the similarity at which a location matches a
+	// dictionary alias
+	DictionaryThreshold = 0.7
+	// AutoMergeThreshold is the similarity at which two locations missing
+	// from the dictionary are merged without it
+	AutoMergeThreshold = 0.9
+)
+
+// translit maps Cyrillic letters to Latin
+var translit = map[rune]string{
+	'а': "a", 'б': "b", 'в': "v", 'г': "g", 'д': "d", 'е': "e", 'ё': "e",
+	'ж': "zh", 'з': "z", 'и': "i", 'й': "i", 'к': "k", 'л': "l", 'м': "m",
+	'н': "n", 'о': "o", 'п': "p", 'р': "r", 'с': "s", 'т': "t", 'у': "u",
+	'ф': "f", 'х': "kh", 'ц': "ts", 'ч': "ch", 'ш': "sh", 'щ': "shch",
+	'ъ': "", 'ы': "y", 'ь': "", 'э': "e", 'ю': "yu", 'я': "ya",
+	'і': "i", 'ї': "i", 'є': "e", 'ґ': "g",
+}
+
+// Transliterate lowercases the text and transliterates Cyrillic to Latin
+// Other characters are kept as they are
+func Transliterate(text string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(text) {
+		if latin, ok := translit[r]; ok {
+			b.WriteString(latin)
+		} else {
+			b.WriteRune(r)
+		}
+	}
+
+	return b.String()
+}
+
+// Normalize lowercases the location, transliterates Cyrillic to Latin and
+// replaces punctuation with spaces
+func Normalize(location string) string {
+	var b strings.Builder
+	for _, r := range Transliterate(location) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			b.WriteRune(r)
+		} else {
+			b.WriteByte(' ')
+		}
+	}
+
+	return strings.Join(strings.Fields(b.String()), " ")
+}
+
+// Tokens returns the sorted distinct tokens of the normalized location
+func Tokens(location string) []string {
+	seen := make(map[string]bool)
+	var tokens []string
+	for _, token := range strings.Fields(Normalize(location)) {
+		if !seen[token] {
+			seen[token] = true
+			tokens = append(tokens, token)
+		}
+	}
+	sort.Strings(tokens)
+
+	return tokens
+}
+
+// Similarity is the Jaccard index of the token sets of two token lists
+func Similarity(a, b []string) float64 {
+	if len(a) == 0 && len(b) == 0 {
+		return 0
+	}
+
+	set := make(map[string]bool, len(a))
+	for _, token := range a {
+		set[token] = true
+	}
+	common := 0
+	union := len(set)
+	for _, token := range b {
+		if set[token] {
+			common++
+		} else {
+			union++
+		}
+	}
+
+	return float64(common) / float64(union)
+}
+
+// ID builds the stable identifier of a canonical location key
+func ID(key string) string {
+	sum := sha1.Sum([]byte(key))
+	return "loc_" + hex.EncodeToString(sum[:6])
+}
@@ -0,0 +1,193 @@
+package locations
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// loadRepoDictionary loads the location_aliases.yaml shipped with the service
+func loadRepoDictionary(t *testing.T) *Dictionary {
+	t.Helper()
+
+	dict, err := LoadDictionary(filepath.Join("..", "..", "location_aliases.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dict.Len() == 0 {
+		t.Fatal("location_aliases.yaml has no locations")
+	}
+
+	return dict
+}
+
+func TestThreeSpellingsOfOneArenaMerge(t *testing.T) {
+	counts := map[string]int{
+		"США, Лас-Вегас, T-Mobile Arena": 3,
+		"Лас-Вегас, Т-Мобайл Арена":      2,
+		"T-Mobile Arena, Las Vegas":      1,
+	}
+
+	g := Group(counts, loadRepoDictionary(t))
+	if len(g.Locations) != 1 {
+		t.Fatalf("got %d locations %+v, want the three spellings merged", len(g.Locations), g.Locations)
+	}
+	location := g.Locations[0]
+	want := Location{
+		ID:         "t-mobile-arena",
+		Name:       "T-Mobile Arena, Las Vegas",
+		Variants:   []string{"T-Mobile Arena, Las Vegas", "Лас-Вегас, Т-Мобайл Арена", "США, Лас-Вегас, T-Mobile Arena"},
+		FightCount: 6,
+		Known:      true,
+	}
+	if !reflect.DeepEqual(location, want) {
+		t.Errorf("location = %+v, want %+v", location, want)
+	}
+	for spelling := range counts {
+		if id := g.LocationID(spelling); id != "t-mobile-arena" {
+			t.Errorf("LocationID(%q) = %q, want t-mobile-arena", spelling, id)
+		}
+	}
+	if len(g.Candidates) != 0 {
+		t.Errorf("candidates = %+v, want none", g.Candidates)
+	}
+}
+
+func TestArenasOfOneCityStayApart(t *testing.T) {
+	counts := map[string]int{
+		"T-Mobile Arena, Las Vegas":         1,
+		"MGM Grand Garden Arena, Las Vegas": 1,
+		"Michelob Ultra Arena, Las Vegas":   1,
+	}
+
+	g := Group(counts, loadRepoDictionary(t))
+	if len(g.Locations) != 3 {
+		t.Fatalf("got %d locations %+v, want the three arenas apart", len(g.Locations), g.Locations)
+	}
+	ids := make(map[string]bool)
+	for spelling := range counts {
+		ids[g.LocationID(spelling)] = true
+	}
+	if len(ids) != 3 {
+		t.Errorf("the arenas share location IDs: %v", ids)
+	}
+	if len(g.Candidates) != 0 {
+		t.Errorf("candidates = %+v, want none for different arenas", g.Candidates)
+	}
+}
+
+func TestSimilarSpellingsOutsideTheDictionary(t *testing.T) {
+	counts := map[string]int{
+		// Same tokens: merged without the dictionary
+		"O2 Arena, London": 2,
+		"O2 Arena London":  1,
+		// 5 of 6 tokens in common: reported, not merged
+		"Madison Square Garden, New York":      4,
+		"Madison Square Garden, New York, USA": 1,
+	}
+
+	g := Group(counts, nil)
+	if len(g.Locations) != 3 {
+		t.Fatalf("got %d locations %+v, want 3", len(g.Locations), g.Locations)
+	}
+	if g.LocationID("O2 Arena, London") != g.LocationID("O2 Arena London") {
+		t.Error("identical token sets were not merged")
+	}
+	if g.LocationID("Madison Square Garden, New York") == g.LocationID("Madison Square Garden, New York, USA") {
+		t.Error("spellings below the auto-merge threshold were merged without the dictionary")
+	}
+
+	if len(g.Candidates) != 1 {
+		t.Fatalf("candidates = %+v, want the Madison Square Garden pair", g.Candidates)
+	}
+	candidate := g.Candidates[0]
+	if candidate.Similarity < DictionaryThreshold || candidate.Similarity >= AutoMergeThreshold {
+		t.Errorf("candidate similarity = %v, want between the thresholds", candidate.Similarity)
+	}
+	wantVariants := []string{"Madison Square Garden, New York", "Madison Square Garden, New York, USA"}
+	if !reflect.DeepEqual(candidate.Variants, wantVariants) {
+		t.Errorf("candidate variants = %q, want %q", candidate.Variants, wantVariants)
+	}
+
+	// The merged location is named after its most frequent spelling
+	for _, location := range g.Locations {
+		if location.ID == g.LocationID("O2 Arena London") && (location.Name != "O2 Arena, London" || location.FightCount != 3) {
+			t.Errorf("merged location = %+v, want O2 Arena, London with 3 fights", location)
+		}
+	}
+}
+
+func TestRecountKeepsTheClusters(t *testing.T) {
+	dict := loadRepoDictionary(t)
+	counts := map[string]int{"O2 Arena, London": 2, "O2 Arena London": 1, "Лас-Вегас, Т-Мобайл Арена": 1, "": 4}
+	g := Group(counts, dict)
+
+	counts["O2 Arena London"] = 5
+	recounted, ok := g.Recount(counts)
+	if !ok {
+		t.Fatal("Recount of the same spellings failed")
+	}
+	if want := Group(counts, dict); !reflect.DeepEqual(recounted, want) {
+		t.Errorf("Recount = %+v, want the result of Group %+v", recounted, want)
+	}
+
+	counts["Wembley Stadium, London"] = 1
+	if _, ok := g.Recount(counts); ok {
+		t.Error("Recount with a new spelling succeeded, want Group to run again")
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		location string
+		want     string
+	}{
+		{"T-Mobile Arena, Las Vegas", "t mobile arena las vegas"},
+		{"Лас-Вегас, Т-Мобайл Арена", "las vegas t mobail arena"},
+		{"  Ёбург;  Екатеринбург ", "eburg ekaterinburg"},
+		{"Київ", "kiiv"},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := Normalize(tt.location); got != tt.want {
+			t.Errorf("Normalize(%q) = %q, want %q", tt.location, got, tt.want)
+		}
+	}
+}
+
+func TestSimilarity(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want float64
+	}{
+		{"O2 Arena, London", "London O2 Arena", 1},
+		{"Madison Square Garden, New York", "Madison Square Garden, New York, USA", 5.0 / 6},
+		{"T-Mobile Arena", "Wembley Stadium", 0},
+		{"", "", 0},
+	}
+	for _, tt := range tests {
+		if got := Similarity(Tokens(tt.a), Tokens(tt.b)); got != tt.want {
+			t.Errorf("Similarity(%q, %q) = %v, want %v", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestDictionary(t *testing.T) {
+	if dict, err := LoadDictionary(filepath.Join(t.TempDir(), "missing.yaml")); err != nil || dict.Len() != 0 {
+		t.Errorf("LoadDictionary of a missing file = %v, %v, want an empty dictionary", dict, err)
+	}
+	if _, err := NewDictionary([]Entry{{Name: " "}}); err == nil {
+		t.Error("NewDictionary accepted an entry without a name")
+	}
+	if _, err := NewDictionary([]Entry{{ID: "a", Name: "One"}, {ID: "a", Name: "Two"}}); err == nil {
+		t.Error("NewDictionary accepted a duplicate ID")
+	}
+
+	dict, err := NewDictionary([]Entry{{Name: "O2 Arena, London"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id := Group(map[string]int{"O2 Arena London": 1}, dict).LocationID("O2 Arena London"); id != ID("arena london o2") {
+		t.Errorf("derived ID = %q, want the ID of the name tokens", id)
+	}
+}
@@ -0,0 +1,75 @@
+// Package logging builds the structured logger of the application
+// Records are written as text or JSON to stderr or a file; records logged
+// with the context of an API request carry its request ID (see requestid).
+// Packages log through slog.Default unless they are given a logger, so the
+// logger is installed as the default one by main.
+package logging
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+
+	"easypars/pkg/requestid"
+)
+
+// Options select the level, format and output of the logs
+type Options struct {
+	// Level is debug, info, warn or error; empty means info
+	Level string
+	// Format is text or json; empty means text
+	Format string
+	// File receives the logs, appended; empty means stderr
+	File string
+}
+
+// New creates the logger of the options
+// The returned closer closes the log file; it does nothing for stderr.
+func New(opts Options) (*slog.Logger, io.Closer, error) {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(defaultString(opts.Level, "info"))); err != nil {
+		return nil, nil, fmt.Errorf("invalid log level %q: %w", opts.Level, err)
+	}
+
+	var out io.Writer = os.Stderr
+	var closer io.Closer = nopCloser{}
+	if opts.File != "" {
+		file, err := os.OpenFile(opts.File, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+		if err != nil {
+			return nil, nil, fmt.Errorf("error opening log file: %w", err)
+		}
+		out, closer = file, file
+	}
+
+	handlerOpts := &slog.HandlerOptions{Level: level}
+	var handler slog.Handler
+	switch strings.ToLower(defaultString(opts.Format, "text")) {
+	case "text":
+		handler = slog.NewTextHandler(out, handlerOpts)
+	case "json":
+		handler = slog.NewJSONHandler(out, handlerOpts)
+	default:
+		closer.Close()
+		return nil, nil, fmt.Errorf("invalid log format %q, must be text or json", opts.Format)
+	}
+
+	return slog.New(requestid.NewLogHandler(handler)), closer, nil
+}
+
+// defaultString returns value, or fallback when it is empty
+func defaultString(value, fallback string) string {
+	if value == "" {
+		return fallback
+	}
+	return value
+}
+
+// nopCloser is the closer of stderr
+type nopCloser struct{}
+
+// Close does nothing
+func (nopCloser) Close() error {
+	return nil
+}
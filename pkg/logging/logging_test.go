@@ -0,0 +1,168 @@
+package logging
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"easypars/pkg/clock"
+	"easypars/pkg/parser"
+	"easypars/pkg/requestid"
+)
+
+// readRecords returns the JSON records of a log file
+func readRecords(t *testing.T, path string) []map[string]any {
+	t.Helper()
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var records []map[string]any
+	for _, line := range strings.Split(strings.TrimSpace(string(data)), "\n") {
+		var record map[string]any
+		if err := json.Unmarshal([]byte(line), &record); err != nil {
+			t.Fatalf("log line %q is not JSON: %v", line, err)
+		}
+		records = append(records, record)
+	}
+	return records
+}
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		name    string
+		opts    Options
+		err     string
+		logged  []string
+		jsonOut bool
+	}{
+		{"defaults", Options{}, "", []string{"info", "warn"}, false},
+		{"debug json", Options{Level: "debug", Format: "json"}, "", []string{"debug", "info", "warn"}, true},
+		{"warn level", Options{Level: "WARN", Format: "JSON"}, "", []string{"warn"}, true},
+		{"invalid level", Options{Level: "verbose"}, "invalid log level", nil, false},
+		{"invalid format", Options{Format: "xml"}, "invalid log format", nil, false},
+		{"unwritable file", Options{File: filepath.Join("missing", "dir", "easypars.log")}, "error opening log file", nil, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := tt.opts
+			if opts.File == "" {
+				opts.File = filepath.Join(t.TempDir(), "easypars.log")
+			} else {
+				opts.File = filepath.Join(t.TempDir(), opts.File)
+			}
+			logger, closer, err := New(opts)
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Fatalf("New(%+v) = %v, want an error about %q", tt.opts, err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("New(%+v): %v", tt.opts, err)
+			}
+
+			logger.Debug("debug")
+			logger.Info("info")
+			logger.Warn("warn")
+			closer.Close()
+
+			data, err := os.ReadFile(opts.File)
+			if err != nil {
+				t.Fatal(err)
+			}
+			lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+			if len(lines) != len(tt.logged) {
+				t.Fatalf("log = %q, want the records %q", lines, tt.logged)
+			}
+			for i, line := range lines {
+				if got := strings.HasPrefix(line, "{"); got != tt.jsonOut {
+					t.Errorf("record %q is JSON %v, want %v", line, got, tt.jsonOut)
+				}
+				if !strings.Contains(line, "msg="+tt.logged[i]) && !strings.Contains(line, `"msg":"`+tt.logged[i]+`"`) {
+					t.Errorf("record %d = %q, want %s", i, line, tt.logged[i])
+				}
+			}
+		})
+	}
+}
+
+func TestNewAppendsToTheFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "easypars.log")
+	for i := 0; i < 2; i++ {
+		logger, closer, err := New(Options{Format: "json", File: path})
+		if err != nil {
+			t.Fatal(err)
+		}
+		logger.Info("started", "run", i)
+		closer.Close()
+	}
+	if records := readRecords(t, path); len(records) != 2 {
+		t.Errorf("records = %v, want both runs", records)
+	}
+}
+
+// TestParseLogs is a smoke test of the JSON records of a parse: every
+// record has its fields as attributes, not formatted into the message
+func TestParseLogs(t *testing.T) {
+	page := `<html><body><div class="month">Май 2024</div><table>` +
+		`<tr><td class="date">18</td><td class="place">Riyadh</td><td class="boxer_1">Usyk</td><td class="vs">SD</td><td class="boxer_2">Fury</td></tr>` +
+		`</table></body></html>`
+	src := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/broken" {
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		fmt.Fprint(w, page)
+	}))
+	defer src.Close()
+
+	path := filepath.Join(t.TempDir(), "easypars.log")
+	logger, closer, err := New(Options{Format: "json", File: path})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := requestid.WithID(context.Background(), "smoke-1")
+	for _, url := range []string{src.URL + "/", src.URL + "/broken"} {
+		p := parser.NewParser(url)
+		p.Logger = logger
+		p.Clock = clock.Fixed{Time: time.Date(2024, time.June, 10, 12, 0, 0, 0, time.UTC)}
+		p.ParseFightsContext(ctx)
+	}
+	closer.Close()
+
+	// required are the attributes of the records the test relies on
+	required := map[string][]string{
+		"Parsed fights":               {"url", "fight_count", "duration_ms"},
+		"Failed to fetch fights page": {"url", "error"},
+	}
+	found := make(map[string]bool)
+	for _, record := range readRecords(t, path) {
+		for _, key := range []string{"time", "level", "msg", "request_id"} {
+			if _, ok := record[key]; !ok {
+				t.Errorf("record %v has no %s", record, key)
+			}
+		}
+		msg, _ := record["msg"].(string)
+		for _, key := range required[msg] {
+			if _, ok := record[key]; !ok {
+				t.Errorf("record %q has no %s: %v", msg, key, record)
+			}
+		}
+		found[msg] = true
+	}
+	for msg := range required {
+		if !found[msg] {
+			t.Errorf("no %q record was logged", msg)
+		}
+	}
+}
@@ -0,0 +1,144 @@
+// Package metrics exposes the Prometheus metrics of the service
+// The collectors are registered once, in a registry of this package, so
+// creating several routers or parsers (e.g. in tests) does not register
+// them twice. The parser and the API record through the functions of this
+// package and do not use the Prometheus client themselves; Handler serves
+// the registry for scrapes of /metrics.
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// namespace prefixes the names of the metrics of the service
+const namespace = "easypars"
+
+// UnmatchedRoute is the route label of requests matching no route, so
+// scans of random paths do not create a series per path
+const UnmatchedRoute = "unmatched"
+
+var (
+	registry = prometheus.NewRegistry()
+
+	httpRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "http_requests_total",
+		Help:      "HTTP requests handled, by method, route and status.",
+	}, []string{"method", "route", "status"})
+
+	httpDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "Duration of the HTTP requests, by method and route.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route"})
+
+	parseDuration = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "parse_duration_seconds",
+		Help:      "Duration of the parses of a source page, fetch included.",
+		Buckets:   []float64{0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60},
+	})
+
+	parseFights = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "parse_fights",
+		Help:      "Fights parsed per successful parse of a source page.",
+		Buckets:   []float64{0, 10, 25, 50, 100, 200, 500, 1000},
+	})
+
+	parseErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "parse_errors_total",
+		Help:      "Failed parses of a source page, by error type (the origin of the error).",
+	}, []string{"type"})
+
+	cacheRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "cache_requests_total",
+		Help:      "Cache lookups, by cache and result (hit or miss).",
+	}, []string{"cache", "result"})
+
+	upstreamResponses = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "upstream_responses_total",
+		Help:      "Responses of the source, by status code; \"error\" counts requests without a response.",
+	}, []string{"code"})
+)
+
+func init() {
+	registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		httpRequests,
+		httpDuration,
+		parseDuration,
+		parseFights,
+		parseErrors,
+		cacheRequests,
+		upstreamResponses,
+	)
+}
+
+// Handler serves the metrics in the Prometheus exposition format
+func Handler() http.Handler {
+	return promhttp.HandlerFor(registry, promhttp.HandlerOpts{})
+}
+
+// ObserveHTTPRequest records a handled HTTP request
+// route is the route pattern (e.g. /api/fights/:id), not the path, so the
+// series stay few.
+func ObserveHTTPRequest(method, route string, status int, duration time.Duration) {
+	if route == "" {
+		route = UnmatchedRoute
+	}
+	httpRequests.WithLabelValues(method, route, strconv.Itoa(status)).Inc()
+	httpDuration.WithLabelValues(method, route).Observe(duration.Seconds())
+}
+
+// ObserveParse records a successful parse of a source page
+func ObserveParse(duration time.Duration, fights int) {
+	parseDuration.Observe(duration.Seconds())
+	parseFights.Observe(float64(fights))
+}
+
+// ParseFailed records a failed parse of a source page
+// errorType is the origin of the error, e.g. "source" or "network".
+func ParseFailed(duration time.Duration, errorType string) {
+	parseDuration.Observe(duration.Seconds())
+	parseErrors.WithLabelValues(errorType).Inc()
+}
+
+// Names of the caches in the cache metrics
+const (
+	CacheFights = "fights"
+	CachePages  = "pages"
+	CacheCards  = "cards"
+	CacheEmbeds = "embeds"
+)
+
+// CacheLookup records a lookup of the named cache and whether it found a
+// value
+func CacheLookup(cache string, hit bool) {
+	result := "miss"
+	if hit {
+		result = "hit"
+	}
+	cacheRequests.WithLabelValues(cache, result).Inc()
+}
+
+// UpstreamResponse records a response of the source with its status code
+func UpstreamResponse(status int) {
+	upstreamResponses.WithLabelValues(strconv.Itoa(status)).Inc()
+}
+
+// UpstreamError records a request to the source that got no response
+func UpstreamError() {
+	upstreamResponses.WithLabelValues("error").Inc()
+}
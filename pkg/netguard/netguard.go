@@ -0,0 +1,124 @@
+// Package netguard keeps outbound requests to user supplied URLs away from
+// internal addresses
+// Webhook deliveries and extra parser sources are URLs registered through
+// the admin API. Without a check they could make the service request
+// itself, the local network or the cloud metadata endpoint. The check runs
+// when a connection is made, on the address the host name resolved to, so
+// a name that resolves to an internal address, or rebinds to one after
+// the registration, is refused too.
+package netguard
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/netip"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// ErrBlockedAddress is returned for a connection to a loopback, private,
+// link-local or otherwise internal address
+var ErrBlockedAddress = errors.New("address not allowed")
+
+// blockedPrefixes are the networks guarded connections may not reach: the
+// service itself, the local network, cloud metadata endpoints
+// (169.254.169.254) and addresses that are not routable on the internet
+var blockedPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),
+	netip.MustParsePrefix("10.0.0.0/8"),
+	netip.MustParsePrefix("100.64.0.0/10"),
+	netip.MustParsePrefix("127.0.0.0/8"),
+	netip.MustParsePrefix("169.254.0.0/16"),
+	netip.MustParsePrefix("172.16.0.0/12"),
+	netip.MustParsePrefix("192.0.0.0/24"),
+	netip.MustParsePrefix("192.168.0.0/16"),
+	netip.MustParsePrefix("198.18.0.0/15"),
+	netip.MustParsePrefix("224.0.0.0/4"),
+	netip.MustParsePrefix("240.0.0.0/4"),
+	netip.MustParsePrefix("::/128"),
+	netip.MustParsePrefix("::1/128"),
+	netip.MustParsePrefix("fc00::/7"),
+	netip.MustParsePrefix("fe80::/10"),
+	netip.MustParsePrefix("ff00::/8"),
+}
+
+// Blocked reports whether guarded connections may not reach the address
+// IPv4 addresses written as IPv6 (::ffff:127.0.0.1) are checked as IPv4.
+func Blocked(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	for _, prefix := range blockedPrefixes {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// BlockedHost reports whether the host is an IP address guarded
+// connections may not reach; host names are not resolved
+func BlockedHost(host string) bool {
+	addr, err := netip.ParseAddr(strings.Trim(host, "[]"))
+	return err == nil && Blocked(addr)
+}
+
+// Allowlist holds the hosts exempt from the guard, lowercased and without
+// IPv6 brackets
+type Allowlist map[string]bool
+
+// NewAllowlist builds the allowlist of the host names and IP addresses
+func NewAllowlist(hosts []string) Allowlist {
+	allowed := make(Allowlist, len(hosts))
+	for _, host := range hosts {
+		if host = normalizeHost(host); host != "" {
+			allowed[host] = true
+		}
+	}
+
+	return allowed
+}
+
+// Allows reports whether the host of a URL or a dial address is exempt
+func (a Allowlist) Allows(host string) bool {
+	return a[normalizeHost(host)]
+}
+
+func normalizeHost(host string) string {
+	return strings.Trim(strings.ToLower(strings.TrimSpace(host)), "[]")
+}
+
+// Control is a net.Dialer Control function refusing blocked addresses
+// It runs after the DNS lookup, for every address the dialer tries.
+func Control(network, address string, _ syscall.RawConn) error {
+	addrPort, err := netip.ParseAddrPort(address)
+	if err != nil {
+		return fmt.Errorf("%w: %s", ErrBlockedAddress, address)
+	}
+	if Blocked(addrPort.Addr()) {
+		return fmt.Errorf("%w: %s is an internal address", ErrBlockedAddress, addrPort.Addr())
+	}
+
+	return nil
+}
+
+// DialFunc is the signature of http.Transport.DialContext
+type DialFunc func(ctx context.Context, network, addr string) (net.Conn, error)
+
+// Dialer returns a dial function guarding the connections for which
+// guarded returns true; the others are dialed as they are
+// guarded gets the dial context and the host of the dial address.
+func Dialer(timeout time.Duration, guarded func(ctx context.Context, host string) bool) DialFunc {
+	open := &net.Dialer{Timeout: timeout, KeepAlive: 30 * time.Second}
+	checked := &net.Dialer{Timeout: timeout, KeepAlive: 30 * time.Second, Control: Control}
+
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		host, _, err := net.SplitHostPort(addr)
+		if err != nil || guarded(ctx, host) {
+			return checked.DialContext(ctx, network, addr)
+		}
+		return open.DialContext(ctx, network, addr)
+	}
+}
@@ -0,0 +1,102 @@
+package netguard
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/netip"
+	"testing"
+	"time"
+)
+
+func TestBlocked(t *testing.T) {
+	tests := []struct {
+		addr    string
+		blocked bool
+	}{
+		{"127.0.0.1", true},
+		{"127.1.2.3", true},
+		{"10.0.0.12", true},
+		{"172.16.0.1", true},
+		{"172.31.255.255", true},
+		{"192.168.1.1", true},
+		{"169.254.169.254", true},
+		{"100.64.0.1", true},
+		{"0.0.0.0", true},
+		{"224.0.0.1", true},
+		{"255.255.255.255", true},
+		{"::1", true},
+		{"::", true},
+		{"::ffff:127.0.0.1", true},
+		{"::ffff:169.254.169.254", true},
+		{"fd00::1", true},
+		{"fe80::1", true},
+		{"ff02::1", true},
+		{"8.8.8.8", false},
+		{"172.32.0.1", false},
+		{"93.184.216.34", false},
+		{"2606:4700:4700::1111", false},
+	}
+	for _, tt := range tests {
+		if got := Blocked(netip.MustParseAddr(tt.addr)); got != tt.blocked {
+			t.Errorf("Blocked(%s) = %v, want %v", tt.addr, got, tt.blocked)
+		}
+		if got := BlockedHost("[" + tt.addr + "]"); got != tt.blocked {
+			t.Errorf("BlockedHost([%s]) = %v, want %v", tt.addr, got, tt.blocked)
+		}
+	}
+}
+
+func TestBlockedHostDoesNotResolve(t *testing.T) {
+	if BlockedHost("localhost") {
+		t.Error("BlockedHost resolved the host name localhost")
+	}
+}
+
+func TestAllowlist(t *testing.T) {
+	allowed := NewAllowlist([]string{" Bot.LAN ", "[fd00::1]", "10.0.0.12", ""})
+
+	for host, want := range map[string]bool{
+		"bot.lan":   true,
+		"BOT.lan":   true,
+		"fd00::1":   true,
+		"[fd00::1]": true,
+		"10.0.0.12": true,
+		"10.0.0.13": false,
+		"":          false,
+	} {
+		if got := allowed.Allows(host); got != want {
+			t.Errorf("Allows(%q) = %v, want %v", host, got, want)
+		}
+	}
+}
+
+func TestDialer(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			conn.Close()
+		}
+	}()
+	_, port, _ := net.SplitHostPort(listener.Addr().String())
+
+	dial := Dialer(time.Second, func(_ context.Context, host string) bool { return host != "127.0.0.1" })
+	conn, err := dial(context.Background(), "tcp", listener.Addr().String())
+	if err != nil {
+		t.Fatalf("dial of an unguarded host: %v", err)
+	}
+	conn.Close()
+
+	// localhost is guarded and resolves to the loopback address
+	if _, err := dial(context.Background(), "tcp", net.JoinHostPort("localhost", port)); !errors.Is(err, ErrBlockedAddress) {
+		t.Errorf("dial of a guarded loopback host: err = %v, want ErrBlockedAddress", err)
+	}
+}
@@ -0,0 +1,237 @@
+// Package newslink links news items to the fights they are about
+// An announcement such as "Usyk and Fury meet on May 18 in Riyadh" is linked
+// to the fight record of the pair. Fighter names are looked up in the news
+// text using the fighters of the fight list as the dictionary; a link needs
+// both fighters of a fight and a fight date close to the publication date,
+// which keeps false links rare.
+package newslink
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"easypars/models"
+	"easypars/pkg/locations"
+)
+
+// DateWindow is the largest distance between the publication date of a
+// news item and the date of the fight it is linked to
+const DateWindow = 30 * 24 * time.Hour
+
+// maxNameWords bounds the number of words of a fighter name looked up in the text
+const maxNameWords = 4
+
+// NewsLink links a news item to a fight
+type NewsLink struct {
+	NewsURL  string `json:"news_url"`
+	FightKey string `json:"fight_key"`
+	// FullNames is the number of fighters mentioned with their full name
+	// rather than only the surname
+	FullNames int `json:"full_names"`
+	// DaysApart is the distance between the publication and the fight
+	DaysApart int `json:"days_apart"`
+}
+
+// fighterName is a dictionary entry: a fighter and the forms of its name
+type fighterName struct {
+	// key is the normalized name of the fighter (models.NormalizeName)
+	key string
+	// full and surname are the name and the last word of the name as
+	// matched in the text (locations.Normalize)
+	full    string
+	surname string
+}
+
+// LinkNewsToFights finds the fight every news item is about
+// The fighters mentioned in the title and summary are collected first: a
+// full name match wins over a surname match, so the surname of a mentioned
+// fighter does not also count for a namesake. A fight qualifies when both of
+// its fighters are mentioned and its date is within DateWindow of the
+// publication; among several the one with more full name matches, then the
+// nearest date, is linked. Items without a qualifying fight are not linked.
+func LinkNewsToFights(news []models.NewsItem, fights []models.Fight) []NewsLink {
+	dictionary := buildDictionary(fights)
+
+	// Index the fights by their first fighter: both fighters of a linked
+	// fight are mentioned, so the fights of the mentioned fighters cover them
+	byFighter := make(map[string][]int)
+	for i, fight := range fights {
+		key := models.NormalizeName(fight.Fighter1)
+		byFighter[key] = append(byFighter[key], i)
+	}
+
+	var links []NewsLink
+	for _, item := range news {
+		mentioned := mentions(item, dictionary)
+		if len(mentioned) < 2 {
+			continue
+		}
+
+		var best *NewsLink
+		for key := range mentioned {
+			for _, idx := range byFighter[key] {
+				fight := fights[idx]
+				full1, ok1 := mentioned[models.NormalizeName(fight.Fighter1)]
+				full2, ok2 := mentioned[models.NormalizeName(fight.Fighter2)]
+				if !ok1 || !ok2 {
+					continue
+				}
+				days, ok := daysApart(item.PublishedAt, fight.Date)
+				if !ok {
+					continue
+				}
+
+				link := NewsLink{NewsURL: item.URL, FightKey: fight.Key, DaysApart: days}
+				for _, full := range []bool{full1, full2} {
+					if full {
+						link.FullNames++
+					}
+				}
+				if best == nil || betterLink(link, *best) {
+					best = &link
+				}
+			}
+		}
+		if best != nil {
+			links = append(links, *best)
+		}
+	}
+
+	return links
+}
+
+// Apply sets RelatedFightKey of the linked news items and RelatedNews of
+// the linked fights
+// The related news of a fight are sorted, so the result does not depend on
+// the order of the news.
+func Apply(links []NewsLink, news []models.NewsItem, fights []models.Fight) {
+	byURL := make(map[string]string, len(links))
+	byFight := make(map[string][]string)
+	for _, link := range links {
+		byURL[link.NewsURL] = link.FightKey
+		byFight[link.FightKey] = append(byFight[link.FightKey], link.NewsURL)
+	}
+
+	for i := range news {
+		if key, ok := byURL[news[i].URL]; ok {
+			news[i].RelatedFightKey = key
+		}
+	}
+	for i := range fights {
+		if urls, ok := byFight[fights[i].Key]; ok {
+			sort.Strings(urls)
+			fights[i].RelatedNews = urls
+		}
+	}
+}
+
+// buildDictionary collects the names of the fighters of the fight list
+// Names without letters or digits and names longer than maxNameWords words
+// are left out.
+func buildDictionary(fights []models.Fight) []fighterName {
+	seen := make(map[string]bool)
+	var dictionary []fighterName
+	add := func(name string) {
+		key := models.NormalizeName(name)
+		if key == "" || seen[key] {
+			return
+		}
+		seen[key] = true
+
+		words := strings.Fields(locations.Normalize(name))
+		if len(words) == 0 || len(words) > maxNameWords {
+			return
+		}
+		dictionary = append(dictionary, fighterName{
+			key:     key,
+			full:    strings.Join(words, " "),
+			surname: words[len(words)-1],
+		})
+	}
+	for _, fight := range fights {
+		add(fight.Fighter1)
+		add(fight.Fighter2)
+	}
+
+	// A fixed order keeps the matching deterministic
+	sort.Slice(dictionary, func(i, j int) bool { return dictionary[i].key < dictionary[j].key })
+
+	return dictionary
+}
+
+// mentions returns the fighters mentioned in the news item
+// The value tells whether the full name was mentioned. Surnames of fighters
+// mentioned in full are not matched against other fighters.
+func mentions(item models.NewsItem, dictionary []fighterName) map[string]bool {
+	phrases := wordSequences(locations.Normalize(item.Title + " " + item.Summary))
+
+	mentioned := make(map[string]bool)
+	claimed := make(map[string]bool)
+	for _, fighter := range dictionary {
+		if strings.Contains(fighter.full, " ") && phrases[fighter.full] {
+			mentioned[fighter.key] = true
+			claimed[fighter.surname] = true
+		}
+	}
+	for _, fighter := range dictionary {
+		if _, ok := mentioned[fighter.key]; ok || claimed[fighter.surname] {
+			continue
+		}
+		if phrases[fighter.surname] {
+			mentioned[fighter.key] = false
+		}
+	}
+
+	return mentioned
+}
+
+// wordSequences returns every sequence of up to maxNameWords consecutive
+// words of the text
+func wordSequences(text string) map[string]bool {
+	words := strings.Fields(text)
+	sequences := make(map[string]bool, len(words)*maxNameWords)
+	for i := range words {
+		for n := 1; n <= maxNameWords && i+n <= len(words); n++ {
+			sequences[strings.Join(words[i:i+n], " ")] = true
+		}
+	}
+
+	return sequences
+}
+
+// daysApart returns the number of days between the publication and the
+// fight date, false when the date is unknown or outside DateWindow
+func daysApart(published time.Time, fightDate string) (int, bool) {
+	if published.IsZero() {
+		return 0, false
+	}
+	date, err := time.Parse("2006-01-02", fightDate)
+	if err != nil {
+		return 0, false
+	}
+
+	// Compare calendar days, the publication time of day does not matter
+	y, m, d := published.Date()
+	distance := date.Sub(time.Date(y, m, d, 0, 0, 0, 0, time.UTC))
+	if distance < 0 {
+		distance = -distance
+	}
+	if distance > DateWindow {
+		return 0, false
+	}
+
+	return int(distance / (24 * time.Hour)), true
+}
+
+// betterLink reports whether link a is preferred over link b
+func betterLink(a, b NewsLink) bool {
+	if a.FullNames != b.FullNames {
+		return a.FullNames > b.FullNames
+	}
+	if a.DaysApart != b.DaysApart {
+		return a.DaysApart < b.DaysApart
+	}
+
+	return a.FightKey < b.FightKey
+}
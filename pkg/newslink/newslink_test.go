@@ -0,0 +1,149 @@
+package newslink
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"easypars/models"
+)
+
+// testFights are the fights the news are linked to
+// Two fighters share the surname Smith, told apart by their first names.
+func testFights() []models.Fight {
+	fights := []models.Fight{
+		{Date: "2024-05-18", Fighter1: "Oleksandr Usyk", Fighter2: "Tyson Fury", Location: "Riyadh"},
+		{Date: "2024-06-01", Fighter1: "Callum Smith", Fighter2: "Joshua Buatsi", Location: "Liverpool"},
+		{Date: "2024-06-01", Fighter1: "Liam Smith", Fighter2: "Chris Eubank", Location: "Manchester"},
+	}
+	for i := range fights {
+		fights[i].Key = fights[i].NaturalKey()
+	}
+
+	return fights
+}
+
+func published(date string) time.Time {
+	t, err := time.Parse("2006-01-02", date)
+	if err != nil {
+		panic(err)
+	}
+
+	return t.Add(9 * time.Hour)
+}
+
+func TestLinkNewsToFights(t *testing.T) {
+	fights := testFights()
+
+	tests := []struct {
+		name string
+		item models.NewsItem
+		// want is the fighters of the linked fight, empty for no link
+		want string
+	}{
+		{
+			name: "both names",
+			item: models.NewsItem{Title: "Usyk and Fury meet on May 18 in Riyadh", PublishedAt: published("2024-04-20")},
+			want: "Oleksandr Usyk",
+		},
+		{
+			name: "both names in the summary",
+			item: models.NewsItem{Title: "Undisputed at last", Summary: "Oleksandr Usyk faces Tyson Fury", PublishedAt: published("2024-05-10")},
+			want: "Oleksandr Usyk",
+		},
+		{
+			name: "a single name",
+			item: models.NewsItem{Title: "Fury arrives in Riyadh", PublishedAt: published("2024-05-10")},
+		},
+		{
+			name: "a namesake",
+			// Liam Smith is named in full, so "Smith" is not Callum Smith and
+			// Buatsi alone does not make a link
+			item: models.NewsItem{Title: "Liam Smith watches Buatsi spar", PublishedAt: published("2024-05-25")},
+		},
+		{
+			name: "full name wins over surname",
+			item: models.NewsItem{Title: "Liam Smith ready for Eubank, Buatsi next for Smith?", PublishedAt: published("2024-05-25")},
+			want: "Liam Smith",
+		},
+		{
+			name: "date outside the window",
+			item: models.NewsItem{Title: "Usyk and Fury sign for a fight", PublishedAt: published("2024-02-01")},
+		},
+		{
+			name: "no publication date",
+			item: models.NewsItem{Title: "Usyk and Fury meet on May 18"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.item.URL = "https://news.example/" + tt.name
+			links := LinkNewsToFights([]models.NewsItem{tt.item}, fights)
+
+			var got string
+			if len(links) > 0 {
+				for _, fight := range fights {
+					if fight.Key == links[0].FightKey {
+						got = fight.Fighter1
+					}
+				}
+			}
+			if len(links) > 1 || got != tt.want {
+				t.Errorf("links = %+v, want a link to the fight of %q", links, tt.want)
+			}
+		})
+	}
+}
+
+func TestApply(t *testing.T) {
+	fights := testFights()
+	news := []models.NewsItem{
+		{Title: "Usyk and Fury: the weigh-in", URL: "https://news.example/b", PublishedAt: published("2024-05-17")},
+		{Title: "Usyk vs Fury: the press conference", URL: "https://news.example/a", PublishedAt: published("2024-05-15")},
+		{Title: "Eubank is injured", URL: "https://news.example/c", PublishedAt: published("2024-05-15")},
+	}
+
+	Apply(LinkNewsToFights(news, fights), news, fights)
+
+	if want := []string{"https://news.example/a", "https://news.example/b"}; !reflect.DeepEqual(fights[0].RelatedNews, want) {
+		t.Errorf("related news = %v, want %v", fights[0].RelatedNews, want)
+	}
+	if fights[1].RelatedNews != nil || fights[2].RelatedNews != nil {
+		t.Errorf("unrelated fights got news: %v, %v", fights[1].RelatedNews, fights[2].RelatedNews)
+	}
+	if news[0].RelatedFightKey != fights[0].Key || news[1].RelatedFightKey != fights[0].Key || news[2].RelatedFightKey != "" {
+		t.Errorf("related fight keys = %q, %q, %q", news[0].RelatedFightKey, news[1].RelatedFightKey, news[2].RelatedFightKey)
+	}
+}
+
+func TestNewStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "news.json")
+	data := `[{"title":"Usyk and Fury meet","url":"https://news.example/a","published_at":"2024-05-01T09:00:00Z","related_fight_key":"stale"}]`
+	if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	store, err := NewStore(path)
+	if err != nil {
+		t.Fatalf("NewStore: %v", err)
+	}
+	items := store.Items()
+	if len(items) != 1 || items[0].URL != "https://news.example/a" || items[0].RelatedFightKey != "" {
+		t.Errorf("items = %+v, want the item without its stale link", items)
+	}
+
+	if store, err := NewStore(filepath.Join(t.TempDir(), "missing.json")); err != nil || len(store.Items()) != 0 {
+		t.Errorf("NewStore of a missing file = %v, %v, want an empty store", store, err)
+	}
+	if err := os.WriteFile(path, []byte("{"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewStore(path); err == nil {
+		t.Error("NewStore of a damaged file succeeded")
+	}
+	if (*Store)(nil).Items() != nil {
+		t.Error("a nil store has items")
+	}
+}
@@ -0,0 +1,78 @@
+package newslink
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+
+	"easypars/models"
+)
+
+// Store holds the published news items
+// The items are linked to the fights whenever a fight snapshot is built
+// (see snapshot.BuildOptions), so a new fight list or a new set of news is
+// linked without a separate step.
+type Store struct {
+	mu    sync.RWMutex
+	items []models.NewsItem
+}
+
+// NewStore creates a store, loading the news items of path when the file
+// exists
+// The file is a JSON array of news items; an empty path or a missing file
+// gives an empty store.
+func NewStore(path string) (*Store, error) {
+	s := &Store{}
+	if path == "" {
+		return s, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading news file: %w", err)
+	}
+
+	var items []models.NewsItem
+	if err := json.Unmarshal(data, &items); err != nil {
+		return nil, fmt.Errorf("error decoding news file: %w", err)
+	}
+	s.Publish(items)
+
+	return s, nil
+}
+
+// Publish replaces the news items of the store
+// The links of the previous items are dropped, the new ones are linked
+// with the next fight snapshot.
+func (s *Store) Publish(items []models.NewsItem) {
+	published := make([]models.NewsItem, len(items))
+	copy(published, items)
+	for i := range published {
+		published[i].RelatedFightKey = ""
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.items = published
+}
+
+// Items returns a copy of the published news items, none for a nil store
+func (s *Store) Items() []models.NewsItem {
+	if s == nil {
+		return nil
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	items := make([]models.NewsItem, len(s.items))
+	copy(items, s.items)
+
+	return items
+}
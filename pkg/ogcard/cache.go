@@ -0,0 +1,176 @@
+package ogcard
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+)
+
+// DefaultMaxCached is the number of cards kept in memory when none is configured
+const DefaultMaxCached = 500
+
+// Cache keeps rendered cards by fight ID and card version
+// The most recently used cards are kept in memory; with a directory the
+// cards are also written to disk, so a restart does not draw them again.
+// A new version of a card replaces the old one, in memory and on disk;
+// outdated files of cards already evicted from memory stay on disk.
+type Cache struct {
+	mu  sync.Mutex
+	max int
+	dir string
+	// entries maps a fight ID to its element in order
+	entries map[string]*list.Element
+	// order holds the cached cards, most recently used first
+	order *list.List
+
+	hits   atomic.Int64
+	misses atomic.Int64
+}
+
+// cacheEntry is a cached card
+type cacheEntry struct {
+	id      string
+	version string
+	png     []byte
+}
+
+// CacheStats reports the use of the cache
+type CacheStats struct {
+	Cached int   `json:"cached"`
+	Hits   int64 `json:"hits"`
+	Misses int64 `json:"misses"`
+}
+
+// NewCache creates a cache of up to max cards in memory
+// A non-positive max means DefaultMaxCached; an empty dir keeps the cards
+// in memory only.
+func NewCache(max int, dir string) *Cache {
+	if max <= 0 {
+		max = DefaultMaxCached
+	}
+
+	return &Cache{
+		max:     max,
+		dir:     dir,
+		entries: make(map[string]*list.Element),
+		order:   list.New(),
+	}
+}
+
+// Get returns the card of the fight in the given version
+func (c *Cache) Get(id, version string) ([]byte, bool) {
+	c.mu.Lock()
+	if elem, ok := c.entries[id]; ok {
+		entry := elem.Value.(*cacheEntry)
+		if entry.version == version {
+			c.order.MoveToFront(elem)
+			c.mu.Unlock()
+			c.hits.Add(1)
+			return entry.png, true
+		}
+	}
+	c.mu.Unlock()
+
+	// Cards drawn before a restart are read back from disk
+	if c.dir != "" {
+		if data, err := os.ReadFile(c.path(id, version)); err == nil {
+			c.remember(id, version, data)
+			c.hits.Add(1)
+			return data, true
+		}
+	}
+
+	c.misses.Add(1)
+	return nil, false
+}
+
+// Put stores the card of the fight in the given version
+// Errors writing to disk are logged, the card stays cached in memory.
+func (c *Cache) Put(id, version string, data []byte) {
+	replaced := c.remember(id, version, data)
+	if c.dir == "" {
+		return
+	}
+
+	if replaced != "" {
+		if err := os.Remove(c.path(id, replaced)); err != nil && !os.IsNotExist(err) {
+			slog.Warn("Failed to remove an outdated card", "error", err)
+		}
+	}
+	if err := c.save(c.path(id, version), data); err != nil {
+		slog.Warn("Failed to save the card", "fight_key", id, "error", err)
+	}
+}
+
+// Stats returns the number of cards in memory and the hit counters
+func (c *Cache) Stats() CacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return CacheStats{Cached: c.order.Len(), Hits: c.hits.Load(), Misses: c.misses.Load()}
+}
+
+// remember stores the card in memory and evicts the least recently used
+// cards over the limit
+// Returns the previous version of the card, empty when there was none.
+func (c *Cache) remember(id, version string, data []byte) string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	replaced := ""
+	if elem, ok := c.entries[id]; ok {
+		entry := elem.Value.(*cacheEntry)
+		if entry.version != version {
+			replaced = entry.version
+		}
+		entry.version, entry.png = version, data
+		c.order.MoveToFront(elem)
+		return replaced
+	}
+
+	c.entries[id] = c.order.PushFront(&cacheEntry{id: id, version: version, png: data})
+	for c.order.Len() > c.max {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*cacheEntry).id)
+	}
+
+	return replaced
+}
+
+// path returns the file of a card version
+// IDs are hashed, so any ID gives a safe file name.
+func (c *Cache) path(id, version string) string {
+	sum := sha256.Sum256([]byte(id))
+
+	return filepath.Join(c.dir, hex.EncodeToString(sum[:12])+"-"+version+".png")
+}
+
+// save writes the card atomically: a temp file renamed over the target
+func (c *Cache) save(path string, data []byte) error {
+	if err := os.MkdirAll(c.dir, 0o755); err != nil {
+		return fmt.Errorf("error creating the card directory: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(c.dir, ".card-*.tmp")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+
+	return os.Rename(tmp.Name(), path)
+}
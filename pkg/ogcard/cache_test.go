@@ -0,0 +1,73 @@
+package ogcard
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCache(t *testing.T) {
+	c := NewCache(2, "")
+
+	if _, ok := c.Get("usyk-fury", "v1"); ok {
+		t.Fatal("an empty cache returned a card")
+	}
+	c.Put("usyk-fury", "v1", []byte("card v1"))
+	if data, ok := c.Get("usyk-fury", "v1"); !ok || string(data) != "card v1" {
+		t.Errorf("Get = %q, %v; want the stored card", data, ok)
+	}
+
+	// A new version replaces the card, the old version is gone
+	c.Put("usyk-fury", "v2", []byte("card v2"))
+	if _, ok := c.Get("usyk-fury", "v1"); ok {
+		t.Error("the outdated version is still cached")
+	}
+
+	// The least recently used card is evicted
+	c.Put("bivol-zinad", "v1", []byte("bivol"))
+	c.Get("usyk-fury", "v2")
+	c.Put("joshua-dubois", "v1", []byte("joshua"))
+	if _, ok := c.Get("bivol-zinad", "v1"); ok {
+		t.Error("the least recently used card was kept")
+	}
+	if _, ok := c.Get("usyk-fury", "v2"); !ok {
+		t.Error("a recently used card was evicted")
+	}
+
+	if stats := c.Stats(); stats.Cached != 2 || stats.Hits != 3 || stats.Misses != 3 {
+		t.Errorf("stats = %+v, want 2 cached, 3 hits and 3 misses", stats)
+	}
+}
+
+func TestCacheOnDisk(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "cards")
+	c := NewCache(1, dir)
+	c.Put("usyk-fury", "v1", []byte("card v1"))
+	c.Put("usyk-fury", "v2", []byte("card v2"))
+
+	files, err := filepath.Glob(filepath.Join(dir, "*.png"))
+	if err != nil || len(files) != 1 {
+		t.Fatalf("card files = %q (%v), want the current version only", files, err)
+	}
+
+	// A restarted cache reads the card back from disk
+	restarted := NewCache(1, dir)
+	if data, ok := restarted.Get("usyk-fury", "v2"); !ok || !bytes.Equal(data, []byte("card v2")) {
+		t.Errorf("Get after the restart = %q, %v; want the saved card", data, ok)
+	}
+	if _, ok := restarted.Get("usyk-fury", "v1"); ok {
+		t.Error("the outdated version was read from disk")
+	}
+
+	// An unwritable directory keeps the card in memory
+	blocked := filepath.Join(t.TempDir(), "file")
+	if err := os.WriteFile(blocked, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	memoryOnly := NewCache(1, blocked)
+	memoryOnly.Put("usyk-fury", "v1", []byte("card v1"))
+	if _, ok := memoryOnly.Get("usyk-fury", "v1"); !ok {
+		t.Error("a card that could not be saved is not cached in memory")
+	}
+}
@@ -0,0 +1,309 @@
+// Package ogcard renders the preview images of fights shown by messengers
+// and social networks (OpenGraph og:image)
+// Cards are drawn in pure Go with the Go fonts, which cover Latin and
+// Cyrillic, so no browser or system font is needed on the server.
+package ogcard
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"strings"
+	"sync"
+
+	"easypars/models"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/gofont/gobold"
+	"golang.org/x/image/font/gofont/goregular"
+	"golang.org/x/image/font/opentype"
+	"golang.org/x/image/font/sfnt"
+	"golang.org/x/image/math/fixed"
+)
+
+// Card size, the size recommended for OpenGraph images
+const (
+	Width  = 1200
+	Height = 630
+)
+
+// layoutVersion is part of every card version; bump it when the layout
+// changes so cached cards are drawn again
+const layoutVersion = "1"
+
+// Layout of the card in pixels
+const (
+	margin       = 60
+	accentHeight = 14
+	nameMaxSize  = 76
+	nameMinSize  = 40
+	infoSize     = 34
+	brandSize    = 26
+	sizeStep     = 4
+	ellipsis     = "…"
+	missingName  = "TBA"
+	brandText    = "EasyPars"
+	versusText   = "vs"
+)
+
+// statusLabels are shown instead of the result of fights without one
+var statusLabels = map[string]string{
+	models.StatusScheduled:     "Scheduled",
+	models.StatusCompleted:     "Completed",
+	models.StatusResultUnknown: "Result unknown",
+	models.StatusCancelled:     "Cancelled",
+}
+
+// Colors holds the colors of a card
+type Colors struct {
+	Background color.RGBA
+	Text       color.RGBA
+	Accent     color.RGBA
+}
+
+// DefaultColors returns the colors used when none are configured
+func DefaultColors() Colors {
+	return Colors{
+		Background: color.RGBA{R: 0x1b, G: 0x1f, B: 0x3b, A: 0xff},
+		Text:       color.RGBA{R: 0xff, G: 0xff, B: 0xff, A: 0xff},
+		Accent:     color.RGBA{R: 0xe6, G: 0x39, B: 0x46, A: 0xff},
+	}
+}
+
+// ParseColor parses a "#rrggbb" color
+func ParseColor(value string) (color.RGBA, error) {
+	raw, ok := strings.CutPrefix(value, "#")
+	if !ok || len(raw) != 6 {
+		return color.RGBA{}, fmt.Errorf("invalid color %q: must be #rrggbb", value)
+	}
+	rgb, err := hex.DecodeString(raw)
+	if err != nil {
+		return color.RGBA{}, fmt.Errorf("invalid color %q: must be #rrggbb", value)
+	}
+
+	return color.RGBA{R: rgb[0], G: rgb[1], B: rgb[2], A: 0xff}, nil
+}
+
+// ParseColors parses the background, text and accent colors
+// Empty values keep the default color
+func ParseColors(background, text, accent string) (Colors, error) {
+	colors := DefaultColors()
+	for _, field := range []struct {
+		value string
+		dst   *color.RGBA
+	}{
+		{background, &colors.Background},
+		{text, &colors.Text},
+		{accent, &colors.Accent},
+	} {
+		if field.value == "" {
+			continue
+		}
+		parsed, err := ParseColor(field.value)
+		if err != nil {
+			return Colors{}, err
+		}
+		*field.dst = parsed
+	}
+
+	return colors, nil
+}
+
+// Card holds the fight fields shown on a card
+type Card struct {
+	Fighter1 string
+	Fighter2 string
+	Date     string
+	Location string
+	Result   string
+	Status   string
+}
+
+// CardOf returns the card fields of a fight
+func CardOf(fight models.Fight) Card {
+	return Card{
+		Fighter1: strings.TrimSpace(fight.Fighter1),
+		Fighter2: strings.TrimSpace(fight.Fighter2),
+		Date:     strings.TrimSpace(fight.Date),
+		Location: strings.TrimSpace(fight.Location),
+		Result:   strings.TrimSpace(fight.Result),
+		Status:   fight.Status,
+	}
+}
+
+// Renderer draws fight cards
+// Rendering is serialized: font faces keep per-face buffers and must not be
+// used concurrently. Rendered cards are meant to be cached (see Cache).
+type Renderer struct {
+	colors Colors
+	bold   *sfnt.Font
+	normal *sfnt.Font
+
+	mu sync.Mutex
+}
+
+// NewRenderer creates a renderer drawing cards in the given colors
+func NewRenderer(colors Colors) (*Renderer, error) {
+	bold, err := opentype.Parse(gobold.TTF)
+	if err != nil {
+		return nil, fmt.Errorf("error loading the bold font: %w", err)
+	}
+	normal, err := opentype.Parse(goregular.TTF)
+	if err != nil {
+		return nil, fmt.Errorf("error loading the regular font: %w", err)
+	}
+
+	return &Renderer{colors: colors, bold: bold, normal: normal}, nil
+}
+
+// Version returns the version of the card image
+// It changes with any shown field, the colors and the layout, so a card is
+// drawn again when e.g. the result of the fight appears.
+func (r *Renderer) Version(card Card) string {
+	h := sha256.New()
+	fmt.Fprintf(h, "%s\x00%v\x00", layoutVersion, r.colors)
+	for _, field := range []string{card.Fighter1, card.Fighter2, card.Date, card.Location, card.Result, card.Status} {
+		h.Write([]byte(field))
+		h.Write([]byte{0})
+	}
+
+	return hex.EncodeToString(h.Sum(nil))[:16]
+}
+
+// Render draws the card and returns it as PNG
+// The same card always gives the same bytes. Long names are set in a
+// smaller size and cut with an ellipsis when they still do not fit;
+// missing fields are left out or shown as TBA.
+func (r *Renderer) Render(card Card) ([]byte, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	img := image.NewRGBA(image.Rect(0, 0, Width, Height))
+	draw.Draw(img, img.Bounds(), image.NewUniform(r.colors.Background), image.Point{}, draw.Src)
+	draw.Draw(img, image.Rect(0, 0, Width, accentHeight), image.NewUniform(r.colors.Accent), image.Point{}, draw.Src)
+
+	maxWidth := Width - 2*margin
+
+	// Step 1: Brand in the top left corner
+	if err := r.drawText(img, r.normal, brandText, brandSize, brandSize, maxWidth, 90, r.colors.Accent, false); err != nil {
+		return nil, err
+	}
+
+	// Step 2: Fighter names around the accent colored "vs"
+	if err := r.drawText(img, r.bold, nameOrTBA(card.Fighter1), nameMaxSize, nameMinSize, maxWidth, 235, r.colors.Text, true); err != nil {
+		return nil, err
+	}
+	if err := r.drawText(img, r.normal, versusText, infoSize, infoSize, maxWidth, 310, r.colors.Accent, true); err != nil {
+		return nil, err
+	}
+	if err := r.drawText(img, r.bold, nameOrTBA(card.Fighter2), nameMaxSize, nameMinSize, maxWidth, 405, r.colors.Text, true); err != nil {
+		return nil, err
+	}
+
+	// Step 3: Date and location, then the result or the status
+	if info := joinNonEmpty(" · ", card.Date, card.Location); info != "" {
+		if err := r.drawText(img, r.normal, info, infoSize, infoSize, maxWidth, 500, r.colors.Text, true); err != nil {
+			return nil, err
+		}
+	}
+	outcome := card.Result
+	if outcome == "" {
+		outcome = statusLabels[card.Status]
+	}
+	if outcome != "" {
+		if err := r.drawText(img, r.bold, outcome, infoSize, infoSize, maxWidth, 560, r.colors.Accent, true); err != nil {
+			return nil, err
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, fmt.Errorf("error encoding the card: %w", err)
+	}
+
+	return buf.Bytes(), nil
+}
+
+// drawText draws a single line with its baseline at y
+// The size shrinks from maxSize to minSize until the text fits maxWidth;
+// at minSize the text is cut with an ellipsis.
+func (r *Renderer) drawText(img *image.RGBA, f *sfnt.Font, text string, maxSize, minSize float64, maxWidth, y int, col color.RGBA, centered bool) error {
+	face, text, err := fitText(f, text, maxSize, minSize, maxWidth)
+	if err != nil {
+		return err
+	}
+	defer face.Close()
+
+	x := margin
+	if centered {
+		x = (Width - font.MeasureString(face, text).Ceil()) / 2
+	}
+	drawer := font.Drawer{
+		Dst:  img,
+		Src:  image.NewUniform(col),
+		Face: face,
+		Dot:  fixed.P(x, y),
+	}
+	drawer.DrawString(text)
+
+	return nil
+}
+
+// fitText returns the face and the text that fit maxWidth
+func fitText(f *sfnt.Font, text string, maxSize, minSize float64, maxWidth int) (font.Face, string, error) {
+	for size := maxSize; ; size -= sizeStep {
+		if size < minSize {
+			size = minSize
+		}
+		face, err := opentype.NewFace(f, &opentype.FaceOptions{Size: size, DPI: 72, Hinting: font.HintingFull})
+		if err != nil {
+			return nil, "", fmt.Errorf("error creating a font face: %w", err)
+		}
+		if font.MeasureString(face, text).Ceil() <= maxWidth {
+			return face, text, nil
+		}
+		if size == minSize {
+			return face, truncate(face, text, maxWidth), nil
+		}
+		face.Close()
+	}
+}
+
+// truncate cuts the text so that it fits maxWidth together with an ellipsis
+func truncate(face font.Face, text string, maxWidth int) string {
+	runes := []rune(text)
+	for len(runes) > 0 {
+		runes = runes[:len(runes)-1]
+		candidate := strings.TrimSpace(string(runes)) + ellipsis
+		if font.MeasureString(face, candidate).Ceil() <= maxWidth {
+			return candidate
+		}
+	}
+
+	return ellipsis
+}
+
+// nameOrTBA returns the name, TBA for a missing one
+func nameOrTBA(name string) string {
+	if name == "" {
+		return missingName
+	}
+	return name
+}
+
+// joinNonEmpty joins the non-empty parts with sep
+func joinNonEmpty(sep string, parts ...string) string {
+	kept := make([]string, 0, len(parts))
+	for _, part := range parts {
+		if part != "" {
+			kept = append(kept, part)
+		}
+	}
+
+	return strings.Join(kept, sep)
+}
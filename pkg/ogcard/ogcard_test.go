@@ -0,0 +1,144 @@
+package ogcard
+
+import (
+	"bytes"
+	"image/png"
+	"strings"
+	"testing"
+
+	"golang.org/x/image/font/gofont/gobold"
+	"golang.org/x/image/font/opentype"
+)
+
+// newTestRenderer returns a renderer in the default colors
+func newTestRenderer(t *testing.T) *Renderer {
+	t.Helper()
+
+	r, err := NewRenderer(DefaultColors())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return r
+}
+
+func TestRender(t *testing.T) {
+	r := newTestRenderer(t)
+	long := strings.Repeat("Wladimir Wladimirowitsch Klitschko ", 6)
+
+	tests := []struct {
+		name string
+		card Card
+	}{
+		{"latin", Card{Fighter1: "Oleksandr Usyk", Fighter2: "Tyson Fury", Date: "2024-05-18", Location: "Riyadh", Result: "SD"}},
+		{"cyrillic", Card{Fighter1: "Александр Усик", Fighter2: "Тайсон Фьюри", Date: "2024-05-18", Location: "Эр-Рияд", Result: "раздельным решением"}},
+		{"long names", Card{Fighter1: long, Fighter2: long, Date: "2024-05-18", Location: long, Result: long}},
+		{"empty fields", Card{}},
+		{"status instead of a result", Card{Fighter1: "Dmitry Bivol", Fighter2: "Artur Beterbiev", Status: "scheduled"}},
+		{"no glyphs", Card{Fighter1: "拳击手", Fighter2: "🥊", Location: "\x00​"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := r.Render(tt.card)
+			if err != nil {
+				t.Fatalf("Render: %v", err)
+			}
+			img, err := png.Decode(bytes.NewReader(data))
+			if err != nil {
+				t.Fatalf("the card is not a PNG: %v", err)
+			}
+			if size := img.Bounds().Size(); size.X != Width || size.Y != Height {
+				t.Errorf("card size = %v, want %dx%d", size, Width, Height)
+			}
+
+			// The same card always gives the same bytes
+			again, err := r.Render(tt.card)
+			if err != nil || !bytes.Equal(again, data) {
+				t.Error("rendering the same card twice gave different images")
+			}
+		})
+	}
+}
+
+func TestVersion(t *testing.T) {
+	r := newTestRenderer(t)
+	card := Card{Fighter1: "Oleksandr Usyk", Fighter2: "Tyson Fury", Date: "2024-05-18", Location: "Riyadh", Status: "scheduled"}
+	version := r.Version(card)
+
+	if r.Version(card) != version {
+		t.Error("the version of the same card changed")
+	}
+	withResult := card
+	withResult.Result = "SD"
+	if r.Version(withResult) == version {
+		t.Error("the version did not change with the result")
+	}
+	recolored, err := ParseColors("#000000", "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := NewRenderer(recolored)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if other.Version(card) == version {
+		t.Error("the version did not change with the colors")
+	}
+}
+
+func TestFitText(t *testing.T) {
+	f, err := opentype.Parse(gobold.TTF)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		text     string
+		maxWidth int
+		// shrunk is set when the text must be set below the maximum size
+		shrunk    bool
+		truncated bool
+	}{
+		{"fits", "Usyk", 1000, false, false},
+		{"fits smaller", "Oleksandr Usyk vs Tyson Fury", 900, true, false},
+		{"cut", strings.Repeat("Klitschko ", 20), 600, true, true},
+		{"nothing fits", "Klitschko", 1, true, true},
+	}
+	for _, tt := range tests {
+		face, text, err := fitText(f, tt.text, nameMaxSize, nameMinSize, tt.maxWidth)
+		if err != nil {
+			t.Fatalf("%s: fitText: %v", tt.name, err)
+		}
+		size := float64(face.Metrics().Height.Ceil())
+		full, _ := opentype.NewFace(f, &opentype.FaceOptions{Size: nameMaxSize, DPI: 72})
+		if shrunk := size < float64(full.Metrics().Height.Ceil()); shrunk != tt.shrunk {
+			t.Errorf("%s: shrunk = %v, want %v", tt.name, shrunk, tt.shrunk)
+		}
+		if truncated := strings.HasSuffix(text, ellipsis); truncated != tt.truncated {
+			t.Errorf("%s: text = %q, want truncated %v", tt.name, text, tt.truncated)
+		}
+	}
+}
+
+func TestParseColors(t *testing.T) {
+	tests := []struct {
+		background, text, accent string
+		wantErr                  bool
+	}{
+		{"", "", "", false},
+		{"#000000", "#FFFFFF", "#e63946", false},
+		{"000000", "", "", true},
+		{"#0000", "", "", true},
+		{"", "#gggggg", "", true},
+		{"", "", "red", true},
+	}
+	for _, tt := range tests {
+		colors, err := ParseColors(tt.background, tt.text, tt.accent)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseColors(%q, %q, %q) = %v, want error %v", tt.background, tt.text, tt.accent, err, tt.wantErr)
+		}
+		if err == nil && tt.background == "" && colors.Background != DefaultColors().Background {
+			t.Errorf("ParseColors without a background = %v, want the default", colors.Background)
+		}
+	}
+}
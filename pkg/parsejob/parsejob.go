@@ -0,0 +1,174 @@
+// Package parsejob runs parses requested by operators in the background
+// A job is started by POST /api/parse and polled by its ID; at most one job
+// runs at a time, so repeated requests cannot pile up parses of the source.
+// Job states are kept in memory and the oldest finished jobs are forgotten.
+package parsejob
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// TriggerManual marks manual parses in the parse history
+const TriggerManual = "manual"
+
+// Job states reported by Status
+const (
+	StateRunning   = "running"
+	StateSucceeded = "succeeded"
+	StateFailed    = "failed"
+)
+
+// maxFinished is the number of finished jobs kept for polling
+const maxFinished = 20
+
+var (
+	// ErrRunning is returned when starting a job while another one runs
+	ErrRunning = errors.New("a parse job is already running")
+	// ErrStopped is returned when starting a job after Stop
+	ErrStopped = errors.New("parse jobs are stopped")
+)
+
+// Func runs the parse and returns the number of fights
+type Func func(ctx context.Context) (int, error)
+
+// Status is the state of a job for GET /api/parse/:jobID
+type Status struct {
+	ID         string     `json:"id"`
+	State      string     `json:"state"`
+	StartedAt  time.Time  `json:"started_at"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+	// DurationMs is the run time so far while the job runs
+	DurationMs int64  `json:"duration_ms"`
+	FightCount int    `json:"fight_count"`
+	Error      string `json:"error,omitempty"`
+}
+
+// job is a parse started by Start
+type job struct {
+	status Status
+	done   chan struct{}
+}
+
+// Manager runs one parse job at a time and keeps the recent jobs
+type Manager struct {
+	mu      sync.Mutex
+	seq     int
+	stopped bool
+	ctx     context.Context
+	cancel  context.CancelFunc
+	// running is the job in flight, nil when idle
+	running *job
+	jobs    map[string]*job
+	// finished holds the IDs of the finished jobs, oldest first
+	finished []string
+}
+
+// NewManager creates an idle manager
+func NewManager() *Manager {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	return &Manager{ctx: ctx, cancel: cancel, jobs: make(map[string]*job)}
+}
+
+// Start runs the parse in the background and returns the new job
+// While a job runs, the running job is returned with ErrRunning. The parse
+// runs on a context of the manager, not of the request that started it.
+func (m *Manager) Start(run Func) (Status, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.stopped {
+		return Status{}, ErrStopped
+	}
+	if m.running != nil {
+		return m.snapshot(m.running), ErrRunning
+	}
+
+	m.seq++
+	j := &job{
+		status: Status{
+			ID:        fmt.Sprintf("parse-%d-%d", time.Now().Unix(), m.seq),
+			State:     StateRunning,
+			StartedAt: time.Now(),
+		},
+		done: make(chan struct{}),
+	}
+	m.running = j
+	m.jobs[j.status.ID] = j
+
+	go m.run(j, run)
+
+	slog.Info("Parse job started", "job_id", j.status.ID)
+	return m.snapshot(j), nil
+}
+
+// Get returns the status of a job
+func (m *Manager) Get(id string) (Status, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	j, ok := m.jobs[id]
+	if !ok {
+		return Status{}, false
+	}
+
+	return m.snapshot(j), true
+}
+
+// Stop cancels the running job, waits for it to return and refuses new jobs
+func (m *Manager) Stop() {
+	m.mu.Lock()
+	m.stopped = true
+	m.cancel()
+	running := m.running
+	m.mu.Unlock()
+
+	if running != nil {
+		<-running.done
+	}
+}
+
+// run runs the parse of a job and records its outcome
+func (m *Manager) run(j *job, run Func) {
+	defer close(j.done)
+
+	count, err := run(m.ctx)
+	finished := time.Now()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	j.status.FinishedAt = &finished
+	j.status.DurationMs = finished.Sub(j.status.StartedAt).Milliseconds()
+	j.status.FightCount = count
+	j.status.State = StateSucceeded
+	if err != nil {
+		j.status.State = StateFailed
+		j.status.Error = err.Error()
+		slog.Error("Parse job failed", "job_id", j.status.ID, "duration_ms", j.status.DurationMs, "error", err)
+	} else {
+		slog.Info("Parse job finished", "job_id", j.status.ID, "fight_count", count, "duration_ms", j.status.DurationMs)
+	}
+
+	m.running = nil
+	m.finished = append(m.finished, j.status.ID)
+	if len(m.finished) > maxFinished {
+		delete(m.jobs, m.finished[0])
+		m.finished = m.finished[1:]
+	}
+}
+
+// snapshot copies the status of a job; the caller holds the lock
+func (m *Manager) snapshot(j *job) Status {
+	status := j.status
+	if status.State == StateRunning {
+		status.DurationMs = time.Since(status.StartedAt).Milliseconds()
+	}
+
+	return status
+}
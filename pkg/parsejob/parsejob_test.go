@@ -0,0 +1,179 @@
+package parsejob
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+// blockingRun returns a parse that waits for release, or for its context,
+// and then reports the fights; started is closed once it runs
+func blockingRun(fights int, err error) (run Func, started, release chan struct{}) {
+	started, release = make(chan struct{}), make(chan struct{})
+	run = func(ctx context.Context) (int, error) {
+		close(started)
+		select {
+		case <-release:
+			return fights, err
+		case <-ctx.Done():
+			return 0, ctx.Err()
+		}
+	}
+
+	return run, started, release
+}
+
+// waitFinished polls the job until it is no longer running
+func waitFinished(t *testing.T, m *Manager, id string) Status {
+	t.Helper()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		status, ok := m.Get(id)
+		if !ok {
+			t.Fatalf("job %s is unknown", id)
+		}
+		if status.State != StateRunning {
+			return status
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("job %s is still running", id)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestManager(t *testing.T) {
+	tests := []struct {
+		name   string
+		fights int
+		err    error
+		state  string
+	}{
+		{"succeeded", 6, nil, StateSucceeded},
+		{"failed", 0, errors.New("source unavailable"), StateFailed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := NewManager()
+			defer m.Stop()
+			run, started, release := blockingRun(tt.fights, tt.err)
+
+			job, err := m.Start(run)
+			if err != nil || job.State != StateRunning || job.ID == "" {
+				t.Fatalf("Start = %+v, %v; want a running job", job, err)
+			}
+			<-started
+
+			// A second start while the job runs returns the running job
+			second, err := m.Start(func(context.Context) (int, error) { return 0, nil })
+			if !errors.Is(err, ErrRunning) || second.ID != job.ID {
+				t.Errorf("second Start = %+v, %v; want ErrRunning with job %s", second, err, job.ID)
+			}
+
+			close(release)
+			status := waitFinished(t, m, job.ID)
+			if status.State != tt.state || status.FightCount != tt.fights || status.FinishedAt == nil {
+				t.Errorf("status = %+v, want %s with %d fights", status, tt.state, tt.fights)
+			}
+			if tt.err != nil && status.Error != tt.err.Error() {
+				t.Errorf("error = %q, want %q", status.Error, tt.err)
+			}
+
+			// A finished job frees the manager for the next one
+			next, err := m.Start(func(context.Context) (int, error) { return 1, nil })
+			if err != nil || next.ID == job.ID {
+				t.Errorf("Start after the job finished = %+v, %v; want a new job", next, err)
+			}
+			waitFinished(t, m, next.ID)
+		})
+	}
+}
+
+func TestManagerRunsOneJobAtATime(t *testing.T) {
+	m := NewManager()
+	defer m.Stop()
+	run, _, release := blockingRun(1, nil)
+
+	// Concurrent starts: exactly one job runs, the others see it
+	const starts = 20
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	started, conflicts := map[string]int{}, map[string]int{}
+	for i := 0; i < starts; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			job, err := m.Start(run)
+			mu.Lock()
+			defer mu.Unlock()
+			switch {
+			case err == nil:
+				started[job.ID]++
+			case errors.Is(err, ErrRunning):
+				conflicts[job.ID]++
+			default:
+				t.Errorf("Start = %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+	close(release)
+
+	if len(started) != 1 {
+		t.Fatalf("started jobs = %v, want exactly one", started)
+	}
+	for id := range started {
+		if conflicts[id] != starts-1 || len(conflicts) != 1 {
+			t.Errorf("conflicts = %v, want %d on job %s", conflicts, starts-1, id)
+		}
+		waitFinished(t, m, id)
+	}
+}
+
+func TestManagerStop(t *testing.T) {
+	m := NewManager()
+	run, started, _ := blockingRun(1, nil)
+	job, err := m.Start(run)
+	if err != nil {
+		t.Fatal(err)
+	}
+	<-started
+
+	// Stop cancels the running parse and waits for it
+	m.Stop()
+	status, _ := m.Get(job.ID)
+	if status.State != StateFailed || status.Error != context.Canceled.Error() {
+		t.Errorf("status after Stop = %+v, want failed with %v", status, context.Canceled)
+	}
+	if _, err := m.Start(run); !errors.Is(err, ErrStopped) {
+		t.Errorf("Start after Stop = %v, want ErrStopped", err)
+	}
+}
+
+func TestManagerForgetsOldJobs(t *testing.T) {
+	m := NewManager()
+	defer m.Stop()
+
+	var ids []string
+	for i := 0; i < maxFinished+2; i++ {
+		job, err := m.Start(func(context.Context) (int, error) { return i, nil })
+		if err != nil {
+			t.Fatal(err)
+		}
+		waitFinished(t, m, job.ID)
+		ids = append(ids, job.ID)
+	}
+
+	for i, id := range ids {
+		_, ok := m.Get(id)
+		if want := i >= 2; ok != want {
+			t.Errorf("job %d kept = %v, want %v", i, ok, want)
+		}
+	}
+	if _, ok := m.Get("parse-0-0"); ok {
+		t.Error("an unknown job ID was found")
+	}
+}
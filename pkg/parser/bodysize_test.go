@@ -0,0 +1,150 @@
+package parser
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestDeclaredOversizedBodyIsNotRead(t *testing.T) {
+	const limit = 64 << 10
+	var written atomic.Int64
+	src := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Header().Set("Content-Length", strconv.Itoa(100<<20))
+		chunk := bytes.Repeat([]byte("x"), 32<<10)
+		for written.Load() < 100<<20 {
+			n, err := w.Write(chunk)
+			written.Add(int64(n))
+			if err != nil {
+				return
+			}
+		}
+	}))
+	t.Cleanup(src.Close)
+	p := newQuietParser(src.URL + "/")
+	p.MaxBodySize = limit
+
+	_, err := p.fetchHTMLDocument(context.Background(), src.URL+"/")
+	var tooLarge *ResponseTooLargeError
+	if !errors.As(err, &tooLarge) {
+		t.Fatalf("fetch = %v, want a ResponseTooLargeError", err)
+	}
+	if tooLarge.ContentLength != 100<<20 || tooLarge.Limit != limit {
+		t.Errorf("error = %+v, want the declared 100 MiB against the limit", tooLarge)
+	}
+	if !strings.Contains(err.Error(), "104857600 bytes declared") {
+		t.Errorf("error = %q, want the declared size", err)
+	}
+}
+
+func TestStreamedOversizedBodyStopsAtTheLimit(t *testing.T) {
+	const limit = 64 << 10
+	const endless = 1 << 30
+	var written atomic.Int64
+	done := make(chan struct{})
+	src := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defer close(done)
+		// No Content-Length: the body is streamed until the client hangs up
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		chunk := bytes.Repeat([]byte("<p>filler</p>"), 2<<10)
+		for written.Load() < endless {
+			n, err := w.Write(chunk)
+			written.Add(int64(n))
+			if err != nil {
+				return
+			}
+			w.(http.Flusher).Flush()
+		}
+	}))
+	t.Cleanup(src.Close)
+	p := newQuietParser(src.URL + "/")
+	p.MaxBodySize = limit
+
+	_, err := p.fetchHTMLDocument(context.Background(), src.URL+"/")
+	var tooLarge *ResponseTooLargeError
+	if !errors.As(err, &tooLarge) || !errors.Is(err, ErrResponseTooLarge) {
+		t.Fatalf("fetch = %v, want a ResponseTooLargeError", err)
+	}
+	if tooLarge.ContentLength != -1 {
+		t.Errorf("ContentLength = %d, want -1 for a streamed body", tooLarge.ContentLength)
+	}
+	if origin, _ := OriginOf(err); origin != ErrorOriginSource {
+		t.Errorf("origin = %q, want %q", origin, ErrorOriginSource)
+	}
+
+	// The parser hung up after the limit: the server stops writing long
+	// before the end of the body, having filled at most the socket buffers
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("the server is still writing the body")
+	}
+	if sent := written.Load(); sent >= endless/16 {
+		t.Errorf("the server wrote %d bytes, want the transfer to stop soon after the %d byte limit", sent, limit)
+	}
+}
+
+func TestBodyAtTheLimitIsRead(t *testing.T) {
+	page := monthPage("Sized", 2024, time.May, 2)
+	src := newEncodedSource(t, "", []byte(page), nil)
+	p := newQuietParser(src.URL + "/")
+	p.MaxBodySize = int64(len(page))
+
+	body, err := p.fetchHTMLDocument(context.Background(), src.URL+"/")
+	if err != nil || string(body) != page {
+		t.Fatalf("fetch of a page of exactly the limit = %d bytes, %v, want the page", len(body), err)
+	}
+
+	p.MaxBodySize = int64(len(page)) - 1
+	if _, err := p.fetchHTMLDocument(context.Background(), src.URL+"/"); !errors.Is(err, ErrResponseTooLarge) {
+		t.Errorf("fetch of a page one byte over the limit = %v, want ErrResponseTooLarge", err)
+	}
+}
+
+func TestReadLimited(t *testing.T) {
+	tests := []struct {
+		size    int
+		limit   int64
+		tooLong bool
+	}{
+		{0, 10, false},
+		{10, 10, false},
+		{11, 10, true},
+		{1 << 20, 10, true},
+	}
+	for _, tt := range tests {
+		reader := &countingReader{r: bytes.NewReader(make([]byte, tt.size))}
+		body, err := readLimited(reader, "http://source.example/", tt.limit)
+		if tt.tooLong {
+			if !errors.Is(err, ErrResponseTooLarge) {
+				t.Errorf("readLimited of %d bytes with limit %d = %v, want ErrResponseTooLarge", tt.size, tt.limit, err)
+			}
+		} else if err != nil || len(body) != tt.size {
+			t.Errorf("readLimited of %d bytes with limit %d = %d bytes, %v", tt.size, tt.limit, len(body), err)
+		}
+		// Never more than one byte past the limit is read
+		if reader.n > tt.limit+1 {
+			t.Errorf("readLimited of %d bytes with limit %d read %d bytes", tt.size, tt.limit, reader.n)
+		}
+	}
+}
+
+// countingReader counts the bytes read through it
+type countingReader struct {
+	r *bytes.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
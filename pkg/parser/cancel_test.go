@@ -0,0 +1,106 @@
+package parser
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// hangingSource accepts requests and never answers them; aborted is
+// incremented when the parser gives a request up
+func hangingSource(t *testing.T) (srv *httptest.Server, started chan struct{}, hits, aborted *atomic.Int32) {
+	t.Helper()
+
+	started = make(chan struct{}, 10)
+	hits, aborted = new(atomic.Int32), new(atomic.Int32)
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		started <- struct{}{}
+		<-r.Context().Done()
+		aborted.Add(1)
+	}))
+	t.Cleanup(srv.Close)
+
+	return srv, started, hits, aborted
+}
+
+func TestParseFightsContextCancellation(t *testing.T) {
+	tests := []struct {
+		name string
+		// context returns the context of the parse, cancelled once the
+		// source got the request
+		context func(started <-chan struct{}) context.Context
+		want    error
+		// requested is set when the source is reached before the end
+		requested bool
+	}{
+		{
+			name: "cancelled during the fetch",
+			context: func(started <-chan struct{}) context.Context {
+				ctx, cancel := context.WithCancel(context.Background())
+				go func() {
+					<-started
+					cancel()
+				}()
+				return ctx
+			},
+			want:      context.Canceled,
+			requested: true,
+		},
+		{
+			name: "deadline during the fetch",
+			context: func(<-chan struct{}) context.Context {
+				ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+				t.Cleanup(cancel)
+				return ctx
+			},
+			want:      context.DeadlineExceeded,
+			requested: true,
+		},
+		{
+			name: "cancelled before the parse",
+			context: func(<-chan struct{}) context.Context {
+				ctx, cancel := context.WithCancel(context.Background())
+				cancel()
+				return ctx
+			},
+			want: context.Canceled,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv, started, hits, aborted := hangingSource(t)
+			p := NewParser(srv.URL + "/")
+			p.RetryAttempts = 1
+			goroutines := runtime.NumGoroutine()
+
+			begin := time.Now()
+			_, err := p.ParseFightsContext(tt.context(started))
+			if !errors.Is(err, tt.want) || !strings.Contains(err.Error(), "cancelled") {
+				t.Fatalf("ParseFightsContext = %v, want a cancelled parse with %v", err, tt.want)
+			}
+			if elapsed := time.Since(begin); elapsed > 5*time.Second {
+				t.Errorf("the cancelled parse returned after %s", elapsed)
+			}
+			if got := hits.Load() > 0; got != tt.requested {
+				t.Errorf("source requested = %v, want %v", got, tt.requested)
+			}
+
+			// The aborted request and the parse goroutines are gone
+			deadline := time.Now().Add(2 * time.Second)
+			for aborted.Load() != hits.Load() || runtime.NumGoroutine() > goroutines {
+				if time.Now().After(deadline) {
+					t.Fatalf("after the cancellation: %d of %d requests aborted, %d goroutines, want at most %d",
+						aborted.Load(), hits.Load(), runtime.NumGoroutine(), goroutines)
+				}
+				time.Sleep(10 * time.Millisecond)
+			}
+		})
+	}
+}
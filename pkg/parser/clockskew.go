@@ -0,0 +1,80 @@
+package parser
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"easypars/models"
+	"easypars/pkg/clock"
+)
+
+// IssueDateContextMissing is the issue code of a fight dropped because its
+// date could not be resolved from the page while the local clock is not
+// trusted
+const IssueDateContextMissing = "date_context_missing"
+
+// checkClockSkew compares the Date header of a successful source response
+// with the parser clock
+// Level changes are logged; a critical skew switches the parser to dates
+// taken from the page only (see ClockSkewCritical).
+func (p *Parser) checkClockSkew(resp *http.Response) {
+	if p.ClockSkew == nil {
+		return
+	}
+	date, err := http.ParseTime(resp.Header.Get("Date"))
+	if err != nil {
+		return
+	}
+
+	status, changed := p.ClockSkew.Observe(resp.Request.URL.Host, p.clock().Now(), date)
+	if !changed {
+		return
+	}
+	switch status.Level {
+	case clock.SkewCritical:
+		p.logger().Error("Server clock differs from the source time, dates are only taken from the page",
+			"skew_seconds", status.SkewSeconds, "reference", status.Reference)
+	case clock.SkewWarning:
+		p.logger().Warn("Server clock differs from the source time",
+			"skew_seconds", status.SkewSeconds, "reference", status.Reference)
+	default:
+		p.logger().Info("Server clock agrees with the source time again",
+			"skew_seconds", status.SkewSeconds, "reference", status.Reference)
+	}
+}
+
+// ClockSkewCritical reports whether the parser clock is off by more than
+// the critical skew
+// Incomplete dates are then resolved from the div.month headers only, never
+// from the clock, and time spans are measured with the monotonic clock.
+func (p *Parser) ClockSkewCritical() bool {
+	return p.ClockSkew != nil && p.ClockSkew.Critical()
+}
+
+// contextDates converts events with dates resolved from the page context
+// only, for runs while the clock is not trusted
+// Rows without a div.month header are dropped with an issue.
+func contextDates(events []FightEvent, location *time.Location) ([]models.Fight, []ParseIssue) {
+	fights := make([]models.Fight, 0, len(events))
+	var issues []ParseIssue
+	for _, event := range events {
+		if event.ContextYear == 0 {
+			issues = append(issues, droppedWithoutContext(event.Fighter1, event.Fighter2))
+			continue
+		}
+		ref := time.Date(event.ContextYear, event.ContextMonth, 1, 0, 0, 0, 0, location)
+		fights = append(fights, convertEventToFight(event, ref))
+	}
+
+	return fights, issues
+}
+
+// droppedWithoutContext returns the issue of a fight dropped without a date context
+func droppedWithoutContext(fighter1, fighter2 string) ParseIssue {
+	return ParseIssue{
+		Stage:   "extract",
+		Code:    IssueDateContextMissing,
+		Message: fmt.Sprintf("fight %s vs %s dropped: no month header to date it while the server clock is skewed", fighter1, fighter2),
+	}
+}
@@ -0,0 +1,91 @@
+package parser
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"easypars/pkg/clock"
+)
+
+// skewPage has a fight before any month header and two fights of May 2024
+var skewPage = strings.Replace(monthPage("Dated", 2024, time.May, 2), "<body>",
+	`<body><table><tr><td class="date">3</td><td class="place">Arena</td>`+
+		`<td class="boxer_1">Loose Red</td><td class="vs">UD</td><td class="boxer_2">Loose Blue</td></tr></table>`, 1)
+
+func TestClockSkew(t *testing.T) {
+	clk := newFakeClock()
+	var sourceTime atomic.Pointer[time.Time]
+	src := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Date", sourceTime.Load().Format(http.TimeFormat))
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		fmt.Fprint(w, skewPage)
+	}))
+	defer src.Close()
+
+	p := NewParser(src.URL + "/")
+	p.Clock = clk
+	p.ClockSkew = clock.NewSkewMonitor(10*time.Minute, 24*time.Hour)
+
+	// The runs follow each other: the last one shows the recovery
+	tests := []struct {
+		name string
+		// sourceOffset is the source time minus the parser clock
+		sourceOffset time.Duration
+		level        string
+		// dates are the fight dates in page order
+		dates   []string
+		dropped int
+	}{
+		{"in sync", 2 * time.Second, clock.SkewOK, []string{"2024-06-03", "2024-05-01", "2024-05-02"}, 0},
+		{"medium skew", -time.Hour, clock.SkewWarning, []string{"2024-06-03", "2024-05-01", "2024-05-02"}, 0},
+		{"clock a year behind", 365 * 24 * time.Hour, clock.SkewCritical, []string{"2024-05-01", "2024-05-02"}, 1},
+		{"recovered", 0, clock.SkewOK, []string{"2024-06-03", "2024-05-01", "2024-05-02"}, 0},
+	}
+	for _, tt := range tests {
+		at := clk.Now().Add(tt.sourceOffset)
+		sourceTime.Store(&at)
+
+		result, err := p.ParseDetailed(context.Background())
+		if err != nil {
+			t.Fatalf("%s: ParseDetailed: %v", tt.name, err)
+		}
+
+		status, _ := p.ClockSkew.Status()
+		if status.Level != tt.level || p.ClockSkewCritical() != (tt.level == clock.SkewCritical) {
+			t.Errorf("%s: level = %s, want %s", tt.name, status.Level, tt.level)
+		}
+		var dates []string
+		for _, fight := range result.Fights {
+			dates = append(dates, fight.Date)
+		}
+		if strings.Join(dates, " ") != strings.Join(tt.dates, " ") {
+			t.Errorf("%s: dates = %q, want %q", tt.name, dates, tt.dates)
+		}
+		dropped := 0
+		for _, issue := range result.Issues {
+			if issue.Code == IssueDateContextMissing {
+				dropped++
+			}
+		}
+		if dropped != tt.dropped {
+			t.Errorf("%s: %d %s issues, want %d", tt.name, dropped, IssueDateContextMissing, tt.dropped)
+		}
+	}
+}
+
+func TestClockSkewIgnoresResponsesWithoutADate(t *testing.T) {
+	p := NewParser("http://vringe.example/")
+	p.ClockSkew = clock.NewSkewMonitor(0, 0)
+
+	resp := &http.Response{Header: http.Header{"Date": {"yesterday"}}, Request: httptest.NewRequest(http.MethodGet, "http://vringe.example/", nil)}
+	p.checkClockSkew(resp)
+	if _, checked := p.ClockSkew.Status(); checked {
+		t.Error("an unreadable Date header was used as the reference time")
+	}
+}
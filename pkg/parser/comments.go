@@ -0,0 +1,46 @@
+package parser
+
+import (
+	"regexp"
+	"strings"
+	"time"
+
+	"easypars/models"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// commentPattern matches HTML comments including multi-line ones
+var commentPattern = regexp.MustCompile(`(?s)<!--(.*?)-->`)
+
+// extractCommentedFights finds fight rows that the editors commented out
+// Such rows are invisible to goquery, but sometimes hold useful announcements.
+// Only comments that look like table rows (contain a boxer_1 cell) are parsed,
+// the resulting fights are marked hidden_in_source with a lower confidence.
+func extractCommentedFights(html string, ref time.Time) []models.Fight {
+	var fights []models.Fight
+
+	for _, match := range commentPattern.FindAllStringSubmatch(html, -1) {
+		content := match[1]
+
+		// Cheap check before building a document for the comment
+		if !strings.Contains(content, "boxer_1") {
+			continue
+		}
+
+		// Rows outside of a table are dropped by the HTML parser, so wrap them
+		doc, err := goquery.NewDocumentFromReader(strings.NewReader("<table>" + content + "</table>"))
+		if err != nil {
+			continue
+		}
+
+		for _, event := range extractFightElements(doc.Selection) {
+			fight := convertEventToFight(event, eventRef(event, ref))
+			fight.HiddenInSource = true
+			fight.Confidence = confidenceHidden
+			fights = append(fights, fight)
+		}
+	}
+
+	return fights
+}
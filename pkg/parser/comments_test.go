@@ -0,0 +1,97 @@
+package parser
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"easypars/models"
+)
+
+func TestExtractCommentedFights(t *testing.T) {
+	html := `<div class="month">Май 2024</div>
+<table>
+<tr><td class="date">18</td><td class="place">Riyadh</td><td class="boxer_1">Usyk</td><td class="vs">vs</td><td class="boxer_2">Fury</td></tr>
+<!-- <tr><td class="date">25</td><td class="place">London</td><td class="boxer_1">Joshua</td><td class="vs">vs</td><td class="boxer_2">Dubois</td></tr> -->
+<!-- editor note: check the undercard -->
+<!--
+<tr><td class="date">01.06</td><td class="place">Las Vegas</td>
+<td class="boxer_1">Canelo</td><td class="vs">vs</td><td class="boxer_2">Munguia</td></tr>
+-->
+</table>`
+	ref := time.Date(2024, time.May, 1, 0, 0, 0, 0, time.UTC)
+
+	fights := extractCommentedFights(html, ref)
+	if len(fights) != 2 {
+		t.Fatalf("extracted %d hidden fights, want 2: %+v", len(fights), fights)
+	}
+	want := []struct{ date, fighter1, fighter2 string }{
+		{"2024-05-25", "Joshua", "Dubois"},
+		{"2024-06-01", "Canelo", "Munguia"},
+	}
+	for i, w := range want {
+		fight := fights[i]
+		if fight.Date != w.date || fight.Fighter1 != w.fighter1 || fight.Fighter2 != w.fighter2 {
+			t.Errorf("fight %d = %s %s vs %s, want %s %s vs %s", i, fight.Date, fight.Fighter1, fight.Fighter2, w.date, w.fighter1, w.fighter2)
+		}
+		if !fight.HiddenInSource || fight.Confidence != confidenceHidden {
+			t.Errorf("fight %d: hidden = %v, confidence = %v, want hidden with %v", i, fight.HiddenInSource, fight.Confidence, confidenceHidden)
+		}
+	}
+}
+
+func TestExtractCommentedFightsIgnoresOtherComments(t *testing.T) {
+	html := `<!-- counter --><!-- <div class="boxer">not a row</div> --><p>text</p>`
+	if fights := extractCommentedFights(html, time.Now()); len(fights) != 0 {
+		t.Errorf("extracted %+v from comments without fight rows", fights)
+	}
+}
+
+func TestParseCommentsOption(t *testing.T) {
+	page, err := os.ReadFile(filepath.Join("testdata", "hidden_comments.html"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	src := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write(page)
+	}))
+	defer src.Close()
+
+	for _, parseComments := range []bool{false, true} {
+		p := NewParser(src.URL + "/")
+		p.MonthURL = src.URL + "/{year}/{month}"
+		p.ParseComments = parseComments
+
+		result, err := p.ParseMonth(context.Background(), 2024, time.May)
+		if err != nil {
+			t.Fatalf("ParseComments=%v: %v", parseComments, err)
+		}
+
+		var visible, hidden []models.Fight
+		for _, fight := range result.Fights {
+			if fight.HiddenInSource {
+				hidden = append(hidden, fight)
+			} else {
+				visible = append(visible, fight)
+			}
+		}
+		if len(visible) != 2 {
+			t.Errorf("ParseComments=%v: %d visible fights, want 2", parseComments, len(visible))
+		}
+		wantHidden := 0
+		if parseComments {
+			wantHidden = 1
+		}
+		if len(hidden) != wantHidden {
+			t.Fatalf("ParseComments=%v: %d hidden fights, want %d", parseComments, len(hidden), wantHidden)
+		}
+		if parseComments && (hidden[0].Fighter1 != "Joshua" || hidden[0].Date != "2024-05-25") {
+			t.Errorf("hidden fight = %s %s vs %s, want 2024-05-25 Joshua vs Dubois", hidden[0].Date, hidden[0].Fighter1, hidden[0].Fighter2)
+		}
+	}
+}
@@ -0,0 +1,229 @@
+package parser
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"easypars/pkg/contract"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// Compatibility verdicts of CheckSource
+const (
+	VerdictCompatible   = "compatible"
+	VerdictDegraded     = "degraded"
+	VerdictIncompatible = "incompatible"
+)
+
+// CompatThresholds decide the verdict of a compatibility check
+type CompatThresholds struct {
+	// MinFights is the number of fights below which the page is incompatible
+	MinFights int
+	// DegradedValidShare is the share of valid fights below which the page
+	// is degraded
+	DegradedValidShare float64
+	// IncompatibleValidShare is the share of valid fights below which the
+	// page is incompatible
+	IncompatibleValidShare float64
+}
+
+// DefaultCompatThresholds returns the thresholds used when none are configured
+func DefaultCompatThresholds() CompatThresholds {
+	return CompatThresholds{
+		MinFights:              1,
+		DegradedValidShare:     0.9,
+		IncompatibleValidShare: 0.5,
+	}
+}
+
+// Validate checks that the shares are ordered and within 0..1
+func (t CompatThresholds) Validate() error {
+	if t.MinFights < 0 {
+		return fmt.Errorf("min_fights must not be negative, got %d", t.MinFights)
+	}
+	if t.IncompatibleValidShare < 0 || t.DegradedValidShare > 1 || t.IncompatibleValidShare > t.DegradedValidShare {
+		return fmt.Errorf("valid shares must satisfy 0 <= incompatible (%g) <= degraded (%g) <= 1",
+			t.IncompatibleValidShare, t.DegradedValidShare)
+	}
+
+	return nil
+}
+
+// CompatReport is the outcome of a compatibility check of the source
+type CompatReport struct {
+	URL       string    `json:"url"`
+	CheckedAt time.Time `json:"checked_at"`
+	// DurationMs covers the fetch and the analysis
+	DurationMs int64 `json:"duration_ms"`
+	// Tables, Rows and FightRows count the tables, the table rows and the
+	// rows recognized as fights (with a td.boxer_1 cell)
+	Tables    int `json:"tables"`
+	Rows      int `json:"rows"`
+	FightRows int `json:"fight_rows"`
+	// Fights is the number of fights after post-processing, ValidFights
+	// those without a broken invariant or a date issue
+	Fights      int     `json:"fights"`
+	ValidFights int     `json:"valid_fights"`
+	ValidShare  float64 `json:"valid_share"`
+	// MonthHeaders counts the div.month headers, MonthContext tells whether
+	// fight rows got their month from one
+	MonthHeaders int  `json:"month_headers"`
+	MonthContext bool `json:"month_context"`
+	// Structure counts the selectors the extraction relies on
+	Structure StructureReport `json:"structure"`
+	// IssueCodes counts the issues of the parse by code
+	IssueCodes map[string]int `json:"issue_codes,omitempty"`
+	Warnings   []string       `json:"warnings,omitempty"`
+	Verdict    string         `json:"verdict"`
+}
+
+// ExitCode returns the exit code of the verdict for deploy pipelines:
+// 0 compatible, 1 degraded, 2 incompatible
+func (r *CompatReport) ExitCode() int {
+	switch r.Verdict {
+	case VerdictCompatible:
+		return 0
+	case VerdictDegraded:
+		return 1
+	default:
+		return 2
+	}
+}
+
+// Text renders the report for terminals
+func (r *CompatReport) Text() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Source:        %s\n", r.URL)
+	fmt.Fprintf(&b, "Checked at:    %s (%d ms)\n", r.CheckedAt.Format(time.RFC3339), r.DurationMs)
+	fmt.Fprintf(&b, "Tables/rows:   %d tables, %d rows, %d fight rows\n", r.Tables, r.Rows, r.FightRows)
+	fmt.Fprintf(&b, "Fights:        %d, %d valid (%.0f%%)\n", r.Fights, r.ValidFights, r.ValidShare*100)
+	fmt.Fprintf(&b, "Month context: %t (%d headers)\n", r.MonthContext, r.MonthHeaders)
+	if len(r.Structure.Missing) > 0 {
+		fmt.Fprintf(&b, "Missing:       %s\n", strings.Join(r.Structure.Missing, ", "))
+	}
+	for _, warning := range r.Warnings {
+		fmt.Fprintf(&b, "Warning:       %s\n", warning)
+	}
+	fmt.Fprintf(&b, "Verdict:       %s\n", r.Verdict)
+
+	return b.String()
+}
+
+// CheckSource checks that the parser still understands the live source
+// It makes a single request to the main page, respecting the pause of the
+// source, and runs the extraction, the post-processors and the model
+// invariants on it without storing anything. The verdict follows the
+// thresholds: too few fights or valid fights make the page incompatible,
+// a lower share of valid fights, degraded columns or missing month
+// headers make it degraded. Fetch errors are returned as errors.
+func (p *Parser) CheckSource(ctx context.Context, thresholds CompatThresholds) (*CompatReport, error) {
+	start := time.Now()
+	url := p.relocations.resolve(p.BaseURL)
+	report := &CompatReport{URL: url, CheckedAt: p.clock().Now()}
+
+	// Step 1: Fetch the page like a regular parse
+	if err := p.waitSourcePause(ctx); err != nil {
+		return nil, Classify(ErrorOriginSource, err)
+	}
+	body, err := p.fetchHTMLDocument(ctx, url)
+	if err != nil {
+		return nil, err
+	}
+
+	// Step 2: Count the structure the extraction relies on
+	doc, err := goquery.NewDocumentFromReader(bytes.NewReader(body))
+	if err != nil {
+		return nil, Classify(ErrorOriginSource, fmt.Errorf("error parsing HTML: %w", err))
+	}
+	report.Tables = doc.Find("table").Length()
+	report.Rows = doc.Find("tr").Length()
+	report.MonthHeaders = doc.Find("div.month").Length()
+	report.Structure = detectHTMLStructureChanges(doc)
+	events := extractFightElements(doc.Selection)
+	report.FightRows = len(events)
+	for _, event := range events {
+		if event.ContextYear != 0 {
+			report.MonthContext = true
+			break
+		}
+	}
+
+	// Step 3: Parse and validate the fights
+	ref := p.clock().Now().In(p.location())
+	// A page without the expected elements is reported, not failed
+	fights, columns, issues, err := p.parseHTML(ctx, body, ref, false)
+	if errors.Is(err, ErrStructureChanged) {
+		fights, columns, issues, err = nil, nil, nil, nil
+		report.Warnings = append(report.Warnings, "page structure changed, missing: "+strings.Join(report.Structure.Missing, ", "))
+	}
+	if err != nil {
+		return nil, Classify(ErrorOriginSource, err)
+	}
+	fights, stageIssues, _, err := p.runPostProcessors(ctx, fights)
+	if err != nil {
+		return nil, Classify(ErrorOriginInternal, err)
+	}
+	issues = append(issues, stageIssues...)
+	report.IssueCodes = CountIssues(issues)
+
+	invalid := make(map[string]bool)
+	for _, issue := range issues {
+		if issue.Code == "invalid_date" && issue.FightKey != "" {
+			invalid[issue.FightKey] = true
+		}
+	}
+	report.Fights = len(fights)
+	for _, fight := range fights {
+		if !invalid[fight.Key] && len(contract.ValidateFightInvariants(fight)) == 0 {
+			report.ValidFights++
+		}
+	}
+	if report.Fights > 0 {
+		report.ValidShare = float64(report.ValidFights) / float64(report.Fights)
+	}
+
+	// Step 4: Decide the verdict
+	report.Verdict = VerdictCompatible
+	degrade := func(warning string) {
+		report.Warnings = append(report.Warnings, warning)
+		if report.Verdict == VerdictCompatible {
+			report.Verdict = VerdictDegraded
+		}
+	}
+	incompatible := func(warning string) {
+		report.Warnings = append(report.Warnings, warning)
+		report.Verdict = VerdictIncompatible
+	}
+
+	if report.FightRows == 0 {
+		incompatible("no fight rows (td.boxer_1) found")
+	}
+	if report.Fights < thresholds.MinFights {
+		incompatible(fmt.Sprintf("%d fights parsed, at least %d expected", report.Fights, thresholds.MinFights))
+	}
+	if report.Fights > 0 && report.ValidShare < thresholds.IncompatibleValidShare {
+		incompatible(fmt.Sprintf("only %.0f%% of the fights are valid", report.ValidShare*100))
+	} else if report.Fights > 0 && report.ValidShare < thresholds.DegradedValidShare {
+		degrade(fmt.Sprintf("only %.0f%% of the fights are valid", report.ValidShare*100))
+	}
+	for _, role := range DegradedColumns(columns) {
+		degrade("column " + role + " has too many unexpected values")
+	}
+	if report.FightRows > 0 && !report.MonthContext {
+		degrade("no month headers (div.month) before the fight rows")
+	}
+	report.DurationMs = time.Since(start).Milliseconds()
+
+	p.logger().InfoContext(ctx, "Source compatibility checked",
+		"url", url,
+		"verdict", report.Verdict,
+		"fights", report.Fights,
+		"valid_fights", report.ValidFights)
+
+	return report, nil
+}
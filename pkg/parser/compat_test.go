@@ -0,0 +1,113 @@
+package parser
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// pageServer serves the page as the source
+func pageServer(t *testing.T, page string) *httptest.Server {
+	t.Helper()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		fmt.Fprint(w, page)
+	}))
+	t.Cleanup(srv.Close)
+
+	return srv
+}
+
+func TestCheckSource(t *testing.T) {
+	compatible := monthPage("Check", 2024, time.May, 5)
+	withoutMonths := strings.Replace(compatible, `<div class="month">Май 2024</div>`, "", 1)
+	withoutFights := `<html><body><div class="month">Май 2024</div><table><tr><td class="date">1</td><td>Usyk - Fury</td></tr></table></body></html>`
+
+	tests := []struct {
+		name       string
+		page       string
+		thresholds CompatThresholds
+		verdict    string
+		fights     int
+		warning    string
+	}{
+		{"compatible", compatible, DefaultCompatThresholds(), VerdictCompatible, 5, ""},
+		{"no month headers", withoutMonths, DefaultCompatThresholds(), VerdictDegraded, 5, "no month headers"},
+		{"no fight rows", withoutFights, DefaultCompatThresholds(), VerdictIncompatible, 0, "no fight rows"},
+		{"too few fights", compatible, CompatThresholds{MinFights: 10, DegradedValidShare: 0.9, IncompatibleValidShare: 0.5}, VerdictIncompatible, 5, "at least 10 expected"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := NewParser(pageServer(t, tt.page).URL + "/")
+			p.Clock = newFakeClock()
+
+			report, err := p.CheckSource(context.Background(), tt.thresholds)
+			if err != nil {
+				t.Fatalf("CheckSource: %v", err)
+			}
+			if report.Verdict != tt.verdict || report.Fights != tt.fights {
+				t.Errorf("verdict = %s with %d fights (%q), want %s with %d", report.Verdict, report.Fights, report.Warnings, tt.verdict, tt.fights)
+			}
+			if tt.warning != "" && !strings.Contains(strings.Join(report.Warnings, "\n"), tt.warning) {
+				t.Errorf("warnings = %q, want one about %q", report.Warnings, tt.warning)
+			}
+			if tt.verdict == VerdictCompatible && (len(report.Warnings) != 0 || report.ValidShare != 1 || !report.MonthContext) {
+				t.Errorf("report = %+v, want all fights valid in their month and no warnings", report)
+			}
+
+			wantExit := map[string]int{VerdictCompatible: 0, VerdictDegraded: 1, VerdictIncompatible: 2}[tt.verdict]
+			if report.ExitCode() != wantExit {
+				t.Errorf("exit code = %d, want %d", report.ExitCode(), wantExit)
+			}
+
+			// Both renderings carry the verdict
+			data, err := json.Marshal(report)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var decoded CompatReport
+			if err := json.Unmarshal(data, &decoded); err != nil || decoded.Verdict != report.Verdict || decoded.Fights != report.Fights {
+				t.Errorf("JSON report = %s (%v), want the verdict and the counts", data, err)
+			}
+			if text := report.Text(); !strings.Contains(text, "Verdict:       "+tt.verdict) {
+				t.Errorf("text report = %q, want the verdict", text)
+			}
+		})
+	}
+}
+
+func TestCheckSourceFetchFailure(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "gone", http.StatusNotFound)
+	}))
+	defer srv.Close()
+
+	p := NewParser(srv.URL + "/")
+	if report, err := p.CheckSource(context.Background(), DefaultCompatThresholds()); err == nil {
+		t.Errorf("CheckSource of a failing source = %+v, want an error", report)
+	}
+}
+
+func TestCompatThresholdsValidate(t *testing.T) {
+	tests := []struct {
+		thresholds CompatThresholds
+		wantErr    bool
+	}{
+		{DefaultCompatThresholds(), false},
+		{CompatThresholds{}, false},
+		{CompatThresholds{MinFights: -1}, true},
+		{CompatThresholds{DegradedValidShare: 0.5, IncompatibleValidShare: 0.9}, true},
+		{CompatThresholds{DegradedValidShare: 1.5, IncompatibleValidShare: 0.5}, true},
+	}
+	for _, tt := range tests {
+		if err := tt.thresholds.Validate(); (err != nil) != tt.wantErr {
+			t.Errorf("Validate(%+v) = %v, want error %v", tt.thresholds, err, tt.wantErr)
+		}
+	}
+}
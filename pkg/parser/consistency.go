@@ -0,0 +1,114 @@
+package parser
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"easypars/models"
+	"easypars/pkg/clock"
+)
+
+// Default thresholds of the date consistency checks
+const (
+	// DefaultStaleTBDDays is the number of days after which a past fight
+	// without a result is considered to have an unknown result
+	DefaultStaleTBDDays = 14
+)
+
+// Issue codes reported by the date consistency checks
+const (
+	IssueDateResultConflict = "date_result_conflict"
+	IssueStaleTBD           = "stale_tbd"
+	IssueYearAdjusted       = "year_adjusted"
+)
+
+// dateConsistencyStage checks that the fight date agrees with the result
+// It reads the clock and thresholds from the parser at run time
+type dateConsistencyStage struct {
+	parser *Parser
+}
+
+// Name identifies the stage
+func (dateConsistencyStage) Name() string { return "date_consistency" }
+
+// Process applies the date/result consistency rules:
+//   - a date more than a year ahead is most likely a wrong year: the year is
+//     replaced with the current one and the fight is marked year_adjusted
+//   - a future fight that already has a result gets a lower confidence and
+//     the date_result_conflict warning
+//   - a fight older than StaleTBDDays without a result gets result_unknown
+//
+// The status itself always comes from ResolveStatus, fights without a valid
+// date are left to the validation stage
+func (s dateConsistencyStage) Process(_ context.Context, fights []models.Fight) ([]models.Fight, []ParseIssue, error) {
+	today := clock.Today(s.parser.clock())
+	staleDays := s.parser.StaleTBDDays
+	if staleDays <= 0 {
+		staleDays = DefaultStaleTBDDays
+	}
+
+	result := make([]models.Fight, len(fights))
+	var issues []ParseIssue
+
+	for i, fight := range fights {
+		date, err := time.ParseInLocation("2006-01-02", fight.Date, today.Location())
+		if err != nil {
+			result[i] = fight
+			continue
+		}
+
+		// Rule 1: a date more than a year in the future is probably a wrong year
+		if date.After(today.AddDate(1, 0, 0)) {
+			adjusted := time.Date(today.Year(), date.Month(), date.Day(), 0, 0, 0, 0, today.Location())
+			issues = append(issues, ParseIssue{
+				Code:     IssueYearAdjusted,
+				Message:  fmt.Sprintf("date %s moved to %s", fight.Date, adjusted.Format("2006-01-02")),
+				FightKey: fight.Key,
+			})
+			date = adjusted
+			fight.Date = adjusted.Format("2006-01-02")
+			fight.YearAdjusted = true
+			fight.Warnings = appendWarning(fight.Warnings, IssueYearAdjusted)
+			fight.AssignKey()
+		}
+
+		// The status is resolved again because the date may have been adjusted
+		fight.Status = ResolveStatus(fight, s.parser.clock(), staleDays)
+
+		// Rule 2: a result for a fight that has not happened yet
+		if fight.Status == models.StatusCompleted && date.After(today) {
+			fight.Confidence /= 2
+			fight.Warnings = appendWarning(fight.Warnings, IssueDateResultConflict)
+			issues = append(issues, ParseIssue{
+				Code:     IssueDateResultConflict,
+				Message:  fmt.Sprintf("fight dated %s already has result %q", fight.Date, fight.Result),
+				FightKey: fight.Key,
+			})
+		}
+
+		// Rule 3: a past fight whose result never appeared
+		if fight.Status == models.StatusResultUnknown {
+			issues = append(issues, ParseIssue{
+				Code:     IssueStaleTBD,
+				Message:  fmt.Sprintf("fight dated %s still has no result", fight.Date),
+				FightKey: fight.Key,
+			})
+		}
+
+		result[i] = fight
+	}
+
+	return result, issues, nil
+}
+
+// appendWarning adds a warning code unless it is already present
+func appendWarning(warnings []string, code string) []string {
+	for _, warning := range warnings {
+		if warning == code {
+			return warnings
+		}
+	}
+
+	return append(warnings, code)
+}
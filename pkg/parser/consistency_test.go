@@ -0,0 +1,100 @@
+package parser
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"easypars/models"
+	"easypars/pkg/clock"
+)
+
+func TestDateConsistency(t *testing.T) {
+	// Today is 2024-06-10; fights without a result for more than 14 days
+	// have an unknown result
+	p := NewParser("")
+	p.Clock = clock.Fixed{Time: time.Date(2024, time.June, 10, 12, 0, 0, 0, time.UTC)}
+	p.StaleTBDDays = 14
+
+	tests := []struct {
+		name       string
+		date       string
+		result     string
+		wantDate   string
+		status     string
+		confidence float64
+		warnings   []string
+		issues     []string
+	}{
+		{"future fight with a result", "2024-07-10", "KO 3", "2024-07-10", models.StatusCompleted, 0.5,
+			[]string{IssueDateResultConflict}, []string{IssueDateResultConflict}},
+		{"past fight with a result", "2024-06-01", "KO 3", "2024-06-01", models.StatusCompleted, 1, nil, nil},
+		{"stale TBD", "2024-05-26", "vs", "2024-05-26", models.StatusResultUnknown, 1, nil, []string{IssueStaleTBD}},
+		{"TBD exactly stale_tbd_days ago", "2024-05-27", "vs", "2024-05-27", models.StatusScheduled, 1, nil, nil},
+		{"recent TBD", "2024-06-05", "vs", "2024-06-05", models.StatusScheduled, 1, nil, nil},
+		{"date more than a year ahead", "2025-08-01", "vs", "2024-08-01", models.StatusScheduled, 1,
+			[]string{IssueYearAdjusted}, []string{IssueYearAdjusted}},
+		{"date exactly a year ahead", "2025-06-10", "vs", "2025-06-10", models.StatusScheduled, 1, nil, nil},
+		// The adjusted year may still leave a result in the future
+		{"adjusted year and a result", "2025-09-01", "KO 3", "2024-09-01", models.StatusCompleted, 0.5,
+			[]string{IssueYearAdjusted, IssueDateResultConflict}, []string{IssueYearAdjusted, IssueDateResultConflict}},
+		{"adjusted year into the past with a result", "2026-03-01", "UD", "2024-03-01", models.StatusCompleted, 1,
+			[]string{IssueYearAdjusted}, []string{IssueYearAdjusted}},
+		// A guessed year is not reliable enough to call the result unknown
+		{"adjusted year into the past without a result", "2026-01-05", "vs", "2024-01-05", models.StatusScheduled, 1,
+			[]string{IssueYearAdjusted}, []string{IssueYearAdjusted}},
+		{"unreadable date", "", "vs", "", "", 1, nil, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fight := models.Fight{Date: tt.date, Fighter1: "Oleksandr Usyk", Fighter2: "Tyson Fury", Result: tt.result, Location: "Riyadh", Confidence: 1}
+			fight.AssignKey()
+
+			fights, issues, err := dateConsistencyStage{parser: p}.Process(context.Background(), []models.Fight{fight})
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := fights[0]
+			if got.Date != tt.wantDate || got.Status != tt.status || got.Confidence != tt.confidence {
+				t.Errorf("fight = %s %s confidence %v, want %s %s confidence %v", got.Date, got.Status, got.Confidence, tt.wantDate, tt.status, tt.confidence)
+			}
+			if !reflect.DeepEqual(got.Warnings, tt.warnings) {
+				t.Errorf("warnings = %q, want %q", got.Warnings, tt.warnings)
+			}
+			var codes []string
+			for _, issue := range issues {
+				codes = append(codes, issue.Code)
+			}
+			if !reflect.DeepEqual(codes, tt.issues) {
+				t.Errorf("issues = %q, want %q", codes, tt.issues)
+			}
+			if got.YearAdjusted != (got.Date != tt.date) {
+				t.Errorf("year_adjusted = %v for %s parsed as %s", got.YearAdjusted, tt.date, got.Date)
+			}
+			if got.Key != got.NaturalKey() {
+				t.Errorf("key = %s, want the key of the adjusted date %s", got.Key, got.NaturalKey())
+			}
+		})
+	}
+}
+
+func TestStaleTBDDaysDefault(t *testing.T) {
+	c := clock.Fixed{Time: time.Date(2024, time.June, 10, 12, 0, 0, 0, time.UTC)}
+	tests := []struct {
+		staleDays int
+		date      string
+		want      string
+	}{
+		{0, "2024-05-27", models.StatusScheduled},
+		{0, "2024-05-26", models.StatusResultUnknown},
+		{3, "2024-06-07", models.StatusScheduled},
+		{3, "2024-06-06", models.StatusResultUnknown},
+	}
+	for _, tt := range tests {
+		fight := models.Fight{Date: tt.date, Result: "vs"}
+		if got := ResolveStatus(fight, c, tt.staleDays); got != tt.want {
+			t.Errorf("ResolveStatus(%s, stale after %d days) = %s, want %s", tt.date, tt.staleDays, got, tt.want)
+		}
+	}
+}
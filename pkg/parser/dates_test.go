@@ -0,0 +1,108 @@
+package parser
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"easypars/pkg/clock"
+)
+
+func TestFormatDate(t *testing.T) {
+	december := time.Date(2024, time.December, 1, 0, 0, 0, 0, time.UTC)
+	january := time.Date(2025, time.January, 1, 0, 0, 0, 0, time.UTC)
+	june := time.Date(2024, time.June, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		text string
+		ref  time.Time
+		want string
+	}{
+		{"15", june, "2024-06-15"},
+		{"15.07", june, "2024-07-15"},
+		{"30.05", june, "2024-05-30"},
+		{"21", december, "2024-12-21"},
+		{"05.01", december, "2025-01-05"},
+		{"28.12", january, "2024-12-28"},
+		{"05.01", january, "2025-01-05"},
+		{"31.02", june, ""},
+		{"29.02", june, "2024-02-29"},
+		{"29.02", time.Date(2025, time.March, 1, 0, 0, 0, 0, time.UTC), ""},
+		{"31", time.Date(2024, time.April, 1, 0, 0, 0, 0, time.UTC), ""},
+		{"0", june, ""},
+		{"15.13", june, ""},
+		{"TBA", june, ""},
+	}
+	for _, tt := range tests {
+		if got := formatDate(tt.text, tt.ref); got != tt.want {
+			t.Errorf("formatDate(%q, %s) = %q, want %q", tt.text, tt.ref.Format("2006-01"), got, tt.want)
+		}
+	}
+}
+
+// parseFixtureAt parses a testdata page as the main page with the parser
+// clock at now
+func parseFixtureAt(t *testing.T, name string, now time.Time) map[string]string {
+	t.Helper()
+
+	page, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	src := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write(page)
+	}))
+	defer src.Close()
+
+	p := NewParser(src.URL + "/")
+	p.Clock = clock.Fixed{Time: now}
+	result, err := p.ParseDetailed(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dates := make(map[string]string, len(result.Fights))
+	for _, fight := range result.Fights {
+		dates[fight.Fighter1] = fight.Date
+	}
+
+	return dates
+}
+
+func TestDecemberPageParsedInJanuary(t *testing.T) {
+	dates := parseFixtureAt(t, "december_in_january.html", time.Date(2025, time.January, 10, 12, 0, 0, 0, time.UTC))
+
+	want := map[string]string{
+		"Usyk":     "2024-12-21",
+		"Zhang":    "2024-12-21",
+		"Nakatani": "2024-12-28",
+		"Inoue":    "2025-01-05",
+		"Typo":     "",
+	}
+	for fighter, date := range want {
+		if got, ok := dates[fighter]; !ok || got != date {
+			t.Errorf("%s: date %q, want %q", fighter, got, date)
+		}
+	}
+}
+
+func TestTwoMonthBlocks(t *testing.T) {
+	dates := parseFixtureAt(t, "two_month_blocks.html", time.Date(2025, time.January, 10, 12, 0, 0, 0, time.UTC))
+
+	want := map[string]string{
+		"Bivol":     "2025-01-04",
+		"Benavidez": "2025-01-07",
+		"Nakatani":  "2024-12-28",
+		"Paul":      "2024-12-14",
+	}
+	for fighter, date := range want {
+		if got := dates[fighter]; got != date {
+			t.Errorf("%s: date %q, want %q", fighter, got, date)
+		}
+	}
+}
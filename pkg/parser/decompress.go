@@ -0,0 +1,95 @@
+package parser
+
+import (
+	"compress/flate"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// DefaultMaxBodySize bounds a decoded page when no limit is configured
+const DefaultMaxBodySize = 10 << 20
+
+// acceptEncoding lists the content encodings the parser decodes itself
+// Setting it disables the transparent gzip of the transport, so a forced
+// or advertised compression is always handled here.
+const acceptEncoding = "gzip, deflate"
+
+// ErrResponseTooLarge is matched by the errors of pages whose body exceeds
+// the limit, e.g. a compression bomb; see ResponseTooLargeError
+var ErrResponseTooLarge = errors.New("response body too large")
+
+// ResponseTooLargeError is a page whose body exceeds MaxBodySize
+// ContentLength is the size the source declared, -1 when it was unknown
+// and the limit was hit while reading.
+type ResponseTooLargeError struct {
+	URL           string
+	Limit         int64
+	ContentLength int64
+}
+
+// Error implements the error interface
+func (e *ResponseTooLargeError) Error() string {
+	if e.ContentLength >= 0 {
+		return fmt.Sprintf("response body of %s too large: %d bytes declared, limit %d", e.URL, e.ContentLength, e.Limit)
+	}
+	return fmt.Sprintf("response body of %s too large: more than %d bytes", e.URL, e.Limit)
+}
+
+// Is matches ErrResponseTooLarge
+func (e *ResponseTooLargeError) Is(target error) bool {
+	return target == ErrResponseTooLarge
+}
+
+// decodeContent returns the reader of the decoded response body
+// Brotli is not advertised and fails as an unsupported encoding when a
+// source forces it.
+func decodeContent(resp *http.Response) (io.Reader, error) {
+	switch encoding := strings.ToLower(strings.TrimSpace(resp.Header.Get("Content-Encoding"))); encoding {
+	case "", "identity":
+		return resp.Body, nil
+	case "gzip", "x-gzip":
+		reader, err := gzip.NewReader(resp.Body)
+		if err != nil {
+			return nil, fmt.Errorf("error decoding gzip body: %w", err)
+		}
+		return reader, nil
+	case "deflate":
+		return flate.NewReader(resp.Body), nil
+	default:
+		return nil, fmt.Errorf("unsupported content encoding %q", encoding)
+	}
+}
+
+// readLimited reads the whole body of url, failing with a
+// ResponseTooLargeError once it grows past limit bytes
+// At most limit+1 bytes are read, so an endless body is not drained.
+func readLimited(reader io.Reader, url string, limit int64) ([]byte, error) {
+	body, err := io.ReadAll(io.LimitReader(reader, limit+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(body)) > limit {
+		return nil, &ResponseTooLargeError{URL: url, Limit: limit, ContentLength: -1}
+	}
+
+	return body, nil
+}
+
+// corruptContent reports whether a read failed on broken compressed data,
+// which is the fault of the source rather than of the network
+func corruptContent(err error) bool {
+	var corrupt flate.CorruptInputError
+	return errors.Is(err, gzip.ErrChecksum) || errors.Is(err, gzip.ErrHeader) || errors.As(err, &corrupt)
+}
+
+// maxBodySize returns the configured limit of a decoded page
+func (p *Parser) maxBodySize() int64 {
+	if p.MaxBodySize > 0 {
+		return p.MaxBodySize
+	}
+	return DefaultMaxBodySize
+}
@@ -0,0 +1,154 @@
+package parser
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// compressed returns the body compressed with the content encoding
+func compressed(t *testing.T, encoding string, body []byte) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	var writer io.WriteCloser
+	switch encoding {
+	case "gzip":
+		writer = gzip.NewWriter(&buf)
+	case "deflate":
+		writer, _ = flate.NewWriter(&buf, flate.BestCompression)
+	default:
+		t.Fatalf("unknown encoding %q", encoding)
+	}
+	if _, err := writer.Write(body); err != nil {
+		t.Fatal(err)
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	return buf.Bytes()
+}
+
+// newEncodedSource serves the body with the Content-Encoding header,
+// recording the Accept-Encoding of the last request
+func newEncodedSource(t *testing.T, encoding string, body []byte, acceptEncoding *string) *httptest.Server {
+	t.Helper()
+
+	src := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if acceptEncoding != nil {
+			*acceptEncoding = r.Header.Get("Accept-Encoding")
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		if encoding != "" {
+			w.Header().Set("Content-Encoding", encoding)
+		}
+		w.Write(body)
+	}))
+	t.Cleanup(src.Close)
+
+	return src
+}
+
+// newQuietParser returns a parser of the URL without log output
+func newQuietParser(url string) *Parser {
+	p := NewParser(url)
+	p.Logger = slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	return p
+}
+
+func TestCompressedPagesAreDecoded(t *testing.T) {
+	page := []byte(monthPage("Packed", 2024, time.May, 4))
+
+	for _, encoding := range []string{"gzip", "deflate"} {
+		t.Run(encoding, func(t *testing.T) {
+			var accepted string
+			src := newEncodedSource(t, encoding, compressed(t, encoding, page), &accepted)
+			p := newQuietParser(src.URL + "/")
+
+			fights, err := p.ParseFightsContext(context.Background())
+			if err != nil {
+				t.Fatalf("ParseFightsContext: %v", err)
+			}
+			if len(fights) != 4 || fights[0].Fighter1 == "" {
+				t.Errorf("got %d fights, want the 4 of the compressed page", len(fights))
+			}
+			if accepted != acceptEncoding {
+				t.Errorf("Accept-Encoding = %q, want %q", accepted, acceptEncoding)
+			}
+		})
+	}
+}
+
+func TestCompressionBombIsCapped(t *testing.T) {
+	// 64 MiB of zeros compress to about 64 KiB
+	bomb := make([]byte, 64<<20)
+	const limit = 1 << 20
+
+	for _, encoding := range []string{"gzip", "deflate"} {
+		t.Run(encoding, func(t *testing.T) {
+			packed := compressed(t, encoding, bomb)
+			if len(packed) > limit {
+				t.Fatalf("the compressed bomb is %d bytes, want it under the limit", len(packed))
+			}
+			src := newEncodedSource(t, encoding, packed, nil)
+			p := newQuietParser(src.URL + "/")
+			p.MaxBodySize = limit
+
+			_, err := p.fetchHTMLDocument(context.Background(), src.URL+"/")
+			var tooLarge *ResponseTooLargeError
+			if !errors.As(err, &tooLarge) || !errors.Is(err, ErrResponseTooLarge) {
+				t.Fatalf("fetch = %v, want a ResponseTooLargeError", err)
+			}
+			if tooLarge.Limit != limit || tooLarge.ContentLength != -1 {
+				t.Errorf("error = %+v, want the limit %d hit while decoding", tooLarge, limit)
+			}
+			if origin, _ := OriginOf(err); origin != ErrorOriginSource {
+				t.Errorf("origin = %q, want %q", origin, ErrorOriginSource)
+			}
+		})
+	}
+}
+
+func TestUnsupportedAndCorruptEncodings(t *testing.T) {
+	page := []byte(monthPage("Packed", 2024, time.May, 4))
+	tests := []struct {
+		name     string
+		encoding string
+		body     []byte
+		message  string
+		origin   ErrorOrigin
+	}{
+		// Brotli is not advertised; a source forcing it is refused before
+		// anything is decoded
+		{"forced brotli", "br", []byte{0x1b, 0xff, 0xff, 0xff}, `unsupported content encoding "br"`, ErrorOriginSource},
+		{"unknown encoding", "zstd", page, `unsupported content encoding "zstd"`, ErrorOriginSource},
+		{"broken gzip header", "gzip", page, "error decoding gzip body", ErrorOriginSource},
+		// A body cut short looks like a dropped connection
+		{"truncated gzip", "gzip", compressed(t, "gzip", page)[:40], "unexpected EOF", ErrorOriginNetwork},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			src := newEncodedSource(t, tt.encoding, tt.body, nil)
+			p := newQuietParser(src.URL + "/")
+
+			_, err := p.fetchHTMLDocument(context.Background(), src.URL+"/")
+			if err == nil || !strings.Contains(err.Error(), tt.message) {
+				t.Fatalf("fetch = %v, want an error containing %q", err, tt.message)
+			}
+			if origin, _ := OriginOf(err); origin != tt.origin {
+				t.Errorf("origin = %q, want %q", origin, tt.origin)
+			}
+		})
+	}
+}
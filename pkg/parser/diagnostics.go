@@ -0,0 +1,181 @@
+package parser
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// Column diagnostics settings
+const (
+	// DefaultColumnInvalidThreshold is the share of invalid values above which
+	// a column is reported as degraded
+	DefaultColumnInvalidThreshold = 0.3
+	// minRowsForDiagnostics avoids flagging columns on tiny pages
+	minRowsForDiagnostics = 5
+	// topUnexpectedValues is the number of unexpected values kept per column
+	topUnexpectedValues = 5
+)
+
+// Cell roles of a fight row
+const (
+	RoleDate    = "date"
+	RolePlace   = "place"
+	RoleBoxer1  = "boxer_1"
+	RoleVs      = "vs"
+	RoleBoxer2  = "boxer_2"
+	IssueColumn = "column_degraded"
+)
+
+// ColumnDiagnostics profiles the values extracted for one cell role
+type ColumnDiagnostics struct {
+	Role         string       `json:"role"`
+	Rows         int          `json:"rows"`
+	EmptyShare   float64      `json:"empty_share"`
+	ValidShare   float64      `json:"valid_share"`
+	InvalidShare float64      `json:"invalid_share"`
+	Unexpected   []ValueCount `json:"unexpected,omitempty"`
+	Degraded     bool         `json:"degraded"`
+}
+
+// columnProfile accumulates the values of one role while profiling
+type columnProfile struct {
+	role    string
+	isValid func(string) bool
+	empty   int
+	valid   int
+	invalid map[string]int
+}
+
+// ValueCount is an unexpected value with its number of occurrences
+type ValueCount struct {
+	Value string `json:"value"`
+	Count int    `json:"count"`
+}
+
+// resultPattern recognizes result texts: pending markers and win methods
+var resultPattern = regexp.MustCompile(`(?i)^(vs\.?|tbd|tba|ko|tko|ud|sd|md|pts|rtd|dq|nc|td|draw|d|mdraw|sdraw|ничья|нокаут|технический нокаут|решение|единогласн|раздельн|большинств|дисквалификац|отказ|отмен|cancel|снят)`)
+
+// monthWordPattern recognizes textual dates ("15 января", "Jan 15")
+var monthWordPattern = regexp.MustCompile(`(?i)(янв|фев|мар|апр|мая|май|июн|июл|авг|сен|окт|ноя|дек|jan|feb|mar|apr|may|jun|jul|aug|sep|oct|nov|dec)`)
+
+// isValidDateText reports whether a date cell looks like a day number or a textual date
+func isValidDateText(text string) bool {
+	if match := dayPattern.FindStringSubmatch(text); match != nil {
+		return formatDate(text, time.Now()) != ""
+	}
+
+	return monthWordPattern.MatchString(text)
+}
+
+// isValidNameText reports whether a name cell holds at least two letters
+func isValidNameText(text string) bool {
+	letters := 0
+	for _, r := range text {
+		if unicode.IsLetter(r) {
+			letters++
+		}
+	}
+
+	return letters >= 2
+}
+
+// isValidResultText reports whether a vs cell holds a known result or a pending marker
+func isValidResultText(text string) bool {
+	return resultPattern.MatchString(strings.TrimSpace(text))
+}
+
+// isValidPlaceText accepts any place with letters
+func isValidPlaceText(text string) bool {
+	return isValidNameText(text)
+}
+
+// computeColumnDiagnostics profiles every cell role over the extracted rows
+// A column is degraded when the share of non-empty values failing the role
+// check exceeds the threshold (on pages with enough rows)
+func computeColumnDiagnostics(events []FightEvent, threshold float64) []ColumnDiagnostics {
+	if threshold <= 0 {
+		threshold = DefaultColumnInvalidThreshold
+	}
+
+	profiles := []*columnProfile{
+		{role: RoleDate, isValid: isValidDateText},
+		{role: RolePlace, isValid: isValidPlaceText},
+		{role: RoleBoxer1, isValid: isValidNameText},
+		{role: RoleVs, isValid: isValidResultText},
+		{role: RoleBoxer2, isValid: isValidNameText},
+	}
+	for _, profile := range profiles {
+		profile.invalid = make(map[string]int)
+	}
+
+	for _, event := range events {
+		values := []string{event.DateText, event.Location, event.Fighter1, event.Result, event.Fighter2}
+		for i, value := range values {
+			profile := profiles[i]
+			switch {
+			case value == "":
+				profile.empty++
+			case profile.isValid(value):
+				profile.valid++
+			default:
+				profile.invalid[value]++
+			}
+		}
+	}
+
+	rows := len(events)
+	result := make([]ColumnDiagnostics, 0, len(profiles))
+	for _, profile := range profiles {
+		column := ColumnDiagnostics{
+			Role:       profile.role,
+			Rows:       rows,
+			Unexpected: topValues(profile.invalid, topUnexpectedValues),
+		}
+		if rows > 0 {
+			invalid := rows - profile.empty - profile.valid
+			column.EmptyShare = float64(profile.empty) / float64(rows)
+			column.ValidShare = float64(profile.valid) / float64(rows)
+			column.InvalidShare = float64(invalid) / float64(rows)
+			column.Degraded = rows >= minRowsForDiagnostics && column.InvalidShare > threshold
+		}
+		result = append(result, column)
+	}
+
+	return result
+}
+
+// topValues returns the most frequent values, ties broken alphabetically
+func topValues(counts map[string]int, limit int) []ValueCount {
+	values := make([]ValueCount, 0, len(counts))
+	for value, count := range counts {
+		values = append(values, ValueCount{Value: value, Count: count})
+	}
+
+	sort.Slice(values, func(i, j int) bool {
+		if values[i].Count != values[j].Count {
+			return values[i].Count > values[j].Count
+		}
+		return values[i].Value < values[j].Value
+	})
+
+	if len(values) > limit {
+		values = values[:limit]
+	}
+
+	return values
+}
+
+// DegradedColumns returns the roles of degraded columns
+func DegradedColumns(columns []ColumnDiagnostics) []string {
+	var roles []string
+	for _, column := range columns {
+		if column.Degraded {
+			roles = append(roles, column.Role)
+		}
+	}
+
+	return roles
+}
@@ -0,0 +1,109 @@
+package parser
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"easypars/pkg/clock"
+)
+
+// event returns an extracted row with the given cell texts
+func event(date, place, boxer1, result, boxer2 string) FightEvent {
+	return FightEvent{DateText: date, Location: place, Fighter1: boxer1, Result: result, Fighter2: boxer2}
+}
+
+// repeated returns n copies of the event
+func repeated(n int, e FightEvent) []FightEvent {
+	events := make([]FightEvent, n)
+	for i := range events {
+		events[i] = e
+	}
+
+	return events
+}
+
+func TestComputeColumnDiagnostics(t *testing.T) {
+	valid := event("15", "Riyadh", "Usyk", "SD", "Fury")
+
+	tests := []struct {
+		name     string
+		events   []FightEvent
+		degraded []string
+	}{
+		{"valid page", repeated(6, valid), nil},
+		{"textual dates", repeated(6, event("15 мая", "Riyadh", "Usyk", "vs", "Fury")), nil},
+		{"empty places", repeated(6, event("15", "", "Usyk", "UD", "Fury")), nil},
+		{"names in the date column", repeated(6, event("Usyk", "Riyadh", "Usyk", "SD", "Fury")), []string{RoleDate}},
+		{"numbers in the name columns", repeated(6, event("15", "Riyadh", "1", "SD", "2")), []string{RoleBoxer1, RoleBoxer2}},
+		{"names in the vs column", repeated(6, event("15", "Riyadh", "Usyk", "Fury", "")), []string{RoleVs}},
+		{"below the threshold", append(repeated(5, valid), event("Usyk", "Riyadh", "Usyk", "SD", "Fury")), nil},
+		{"too few rows", repeated(minRowsForDiagnostics-1, event("Usyk", "Riyadh", "Usyk", "SD", "Fury")), nil},
+		{"no rows", nil, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			columns := computeColumnDiagnostics(tt.events, 0)
+			if len(columns) != 5 {
+				t.Fatalf("columns = %d, want one per role", len(columns))
+			}
+			if got := DegradedColumns(columns); !reflect.DeepEqual(got, tt.degraded) {
+				t.Errorf("degraded = %q, want %q", got, tt.degraded)
+			}
+		})
+	}
+}
+
+func TestColumnDiagnosticsTopUnexpected(t *testing.T) {
+	var events []FightEvent
+	for _, date := range []string{"Usyk", "Fury", "Usyk", "Bivol", "Usyk", "Fury", "Inoue", "Nery", "Haney", "15"} {
+		events = append(events, event(date, "Riyadh", "Usyk", "SD", "Fury"))
+	}
+
+	date := computeColumnDiagnostics(events, 0)[0]
+	want := []ValueCount{{"Usyk", 3}, {"Fury", 2}, {"Bivol", 1}, {"Haney", 1}, {"Inoue", 1}}
+	if !reflect.DeepEqual(date.Unexpected, want) {
+		t.Errorf("unexpected = %+v, want %+v", date.Unexpected, want)
+	}
+	if date.ValidShare != 0.1 || date.InvalidShare != 0.9 || date.EmptyShare != 0 {
+		t.Errorf("shares = %v valid, %v invalid, %v empty, want 0.1, 0.9, 0", date.ValidShare, date.InvalidShare, date.EmptyShare)
+	}
+}
+
+func TestParseReportsDegradedDateColumn(t *testing.T) {
+	page, err := os.ReadFile(filepath.Join("testdata", "names_in_date_column.html"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	src := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write(page)
+	}))
+	defer src.Close()
+
+	p := NewParser(src.URL + "/")
+	p.Clock = clock.Fixed{Time: time.Date(2024, time.June, 10, 12, 0, 0, 0, time.UTC)}
+	result, err := p.ParseAll(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := DegradedColumns(result.Columns); !reflect.DeepEqual(got, []string{RoleDate}) {
+		t.Errorf("degraded columns = %q, want the date column only", got)
+	}
+	if unexpected := result.Columns[0].Unexpected; len(unexpected) == 0 || unexpected[0].Count != 1 {
+		t.Errorf("unexpected dates = %+v, want the names found in the column", unexpected)
+	}
+	found := false
+	for _, issue := range result.Issues {
+		found = found || issue.Code == IssueColumn
+	}
+	if !found {
+		t.Errorf("issues = %+v, want a %s issue", result.Issues, IssueColumn)
+	}
+}
@@ -0,0 +1,42 @@
+package parser
+
+import (
+	"fmt"
+	"unicode/utf8"
+
+	"golang.org/x/net/html/charset"
+	"golang.org/x/text/encoding/charmap"
+)
+
+// toUTF8 converts a fetched page to UTF-8
+// A body that is valid UTF-8 is returned unchanged, whatever it declares.
+// Any other body is decoded with the charset of the Content-Type header,
+// a byte order mark or the <meta charset> tag, the tag winning over a
+// header claiming UTF-8 for invalid bytes; without any it is read as
+// windows-1251, the legacy encoding of Russian sites, rather than the
+// windows-1252 default of HTML.
+func toUTF8(body []byte, contentType string) ([]byte, string, error) {
+	if utf8.Valid(body) {
+		return body, "utf-8", nil
+	}
+
+	enc, name, certain := charset.DetermineEncoding(body, contentType)
+	if name == "utf-8" {
+		// The header is wrong about the body, the page may know better
+		enc, name, certain = charset.DetermineEncoding(body, "")
+	}
+	if name == "utf-8" {
+		// Declared UTF-8 with invalid bytes: the HTML parser replaces them
+		return body, name, nil
+	}
+	if name == "windows-1252" && !certain {
+		enc, name = charmap.Windows1251, "windows-1251"
+	}
+
+	decoded, err := enc.NewDecoder().Bytes(body)
+	if err != nil {
+		return nil, name, fmt.Errorf("error decoding %s page: %w", name, err)
+	}
+
+	return decoded, name, nil
+}
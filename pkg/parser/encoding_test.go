@@ -0,0 +1,86 @@
+package parser
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"unicode/utf8"
+)
+
+// windows1251Meta is the charset declaration of the windows-1251 fixture
+var windows1251Meta = []byte(`<meta http-equiv="Content-Type" content="text/html; charset=windows-1251">`)
+
+func TestWindows1251PageIsDecoded(t *testing.T) {
+	fixture, err := os.ReadFile(filepath.Join("testdata", "results_windows1251.html"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if utf8.Valid(fixture) {
+		t.Fatal("the fixture is valid UTF-8, want windows-1251 bytes")
+	}
+	withoutMeta := bytes.Replace(fixture, windows1251Meta, nil, 1)
+
+	tests := []struct {
+		name        string
+		contentType string
+		body        []byte
+	}{
+		{"charset in the header", "text/html; charset=windows-1251", withoutMeta},
+		{"charset in the page", "text/html", fixture},
+		{"header wrongly claiming UTF-8", "text/html; charset=utf-8", fixture},
+		{"no charset at all", "text/html", withoutMeta},
+	}
+	want := [][3]string{
+		{"Александр Усик", "Тайсон Фьюри", "Эр-Рияд, Саудовская Аравия"},
+		{"Гассиев Мурат", "Шарипов Ёкубжон", "Екатеринбург, Россия"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			src := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", tt.contentType)
+				w.Write(tt.body)
+			}))
+			defer src.Close()
+			p := newQuietParser(src.URL + "/")
+
+			fights, err := p.ParseFightsContext(context.Background())
+			if err != nil {
+				t.Fatalf("ParseFightsContext: %v", err)
+			}
+			if len(fights) != len(want) {
+				t.Fatalf("got %d fights, want %d", len(fights), len(want))
+			}
+			for i, fight := range fights {
+				got := [3]string{fight.Fighter1, fight.Fighter2, fight.Location}
+				if got != want[i] {
+					t.Errorf("fight %d = %q, want %q", i, got, want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestToUTF8(t *testing.T) {
+	tests := []struct {
+		name        string
+		body        []byte
+		contentType string
+		want        string
+		encoding    string
+	}{
+		{"utf-8 as is", []byte("Усик"), "text/html; charset=windows-1251", "Усик", "utf-8"},
+		{"windows-1251 header", []byte{0xd3, 0xf1, 0xe8, 0xea}, "text/html; charset=windows-1251", "Усик", "windows-1251"},
+		{"koi8-r header", []byte{0xf5, 0xd3, 0xc9, 0xcb}, "text/html; charset=koi8-r", "Усик", "koi8-r"},
+		{"unknown single byte encoding", []byte{0xd3, 0xf1, 0xe8, 0xea}, "text/html", "Усик", "windows-1251"},
+	}
+	for _, tt := range tests {
+		got, encoding, err := toUTF8(tt.body, tt.contentType)
+		if err != nil || string(got) != tt.want || encoding != tt.encoding {
+			t.Errorf("%s: toUTF8 = %q, %s, %v, want %q, %s", tt.name, got, encoding, err, tt.want, tt.encoding)
+		}
+	}
+}
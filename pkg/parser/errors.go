@@ -0,0 +1,106 @@
+package parser
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+)
+
+// ErrorOrigin tells whose fault a parse error is
+// Dashboards and alerts use it to tell a degraded service from a degraded
+// source.
+type ErrorOrigin string
+
+// Error origins
+const (
+	// ErrorOriginSource means the source answered, but with an error status,
+	// a rate limit, a blocked redirect or a page that cannot be decoded
+	ErrorOriginSource ErrorOrigin = "source"
+	// ErrorOriginNetwork means the source could not be reached or the
+	// transfer failed
+	ErrorOriginNetwork ErrorOrigin = "network"
+	// ErrorOriginInternal means a bug on our side: a panic, a failed
+	// invariant or a failing post-processor
+	ErrorOriginInternal ErrorOrigin = "internal"
+	// ErrorOriginConfig means the configuration is invalid, e.g. a missing
+	// URL or a pattern that does not compile
+	ErrorOriginConfig ErrorOrigin = "config"
+)
+
+// ErrStructureChanged is matched by a StructureChangedError: a page lacks
+// the elements the extraction relies on, the source most likely changed
+// its HTML and the parser needs an update
+var ErrStructureChanged = errors.New("page structure changed")
+
+// ErrNoFightsFound is returned when a document with the expected structure
+// holds no fights where some are required (see ParseFromReader)
+var ErrNoFightsFound = errors.New("no fights found")
+
+// ClassifiedError is an error with its origin
+// The origin survives wrapping with %w and is read back with OriginOf.
+type ClassifiedError struct {
+	Origin ErrorOrigin
+	Err    error
+}
+
+// Error returns the message of the wrapped error
+func (e *ClassifiedError) Error() string {
+	return e.Err.Error()
+}
+
+// Unwrap returns the wrapped error
+func (e *ClassifiedError) Unwrap() error {
+	return e.Err
+}
+
+// Classify sets the origin of err
+// An error that already has an origin keeps it: the place where the error
+// occurred knows best. A nil error stays nil.
+func Classify(origin ErrorOrigin, err error) error {
+	if err == nil {
+		return nil
+	}
+	if _, ok := OriginOf(err); ok {
+		return err
+	}
+
+	return &ClassifiedError{Origin: origin, Err: err}
+}
+
+// OriginOf returns the origin of err, false when the error is not classified
+func OriginOf(err error) (ErrorOrigin, bool) {
+	var classified *ClassifiedError
+	if !errors.As(err, &classified) {
+		return "", false
+	}
+
+	return classified.Origin, true
+}
+
+// IsTimeout reports whether err is a timeout, as opposed to a refused
+// connection or an error response
+func IsTimeout(err error) bool {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+	var netErr net.Error
+
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
+
+// cancelledError wraps the error of the cancelled context of a parse
+// The transfer was abandoned by the caller rather than failed by the
+// source, so the error counts as a network error; errors.Is still finds
+// context.Canceled or context.DeadlineExceeded in it.
+func cancelledError(ctx context.Context, url string) error {
+	return Classify(ErrorOriginNetwork, fmt.Errorf("parse of %s cancelled: %w", url, ctx.Err()))
+}
+
+// panicError converts a recovered panic into an internal error
+func panicError(recovered any) error {
+	return &ClassifiedError{
+		Origin: ErrorOriginInternal,
+		Err:    fmt.Errorf("parser panicked: %v", recovered),
+	}
+}
@@ -0,0 +1,115 @@
+package parser
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestClassify(t *testing.T) {
+	base := errors.New("connection refused")
+	tests := []struct {
+		name string
+		err  error
+		want ErrorOrigin
+		ok   bool
+	}{
+		{"unclassified", base, "", false},
+		{"classified", Classify(ErrorOriginNetwork, base), ErrorOriginNetwork, true},
+		{"wrapped once", fmt.Errorf("fetching page: %w", Classify(ErrorOriginSource, base)), ErrorOriginSource, true},
+		{"wrapped twice", fmt.Errorf("parse: %w", fmt.Errorf("fetch: %w", Classify(ErrorOriginConfig, base))), ErrorOriginConfig, true},
+		{"classified again", Classify(ErrorOriginInternal, fmt.Errorf("fetch: %w", Classify(ErrorOriginNetwork, base))), ErrorOriginNetwork, true},
+		{"joined", errors.Join(errors.New("other"), Classify(ErrorOriginSource, base)), ErrorOriginSource, true},
+		{"wrapped with %v", fmt.Errorf("fetch: %v", Classify(ErrorOriginSource, base)), "", false},
+		{"panic", panicError("nil map"), ErrorOriginInternal, true},
+		{"cancelled", cancelledError(cancelledContext(), "https://vringe.example/"), ErrorOriginNetwork, true},
+	}
+	for _, tt := range tests {
+		origin, ok := OriginOf(tt.err)
+		if origin != tt.want || ok != tt.ok {
+			t.Errorf("%s: OriginOf(%v) = %q, %v; want %q, %v", tt.name, tt.err, origin, ok, tt.want, tt.ok)
+		}
+	}
+
+	if Classify(ErrorOriginSource, nil) != nil {
+		t.Error("Classify of a nil error is not nil")
+	}
+	if err := Classify(ErrorOriginSource, base); !errors.Is(err, base) || err.Error() != base.Error() {
+		t.Errorf("Classify changed the error to %v", err)
+	}
+}
+
+// cancelledContext returns a cancelled context
+func cancelledContext() context.Context {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	return ctx
+}
+
+func TestIsTimeout(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"deadline", Classify(ErrorOriginNetwork, fmt.Errorf("fetch: %w", context.DeadlineExceeded)), true},
+		{"cancelled", cancelledError(cancelledContext(), "https://vringe.example/"), false},
+		{"status", &StatusError{URL: "https://vringe.example/", StatusCode: 503}, false},
+		{"plain", errors.New("connection refused"), false},
+	}
+	for _, tt := range tests {
+		if got := IsTimeout(tt.err); got != tt.want {
+			t.Errorf("%s: IsTimeout(%v) = %v, want %v", tt.name, tt.err, got, tt.want)
+		}
+	}
+}
+
+func TestParseErrorsAreTyped(t *testing.T) {
+	tests := []struct {
+		name      string
+		status    int
+		page      string
+		fights    int
+		structure bool
+		code      int
+	}{
+		{"results page", http.StatusOK, monthPage("Typed", 2024, time.May, 2), 2, false, 0},
+		{"structure changed", http.StatusOK, `<html><body><ul class="fights"><li>Usyk - Fury</li></ul></body></html>`, 0, true, 0},
+		{"not found", http.StatusNotFound, "gone", 0, false, http.StatusNotFound},
+		{"server error", http.StatusBadGateway, "down", 0, false, http.StatusBadGateway},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "text/html; charset=utf-8")
+				w.WriteHeader(tt.status)
+				fmt.Fprint(w, tt.page)
+			}))
+			defer srv.Close()
+			p := newQuietParser(srv.URL + "/")
+			p.Clock = newFakeClock()
+
+			fights, err := p.ParseFightsContext(context.Background())
+			if got := errors.Is(err, ErrStructureChanged); got != tt.structure {
+				t.Errorf("errors.Is(%v, ErrStructureChanged) = %v, want %v", err, got, tt.structure)
+			}
+			var statusErr *StatusError
+			if errors.As(err, &statusErr) != (tt.code != 0) || (tt.code != 0 && statusErr.StatusCode != tt.code) {
+				t.Errorf("errors.As(%v, *StatusError) = %+v, want status %d", err, statusErr, tt.code)
+			}
+			if tt.structure || tt.code != 0 {
+				if origin, _ := OriginOf(err); origin != ErrorOriginSource {
+					t.Errorf("origin = %s, want %s", origin, ErrorOriginSource)
+				}
+				return
+			}
+			if err != nil || len(fights) != tt.fights {
+				t.Errorf("ParseFightsContext = %d fights (%v), want %d", len(fights), err, tt.fights)
+			}
+		})
+	}
+}
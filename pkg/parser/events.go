@@ -0,0 +1,56 @@
+package parser
+
+import (
+	"sort"
+
+	"easypars/models"
+)
+
+// GroupEvents groups fights into cards: the fights on the same date at the
+// same location, like assignCardPositions
+// Locations are compared normalized, the spelling of the first fight of a
+// card is kept. Fights without a date are not on any card. The fights of a
+// card are ordered by their card position, and the first row of the card is
+// its main event, as the source lists the headliner on top. Cards are
+// ordered by date, then location.
+func GroupEvents(fights []models.Fight) []models.Event {
+	index := make(map[string]int)
+	events := make([]models.Event, 0)
+
+	for _, fight := range fights {
+		if fight.Date == "" {
+			continue
+		}
+
+		key := fight.Date + "|" + models.NormalizeName(fight.Location)
+		idx, ok := index[key]
+		if !ok {
+			idx = len(events)
+			index[key] = idx
+			events = append(events, models.Event{Key: key, Date: fight.Date, Location: fight.Location})
+		}
+		events[idx].Fights = append(events[idx].Fights, fight)
+	}
+
+	for i := range events {
+		// Fights without a position keep their order after the others
+		sort.SliceStable(events[i].Fights, func(a, b int) bool {
+			posA, posB := events[i].Fights[a].CardPosition, events[i].Fights[b].CardPosition
+			if posA == 0 || posB == 0 {
+				return posB == 0 && posA != 0
+			}
+			return posA < posB
+		})
+		mainEvent := events[i].Fights[0]
+		events[i].MainEvent = &mainEvent
+	}
+
+	sort.SliceStable(events, func(i, j int) bool {
+		if events[i].Date != events[j].Date {
+			return events[i].Date < events[j].Date
+		}
+		return models.NormalizeName(events[i].Location) < models.NormalizeName(events[j].Location)
+	})
+
+	return events
+}
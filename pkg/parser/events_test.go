@@ -0,0 +1,87 @@
+package parser
+
+import (
+	"reflect"
+	"testing"
+
+	"easypars/models"
+)
+
+func TestGroupEvents(t *testing.T) {
+	// card is a fight on a card with its position
+	card := func(date, location, fighter string, position int) models.Fight {
+		return models.Fight{Date: date, Location: location, Fighter1: fighter, Fighter2: "Opponent", CardPosition: position}
+	}
+
+	tests := []struct {
+		name   string
+		fights []models.Fight
+		// want lists every card as its date, location and fighters in order
+		want [][]string
+	}{
+		{
+			name: "same location on different dates",
+			fights: []models.Fight{
+				card("2024-06-01", "Riyadh", "Bivol", 1),
+				card("2024-05-18", "Riyadh", "Usyk", 1),
+				card("2024-06-01", "Riyadh", "Zhang", 2),
+			},
+			want: [][]string{{"2024-05-18", "Riyadh", "Usyk"}, {"2024-06-01", "Riyadh", "Bivol", "Zhang"}},
+		},
+		{
+			name: "same date at different locations",
+			fights: []models.Fight{
+				card("2024-06-01", "Riyadh", "Bivol", 1),
+				card("2024-06-01", "London", "Joshua", 1),
+			},
+			want: [][]string{{"2024-06-01", "London", "Joshua"}, {"2024-06-01", "Riyadh", "Bivol"}},
+		},
+		{
+			name: "location spelled differently",
+			fights: []models.Fight{
+				card("2024-06-01", "Riyadh", "Bivol", 1),
+				card("2024-06-01", "  RIYADH ", "Zhang", 2),
+			},
+			want: [][]string{{"2024-06-01", "Riyadh", "Bivol", "Zhang"}},
+		},
+		{
+			name: "ordered by card position",
+			fights: []models.Fight{
+				card("2024-06-01", "Riyadh", "Undercard", 3),
+				card("2024-06-01", "Riyadh", "Unplaced", 0),
+				card("2024-06-01", "Riyadh", "Headliner", 1),
+				card("2024-06-01", "Riyadh", "Co-main", 2),
+			},
+			want: [][]string{{"2024-06-01", "Riyadh", "Headliner", "Co-main", "Undercard", "Unplaced"}},
+		},
+		{
+			name: "undated fights are on no card",
+			fights: []models.Fight{
+				card("", "Riyadh", "Undated", 1),
+				card("2024-06-01", "Riyadh", "Bivol", 1),
+			},
+			want: [][]string{{"2024-06-01", "Riyadh", "Bivol"}},
+		},
+		{name: "no fights", fights: nil, want: nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			events := GroupEvents(tt.fights)
+			var got [][]string
+			for _, event := range events {
+				row := []string{event.Date, event.Location}
+				for _, fight := range event.Fights {
+					row = append(row, fight.Fighter1)
+				}
+				got = append(got, row)
+
+				if event.MainEvent == nil || event.MainEvent.Fighter1 != event.Fights[0].Fighter1 {
+					t.Errorf("main event of %s = %+v, want the first fight of the card", event.Key, event.MainEvent)
+				}
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("cards = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,80 @@
+package parser
+
+import (
+	"regexp"
+
+	"easypars/models"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// boxrecLinkPattern matches links to a BoxRec boxer page and captures its ID
+// Known formats:
+//   - https://boxrec.com/en/proboxer/447121
+//   - https://www.boxrec.com/proboxer/447121
+//   - http://boxrec.com/boxer/447121 (legacy)
+var boxrecLinkPattern = regexp.MustCompile(`(?i)^(?:(?:https?:)?//)?(?:www\.)?boxrec\.com/(?:[a-z]{2}/)?(?:pro)?boxer/(\d+)`)
+
+// extractExternalIDs collects external fighter IDs from the links of a boxer cell
+// Returns nil when the cell has no recognized link
+func extractExternalIDs(cell *goquery.Selection) map[string]string {
+	if cell == nil {
+		return nil
+	}
+
+	var ids map[string]string
+	cell.Find("a[href]").Each(func(_ int, link *goquery.Selection) {
+		href, _ := link.Attr("href")
+		if id := boxrecID(href); id != "" {
+			if ids == nil {
+				ids = make(map[string]string)
+			}
+			ids[models.ExternalSourceBoxRec] = id
+		}
+	})
+
+	return ids
+}
+
+// boxrecID returns the BoxRec ID of a boxer page link, or "" for other links
+func boxrecID(href string) string {
+	match := boxrecLinkPattern.FindStringSubmatch(cleanText(href))
+	if match == nil {
+		return ""
+	}
+	return match[1]
+}
+
+// applyExternalIDs adds manually mapped external IDs to the fighters of a fight
+// IDs found in the page win, the manual mapping only fills missing sources
+func (p *Parser) applyExternalIDs(fight *models.Fight) {
+	if len(p.FighterExternalIDs) == 0 {
+		return
+	}
+
+	fight.Fighter1ExternalIDs = mergeExternalIDs(fight.Fighter1ExternalIDs, p.FighterExternalIDs[models.NormalizeName(fight.Fighter1)])
+	fight.Fighter2ExternalIDs = mergeExternalIDs(fight.Fighter2ExternalIDs, p.FighterExternalIDs[models.NormalizeName(fight.Fighter2)])
+}
+
+// mergeExternalIDs returns ids completed with the sources of extra it lacks
+// The ids map is not modified, a new map is returned when anything is added
+func mergeExternalIDs(ids, extra map[string]string) map[string]string {
+	var merged map[string]string
+	for source, id := range extra {
+		if _, ok := ids[source]; ok {
+			continue
+		}
+		if merged == nil {
+			merged = make(map[string]string, len(ids)+len(extra))
+			for s, v := range ids {
+				merged[s] = v
+			}
+		}
+		merged[source] = id
+	}
+
+	if merged == nil {
+		return ids
+	}
+	return merged
+}
@@ -0,0 +1,84 @@
+package parser
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"easypars/models"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+func TestBoxrecID(t *testing.T) {
+	tests := []struct {
+		href string
+		want string
+	}{
+		{"https://boxrec.com/en/proboxer/447121", "447121"},
+		{"https://www.boxrec.com/proboxer/447121", "447121"},
+		{"http://boxrec.com/boxer/447121", "447121"},
+		{"//boxrec.com/ru/proboxer/659772?tab=fights", "659772"},
+		{"  HTTPS://BoxRec.com/en/proboxer/1  ", "1"},
+		{"https://boxrec.com/en/event/447121", ""},
+		{"https://boxrec.com/en/proboxer/", ""},
+		{"https://notboxrec.com/en/proboxer/447121", ""},
+		{"https://vringe.com/boxer/447121", ""},
+		{"/proboxer/447121", ""},
+	}
+	for _, tt := range tests {
+		if got := boxrecID(tt.href); got != tt.want {
+			t.Errorf("boxrecID(%q) = %q, want %q", tt.href, got, tt.want)
+		}
+	}
+}
+
+func TestExtractExternalIDs(t *testing.T) {
+	tests := []struct {
+		name string
+		cell string
+		want map[string]string
+	}{
+		{"boxrec link", `<a href="https://boxrec.com/en/proboxer/447121">Oleksandr Usyk</a>`, map[string]string{models.ExternalSourceBoxRec: "447121"}},
+		{"link among others", `<a href="/boxers/usyk">Usyk</a> <a href="http://boxrec.com/boxer/447121">BoxRec</a>`, map[string]string{models.ExternalSourceBoxRec: "447121"}},
+		{"no link", `Oleksandr Usyk`, nil},
+		{"other link", `<a href="https://vringe.com/boxers/usyk">Usyk</a>`, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, err := goquery.NewDocumentFromReader(strings.NewReader(`<table><tr><td class="boxer_1">` + tt.cell + `</td></tr></table>`))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := extractExternalIDs(doc.Find("td.boxer_1")); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("extractExternalIDs = %v, want %v", got, tt.want)
+			}
+		})
+	}
+	if got := extractExternalIDs(nil); got != nil {
+		t.Errorf("extractExternalIDs of a missing cell = %v, want nil", got)
+	}
+}
+
+func TestApplyExternalIDs(t *testing.T) {
+	p := NewParser("")
+	p.FighterExternalIDs = map[string]map[string]string{
+		models.NormalizeName("Tyson Fury"):     {models.ExternalSourceBoxRec: "356831"},
+		models.NormalizeName("Oleksandr Usyk"): {models.ExternalSourceBoxRec: "000000", "other": "usyk"},
+	}
+
+	page := map[string]string{models.ExternalSourceBoxRec: "447121"}
+	fight := models.Fight{Fighter1: "Oleksandr Usyk", Fighter2: "Tyson Fury", Fighter1ExternalIDs: page}
+	p.applyExternalIDs(&fight)
+
+	// The ID found in the page wins over the manual mapping
+	if want := map[string]string{models.ExternalSourceBoxRec: "447121", "other": "usyk"}; !reflect.DeepEqual(fight.Fighter1ExternalIDs, want) {
+		t.Errorf("fighter 1 IDs = %v, want %v", fight.Fighter1ExternalIDs, want)
+	}
+	if want := map[string]string{models.ExternalSourceBoxRec: "356831"}; !reflect.DeepEqual(fight.Fighter2ExternalIDs, want) {
+		t.Errorf("fighter 2 IDs = %v, want %v", fight.Fighter2ExternalIDs, want)
+	}
+	if len(page) != 1 {
+		t.Errorf("the IDs of the page were modified: %v", page)
+	}
+}
@@ -0,0 +1,385 @@
+package parser
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"easypars/models"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// Confidence levels assigned to extracted fights
+const (
+	confidenceNormal = 1.0
+	confidenceHidden = 0.5
+)
+
+// FightEvent is a single fight row extracted from the results table
+// Location is inherited from the previous row when the place cell is empty,
+// because the source lists the venue only on the first row of a card
+type FightEvent struct {
+	DateText string
+	Location string
+	Fighter1 string
+	Fighter2 string
+	Result   string
+	// Boxer1Text and Boxer2Text are the full texts of the boxer cells
+	Boxer1Text string
+	Boxer2Text string
+	// External IDs found in links of the boxer cells
+	Fighter1IDs map[string]string
+	Fighter2IDs map[string]string
+	// Flags of the boxer cells, see extractFlag
+	Fighter1Flag string
+	Fighter2Flag string
+	// Records of the boxer cells, nil when a cell has none
+	Fighter1Record *models.FighterRecord
+	Fighter2Record *models.FighterRecord
+	// ContextYear and ContextMonth come from the div.month header preceding
+	// the row, zero when the row has none
+	ContextYear  int
+	ContextMonth time.Month
+}
+
+// monthHeaderPattern matches a month header such as "Январь 2025"
+var monthHeaderPattern = regexp.MustCompile(`^(\p{L}+)\s+(\d{4})$`)
+
+// monthNames maps Russian month names, nominative and genitive, to months
+var monthNames = map[string]time.Month{
+	"январь": time.January, "января": time.January,
+	"февраль": time.February, "февраля": time.February,
+	"март": time.March, "марта": time.March,
+	"апрель": time.April, "апреля": time.April,
+	"май": time.May, "мая": time.May,
+	"июнь": time.June, "июня": time.June,
+	"июль": time.July, "июля": time.July,
+	"август": time.August, "августа": time.August,
+	"сентябрь": time.September, "сентября": time.September,
+	"октябрь": time.October, "октября": time.October,
+	"ноябрь": time.November, "ноября": time.November,
+	"декабрь": time.December, "декабря": time.December,
+}
+
+// parseMonthHeader parses the text of a div.month header
+func parseMonthHeader(text string) (int, time.Month, bool) {
+	match := monthHeaderPattern.FindStringSubmatch(cleanText(text))
+	if match == nil {
+		return 0, 0, false
+	}
+	month, ok := monthNames[strings.ToLower(match[1])]
+	if !ok {
+		return 0, 0, false
+	}
+	year, _ := strconv.Atoi(match[2])
+
+	return year, month, true
+}
+
+// dayPattern matches "15" or "15.01" in a date cell
+var dayPattern = regexp.MustCompile(`(\d{1,2})(?:\.(\d{1,2}))?`)
+
+// rowCells holds the cells of a fight row by their role
+// Missing cells are nil
+type rowCells struct {
+	date   *goquery.Selection
+	place  *goquery.Selection
+	boxer1 *goquery.Selection
+	vs     *goquery.Selection
+	boxer2 *goquery.Selection
+}
+
+// classifyRowCells sorts the cells of a row by class in a single pass
+// over its children, instead of searching the row subtree once per role
+func classifyRowCells(row *goquery.Selection) rowCells {
+	var cells rowCells
+
+	row.Children().Each(func(_ int, cell *goquery.Selection) {
+		if goquery.NodeName(cell) != "td" {
+			return
+		}
+
+		class, _ := cell.Attr("class")
+		for _, name := range strings.Fields(class) {
+			switch {
+			case name == "date" && cells.date == nil:
+				cells.date = cell
+			case name == "place" && cells.place == nil:
+				cells.place = cell
+			case name == "boxer_1" && cells.boxer1 == nil:
+				cells.boxer1 = cell
+			case name == "vs" && cells.vs == nil:
+				cells.vs = cell
+			case name == "boxer_2" && cells.boxer2 == nil:
+				cells.boxer2 = cell
+			}
+		}
+	})
+
+	return cells
+}
+
+// cellText returns the cleaned text of a cell, or "" for a missing cell
+func cellText(cell *goquery.Selection) string {
+	if cell == nil {
+		return ""
+	}
+	return cleanText(cell.Text())
+}
+
+// extractFightElements walks the result tables and extracts fight rows
+// Rows are recognized by the td.boxer_1 cell; other rows are skipped.
+// Every row gets the month of the div.month header preceding it in the
+// document, so pages with several month blocks are covered.
+func extractFightElements(root *goquery.Selection) []FightEvent {
+	var events []FightEvent
+	currentLocation := ""
+	contextYear, contextMonth := 0, time.Month(0)
+
+	root.Find("div.month, tr").Each(func(_ int, row *goquery.Selection) {
+		if goquery.NodeName(row) == "div" {
+			// An unreadable header leaves the following rows without context
+			var ok bool
+			if contextYear, contextMonth, ok = parseMonthHeader(row.Text()); !ok {
+				contextYear, contextMonth = 0, 0
+			}
+			return
+		}
+
+		cells := classifyRowCells(row)
+		if cells.boxer1 == nil {
+			return
+		}
+
+		// Carry the location over from the previous row of the same card
+		if place := cellText(cells.place); place != "" {
+			currentLocation = place
+		}
+
+		event := FightEvent{
+			DateText: cellText(cells.date),
+			Location: currentLocation,
+			Fighter1: extractFighterName(cells.boxer1),
+			Fighter2: extractFighterName(cells.boxer2),
+			Result:   cellText(cells.vs),
+
+			Boxer1Text:   cellText(cells.boxer1),
+			Boxer2Text:   cellText(cells.boxer2),
+			Fighter1IDs:  extractExternalIDs(cells.boxer1),
+			Fighter2IDs:  extractExternalIDs(cells.boxer2),
+			Fighter1Flag: extractFlag(cells.boxer1),
+			Fighter2Flag: extractFlag(cells.boxer2),
+
+			Fighter1Record: extractFighterRecord(cells.boxer1),
+			Fighter2Record: extractFighterRecord(cells.boxer2),
+
+			ContextYear:  contextYear,
+			ContextMonth: contextMonth,
+		}
+
+		if event.Fighter1 == "" && event.Fighter2 == "" {
+			return
+		}
+
+		events = append(events, event)
+	})
+
+	return events
+}
+
+// extractFighterName returns the fighter name from a boxer cell
+// The name is the link text when present, otherwise the text before the record
+func extractFighterName(cell *goquery.Selection) string {
+	if cell == nil {
+		return ""
+	}
+
+	if link := cell.Find("a").First(); link.Length() > 0 {
+		if name := cleanText(link.Text()); name != "" {
+			return name
+		}
+	}
+
+	return fighterNameFromText(cell.Text())
+}
+
+// extractFlag returns the country flag of a boxer cell: the title or alt
+// text of its image, or the image address when it has neither
+// Countries are resolved when a snapshot is built, so the raw flag is kept.
+func extractFlag(cell *goquery.Selection) string {
+	if cell == nil {
+		return ""
+	}
+
+	img := cell.Find("img").First()
+	if img.Length() == 0 {
+		return ""
+	}
+	for _, attr := range []string{"title", "alt"} {
+		if text := cleanText(img.AttrOr(attr, "")); text != "" {
+			return text
+		}
+	}
+
+	return strings.TrimSpace(img.AttrOr("src", ""))
+}
+
+// recordPattern matches a fighter record such as "(27-1, 24 KO)" or
+// "(30-0-1)": wins, losses, optional draws and optional KO count, with a
+// Latin or Cyrillic "KO"
+var recordPattern = regexp.MustCompile(`\(\s*(\d+)\s*-\s*(\d+)(?:\s*-\s*(\d+))?\s*(?:,\s*(\d+)\s*(?i:KO|КО))?\s*\)`)
+
+// extractFighterRecord returns the record of a boxer cell, nil when the
+// cell has none
+// A missing record is common for debutants and never fails the fight.
+func extractFighterRecord(cell *goquery.Selection) *models.FighterRecord {
+	if cell == nil {
+		return nil
+	}
+
+	return fighterRecordFromText(cell.Text())
+}
+
+// fighterRecordFromText returns the record in the text of a boxer cell
+// Draws default to 0 and KOs stay nil when the source omits them.
+func fighterRecordFromText(text string) *models.FighterRecord {
+	match := recordPattern.FindStringSubmatch(text)
+	if match == nil {
+		return nil
+	}
+
+	record := &models.FighterRecord{}
+	record.Wins, _ = strconv.Atoi(match[1])
+	record.Losses, _ = strconv.Atoi(match[2])
+	if match[3] != "" {
+		record.Draws, _ = strconv.Atoi(match[3])
+	}
+	if match[4] != "" {
+		kos, _ := strconv.Atoi(match[4])
+		record.KOs = &kos
+	}
+
+	return record
+}
+
+// fighterNameFromText returns the fighter name from the text of a boxer cell
+// The record in parentheses following the name is dropped
+func fighterNameFromText(text string) string {
+	text = cleanText(text)
+	if idx := strings.Index(text, "("); idx >= 0 {
+		text = strings.TrimSpace(text[:idx])
+	}
+
+	return text
+}
+
+// convertEventToFight converts an extracted row into a fight record
+// The reference time supplies the month and year missing from the date cell.
+// The status is left to ResolveStatus, which needs the parser clock.
+func convertEventToFight(event FightEvent, ref time.Time) models.Fight {
+	fight := models.Fight{
+		Date:       formatDate(event.DateText, ref),
+		Fighter1:   event.Fighter1,
+		Fighter2:   event.Fighter2,
+		Result:     event.Result,
+		Location:   event.Location,
+		Confidence: confidenceNormal,
+
+		Fighter1ExternalIDs: event.Fighter1IDs,
+		Fighter2ExternalIDs: event.Fighter2IDs,
+		Fighter1Record:      event.Fighter1Record,
+		Fighter2Record:      event.Fighter2Record,
+
+		Raw: &models.RawFields{
+			DateText:      models.TruncateRaw(event.DateText),
+			ResultText:    models.TruncateRaw(event.Result),
+			LocationText:  models.TruncateRaw(event.Location),
+			Boxer1Text:    models.TruncateRaw(event.Boxer1Text),
+			Boxer2Text:    models.TruncateRaw(event.Boxer2Text),
+			Boxer1Country: models.TruncateRaw(event.Fighter1Flag),
+			Boxer2Country: models.TruncateRaw(event.Fighter2Flag),
+			RefMonth:      ref.Format("2006-01"),
+		},
+	}
+	fight.ResultType, fight.Round = classifyResult(event.Result)
+	fight.AssignKey()
+
+	return fight
+}
+
+// eventRef returns the reference time of an event: the first day of the
+// month of its div.month header, or fallback for a row without one
+// The header wins over the clock, so a December page read in January keeps
+// its December dates.
+func eventRef(event FightEvent, fallback time.Time) time.Time {
+	if event.ContextYear == 0 {
+		return fallback
+	}
+
+	return time.Date(event.ContextYear, event.ContextMonth, 1, 0, 0, 0, 0, fallback.Location())
+}
+
+// pendingResults are vs cell values of fights without a result yet
+var pendingResults = map[string]bool{
+	"":    true,
+	"vs":  true,
+	"tbd": true,
+	"tba": true,
+	"-":   true,
+	"—":   true,
+	"?":   true,
+}
+
+// isPendingResult reports whether the vs cell text means "no result yet"
+func isPendingResult(result string) bool {
+	return pendingResults[strings.ToLower(cleanText(result))]
+}
+
+// formatDate converts the date cell text into YYYY-MM-DD
+// The cell holds the day and sometimes the month ("15" or "15.01"),
+// the missing parts are taken from the reference time (see eventRef).
+// An explicit month more than half a year away from the reference month
+// lies across the year boundary: "05.01" under "Декабрь 2024" is
+// 2025-01-05 and "28.12" under "Январь 2025" is 2024-12-28. Days the
+// month does not have ("31.02") give no date.
+func formatDate(text string, ref time.Time) string {
+	match := dayPattern.FindStringSubmatch(text)
+	if match == nil {
+		return ""
+	}
+
+	day, _ := strconv.Atoi(match[1])
+	year, month := ref.Year(), int(ref.Month())
+	if match[2] != "" {
+		month, _ = strconv.Atoi(match[2])
+		switch diff := month - int(ref.Month()); {
+		case diff < -6:
+			year++
+		case diff > 6:
+			year--
+		}
+	}
+
+	if day < 1 || day > 31 || month < 1 || month > 12 {
+		return ""
+	}
+	// time.Date normalizes impossible days into the next month
+	date := time.Date(year, time.Month(month), day, 0, 0, 0, 0, time.UTC)
+	if date.Day() != day || int(date.Month()) != month {
+		return ""
+	}
+
+	return date.Format("2006-01-02")
+}
+
+// cleanText trims and collapses whitespace in extracted text
+// Control characters, which the page may hold literally or as character
+// references, count as whitespace so they never reach a fight field.
+func cleanText(text string) string {
+	return strings.Join(strings.FieldsFunc(text, func(r rune) bool {
+		return unicode.IsSpace(r) || unicode.IsControl(r)
+	}), " ")
+}
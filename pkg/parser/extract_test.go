@@ -0,0 +1,209 @@
+package parser
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// findRowCells finds the cells of a row with one search of the row subtree
+// per role, as the extraction did before classifyRowCells; it is the
+// reference classifyRowCells must agree with
+func findRowCells(row *goquery.Selection) rowCells {
+	find := func(selector string) *goquery.Selection {
+		if cell := row.Find(selector); cell.Length() > 0 {
+			return cell
+		}
+		return nil
+	}
+
+	return rowCells{
+		date:   find("td.date"),
+		place:  find("td.place"),
+		boxer1: find("td.boxer_1"),
+		vs:     find("td.vs"),
+		boxer2: find("td.boxer_2"),
+	}
+}
+
+// fixturePages returns the HTML pages of testdata
+func fixturePages(t testing.TB) map[string]string {
+	t.Helper()
+
+	paths, err := filepath.Glob(filepath.Join("testdata", "*.html"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(paths) == 0 {
+		t.Fatal("no fixture pages in testdata")
+	}
+	pages := make(map[string]string, len(paths))
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		pages[filepath.Base(path)] = string(data)
+	}
+
+	return pages
+}
+
+// largeResultsPage returns a results page of the given number of fight rows
+func largeResultsPage(rows int) string {
+	var page strings.Builder
+	page.WriteString(`<html><body><div class="month">Май 2024</div><table>`)
+	for i := 0; i < rows; i++ {
+		place := ""
+		if i%4 == 0 {
+			place = fmt.Sprintf("Arena %d", i/4)
+		}
+		fmt.Fprintf(&page, `<tr><td class="date">%d</td><td class="place">%s</td>`+
+			`<td class="boxer_1"><img src="/flags/ua.png" title="Украина"> <a href="https://boxrec.com/en/proboxer/%d">Boxer %d</a> (%d-1, %d KO)</td>`+
+			`<td class="vs">UD</td>`+
+			`<td class="boxer_2">Rival %d (%d-0)</td></tr>`,
+			i%28+1, place, 100000+i, i, i%30, i%20, i, i%40)
+	}
+	page.WriteString(`</table></body></html>`)
+
+	return page.String()
+}
+
+func TestClassifyRowCellsMatchesFind(t *testing.T) {
+	pages := fixturePages(t)
+	pages["large"] = largeResultsPage(1000)
+
+	for name, page := range pages {
+		doc, err := goquery.NewDocumentFromReader(strings.NewReader(page))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		rows := 0
+		doc.Find("tr").Each(func(i int, row *goquery.Selection) {
+			got, want := classifyRowCells(row), findRowCells(row)
+			for _, role := range []struct {
+				name      string
+				got, want *goquery.Selection
+			}{
+				{"date", got.date, want.date},
+				{"place", got.place, want.place},
+				{"boxer_1", got.boxer1, want.boxer1},
+				{"vs", got.vs, want.vs},
+				{"boxer_2", got.boxer2, want.boxer2},
+			} {
+				if (role.got == nil) != (role.want == nil) || cellText(role.got) != cellText(role.want) {
+					t.Errorf("%s row %d %s: one pass = %q, find = %q", name, i, role.name, cellText(role.got), cellText(role.want))
+				}
+			}
+			if want.boxer1 != nil {
+				rows++
+				if extractFighterName(got.boxer1) != extractFighterName(want.boxer1) ||
+					extractFighterName(got.boxer2) != extractFighterName(want.boxer2) {
+					t.Errorf("%s row %d: fighter names differ", name, i)
+				}
+			}
+		})
+		if rows == 0 {
+			t.Errorf("%s: no fight rows", name)
+		}
+	}
+}
+
+func TestExtractFightElementsFixture(t *testing.T) {
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(fixturePages(t)["vringe_results.html"]))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	events := extractFightElements(doc.Selection)
+	if len(events) != 6 {
+		t.Fatalf("extracted %d fights, want 6", len(events))
+	}
+	zhang := events[1]
+	if zhang.Fighter1 != "Zhilei Zhang" || zhang.Fighter2 != "Deontay Wilder" || zhang.Location != "Riyadh, Saudi Arabia" {
+		t.Errorf("second row = %+v, want Zhang vs Wilder in Riyadh", zhang)
+	}
+	if zhang.Fighter2IDs["boxrec"] != "274017" || zhang.Fighter1Flag != "Китай" {
+		t.Errorf("second row ids = %v, flag = %q", zhang.Fighter2IDs, zhang.Fighter1Flag)
+	}
+	if usyk := events[4]; usyk.ContextMonth != 5 || usyk.ContextYear != 2024 || usyk.Fighter1 != "Oleksandr Usyk" {
+		t.Errorf("fifth row = %+v, want Usyk in May 2024", usyk)
+	}
+}
+
+func TestExtractFlag(t *testing.T) {
+	tests := []struct {
+		name string
+		cell string
+		want string
+	}{
+		{"title", `<img title=" Украина " alt="UA" src="/flags/ua.png">Oleksandr Usyk`, "Украина"},
+		{"alt", `<img alt="Great Britain" src="/flags/gb.png">Tyson Fury`, "Great Britain"},
+		{"address", `<img src="/flags/ua.png">Oleksandr Usyk`, "/flags/ua.png"},
+		{"unknown flag kept raw", `<img title="Атлантида">Nobody`, "Атлантида"},
+		{"no flag", `Oleksandr Usyk`, ""},
+	}
+	for _, tt := range tests {
+		doc, err := goquery.NewDocumentFromReader(strings.NewReader(`<table><tr><td class="boxer_1">` + tt.cell + `</td></tr></table>`))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := extractFlag(doc.Find("td.boxer_1")); got != tt.want {
+			t.Errorf("%s: flag = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+	if got := extractFlag(nil); got != "" {
+		t.Errorf("flag of a missing cell = %q, want none", got)
+	}
+}
+
+// BenchmarkRowCells compares the one pass classification of the row cells
+// with the search per role it replaced, on a page of 1000 fight rows
+func BenchmarkRowCells(b *testing.B) {
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(largeResultsPage(1000)))
+	if err != nil {
+		b.Fatal(err)
+	}
+	rows := doc.Find("tr")
+
+	for _, bench := range []struct {
+		name     string
+		classify func(*goquery.Selection) rowCells
+	}{
+		{"one_pass", classifyRowCells},
+		{"find_per_role", findRowCells},
+	} {
+		b.Run(bench.name, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				rows.Each(func(_ int, row *goquery.Selection) {
+					cells := bench.classify(row)
+					_ = cellText(cells.date) + cellText(cells.place) + cellText(cells.vs)
+					_ = extractFighterName(cells.boxer1) + extractFighterName(cells.boxer2)
+				})
+			}
+		})
+	}
+}
+
+// BenchmarkExtractFightElements measures the extraction of a page of 1000
+// fight rows
+func BenchmarkExtractFightElements(b *testing.B) {
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(largeResultsPage(1000)))
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if events := extractFightElements(doc.Selection); len(events) != 1000 {
+			b.Fatalf("extracted %d fights, want 1000", len(events))
+		}
+	}
+}
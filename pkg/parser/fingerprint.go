@@ -0,0 +1,217 @@
+package parser
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sync"
+	"time"
+
+	"easypars/models"
+	"easypars/pkg/safeexec"
+)
+
+// Origins of a parse result
+const (
+	// OriginSource means the page was fully parsed
+	OriginSource = "source"
+	// OriginCache means the page content was unchanged and the previous result was reused
+	OriginCache = "cache"
+	// OriginRecovered means stored fights recovered from a damaged storage
+	// file; fights may be missing until the next successful parse
+	OriginRecovered = "recovered"
+)
+
+// Provenance describes where a parse result came from
+type Provenance struct {
+	Origin      string `json:"origin"`
+	URL         string `json:"url"`
+	Fingerprint string `json:"fingerprint"`
+	// ContentUnchanged is set when the page matched the previous fingerprint
+	ContentUnchanged bool `json:"content_unchanged,omitempty"`
+}
+
+// fingerprintEntry is the last successful result of a page
+type fingerprintEntry struct {
+	Fingerprint string      `json:"fingerprint"`
+	Result      ParseResult `json:"result"`
+	SavedAt     time.Time   `json:"saved_at"`
+	// savedMono is the monotonic time of the save, unset for entries loaded
+	// from the file
+	savedMono time.Time
+	// strictDates is set when the dates were taken from the page only
+	strictDates bool
+}
+
+// fingerprintCache keeps the last successful result per page URL
+// It is loaded lazily from the file on first use and saved after every update
+type fingerprintCache struct {
+	mu      sync.Mutex
+	loaded  bool
+	entries map[string]fingerprintEntry
+}
+
+// SetVolatilePatterns sets the regular expressions of page fragments that
+// change on every request (counters, generation time) and are ignored when
+// fingerprinting the page
+func (p *Parser) SetVolatilePatterns(patterns ...string) error {
+	compiled := make([]*regexp.Regexp, 0, len(patterns))
+	for _, pattern := range patterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return Classify(ErrorOriginConfig, fmt.Errorf("invalid volatile pattern %q: %w", pattern, err))
+		}
+		compiled = append(compiled, re)
+	}
+
+	p.volatilePatterns = compiled
+	return nil
+}
+
+// contentFingerprint returns the SHA-256 of the page with volatile fragments removed
+// A failing pattern is skipped, the page is then fingerprinted without it
+func (p *Parser) contentFingerprint(body []byte) string {
+	for _, re := range p.volatilePatterns {
+		_ = safeexec.Exec(fmt.Sprintf("volatile pattern '%s'", re), func() error {
+			body = re.ReplaceAll(body, nil)
+			return nil
+		})
+	}
+
+	sum := sha256.Sum256(body)
+	return hex.EncodeToString(sum[:])
+}
+
+// cachedResult returns the previous result of the page when its fingerprint matches
+// A result is only reused on the day it was produced, because the date
+// consistency rules depend on the current day
+// With a skewed clock (skewed set) the day of the clock means nothing: only
+// results dated from the page and saved by this process less than a day
+// ago, as measured by the monotonic clock, are reused. Results dated from
+// the page are not reused once the clock is trusted again.
+func (p *Parser) cachedResult(url, fingerprint string, now time.Time, skewed bool) (*ParseResult, bool) {
+	p.fingerprints.mu.Lock()
+	defer p.fingerprints.mu.Unlock()
+
+	p.loadFingerprints()
+
+	entry, ok := p.fingerprints.entries[url]
+	if !ok || entry.Fingerprint != fingerprint || entry.strictDates != skewed {
+		return nil, false
+	}
+
+	if skewed {
+		if entry.savedMono.IsZero() || time.Since(entry.savedMono) >= 24*time.Hour {
+			return nil, false
+		}
+	} else {
+		saved := entry.SavedAt.In(now.Location())
+		if saved.Year() != now.Year() || saved.YearDay() != now.YearDay() {
+			return nil, false
+		}
+	}
+
+	result := cloneResult(entry.Result)
+	result.Provenance = Provenance{
+		Origin:           OriginCache,
+		URL:              url,
+		Fingerprint:      fingerprint,
+		ContentUnchanged: true,
+	}
+
+	return &result, true
+}
+
+// rememberResult stores the result as the last successful one of its page
+// strictDates tells that the dates were taken from the page only.
+func (p *Parser) rememberResult(result *ParseResult, now time.Time, strictDates bool) error {
+	p.fingerprints.mu.Lock()
+	defer p.fingerprints.mu.Unlock()
+
+	p.loadFingerprints()
+
+	p.fingerprints.entries[result.Provenance.URL] = fingerprintEntry{
+		Fingerprint: result.Provenance.Fingerprint,
+		Result:      cloneResult(*result),
+		SavedAt:     now,
+		savedMono:   time.Now(),
+		strictDates: strictDates,
+	}
+
+	return p.saveFingerprints()
+}
+
+// cloneResult copies the slices of a result so cached results are not shared
+func cloneResult(result ParseResult) ParseResult {
+	result.Fights = append([]models.Fight(nil), result.Fights...)
+	result.Issues = append([]ParseIssue(nil), result.Issues...)
+	result.Stages = append([]StageStats(nil), result.Stages...)
+	result.Columns = append([]ColumnDiagnostics(nil), result.Columns...)
+
+	return result
+}
+
+// loadFingerprints reads saved fingerprints once
+// A missing or damaged file starts an empty cache
+// The caller must hold the lock
+func (p *Parser) loadFingerprints() {
+	if p.fingerprints.loaded {
+		return
+	}
+	p.fingerprints.loaded = true
+	p.fingerprints.entries = make(map[string]fingerprintEntry)
+
+	if p.FingerprintFile == "" {
+		return
+	}
+
+	data, err := os.ReadFile(p.FingerprintFile)
+	if errors.Is(err, os.ErrNotExist) {
+		return
+	}
+	if err == nil {
+		err = json.Unmarshal(data, &p.fingerprints.entries)
+	}
+	if err != nil {
+		p.logger().Warn("Ignoring unreadable fingerprint file", "path", p.FingerprintFile, "error", err)
+		p.fingerprints.entries = make(map[string]fingerprintEntry)
+	}
+}
+
+// saveFingerprints writes the fingerprints to the file atomically
+// The caller must hold the lock
+func (p *Parser) saveFingerprints() error {
+	if p.FingerprintFile == "" {
+		return nil
+	}
+
+	data, err := json.Marshal(p.fingerprints.entries)
+	if err != nil {
+		return fmt.Errorf("error encoding fingerprints: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(p.FingerprintFile), ".fingerprints-*.tmp")
+	if err != nil {
+		return fmt.Errorf("error saving fingerprints: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("error saving fingerprints: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("error saving fingerprints: %w", err)
+	}
+
+	if err := os.Rename(tmp.Name(), p.FingerprintFile); err != nil {
+		return fmt.Errorf("error saving fingerprints: %w", err)
+	}
+
+	return nil
+}
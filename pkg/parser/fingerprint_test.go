@@ -0,0 +1,130 @@
+package parser
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// pageSource serves the page stored in it
+func pageSource(t *testing.T, page *atomic.Value) *httptest.Server {
+	t.Helper()
+
+	src := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write([]byte(page.Load().(string)))
+	}))
+	t.Cleanup(src.Close)
+
+	return src
+}
+
+func TestContentUnchangedShortcut(t *testing.T) {
+	page := monthPage("Fingerprint", 2024, time.June, 4)
+	generated := func(body, stamp string) string {
+		return strings.Replace(body, "</body>", `<div class="footer">Generated at `+stamp+`</div></body>`, 1)
+	}
+
+	tests := []struct {
+		name      string
+		first     string
+		second    string
+		nextDay   bool
+		unchanged bool
+	}{
+		{"identical body", page, page, false, true},
+		{"one fight changed", page, strings.Replace(page, "Fingerprint Red 2", "Fingerprint Green 2", 1), false, false},
+		{"volatile fragment changed", generated(page, "10:00:01"), generated(page, "10:05:42"), false, true},
+		{"identical body on the next day", page, page, true, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var body atomic.Value
+			body.Store(tt.first)
+			src := pageSource(t, &body)
+			clk := newFakeClock()
+			p := NewParser(src.URL + "/")
+			p.Clock = clk
+			if err := p.SetVolatilePatterns(`Generated at [0-9:]+`); err != nil {
+				t.Fatal(err)
+			}
+
+			ctx := context.Background()
+			first, err := p.ParseDetailed(ctx)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if first.Provenance.Origin != OriginSource || first.Provenance.ContentUnchanged {
+				t.Errorf("first provenance = %+v, want a full parse", first.Provenance)
+			}
+
+			body.Store(tt.second)
+			if tt.nextDay {
+				clk.mu.Lock()
+				clk.now = clk.now.AddDate(0, 0, 1)
+				clk.mu.Unlock()
+			}
+			second, err := p.ParseDetailed(ctx)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if second.Provenance.ContentUnchanged != tt.unchanged {
+				t.Errorf("content_unchanged = %v, want %v", second.Provenance.ContentUnchanged, tt.unchanged)
+			}
+			wantOrigin := OriginSource
+			if tt.unchanged {
+				wantOrigin = OriginCache
+			}
+			if second.Provenance.Origin != wantOrigin {
+				t.Errorf("origin = %s, want %s", second.Provenance.Origin, wantOrigin)
+			}
+			if len(second.Fights) != len(first.Fights) {
+				t.Errorf("fights = %d, want %d", len(second.Fights), len(first.Fights))
+			}
+		})
+	}
+}
+
+func TestFingerprintsSurviveRestart(t *testing.T) {
+	var body atomic.Value
+	body.Store(monthPage("Restart", 2024, time.June, 3))
+	src := pageSource(t, &body)
+	path := filepath.Join(t.TempDir(), "fingerprints.json")
+
+	newParser := func() *Parser {
+		p := NewParser(src.URL + "/")
+		p.Clock = newFakeClock()
+		p.FingerprintFile = path
+		return p
+	}
+
+	ctx := context.Background()
+	first, err := newParser().ParseDetailed(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	second, err := newParser().ParseDetailed(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !second.Provenance.ContentUnchanged || second.Provenance.Fingerprint != first.Provenance.Fingerprint {
+		t.Errorf("provenance after a restart = %+v, want the unchanged content of %s", second.Provenance, first.Provenance.Fingerprint)
+	}
+	if len(second.Fights) != 3 {
+		t.Errorf("fights after a restart = %d, want 3", len(second.Fights))
+	}
+}
+
+func TestSetVolatilePatternsRejectsInvalidPattern(t *testing.T) {
+	p := NewParser("")
+	err := p.SetVolatilePatterns(`Generated at (`)
+	if origin, ok := OriginOf(err); !ok || origin != ErrorOriginConfig {
+		t.Errorf("SetVolatilePatterns of an invalid pattern = %v, want a config error", err)
+	}
+}
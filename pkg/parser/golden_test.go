@@ -0,0 +1,105 @@
+package parser
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"easypars/pkg/clock"
+)
+
+// update rewrites the golden files:
+// go test ./pkg/parser -run Golden -args -update
+var update = flag.Bool("update", false, "rewrite the golden files of testdata")
+
+// fixtureTransport answers every request with a page of testdata, chosen by
+// the last element of the URL path, without touching the network
+type fixtureTransport struct{}
+
+func (fixtureTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	name := filepath.Base(req.URL.Path) + ".html"
+	page, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		return &http.Response{
+			StatusCode: http.StatusNotFound,
+			Header:     http.Header{"Content-Type": {"text/plain"}},
+			Body:       http.NoBody,
+			Request:    req,
+		}, nil
+	}
+
+	return &http.Response{
+		StatusCode:    http.StatusOK,
+		Header:        http.Header{"Content-Type": {"text/html; charset=utf-8"}},
+		Body:          readCloser{bytes.NewReader(page)},
+		ContentLength: int64(len(page)),
+		Request:       req,
+	}, nil
+}
+
+type readCloser struct{ *bytes.Reader }
+
+func (readCloser) Close() error { return nil }
+
+func TestGoldenVringeResults(t *testing.T) {
+	p := NewParser("https://www.vringe.com/results/vringe_results", WithTransport(fixtureTransport{}))
+	p.Clock = clock.Fixed{Time: time.Date(2024, time.June, 10, 12, 0, 0, 0, time.UTC)}
+
+	result, err := p.ParseDetailed(context.Background())
+	if err != nil {
+		t.Fatalf("ParseDetailed: %v", err)
+	}
+	got, err := json.MarshalIndent(result.Fights, "", "  ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got = append(got, '\n')
+
+	golden := filepath.Join("testdata", "vringe_results.golden.json")
+	if *update {
+		if err := os.WriteFile(golden, got, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want, err := os.ReadFile(golden)
+	if err != nil {
+		t.Fatalf("%v (run with -update to create it)", err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("parsed fights differ from %s (run with -update after checking the change):\n%s", golden, firstDiffLine(string(got), string(want)))
+	}
+}
+
+func TestFixtureTransportUnknownPage(t *testing.T) {
+	p := NewParser("https://www.vringe.com/results/missing", WithTransport(fixtureTransport{}))
+	if _, err := p.ParseDetailed(context.Background()); err == nil {
+		t.Error("parsing a missing page succeeded")
+	}
+}
+
+// firstDiffLine describes the first line where got and want differ
+func firstDiffLine(got, want string) string {
+	gotLines, wantLines := strings.Split(got, "\n"), strings.Split(want, "\n")
+	for i := 0; i < max(len(gotLines), len(wantLines)); i++ {
+		var g, w string
+		if i < len(gotLines) {
+			g = gotLines[i]
+		}
+		if i < len(wantLines) {
+			w = wantLines[i]
+		}
+		if g != w {
+			return "line " + strconv.Itoa(i+1) + ":\n  got:  " + g + "\n  want: " + w
+		}
+	}
+
+	return ""
+}
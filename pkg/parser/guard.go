@@ -0,0 +1,66 @@
+package parser
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"easypars/pkg/netguard"
+)
+
+// guardedFetchKey marks the context of a fetch whose connections are
+// checked by the dial guard
+type guardedFetchKey struct{}
+
+// withGuardedFetch marks the requests made with the context as requests to
+// an extra source: untrusted hosts may not reach internal addresses
+func withGuardedFetch(ctx context.Context) context.Context {
+	return context.WithValue(ctx, guardedFetchKey{}, true)
+}
+
+// guardedFetch reports whether the context belongs to a guarded fetch
+func guardedFetch(ctx context.Context) bool {
+	guarded, _ := ctx.Value(guardedFetchKey{}).(bool)
+	return guarded
+}
+
+// trustedHost reports whether the host was configured by the operator:
+// listed in AllowedHosts or the host of BaseURL, MonthURL or PageURL
+// Hosts of extra sources added through the admin API are not trusted.
+func (p *Parser) trustedHost(host string) bool {
+	for _, allowed := range p.AllowedHosts {
+		if strings.EqualFold(allowed, host) {
+			return true
+		}
+	}
+	for _, raw := range []string{p.BaseURL, p.MonthURL, p.PageURL} {
+		if u, err := url.Parse(raw); err == nil && strings.EqualFold(u.Hostname(), host) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// newTransport returns the transport of the default parser client
+// Connections of extra source fetches to untrusted hosts are checked after
+// the DNS lookup, so a source host resolving, or rebinding after its
+// registration, to a loopback, private or link-local address is refused
+// with netguard.ErrBlockedAddress; redirects of such a fetch are checked the
+// same way. Guarded fetches do not use a proxy, which would be the only
+// address checked.
+func (p *Parser) newTransport() *http.Transport {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = func(req *http.Request) (*url.URL, error) {
+		if guardedFetch(req.Context()) {
+			return nil, nil
+		}
+		return http.ProxyFromEnvironment(req)
+	}
+	transport.DialContext = netguard.Dialer(defaultTimeout, func(ctx context.Context, host string) bool {
+		return guardedFetch(ctx) && !p.trustedHost(host)
+	})
+
+	return transport
+}
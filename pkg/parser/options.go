@@ -0,0 +1,35 @@
+package parser
+
+import "net/http"
+
+// Option configures a parser created by NewParser
+type Option func(*Parser)
+
+// WithHTTPClient makes the parser send its requests with the client
+// The client is copied, so the caller's client is not changed. A client
+// without a redirect policy gets the one of the parser (see checkRedirect),
+// so redirects stay restricted to the allowed hosts. The dial guard of extra
+// sources is part of the default transport (see newTransport) and is left
+// to the client.
+func WithHTTPClient(client *http.Client) Option {
+	return func(p *Parser) {
+		if client == nil {
+			return
+		}
+		copied := *client
+		if copied.CheckRedirect == nil {
+			copied.CheckRedirect = p.checkRedirect
+		}
+		p.HTTPClient = &copied
+	}
+}
+
+// WithTransport makes the default client of the parser send its requests
+// through the round tripper, e.g. an httptest server or a recorder, and
+// keeps its timeout and redirect policy; it replaces the dial guard of extra
+// sources
+func WithTransport(transport http.RoundTripper) Option {
+	return func(p *Parser) {
+		p.HTTPClient.Transport = transport
+	}
+}
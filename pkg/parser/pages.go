@@ -0,0 +1,155 @@
+package parser
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"easypars/models"
+	"easypars/pkg/tracing"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// IssuePageFailed is the issue code of a result page that failed in
+// ParseWithPagination
+const IssuePageFailed = "page_failed"
+
+// resultPage is the outcome of fetching one page of the results
+type resultPage struct {
+	attempted bool
+	result    *ParseResult
+	err       error
+}
+
+// pageURL returns the URL of a page of the results; page 1 is BaseURL
+func (p *Parser) pageURL(page int) string {
+	if page == 1 {
+		return p.BaseURL
+	}
+	return strings.ReplaceAll(p.PageURL, "{page}", strconv.Itoa(page))
+}
+
+// ParseWithPagination parses the result pages startPage to endPage
+// Page 1 is BaseURL, later pages come from the PageURL template. Pages are
+// fetched on the source pool, so at most SourceWorkers at a time. A 404
+// marks the end of the results: the pages from it on are dropped without
+// an error. The first page must succeed, a later failing page is reported
+// as an issue. A fight found on several pages is kept from the first one
+// and the merged fights are ordered by date, stable within a date.
+func (p *Parser) ParseWithPagination(ctx context.Context, startPage, endPage int) (result *ParseResult, err error) {
+	if startPage < 1 || endPage < startPage {
+		// Callers validate the range, an invalid one is a bug
+		return nil, Classify(ErrorOriginInternal, fmt.Errorf("invalid page range %d-%d", startPage, endPage))
+	}
+	if endPage > 1 && p.PageURL == "" {
+		return nil, Classify(ErrorOriginConfig, fmt.Errorf("page URL is not configured"))
+	}
+
+	ctx, span := tracing.Tracer().Start(ctx, "parser.ParseWithPagination")
+	span.SetAttributes(attribute.Int("easypars.start_page", startPage), attribute.Int("easypars.end_page", endPage))
+	defer func() {
+		if err == nil {
+			span.SetAttributes(attribute.Int("easypars.fight_count", len(result.Fights)))
+		}
+		tracing.End(span, err)
+	}()
+
+	// Step 1: Fetch the pages; pages after a known 404 are not started
+	pool := p.sourceWorkerPool()
+	ref := p.clock().Now().In(p.location())
+	pages := make([]resultPage, endPage-startPage+1)
+
+	var lastPage atomic.Int64
+	lastPage.Store(int64(endPage))
+	var wg sync.WaitGroup
+	for i := range pages {
+		number := startPage + i
+		page := &pages[i]
+		wg.Add(1)
+		err := pool.Submit(ctx, func(ctx context.Context) error {
+			defer wg.Done()
+			if int64(number) > lastPage.Load() {
+				return nil
+			}
+
+			page.attempted = true
+			page.result, page.err = p.parsePage(ctx, p.pageURL(number), ref, true)
+			if isNotFound(page.err) {
+				for {
+					last := lastPage.Load()
+					if int64(number-1) >= last || lastPage.CompareAndSwap(last, int64(number-1)) {
+						break
+					}
+				}
+			}
+			return page.err
+		})
+		if err != nil {
+			wg.Done()
+			page.attempted, page.err = true, err
+		}
+	}
+	wg.Wait()
+
+	// Step 2: Merge the pages in order up to the first missing one
+	result = &ParseResult{}
+	seen := make(map[string]bool)
+	for i, page := range pages {
+		number := startPage + i
+		if i == 0 && page.err != nil {
+			return nil, page.err
+		}
+		if int64(number) > lastPage.Load() {
+			p.logger().InfoContext(ctx, "Results end before the last requested page", "page", number)
+			break
+		}
+		if page.err != nil {
+			result.Issues = append(result.Issues, ParseIssue{
+				Stage:   "source",
+				Code:    IssuePageFailed,
+				Message: fmt.Sprintf("page %d failed: %v", number, page.err),
+			})
+			continue
+		}
+		if !page.attempted {
+			continue
+		}
+
+		if i == 0 {
+			result.Stages = page.result.Stages
+			result.Columns = page.result.Columns
+			result.Provenance = page.result.Provenance
+		}
+		for _, fight := range page.result.Fights {
+			if seen[fight.Key] {
+				continue
+			}
+			seen[fight.Key] = true
+			result.Fights = append(result.Fights, fight)
+		}
+		result.Issues = append(result.Issues, page.result.Issues...)
+	}
+	sortFightsByDate(result.Fights)
+
+	return result, nil
+}
+
+// isNotFound reports whether err is a 404 response of the source
+func isNotFound(err error) bool {
+	var statusErr *StatusError
+	return errors.As(err, &statusErr) && statusErr.StatusCode == http.StatusNotFound
+}
+
+// sortFightsByDate orders fights by date, keeping the page order within a date
+func sortFightsByDate(fights []models.Fight) {
+	sort.SliceStable(fights, func(i, j int) bool {
+		return fights[i].Date < fights[j].Date
+	})
+}
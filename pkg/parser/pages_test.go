@@ -0,0 +1,180 @@
+package parser
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// pagedServer serves the result pages by number, page 1 at / and later
+// pages at /page/N; a page missing from the map answers 404 and a page of
+// status 500 fails. It records the most pages served at the same time.
+func pagedServer(t *testing.T, pages map[int]string) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+
+	var inFlight, peak atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		current := inFlight.Add(1)
+		defer inFlight.Add(-1)
+		for {
+			old := peak.Load()
+			if current <= old || peak.CompareAndSwap(old, current) {
+				break
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+
+		number := 1
+		if r.URL.Path != "/" {
+			if _, err := fmt.Sscanf(r.URL.Path, "/page/%d", &number); err != nil {
+				http.NotFound(w, r)
+				return
+			}
+		}
+		page, ok := pages[number]
+		switch {
+		case !ok:
+			http.NotFound(w, r)
+		case page == "500":
+			http.Error(w, "broken", http.StatusInternalServerError)
+		default:
+			w.Header().Set("Content-Type", "text/html; charset=utf-8")
+			fmt.Fprint(w, page)
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	return srv, &peak
+}
+
+func TestParseWithPagination(t *testing.T) {
+	june := monthPage("June", 2024, time.June, 3)
+	may := monthPage("May", 2024, time.May, 2)
+	april := monthPage("April", 2024, time.April, 2)
+
+	tests := []struct {
+		name       string
+		pages      map[int]string
+		start, end int
+		fights     int
+		issues     int
+		fail       bool
+	}{
+		{"all pages", map[int]string{1: june, 2: may, 3: april}, 1, 3, 7, 0, false},
+		{"later pages only", map[int]string{1: june, 2: may, 3: april}, 2, 3, 4, 0, false},
+		{"results end with a 404", map[int]string{1: june, 2: may}, 1, 6, 5, 0, false},
+		{"failing later page", map[int]string{1: june, 2: "500", 3: april}, 1, 3, 5, 1, false},
+		{"fight on two pages", map[int]string{1: june, 2: may, 3: may}, 1, 3, 5, 0, false},
+		{"first page missing", map[int]string{2: may}, 1, 2, 0, 0, true},
+		{"first page failing", map[int]string{1: "500", 2: may}, 1, 2, 0, 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv, _ := pagedServer(t, tt.pages)
+			p := NewParser(srv.URL + "/")
+			p.PageURL = srv.URL + "/page/{page}"
+			p.Clock = newFakeClock()
+
+			result, err := p.ParseWithPagination(context.Background(), tt.start, tt.end)
+			if tt.fail {
+				if err == nil {
+					t.Fatalf("ParseWithPagination = %d fights, want an error", len(result.Fights))
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseWithPagination: %v", err)
+			}
+			if len(result.Fights) != tt.fights {
+				t.Errorf("fights = %d, want %d", len(result.Fights), tt.fights)
+			}
+			var failed []ParseIssue
+			for _, issue := range result.Issues {
+				if issue.Code == IssuePageFailed {
+					failed = append(failed, issue)
+				}
+			}
+			if len(failed) != tt.issues {
+				t.Errorf("%s issues = %+v, want %d", IssuePageFailed, failed, tt.issues)
+			}
+			if !sort.SliceIsSorted(result.Fights, func(i, j int) bool { return result.Fights[i].Date < result.Fights[j].Date }) {
+				t.Error("the merged fights are not ordered by date")
+			}
+			seen := make(map[string]bool)
+			for _, fight := range result.Fights {
+				if seen[fight.Key] {
+					t.Errorf("fight %s merged twice", fight.Key)
+				}
+				seen[fight.Key] = true
+			}
+		})
+	}
+}
+
+func TestParseWithPaginationKeepsThePageOrderWithinADate(t *testing.T) {
+	first := strings.Replace(monthPage("First", 2024, time.May, 1), "First Red 1", "Alpha Red", 1)
+	second := strings.Replace(monthPage("Second", 2024, time.May, 1), "Second Red 1", "Beta Red", 1)
+	srv, _ := pagedServer(t, map[int]string{1: first, 2: second})
+	p := NewParser(srv.URL + "/")
+	p.PageURL = srv.URL + "/page/{page}"
+	p.Clock = newFakeClock()
+
+	result, err := p.ParseWithPagination(context.Background(), 1, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Fights) != 2 || result.Fights[0].Fighter1 != "Alpha Red" || result.Fights[1].Fighter1 != "Beta Red" {
+		t.Errorf("fights = %+v, want the fight of page 1 before the fight of page 2", result.Fights)
+	}
+}
+
+func TestParseWithPaginationBoundsTheConcurrency(t *testing.T) {
+	pages := make(map[int]string)
+	for i := 1; i <= 8; i++ {
+		pages[i] = monthPage(fmt.Sprintf("Page%d", i), 2024, time.Month(i), 1)
+	}
+	srv, peak := pagedServer(t, pages)
+	p := NewParser(srv.URL + "/")
+	p.PageURL = srv.URL + "/page/{page}"
+	p.SourceWorkers = 2
+	p.Clock = newFakeClock()
+
+	result, err := p.ParseWithPagination(context.Background(), 1, 8)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Fights) != 8 {
+		t.Errorf("fights = %d, want 8", len(result.Fights))
+	}
+	if got := peak.Load(); got > 2 {
+		t.Errorf("pages fetched at the same time = %d, want at most 2", got)
+	}
+}
+
+func TestParseWithPaginationRejectsInvalidRanges(t *testing.T) {
+	tests := []struct {
+		name       string
+		pageURL    string
+		start, end int
+		origin     ErrorOrigin
+	}{
+		{"start before page 1", "http://source.example/page/{page}", 0, 2, ErrorOriginInternal},
+		{"end before start", "http://source.example/page/{page}", 3, 2, ErrorOriginInternal},
+		{"no page URL", "", 1, 2, ErrorOriginConfig},
+	}
+	for _, tt := range tests {
+		p := NewParser("http://source.example/")
+		p.PageURL = tt.pageURL
+
+		_, err := p.ParseWithPagination(context.Background(), tt.start, tt.end)
+		if origin, _ := OriginOf(err); err == nil || origin != tt.origin {
+			t.Errorf("%s: ParseWithPagination = %v of origin %s, want an error of origin %s", tt.name, err, origin, tt.origin)
+		}
+	}
+}
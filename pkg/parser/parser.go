@@ -1,57 +1,574 @@
 package parser
 
 import (
-	"log"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"regexp"
+	"runtime/debug"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"easypars/models"
+	"easypars/pkg/clock"
+	"easypars/pkg/metrics"
+	"easypars/pkg/pipeline"
+	"easypars/pkg/tracing"
+
+	"github.com/PuerkitoBio/goquery"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
+// defaultTimeout is used when no HTTP timeout is configured
+const defaultTimeout = 30 * time.Second
+
 // Parser represents the main parser structure
-// Future steps: Add HTTP client, parsing configuration, and data structures
+// Future steps: Add retry settings and rate limiting
 type Parser struct {
 	// BaseURL stores the target URL for parsing
 	BaseURL string
-	// Future fields:
-	// HTTPClient *http.Client
-	// Config     *config.ParserConfig
-	// Logger     *log.Logger
+	// MonthURL is the URL template of the monthly results archive
+	// {year} and {month} are replaced with the requested month ("2024", "06")
+	MonthURL string
+	// PageURL is the URL template of the later pages of the results, used
+	// by ParseWithPagination; {page} is replaced with the page number
+	PageURL string
+	// Location is the time zone of the source site, UTC is used when nil
+	Location *time.Location
+	// HTTPClient performs requests to the source site; the transport of the
+	// default client refuses internal addresses for extra sources
+	HTTPClient *http.Client
+	// ParseComments enables extraction of fights hidden in HTML comments
+	ParseComments bool
+	// Logger receives parser log records, slog.Default() is used when nil
+	Logger *slog.Logger
+	// FailOnPostProcessError fails the run when a post-processing stage errors,
+	// otherwise the failing stage is skipped
+	FailOnPostProcessError bool
+	// ColumnInvalidThreshold is the share of unexpected values above which a
+	// column is reported as degraded (DefaultColumnInvalidThreshold when zero)
+	ColumnInvalidThreshold float64
+	// StaleTBDDays is the age in days after which a fight without a result
+	// gets the result_unknown status (DefaultStaleTBDDays when zero)
+	StaleTBDDays int
+	// Clock provides the current time, the system clock is used when nil
+	Clock clock.Clock
+	// AllowedHosts lists the hosts redirects may lead to, the hosts of
+	// BaseURL, MonthURL and the registered sources are allowed when empty
+	// When set, it is also the whitelist of hosts extra sources may use
+	AllowedHosts []string
+	// OnSourceMoved is called when the source redirects permanently to a new location
+	OnSourceMoved func(SourceMove)
+	// FingerprintFile keeps page fingerprints and results between restarts,
+	// empty keeps them in memory only
+	FingerprintFile string
+	// FighterExternalIDs maps normalized fighter names to external IDs by
+	// source, for fighters the page does not link to an external database
+	FighterExternalIDs map[string]map[string]string
+	// Sources holds extra pages managed at runtime and parsed by ParseAll
+	Sources *SourceRegistry
+	// RequestsPerSecond limits the outbound requests of the parser, shared
+	// by all its goroutines (API refreshes, sources, backfill); zero means
+	// no limit
+	RequestsPerSecond float64
+	// MaxBodySize bounds a page in bytes, so an oversized response or a
+	// compression bomb cannot exhaust memory (DefaultMaxBodySize when
+	// zero); larger pages fail with ErrResponseTooLarge
+	MaxBodySize int64
+	// RetryAttempts is the number of retries of a failed fetch, see
+	// fetchHTMLDocument; zero disables retries
+	RetryAttempts int
+	// RetryBaseDelay is the first backoff delay between retries
+	// (DefaultRetryBaseDelay when zero)
+	RetryBaseDelay time.Duration
+	// RateLimitPause is the pause of all requests after a 429 response
+	// without Retry-After (DefaultRateLimitPause when zero)
+	RateLimitPause time.Duration
+	// SourceWorkers is the number of extra sources fetched at the same time
+	// (DefaultSourceWorkers when zero)
+	SourceWorkers int
+	// ClockSkew compares the clock with the Date header of source responses,
+	// no check when nil (see ClockSkewCritical)
+	ClockSkew *clock.SkewMonitor
+
+	// postProcessors are applied to extracted fights in order
+	postProcessors []PostProcessor
+	// disabledStages holds the names of turned off post-processors
+	disabledStages map[string]bool
+	// volatilePatterns match page fragments ignored by the content fingerprint
+	volatilePatterns []*regexp.Regexp
+	// fingerprints holds the last successful result of every page
+	fingerprints fingerprintCache
+	// relocations holds permanent redirects of the source
+	relocations relocations
+	// pause holds the polite mode entered after 429 responses
+	pause sourcePause
+	// throttle spaces outbound requests at RequestsPerSecond
+	throttle requestThrottle
+	// sleep waits between retries and for the end of a source pause,
+	// sleepContext when nil; tests replace it so they do not wait for real
+	sleep func(ctx context.Context, d time.Duration) error
+	// sourcePool fetches extra sources, started on first use
+	sourcePool     *pipeline.Pool
+	sourcePoolOnce sync.Once
+}
+
+// ParseResult holds the outcome of a parse run
+type ParseResult struct {
+	Fights []models.Fight `json:"fights"`
+	Issues []ParseIssue   `json:"issues"`
+	Stages []StageStats   `json:"stages"`
+	// Columns profiles the extracted cell values per role
+	Columns []ColumnDiagnostics `json:"columns"`
+	// Provenance tells whether the page was parsed or the previous result reused
+	Provenance Provenance `json:"provenance"`
 }
 
 // NewParser creates a new parser instance
-// Future steps: Initialize HTTP client with proper timeouts and headers
-func NewParser(baseURL string) *Parser {
-	return &Parser{
+// The HTTP client gets a default timeout so a stuck source cannot block forever
+// Options replace the HTTP client or its transport, e.g. in tests.
+func NewParser(baseURL string, opts ...Option) *Parser {
+	p := &Parser{
 		BaseURL: baseURL,
+		HTTPClient: &http.Client{
+			Timeout: defaultTimeout,
+		},
+		StaleTBDDays: DefaultStaleTBDDays,
 	}
+	p.HTTPClient.CheckRedirect = p.checkRedirect
+	p.HTTPClient.Transport = p.newTransport()
+	p.postProcessors = defaultPostProcessors(p)
+	for _, opt := range opts {
+		opt(p)
+	}
+
+	return p
 }
 
 // ParseFights parses fight data from the target website
-// Future steps:
-// 1. Implement HTTP client to fetch data from https://vringe.com/results/
-// 2. Add HTML parsing using goquery or similar library
-// 3. Implement data extraction logic for fights
-// 4. Add error handling and retry logic
-// 5. Implement goroutines for concurrent parsing
-// 6. Add channels for data communication
-// 7. Implement rate limiting to avoid overwhelming the target server
-func (p *Parser) ParseFights() ([]interface{}, error) {
-	log.Println("ParseFights called - implementation pending")
+func (p *Parser) ParseFights() ([]models.Fight, error) {
+	return p.ParseFightsContext(context.Background())
+}
 
-	// Placeholder return
-	return nil, nil
+// ParseFightsContext parses fight data using the given context
+// Log records carry the context, so the run history can attribute them to a run
+// The fights are collected from ParseFightsStream.
+func (p *Parser) ParseFightsContext(ctx context.Context) ([]models.Fight, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	results, err := p.ParseFightsStream(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// A stream stopped by the cancellation ends early without an error
+	fights, err := collectFights(results)
+	if err == nil && ctx.Err() != nil {
+		return nil, cancelledError(ctx, p.BaseURL)
+	}
+
+	return fights, err
+}
+
+// ParseDetailed parses fight data and reports post-processing issues and statistics
+func (p *Parser) ParseDetailed(ctx context.Context) (*ParseResult, error) {
+	return p.parsePage(ctx, p.BaseURL, p.clock().Now().In(p.location()), true)
+}
+
+// ParseMonth parses the archive page of a single month
+// Dates without a month or year are resolved within the requested month
+func (p *Parser) ParseMonth(ctx context.Context, year int, month time.Month) (*ParseResult, error) {
+	if p.MonthURL == "" {
+		return nil, Classify(ErrorOriginConfig, fmt.Errorf("month archive URL is not configured"))
+	}
+	if month < time.January || month > time.December {
+		// Callers validate the month, an invalid one is a bug
+		return nil, Classify(ErrorOriginInternal, fmt.Errorf("invalid month: %d", month))
+	}
+
+	url := strings.NewReplacer(
+		"{year}", strconv.Itoa(year),
+		"{month}", fmt.Sprintf("%02d", int(month)),
+	).Replace(p.MonthURL)
+
+	return p.parsePage(ctx, url, time.Date(year, month, 1, 0, 0, 0, 0, p.location()), false)
+}
+
+// parsePage fetches, extracts and post-processes a single results page
+// The reference time resolves incomplete dates of the page. When the page
+// content matches the previous successful run, its result is reused without
+// building the DOM or running the post-processors.
+// Errors carry their origin (see ErrorOrigin); a panic is returned as an
+// internal error. fromClock tells that ref is the parser clock rather than
+// a requested month: such a ref is not used while the clock is skewed.
+func (p *Parser) parsePage(ctx context.Context, url string, ref time.Time, fromClock bool) (result *ParseResult, err error) {
+	start := time.Now()
+	ctx, span := tracing.Tracer().Start(ctx, "parser.parsePage")
+	// Registered first, so it sees the error of a recovered panic
+	defer func() {
+		if err == nil {
+			span.SetAttributes(
+				attribute.Int("easypars.fight_count", len(result.Fights)),
+				attribute.String("easypars.origin", string(result.Provenance.Origin)))
+		}
+		tracing.End(span, err)
+
+		switch {
+		case err == nil:
+			metrics.ObserveParse(time.Since(start), len(result.Fights))
+		case ctx.Err() == nil:
+			origin, _ := OriginOf(err)
+			metrics.ParseFailed(time.Since(start), string(origin))
+		}
+	}()
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			p.logger().ErrorContext(ctx, "Parser panicked", "url", url, "panic", recovered, "stack", string(debug.Stack()))
+			result, err = nil, panicError(recovered)
+		}
+	}()
+	url = p.relocations.resolve(url)
+	span.SetAttributes(attribute.String("url.full", url))
+
+	// A parse queued behind others may have been cancelled meanwhile
+	if ctx.Err() != nil {
+		return nil, cancelledError(ctx, url)
+	}
+
+	// Respect the pause of the source before any request
+	if err := p.waitSourcePause(ctx); err != nil {
+		p.logger().InfoContext(ctx, "Fights page not fetched", "url", url, "error", err)
+		return nil, Classify(ErrorOriginSource, err)
+	}
+
+	p.logger().InfoContext(ctx, "Fetching fights page", "url", url)
+
+	body, err := p.fetchHTMLDocument(ctx, url)
+	if err != nil && ctx.Err() != nil {
+		p.logger().InfoContext(ctx, "Fights page fetch cancelled", "url", url, "error", err)
+		return nil, err
+	}
+	if err != nil {
+		p.logger().ErrorContext(ctx, "Failed to fetch fights page", "url", url, "error", err)
+		return nil, err
+	}
+
+	// The fetch checked the clock against the source; with a critical skew
+	// the clock gives no dates
+	strictDates := fromClock && p.ClockSkewCritical()
+
+	now := p.clock().Now().In(p.location())
+	fingerprint := p.contentFingerprint(body)
+	cached, ok := p.cachedResult(url, fingerprint, now, strictDates)
+	metrics.CacheLookup(metrics.CachePages, ok)
+	if ok {
+		p.logger().InfoContext(ctx, "Page content unchanged, reusing the previous result",
+			"url", url,
+			"fight_count", len(cached.Fights),
+			"duration_ms", time.Since(start).Milliseconds())
+		return cached, nil
+	}
+
+	// The client may have gone while the page was downloaded
+	if ctx.Err() != nil {
+		return nil, cancelledError(ctx, url)
+	}
+
+	fights, columns, extractIssues, err := p.parseHTML(ctx, body, ref, strictDates)
+	if err != nil {
+		p.logger().ErrorContext(ctx, "Failed to parse fights page", "url", url, "error", err)
+		return nil, Classify(ErrorOriginSource, err)
+	}
+
+	fights, issues, stages, err := p.runPostProcessors(ctx, fights)
+	if ctx.Err() != nil {
+		return nil, cancelledError(ctx, url)
+	}
+	if err != nil {
+		p.logger().ErrorContext(ctx, "Post-processing failed", "url", url, "error", err)
+		return nil, Classify(ErrorOriginInternal, err)
+	}
+	issues = append(extractIssues, issues...)
+
+	// Positions on the card are taken from the source order before any sorting
+	assignCardPositions(fights)
+
+	// The page is remembered, so fights disappearing from it can be told apart
+	// from fights moved to an archive page
+	for i := range fights {
+		fights[i].SourceURL = url
+	}
+
+	// Degraded columns are reported as issues so they show up in the quality summary
+	for _, role := range DegradedColumns(columns) {
+		p.logger().WarnContext(ctx, "Column values look degraded", "role", role)
+		issues = append(issues, ParseIssue{
+			Stage:   "extract",
+			Code:    IssueColumn,
+			Message: "column " + role + " has too many unexpected values",
+		})
+	}
+
+	for _, stage := range stages {
+		p.logger().DebugContext(ctx, "Post-processing stage finished",
+			"stage", stage.Name,
+			"changed", stage.Changed,
+			"removed", stage.Removed,
+			"issues", stage.Issues,
+			"skipped", stage.Skipped)
+	}
+
+	p.logger().InfoContext(ctx, "Parsed fights",
+		"url", url,
+		"fight_count", len(fights),
+		"issue_count", len(issues),
+		"duration_ms", time.Since(start).Milliseconds())
+
+	result = &ParseResult{
+		Fights:  fights,
+		Issues:  issues,
+		Stages:  stages,
+		Columns: columns,
+		Provenance: Provenance{
+			Origin:      OriginSource,
+			URL:         url,
+			Fingerprint: fingerprint,
+		},
+	}
+	if err := p.rememberResult(result, now, strictDates); err != nil {
+		p.logger().WarnContext(ctx, "Failed to save the page fingerprint", "url", url, "error", err)
+	}
+
+	return result, nil
+}
+
+// assignCardPositions numbers the fights of every card (same date and
+// location) in source order, starting at 1
+func assignCardPositions(fights []models.Fight) {
+	positions := make(map[string]int)
+	for i := range fights {
+		card := fights[i].Date + "|" + models.NormalizeName(fights[i].Location)
+		positions[card]++
+		fights[i].CardPosition = positions[card]
+	}
 }
 
 // ParseFighters parses fighter data from the target website
 // Future steps: Extract fighter information, statistics, and records
 func (p *Parser) ParseFighters() ([]interface{}, error) {
-	log.Println("ParseFighters called - implementation pending")
+	p.logger().Info("ParseFighters called - implementation pending")
 
 	// Placeholder return
 	return nil, nil
 }
 
+// clock returns the configured clock or the system clock
+func (p *Parser) clock() clock.Clock {
+	if p.Clock != nil {
+		return p.Clock
+	}
+	return clock.Real{}
+}
+
+// sleepFor waits for d or until ctx ends, see the sleep field
+func (p *Parser) sleepFor(ctx context.Context, d time.Duration) error {
+	if p.sleep != nil {
+		return p.sleep(ctx, d)
+	}
+	return sleepContext(ctx, d)
+}
+
+// sleepContext blocks for d, returning the context error when ctx ends first
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// location returns the time zone of the source site
+func (p *Parser) location() *time.Location {
+	if p.Location != nil {
+		return p.Location
+	}
+	return time.UTC
+}
+
+// logger returns the configured logger or the default one
+func (p *Parser) logger() *slog.Logger {
+	if p.Logger != nil {
+		return p.Logger
+	}
+	return slog.Default()
+}
+
+// fetchOnce makes a single attempt to download the page, see fetchHTMLDocument
+// The attempt first waits for its turn at RequestsPerSecond.
+// Permanent redirects are remembered, so later requests skip the extra hop.
+// A 429 response pauses all requests to the source (see sourcePause).
+// Transport failures are network errors, error responses source errors.
+// Bodies are read up to MaxBodySize, a larger declared Content-Length fails
+// before reading; compressed bodies are decoded (see decodeContent) and
+// pages in other encodings are converted to UTF-8 (see toUTF8).
+// Cancelling ctx aborts the request and the transfer of the body.
+func (p *Parser) fetchOnce(ctx context.Context, url string) ([]byte, error) {
+	if err := p.throttle.wait(ctx, p.RequestsPerSecond); err != nil {
+		return nil, cancelledError(ctx, url)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, Classify(ErrorOriginConfig, fmt.Errorf("error creating request for %s: %w", url, err))
+	}
+	req.Header.Set("User-Agent", "EasyPars/1.0 (+https://github.com/AndreyCoder404/EasyPars_2)")
+	req.Header.Set("Accept", "text/html,application/xhtml+xml")
+	req.Header.Set("Accept-Encoding", acceptEncoding)
+
+	resp, err := p.HTTPClient.Do(req)
+	if err != nil && ctx.Err() != nil {
+		return nil, cancelledError(ctx, url)
+	}
+	if err != nil {
+		metrics.UpstreamError()
+		return nil, Classify(ErrorOriginNetwork, fmt.Errorf("error fetching %s: %w", url, err))
+	}
+	defer resp.Body.Close()
+	metrics.UpstreamResponse(resp.StatusCode)
+	trace.SpanFromContext(ctx).SetAttributes(attribute.Int("http.response.status_code", resp.StatusCode))
+
+	if move, ok := permanentMove(resp); ok {
+		p.relocations.record(move.From, move.To)
+		p.logger().Warn("Source moved permanently", "from", move.From, "to", move.To)
+		if p.OnSourceMoved != nil {
+			p.OnSourceMoved(move)
+		}
+	}
+
+	if resp.StatusCode == http.StatusTooManyRequests {
+		return nil, Classify(ErrorOriginSource, p.pauseSource(url, resp))
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, Classify(ErrorOriginSource, &StatusError{URL: url, StatusCode: resp.StatusCode})
+	}
+	p.pause.reset()
+	p.checkClockSkew(resp)
+
+	// A body declared larger than the limit is not read at all; the
+	// declared size of a compressed body is below its decoded size
+	limit := p.maxBodySize()
+	if resp.ContentLength > limit {
+		return nil, Classify(ErrorOriginSource, &ResponseTooLargeError{URL: url, Limit: limit, ContentLength: resp.ContentLength})
+	}
+
+	// Compressed bodies are decoded here, within the size limit
+	reader, err := decodeContent(resp)
+	if err != nil {
+		return nil, Classify(ErrorOriginSource, fmt.Errorf("error reading response from %s: %w", url, err))
+	}
+	body, err := readLimited(reader, url, limit)
+	if err != nil && ctx.Err() != nil {
+		return nil, cancelledError(ctx, url)
+	}
+	if errors.Is(err, ErrResponseTooLarge) {
+		return nil, Classify(ErrorOriginSource, err)
+	}
+	if corruptContent(err) {
+		return nil, Classify(ErrorOriginSource, fmt.Errorf("error reading response from %s: %w", url, err))
+	}
+	if err != nil {
+		return nil, Classify(ErrorOriginNetwork, fmt.Errorf("error reading response from %s: %w", url, err))
+	}
+
+	// The extraction works on UTF-8 only
+	body, encoding, err := toUTF8(body, resp.Header.Get("Content-Type"))
+	if err != nil {
+		return nil, Classify(ErrorOriginSource, fmt.Errorf("error reading response from %s: %w", url, err))
+	}
+	if encoding != "utf-8" {
+		p.logger().DebugContext(ctx, "Page converted to UTF-8", "url", url, "encoding", encoding)
+	}
+
+	return body, nil
+}
+
+// parseHTML extracts fights from a raw HTML page
+// A page without fights whose structure changed (see StructureReport.Changed)
+// fails with a StructureChangedError carrying the report.
+// Fights hidden in HTML comments are added when ParseComments is enabled
+// Column diagnostics are computed over the rows of the main document
+// With strictDates the dates come from the div.month headers only; fights
+// without one, hidden fights included, are dropped with an issue.
+func (p *Parser) parseHTML(ctx context.Context, body []byte, ref time.Time, strictDates bool) ([]models.Fight, []ColumnDiagnostics, []ParseIssue, error) {
+	var hidden []models.Fight
+	var issues []ParseIssue
+	if p.ParseComments {
+		hidden = extractCommentedFights(string(body), ref)
+		if strictDates {
+			for _, fight := range hidden {
+				issues = append(issues, droppedWithoutContext(fight.Fighter1, fight.Fighter2))
+			}
+			hidden = nil
+		}
+	}
+
+	doc, err := goquery.NewDocumentFromReader(bytes.NewReader(body))
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("error parsing HTML: %w", err)
+	}
+
+	_, span := tracing.Tracer().Start(ctx, "parser.extractFightElements")
+	events := extractFightElements(doc.Selection)
+	span.SetAttributes(attribute.Int("easypars.fight_elements", len(events)))
+	span.End()
+	if len(events) == 0 && len(hidden) == 0 {
+		if report := detectHTMLStructureChanges(doc); report.Changed() {
+			p.logger().ErrorContext(ctx, "Page structure changed, no fights extracted",
+				"missing", report.Missing,
+				"counts", report.Counts)
+			return nil, nil, nil, &StructureChangedError{Report: report}
+		}
+	}
+	columns := computeColumnDiagnostics(events, p.ColumnInvalidThreshold)
+	var fights []models.Fight
+	if strictDates {
+		var dropped []ParseIssue
+		fights, dropped = contextDates(events, p.location())
+		issues = append(issues, dropped...)
+		if len(dropped) > 0 {
+			p.logger().WarnContext(ctx, "Fights without a month header dropped, the server clock is skewed", "fight_count", len(dropped))
+		}
+	} else {
+		fights = make([]models.Fight, 0, len(events)+len(hidden))
+		for _, event := range events {
+			fights = append(fights, convertEventToFight(event, eventRef(event, ref)))
+		}
+	}
+
+	if len(hidden) > 0 {
+		p.logger().InfoContext(ctx, "Found fights hidden in HTML comments", "fight_count", len(hidden))
+		fights = append(fights, hidden...)
+	}
+
+	for i := range fights {
+		p.applyExternalIDs(&fights[i])
+		fights[i].Status = p.resolveStatus(fights[i])
+	}
+
+	return fights, columns, issues, nil
+}
+
 // Future functions to be implemented:
-// - parseHTMLPage(url string) (*goquery.Document, error)
-// - extractFightData(doc *goquery.Document) ([]Fight, error)
-// - extractFighterData(doc *goquery.Document) ([]Fighter, error)
 // - validateParsedData(data interface{}) error
-// - saveToDatabase(data interface{}) error
 // - setupConcurrentParsing() error
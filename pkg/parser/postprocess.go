@@ -0,0 +1,173 @@
+package parser
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"easypars/models"
+	"easypars/pkg/safeexec"
+)
+
+// ParseIssue describes a data problem found while processing parsed fights
+type ParseIssue struct {
+	Stage    string `json:"stage"`
+	Code     string `json:"code"`
+	Message  string `json:"message"`
+	FightKey string `json:"fight_key,omitempty"`
+}
+
+// PostProcessor is a single post-processing stage applied to parsed fights
+// Stages run in a fixed order; each receives the output of the previous one
+type PostProcessor interface {
+	// Name identifies the stage in configuration and statistics
+	Name() string
+	// Process transforms the fights and reports the issues it found
+	Process(ctx context.Context, fights []models.Fight) ([]models.Fight, []ParseIssue, error)
+}
+
+// StageStats reports what a post-processing stage did during a run
+type StageStats struct {
+	Name       string `json:"name"`
+	Input      int    `json:"input"`
+	Output     int    `json:"output"`
+	Changed    int    `json:"changed"`
+	Removed    int    `json:"removed"`
+	Issues     int    `json:"issues"`
+	DurationMs int64  `json:"duration_ms"`
+	Skipped    bool   `json:"skipped,omitempty"`
+	Error      string `json:"error,omitempty"`
+}
+
+// defaultPostProcessors returns the built-in stages in their application order
+// Normalization must run before deduplication, otherwise differently spelled
+// copies of the same fight get different keys and survive. Date consistency
+// may change dates (and keys), so it also runs before deduplication.
+func defaultPostProcessors(p *Parser) []PostProcessor {
+	return []PostProcessor{
+		normalizeStage{},
+		junkFilterStage{},
+		dateConsistencyStage{parser: p},
+		dedupStage{},
+		validateStage{},
+	}
+}
+
+// DisablePostProcessors turns off the named stages
+// Returns an error when a name does not match any registered stage
+func (p *Parser) DisablePostProcessors(names ...string) error {
+	known := make(map[string]bool)
+	for _, stage := range p.postProcessors {
+		known[stage.Name()] = true
+	}
+
+	for _, name := range names {
+		if !known[name] {
+			return Classify(ErrorOriginConfig, fmt.Errorf("unknown post-processor: %s", name))
+		}
+		if p.disabledStages == nil {
+			p.disabledStages = make(map[string]bool)
+		}
+		p.disabledStages[name] = true
+	}
+
+	return nil
+}
+
+// runPostProcessors applies the enabled stages in order
+// A failing stage is skipped unless FailOnPostProcessError is set,
+// in which case the whole run fails
+func (p *Parser) runPostProcessors(ctx context.Context, fights []models.Fight) ([]models.Fight, []ParseIssue, []StageStats, error) {
+	var issues []ParseIssue
+	stats := make([]StageStats, 0, len(p.postProcessors))
+
+	for _, stage := range p.postProcessors {
+		stat := StageStats{Name: stage.Name(), Input: len(fights)}
+
+		if p.disabledStages[stage.Name()] {
+			stat.Output = len(fights)
+			stat.Skipped = true
+			stats = append(stats, stat)
+			continue
+		}
+
+		// Stages run isolated, a panicking stage fails like an erroring one
+		start := time.Now()
+		var output []models.Fight
+		var stageIssues []ParseIssue
+		err := safeexec.Exec(fmt.Sprintf("post-processor '%s'", stage.Name()), func() error {
+			var err error
+			output, stageIssues, err = stage.Process(ctx, fights)
+			return err
+		})
+		stat.DurationMs = time.Since(start).Milliseconds()
+
+		if err != nil {
+			stat.Output = len(fights)
+			stat.Skipped = true
+			stat.Error = err.Error()
+			stats = append(stats, stat)
+
+			if p.FailOnPostProcessError {
+				return nil, issues, stats, fmt.Errorf("post-processor %s failed: %w", stage.Name(), err)
+			}
+
+			p.logger().WarnContext(ctx, "Post-processor failed, stage skipped", "stage", stage.Name(), "error", err)
+			continue
+		}
+
+		for i := range stageIssues {
+			stageIssues[i].Stage = stage.Name()
+		}
+
+		stat.Output = len(output)
+		stat.Removed = max(0, len(fights)-len(output))
+		stat.Changed = countChanged(fights, output)
+		stat.Issues = len(stageIssues)
+		stats = append(stats, stat)
+
+		issues = append(issues, stageIssues...)
+		fights = output
+	}
+
+	return fights, issues, stats, nil
+}
+
+// CountIssues returns the number of issues per issue code
+// The counts form the data quality summary of a run
+func CountIssues(issues []ParseIssue) map[string]int {
+	counts := make(map[string]int)
+	for _, issue := range issues {
+		counts[issue.Code]++
+	}
+
+	return counts
+}
+
+// countChanged counts output fights that do not appear unchanged in the input
+// Fights are compared by their JSON representation
+func countChanged(before, after []models.Fight) int {
+	seen := make(map[string]int, len(before))
+	for _, fight := range before {
+		seen[fingerprint(fight)]++
+	}
+
+	changed := 0
+	for _, fight := range after {
+		fp := fingerprint(fight)
+		if seen[fp] > 0 {
+			seen[fp]--
+			continue
+		}
+		changed++
+	}
+
+	return changed
+}
+
+// fingerprint returns a comparable representation of a fight
+func fingerprint(fight models.Fight) string {
+	data, _ := json.Marshal(fight)
+	return string(data)
+}
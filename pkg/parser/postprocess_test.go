@@ -0,0 +1,155 @@
+package parser
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"easypars/models"
+)
+
+// rawFights returns two spellings of one fight and a row without an opponent,
+// keyed with the names as extracted
+func rawFights() []models.Fight {
+	fights := []models.Fight{
+		{Date: "2024-05-18", Fighter1: "Oleksandr Usyk (22-0)", Fighter2: "Tyson Fury", Location: "Riyadh", Confidence: 1},
+		{Date: "2024-05-18", Fighter1: "Oleksandr  Usyk", Fighter2: "Tyson Fury.", Location: "Riyadh", Confidence: 0.5},
+		{Date: "2024-05-18", Fighter1: "Daniel Dubois", Fighter2: "", Location: "Riyadh", Confidence: 1},
+	}
+	for i := range fights {
+		fights[i].AssignKey()
+	}
+
+	return fights
+}
+
+// stageFunc is a post-processor made of a function
+type stageFunc struct {
+	name    string
+	process func([]models.Fight) ([]models.Fight, error)
+}
+
+func (s stageFunc) Name() string { return s.name }
+
+func (s stageFunc) Process(_ context.Context, fights []models.Fight) ([]models.Fight, []ParseIssue, error) {
+	output, err := s.process(fights)
+	return output, nil, err
+}
+
+func TestPostProcessorOrder(t *testing.T) {
+	tests := []struct {
+		name   string
+		stages []PostProcessor
+		want   int
+	}{
+		// The spellings get one key once normalized, so dedup merges them
+		{"normalize before dedup", []PostProcessor{normalizeStage{}, junkFilterStage{}, dedupStage{}}, 1},
+		// Deduplicated on the raw keys, both spellings survive
+		{"dedup before normalize", []PostProcessor{dedupStage{}, junkFilterStage{}, normalizeStage{}}, 2},
+		{"default order", defaultPostProcessors(NewParser("")), 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := NewParser("")
+			p.postProcessors = tt.stages
+
+			fights, _, _, err := p.runPostProcessors(context.Background(), rawFights())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(fights) != tt.want {
+				t.Errorf("got %d fights %+v, want %d", len(fights), fights, tt.want)
+			}
+		})
+	}
+}
+
+func TestDisablePostProcessors(t *testing.T) {
+	p := NewParser("")
+	if err := p.DisablePostProcessors("dedup", "junk_filter"); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.DisablePostProcessors("spellcheck"); err == nil {
+		t.Error("DisablePostProcessors of an unknown stage succeeded")
+	} else if origin, _ := OriginOf(err); origin != ErrorOriginConfig {
+		t.Errorf("DisablePostProcessors of an unknown stage = %v, want a configuration error", err)
+	}
+
+	fights, issues, stats, err := p.runPostProcessors(context.Background(), rawFights())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(fights) != 3 {
+		t.Errorf("got %d fights, want all 3 without dedup and the junk filter", len(fights))
+	}
+	for _, issue := range issues {
+		if issue.Stage == "dedup" || issue.Stage == "junk_filter" {
+			t.Errorf("disabled stage reported %+v", issue)
+		}
+	}
+	for _, stat := range stats {
+		disabled := stat.Name == "dedup" || stat.Name == "junk_filter"
+		if stat.Skipped != disabled {
+			t.Errorf("stage %s skipped = %v, want %v", stat.Name, stat.Skipped, disabled)
+		}
+	}
+}
+
+func TestPostProcessorStats(t *testing.T) {
+	p := NewParser("")
+	p.postProcessors = []PostProcessor{normalizeStage{}, junkFilterStage{}, dedupStage{}, validateStage{}}
+
+	_, issues, stats, err := p.runPostProcessors(context.Background(), rawFights())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []StageStats{
+		{Name: "normalize", Input: 3, Output: 3, Changed: 2},
+		{Name: "junk_filter", Input: 3, Output: 2, Removed: 1, Issues: 1},
+		{Name: "dedup", Input: 2, Output: 1, Removed: 1, Issues: 1},
+		{Name: "validate", Input: 1, Output: 1},
+	}
+	for i := range stats {
+		stats[i].DurationMs = 0
+	}
+	if !reflect.DeepEqual(stats, want) {
+		t.Errorf("stats = %+v, want %+v", stats, want)
+	}
+	if got := CountIssues(issues); !reflect.DeepEqual(got, map[string]int{"missing_fighter": 1, "duplicate": 1}) {
+		t.Errorf("issues = %v, want a missing fighter and a duplicate", got)
+	}
+}
+
+func TestFailingPostProcessor(t *testing.T) {
+	failing := []PostProcessor{
+		stageFunc{"broken", func([]models.Fight) ([]models.Fight, error) { return nil, errors.New("broken stage") }},
+		stageFunc{"panicking", func([]models.Fight) ([]models.Fight, error) { panic("bad pattern") }},
+	}
+	for _, stage := range failing {
+		for _, failRun := range []bool{false, true} {
+			p := NewParser("")
+			p.postProcessors = []PostProcessor{stage, junkFilterStage{}}
+			p.FailOnPostProcessError = failRun
+
+			fights, _, stats, err := p.runPostProcessors(context.Background(), rawFights())
+			if failRun {
+				if err == nil {
+					t.Errorf("%s with FailOnPostProcessError: no error", stage.Name())
+				}
+				continue
+			}
+			if err != nil {
+				t.Errorf("%s: %v, want the stage skipped", stage.Name(), err)
+				continue
+			}
+			if !stats[0].Skipped || stats[0].Error == "" {
+				t.Errorf("%s stats = %+v, want skipped with the error", stage.Name(), stats[0])
+			}
+			if len(fights) != 2 {
+				t.Errorf("%s: got %d fights, want the next stages applied to the unchanged input", stage.Name(), len(fights))
+			}
+		}
+	}
+}
@@ -0,0 +1,193 @@
+package parser
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// Rate limit pause bounds
+const (
+	// DefaultRateLimitPause is the pause after a 429 without Retry-After
+	DefaultRateLimitPause = 5 * time.Minute
+	// maxRateLimitPause caps the doubling of repeated pauses; a longer
+	// Retry-After from the source is still respected
+	maxRateLimitPause = time.Hour
+)
+
+// ErrSourcePaused is returned instead of a request while the source is paused
+var ErrSourcePaused = errors.New("source requests are paused")
+
+// ErrRateLimited is returned when the source responds with 429 Too Many Requests
+var ErrRateLimited = errors.New("source rate limit exceeded")
+
+// SourcePausedError tells until when requests to the source are paused
+type SourcePausedError struct {
+	Until time.Time
+}
+
+// Error describes the pause
+func (e *SourcePausedError) Error() string {
+	return fmt.Sprintf("source requests are paused until %s after a rate limit response", e.Until.Format(time.RFC3339))
+}
+
+// Is matches ErrSourcePaused
+func (e *SourcePausedError) Is(target error) bool {
+	return target == ErrSourcePaused
+}
+
+// RateLimitedError describes a 429 response and the pause it started
+type RateLimitedError struct {
+	URL   string
+	Pause time.Duration
+}
+
+// Error describes the rate limit response
+func (e *RateLimitedError) Error() string {
+	return fmt.Sprintf("%s responded with 429 Too Many Requests, requests paused for %s", e.URL, e.Pause)
+}
+
+// Is matches ErrRateLimited
+func (e *RateLimitedError) Is(target error) bool {
+	return target == ErrRateLimited
+}
+
+// sourcePause is the polite mode shared by every request of a parser
+// The source bans by the total request rate, so one 429 pauses all
+// subsystems (API refreshes, backfill) instead of only the failing request.
+type sourcePause struct {
+	// until is the end of the pause in Unix nanoseconds, zero when not paused
+	until atomic.Int64
+	// streak counts 429 responses since the last successful request;
+	// every repeated 429 doubles the pause
+	streak atomic.Int32
+}
+
+// Until returns the end of the current or last pause
+func (s *sourcePause) Until() time.Time {
+	nanos := s.until.Load()
+	if nanos == 0 {
+		return time.Time{}
+	}
+	return time.Unix(0, nanos)
+}
+
+// trigger starts or extends the pause after a 429 response
+// A pause is never shortened by a later, shorter one
+func (s *sourcePause) trigger(now time.Time, base time.Duration) time.Duration {
+	streak := s.streak.Add(1)
+
+	pause := base
+	for i := int32(1); i < streak && pause < maxRateLimitPause; i++ {
+		pause *= 2
+	}
+	pause = min(pause, max(maxRateLimitPause, base))
+
+	until := now.Add(pause).UnixNano()
+	for {
+		current := s.until.Load()
+		if current >= until || s.until.CompareAndSwap(current, until) {
+			break
+		}
+	}
+
+	return pause
+}
+
+// reset ends the streak of 429 responses after a successful request
+func (s *sourcePause) reset() {
+	s.streak.Store(0)
+}
+
+// interactiveKey is the context key marking requests that must not wait
+type interactiveKey struct{}
+
+// WithInteractive marks the context of a request served to a user
+// Interactive requests fail with ErrSourcePaused during a pause instead of
+// waiting, so the caller can answer from cached data right away.
+// Background work (backfill) waits for the pause to end.
+func WithInteractive(ctx context.Context) context.Context {
+	return context.WithValue(ctx, interactiveKey{}, true)
+}
+
+// isInteractive reports whether the context belongs to an interactive request
+func isInteractive(ctx context.Context) bool {
+	interactive, _ := ctx.Value(interactiveKey{}).(bool)
+	return interactive
+}
+
+// SourcePausedUntil returns the end of the current pause, zero when requests are allowed
+func (p *Parser) SourcePausedUntil() time.Time {
+	until := p.pause.Until()
+	if !p.clock().Now().Before(until) {
+		return time.Time{}
+	}
+	return until
+}
+
+// waitSourcePause blocks until the source pause is over
+// Interactive requests get a SourcePausedError immediately instead
+func (p *Parser) waitSourcePause(ctx context.Context) error {
+	for {
+		until := p.SourcePausedUntil()
+		if until.IsZero() {
+			return nil
+		}
+		if isInteractive(ctx) {
+			return &SourcePausedError{Until: until}
+		}
+
+		p.logger().InfoContext(ctx, "Source is paused after rate limiting, waiting", "until", until)
+		if err := p.sleepFor(ctx, until.Sub(p.clock().Now())); err != nil {
+			return err
+		}
+	}
+}
+
+// pauseSource starts the shared pause after a 429 response
+func (p *Parser) pauseSource(url string, resp *http.Response) error {
+	now := p.clock().Now()
+	base := parseRetryAfter(resp.Header.Get("Retry-After"), now)
+	if base <= 0 {
+		base = p.rateLimitPause()
+	}
+
+	pause := p.pause.trigger(now, base)
+	p.logger().Warn("Source rate limit exceeded, pausing all requests",
+		"url", url,
+		"pause", pause.String(),
+		"until", p.pause.Until())
+
+	return &RateLimitedError{URL: url, Pause: pause}
+}
+
+// rateLimitPause returns the configured pause after a 429 without Retry-After
+func (p *Parser) rateLimitPause() time.Duration {
+	if p.RateLimitPause > 0 {
+		return p.RateLimitPause
+	}
+	return DefaultRateLimitPause
+}
+
+// parseRetryAfter reads a Retry-After header given in seconds or as an HTTP date
+// Returns zero when the header is missing or invalid
+func parseRetryAfter(value string, now time.Time) time.Duration {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil {
+		return time.Duration(max(seconds, 0)) * time.Second
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		return max(date.Sub(now), 0)
+	}
+
+	return 0
+}
@@ -0,0 +1,135 @@
+package parser
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestSourcePauseDoubles(t *testing.T) {
+	tests := []struct {
+		name   string
+		base   time.Duration
+		pauses []time.Duration
+	}{
+		{"default pause", DefaultRateLimitPause, []time.Duration{
+			5 * time.Minute, 10 * time.Minute, 20 * time.Minute, 40 * time.Minute, time.Hour, time.Hour,
+		}},
+		{"short Retry-After", 30 * time.Second, []time.Duration{30 * time.Second, time.Minute, 2 * time.Minute}},
+		{"Retry-After beyond the cap", 2 * time.Hour, []time.Duration{2 * time.Hour, 2 * time.Hour}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var pause sourcePause
+			now := time.Date(2024, time.June, 10, 12, 0, 0, 0, time.UTC)
+			for i, want := range tt.pauses {
+				if got := pause.trigger(now, tt.base); got != want {
+					t.Errorf("pause %d = %s, want %s", i+1, got, want)
+				}
+			}
+
+			// A success ends the streak, the next 429 starts over
+			pause.reset()
+			if got := pause.trigger(now, tt.base); got != tt.pauses[0] {
+				t.Errorf("pause after a success = %s, want %s", got, tt.pauses[0])
+			}
+		})
+	}
+}
+
+func TestSourcePauseIsNeverShortened(t *testing.T) {
+	var pause sourcePause
+	now := time.Date(2024, time.June, 10, 12, 0, 0, 0, time.UTC)
+	pause.trigger(now, time.Hour)
+	pause.reset()
+	pause.trigger(now, time.Minute)
+
+	if until := pause.Until(); !until.Equal(now.Add(time.Hour)) {
+		t.Errorf("pause ends at %s, want the longer pause kept until %s", until, now.Add(time.Hour))
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2024, time.June, 10, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		value string
+		want  time.Duration
+	}{
+		{"", 0},
+		{"120", 2 * time.Minute},
+		{" 7 ", 7 * time.Second},
+		{"-5", 0},
+		{"later", 0},
+		{now.Add(90 * time.Second).Format(http.TimeFormat), 90 * time.Second},
+		{now.Add(-time.Minute).Format(http.TimeFormat), 0},
+	}
+	for _, tt := range tests {
+		if got := parseRetryAfter(tt.value, now); got != tt.want {
+			t.Errorf("parseRetryAfter(%q) = %s, want %s", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestPauseIsSharedByAllRequests(t *testing.T) {
+	src := newScriptedSource(t, scriptedResponse{status: 429, retryAfter: "120"}, scriptedResponse{status: 200})
+	clk := newFakeClock()
+	clk.sleepsOnly = true
+	p := newRetryParser(src, 0, clk)
+	p.MonthURL = src.URL + "/archive/{year}-{month}"
+	ctx := context.Background()
+
+	// A 429 of one page pauses the requests of every other page
+	if _, err := p.ParseMonth(ctx, 2024, time.May); !errors.Is(err, ErrRateLimited) {
+		t.Fatalf("fetch answered with 429 = %v, want ErrRateLimited", err)
+	}
+	until := p.SourcePausedUntil()
+	if want := clk.Now().Add(2 * time.Minute); !until.Equal(want) {
+		t.Fatalf("paused until %s, want the Retry-After of 2 minutes", until)
+	}
+
+	// An interactive request fails at once without reaching the source
+	_, err := p.ParseDetailed(WithInteractive(ctx))
+	var paused *SourcePausedError
+	if !errors.As(err, &paused) || !paused.Until.Equal(until) {
+		t.Errorf("interactive parse during the pause = %v, want a SourcePausedError until %s", err, until)
+	}
+	if src.hits.Load() != 1 || len(clk.Slept()) != 0 {
+		t.Errorf("interactive parse made %d requests and slept %v, want neither", src.hits.Load()-1, clk.Slept())
+	}
+
+	// The pause expires: requests go through again
+	clk.mu.Lock()
+	clk.now = until
+	clk.mu.Unlock()
+	if !p.SourcePausedUntil().IsZero() {
+		t.Error("the source is still paused after the pause ended")
+	}
+	if _, err := p.ParseDetailed(WithInteractive(ctx)); errors.Is(err, ErrSourcePaused) || src.hits.Load() != 2 {
+		t.Errorf("parse after the pause = %v with %d requests, want the source requested", err, src.hits.Load())
+	}
+}
+
+func TestBackgroundRequestWaitsForThePause(t *testing.T) {
+	src := newScriptedSource(t, scriptedResponse{status: 429, retryAfter: "120"}, scriptedResponse{status: 200})
+	clk := newFakeClock()
+	p := newRetryParser(src, 0, clk)
+	p.MonthURL = src.URL + "/archive/{year}-{month}"
+	ctx := context.Background()
+
+	if _, err := p.ParseMonth(ctx, 2024, time.May); !errors.Is(err, ErrRateLimited) {
+		t.Fatalf("fetch answered with 429 = %v, want ErrRateLimited", err)
+	}
+
+	// Background work waits for the end of the pause, then fetches
+	if _, err := p.ParseMonth(ctx, 2024, time.April); errors.Is(err, ErrSourcePaused) {
+		t.Fatalf("background parse = %v, want it to wait for the pause", err)
+	}
+	if slept := clk.Slept(); len(slept) != 1 || slept[0] != 2*time.Minute {
+		t.Errorf("slept %v, want the 2 minutes of the pause", slept)
+	}
+	if src.hits.Load() != 2 {
+		t.Errorf("requests = %d, want 2", src.hits.Load())
+	}
+}
@@ -0,0 +1,48 @@
+package parser
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+
+	"easypars/models"
+)
+
+// ParseFromReader parses fights from a saved results page
+// No request is made: the document, converted to UTF-8 like a fetched page,
+// goes straight to the extraction and the post-processors, with the parser
+// clock resolving dates without a month header. The result is not cached and does not touch the fingerprints.
+// An empty document or one without fights is an error rather than an
+// empty result: ErrStructureChanged when it is most likely not a results
+// page, ErrNoFightsFound when it is one without fights.
+func (p *Parser) ParseFromReader(r io.Reader) ([]models.Fight, error) {
+	body, err := io.ReadAll(r)
+	if err != nil {
+		return nil, Classify(ErrorOriginConfig, fmt.Errorf("error reading HTML document: %w", err))
+	}
+	if len(bytes.TrimSpace(body)) == 0 {
+		return nil, Classify(ErrorOriginSource, fmt.Errorf("empty HTML document"))
+	}
+	if body, _, err = toUTF8(body, ""); err != nil {
+		return nil, Classify(ErrorOriginSource, err)
+	}
+
+	ctx := context.Background()
+	ref := p.clock().Now().In(p.location())
+	fights, _, _, err := p.parseHTML(ctx, body, ref, false)
+	if err != nil {
+		return nil, Classify(ErrorOriginSource, fmt.Errorf("%w, is it a results page?", err))
+	}
+	if len(fights) == 0 {
+		return nil, Classify(ErrorOriginSource, fmt.Errorf("%w in the HTML document", ErrNoFightsFound))
+	}
+
+	fights, _, _, err = p.runPostProcessors(ctx, fights)
+	if err != nil {
+		return nil, Classify(ErrorOriginInternal, err)
+	}
+	assignCardPositions(fights)
+
+	return fights, nil
+}
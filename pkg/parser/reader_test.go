@@ -0,0 +1,107 @@
+package parser
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// failingReader fails every read
+type failingReader struct{}
+
+func (failingReader) Read([]byte) (int, error) { return 0, errors.New("disk error") }
+
+// readFixture returns a file of the testdata directory
+func readFixture(t *testing.T, name string) string {
+	t.Helper()
+
+	data, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestParseFromReader(t *testing.T) {
+	tests := []struct {
+		name     string
+		page     string
+		fights   int
+		fighter1 string
+		err      error
+		origin   ErrorOrigin
+	}{
+		{"saved results page", monthPage("Saved", 2024, time.May, 3), 3, "Saved Red 1", nil, ""},
+		{"windows-1251 page", readFixture(t, "results_windows1251.html"), 2, "Александр Усик", nil, ""},
+		{"empty document", "", 0, "", nil, ErrorOriginSource},
+		{"only whitespace", " \n\t", 0, "", nil, ErrorOriginSource},
+		{"not a results page", "<html><body><p>Page not found</p></body></html>", 0, "", ErrStructureChanged, ErrorOriginSource},
+		{"results page without fights", `<html><body><div class="month">Май 2024</div><table><tr><td class="date">1</td><td class="place">Arena</td><td class="boxer_1"></td><td class="vs"></td><td class="boxer_2"></td></tr></table></body></html>`, 0, "", ErrNoFightsFound, ErrorOriginSource},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// The base URL refuses connections: no request may be made
+			p := newQuietParser("http://127.0.0.1:1/")
+			p.Clock = newFakeClock()
+
+			fights, err := p.ParseFromReader(strings.NewReader(tt.page))
+			if tt.origin != "" {
+				if err == nil {
+					t.Fatalf("ParseFromReader = %d fights, want an error", len(fights))
+				}
+				if origin, _ := OriginOf(err); origin != tt.origin {
+					t.Errorf("origin = %s, want %s", origin, tt.origin)
+				}
+				if tt.err != nil && !errors.Is(err, tt.err) {
+					t.Errorf("ParseFromReader = %v, want %v", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseFromReader: %v", err)
+			}
+			if len(fights) != tt.fights || fights[0].Fighter1 != tt.fighter1 {
+				t.Errorf("fights = %+v, want %d starting with %s", fights, tt.fights, tt.fighter1)
+			}
+			for _, fight := range fights {
+				if fight.Key == "" || fight.ResultType == "" {
+					t.Errorf("fight %+v is not post-processed", fight)
+				}
+			}
+		})
+	}
+}
+
+func TestParseFromReaderReadError(t *testing.T) {
+	_, err := NewParser("http://127.0.0.1:1/").ParseFromReader(failingReader{})
+	if origin, _ := OriginOf(err); err == nil || origin != ErrorOriginConfig {
+		t.Errorf("ParseFromReader of a failing reader = %v of origin %s, want an error of origin %s", err, origin, ErrorOriginConfig)
+	}
+}
+
+func TestParseFromReaderMatchesAFetchedPage(t *testing.T) {
+	page := readFixture(t, "vringe_results.html")
+	p := newQuietParser(pageServer(t, page).URL + "/")
+	p.Clock = newFakeClock()
+
+	fetched, err := p.ParseFightsContext(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	saved, err := p.ParseFromReader(strings.NewReader(page))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(saved) != len(fetched) {
+		t.Fatalf("ParseFromReader = %d fights, the fetched page %d", len(saved), len(fetched))
+	}
+	for i := range saved {
+		if saved[i].Key != fetched[i].Key || saved[i].Result != fetched[i].Result {
+			t.Errorf("fight %d = %s %q, fetched %s %q", i, saved[i].Key, saved[i].Result, fetched[i].Key, fetched[i].Result)
+		}
+	}
+}
@@ -0,0 +1,78 @@
+package parser
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"easypars/models"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// record returns a fighter record, kos < 0 for none
+func record(wins, losses, draws, kos int) *models.FighterRecord {
+	r := &models.FighterRecord{Wins: wins, Losses: losses, Draws: draws}
+	if kos >= 0 {
+		r.KOs = &kos
+	}
+	return r
+}
+
+func TestFighterRecordFromText(t *testing.T) {
+	tests := []struct {
+		text string
+		want *models.FighterRecord
+	}{
+		{"Anthony Joshua (27-1, 24 KO)", record(27, 1, 0, 24)},
+		{"Tyson Fury (34-0-1, 24 KO)", record(34, 0, 1, 24)},
+		{"Deontay Wilder (30-0-1)", record(30, 0, 1, -1)},
+		{"Oleksandr Usyk (21-0)", record(21, 0, 0, -1)},
+		{"Anthony Joshua ( 27 - 3 , 24 ко )", record(27, 3, 0, 24)},
+		{"Дмитрий Бивол (23-0, 12 КО)", record(23, 0, 0, 12)},
+		{"Jai Opetaia (0-0)", record(0, 0, 0, -1)},
+		{"Debutant", nil},
+		{"Joe Smith Jr. (Jr.)", nil},
+		{"Broken (27-)", nil},
+		{"", nil},
+	}
+	for _, tt := range tests {
+		got := fighterRecordFromText(tt.text)
+		if got.String() != tt.want.String() || (got == nil) != (tt.want == nil) {
+			t.Errorf("fighterRecordFromText(%q) = %q, want %q", tt.text, got.String(), tt.want.String())
+		}
+	}
+}
+
+func TestExtractFighterRecord(t *testing.T) {
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(`<table><tr>` +
+		`<td class="boxer_1"><img title="Украина"> <a href="/boxer/usyk">Oleksandr Usyk</a> (21-0, 14 KO)</td>` +
+		`<td class="boxer_2">Newcomer</td></tr></table>`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	events := extractFightElements(doc.Selection)
+	if len(events) != 1 {
+		t.Fatalf("extracted %d fights, want 1", len(events))
+	}
+	event := events[0]
+	if event.Fighter1 != "Oleksandr Usyk" || event.Fighter1Record.String() != "21-0-0, 14 KO" {
+		t.Errorf("fighter 1 = %q with %q, want Oleksandr Usyk with 21-0-0, 14 KO", event.Fighter1, event.Fighter1Record.String())
+	}
+	if event.Fighter2 != "Newcomer" || event.Fighter2Record != nil {
+		t.Errorf("fighter 2 = %q with %+v, want Newcomer without a record", event.Fighter2, event.Fighter2Record)
+	}
+	if extractFighterRecord(nil) != nil {
+		t.Error("a missing cell has a record")
+	}
+
+	// The records are part of the fight output, a missing one is left out
+	data, err := json.Marshal(convertEventToFight(event, newFakeClock().Now()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), `"fighter1_record":{"wins":21,"losses":0,"draws":0,"kos":14}`) || strings.Contains(string(data), "fighter2_record") {
+		t.Errorf("fight JSON = %s, want fighter1_record only", data)
+	}
+}
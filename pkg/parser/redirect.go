@@ -0,0 +1,186 @@
+package parser
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+)
+
+// maxRedirects is the longest redirect chain followed for a single request
+const maxRedirects = 10
+
+// ErrRedirectBlocked is returned when the source redirects to a host that is not allowed
+var ErrRedirectBlocked = errors.New("redirect to a host that is not allowed")
+
+// RedirectBlockedError tells where a blocked redirect was leading
+type RedirectBlockedError struct {
+	From string
+	To   string
+}
+
+// Error describes the blocked redirect
+func (e *RedirectBlockedError) Error() string {
+	return fmt.Sprintf("redirect from %s to %s blocked: host is not allowed", e.From, e.To)
+}
+
+// Is matches ErrRedirectBlocked
+func (e *RedirectBlockedError) Is(target error) bool {
+	return target == ErrRedirectBlocked
+}
+
+// SourceMove describes a permanent redirect of the source
+type SourceMove struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// relocations remembers permanent redirects of the source
+// Requests to a moved URL go straight to its new location
+type relocations struct {
+	mu sync.RWMutex
+	// urls maps a moved URL to its new location
+	urls map[string]string
+	// origins maps a moved scheme://host to the new one, recorded when the
+	// redirect kept the path, so other pages of the site follow the move too
+	origins map[string]string
+}
+
+// resolve returns the current location of a URL
+func (r *relocations) resolve(rawURL string) string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if moved, ok := r.urls[rawURL]; ok {
+		return moved
+	}
+
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return rawURL
+	}
+	if origin, ok := r.origins[u.Scheme+"://"+u.Host]; ok {
+		if target, err := url.Parse(origin); err == nil {
+			u.Scheme = target.Scheme
+			u.Host = target.Host
+			return u.String()
+		}
+	}
+
+	return rawURL
+}
+
+// record stores a permanent move
+func (r *relocations) record(from, to string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.urls == nil {
+		r.urls = make(map[string]string)
+		r.origins = make(map[string]string)
+	}
+	r.urls[from] = to
+
+	fromURL, errFrom := url.Parse(from)
+	toURL, errTo := url.Parse(to)
+	if errFrom == nil && errTo == nil && fromURL.Host != toURL.Host && fromURL.RequestURI() == toURL.RequestURI() {
+		r.origins[fromURL.Scheme+"://"+fromURL.Host] = toURL.Scheme + "://" + toURL.Host
+	}
+}
+
+// EffectiveBaseURL returns the base URL after permanent redirects of the source
+func (p *Parser) EffectiveBaseURL() string {
+	return p.relocations.resolve(p.BaseURL)
+}
+
+// RelocateSources stores the new URL of the registered sources a permanent
+// move applies to, so they are requested at their new location after a
+// restart too
+// It is meant to be registered as OnSourceMoved. The base URL comes from the
+// configuration and is only followed in memory.
+func (p *Parser) RelocateSources(move SourceMove) {
+	if p.Sources == nil {
+		return
+	}
+
+	moved, err := p.Sources.relocate(move, p.clock().Now())
+	if err != nil {
+		p.logger().Error("Failed to store the new URL of a moved source", "from", move.From, "to", move.To, "error", err)
+		return
+	}
+	for _, source := range moved {
+		p.logger().Info("Source URL updated after a permanent redirect", "source", source.Name, "url", source.URL)
+	}
+}
+
+// hostAllowed reports whether requests may be redirected to the host
+// When no hosts are configured, the hosts of BaseURL, MonthURL and PageURL
+// are allowed
+func (p *Parser) hostAllowed(host string) bool {
+	allowed := p.AllowedHosts
+	if len(allowed) == 0 {
+		for _, raw := range []string{p.BaseURL, p.MonthURL, p.PageURL} {
+			if u, err := url.Parse(raw); err == nil && u.Hostname() != "" {
+				allowed = append(allowed, u.Hostname())
+			}
+		}
+		allowed = append(allowed, p.sourceHosts()...)
+	}
+
+	for _, candidate := range allowed {
+		if strings.EqualFold(candidate, host) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// checkRedirect is the CheckRedirect policy of the parser HTTP client
+// Redirects are only followed to allowed hosts. Refused redirects are
+// errors of the source.
+func (p *Parser) checkRedirect(req *http.Request, via []*http.Request) error {
+	if len(via) >= maxRedirects {
+		return Classify(ErrorOriginSource, fmt.Errorf("stopped after %d redirects", maxRedirects))
+	}
+
+	if !p.hostAllowed(req.URL.Hostname()) {
+		return Classify(ErrorOriginSource, &RedirectBlockedError{From: via[len(via)-1].URL.String(), To: req.URL.String()})
+	}
+
+	return nil
+}
+
+// permanentMove returns the new location when the response was reached through
+// permanent redirects (301/308) from the start of the chain
+// A temporary redirect (302/307) ends the part of the chain that is remembered
+func permanentMove(resp *http.Response) (SourceMove, bool) {
+	// Walk the chain back from the final request to the original one
+	var chain []*http.Request
+	for req := resp.Request; req != nil; {
+		chain = append([]*http.Request{req}, chain...)
+		if req.Response == nil {
+			break
+		}
+		req = req.Response.Request
+	}
+	if len(chain) < 2 {
+		return SourceMove{}, false
+	}
+
+	last := 0
+	for i := 1; i < len(chain); i++ {
+		status := chain[i].Response.StatusCode
+		if status != http.StatusMovedPermanently && status != http.StatusPermanentRedirect {
+			break
+		}
+		last = i
+	}
+	if last == 0 {
+		return SourceMove{}, false
+	}
+
+	return SourceMove{From: chain[0].URL.String(), To: chain[last].URL.String()}, true
+}
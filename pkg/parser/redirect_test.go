@@ -0,0 +1,228 @@
+package parser
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// redirectServer redirects every request to target with the status and
+// counts the requests
+func redirectServer(t *testing.T, status int, target func() string) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+
+	var hits atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		http.Redirect(w, r, target()+r.URL.Path, status)
+	}))
+	t.Cleanup(srv.Close)
+
+	return srv, &hits
+}
+
+func TestRedirects(t *testing.T) {
+	tests := []struct {
+		name string
+		// statuses are the redirects from the configured URL to the page,
+		// one server per status
+		statuses []int
+		// movedTo is the index of the server remembered as the new location,
+		// -1 for none; the page server follows the redirect servers
+		movedTo int
+	}{
+		{"301 to an allowed host", []int{http.StatusMovedPermanently}, 1},
+		{"308 to an allowed host", []int{http.StatusPermanentRedirect}, 1},
+		{"302", []int{http.StatusFound}, -1},
+		{"307", []int{http.StatusTemporaryRedirect}, -1},
+		{"chain of two permanent redirects", []int{http.StatusMovedPermanently, http.StatusMovedPermanently}, 2},
+		{"permanent then temporary redirect", []int{http.StatusMovedPermanently, http.StatusFound}, 1},
+		{"temporary then permanent redirect", []int{http.StatusFound, http.StatusMovedPermanently}, -1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			page := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "text/html; charset=utf-8")
+				fmt.Fprint(w, monthPage("Moved", 2024, time.April, 2))
+			}))
+			defer page.Close()
+
+			// Each redirect server leads to the next one, the last to the page
+			urls := make([]string, len(tt.statuses)+1)
+			urls[len(tt.statuses)] = page.URL
+			var firstHits *atomic.Int32
+			for i := len(tt.statuses) - 1; i >= 0; i-- {
+				next := urls[i+1]
+				srv, hits := redirectServer(t, tt.statuses[i], func() string { return next })
+				urls[i] = srv.URL
+				if i == 0 {
+					firstHits = hits
+				}
+			}
+
+			p := NewParser(urls[0] + "/")
+			var moves []SourceMove
+			p.OnSourceMoved = func(move SourceMove) { moves = append(moves, move) }
+
+			ctx := context.Background()
+			if _, err := p.ParseDetailed(ctx); err != nil {
+				t.Fatalf("ParseDetailed: %v", err)
+			}
+
+			want := urls[0] + "/"
+			if tt.movedTo >= 0 {
+				want = urls[tt.movedTo] + "/"
+				if len(moves) != 1 || moves[0] != (SourceMove{From: urls[0] + "/", To: want}) {
+					t.Errorf("source_moved events = %+v, want one move to %s", moves, want)
+				}
+			} else if len(moves) != 0 {
+				t.Errorf("source_moved events = %+v, want none", moves)
+			}
+			if got := p.EffectiveBaseURL(); got != want {
+				t.Errorf("effective base URL = %s, want %s", got, want)
+			}
+
+			// A remembered move skips the old URL on the next request
+			firstHits.Store(0)
+			if _, err := p.ParseDetailed(ctx); err != nil {
+				t.Fatalf("second ParseDetailed: %v", err)
+			}
+			wantHits := int32(1)
+			if tt.movedTo >= 0 {
+				wantHits = 0
+			}
+			if got := firstHits.Load(); got != wantHits {
+				t.Errorf("requests to the configured URL = %d, want %d", got, wantHits)
+			}
+		})
+	}
+}
+
+func TestRedirectToAForeignHostIsBlocked(t *testing.T) {
+	src, _ := redirectServer(t, http.StatusMovedPermanently, func() string { return "http://foreign.example" })
+	p := NewParser(src.URL + "/")
+	moved := false
+	p.OnSourceMoved = func(SourceMove) { moved = true }
+
+	_, err := p.ParseDetailed(context.Background())
+	if !errors.Is(err, ErrRedirectBlocked) {
+		t.Fatalf("ParseDetailed = %v, want ErrRedirectBlocked", err)
+	}
+	var blocked *RedirectBlockedError
+	if !errors.As(err, &blocked) || blocked.From != src.URL+"/" || blocked.To != "http://foreign.example/" {
+		t.Errorf("blocked redirect = %+v, want from %s/ to http://foreign.example/", blocked, src.URL)
+	}
+	if origin, _ := OriginOf(err); origin != ErrorOriginSource {
+		t.Errorf("origin = %s, want %s", origin, ErrorOriginSource)
+	}
+	if moved || p.EffectiveBaseURL() != src.URL+"/" {
+		t.Errorf("a blocked redirect moved the source to %s", p.EffectiveBaseURL())
+	}
+}
+
+func TestRelocateSourcesStoresTheNewURL(t *testing.T) {
+	tests := []struct {
+		name   string
+		status int
+		moved  bool
+	}{
+		{"301", http.StatusMovedPermanently, true},
+		{"308", http.StatusPermanentRedirect, true},
+		{"302", http.StatusFound, false},
+		{"307", http.StatusTemporaryRedirect, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			moved := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "text/html; charset=utf-8")
+				fmt.Fprint(w, monthPage("Moved", 2024, time.April, 2))
+			}))
+			defer moved.Close()
+			var oldHits atomic.Int32
+			old := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				oldHits.Add(1)
+				http.Redirect(w, r, moved.URL+r.URL.Path, tt.status)
+			}))
+			defer old.Close()
+
+			path := filepath.Join(t.TempDir(), "sources.json")
+			p := NewParser(moved.URL + "/")
+			p.Sources, _ = NewSourceRegistry(path)
+			if _, err := p.Sources.add(Source{Name: "extra", Type: SourceTypeResults, URL: old.URL + "/extra", Enabled: true}); err != nil {
+				t.Fatal(err)
+			}
+			p.OnSourceMoved = p.RelocateSources
+
+			ctx := context.Background()
+			if _, err := p.ParseAll(ctx); err != nil {
+				t.Fatalf("ParseAll: %v", err)
+			}
+
+			// The registry saved to the file holds the URL of the next run
+			saved, err := NewSourceRegistry(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			want := old.URL + "/extra"
+			if tt.moved {
+				want = moved.URL + "/extra"
+			}
+			if got := saved.List()[0].URL; got != want {
+				t.Errorf("stored source URL = %s, want %s", got, want)
+			}
+
+			// After a restart a moved source is requested at its new URL
+			restarted := NewParser(moved.URL + "/")
+			restarted.Sources = saved
+			oldHits.Store(0)
+			if _, err := restarted.ParseAll(ctx); err != nil {
+				t.Fatalf("ParseAll after the restart: %v", err)
+			}
+			if got := oldHits.Load(); tt.moved && got != 0 {
+				t.Errorf("the old URL got %d requests after the restart, want none", got)
+			}
+		})
+	}
+}
+
+func TestRelocateMovesTheSourcesOfAMovedSite(t *testing.T) {
+	registry, _ := NewSourceRegistry("")
+	for _, source := range []Source{
+		{Name: "archive", URL: "https://old.example/archive"},
+		{Name: "results", URL: "https://old.example/results"},
+		{Name: "other", URL: "https://other.example/results"},
+	} {
+		if _, err := registry.add(source); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// The path stayed the same: every page of the old host moved
+	moved, err := registry.relocate(SourceMove{From: "https://old.example/results", To: "https://new.example/results"}, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(moved) != 2 || moved[0].URL != "https://new.example/archive" || moved[1].URL != "https://new.example/results" {
+		t.Errorf("moved sources = %+v, want archive and results on new.example", moved)
+	}
+	for _, source := range registry.List() {
+		if source.Name == "other" && source.URL != "https://other.example/results" {
+			t.Errorf("source of another host moved to %s", source.URL)
+		}
+	}
+
+	// A page moved to another path moves only its own source
+	moved, err = registry.relocate(SourceMove{From: "https://new.example/archive", To: "https://new.example/archive/2024"}, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(moved) != 1 || moved[0].Name != "archive" {
+		t.Errorf("moved sources = %+v, want the archive only", moved)
+	}
+}
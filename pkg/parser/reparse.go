@@ -0,0 +1,92 @@
+package parser
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"easypars/models"
+)
+
+// FieldChange is a value changed by reparsing a stored fight
+type FieldChange struct {
+	Field string `json:"field"`
+	Old   string `json:"old"`
+	New   string `json:"new"`
+}
+
+// ReparseFight parses a stored fight again from its raw source text
+// The current extraction and normalization rules are applied without any
+// request to the source. Returns false when the fight has no raw text.
+// Values that do not come from the row (confidence, hidden flag, external
+// IDs) are kept, and so is a date moved by the year consistency check,
+// which needs the whole page to be repeated.
+func (p *Parser) ReparseFight(fight models.Fight) (models.Fight, []FieldChange, bool) {
+	raw := fight.Raw
+	if raw == nil {
+		return fight, nil, false
+	}
+
+	event := FightEvent{
+		DateText:   raw.DateText,
+		Location:   raw.LocationText,
+		Fighter1:   fighterNameFromText(raw.Boxer1Text),
+		Fighter2:   fighterNameFromText(raw.Boxer2Text),
+		Result:     raw.ResultText,
+		Boxer1Text: raw.Boxer1Text,
+		Boxer2Text: raw.Boxer2Text,
+
+		Fighter1Record: fighterRecordFromText(raw.Boxer1Text),
+		Fighter2Record: fighterRecordFromText(raw.Boxer2Text),
+	}
+	parsed := convertEventToFight(event, p.reparseRef(fight))
+	normalized, _, _ := normalizeStage{}.Process(context.Background(), []models.Fight{parsed})
+	parsed = normalized[0]
+
+	reparsed := fight
+	reparsed.Fighter1 = parsed.Fighter1
+	reparsed.Fighter2 = parsed.Fighter2
+	reparsed.Result = parsed.Result
+	reparsed.ResultType = parsed.ResultType
+	reparsed.Round = parsed.Round
+	reparsed.Location = parsed.Location
+	reparsed.Fighter1Record = parsed.Fighter1Record
+	reparsed.Fighter2Record = parsed.Fighter2Record
+	if !fight.YearAdjusted {
+		reparsed.Date = parsed.Date
+	}
+	reparsed.AssignKey()
+	reparsed.Status = p.resolveStatus(reparsed)
+
+	var changes []FieldChange
+	compare := func(field, before, after string) {
+		if before != after {
+			changes = append(changes, FieldChange{Field: field, Old: before, New: after})
+		}
+	}
+	compare("date", fight.Date, reparsed.Date)
+	compare("fighter1", fight.Fighter1, reparsed.Fighter1)
+	compare("fighter2", fight.Fighter2, reparsed.Fighter2)
+	compare("result", fight.Result, reparsed.Result)
+	compare("result_type", fight.ResultType, reparsed.ResultType)
+	compare("round", strconv.Itoa(fight.Round), strconv.Itoa(reparsed.Round))
+	compare("location", fight.Location, reparsed.Location)
+	compare("status", fight.Status, reparsed.Status)
+	compare("fighter1_record", fight.Fighter1Record.String(), reparsed.Fighter1Record.String())
+	compare("fighter2_record", fight.Fighter2Record.String(), reparsed.Fighter2Record.String())
+
+	return reparsed, changes, true
+}
+
+// reparseRef returns the reference time incomplete dates of a stored fight
+// are resolved in: the recorded month, or the month of the stored date
+func (p *Parser) reparseRef(fight models.Fight) time.Time {
+	if ref, err := time.ParseInLocation("2006-01", fight.Raw.RefMonth, p.location()); err == nil {
+		return ref
+	}
+	if date, err := time.ParseInLocation("2006-01-02", fight.Date, p.location()); err == nil {
+		return date
+	}
+
+	return p.clock().Now().In(p.location())
+}
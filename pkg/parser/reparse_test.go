@@ -0,0 +1,93 @@
+package parser
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"easypars/models"
+	"easypars/pkg/clock"
+)
+
+// reparseRaw is the source row of the stored fights of the reparse tests
+var reparseRaw = models.RawFields{
+	DateText:     "18",
+	ResultText:   "нокаут в 3 раунде",
+	LocationText: "Riyadh",
+	Boxer1Text:   "Oleksandr Usyk (22-0)",
+	Boxer2Text:   "Tyson Fury (34-0-1)",
+	RefMonth:     "2024-05",
+}
+
+func TestReparseFight(t *testing.T) {
+	p := NewParser("https://vringe.example/")
+	p.Clock = clock.Fixed{Time: time.Date(2024, time.June, 10, 12, 0, 0, 0, time.UTC)}
+
+	// The fight as the current rules parse it
+	raw := reparseRaw
+	current, _, ok := p.ReparseFight(models.Fight{Raw: &raw})
+	if !ok || current.Date != "2024-05-18" || current.ResultType != models.ResultKO || current.Round != 3 {
+		t.Fatalf("ReparseFight of the raw row = %+v (%v), want a KO in round 3 on 2024-05-18", current, ok)
+	}
+
+	tests := []struct {
+		name string
+		// stored changes the current parse into the stored fight
+		stored func(*models.Fight)
+		fields []string
+	}{
+		{"parsed by the current rules", func(*models.Fight) {}, nil},
+		{
+			"parsed by an older method dictionary",
+			func(f *models.Fight) { f.ResultType, f.Round = models.ResultUnknown, 0 },
+			[]string{"result_type", "round"},
+		},
+		{
+			"name stored with the record",
+			func(f *models.Fight) { f.Fighter1 = "Oleksandr Usyk (22-0)" },
+			[]string{"fighter1"},
+		},
+		{
+			"date resolved in another month",
+			func(f *models.Fight) { f.Date = "2024-06-18" },
+			[]string{"date"},
+		},
+		{
+			"date moved by the year check",
+			func(f *models.Fight) { f.Date, f.YearAdjusted = "2023-05-18", true },
+			nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stored := current
+			tt.stored(&stored)
+			stored.AssignKey()
+
+			reparsed, changes, ok := p.ReparseFight(stored)
+			if !ok {
+				t.Fatal("ReparseFight skipped a fight with raw text")
+			}
+			var fields []string
+			for _, change := range changes {
+				fields = append(fields, change.Field)
+			}
+			if !reflect.DeepEqual(fields, tt.fields) {
+				t.Errorf("changed fields = %q, want %q", fields, tt.fields)
+			}
+			if len(tt.fields) > 0 && !stored.YearAdjusted && reparsed.Key != current.Key {
+				t.Errorf("key = %s, want %s", reparsed.Key, current.Key)
+			}
+		})
+	}
+}
+
+func TestReparseFightWithoutRawIsSkipped(t *testing.T) {
+	p := NewParser("https://vringe.example/")
+	fight := models.Fight{Date: "2024-05-18", Fighter1: "Oleksandr Usyk", Fighter2: "Tyson Fury", Result: "SD"}
+
+	reparsed, changes, ok := p.ReparseFight(fight)
+	if ok || changes != nil || !reflect.DeepEqual(reparsed, fight) {
+		t.Errorf("ReparseFight without raw text = %+v, %+v, %v; want the fight unchanged and skipped", reparsed, changes, ok)
+	}
+}
@@ -0,0 +1,77 @@
+package parser
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+
+	"easypars/models"
+)
+
+// maxRound bounds the round numbers accepted from a result text
+const maxRound = 15
+
+// resultRule maps result spellings to a result type
+// The source is Russian, so both the English abbreviations and the Russian
+// words and abbreviations are listed.
+type resultRule struct {
+	resultType string
+	pattern    *regexp.Regexp
+}
+
+// resultWords builds a case-insensitive pattern matching any of the words
+// as a whole word; Go's \b only knows ASCII letters, so the boundaries are
+// spelled out to cover Cyrillic
+func resultWords(words ...string) *regexp.Regexp {
+	return regexp.MustCompile(`(?i)(?:^|[^\p{L}])(?:` + strings.Join(words, "|") + `)(?:[^\p{L}]|$)`)
+}
+
+// resultRules are tried in order, so the more specific spellings come
+// first: a technical knockout before a knockout and a draw before the
+// decision it was scored by ("SD draw")
+var resultRules = []resultRule{
+	{models.ResultNC, resultWords(`nc`, `no contest`, `не состоялся`, `признан несостоявшимся`, `без результата`)},
+	{models.ResultDraw, resultWords(`draw`, `ничья`, `ничьей`, `ничью`)},
+	{models.ResultTKO, resultWords(`tko`, `rtd`, `тко`, `технический нокаут`, `техническим нокаутом`, `тех\. ?нокаут\p{L}*`, `отказ\p{L}*`)},
+	{models.ResultKO, resultWords(`ko`, `ко`, `нокаут\p{L}*`)},
+	{models.ResultUD, resultWords(`ud`, `ер`, `единогласн\p{L}*`, `unanimous`)},
+	{models.ResultSD, resultWords(`sd`, `рр`, `раздельн\p{L}*`, `split`)},
+	{models.ResultMD, resultWords(`md`, `большинств\p{L}*`, `majority`)},
+}
+
+// roundPattern matches the numbers of a result text
+var roundPattern = regexp.MustCompile(`\d+`)
+
+// classifyResult derives the result type and the round from the vs cell
+// text ("UD 12", "TKO 5", "нокаут в 3 раунде")
+// Pending and cancelled texts follow isPendingResult and isCancelledResult,
+// texts in no known format give ResultUnknown. The round is the first
+// number of the text within 1..maxRound, so "KO 3 (12)" gives 3.
+func classifyResult(result string) (string, int) {
+	text := cleanText(result)
+	switch {
+	case isPendingResult(text):
+		return models.ResultScheduled, 0
+	case isCancelledResult(text):
+		return models.ResultCancelled, 0
+	}
+
+	for _, rule := range resultRules {
+		if rule.pattern.MatchString(text) {
+			return rule.resultType, resultRound(text)
+		}
+	}
+
+	return models.ResultUnknown, 0
+}
+
+// resultRound returns the first plausible round number of a result text
+func resultRound(text string) int {
+	for _, number := range roundPattern.FindAllString(text, -1) {
+		if round, err := strconv.Atoi(number); err == nil && round >= 1 && round <= maxRound {
+			return round
+		}
+	}
+
+	return 0
+}
@@ -0,0 +1,72 @@
+package parser
+
+import (
+	"testing"
+	"time"
+
+	"easypars/models"
+)
+
+func TestClassifyResult(t *testing.T) {
+	tests := []struct {
+		result     string
+		resultType string
+		round      int
+	}{
+		// English abbreviations
+		{"KO 3", models.ResultKO, 3},
+		{"TKO 5", models.ResultTKO, 5},
+		{"RTD 7", models.ResultTKO, 7},
+		{"UD 12", models.ResultUD, 12},
+		{"SD", models.ResultSD, 0},
+		{"MD 12", models.ResultMD, 12},
+		{"Draw", models.ResultDraw, 0},
+		{"SD draw 12", models.ResultDraw, 12},
+		{"NC 2", models.ResultNC, 2},
+		{"KO 3 (12)", models.ResultKO, 3},
+		{"UD 2024", models.ResultUD, 0},
+		// Russian spellings of the source
+		{"нокаут в 3 раунде", models.ResultKO, 3},
+		{"КО 4", models.ResultKO, 4},
+		{"технический нокаут в 9 раунде", models.ResultTKO, 9},
+		{"тех. нокаутом, 6", models.ResultTKO, 6},
+		{"ТКО 10", models.ResultTKO, 10},
+		{"отказ от продолжения боя, 8", models.ResultTKO, 8},
+		{"единогласным решением", models.ResultUD, 0},
+		{"ЕР 12", models.ResultUD, 12},
+		{"раздельным решением судей", models.ResultSD, 0},
+		{"РР", models.ResultSD, 0},
+		{"решением большинства", models.ResultMD, 0},
+		{"ничья", models.ResultDraw, 0},
+		{"бой признан несостоявшимся", models.ResultNC, 0},
+		// Pending, cancelled and unknown texts
+		{"vs", models.ResultScheduled, 0},
+		{"", models.ResultScheduled, 0},
+		{"—", models.ResultScheduled, 0},
+		{"бой отменён", models.ResultCancelled, 0},
+		{"Cancelled", models.ResultCancelled, 0},
+		{"победа", models.ResultUnknown, 0},
+		{"Kookaburra", models.ResultUnknown, 0},
+		{"кокон", models.ResultUnknown, 0},
+	}
+	for _, tt := range tests {
+		resultType, round := classifyResult(tt.result)
+		if resultType != tt.resultType || round != tt.round {
+			t.Errorf("classifyResult(%q) = %s, %d; want %s, %d", tt.result, resultType, round, tt.resultType, tt.round)
+		}
+	}
+}
+
+func TestConvertedFightKeepsTheRawResult(t *testing.T) {
+	ref := time.Date(2024, time.May, 1, 0, 0, 0, 0, time.UTC)
+	for _, result := range []string{"нокаут в 3 раунде", "победа по очкам"} {
+		fight := convertEventToFight(FightEvent{DateText: "18", Fighter1: "Usyk", Fighter2: "Fury", Result: result}, ref)
+		if fight.Result != result || fight.Raw.ResultText != result {
+			t.Errorf("fight of %q has result %q and raw %q, want the text kept", result, fight.Result, fight.Raw.ResultText)
+		}
+		resultType, round := classifyResult(result)
+		if fight.ResultType != resultType || fight.Round != round {
+			t.Errorf("fight of %q = %s, %d; want %s, %d", result, fight.ResultType, fight.Round, resultType, round)
+		}
+	}
+}
@@ -0,0 +1,126 @@
+package parser
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"net/http"
+	"time"
+
+	"easypars/pkg/tracing"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Retry bounds
+const (
+	// DefaultRetryBaseDelay is the first backoff delay when none is configured
+	DefaultRetryBaseDelay = 500 * time.Millisecond
+	// maxRetryDelay caps the backoff; a 429 whose pause lasts longer is
+	// not retried and leaves the source paused
+	maxRetryDelay = 30 * time.Second
+)
+
+// StatusError is an unexpected HTTP status of the source
+type StatusError struct {
+	URL        string
+	StatusCode int
+}
+
+// Error describes the status
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("unexpected status code %d from %s", e.StatusCode, e.URL)
+}
+
+// fetchHTMLDocument downloads the page and returns its raw body
+// The raw body is kept so HTML comments can be inspected before building the DOM.
+// Network errors, 5xx and 429 responses are retried up to RetryAttempts
+// times with exponential backoff and jitter; other statuses fail at once.
+// A 429 response pauses the source (see pauseSource), so its retry waits
+// for the end of the pause, which honors Retry-After; a pause longer than
+// maxRetryDelay ends the retries, and so does any pause of an interactive
+// request.
+func (p *Parser) fetchHTMLDocument(ctx context.Context, url string) (body []byte, err error) {
+	ctx, span := tracing.Tracer().Start(ctx, "parser.fetchHTMLDocument", trace.WithSpanKind(trace.SpanKindClient))
+	span.SetAttributes(attribute.String("url.full", url))
+	attempts := 0
+	defer func() {
+		span.SetAttributes(attribute.Int("easypars.fetch_attempts", attempts), attribute.Int("easypars.body_bytes", len(body)))
+		tracing.End(span, err)
+	}()
+
+	for attempt := 0; ; attempt++ {
+		attempts++
+		body, err = p.fetchOnce(ctx, url)
+		if err == nil {
+			return body, nil
+		}
+		if attempt >= p.RetryAttempts {
+			return nil, err
+		}
+
+		delay, ok := p.retryDelay(ctx, attempt, err)
+		if !ok {
+			return nil, err
+		}
+		p.logger().WarnContext(ctx, "Fetch failed, retrying",
+			"url", url,
+			"attempt", attempt+1,
+			"delay", delay.String(),
+			"error", err)
+
+		if err := p.sleepFor(ctx, delay); err != nil {
+			return nil, cancelledError(ctx, url)
+		}
+	}
+}
+
+// retryDelay returns the wait before the next attempt, false when err is
+// not worth retrying
+func (p *Parser) retryDelay(ctx context.Context, attempt int, err error) (time.Duration, bool) {
+	if ctx.Err() != nil {
+		return 0, false
+	}
+
+	// Interactive requests never wait for the source pause
+	if errors.Is(err, ErrRateLimited) {
+		if isInteractive(ctx) {
+			return 0, false
+		}
+		wait := max(p.pause.Until().Sub(p.clock().Now()), 0)
+		return wait, wait <= maxRetryDelay
+	}
+
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) {
+		if statusErr.StatusCode < http.StatusInternalServerError {
+			return 0, false
+		}
+		return p.backoff(attempt), true
+	}
+
+	if origin, _ := OriginOf(err); origin == ErrorOriginNetwork {
+		return p.backoff(attempt), true
+	}
+
+	return 0, false
+}
+
+// backoff returns the exponential delay of an attempt with jitter
+// The delay doubles per attempt from RetryBaseDelay up to maxRetryDelay,
+// and a random half of it is dropped, so parsers restarted together do not
+// retry in lockstep.
+func (p *Parser) backoff(attempt int) time.Duration {
+	delay := p.RetryBaseDelay
+	if delay <= 0 {
+		delay = DefaultRetryBaseDelay
+	}
+	for i := 0; i < attempt && delay < maxRetryDelay; i++ {
+		delay *= 2
+	}
+	delay = min(delay, maxRetryDelay)
+
+	return delay/2 + rand.N(delay/2+1)
+}
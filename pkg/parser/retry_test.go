@@ -0,0 +1,255 @@
+package parser
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// fakeClock is a parser clock whose sleeps advance it at once, recording
+// the requested delays
+type fakeClock struct {
+	mu    sync.Mutex
+	now   time.Time
+	slept []time.Duration
+	// sleepsOnly records the sleeps without advancing the clock
+	sleepsOnly bool
+}
+
+func newFakeClock() *fakeClock {
+	return &fakeClock{now: time.Date(2024, time.June, 10, 12, 0, 0, 0, time.UTC)}
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) Sleep(ctx context.Context, d time.Duration) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.sleepsOnly {
+		c.now = c.now.Add(d)
+	}
+	c.slept = append(c.slept, d)
+	return nil
+}
+
+func (c *fakeClock) Slept() []time.Duration {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]time.Duration(nil), c.slept...)
+}
+
+// scriptedResponse is a response of scriptedSource
+type scriptedResponse struct {
+	status     int
+	retryAfter string
+}
+
+// scriptedSource answers the requests with the responses in order,
+// repeating the last one, and counts the requests
+type scriptedSource struct {
+	*httptest.Server
+	hits atomic.Int32
+}
+
+func newScriptedSource(t *testing.T, responses ...scriptedResponse) *scriptedSource {
+	t.Helper()
+
+	src := &scriptedSource{}
+	src.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := int(src.hits.Add(1))
+		response := responses[min(n, len(responses))-1]
+		if response.retryAfter != "" {
+			w.Header().Set("Retry-After", response.retryAfter)
+		}
+		w.WriteHeader(response.status)
+		io.WriteString(w, "<html><body>page</body></html>")
+	}))
+	t.Cleanup(src.Close)
+
+	return src
+}
+
+// newRetryParser returns a parser of the source retrying up to attempts
+// times, sleeping on the fake clock
+func newRetryParser(src *scriptedSource, attempts int, clk *fakeClock) *Parser {
+	p := NewParser(src.URL + "/")
+	p.RetryAttempts = attempts
+	p.RetryBaseDelay = time.Second
+	p.Clock = clk
+	p.sleep = clk.Sleep
+	p.Logger = slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	return p
+}
+
+func TestFetchRetries(t *testing.T) {
+	tests := []struct {
+		name      string
+		responses []scriptedResponse
+		attempts  int
+		hits      int32
+		status    int
+		// delays are the bounds of every backoff, [base/2, base] doubling
+		// per attempt
+		delays [][2]time.Duration
+	}{
+		{
+			name:      "5xx then success",
+			responses: []scriptedResponse{{status: 503}, {status: 502}, {status: 200}},
+			attempts:  3,
+			hits:      3,
+			delays:    [][2]time.Duration{{500 * time.Millisecond, time.Second}, {time.Second, 2 * time.Second}},
+		},
+		{
+			name:      "5xx until the attempts are used up",
+			responses: []scriptedResponse{{status: 500}},
+			attempts:  2,
+			hits:      3,
+			status:    500,
+			delays:    [][2]time.Duration{{500 * time.Millisecond, time.Second}, {time.Second, 2 * time.Second}},
+		},
+		{
+			name:      "404 is not retried",
+			responses: []scriptedResponse{{status: 404}, {status: 200}},
+			attempts:  3,
+			hits:      1,
+			status:    404,
+		},
+		{
+			name:      "403 is not retried",
+			responses: []scriptedResponse{{status: 403}, {status: 200}},
+			attempts:  3,
+			hits:      1,
+			status:    403,
+		},
+		{
+			name:      "retries disabled",
+			responses: []scriptedResponse{{status: 503}, {status: 200}},
+			attempts:  0,
+			hits:      1,
+			status:    503,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			src := newScriptedSource(t, tt.responses...)
+			clk := newFakeClock()
+			p := newRetryParser(src, tt.attempts, clk)
+
+			_, err := p.fetchHTMLDocument(context.Background(), src.URL+"/")
+			var statusErr *StatusError
+			switch {
+			case tt.status == 0 && err != nil:
+				t.Errorf("fetch = %v, want success", err)
+			case tt.status != 0 && (!errors.As(err, &statusErr) || statusErr.StatusCode != tt.status):
+				t.Errorf("fetch = %v, want status %d", err, tt.status)
+			}
+			if hits := src.hits.Load(); hits != tt.hits {
+				t.Errorf("the source got %d requests, want %d", hits, tt.hits)
+			}
+
+			slept := clk.Slept()
+			if len(slept) != len(tt.delays) {
+				t.Fatalf("slept %v, want %d backoffs", slept, len(tt.delays))
+			}
+			for i, bounds := range tt.delays {
+				if slept[i] < bounds[0] || slept[i] > bounds[1] {
+					t.Errorf("backoff %d = %v, want within %v", i, slept[i], bounds)
+				}
+			}
+		})
+	}
+}
+
+func TestFetchHonoursRetryAfter(t *testing.T) {
+	src := newScriptedSource(t, scriptedResponse{status: 429, retryAfter: "7"}, scriptedResponse{status: 200})
+	clk := newFakeClock()
+	p := newRetryParser(src, 3, clk)
+
+	if _, err := p.fetchHTMLDocument(context.Background(), src.URL+"/"); err != nil {
+		t.Fatalf("fetch = %v, want success after the pause", err)
+	}
+	if slept := clk.Slept(); len(slept) != 1 || slept[0] != 7*time.Second {
+		t.Errorf("slept %v, want the 7s of Retry-After", slept)
+	}
+	if hits := src.hits.Load(); hits != 2 {
+		t.Errorf("the source got %d requests, want 2", hits)
+	}
+}
+
+func TestFetchDoesNotWaitForLongRetryAfter(t *testing.T) {
+	src := newScriptedSource(t, scriptedResponse{status: 429, retryAfter: "3600"}, scriptedResponse{status: 200})
+	clk := newFakeClock()
+	p := newRetryParser(src, 3, clk)
+
+	_, err := p.fetchHTMLDocument(context.Background(), src.URL+"/")
+	if !errors.Is(err, ErrRateLimited) {
+		t.Errorf("fetch = %v, want ErrRateLimited", err)
+	}
+	if slept := clk.Slept(); len(slept) != 0 {
+		t.Errorf("slept %v, want no retry of a pause over %v", slept, maxRetryDelay)
+	}
+	if until := p.SourcePausedUntil(); !until.Equal(clk.Now().Add(time.Hour)) {
+		t.Errorf("source paused until %v, want an hour from now", until)
+	}
+}
+
+func TestFetchInteractiveDoesNotWaitForRetryAfter(t *testing.T) {
+	src := newScriptedSource(t, scriptedResponse{status: 429, retryAfter: "7"}, scriptedResponse{status: 200})
+	clk := newFakeClock()
+	p := newRetryParser(src, 3, clk)
+
+	_, err := p.fetchHTMLDocument(WithInteractive(context.Background()), src.URL+"/")
+	if !errors.Is(err, ErrRateLimited) || len(clk.Slept()) != 0 || src.hits.Load() != 1 {
+		t.Errorf("interactive fetch = %v after %d requests and the sleeps %v, want ErrRateLimited at once", err, src.hits.Load(), clk.Slept())
+	}
+}
+
+func TestFetchStopsRetryingOnCancellation(t *testing.T) {
+	src := newScriptedSource(t, scriptedResponse{status: 503})
+	p := newRetryParser(src, 5, newFakeClock())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	// The caller goes away during the first backoff
+	p.sleep = func(ctx context.Context, _ time.Duration) error {
+		cancel()
+		return ctx.Err()
+	}
+
+	_, err := p.fetchHTMLDocument(ctx, src.URL+"/")
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("fetch = %v, want context.Canceled", err)
+	}
+	if origin, _ := OriginOf(err); origin != ErrorOriginNetwork {
+		t.Errorf("origin = %q, want %q", origin, ErrorOriginNetwork)
+	}
+	if hits := src.hits.Load(); hits != 1 {
+		t.Errorf("the source got %d requests, want 1", hits)
+	}
+}
+
+func TestSleepContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := sleepContext(ctx, time.Hour); !errors.Is(err, context.Canceled) {
+		t.Errorf("sleepContext on a cancelled context = %v, want context.Canceled", err)
+	}
+	if err := sleepContext(context.Background(), time.Millisecond); err != nil {
+		t.Errorf("sleepContext = %v, want nil", err)
+	}
+}
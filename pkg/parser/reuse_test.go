@@ -0,0 +1,129 @@
+package parser
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// russianMonthNames are the month headers of the generated pages
+var russianMonthNames = [...]string{
+	"Январь", "Февраль", "Март", "Апрель", "Май", "Июнь",
+	"Июль", "Август", "Сентябрь", "Октябрь", "Ноябрь", "Декабрь",
+}
+
+// monthPage returns a results page of the month whose fighters are named
+// after the page, so fights of different pages never share a key
+func monthPage(name string, year int, month time.Month, fights int) string {
+	var page strings.Builder
+	fmt.Fprintf(&page, `<html><body><div class="month">%s %d</div><table>`, russianMonthNames[month-1], year)
+	for i := 1; i <= fights; i++ {
+		fmt.Fprintf(&page, `<tr><td class="date">%d</td><td class="place">Arena</td>`+
+			`<td class="boxer_1">%s Red %d</td><td class="vs">UD</td><td class="boxer_2">%s Blue %d</td></tr>`,
+			i, name, i, name, i)
+	}
+	page.WriteString(`</table></body></html>`)
+
+	return page.String()
+}
+
+// fightKeys returns the sorted keys of the fights
+func fightKeys(result *ParseResult) []string {
+	keys := make([]string, 0, len(result.Fights))
+	for _, fight := range result.Fights {
+		keys = append(keys, fight.Key)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
+
+// TestConcurrentParsesOnOneParser runs parses of different pages on one
+// parser at the same time; every call must get exactly the fights of its
+// own pages
+func TestConcurrentParsesOnOneParser(t *testing.T) {
+	src := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		var year, month int
+		var source string
+		switch {
+		case r.URL.Path == "/":
+			fmt.Fprint(w, monthPage("Main", 2024, time.May, 5))
+		case strings.HasPrefix(r.URL.Path, "/extra/"):
+			source = strings.TrimPrefix(r.URL.Path, "/extra/")
+			fmt.Fprint(w, monthPage("Extra"+source, 2024, time.April, 3))
+		default:
+			if _, err := fmt.Sscanf(r.URL.Path, "/archive/%d/%d", &year, &month); err != nil {
+				http.NotFound(w, r)
+				return
+			}
+			fmt.Fprint(w, monthPage(fmt.Sprintf("Month%d", month), year, time.Month(month), month))
+		}
+	}))
+	defer src.Close()
+
+	p := NewParser(src.URL + "/")
+	p.MonthURL = src.URL + "/archive/{year}/{month}"
+	p.Sources, _ = NewSourceRegistry("")
+	for i := 1; i <= 3; i++ {
+		if _, err := p.Sources.add(Source{Name: fmt.Sprintf("extra-%d", i), Type: SourceTypeResults, URL: fmt.Sprintf("%s/extra/%d", src.URL, i), Enabled: true}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// The fights every kind of call must get, from sequential calls
+	want := make(map[string][]string)
+	for month := 1; month <= 6; month++ {
+		result, err := p.ParseMonth(context.Background(), 2024, time.Month(month))
+		if err != nil {
+			t.Fatal(err)
+		}
+		want[fmt.Sprint(month)] = fightKeys(result)
+	}
+	result, err := p.ParseAll(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	want["all"] = fightKeys(result)
+	if len(want["all"]) != 5+3*3 {
+		t.Fatalf("ParseAll got %d fights, want 14", len(want["all"]))
+	}
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 100)
+	for round := 0; round < 8; round++ {
+		for call := range want {
+			wg.Add(1)
+			go func(call string) {
+				defer wg.Done()
+				var result *ParseResult
+				var err error
+				if call == "all" {
+					result, err = p.ParseAll(context.Background())
+				} else {
+					var month int
+					fmt.Sscan(call, &month)
+					result, err = p.ParseMonth(context.Background(), 2024, time.Month(month))
+				}
+				switch {
+				case err != nil:
+					errs <- fmt.Errorf("%s: %v", call, err)
+				case !reflect.DeepEqual(fightKeys(result), want[call]):
+					errs <- fmt.Errorf("%s: got fights %v, want %v", call, fightKeys(result), want[call])
+				}
+			}(call)
+		}
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+}
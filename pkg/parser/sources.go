@@ -0,0 +1,570 @@
+package parser
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/netip"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"easypars/models"
+	"easypars/pkg/netguard"
+	"easypars/pkg/pipeline"
+	"easypars/pkg/tracing"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// Source types
+const (
+	// SourceTypeResults is a page with a results table in the vringe layout
+	SourceTypeResults = "results"
+)
+
+// IssueSourceFailed is the issue code of an extra source that failed in ParseAll
+const IssueSourceFailed = "source_failed"
+
+// Source registry errors
+var (
+	// ErrInvalidSource is returned for a source that fails validation
+	ErrInvalidSource = errors.New("invalid source")
+	// ErrSourceExists is returned when adding a source under a taken name
+	ErrSourceExists = errors.New("source already exists")
+	// ErrSourceNotFound is returned for an unknown source name
+	ErrSourceNotFound = errors.New("source not found")
+)
+
+// sourceNamePattern restricts source names to URL friendly slugs
+var sourceNamePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,63}$`)
+
+// Source is an additional page parsed next to the main one
+type Source struct {
+	Name      string    `json:"name"`
+	Type      string    `json:"type"`
+	URL       string    `json:"url"`
+	Enabled   bool      `json:"enabled"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// SourcePatch holds the changes of a source, nil fields are left as they are
+type SourcePatch struct {
+	URL     *string `json:"url"`
+	Enabled *bool   `json:"enabled"`
+}
+
+// SourceStatus is a source with the outcome of its recent runs
+type SourceStatus struct {
+	Source
+	LastSuccess *time.Time `json:"last_success,omitempty"`
+	LastError   string     `json:"last_error,omitempty"`
+	LastErrorAt *time.Time `json:"last_error_at,omitempty"`
+	// Errors is the total number of failed runs since start
+	Errors int64 `json:"errors"`
+	// ConsecutiveErrors is reset by a successful run
+	ConsecutiveErrors int `json:"consecutive_errors"`
+}
+
+// sourceHealth holds the run outcome of a source, kept in memory only
+type sourceHealth struct {
+	lastSuccess       time.Time
+	lastError         string
+	lastErrorAt       time.Time
+	errors            int64
+	consecutiveErrors int
+}
+
+// SourceRegistry holds the extra sources managed at runtime
+// It is safe for concurrent use. Runs work on a copy of the list, so
+// changes made during a run apply from the next run on. When a file path
+// is set, sources are saved after every change and loaded on start.
+type SourceRegistry struct {
+	mu      sync.RWMutex
+	path    string
+	sources map[string]Source
+	health  map[string]*sourceHealth
+}
+
+// NewSourceRegistry creates a registry, loading saved sources from path
+func NewSourceRegistry(path string) (*SourceRegistry, error) {
+	r := &SourceRegistry{
+		path:    path,
+		sources: make(map[string]Source),
+		health:  make(map[string]*sourceHealth),
+	}
+
+	if path != "" {
+		if err := r.load(); err != nil {
+			return nil, err
+		}
+	}
+
+	return r, nil
+}
+
+// List returns a copy of the registered sources sorted by name
+func (r *SourceRegistry) List() []Source {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	sources := make([]Source, 0, len(r.sources))
+	for _, source := range r.sources {
+		sources = append(sources, source)
+	}
+	sort.Slice(sources, func(i, j int) bool { return sources[i].Name < sources[j].Name })
+
+	return sources
+}
+
+// Statuses returns the registered sources with their run outcome
+func (r *SourceRegistry) Statuses() []SourceStatus {
+	sources := r.List()
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	statuses := make([]SourceStatus, 0, len(sources))
+	for _, source := range sources {
+		status := SourceStatus{Source: source}
+		if health, ok := r.health[source.Name]; ok {
+			if !health.lastSuccess.IsZero() {
+				lastSuccess := health.lastSuccess
+				status.LastSuccess = &lastSuccess
+			}
+			if !health.lastErrorAt.IsZero() {
+				lastErrorAt := health.lastErrorAt
+				status.LastErrorAt = &lastErrorAt
+			}
+			status.LastError = health.lastError
+			status.Errors = health.errors
+			status.ConsecutiveErrors = health.consecutiveErrors
+		}
+		statuses = append(statuses, status)
+	}
+
+	return statuses
+}
+
+// add registers a validated source
+func (r *SourceRegistry) add(source Source) (Source, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.sources[source.Name]; ok {
+		return Source{}, fmt.Errorf("%w: %s", ErrSourceExists, source.Name)
+	}
+
+	r.sources[source.Name] = source
+	if err := r.save(); err != nil {
+		delete(r.sources, source.Name)
+		return Source{}, err
+	}
+
+	return source, nil
+}
+
+// update applies a validated patch to a source
+func (r *SourceRegistry) update(name string, patch SourcePatch, now time.Time) (Source, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	previous, ok := r.sources[name]
+	if !ok {
+		return Source{}, fmt.Errorf("%w: %s", ErrSourceNotFound, name)
+	}
+
+	source := previous
+	if patch.URL != nil {
+		source.URL = *patch.URL
+	}
+	if patch.Enabled != nil {
+		source.Enabled = *patch.Enabled
+	}
+	source.UpdatedAt = now
+
+	r.sources[name] = source
+	if err := r.save(); err != nil {
+		r.sources[name] = previous
+		return Source{}, err
+	}
+
+	return source, nil
+}
+
+// relocate points the sources reached through a permanent move at its new
+// location and returns the moved sources
+// A move of a whole site (same path on a new host) moves every source of
+// the old host.
+func (r *SourceRegistry) relocate(move SourceMove, now time.Time) ([]Source, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var moves relocations
+	moves.record(move.From, move.To)
+
+	previous := make(map[string]Source)
+	var moved []Source
+	for name, source := range r.sources {
+		if target := moves.resolve(source.URL); target != source.URL {
+			previous[name] = source
+			source.URL = target
+			source.UpdatedAt = now
+			r.sources[name] = source
+			moved = append(moved, source)
+		}
+	}
+	if len(moved) == 0 {
+		return nil, nil
+	}
+	if err := r.save(); err != nil {
+		for name, source := range previous {
+			r.sources[name] = source
+		}
+		return nil, err
+	}
+	sort.Slice(moved, func(i, j int) bool { return moved[i].Name < moved[j].Name })
+
+	return moved, nil
+}
+
+// Remove deletes a source from the registry
+func (r *SourceRegistry) Remove(name string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	previous, ok := r.sources[name]
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrSourceNotFound, name)
+	}
+
+	delete(r.sources, name)
+	if err := r.save(); err != nil {
+		r.sources[name] = previous
+		return err
+	}
+	delete(r.health, name)
+
+	return nil
+}
+
+// recordRun stores the outcome of a source run
+func (r *SourceRegistry) recordRun(name string, at time.Time, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	health, ok := r.health[name]
+	if !ok {
+		health = &sourceHealth{}
+		r.health[name] = health
+	}
+
+	if err == nil {
+		health.lastSuccess = at
+		health.consecutiveErrors = 0
+		return
+	}
+
+	health.lastError = err.Error()
+	health.lastErrorAt = at
+	health.errors++
+	health.consecutiveErrors++
+}
+
+// load reads sources from the file
+func (r *SourceRegistry) load() error {
+	data, err := os.ReadFile(r.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("error reading sources file: %w", err)
+	}
+
+	var saved []Source
+	if err := json.Unmarshal(data, &saved); err != nil {
+		return fmt.Errorf("error decoding sources file: %w", err)
+	}
+	for _, source := range saved {
+		r.sources[source.Name] = source
+	}
+
+	return nil
+}
+
+// save writes all sources to the file atomically
+// The caller must hold the lock
+func (r *SourceRegistry) save() error {
+	if r.path == "" {
+		return nil
+	}
+
+	saved := make([]Source, 0, len(r.sources))
+	for _, source := range r.sources {
+		saved = append(saved, source)
+	}
+	sort.Slice(saved, func(i, j int) bool { return saved[i].Name < saved[j].Name })
+
+	data, err := json.MarshalIndent(saved, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error encoding sources: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(r.path), ".sources-*.tmp")
+	if err != nil {
+		return fmt.Errorf("error saving sources: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("error saving sources: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("error saving sources: %w", err)
+	}
+
+	if err := os.Rename(tmp.Name(), r.path); err != nil {
+		return fmt.Errorf("error saving sources: %w", err)
+	}
+
+	return nil
+}
+
+// AddSource validates a source and adds it to the registry
+// The source is used from the next run on
+func (p *Parser) AddSource(ctx context.Context, source Source) (Source, error) {
+	if p.Sources == nil {
+		return Source{}, fmt.Errorf("source registry is not configured")
+	}
+
+	source.Name = strings.TrimSpace(source.Name)
+	source.URL = strings.TrimSpace(source.URL)
+	if source.Type == "" {
+		source.Type = SourceTypeResults
+	}
+
+	if !sourceNamePattern.MatchString(source.Name) {
+		return Source{}, fmt.Errorf("%w: name must be 1-64 lowercase letters, digits, '-' or '_'", ErrInvalidSource)
+	}
+	if source.Type != SourceTypeResults {
+		return Source{}, fmt.Errorf("%w: unsupported type %q", ErrInvalidSource, source.Type)
+	}
+	if err := p.ValidateSourceURL(ctx, source.URL); err != nil {
+		return Source{}, err
+	}
+
+	now := p.clock().Now()
+	source.CreatedAt = now
+	source.UpdatedAt = now
+
+	return p.Sources.add(source)
+}
+
+// UpdateSource validates and applies changes to a registered source
+func (p *Parser) UpdateSource(ctx context.Context, name string, patch SourcePatch) (Source, error) {
+	if p.Sources == nil {
+		return Source{}, fmt.Errorf("source registry is not configured")
+	}
+
+	if patch.URL != nil {
+		trimmed := strings.TrimSpace(*patch.URL)
+		if err := p.ValidateSourceURL(ctx, trimmed); err != nil {
+			return Source{}, err
+		}
+		patch.URL = &trimmed
+	}
+
+	return p.Sources.update(name, patch, p.clock().Now())
+}
+
+// ValidateSourceURL checks that a source URL is safe to request
+// Hosts listed in AllowedHosts are trusted. When AllowedHosts is set, no
+// other host is accepted; otherwise the host must resolve to public
+// addresses only (see netguard.Blocked), so the parser cannot be turned
+// against internal services. The addresses are checked again whenever a
+// source is fetched (see newTransport), as the host may resolve to other
+// addresses later.
+func (p *Parser) ValidateSourceURL(ctx context.Context, rawURL string) error {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidSource, err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("%w: url must use http or https", ErrInvalidSource)
+	}
+	host := u.Hostname()
+	if host == "" {
+		return fmt.Errorf("%w: url has no host", ErrInvalidSource)
+	}
+	if u.User != nil {
+		return fmt.Errorf("%w: url must not contain credentials", ErrInvalidSource)
+	}
+
+	for _, allowed := range p.AllowedHosts {
+		if strings.EqualFold(allowed, host) {
+			return nil
+		}
+	}
+	if len(p.AllowedHosts) > 0 {
+		return fmt.Errorf("%w: host %s is not in the allowed hosts", ErrInvalidSource, host)
+	}
+
+	addrs, err := net.DefaultResolver.LookupIPAddr(ctx, host)
+	if err != nil {
+		return fmt.Errorf("%w: cannot resolve %s: %v", ErrInvalidSource, host, err)
+	}
+	for _, addr := range addrs {
+		if ip, ok := netip.AddrFromSlice(addr.IP); !ok || netguard.Blocked(ip) {
+			return fmt.Errorf("%w: host %s resolves to a non-public address %s", ErrInvalidSource, host, addr.IP)
+		}
+	}
+
+	return nil
+}
+
+// sourceHosts returns the hosts of the registered sources
+func (p *Parser) sourceHosts() []string {
+	if p.Sources == nil {
+		return nil
+	}
+
+	var hosts []string
+	for _, source := range p.Sources.List() {
+		if u, err := url.Parse(source.URL); err == nil && u.Hostname() != "" {
+			hosts = append(hosts, u.Hostname())
+		}
+	}
+
+	return hosts
+}
+
+// ParseAll parses the main page and every enabled extra source
+// The main page must succeed; a failing extra source is reported as an
+// issue and recorded in its status without failing the run. A fight found
+// on several pages is kept from the first one (the main page wins).
+// The run works on a copy of the source list taken at its start.
+func (p *Parser) ParseAll(ctx context.Context) (*ParseResult, error) {
+	primary, err := p.ParseDetailed(ctx)
+	if err != nil || p.Sources == nil {
+		return primary, err
+	}
+
+	sources := p.Sources.List()
+	result := *primary
+	result.Fights = append([]models.Fight(nil), primary.Fights...)
+	result.Issues = append([]ParseIssue(nil), primary.Issues...)
+
+	seen := make(map[string]bool, len(result.Fights))
+	for _, fight := range result.Fights {
+		seen[fight.Key] = true
+	}
+
+	// Fetch the sources on the source pool; results are merged afterwards
+	// in the order of the list, so the outcome does not depend on timing
+	pages := p.fetchSources(ctx, sources)
+	for _, page := range pages {
+		if !page.attempted {
+			continue
+		}
+		if page.err != nil {
+			result.Issues = append(result.Issues, ParseIssue{
+				Stage:   "source",
+				Code:    IssueSourceFailed,
+				Message: fmt.Sprintf("source %s failed: %v", page.name, page.err),
+			})
+			continue
+		}
+
+		extra := page.result
+		for _, fight := range extra.Fights {
+			if seen[fight.Key] {
+				continue
+			}
+			seen[fight.Key] = true
+			result.Fights = append(result.Fights, fight)
+		}
+		result.Issues = append(result.Issues, extra.Issues...)
+	}
+
+	return &result, nil
+}
+
+// DefaultSourceWorkers is the number of extra sources fetched at the same time
+const DefaultSourceWorkers = 4
+
+// sourcePage is the outcome of fetching one extra source
+type sourcePage struct {
+	name      string
+	attempted bool
+	result    *ParseResult
+	err       error
+}
+
+// fetchSources parses the enabled result sources on the source pool
+// Once a source reports the pause of the source site, sources not started
+// yet are skipped, like the pages of a sequential run after the pause.
+func (p *Parser) fetchSources(ctx context.Context, sources []Source) []sourcePage {
+	ctx, span := tracing.Tracer().Start(ctx, "parser.fetchSources")
+	defer span.End()
+	span.SetAttributes(attribute.Int("easypars.source_count", len(sources)))
+
+	pool := p.sourceWorkerPool()
+	ref := p.clock().Now().In(p.location())
+	pages := make([]sourcePage, len(sources))
+
+	var paused atomic.Bool
+	var wg sync.WaitGroup
+	for i, source := range sources {
+		if !source.Enabled || source.Type != SourceTypeResults {
+			continue
+		}
+
+		page := &pages[i]
+		page.name = source.Name
+		wg.Add(1)
+		err := pool.Submit(ctx, func(ctx context.Context) error {
+			defer wg.Done()
+			if paused.Load() {
+				return nil
+			}
+
+			page.attempted = true
+			page.result, page.err = p.parsePage(withGuardedFetch(ctx), source.URL, ref, true)
+			p.Sources.recordRun(source.Name, p.clock().Now(), page.err)
+			if errors.Is(page.err, ErrSourcePaused) || errors.Is(page.err, ErrRateLimited) {
+				paused.Store(true)
+			}
+			return page.err
+		})
+		if err != nil {
+			wg.Done()
+			page.attempted, page.err = true, err
+		}
+	}
+	wg.Wait()
+
+	return pages
+}
+
+// sourceWorkerPool returns the pool extra sources are fetched on
+func (p *Parser) sourceWorkerPool() *pipeline.Pool {
+	p.sourcePoolOnce.Do(func() {
+		workers := p.SourceWorkers
+		if workers <= 0 {
+			workers = DefaultSourceWorkers
+		}
+		p.sourcePool = pipeline.NewPool("sources", workers, workers*2)
+	})
+
+	return p.sourcePool
+}
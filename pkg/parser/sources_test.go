@@ -0,0 +1,69 @@
+package parser
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestParseAllReturnsPartialResultsAtDeadline checks that a source that
+// never answers cannot hold a run past the deadline of its context: the run
+// returns in time with the fights of the other pages and an issue for the
+// source
+func TestParseAllReturnsPartialResultsAtDeadline(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+	src := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		switch r.URL.Path {
+		case "/hang":
+			select {
+			case <-r.Context().Done():
+			case <-release:
+			}
+		case "/extra":
+			fmt.Fprint(w, monthPage("Extra", 2024, time.April, 2))
+		default:
+			fmt.Fprint(w, monthPage("Main", 2024, time.May, 3))
+		}
+	}))
+	defer src.Close()
+
+	p := NewParser(src.URL + "/")
+	p.Sources, _ = NewSourceRegistry("")
+	p.Sources.add(Source{Name: "hang", Type: SourceTypeResults, URL: src.URL + "/hang", Enabled: true})
+	p.Sources.add(Source{Name: "extra", Type: SourceTypeResults, URL: src.URL + "/extra", Enabled: true})
+
+	const timeout = 300 * time.Millisecond
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	start := time.Now()
+	result, err := p.ParseAll(ctx)
+	elapsed := time.Since(start)
+
+	if err != nil {
+		t.Fatalf("ParseAll: %v", err)
+	}
+	if elapsed > timeout+time.Second {
+		t.Errorf("ParseAll returned after %v, the deadline was %v", elapsed, timeout)
+	}
+	if len(result.Fights) != 3+2 {
+		t.Errorf("got %d fights, want the 5 of the main page and the answering source", len(result.Fights))
+	}
+	failed := 0
+	for _, issue := range result.Issues {
+		if issue.Code == IssueSourceFailed {
+			failed++
+			if !strings.Contains(issue.Message, "hang") {
+				t.Errorf("issue %q is not about the hanging source", issue.Message)
+			}
+		}
+	}
+	if failed != 1 {
+		t.Errorf("got %d source failures, want 1: %+v", failed, result.Issues)
+	}
+}
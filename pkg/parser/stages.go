@@ -0,0 +1,152 @@
+package parser
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"easypars/models"
+)
+
+// normalizeStage cleans up fighter names and locations and refreshes keys
+type normalizeStage struct{}
+
+// Name identifies the stage
+func (normalizeStage) Name() string { return "normalize" }
+
+// Process collapses whitespace, strips leftover record text and stray
+// punctuation from names and recomputes the natural key
+func (normalizeStage) Process(_ context.Context, fights []models.Fight) ([]models.Fight, []ParseIssue, error) {
+	result := make([]models.Fight, len(fights))
+	for i, fight := range fights {
+		fight.Fighter1 = normalizeFighterName(fight.Fighter1)
+		fight.Fighter2 = normalizeFighterName(fight.Fighter2)
+		fight.Location = strings.Trim(cleanText(fight.Location), " ,;")
+		fight.Result = cleanText(fight.Result)
+		fight.AssignKey()
+		result[i] = fight
+	}
+
+	return result, nil, nil
+}
+
+// normalizeFighterName removes record remnants and punctuation around a name
+func normalizeFighterName(name string) string {
+	name = cleanText(name)
+	if idx := strings.Index(name, "("); idx >= 0 {
+		name = strings.TrimSpace(name[:idx])
+	}
+
+	return strings.Trim(name, " ,.;:-–—*")
+}
+
+// junkFilterStage drops rows that cannot be real fights
+type junkFilterStage struct{}
+
+// Name identifies the stage
+func (junkFilterStage) Name() string { return "junk_filter" }
+
+// Process removes rows without fighter names and rows where a fighter
+// would fight himself (usually a broken header or separator row)
+func (junkFilterStage) Process(_ context.Context, fights []models.Fight) ([]models.Fight, []ParseIssue, error) {
+	result := make([]models.Fight, 0, len(fights))
+	var issues []ParseIssue
+
+	for _, fight := range fights {
+		switch {
+		case fight.Fighter1 == "" || fight.Fighter2 == "":
+			issues = append(issues, ParseIssue{
+				Code:     "missing_fighter",
+				Message:  "row has an empty fighter name",
+				FightKey: fight.Key,
+			})
+		case models.NormalizeName(fight.Fighter1) == models.NormalizeName(fight.Fighter2):
+			issues = append(issues, ParseIssue{
+				Code:     "same_fighter",
+				Message:  fmt.Sprintf("fighter %s is listed on both sides", fight.Fighter1),
+				FightKey: fight.Key,
+			})
+		default:
+			result = append(result, fight)
+		}
+	}
+
+	return result, issues, nil
+}
+
+// dedupStage merges fights sharing the same natural key
+type dedupStage struct{}
+
+// Name identifies the stage
+func (dedupStage) Name() string { return "dedup" }
+
+// Process keeps one fight per natural key, preferring visible fights and
+// higher confidence; an empty location is filled from the dropped copy
+func (dedupStage) Process(_ context.Context, fights []models.Fight) ([]models.Fight, []ParseIssue, error) {
+	result := make([]models.Fight, 0, len(fights))
+	byKey := make(map[string]int, len(fights))
+	var issues []ParseIssue
+
+	for _, fight := range fights {
+		idx, ok := byKey[fight.Key]
+		if !ok {
+			byKey[fight.Key] = len(result)
+			result = append(result, fight)
+			continue
+		}
+
+		kept := result[idx]
+		if preferFight(fight, kept) {
+			fight, kept = kept, fight
+		}
+		if kept.Location == "" {
+			kept.Location = fight.Location
+		}
+		result[idx] = kept
+
+		issues = append(issues, ParseIssue{
+			Code:     "duplicate",
+			Message:  "duplicate fight row merged",
+			FightKey: kept.Key,
+		})
+	}
+
+	return result, issues, nil
+}
+
+// preferFight reports whether candidate should replace current
+func preferFight(candidate, current models.Fight) bool {
+	if candidate.HiddenInSource != current.HiddenInSource {
+		return !candidate.HiddenInSource
+	}
+
+	return candidate.Confidence > current.Confidence
+}
+
+// validateStage checks field formats and lowers confidence of suspicious records
+type validateStage struct{}
+
+// Name identifies the stage
+func (validateStage) Name() string { return "validate" }
+
+// Process reports fights with a missing or malformed date
+// Such fights are kept but their confidence is halved
+func (validateStage) Process(_ context.Context, fights []models.Fight) ([]models.Fight, []ParseIssue, error) {
+	result := make([]models.Fight, len(fights))
+	var issues []ParseIssue
+
+	for i, fight := range fights {
+		if _, err := time.Parse("2006-01-02", fight.Date); err != nil {
+			fight.Confidence /= 2
+			issues = append(issues, ParseIssue{
+				Code:     "invalid_date",
+				Message:  fmt.Sprintf("date %q is not a valid YYYY-MM-DD date", fight.Date),
+				FightKey: fight.Key,
+			})
+		}
+		result[i] = fight
+	}
+
+	return result, issues, nil
+}
@@ -0,0 +1,78 @@
+package parser
+
+import (
+	"strings"
+	"time"
+
+	"easypars/models"
+	"easypars/pkg/clock"
+)
+
+// statusBufferDays is the zone around today in which a fight without a
+// result is always scheduled: results appear with a delay and dates near
+// midnight may be off by a day
+const statusBufferDays = 1
+
+// cancelledMarkers are vs cell fragments meaning the fight will not happen
+var cancelledMarkers = []string{
+	"отмен", "cancel", "снят",
+}
+
+// isCancelledResult reports whether the vs cell text marks a cancelled fight
+func isCancelledResult(result string) bool {
+	text := strings.ToLower(cleanText(result))
+	for _, marker := range cancelledMarkers {
+		if strings.Contains(text, marker) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ResolveStatus derives the status of a fight from its content and date
+// Explicit markers win over the date:
+//  1. a cancellation marker gives cancelled
+//  2. a result in a known format (see classifyResult) gives completed
+//  3. other text in the vs cell gives completed, unless the fight is dated
+//     today or later: stray text is no result before the fight took place
+//
+// A fight without a result is scheduled, unless its date is reliably older
+// than staleDays (and outside the one day buffer around today), in which
+// case its result is unknown. Dates with a guessed year (YearAdjusted) and
+// unparsable dates are not reliable enough to declare the result unknown.
+func ResolveStatus(fight models.Fight, c clock.Clock, staleDays int) string {
+	if isCancelledResult(fight.Result) {
+		return models.StatusCancelled
+	}
+
+	today := clock.Today(c)
+	date, err := time.ParseInLocation("2006-01-02", fight.Date, today.Location())
+	reliableDate := err == nil && !fight.YearAdjusted
+
+	if !isPendingResult(fight.Result) {
+		if resultType, _ := classifyResult(fight.Result); resultType == models.ResultUnknown &&
+			reliableDate && !date.Before(today) {
+			return models.StatusScheduled
+		}
+		return models.StatusCompleted
+	}
+
+	if !reliableDate {
+		return models.StatusScheduled
+	}
+
+	if staleDays <= 0 {
+		staleDays = DefaultStaleTBDDays
+	}
+	if date.Before(today.AddDate(0, 0, -max(staleDays, statusBufferDays))) {
+		return models.StatusResultUnknown
+	}
+
+	return models.StatusScheduled
+}
+
+// resolveStatus applies ResolveStatus with the parser clock and thresholds
+func (p *Parser) resolveStatus(fight models.Fight) string {
+	return ResolveStatus(fight, p.clock(), p.StaleTBDDays)
+}
@@ -0,0 +1,54 @@
+package parser
+
+import (
+	"testing"
+	"time"
+
+	"easypars/models"
+	"easypars/pkg/clock"
+)
+
+func TestResolveStatus(t *testing.T) {
+	// Today is 2024-06-10
+	c := clock.Fixed{Time: time.Date(2024, time.June, 10, 12, 0, 0, 0, time.UTC)}
+
+	tests := []struct {
+		name         string
+		date         string
+		result       string
+		yearAdjusted bool
+		staleDays    int
+		want         string
+	}{
+		{"past win method", "2024-05-18", "SD", false, 0, models.StatusCompleted},
+		{"future win method", "2024-12-21", "KO 3", false, 0, models.StatusCompleted},
+		{"cancelled in the past", "2024-05-18", "Отменён", false, 0, models.StatusCancelled},
+		{"cancelled in the future", "2024-12-21", "cancelled", false, 0, models.StatusCancelled},
+		{"future vs", "2024-12-21", "vs", false, 0, models.StatusScheduled},
+		{"today vs", "2024-06-10", "vs", false, 0, models.StatusScheduled},
+		{"recent past vs", "2024-06-01", "vs", false, 0, models.StatusScheduled},
+		{"stale past vs", "2024-05-01", "vs", false, 0, models.StatusResultUnknown},
+		{"stale past empty result", "2024-05-01", "", false, 0, models.StatusResultUnknown},
+		{"stale past TBA", "2024-05-01", "TBA", false, 0, models.StatusResultUnknown},
+		{"yesterday vs within the buffer", "2024-06-09", "vs", false, 1, models.StatusScheduled},
+		{"two days ago vs outside the buffer", "2024-06-08", "vs", false, 1, models.StatusResultUnknown},
+		{"stale past vs with a guessed year", "2024-05-01", "vs", true, 0, models.StatusScheduled},
+		{"unparsable date vs", "", "vs", false, 0, models.StatusScheduled},
+		{"past stray text", "2024-05-18", "see notes", false, 0, models.StatusCompleted},
+		{"future stray text", "2024-12-21", "see notes", false, 0, models.StatusScheduled},
+		{"future stray text with a guessed year", "2024-12-21", "see notes", true, 0, models.StatusCompleted},
+		{"today stray text", "2024-06-10", "see notes", false, 0, models.StatusScheduled},
+		{"yesterday stray text", "2024-06-09", "see notes", false, 0, models.StatusCompleted},
+		{"today win method", "2024-06-10", "TKO 4", false, 0, models.StatusCompleted},
+		{"today empty result", "2024-06-10", "", false, 0, models.StatusScheduled},
+		{"undated win method", "", "UD", false, 0, models.StatusCompleted},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fight := models.Fight{Date: tt.date, Result: tt.result, YearAdjusted: tt.yearAdjusted}
+			if got := ResolveStatus(fight, c, tt.staleDays); got != tt.want {
+				t.Errorf("ResolveStatus(%s, %q) = %s, want %s", tt.date, tt.result, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,110 @@
+package parser
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"easypars/models"
+	"easypars/pkg/contract"
+)
+
+// streamBuffer is the number of results a stream holds for a slow consumer
+const streamBuffer = 16
+
+// FightResult is an item of ParseFightsStream
+// A fight that was parsed but failed a check comes with a *FightError; any
+// other error ends the stream and comes without a fight.
+type FightResult struct {
+	Fight models.Fight
+	Err   error
+}
+
+// FightError describes the problems of a single parsed fight: broken model
+// invariants and date issues of the parse
+type FightError struct {
+	Key      string
+	Problems []string
+}
+
+// Error lists the problems of the fight
+func (e *FightError) Error() string {
+	return fmt.Sprintf("fight %s: %s", e.Key, strings.Join(e.Problems, "; "))
+}
+
+// ParseFightsStream parses the fights page and sends the fights one by one
+// The channel is closed when every fight was sent or the parse failed; a
+// failed parse sends its error as the last result. The producer stops when
+// ctx is done, so a consumer that stops reading early must cancel ctx.
+func (p *Parser) ParseFightsStream(ctx context.Context) (<-chan FightResult, error) {
+	if p.BaseURL == "" {
+		return nil, Classify(ErrorOriginConfig, fmt.Errorf("base URL is not configured"))
+	}
+
+	results := make(chan FightResult, streamBuffer)
+	go func() {
+		defer close(results)
+		send := func(result FightResult) bool {
+			select {
+			case results <- result:
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		}
+
+		// The error is the first result, so the buffer has room for it
+		// even when ctx is done; the consumer must learn why the stream ended
+		parsed, err := p.ParseDetailed(ctx)
+		if err != nil {
+			results <- FightResult{Err: err}
+			return
+		}
+
+		problems := fightProblems(parsed.Issues)
+		for _, fight := range parsed.Fights {
+			for _, violation := range contract.ValidateFightInvariants(fight) {
+				problems[fight.Key] = append(problems[fight.Key], violation.String())
+			}
+
+			result := FightResult{Fight: fight}
+			if len(problems[fight.Key]) > 0 {
+				result.Err = &FightError{Key: fight.Key, Problems: problems[fight.Key]}
+			}
+			if !send(result) {
+				return
+			}
+		}
+	}()
+
+	return results, nil
+}
+
+// fightProblems collects the date issues of a parse by fight key
+func fightProblems(issues []ParseIssue) map[string][]string {
+	problems := make(map[string][]string)
+	for _, issue := range issues {
+		if issue.Code == "invalid_date" && issue.FightKey != "" {
+			problems[issue.FightKey] = append(problems[issue.FightKey], issue.Message)
+		}
+	}
+
+	return problems
+}
+
+// collectFights drains a stream into a slice
+// Fights with a FightError are kept, as ParseFights always returned them;
+// any other error is returned instead of the fights.
+func collectFights(results <-chan FightResult) ([]models.Fight, error) {
+	var fights []models.Fight
+	for result := range results {
+		var fightErr *FightError
+		if result.Err != nil && !errors.As(result.Err, &fightErr) {
+			return nil, result.Err
+		}
+		fights = append(fights, result.Fight)
+	}
+
+	return fights, nil
+}
@@ -0,0 +1,146 @@
+package parser
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+// undatedRow is a fight row whose date cell is not a day of the month
+const undatedRow = `<tr><td class="date">soon</td><td class="place">Arena</td>` +
+	`<td class="boxer_1">Undated Red</td><td class="vs">UD</td><td class="boxer_2">Undated Blue</td></tr>`
+
+func TestParseFightsStream(t *testing.T) {
+	tests := []struct {
+		name       string
+		page       string
+		status     int
+		fights     int
+		fightErrs  int
+		streamFail bool
+	}{
+		{"valid page", monthPage("Stream", 2024, time.May, 5), http.StatusOK, 5, 0, false},
+		{"fight with an invalid date", strings.Replace(monthPage("Stream", 2024, time.May, 2), "</table>", undatedRow+"</table>", 1), http.StatusOK, 3, 1, false},
+		{"failing source", "unavailable", http.StatusServiceUnavailable, 0, 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "text/html; charset=utf-8")
+				w.WriteHeader(tt.status)
+				w.Write([]byte(tt.page))
+			}))
+			defer srv.Close()
+			p := newQuietParser(srv.URL + "/")
+			p.Clock = newFakeClock()
+
+			results, err := p.ParseFightsStream(context.Background())
+			if err != nil {
+				t.Fatalf("ParseFightsStream: %v", err)
+			}
+			var fights, fightErrs int
+			var streamErr error
+			for result := range results {
+				var fightErr *FightError
+				switch {
+				case result.Err == nil:
+					fights++
+				case errors.As(result.Err, &fightErr):
+					fights++
+					fightErrs++
+					if fightErr.Key != result.Fight.Key || len(fightErr.Problems) == 0 {
+						t.Errorf("fight error = %+v, want the problems of %s", fightErr, result.Fight.Key)
+					}
+				default:
+					if streamErr != nil {
+						t.Errorf("results after the stream error %v", streamErr)
+					}
+					streamErr = result.Err
+				}
+			}
+			if fights != tt.fights || fightErrs != tt.fightErrs {
+				t.Errorf("fights = %d with %d fight errors, want %d with %d", fights, fightErrs, tt.fights, tt.fightErrs)
+			}
+			if (streamErr != nil) != tt.streamFail {
+				t.Errorf("stream error = %v, want one %v", streamErr, tt.streamFail)
+			}
+		})
+	}
+}
+
+func TestParseFightsStreamWithoutABaseURL(t *testing.T) {
+	results, err := NewParser("").ParseFightsStream(context.Background())
+	if origin, _ := OriginOf(err); results != nil || origin != ErrorOriginConfig {
+		t.Errorf("ParseFightsStream without a base URL = %v of origin %s, want a %s error", err, origin, ErrorOriginConfig)
+	}
+}
+
+func TestParseFightsStreamStopsWhenTheConsumerCancels(t *testing.T) {
+	// More fights than the buffer holds, so the producer blocks on the consumer
+	page := monthPage("May", 2024, time.May, 28) + monthPage("April", 2024, time.April, 28)
+	p := newQuietParser(pageServer(t, page).URL + "/")
+	p.Clock = newFakeClock()
+	// A first parse opens the connection, whose goroutines stay for the next one
+	if _, err := p.ParseFightsContext(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	goroutines := runtime.NumGoroutine()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	results, err := p.ParseFightsStream(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first := <-results; first.Err != nil {
+		t.Fatalf("first result: %v", first.Err)
+	}
+	// The consumer stops reading: the producer must end without a reader
+	cancel()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for runtime.NumGoroutine() > goroutines {
+		if time.Now().After(deadline) {
+			t.Fatalf("%d goroutines after the consumer cancelled, want at most %d", runtime.NumGoroutine(), goroutines)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	received := 1
+	for range results {
+		received++
+	}
+	if received > streamBuffer*2 {
+		t.Errorf("received %d results after cancelling, want the producer stopped", received)
+	}
+}
+
+func TestParseFightsMatchesTheStream(t *testing.T) {
+	page := strings.Replace(monthPage("Collect", 2024, time.May, 3), "</table>", undatedRow+"</table>", 1)
+	p := newQuietParser(pageServer(t, page).URL + "/")
+	p.Clock = newFakeClock()
+
+	fights, err := p.ParseFightsContext(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	results, err := p.ParseFightsStream(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	streamed, err := collectFights(results)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(fights) != 4 || len(streamed) != len(fights) {
+		t.Fatalf("ParseFights = %d fights, the stream %d, want 4 including the undated one", len(fights), len(streamed))
+	}
+	for i := range fights {
+		if fights[i].Key != streamed[i].Key {
+			t.Errorf("fight %d = %s, streamed %s", i, fights[i].Key, streamed[i].Key)
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package parser
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// structureSelectors are the selectors the extraction relies on, in the
+// order they are reported
+var structureSelectors = []string{
+	"table", "td.date", "td.place", "td.boxer_1", "td.vs", "td.boxer_2", "div.month",
+}
+
+// fightSelectors are the cells of a fight row
+var fightSelectors = []string{"td.date", "td.place", "td.boxer_1", "td.vs", "td.boxer_2"}
+
+// StructureReport tells which of the expected elements a page has
+type StructureReport struct {
+	// Counts holds the number of matches of every expected selector
+	Counts map[string]int `json:"counts"`
+	// Missing lists the selectors without any match
+	Missing []string `json:"missing,omitempty"`
+}
+
+// Changed reports whether the page lost its fight structure: it has no
+// fight cells while it has tables, or it has neither fight rows nor month
+// headers. An empty month still has its header and no table.
+func (r StructureReport) Changed() bool {
+	fightCells := 0
+	for _, selector := range fightSelectors {
+		fightCells += r.Counts[selector]
+	}
+	if r.Counts["table"] > 0 && fightCells == 0 {
+		return true
+	}
+
+	return r.Counts["td.boxer_1"] == 0 && r.Counts["div.month"] == 0
+}
+
+// detectHTMLStructureChanges counts the expected selectors of a page
+func detectHTMLStructureChanges(doc *goquery.Document) StructureReport {
+	report := StructureReport{Counts: make(map[string]int, len(structureSelectors))}
+	for _, selector := range structureSelectors {
+		count := doc.Find(selector).Length()
+		report.Counts[selector] = count
+		if count == 0 {
+			report.Missing = append(report.Missing, selector)
+		}
+	}
+
+	return report
+}
+
+// StructureChangedError carries the structure report of a page the
+// extraction no longer understands
+type StructureChangedError struct {
+	Report StructureReport
+}
+
+// Error lists the missing selectors
+func (e *StructureChangedError) Error() string {
+	return fmt.Sprintf("%v: missing %s", ErrStructureChanged, strings.Join(e.Report.Missing, ", "))
+}
+
+// Is matches ErrStructureChanged
+func (e *StructureChangedError) Is(target error) bool {
+	return target == ErrStructureChanged
+}
+
+// StructureOf returns the structure report attached to err, false when err
+// is not a StructureChangedError
+func StructureOf(err error) (StructureReport, bool) {
+	var structureErr *StructureChangedError
+	if !errors.As(err, &structureErr) {
+		return StructureReport{}, false
+	}
+
+	return structureErr.Report, true
+}
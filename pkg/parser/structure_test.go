@@ -0,0 +1,94 @@
+package parser
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+func TestDetectHTMLStructureChanges(t *testing.T) {
+	tests := []struct {
+		name    string
+		page    string
+		missing []string
+		changed bool
+	}{
+		{"results page", monthPage("Structure", 2024, time.May, 2), nil, false},
+		{"empty month", `<div class="month">Май 2024</div>`, []string{"table", "td.date", "td.place", "td.boxer_1", "td.vs", "td.boxer_2"}, false},
+		{"renamed cells", `<div class="month">Май 2024</div><table><tr><td class="day">18</td><td class="fighter">Usyk</td></tr></table>`, []string{"td.date", "td.place", "td.boxer_1", "td.vs", "td.boxer_2"}, true},
+		{"no month headers and no fights", `<p>Results moved</p>`, structureSelectors, true},
+		{"fight rows without month headers", strings.Replace(monthPage("Structure", 2024, time.May, 2), `<div class="month">Май 2024</div>`, "", 1), []string{"div.month"}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, err := goquery.NewDocumentFromReader(strings.NewReader(tt.page))
+			if err != nil {
+				t.Fatal(err)
+			}
+			report := detectHTMLStructureChanges(doc)
+			if !reflect.DeepEqual(report.Missing, tt.missing) {
+				t.Errorf("missing = %q, want %q", report.Missing, tt.missing)
+			}
+			if got := report.Changed(); got != tt.changed {
+				t.Errorf("Changed = %v, want %v (counts %v)", got, tt.changed, report.Counts)
+			}
+			if len(report.Counts) != len(structureSelectors) {
+				t.Errorf("counts = %v, want one per selector", report.Counts)
+			}
+		})
+	}
+}
+
+func TestStructureOf(t *testing.T) {
+	report := StructureReport{Counts: map[string]int{"table": 1}, Missing: []string{"td.boxer_1"}}
+	structureErr := &StructureChangedError{Report: report}
+
+	tests := []struct {
+		name string
+		err  error
+		ok   bool
+	}{
+		{"structure error", structureErr, true},
+		{"classified and wrapped", fmt.Errorf("parse: %w", Classify(ErrorOriginSource, structureErr)), true},
+		{"bare sentinel", ErrStructureChanged, false},
+		{"other error", errors.New("connection refused"), false},
+		{"nil", nil, false},
+	}
+	for _, tt := range tests {
+		got, ok := StructureOf(tt.err)
+		if ok != tt.ok || (ok && !reflect.DeepEqual(got, report)) {
+			t.Errorf("%s: StructureOf = %+v, %v; want %v", tt.name, got, ok, tt.ok)
+		}
+	}
+	if !errors.Is(structureErr, ErrStructureChanged) || !strings.Contains(structureErr.Error(), "missing td.boxer_1") {
+		t.Errorf("structure error %q does not match ErrStructureChanged with the missing selector", structureErr)
+	}
+}
+
+func TestChangedStructureFailsTheParse(t *testing.T) {
+	page := `<html><body><div class="month">Май 2024</div><table><tr><td class="day">18</td><td class="fighter">Usyk</td></tr></table></body></html>`
+	var logs bytes.Buffer
+	p := NewParser(pageServer(t, page).URL + "/")
+	p.Logger = slog.New(slog.NewTextHandler(&logs, nil))
+	p.Clock = newFakeClock()
+
+	fights, err := p.ParseFightsContext(context.Background())
+	if !errors.Is(err, ErrStructureChanged) {
+		t.Fatalf("ParseFightsContext = %d fights (%v), want ErrStructureChanged", len(fights), err)
+	}
+	report, ok := StructureOf(err)
+	if !ok || report.Counts["table"] != 1 || report.Counts["div.month"] != 1 || report.Counts["td.boxer_1"] != 0 {
+		t.Errorf("report = %+v, want the table and the month header counted", report)
+	}
+	if !strings.Contains(logs.String(), "Page structure changed") || !strings.Contains(logs.String(), "td.boxer_1") {
+		t.Errorf("logs = %q, want the missing selectors logged", logs.String())
+	}
+}
@@ -0,0 +1,64 @@
+package parser
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// requestThrottle spaces the outbound requests of a parser
+// It is a token bucket holding a single token: a request takes the next
+// free slot and moves it one interval ahead, so requests issued from any
+// number of goroutines leave at most one per interval. Idle time is not
+// saved up into bursts. The wall clock is used, since the wait is real;
+// tests replace now and sleep with a fake clock.
+type requestThrottle struct {
+	mu sync.Mutex
+	// next is the earliest start of the next request
+	next time.Time
+	// now and sleep default to time.Now and sleepContext when nil
+	now   func() time.Time
+	sleep func(ctx context.Context, d time.Duration) error
+}
+
+// wait blocks until the request may start at the given rate
+// A request cancelled while waiting returns its slot when no later request
+// took one in the meantime.
+func (t *requestThrottle) wait(ctx context.Context, perSecond float64) error {
+	if perSecond <= 0 {
+		return nil
+	}
+	interval := time.Duration(float64(time.Second) / perSecond)
+
+	t.mu.Lock()
+	now := time.Now()
+	if t.now != nil {
+		now = t.now()
+	}
+	slot := t.next
+	if slot.Before(now) {
+		slot = now
+	}
+	t.next = slot.Add(interval)
+	t.mu.Unlock()
+
+	delay := slot.Sub(now)
+	if delay <= 0 {
+		return nil
+	}
+
+	sleep := sleepContext
+	if t.sleep != nil {
+		sleep = t.sleep
+	}
+	if err := sleep(ctx, delay); err != nil {
+		t.mu.Lock()
+		if t.next.Equal(slot.Add(interval)) {
+			t.next = slot
+		}
+		t.mu.Unlock()
+		return err
+	}
+
+	return nil
+}
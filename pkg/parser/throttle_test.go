@@ -0,0 +1,108 @@
+package parser
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+)
+
+// useFakeClock makes the throttle read the time from the clock and sleep
+// on it
+func (t *requestThrottle) useFakeClock(clk *fakeClock) {
+	t.now, t.sleep = clk.Now, clk.Sleep
+}
+
+func TestThrottleSpacesSequentialRequests(t *testing.T) {
+	clk := newFakeClock()
+	start := clk.Now()
+	var throttle requestThrottle
+	throttle.useFakeClock(clk)
+
+	for i := 0; i < 5; i++ {
+		if err := throttle.wait(context.Background(), 2); err != nil {
+			t.Fatalf("wait %d: %v", i, err)
+		}
+	}
+
+	// The first request leaves at once, the other four half a second apart
+	if elapsed := clk.Now().Sub(start); elapsed < 2*time.Second {
+		t.Errorf("5 requests at 2 per second took %v, want at least 2s", elapsed)
+	}
+	want := []time.Duration{500 * time.Millisecond, 500 * time.Millisecond, 500 * time.Millisecond, 500 * time.Millisecond}
+	if slept := clk.Slept(); !slices.Equal(slept, want) {
+		t.Errorf("slept %v, want %v", slept, want)
+	}
+}
+
+func TestThrottleSpacesConcurrentRequests(t *testing.T) {
+	src := newScriptedSource(t, scriptedResponse{status: 200})
+	// The clock does not move: every request sleeps until its own slot
+	clk := newFakeClock()
+	clk.sleepsOnly = true
+	p := newRetryParser(src, 0, clk)
+	p.RequestsPerSecond = 2
+	p.throttle.useFakeClock(clk)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := p.fetchHTMLDocument(context.Background(), src.URL+"/"); err != nil {
+				t.Errorf("fetch: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	slept := clk.Slept()
+	slices.Sort(slept)
+	want := []time.Duration{500 * time.Millisecond, time.Second, 1500 * time.Millisecond, 2 * time.Second}
+	if !slices.Equal(slept, want) {
+		t.Errorf("slept %v, want the slots %v: the last of 5 requests at 2 per second starts 2s after the first", slept, want)
+	}
+	if hits := src.hits.Load(); hits != 5 {
+		t.Errorf("the source got %d requests, want 5", hits)
+	}
+}
+
+func TestThrottleCancelledRequestReturnsItsSlot(t *testing.T) {
+	clk := newFakeClock()
+	clk.sleepsOnly = true
+	var throttle requestThrottle
+	throttle.useFakeClock(clk)
+
+	if err := throttle.wait(context.Background(), 2); err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := throttle.wait(ctx, 2); !errors.Is(err, context.Canceled) {
+		t.Fatalf("cancelled wait = %v, want context.Canceled", err)
+	}
+	// The next request gets the slot of the cancelled one
+	if err := throttle.wait(context.Background(), 2); err != nil {
+		t.Fatal(err)
+	}
+	if slept := clk.Slept(); !slices.Equal(slept, []time.Duration{500 * time.Millisecond}) {
+		t.Errorf("slept %v, want the half second slot of the cancelled request", slept)
+	}
+}
+
+func TestThrottleDisabled(t *testing.T) {
+	clk := newFakeClock()
+	var throttle requestThrottle
+	throttle.useFakeClock(clk)
+
+	for i := 0; i < 10; i++ {
+		if err := throttle.wait(context.Background(), 0); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if slept := clk.Slept(); len(slept) != 0 {
+		t.Errorf("slept %v without a rate, want no wait", slept)
+	}
+}
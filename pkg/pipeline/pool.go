@@ -0,0 +1,203 @@
+// Package pipeline provides instrumented worker pools
+// Every pool registers itself under its name, so the load of all pools can
+// be inspected at runtime to choose their sizes.
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ErrPoolClosed is returned when a task is submitted to a closed pool
+var ErrPoolClosed = errors.New("worker pool is closed")
+
+// avgWeight is the weight of the newest task in the moving average
+const avgWeight = 0.2
+
+// PoolStats is a point-in-time view of a pool
+type PoolStats struct {
+	Name        string  `json:"name"`
+	Workers     int     `json:"workers"`
+	Busy        int64   `json:"busy"`
+	QueueLen    int     `json:"queue_len"`
+	QueueCap    int     `json:"queue_cap"`
+	TasksDone   int64   `json:"tasks_done"`
+	TasksFailed int64   `json:"tasks_failed"`
+	AvgTaskMs   float64 `json:"avg_task_ms"`
+	MaxTaskMs   float64 `json:"max_task_ms"`
+}
+
+// task is a queued unit of work
+type task struct {
+	ctx context.Context
+	fn  func(context.Context) error
+}
+
+// Pool runs tasks on a fixed number of workers fed by a bounded queue
+// Counters are atomics, so Stats never blocks the workers.
+type Pool struct {
+	name    string
+	workers int
+	queue   chan task
+
+	mu     sync.RWMutex
+	closed bool
+	wg     sync.WaitGroup
+
+	busy   atomic.Int64
+	done   atomic.Int64
+	failed atomic.Int64
+	// avgBits holds the float64 bits of the moving average task time in ms
+	avgBits atomic.Uint64
+	// maxNs is the longest task time in nanoseconds
+	maxNs atomic.Int64
+}
+
+// NewPool starts a pool and registers it in the default registry
+// A pool with the same name replaces the registered one.
+func NewPool(name string, workers, queueCap int) *Pool {
+	workers = max(workers, 1)
+	queueCap = max(queueCap, 0)
+
+	p := &Pool{
+		name:    name,
+		workers: workers,
+		queue:   make(chan task, queueCap),
+	}
+	p.wg.Add(workers)
+	for i := 0; i < workers; i++ {
+		go p.work()
+	}
+
+	Default.register(p)
+	return p
+}
+
+// Name returns the registered name of the pool
+func (p *Pool) Name() string {
+	return p.name
+}
+
+// Submit queues a task, waiting for queue space until ctx is done
+// A queued task always runs and receives the same context, so a task
+// cancelled while waiting in the queue still gets to clean up.
+func (p *Pool) Submit(ctx context.Context, fn func(context.Context) error) error {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	if p.closed {
+		return ErrPoolClosed
+	}
+
+	select {
+	case p.queue <- task{ctx: ctx, fn: fn}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Close stops accepting tasks, waits for the queued ones and unregisters the pool
+func (p *Pool) Close() {
+	p.mu.Lock()
+	if p.closed {
+		p.mu.Unlock()
+		return
+	}
+	p.closed = true
+	close(p.queue)
+	p.mu.Unlock()
+
+	p.wg.Wait()
+	Default.unregister(p)
+}
+
+// work runs queued tasks until the queue is closed
+func (p *Pool) work() {
+	defer p.wg.Done()
+
+	for t := range p.queue {
+		p.run(t)
+	}
+}
+
+// run executes a single task and updates the counters
+// A panicking task is counted as failed and does not stop the worker
+func (p *Pool) run(t task) {
+	p.busy.Add(1)
+	start := time.Now()
+
+	err := p.call(t)
+
+	p.observe(time.Since(start))
+	if err != nil {
+		p.failed.Add(1)
+	} else {
+		p.done.Add(1)
+	}
+	p.busy.Add(-1)
+}
+
+// call runs the task function, converting a panic into an error
+func (p *Pool) call(t task) (err error) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			err = fmt.Errorf("task of pool %s panicked: %v", p.name, recovered)
+		}
+	}()
+
+	return t.fn(t.ctx)
+}
+
+// observe adds a task duration to the moving average and the maximum
+func (p *Pool) observe(d time.Duration) {
+	ms := float64(d) / float64(time.Millisecond)
+	for {
+		oldBits := p.avgBits.Load()
+		avg := math.Float64frombits(oldBits)
+		if oldBits == 0 {
+			avg = ms
+		} else {
+			avg += avgWeight * (ms - avg)
+		}
+		if p.avgBits.CompareAndSwap(oldBits, math.Float64bits(avg)) {
+			break
+		}
+	}
+
+	for {
+		current := p.maxNs.Load()
+		if int64(d) <= current || p.maxNs.CompareAndSwap(current, int64(d)) {
+			break
+		}
+	}
+}
+
+// Stats returns the current statistics of the pool
+func (p *Pool) Stats() PoolStats {
+	return PoolStats{
+		Name:        p.name,
+		Workers:     p.workers,
+		Busy:        p.busy.Load(),
+		QueueLen:    len(p.queue),
+		QueueCap:    cap(p.queue),
+		TasksDone:   p.done.Load(),
+		TasksFailed: p.failed.Load(),
+		AvgTaskMs:   math.Float64frombits(p.avgBits.Load()),
+		MaxTaskMs:   float64(p.maxNs.Load()) / float64(time.Millisecond),
+	}
+}
+
+// ResetStats clears the counters and timings
+// Busy workers and queued tasks are live values and are not reset
+func (p *Pool) ResetStats() {
+	p.done.Store(0)
+	p.failed.Store(0)
+	p.avgBits.Store(0)
+	p.maxNs.Store(0)
+}
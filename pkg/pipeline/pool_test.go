@@ -0,0 +1,232 @@
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+// newTestPool starts a pool closed at the end of the test
+func newTestPool(t *testing.T, name string, workers, queueCap int) *Pool {
+	t.Helper()
+
+	p := NewPool(name, workers, queueCap)
+	t.Cleanup(p.Close)
+	return p
+}
+
+// submitAll queues the tasks and waits until the pool ran them
+func submitAll(t *testing.T, p *Pool, tasks ...func(context.Context) error) {
+	t.Helper()
+
+	var wg sync.WaitGroup
+	wg.Add(len(tasks))
+	for _, fn := range tasks {
+		err := p.Submit(context.Background(), func(ctx context.Context) error {
+			defer wg.Done()
+			return fn(ctx)
+		})
+		if err != nil {
+			t.Fatalf("Submit: %v", err)
+		}
+	}
+	wg.Wait()
+}
+
+// settled waits until no worker of the pool is busy and returns the stats
+func settled(t *testing.T, p *Pool) PoolStats {
+	t.Helper()
+
+	deadline := time.Now().Add(time.Second)
+	for {
+		stats := p.Stats()
+		if stats.Busy == 0 || time.Now().After(deadline) {
+			return stats
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestPoolCounters(t *testing.T) {
+	ok := func(context.Context) error { return nil }
+	fail := func(context.Context) error { return errors.New("source unavailable") }
+	panics := func(context.Context) error { panic("nil map") }
+
+	tests := []struct {
+		name       string
+		tasks      []func(context.Context) error
+		done, fail int64
+	}{
+		{"successful tasks", []func(context.Context) error{ok, ok, ok}, 3, 0},
+		{"failed tasks", []func(context.Context) error{ok, fail, fail}, 1, 2},
+		{"panicking task", []func(context.Context) error{panics, ok}, 1, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := newTestPool(t, "counters "+tt.name, 2, 4)
+			submitAll(t, p, tt.tasks...)
+
+			stats := settled(t, p)
+			if stats.TasksDone != tt.done || stats.TasksFailed != tt.fail {
+				t.Errorf("tasks done %d, failed %d; want %d and %d", stats.TasksDone, stats.TasksFailed, tt.done, tt.fail)
+			}
+			if stats.Busy != 0 || stats.QueueLen != 0 {
+				t.Errorf("busy %d, queued %d after the tasks, want none", stats.Busy, stats.QueueLen)
+			}
+			if stats.Workers != 2 || stats.QueueCap != 4 {
+				t.Errorf("workers %d, queue capacity %d; want 2 and 4", stats.Workers, stats.QueueCap)
+			}
+		})
+	}
+}
+
+func TestPoolBusyWorkers(t *testing.T) {
+	p := newTestPool(t, "busy", 2, 4)
+	release := make(chan struct{})
+	started := make(chan struct{}, 3)
+	for i := 0; i < 3; i++ {
+		err := p.Submit(context.Background(), func(context.Context) error {
+			started <- struct{}{}
+			<-release
+			return nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	<-started
+	<-started
+
+	if stats := p.Stats(); stats.Busy != 2 || stats.QueueLen != 1 {
+		t.Errorf("busy %d, queued %d while blocked; want 2 and 1", stats.Busy, stats.QueueLen)
+	}
+	close(release)
+	if stats := settled(t, p); stats.Busy != 0 || stats.TasksDone != 3 {
+		t.Errorf("busy %d, done %d after the release; want 0 and 3", stats.Busy, stats.TasksDone)
+	}
+}
+
+func TestPoolAverageTaskTime(t *testing.T) {
+	const taskTime = 20 * time.Millisecond
+	p := newTestPool(t, "timing", 2, 8)
+	tasks := make([]func(context.Context) error, 6)
+	for i := range tasks {
+		tasks[i] = func(context.Context) error {
+			time.Sleep(taskTime)
+			return nil
+		}
+	}
+	submitAll(t, p, tasks...)
+
+	stats := settled(t, p)
+	want := float64(taskTime.Milliseconds())
+	if stats.AvgTaskMs < want || stats.AvgTaskMs > 3*want {
+		t.Errorf("average task time = %.1f ms, want about %.0f ms", stats.AvgTaskMs, want)
+	}
+	if stats.MaxTaskMs < stats.AvgTaskMs {
+		t.Errorf("longest task time %.1f ms is below the average %.1f ms", stats.MaxTaskMs, stats.AvgTaskMs)
+	}
+
+	p.ResetStats()
+	if stats := p.Stats(); stats.TasksDone != 0 || stats.AvgTaskMs != 0 || stats.MaxTaskMs != 0 {
+		t.Errorf("stats after the reset = %+v, want cleared counters", stats)
+	}
+}
+
+func TestPoolStatsDuringWork(t *testing.T) {
+	p := newTestPool(t, "concurrent", 4, 16)
+
+	// Run with -race: readers and workers share the counters
+	stop := make(chan struct{})
+	var readers sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		readers.Add(1)
+		go func() {
+			defer readers.Done()
+			for {
+				select {
+				case <-stop:
+					return
+				default:
+					if stats := GetAllPoolStats(); len(stats) == 0 {
+						t.Error("the running pool is not registered")
+						return
+					}
+					ResetAllPoolStats()
+				}
+			}
+		}()
+	}
+
+	tasks := make([]func(context.Context) error, 200)
+	for i := range tasks {
+		tasks[i] = func(context.Context) error { return nil }
+	}
+	submitAll(t, p, tasks...)
+	close(stop)
+	readers.Wait()
+
+	if stats := settled(t, p); stats.Busy != 0 {
+		t.Errorf("busy = %d after the tasks, want 0", stats.Busy)
+	}
+}
+
+func TestPoolClose(t *testing.T) {
+	p := NewPool("closing", 1, 4)
+	ran := make(chan struct{}, 2)
+	for i := 0; i < 2; i++ {
+		if err := p.Submit(context.Background(), func(context.Context) error { ran <- struct{}{}; return nil }); err != nil {
+			t.Fatal(err)
+		}
+	}
+	p.Close()
+
+	if len(ran) != 2 {
+		t.Errorf("%d of 2 queued tasks ran before Close returned", len(ran))
+	}
+	if err := p.Submit(context.Background(), func(context.Context) error { return nil }); !errors.Is(err, ErrPoolClosed) {
+		t.Errorf("Submit to a closed pool = %v, want ErrPoolClosed", err)
+	}
+	for _, stats := range GetAllPoolStats() {
+		if stats.Name == "closing" {
+			t.Error("a closed pool is still registered")
+		}
+	}
+	p.Close()
+}
+
+func TestSubmitStopsWaitingOnCancel(t *testing.T) {
+	p := newTestPool(t, "full", 1, 0)
+	release := make(chan struct{})
+	defer close(release)
+	started := make(chan struct{})
+	if err := p.Submit(context.Background(), func(context.Context) error { close(started); <-release; return nil }); err != nil {
+		t.Fatal(err)
+	}
+	<-started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := p.Submit(ctx, func(context.Context) error { return nil }); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Submit to a full pool = %v, want the context error", err)
+	}
+}
+
+func TestRegistryReplacesPoolsByName(t *testing.T) {
+	first := NewPool("replaced", 1, 0)
+	second := newTestPool(t, "replaced", 3, 0)
+
+	// Closing the replaced pool leaves the new one registered
+	first.Close()
+	var found *PoolStats
+	for _, stats := range GetAllPoolStats() {
+		if stats.Name == "replaced" {
+			found = &stats
+		}
+	}
+	if found == nil || found.Workers != second.Stats().Workers {
+		t.Errorf("registered pool = %+v, want the pool with 3 workers", found)
+	}
+}
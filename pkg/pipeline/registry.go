@@ -0,0 +1,71 @@
+package pipeline
+
+import (
+	"sort"
+	"sync"
+)
+
+// Default is the registry every pool registers in
+var Default = &Registry{pools: make(map[string]*Pool)}
+
+// Registry keeps the running pools by name
+type Registry struct {
+	mu    sync.Mutex
+	pools map[string]*Pool
+}
+
+// register adds the pool, replacing a pool of the same name
+func (r *Registry) register(p *Pool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.pools[p.name] = p
+}
+
+// unregister removes the pool unless another pool took its name
+func (r *Registry) unregister(p *Pool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.pools[p.name] == p {
+		delete(r.pools, p.name)
+	}
+}
+
+// Stats returns the statistics of every registered pool sorted by name
+func (r *Registry) Stats() []PoolStats {
+	r.mu.Lock()
+	pools := make([]*Pool, 0, len(r.pools))
+	for _, p := range r.pools {
+		pools = append(pools, p)
+	}
+	r.mu.Unlock()
+
+	stats := make([]PoolStats, 0, len(pools))
+	for _, p := range pools {
+		stats = append(stats, p.Stats())
+	}
+	sort.Slice(stats, func(i, j int) bool { return stats[i].Name < stats[j].Name })
+
+	return stats
+}
+
+// Reset clears the statistics of every registered pool
+func (r *Registry) Reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, p := range r.pools {
+		p.ResetStats()
+	}
+}
+
+// GetAllPoolStats returns the statistics of every pool of the default registry
+func GetAllPoolStats() []PoolStats {
+	return Default.Stats()
+}
+
+// ResetAllPoolStats clears the statistics of every pool of the default registry
+func ResetAllPoolStats() {
+	Default.Reset()
+}
@@ -0,0 +1,216 @@
+package presets
+
+import (
+	"container/list"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// slugLength is the length of generated preset slugs
+const slugLength = 8
+
+// slugAlphabet holds the characters used in slugs
+const slugAlphabet = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
+
+// maxSlugAttempts bounds slug regeneration on collisions
+const maxSlugAttempts = 10
+
+// ErrSlugExhausted is returned when no free slug could be generated
+var ErrSlugExhausted = errors.New("could not generate a unique preset slug")
+
+// Preset is a saved set of /api/fights query parameters
+type Preset struct {
+	Slug      string            `json:"slug"`
+	Name      string            `json:"name"`
+	Params    map[string]string `json:"params"`
+	CreatedAt time.Time         `json:"created_at"`
+}
+
+// Store keeps presets in memory with LRU eviction
+// When a file path is set, presets are saved to it after every change and
+// loaded on start, so they survive restarts
+type Store struct {
+	mu       sync.Mutex
+	capacity int
+	path     string
+
+	// order holds *Preset values, most recently used first
+	order *list.List
+	items map[string]*list.Element
+}
+
+// NewStore creates a store keeping up to capacity presets
+// Existing presets are loaded from path when the file exists
+func NewStore(capacity int, path string) (*Store, error) {
+	s := &Store{
+		capacity: capacity,
+		path:     path,
+		order:    list.New(),
+		items:    make(map[string]*list.Element),
+	}
+
+	if path != "" {
+		if err := s.load(); err != nil {
+			return nil, err
+		}
+	}
+
+	return s, nil
+}
+
+// Create stores a new preset under a freshly generated slug
+// The least recently used preset is evicted when the store is full
+func (s *Store) Create(name string, params map[string]string) (Preset, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	slug, err := s.newSlug()
+	if err != nil {
+		return Preset{}, err
+	}
+
+	preset := &Preset{
+		Slug:      slug,
+		Name:      name,
+		Params:    params,
+		CreatedAt: time.Now(),
+	}
+	s.items[slug] = s.order.PushFront(preset)
+
+	for s.order.Len() > s.capacity {
+		oldest := s.order.Back()
+		s.order.Remove(oldest)
+		delete(s.items, oldest.Value.(*Preset).Slug)
+	}
+
+	if err := s.save(); err != nil {
+		return Preset{}, err
+	}
+
+	return *preset, nil
+}
+
+// Get returns the preset with the given slug and marks it as recently used
+func (s *Store) Get(slug string) (Preset, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	elem, ok := s.items[slug]
+	if !ok {
+		return Preset{}, false
+	}
+	s.order.MoveToFront(elem)
+
+	return *elem.Value.(*Preset), true
+}
+
+// Len returns the number of stored presets
+func (s *Store) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.order.Len()
+}
+
+// newSlug generates a slug not used by any stored preset
+// The caller must hold the lock
+func (s *Store) newSlug() (string, error) {
+	for attempt := 0; attempt < maxSlugAttempts; attempt++ {
+		slug, err := randomSlug()
+		if err != nil {
+			return "", err
+		}
+		if _, exists := s.items[slug]; !exists {
+			return slug, nil
+		}
+	}
+
+	return "", ErrSlugExhausted
+}
+
+// randomSlug returns a random slug of slugLength characters
+func randomSlug() (string, error) {
+	b := make([]byte, slugLength)
+	max := big.NewInt(int64(len(slugAlphabet)))
+	for i := range b {
+		n, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return "", fmt.Errorf("error generating slug: %w", err)
+		}
+		b[i] = slugAlphabet[n.Int64()]
+	}
+
+	return string(b), nil
+}
+
+// load reads presets from the file, keeping the saved LRU order
+func (s *Store) load() error {
+	data, err := os.ReadFile(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("error reading presets file: %w", err)
+	}
+
+	var saved []Preset
+	if err := json.Unmarshal(data, &saved); err != nil {
+		return fmt.Errorf("error decoding presets file: %w", err)
+	}
+
+	// The file lists presets from most to least recently used
+	for i := range saved {
+		if s.order.Len() >= s.capacity {
+			break
+		}
+		preset := saved[i]
+		s.items[preset.Slug] = s.order.PushBack(&preset)
+	}
+
+	return nil
+}
+
+// save writes all presets to the file atomically
+// The caller must hold the lock
+func (s *Store) save() error {
+	if s.path == "" {
+		return nil
+	}
+
+	saved := make([]Preset, 0, s.order.Len())
+	for elem := s.order.Front(); elem != nil; elem = elem.Next() {
+		saved = append(saved, *elem.Value.(*Preset))
+	}
+
+	data, err := json.Marshal(saved)
+	if err != nil {
+		return fmt.Errorf("error encoding presets: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), ".presets-*.tmp")
+	if err != nil {
+		return fmt.Errorf("error saving presets: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("error saving presets: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("error saving presets: %w", err)
+	}
+
+	if err := os.Rename(tmp.Name(), s.path); err != nil {
+		return fmt.Errorf("error saving presets: %w", err)
+	}
+
+	return nil
+}
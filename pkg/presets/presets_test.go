@@ -0,0 +1,106 @@
+package presets
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestCreateAndGet(t *testing.T) {
+	store, err := NewStore(10, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	params := map[string]string{"status": "completed", "limit": "5"}
+	preset, err := store.Create("Completed fights", params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(preset.Slug) != slugLength {
+		t.Errorf("slug = %q, want %d characters", preset.Slug, slugLength)
+	}
+
+	got, ok := store.Get(preset.Slug)
+	if !ok || got.Name != "Completed fights" || got.Params["status"] != "completed" {
+		t.Errorf("Get(%s) = %+v, %v, want the created preset", preset.Slug, got, ok)
+	}
+	if _, ok := store.Get("missing1"); ok {
+		t.Error("Get of an unknown slug found a preset")
+	}
+}
+
+func TestEviction(t *testing.T) {
+	tests := []struct {
+		name    string
+		touch   int // index of the preset used before the overflow, -1 for none
+		evicted int
+	}{
+		{"oldest evicted", -1, 0},
+		{"recently used kept", 0, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store, err := NewStore(3, "")
+			if err != nil {
+				t.Fatal(err)
+			}
+			var slugs []string
+			for i := 0; i < 3; i++ {
+				preset, err := store.Create("preset", nil)
+				if err != nil {
+					t.Fatal(err)
+				}
+				slugs = append(slugs, preset.Slug)
+			}
+			if tt.touch >= 0 {
+				store.Get(slugs[tt.touch])
+			}
+
+			if _, err := store.Create("overflow", nil); err != nil {
+				t.Fatal(err)
+			}
+			if store.Len() != 3 {
+				t.Errorf("Len = %d, want the capacity 3", store.Len())
+			}
+			for i, slug := range slugs {
+				if _, ok := store.Get(slug); ok == (i == tt.evicted) {
+					t.Errorf("preset %d stored = %v, want %v", i, ok, i != tt.evicted)
+				}
+			}
+		})
+	}
+}
+
+func TestPresetsSurviveRestart(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "presets.json")
+	store, err := NewStore(2, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var slugs []string
+	for i := 0; i < 2; i++ {
+		preset, err := store.Create("preset", map[string]string{"sort": "date"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		slugs = append(slugs, preset.Slug)
+	}
+
+	restarted, err := NewStore(2, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if restarted.Len() != 2 {
+		t.Fatalf("Len after the restart = %d, want 2", restarted.Len())
+	}
+	// The saved order survives: the overflow evicts the oldest preset
+	if _, err := restarted.Create("overflow", nil); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := restarted.Get(slugs[0]); ok {
+		t.Error("the oldest preset was kept after the restart")
+	}
+	if got, ok := restarted.Get(slugs[1]); !ok || got.Params["sort"] != "date" {
+		t.Errorf("Get(%s) after the restart = %+v, %v, want the saved preset", slugs[1], got, ok)
+	}
+}
@@ -0,0 +1,227 @@
+// Package refresh re-parses the source on a schedule, so the API serves
+// pre-parsed data instead of parsing on demand
+// The refresh itself is provided by the API (see Scheduler.SetFunc), which
+// publishes the result as the active snapshot.
+package refresh
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// TriggerScheduled marks scheduled refreshes in the parse history
+const TriggerScheduled = "scheduled"
+
+// Scheduler states reported by Status
+const (
+	StateStopped = "stopped"
+	StateWaiting = "waiting"
+	StateRunning = "running"
+)
+
+var (
+	// ErrRunning is returned when starting a scheduler that is already running
+	ErrRunning = errors.New("refresher is already running")
+	// ErrNotRunning is returned when stopping a scheduler that is not running
+	ErrNotRunning = errors.New("refresher is not running")
+	// ErrNoFunc is returned when starting a scheduler without a refresh
+	ErrNoFunc = errors.New("refresher has no refresh function")
+)
+
+// Func refreshes the data and returns the number of fights
+type Func func(ctx context.Context) (int, error)
+
+// Status is the state of the scheduler for /api/fights/refresh-status
+type Status struct {
+	State    string `json:"state"`
+	Interval string `json:"interval"`
+	// LastRunAt is the start of the last finished refresh
+	LastRunAt      *time.Time `json:"last_run_at,omitempty"`
+	LastDurationMs int64      `json:"last_duration_ms"`
+	LastFightCount int        `json:"last_fight_count"`
+	LastError      string     `json:"last_error,omitempty"`
+	// LastSuccessAt is the end of the last successful refresh
+	LastSuccessAt *time.Time `json:"last_success_at,omitempty"`
+	NextRunAt     *time.Time `json:"next_run_at,omitempty"`
+	Runs          int        `json:"runs"`
+	Failures      int        `json:"failures"`
+}
+
+// Scheduler runs the refresh right after Start and then every interval,
+// counted from the end of the previous refresh
+type Scheduler struct {
+	interval time.Duration
+
+	mu      sync.RWMutex
+	refresh Func
+	running bool
+	cancel  context.CancelFunc
+	// done is closed when the loop of the last Start returned
+	done  chan struct{}
+	state string
+
+	lastRun      time.Time
+	lastDuration time.Duration
+	lastCount    int
+	lastError    string
+	lastSuccess  time.Time
+	nextRun      time.Time
+	runs         int
+	failures     int
+}
+
+// New creates a stopped scheduler refreshing every interval
+func New(interval time.Duration) *Scheduler {
+	return &Scheduler{interval: interval, state: StateStopped}
+}
+
+// SetFunc sets the refresh run by the scheduler
+func (s *Scheduler) SetFunc(refresh Func) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.refresh = refresh
+}
+
+// Start launches the background loop
+func (s *Scheduler) Start() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.running {
+		return ErrRunning
+	}
+	if s.refresh == nil {
+		return ErrNoFunc
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	s.running = true
+	s.cancel = cancel
+	s.done = make(chan struct{})
+	s.state = StateRunning
+
+	go s.loop(ctx, s.refresh, s.done)
+
+	slog.Info("Refresher started", "interval", s.interval.String())
+	return nil
+}
+
+// Stop stops the background loop and waits for it to return
+// A running refresh is cancelled.
+func (s *Scheduler) Stop() error {
+	s.mu.Lock()
+	if !s.running {
+		s.mu.Unlock()
+		return ErrNotRunning
+	}
+	s.cancel()
+	s.running = false
+	s.state = StateStopped
+	s.nextRun = time.Time{}
+	done := s.done
+	s.mu.Unlock()
+
+	<-done
+	slog.Info("Refresher stopped")
+	return nil
+}
+
+// Running reports whether the loop is running
+func (s *Scheduler) Running() bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.running
+}
+
+// Status returns the state of the scheduler and its last refresh
+func (s *Scheduler) Status() Status {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	status := Status{
+		State:          s.state,
+		Interval:       s.interval.String(),
+		LastDurationMs: s.lastDuration.Milliseconds(),
+		LastFightCount: s.lastCount,
+		LastError:      s.lastError,
+		Runs:           s.runs,
+		Failures:       s.failures,
+	}
+	if !s.lastRun.IsZero() {
+		lastRun := s.lastRun
+		status.LastRunAt = &lastRun
+	}
+	if !s.lastSuccess.IsZero() {
+		lastSuccess := s.lastSuccess
+		status.LastSuccessAt = &lastSuccess
+	}
+	if !s.nextRun.IsZero() {
+		nextRun := s.nextRun
+		status.NextRunAt = &nextRun
+	}
+
+	return status
+}
+
+// loop refreshes right away and then after every interval
+func (s *Scheduler) loop(ctx context.Context, refresh Func, done chan struct{}) {
+	defer close(done)
+
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-timer.C:
+		}
+
+		s.run(ctx, refresh)
+		timer.Reset(s.interval)
+	}
+}
+
+// run refreshes once and records the outcome
+func (s *Scheduler) run(ctx context.Context, refresh Func) {
+	s.mu.Lock()
+	if ctx.Err() != nil {
+		s.mu.Unlock()
+		return
+	}
+	s.state = StateRunning
+	s.mu.Unlock()
+
+	start := time.Now()
+	count, err := refresh(ctx)
+	duration := time.Since(start)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// A refresh cancelled by Stop is not an outcome
+	if ctx.Err() != nil {
+		return
+	}
+
+	s.runs++
+	s.lastRun = start
+	s.lastDuration = duration
+	s.nextRun = time.Now().Add(s.interval)
+	s.state = StateWaiting
+	if err != nil {
+		s.failures++
+		s.lastError = err.Error()
+		slog.ErrorContext(ctx, "Scheduled refresh failed", "duration_ms", duration.Milliseconds(), "error", err)
+		return
+	}
+	s.lastCount = count
+	s.lastError = ""
+	s.lastSuccess = time.Now()
+	slog.InfoContext(ctx, "Scheduled refresh finished", "fight_count", count, "duration_ms", duration.Milliseconds())
+}
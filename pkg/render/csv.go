@@ -0,0 +1,39 @@
+package render
+
+import (
+	"encoding/csv"
+	"io"
+	"strconv"
+)
+
+// csvHeader lists the columns of the CSV format
+var csvHeader = []string{"date", "fighter1", "fighter2", "result", "location", "status", "card_position", "key"}
+
+// csvFormatter writes the fights as CSV with a header row
+type csvFormatter struct{}
+
+func (csvFormatter) ContentType() string { return "text/csv; charset=utf-8" }
+
+func (csvFormatter) Encode(w io.Writer, payload ResponsePayload) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write(csvHeader); err != nil {
+		return err
+	}
+
+	for _, fight := range payload.Fights {
+		position := ""
+		if fight.CardPosition > 0 {
+			position = strconv.Itoa(fight.CardPosition)
+		}
+		record := []string{
+			fight.Date, fight.Fighter1, fight.Fighter2, fight.Result,
+			fight.Location, fight.Status, position, fight.Key,
+		}
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
@@ -0,0 +1,102 @@
+package render
+
+import (
+	"io"
+	"strings"
+	"time"
+)
+
+// icsLineLimit is the maximum line length in octets (RFC 5545, 3.1)
+const icsLineLimit = 75
+
+// icsRefreshInterval is how often calendar clients are asked to reload the
+// feed, sent as REFRESH-INTERVAL (RFC 7986) and the X-PUBLISHED-TTL that
+// Outlook and Google Calendar read
+const icsRefreshInterval = "PT1H"
+
+// icsFormatter writes the fights as an iCalendar feed of all-day events
+// The UID of an event is the natural key of its fight, so a reloaded feed
+// updates the events instead of duplicating them. Fights without a valid
+// date are skipped.
+type icsFormatter struct{}
+
+func (icsFormatter) ContentType() string { return "text/calendar; charset=utf-8" }
+
+func (icsFormatter) Encode(w io.Writer, payload ResponsePayload) error {
+	var b strings.Builder
+	line := func(text string) { b.WriteString(foldICSLine(text)) }
+
+	line("BEGIN:VCALENDAR")
+	line("VERSION:2.0")
+	line("PRODID:-//EasyPars//Fights//EN")
+	line("CALSCALE:GREGORIAN")
+	line("METHOD:PUBLISH")
+	line("X-WR-CALNAME:EasyPars fights")
+	line("REFRESH-INTERVAL;VALUE=DURATION:" + icsRefreshInterval)
+	line("X-PUBLISHED-TTL:" + icsRefreshInterval)
+	stamp := time.Now().UTC().Format("20060102T150405Z")
+	for _, fight := range payload.Fights {
+		date, err := time.Parse("2006-01-02", fight.Date)
+		if err != nil {
+			continue
+		}
+
+		line("BEGIN:VEVENT")
+		line("UID:" + escapeICS(fight.Key) + "@easypars")
+		line("DTSTAMP:" + stamp)
+		line("DTSTART;VALUE=DATE:" + date.Format("20060102"))
+		line("DTEND;VALUE=DATE:" + date.AddDate(0, 0, 1).Format("20060102"))
+		line("SUMMARY:" + escapeICS(fight.Fighter1+" vs "+fight.Fighter2))
+		if fight.Location != "" {
+			line("LOCATION:" + escapeICS(fight.Location))
+		}
+		if fight.Result != "" {
+			line("DESCRIPTION:" + escapeICS(fight.Result))
+		}
+		line("END:VEVENT")
+	}
+	line("END:VCALENDAR")
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// escapeICS escapes a text value (RFC 5545, 3.3.11)
+// Line breaks become \n; other control characters are not allowed in text
+// values and are dropped.
+func escapeICS(text string) string {
+	text = strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`, "\r", `\n`).Replace(text)
+	return strings.Map(func(r rune) rune {
+		if r != '\t' && (r < 0x20 || r == 0x7F) {
+			return -1
+		}
+		return r
+	}, text)
+}
+
+// foldICSLine terminates a content line with CRLF, folding it at the octet
+// limit without splitting a UTF-8 character
+func foldICSLine(text string) string {
+	var b strings.Builder
+	limit := icsLineLimit
+	for len(text) > limit {
+		cut := limit
+		for cut > 0 && !isRuneStart(text[cut]) {
+			cut--
+		}
+		b.WriteString(text[:cut])
+		b.WriteString("\r\n ")
+		text = text[cut:]
+		// Continuation lines start with a space
+		limit = icsLineLimit - 1
+	}
+	b.WriteString(text)
+	b.WriteString("\r\n")
+
+	return b.String()
+}
+
+// isRuneStart reports whether the byte starts a UTF-8 character
+func isRuneStart(c byte) bool {
+	return c&0xC0 != 0x80
+}
@@ -0,0 +1,230 @@
+package render
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"easypars/models"
+)
+
+// icsProperty is a content line of an iCalendar stream
+type icsProperty struct {
+	name   string
+	params string
+	value  string
+}
+
+// parseICS reads an iCalendar stream the way a calendar client does
+// Every physical line must end with CRLF, stay within 75 octets and hold
+// whole UTF-8 characters; folded lines are joined before the properties
+// are split into name, parameters and value.
+func parseICS(t *testing.T, data []byte) []icsProperty {
+	t.Helper()
+
+	if !bytes.HasSuffix(data, []byte("\r\n")) {
+		t.Fatalf("the calendar does not end with CRLF")
+	}
+	var logical []string
+	for i, line := range strings.Split(strings.TrimSuffix(string(data), "\r\n"), "\r\n") {
+		if strings.ContainsAny(line, "\r\n") {
+			t.Errorf("line %d has a bare line break: %q", i+1, line)
+		}
+		if len(line) > icsLineLimit {
+			t.Errorf("line %d has %d octets, want at most %d: %q", i+1, len(line), icsLineLimit, line)
+		}
+		if !utf8.ValidString(line) {
+			t.Errorf("line %d splits a UTF-8 character: %q", i+1, line)
+		}
+		if strings.HasPrefix(line, " ") && len(logical) > 0 {
+			logical[len(logical)-1] += line[1:]
+			continue
+		}
+		logical = append(logical, line)
+	}
+
+	properties := make([]icsProperty, 0, len(logical))
+	for _, line := range logical {
+		head, value, ok := strings.Cut(line, ":")
+		if !ok {
+			t.Fatalf("content line without a value: %q", line)
+		}
+		name, params, _ := strings.Cut(head, ";")
+		properties = append(properties, icsProperty{name: name, params: params, value: value})
+	}
+
+	return properties
+}
+
+// icsEvents returns the properties of every VEVENT by name
+func icsEvents(properties []icsProperty) []map[string]icsProperty {
+	var events []map[string]icsProperty
+	var event map[string]icsProperty
+	for _, property := range properties {
+		switch {
+		case property.name == "BEGIN" && property.value == "VEVENT":
+			event = make(map[string]icsProperty)
+		case property.name == "END" && property.value == "VEVENT":
+			events = append(events, event)
+			event = nil
+		case event != nil:
+			event[property.name] = property
+		}
+	}
+
+	return events
+}
+
+// unescapeICS reads a text value back (RFC 5545, 3.3.11)
+func unescapeICS(text string) string {
+	return strings.NewReplacer(`\\`, `\`, `\;`, ";", `\,`, ",", `\n`, "\n", `\N`, "\n").Replace(text)
+}
+
+func TestICSEvents(t *testing.T) {
+	longName := strings.Repeat("Александр Усик ", 6)
+	tests := []struct {
+		name     string
+		fight    models.Fight
+		summary  string
+		location string
+	}{
+		{
+			name:     "plain",
+			fight:    models.Fight{Date: "2024-06-22", Fighter1: "Canelo Alvarez", Fighter2: "Edgar Berlanga", Location: "Las Vegas"},
+			summary:  "Canelo Alvarez vs Edgar Berlanga",
+			location: "Las Vegas",
+		},
+		{
+			name:     "commas and semicolons",
+			fight:    models.Fight{Date: "2024-05-18", Fighter1: "Usyk, Oleksandr", Fighter2: "Fury; Tyson", Location: `Kingdom Arena, Riyadh; \Saudi Arabia`},
+			summary:  "Usyk, Oleksandr vs Fury; Tyson",
+			location: `Kingdom Arena, Riyadh; \Saudi Arabia`,
+		},
+		{
+			name:     "line breaks",
+			fight:    models.Fight{Date: "2024-06-01", Fighter1: "Bivol\nDmitry", Fighter2: "Zinad\r\nMalik", Location: "Riyadh\rKingdom Arena"},
+			summary:  "Bivol\nDmitry vs Zinad\nMalik",
+			location: "Riyadh\nKingdom Arena",
+		},
+		{
+			name:     "control characters",
+			fight:    models.Fight{Date: "2024-06-01", Fighter1: "Bivol\x00", Fighter2: "Zinad\x7f", Location: "Riy\x1badh"},
+			summary:  "Bivol vs Zinad",
+			location: "Riyadh",
+		},
+		{
+			name:     "long Cyrillic names",
+			fight:    models.Fight{Date: "2024-12-21", Fighter1: longName, Fighter2: "Тайсон Фьюри", Location: "Эр-Рияд"},
+			summary:  longName + " vs Тайсон Фьюри",
+			location: "Эр-Рияд",
+		},
+		{
+			name:    "no location",
+			fight:   models.Fight{Date: "2024-12-21", Fighter1: "Usyk", Fighter2: "Fury"},
+			summary: "Usyk vs Fury",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fight := tt.fight
+			fight.AssignKey()
+			var buf bytes.Buffer
+			if err := (icsFormatter{}).Encode(&buf, ResponsePayload{Fights: []models.Fight{fight}}); err != nil {
+				t.Fatalf("Encode: %v", err)
+			}
+
+			events := icsEvents(parseICS(t, buf.Bytes()))
+			if len(events) != 1 {
+				t.Fatalf("calendar has %d events, want 1", len(events))
+			}
+			event := events[0]
+			// The UID is the fight key, without the control characters a
+			// text value cannot hold
+			uid := strings.Map(func(r rune) rune {
+				if r < 0x20 || r == 0x7F {
+					return -1
+				}
+				return r
+			}, fight.Key) + "@easypars"
+			if got := unescapeICS(event["UID"].value); got != uid {
+				t.Errorf("UID = %q, want the fight key %q", got, uid)
+			}
+			if got := event["SUMMARY"].value; unescapeICS(got) != tt.summary {
+				t.Errorf("SUMMARY = %q, want %q", unescapeICS(got), tt.summary)
+			}
+			location, ok := event["LOCATION"]
+			if ok != (tt.location != "") || unescapeICS(location.value) != tt.location {
+				t.Errorf("LOCATION = %q (set %v), want %q", unescapeICS(location.value), ok, tt.location)
+			}
+			date := strings.ReplaceAll(fight.Date, "-", "")
+			if start := event["DTSTART"]; start.params != "VALUE=DATE" || start.value != date {
+				t.Errorf("DTSTART = %+v, want the all-day date %s", start, date)
+			}
+		})
+	}
+}
+
+func TestICSCalendar(t *testing.T) {
+	fights := []models.Fight{
+		{Date: "2024-06-22", Fighter1: "Canelo Alvarez", Fighter2: "Edgar Berlanga"},
+		{Date: "TBA", Fighter1: "Daniel Dubois", Fighter2: "Anthony Joshua"},
+		{Date: "", Fighter1: "Tyson Fury", Fighter2: "Oleksandr Usyk"},
+	}
+	var buf bytes.Buffer
+	if err := (icsFormatter{}).Encode(&buf, ResponsePayload{Fights: fights}); err != nil {
+		t.Fatalf("Encode: %v", err)
+	}
+	properties := parseICS(t, buf.Bytes())
+
+	calendar := make(map[string]string)
+	for _, property := range properties {
+		if property.name == "BEGIN" && property.value == "VEVENT" {
+			break
+		}
+		calendar[property.name] = property.value
+	}
+	for name, want := range map[string]string{
+		"VERSION":          "2.0",
+		"METHOD":           "PUBLISH",
+		"REFRESH-INTERVAL": icsRefreshInterval,
+		"X-PUBLISHED-TTL":  icsRefreshInterval,
+	} {
+		if calendar[name] != want {
+			t.Errorf("%s = %q, want %q", name, calendar[name], want)
+		}
+	}
+	if events := icsEvents(properties); len(events) != 1 {
+		t.Errorf("calendar has %d events, want the dated fight only", len(events))
+	}
+}
+
+func TestFoldICSLine(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		// lines is the number of physical lines
+		lines int
+	}{
+		{"short", "SUMMARY:Usyk vs Fury", 1},
+		{"exactly the limit", "SUMMARY:" + strings.Repeat("a", icsLineLimit-8), 1},
+		{"one octet over", "SUMMARY:" + strings.Repeat("a", icsLineLimit-7), 2},
+		{"three lines", "DESCRIPTION:" + strings.Repeat("a", 2*icsLineLimit-12), 3},
+		{"two-byte characters", "SUMMARY:" + strings.Repeat("ж", 60), 2},
+		{"four-byte characters", "SUMMARY:" + strings.Repeat("🥊", 40), 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			folded := foldICSLine(tt.text)
+			if properties := parseICS(t, []byte(folded)); len(properties) != 1 {
+				t.Errorf("folded line reads as %d properties, want 1", len(properties))
+			}
+			if got := strings.Count(folded, "\r\n"); got != tt.lines {
+				t.Errorf("folded into %d lines, want %d", got, tt.lines)
+			}
+			if got := strings.ReplaceAll(strings.TrimSuffix(folded, "\r\n"), "\r\n ", ""); got != tt.text {
+				t.Errorf("unfolded = %q, want %q", got, tt.text)
+			}
+		})
+	}
+}
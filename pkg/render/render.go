@@ -0,0 +1,262 @@
+// Package render encodes API responses in the format the client asked for
+// Formatters are kept in a registry and selected by the ?format= query
+// parameter or, without it, by the Accept header.
+package render
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"easypars/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// DefaultFormat is used when the client does not ask for a format
+const DefaultFormat = "json"
+
+// ResponsePayload is the data of a response independent of its format
+type ResponsePayload struct {
+	// Message is the human readable summary of the response
+	Message string
+	// Fights are the records of the response, used by row based formats
+	Fights []models.Fight
+	// Body is the complete JSON response; the fights are encoded when nil
+	Body any
+}
+
+// ResponseFormatter encodes a payload in one format
+type ResponseFormatter interface {
+	// ContentType is the value of the Content-Type header
+	ContentType() string
+	// Encode writes the payload to w
+	Encode(w io.Writer, payload ResponsePayload) error
+}
+
+// registry holds the formatters by name
+var registry = struct {
+	sync.RWMutex
+	formatters map[string]ResponseFormatter
+}{formatters: make(map[string]ResponseFormatter)}
+
+// Register adds a formatter under the given ?format= name
+// Registering a name again replaces the formatter
+func Register(name string, formatter ResponseFormatter) {
+	registry.Lock()
+	defer registry.Unlock()
+
+	registry.formatters[name] = formatter
+}
+
+// Formats returns the registered format names in alphabetical order
+func Formats() []string {
+	registry.RLock()
+	defer registry.RUnlock()
+
+	names := make([]string, 0, len(registry.formatters))
+	for name := range registry.formatters {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// lookup returns the formatter registered under the name
+func lookup(name string) (ResponseFormatter, bool) {
+	registry.RLock()
+	defer registry.RUnlock()
+
+	formatter, ok := registry.formatters[name]
+	return formatter, ok
+}
+
+func init() {
+	Register("json", jsonFormatter{})
+	Register("ndjson", ndjsonFormatter{})
+	Register("csv", csvFormatter{})
+	Register("xml", xmlFormatter{})
+	Register("ics", icsFormatter{})
+}
+
+// Negotiate writes the payload in the format selected for the request
+// The ?format= parameter takes priority over the Accept header. A format
+// that is not registered, or an Accept header no formatter satisfies,
+// is answered with 406 listing the supported formats.
+func Negotiate(c *gin.Context, status int, payload ResponsePayload) {
+	name, ok := selectFormat(c.Query("format"), c.GetHeader("Accept"))
+	if !ok {
+		respondNotAcceptable(c)
+		return
+	}
+
+	formatter, _ := lookup(name)
+	c.Status(status)
+	c.Header("Content-Type", formatter.ContentType())
+	if err := formatter.Encode(c.Writer, payload); err != nil {
+		// The status line is already written, the error can only be recorded
+		_ = c.Error(fmt.Errorf("error encoding %s response: %w", name, err))
+	}
+}
+
+// Acceptable checks the requested format before any work is done
+// It writes the 406 response and returns false when no formatter fits
+func Acceptable(c *gin.Context) bool {
+	if _, ok := selectFormat(c.Query("format"), c.GetHeader("Accept")); !ok {
+		respondNotAcceptable(c)
+		return false
+	}
+
+	return true
+}
+
+// respondNotAcceptable writes the 406 response listing the supported formats
+func respondNotAcceptable(c *gin.Context) {
+	c.JSON(http.StatusNotAcceptable, gin.H{
+		"error":     "not_acceptable",
+		"message":   "supported formats: " + strings.Join(Formats(), ", "),
+		"supported": Formats(),
+	})
+}
+
+// selectFormat picks the format name for the query parameter and Accept header
+func selectFormat(query, accept string) (string, bool) {
+	if query != "" {
+		_, ok := lookup(query)
+		return query, ok
+	}
+	if strings.TrimSpace(accept) == "" {
+		return DefaultFormat, true
+	}
+
+	for _, accepted := range parseAccept(accept) {
+		if accepted.q <= 0 {
+			continue
+		}
+		if name, ok := formatForMediaType(accepted.mediaType); ok {
+			return name, true
+		}
+	}
+
+	return "", false
+}
+
+// formatForMediaType returns the format serving a media range
+// Wildcards and the HTML ranges of browsers select the default format, so
+// a browser (text/html,...,application/xml;q=0.9,*/*;q=0.8) gets JSON as it
+// always did; other formats are only chosen when their media type is named.
+func formatForMediaType(mediaType string) (string, bool) {
+	switch {
+	case strings.HasSuffix(mediaType, "/*"), mediaType == "text/html", mediaType == "application/xhtml+xml":
+		return DefaultFormat, true
+	case mediaType == "text/xml":
+		mediaType = "application/xml"
+	}
+
+	// Walk the formats in a fixed order, so the result does not depend on
+	// the map order of the registry
+	for _, name := range Formats() {
+		formatter, _ := lookup(name)
+		contentType := strings.TrimSpace(strings.SplitN(formatter.ContentType(), ";", 2)[0])
+		if contentType == mediaType {
+			return name, true
+		}
+	}
+
+	return "", false
+}
+
+// Preferred returns the candidate media type the Accept header rates
+// highest; ok is false when the header names none of the candidates
+// Wildcards are not matched, so a client sending */* gets the default of
+// the caller.
+func Preferred(accept string, candidates ...string) (string, bool) {
+	for _, accepted := range parseAccept(accept) {
+		if accepted.q <= 0 {
+			continue
+		}
+		for _, candidate := range candidates {
+			if accepted.mediaType == candidate {
+				return candidate, true
+			}
+		}
+	}
+
+	return "", false
+}
+
+// acceptRange is a media range of the Accept header with its quality
+type acceptRange struct {
+	mediaType string
+	q         float64
+}
+
+// parseAccept parses an Accept header into media ranges ordered by
+// quality, highest first; ranges of equal quality keep the header order
+func parseAccept(header string) []acceptRange {
+	var ranges []acceptRange
+	for _, part := range strings.Split(header, ",") {
+		params := strings.Split(part, ";")
+		mediaType := strings.ToLower(strings.TrimSpace(params[0]))
+		if mediaType == "" {
+			continue
+		}
+
+		q := 1.0
+		for _, param := range params[1:] {
+			key, value, ok := strings.Cut(strings.TrimSpace(param), "=")
+			if !ok || strings.ToLower(strings.TrimSpace(key)) != "q" {
+				continue
+			}
+			if parsed, err := strconv.ParseFloat(strings.TrimSpace(value), 64); err == nil {
+				q = parsed
+			}
+		}
+		ranges = append(ranges, acceptRange{mediaType: mediaType, q: q})
+	}
+
+	sort.SliceStable(ranges, func(i, j int) bool { return ranges[i].q > ranges[j].q })
+
+	return ranges
+}
+
+// jsonFormatter encodes the complete response as JSON
+type jsonFormatter struct{}
+
+func (jsonFormatter) ContentType() string { return "application/json; charset=utf-8" }
+
+func (jsonFormatter) Encode(w io.Writer, payload ResponsePayload) error {
+	body := payload.Body
+	if body == nil {
+		body = payload.Fights
+	}
+
+	data, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(data)
+	return err
+}
+
+// ndjsonFormatter writes one JSON fight per line
+type ndjsonFormatter struct{}
+
+func (ndjsonFormatter) ContentType() string { return "application/x-ndjson" }
+
+func (ndjsonFormatter) Encode(w io.Writer, payload ResponsePayload) error {
+	encoder := json.NewEncoder(w)
+	for _, fight := range payload.Fights {
+		if err := encoder.Encode(fight); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
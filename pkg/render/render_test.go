@@ -0,0 +1,153 @@
+package render
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+
+	"easypars/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files")
+
+func init() {
+	gin.SetMode(gin.TestMode)
+}
+
+// fixturePayload returns the payload of testdata/fights.json
+func fixturePayload(t *testing.T) ResponsePayload {
+	t.Helper()
+
+	data, err := os.ReadFile(filepath.Join("testdata", "fights.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var fights []models.Fight
+	if err := json.Unmarshal(data, &fights); err != nil {
+		t.Fatal(err)
+	}
+
+	return ResponsePayload{Message: "Fights retrieved successfully", Fights: fights}
+}
+
+// icsStamp matches the DTSTAMP lines, the time the calendar was written
+var icsStamp = regexp.MustCompile(`DTSTAMP:\d{8}T\d{6}Z`)
+
+func TestFormattersGolden(t *testing.T) {
+	payload := fixturePayload(t)
+
+	for _, name := range Formats() {
+		t.Run(name, func(t *testing.T) {
+			formatter, _ := lookup(name)
+			var buf bytes.Buffer
+			if err := formatter.Encode(&buf, payload); err != nil {
+				t.Fatalf("Encode: %v", err)
+			}
+			got := icsStamp.ReplaceAll(buf.Bytes(), []byte("DTSTAMP:<time>"))
+
+			path := filepath.Join("testdata", name+".golden")
+			if *update {
+				if err := os.WriteFile(path, got, 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			want, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatalf("reading the golden file (run with -update to create it): %v", err)
+			}
+			if !bytes.Equal(got, want) {
+				t.Errorf("%s output differs from %s:\n%s", name, path, got)
+			}
+		})
+	}
+}
+
+func TestSelectFormat(t *testing.T) {
+	tests := []struct {
+		name   string
+		query  string
+		accept string
+		want   string
+		ok     bool
+	}{
+		{"no preference", "", "", "json", true},
+		{"wildcard", "", "*/*", "json", true},
+		{"browser", "", "text/html,application/xhtml+xml,application/xml;q=0.9,image/avif,image/webp,*/*;q=0.8", "json", true},
+		{"html only", "", "text/html", "json", true},
+		{"text wildcard", "", "text/*", "json", true},
+		{"xml", "", "application/xml", "xml", true},
+		{"text/xml", "", "text/xml", "xml", true},
+		{"csv", "", "text/csv", "csv", true},
+		{"ndjson", "", "application/x-ndjson", "ndjson", true},
+		{"calendar", "", "text/calendar", "ics", true},
+		{"q values", "", "application/xml;q=0.5, text/csv;q=0.8", "csv", true},
+		{"refused type", "", "application/xml;q=0, application/json", "json", true},
+		{"unsupported type", "", "image/png", "", false},
+		{"query over header", "csv", "application/xml", "csv", true},
+		{"query xml", "xml", "text/html", "xml", true},
+		{"unknown query", "yaml", "", "yaml", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := selectFormat(tt.query, tt.accept)
+			if got != tt.want || ok != tt.ok {
+				t.Errorf("selectFormat(%q, %q) = %q, %v, want %q, %v", tt.query, tt.accept, got, ok, tt.want, tt.ok)
+			}
+		})
+	}
+}
+
+func TestNegotiate(t *testing.T) {
+	payload := fixturePayload(t)
+	router := gin.New()
+	router.GET("/fights", func(c *gin.Context) {
+		if !Acceptable(c) {
+			return
+		}
+		Negotiate(c, http.StatusOK, payload)
+	})
+
+	tests := []struct {
+		target      string
+		accept      string
+		status      int
+		contentType string
+	}{
+		{"/fights", "text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8", http.StatusOK, "application/json; charset=utf-8"},
+		{"/fights", "application/xml", http.StatusOK, "application/xml; charset=utf-8"},
+		{"/fights?format=ics", "application/xml", http.StatusOK, "text/calendar; charset=utf-8"},
+		{"/fights?format=yaml", "", http.StatusNotAcceptable, "application/json; charset=utf-8"},
+		{"/fights", "image/png", http.StatusNotAcceptable, "application/json; charset=utf-8"},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodGet, tt.target, nil)
+		if tt.accept != "" {
+			req.Header.Set("Accept", tt.accept)
+		}
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+
+		if rec.Code != tt.status || rec.Header().Get("Content-Type") != tt.contentType {
+			t.Errorf("GET %s (Accept %q) = %d %s, want %d %s", tt.target, tt.accept, rec.Code, rec.Header().Get("Content-Type"), tt.status, tt.contentType)
+		}
+		if tt.status == http.StatusNotAcceptable {
+			var body struct {
+				Error     string   `json:"error"`
+				Supported []string `json:"supported"`
+			}
+			data, _ := io.ReadAll(rec.Body)
+			if err := json.Unmarshal(data, &body); err != nil || body.Error != "not_acceptable" || len(body.Supported) != len(Formats()) {
+				t.Errorf("GET %s 406 body = %s, want not_acceptable with the supported formats", tt.target, data)
+			}
+		}
+	}
+}
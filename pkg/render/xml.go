@@ -0,0 +1,127 @@
+package render
+
+import (
+	"encoding/xml"
+	"io"
+	"sort"
+)
+
+// xmlResponse is the XML document of a fight list
+// Element names are fixed ASCII names; the data, Cyrillic included, is
+// written as UTF-8 text and attribute values
+type xmlResponse struct {
+	XMLName xml.Name   `xml:"fights"`
+	Message string     `xml:"message,attr,omitempty"`
+	Count   int        `xml:"count,attr"`
+	Fights  []xmlFight `xml:"fight"`
+}
+
+// xmlFight is a single fight element
+type xmlFight struct {
+	Key           string       `xml:"key,attr"`
+	Slug          string       `xml:"slug,attr,omitempty"`
+	Status        string       `xml:"status,attr,omitempty"`
+	CardPosition  int          `xml:"card_position,attr,omitempty"`
+	Rematch       bool         `xml:"rematch,attr,omitempty"`
+	Date          string       `xml:"date"`
+	Fighter1      xmlFighter   `xml:"fighter1"`
+	Fighter2      xmlFighter   `xml:"fighter2"`
+	Result        string       `xml:"result"`
+	Location      string       `xml:"location"`
+	Confidence    float64      `xml:"confidence"`
+	InterestScore float64      `xml:"interest_score,omitempty"`
+	Warnings      *xmlWarnings `xml:"warnings,omitempty"`
+	Previous      *xmlMeetings `xml:"previous_meetings,omitempty"`
+}
+
+// xmlWarnings wraps the warning elements, so the wrapper is left out
+// entirely when there are no warnings
+type xmlWarnings struct {
+	Items []string `xml:"warning"`
+}
+
+// xmlMeetings wraps the meeting elements of a rematch
+type xmlMeetings struct {
+	Items []xmlMeeting `xml:"meeting"`
+}
+
+// xmlFighter is a fighter name with its external IDs
+type xmlFighter struct {
+	Name        string          `xml:",chardata"`
+	ExternalIDs []xmlExternalID `xml:"external_id,omitempty"`
+}
+
+// xmlExternalID is an ID of the fighter in an external source
+type xmlExternalID struct {
+	Source string `xml:"source,attr"`
+	ID     string `xml:",chardata"`
+}
+
+// xmlMeeting references an earlier fight of the same pair
+// It mirrors models.PreviousMeeting field by field
+type xmlMeeting struct {
+	Key    string `xml:"key,attr"`
+	Date   string `xml:"date,attr"`
+	Result string `xml:",chardata"`
+}
+
+// xmlFormatter writes the fights as an XML document
+type xmlFormatter struct{}
+
+func (xmlFormatter) ContentType() string { return "application/xml; charset=utf-8" }
+
+func (xmlFormatter) Encode(w io.Writer, payload ResponsePayload) error {
+	doc := xmlResponse{
+		Message: payload.Message,
+		Count:   len(payload.Fights),
+		Fights:  make([]xmlFight, 0, len(payload.Fights)),
+	}
+	for _, fight := range payload.Fights {
+		item := xmlFight{
+			Key:           fight.Key,
+			Slug:          fight.Slug,
+			Status:        fight.Status,
+			CardPosition:  fight.CardPosition,
+			Rematch:       fight.Rematch,
+			Date:          fight.Date,
+			Fighter1:      xmlFighter{Name: fight.Fighter1, ExternalIDs: xmlExternalIDs(fight.Fighter1ExternalIDs)},
+			Fighter2:      xmlFighter{Name: fight.Fighter2, ExternalIDs: xmlExternalIDs(fight.Fighter2ExternalIDs)},
+			Result:        fight.Result,
+			Location:      fight.Location,
+			Confidence:    fight.Confidence,
+			InterestScore: fight.InterestScore,
+		}
+		if len(fight.Warnings) > 0 {
+			item.Warnings = &xmlWarnings{Items: fight.Warnings}
+		}
+		if len(fight.PreviousMeetings) > 0 {
+			item.Previous = &xmlMeetings{}
+			for _, meeting := range fight.PreviousMeetings {
+				item.Previous.Items = append(item.Previous.Items, xmlMeeting(meeting))
+			}
+		}
+		doc.Fights = append(doc.Fights, item)
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	return encoder.Encode(doc)
+}
+
+// xmlExternalIDs converts an ID map into elements sorted by source
+func xmlExternalIDs(ids map[string]string) []xmlExternalID {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	elements := make([]xmlExternalID, 0, len(ids))
+	for source, id := range ids {
+		elements = append(elements, xmlExternalID{Source: source, ID: id})
+	}
+	sort.Slice(elements, func(i, j int) bool { return elements[i].Source < elements[j].Source })
+
+	return elements
+}
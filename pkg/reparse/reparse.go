@@ -0,0 +1,163 @@
+// Package reparse applies the current parsing rules to stored fights
+// Fights are parsed again from the raw source text kept with them, so
+// improvements of the parser reach historical records without any request
+// to the source.
+package reparse
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"easypars/models"
+	"easypars/pkg/history"
+	"easypars/pkg/parser"
+	"easypars/pkg/storage"
+)
+
+// TriggerReparse marks reparse runs in the parse history
+const TriggerReparse = "reparse"
+
+// maxExamples bounds the number of changes listed in a report
+const maxExamples = 20
+
+// Change describes a stored fight whose parsed values changed
+type Change struct {
+	Key string `json:"key"`
+	// NewKey is set when the natural key changed with the values
+	NewKey string               `json:"new_key,omitempty"`
+	Fields []parser.FieldChange `json:"fields"`
+}
+
+// Report summarizes a reparse run
+type Report struct {
+	DryRun bool `json:"dry_run"`
+	// RunID identifies the parse history run holding the change log
+	RunID     string `json:"run_id,omitempty"`
+	Total     int    `json:"total"`
+	Changed   int    `json:"changed"`
+	Unchanged int    `json:"unchanged"`
+	// Skipped counts fights stored without raw text
+	Skipped int `json:"skipped"`
+	// Conflicts counts changed fights whose new key belongs to another
+	// stored fight; they are left untouched
+	Conflicts int `json:"conflicts"`
+	// Fields counts the changes per field
+	Fields   map[string]int        `json:"fields"`
+	Examples []Change              `json:"examples"`
+	Applied  *storage.UpsertResult `json:"applied,omitempty"`
+}
+
+// Run reparses every stored fight
+// With dryRun the changes are only counted. Otherwise they are written to
+// storage and every change is logged into a parse history run, which keeps
+// the revision trail (old and new value of each field).
+// The source is not requested, so every error is internal.
+func Run(ctx context.Context, p *parser.Parser, repo storage.FightRepository, hist *history.History, dryRun bool) (Report, error) {
+	report := Report{DryRun: dryRun, Fields: make(map[string]int), Examples: []Change{}}
+
+	stored, err := repo.List(ctx, storage.FightFilter{})
+	if err != nil {
+		return report, parser.Classify(parser.ErrorOriginInternal, err)
+	}
+	report.Total = len(stored)
+
+	existing := make(map[string]bool, len(stored))
+	for _, fight := range stored {
+		existing[fight.Key] = true
+	}
+
+	// Step 1: Reparse and compare
+	var updated []models.Fight
+	var changes []Change
+	for _, fight := range stored {
+		reparsed, fieldChanges, ok := p.ReparseFight(fight)
+		if !ok {
+			report.Skipped++
+			continue
+		}
+		if len(fieldChanges) == 0 {
+			report.Unchanged++
+			continue
+		}
+
+		change := Change{Key: fight.Key, Fields: fieldChanges}
+		if reparsed.Key != fight.Key {
+			if existing[reparsed.Key] {
+				report.Conflicts++
+				continue
+			}
+			change.NewKey = reparsed.Key
+			existing[reparsed.Key] = true
+		}
+
+		report.Changed++
+		for _, fieldChange := range fieldChanges {
+			report.Fields[fieldChange.Field]++
+		}
+		if len(report.Examples) < maxExamples {
+			report.Examples = append(report.Examples, change)
+		}
+		changes = append(changes, change)
+		updated = append(updated, reparsed)
+	}
+
+	if dryRun || len(updated) == 0 {
+		return report, nil
+	}
+
+	// Step 2: Apply the changes within a history run
+	runCtx := ctx
+	if hist != nil {
+		run := hist.Start(TriggerReparse)
+		report.RunID = run.ID
+		runCtx = history.WithRunID(ctx, run.ID)
+	}
+
+	applied, err := apply(runCtx, logger(p), repo, updated, changes)
+	if hist != nil {
+		hist.Finish(report.RunID, history.RunResult{FightCount: len(updated), Err: err})
+	}
+	if err != nil {
+		return report, parser.Classify(parser.ErrorOriginInternal, err)
+	}
+	report.Applied = &applied
+
+	return report, nil
+}
+
+// apply writes reparsed fights and removes the records of changed keys
+func apply(ctx context.Context, log *slog.Logger, repo storage.FightRepository, updated []models.Fight, changes []Change) (storage.UpsertResult, error) {
+	result, err := repo.UpsertFights(ctx, updated)
+	if err != nil {
+		return result, err
+	}
+
+	for _, change := range changes {
+		for _, field := range change.Fields {
+			log.InfoContext(ctx, "Fight reparsed",
+				"key", change.Key,
+				"field", field.Field,
+				"old", field.Old,
+				"new", field.New)
+		}
+		if change.NewKey == "" {
+			continue
+		}
+		if err := repo.Delete(ctx, change.Key); err != nil {
+			return result, fmt.Errorf("error removing the old record of %s: %w", change.Key, err)
+		}
+	}
+
+	log.InfoContext(ctx, "Reparse applied", "changed", len(updated), "inserted", result.Inserted, "updated", result.Updated)
+
+	return result, nil
+}
+
+// logger returns the parser logger, so records reach the run history
+func logger(p *parser.Parser) *slog.Logger {
+	if p.Logger != nil {
+		return p.Logger
+	}
+	return slog.Default()
+}
@@ -0,0 +1,170 @@
+package reparse
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"easypars/models"
+	"easypars/pkg/clock"
+	"easypars/pkg/history"
+	"easypars/pkg/parser"
+	"easypars/pkg/storage"
+)
+
+// storedFights are a fight parsed before the method dictionary knew the
+// Russian knockout, a fight parsed by the current rules and a fight stored
+// without raw text
+func storedFights() []models.Fight {
+	fights := []models.Fight{
+		{
+			Date: "2024-05-18", Fighter1: "Oleksandr Usyk", Fighter2: "Tyson Fury", Location: "Riyadh",
+			Result: "нокаут в 3 раунде", ResultType: models.ResultUnknown, Status: models.StatusCompleted,
+			Fighter1Record: &models.FighterRecord{Wins: 22}, Fighter2Record: &models.FighterRecord{Wins: 34, Draws: 1},
+			Raw: &models.RawFields{DateText: "18", ResultText: "нокаут в 3 раунде", LocationText: "Riyadh", Boxer1Text: "Oleksandr Usyk (22-0)", Boxer2Text: "Tyson Fury (34-0-1)", RefMonth: "2024-05"},
+		},
+		{
+			Date: "2024-06-01", Fighter1: "Dmitry Bivol", Fighter2: "Malik Zinad", Location: "Riyadh",
+			Result: "UD", ResultType: models.ResultUD, Status: models.StatusCompleted,
+			Raw: &models.RawFields{DateText: "1", ResultText: "UD", LocationText: "Riyadh", Boxer1Text: "Dmitry Bivol", Boxer2Text: "Malik Zinad", RefMonth: "2024-06"},
+		},
+		{Date: "2024-06-02", Fighter1: "Artur Beterbiev", Fighter2: "Callum Smith", Location: "Quebec", Result: "TKO 7", ResultType: models.ResultTKO, Round: 7, Status: models.StatusCompleted},
+	}
+	for i := range fights {
+		fights[i].AssignKey()
+	}
+
+	return fights
+}
+
+// newReparseSetup returns a file store holding storedFights and a parser
+// logging into the run history
+func newReparseSetup(t *testing.T) (*parser.Parser, storage.FightRepository, *history.History) {
+	t.Helper()
+
+	repo, err := storage.OpenFile(filepath.Join(t.TempDir(), "fights.json"), false, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := repo.UpsertFights(context.Background(), storedFights()); err != nil {
+		t.Fatal(err)
+	}
+	hist := history.New(10, 100)
+	p := parser.NewParser("https://vringe.example/")
+	p.Clock = clock.Fixed{Time: time.Date(2024, time.June, 10, 12, 0, 0, 0, time.UTC)}
+	p.Logger = slog.New(history.NewRunLogHandler(slog.NewTextHandler(io.Discard, nil), hist))
+
+	return p, repo, hist
+}
+
+func TestRun(t *testing.T) {
+	tests := []struct {
+		name   string
+		dryRun bool
+	}{
+		{"dry run", true},
+		{"applied", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, repo, hist := newReparseSetup(t)
+			ctx := context.Background()
+			usyk := storedFights()[0]
+
+			report, err := Run(ctx, p, repo, hist, tt.dryRun)
+			if err != nil {
+				t.Fatalf("Run: %v", err)
+			}
+			if report.Total != 3 || report.Changed != 1 || report.Unchanged != 1 || report.Skipped != 1 || report.Conflicts != 0 {
+				t.Errorf("report = %+v, want 3 fights: 1 changed, 1 unchanged, 1 skipped", report)
+			}
+			if report.Fields["result_type"] != 1 || report.Fields["round"] != 1 || len(report.Fields) != 2 {
+				t.Errorf("changed fields = %v, want result_type and round", report.Fields)
+			}
+			if len(report.Examples) != 1 || report.Examples[0].Key != usyk.Key {
+				t.Errorf("examples = %+v, want the change of %s", report.Examples, usyk.Key)
+			}
+
+			stored, err := repo.GetByKey(ctx, usyk.Key)
+			if err != nil {
+				t.Fatal(err)
+			}
+			runs := hist.List()
+			if tt.dryRun {
+				if stored.ResultType != models.ResultUnknown || stored.Round != 0 {
+					t.Errorf("a dry run wrote %s in round %d", stored.ResultType, stored.Round)
+				}
+				if len(runs) != 0 || report.RunID != "" || report.Applied != nil {
+					t.Errorf("a dry run recorded %d runs (run ID %q)", len(runs), report.RunID)
+				}
+				return
+			}
+
+			if stored.ResultType != models.ResultKO || stored.Round != 3 {
+				t.Errorf("stored fight = %s in round %d, want KO in round 3", stored.ResultType, stored.Round)
+			}
+			if report.Applied == nil || report.Applied.Updated != 1 {
+				t.Errorf("applied = %+v, want one update", report.Applied)
+			}
+			if len(runs) != 1 || runs[0].ID != report.RunID || runs[0].Trigger != TriggerReparse {
+				t.Fatalf("runs = %+v, want one reparse run %s", runs, report.RunID)
+			}
+
+			// The run log keeps the old and the new value of every field
+			logs, _ := hist.Logs(report.RunID)
+			revisions := map[string]string{}
+			for _, entry := range logs {
+				if entry.Message == "Fight reparsed" && entry.Attrs["key"] == usyk.Key {
+					revisions[entry.Attrs["field"]] = entry.Attrs["old"] + " -> " + entry.Attrs["new"]
+				}
+			}
+			want := map[string]string{"result_type": models.ResultUnknown + " -> " + models.ResultKO, "round": "0 -> 3"}
+			if len(revisions) != len(want) || revisions["result_type"] != want["result_type"] || revisions["round"] != want["round"] {
+				t.Errorf("revisions = %v, want %v", revisions, want)
+			}
+			if changes, err := repo.Changes(ctx, usyk.Key); err != nil || len(changes) == 0 {
+				t.Errorf("Changes = %+v (%v), want the reparse in the fight history", changes, err)
+			}
+		})
+	}
+}
+
+func TestRunMovesAChangedKey(t *testing.T) {
+	p, repo, hist := newReparseSetup(t)
+	ctx := context.Background()
+
+	// A name stored with the record moves the fight to the key of the
+	// cleaned name
+	fight := storedFights()[1]
+	fight.Fighter1 = "Dmitry Bivol (23-0)"
+	fight.AssignKey()
+	if err := repo.Delete(ctx, storedFights()[1].Key); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := repo.UpsertFights(ctx, []models.Fight{fight}); err != nil {
+		t.Fatal(err)
+	}
+
+	report, err := Run(ctx, p, repo, hist, false)
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	var moved *Change
+	for i := range report.Examples {
+		if report.Examples[i].Key == fight.Key {
+			moved = &report.Examples[i]
+		}
+	}
+	if moved == nil || moved.NewKey != storedFights()[1].Key {
+		t.Fatalf("examples = %+v, want %s moved to %s", report.Examples, fight.Key, storedFights()[1].Key)
+	}
+	if _, err := repo.GetByKey(ctx, fight.Key); err == nil {
+		t.Error("the record of the old key is still stored")
+	}
+	if _, err := repo.GetByKey(ctx, moved.NewKey); err != nil {
+		t.Errorf("GetByKey of the new key: %v", err)
+	}
+}
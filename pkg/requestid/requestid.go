@@ -0,0 +1,89 @@
+// Package requestid correlates the log records of a single API request
+// The API binds the ID of every request to its context (see WithID); the
+// log handler of this package adds it to the records logged with that
+// context, including the records of the parser while it parses for the
+// request.
+package requestid
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"log/slog"
+	"regexp"
+)
+
+// Header is the request and response header carrying the ID
+const Header = "X-Request-ID"
+
+// maxLength bounds the IDs accepted from clients
+const maxLength = 128
+
+// validPattern matches the IDs accepted from clients: IDs end up in log
+// lines, so control characters and spaces are refused
+var validPattern = regexp.MustCompile(`^[A-Za-z0-9._:-]+$`)
+
+// idKey is the context key holding the request ID
+type idKey struct{}
+
+// New returns a random request ID of 32 hex characters
+func New() string {
+	var b [16]byte
+	_, _ = rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
+
+// Valid reports whether a client supplied ID can be used as is
+func Valid(id string) bool {
+	return len(id) <= maxLength && validPattern.MatchString(id)
+}
+
+// WithID returns a context carrying the request ID
+func WithID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, idKey{}, id)
+}
+
+// FromContext returns the request ID of the context, empty when none
+func FromContext(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	id, _ := ctx.Value(idKey{}).(string)
+	return id
+}
+
+// LogHandler is a slog handler adding the request ID of the context to
+// every record as the request_id attribute
+type LogHandler struct {
+	next slog.Handler
+}
+
+// NewLogHandler wraps next
+func NewLogHandler(next slog.Handler) *LogHandler {
+	return &LogHandler{next: next}
+}
+
+// Enabled reports whether the next handler handles the level
+func (h *LogHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.next.Enabled(ctx, level)
+}
+
+// Handle adds the request ID and forwards the record
+func (h *LogHandler) Handle(ctx context.Context, record slog.Record) error {
+	if id := FromContext(ctx); id != "" {
+		record = record.Clone()
+		record.AddAttrs(slog.String("request_id", id))
+	}
+
+	return h.next.Handle(ctx, record)
+}
+
+// WithAttrs returns a handler that includes the attributes in every record
+func (h *LogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &LogHandler{next: h.next.WithAttrs(attrs)}
+}
+
+// WithGroup returns a handler that nests attributes under the group
+func (h *LogHandler) WithGroup(name string) slog.Handler {
+	return &LogHandler{next: h.next.WithGroup(name)}
+}
@@ -0,0 +1,147 @@
+package requestid
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestValid(t *testing.T) {
+	tests := []struct {
+		id   string
+		want bool
+	}{
+		{"0123456789abcdef0123456789abcdef", true},
+		{"frontend-7f3a.2:retry_1", true},
+		{strings.Repeat("a", maxLength), true},
+		{strings.Repeat("a", maxLength+1), false},
+		{"", false},
+		{"two words", false},
+		{"line\nbreak", false},
+		{"semi;colon", false},
+		{"кириллица", false},
+	}
+	for _, tt := range tests {
+		if got := Valid(tt.id); got != tt.want {
+			t.Errorf("Valid(%q) = %v, want %v", tt.id, got, tt.want)
+		}
+	}
+}
+
+func TestNew(t *testing.T) {
+	seen := make(map[string]bool)
+	for i := 0; i < 100; i++ {
+		id := New()
+		if len(id) != 32 || !Valid(id) {
+			t.Fatalf("New = %q, want 32 hex characters", id)
+		}
+		if seen[id] {
+			t.Fatalf("New returned %s twice", id)
+		}
+		seen[id] = true
+	}
+}
+
+func TestFromContext(t *testing.T) {
+	if id := FromContext(context.Background()); id != "" {
+		t.Errorf("ID of a plain context = %q, want none", id)
+	}
+	if id := FromContext(nil); id != "" {
+		t.Errorf("ID of a nil context = %q, want none", id)
+	}
+	ctx := WithID(context.Background(), "outer")
+	if id := FromContext(WithID(ctx, "inner")); id != "inner" {
+		t.Errorf("ID of a nested context = %q, want inner", id)
+	}
+}
+
+func TestLogHandler(t *testing.T) {
+	tests := []struct {
+		name string
+		ctx  context.Context
+		// log logs a record with the logger
+		log  func(ctx context.Context, logger *slog.Logger)
+		want map[string]any
+	}{
+		{
+			name: "request record",
+			ctx:  WithID(context.Background(), "req-1"),
+			log:  func(ctx context.Context, logger *slog.Logger) { logger.InfoContext(ctx, "parsed") },
+			want: map[string]any{"msg": "parsed", "request_id": "req-1"},
+		},
+		{
+			name: "record outside of a request",
+			ctx:  context.Background(),
+			log:  func(ctx context.Context, logger *slog.Logger) { logger.InfoContext(ctx, "scheduled") },
+			want: map[string]any{"msg": "scheduled"},
+		},
+		{
+			name: "logger with attributes",
+			ctx:  WithID(context.Background(), "req-2"),
+			log: func(ctx context.Context, logger *slog.Logger) {
+				logger.With("source", "vringe").InfoContext(ctx, "fetched")
+			},
+			want: map[string]any{"msg": "fetched", "source": "vringe", "request_id": "req-2"},
+		},
+		{
+			name: "logger with a group",
+			ctx:  WithID(context.Background(), "req-3"),
+			log: func(ctx context.Context, logger *slog.Logger) {
+				logger.WithGroup("fetch").InfoContext(ctx, "fetched", "pages", 2)
+			},
+			want: map[string]any{"msg": "fetched", "fetch": map[string]any{"pages": float64(2), "request_id": "req-3"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			handler := slog.NewJSONHandler(&out, &slog.HandlerOptions{
+				ReplaceAttr: func(groups []string, attr slog.Attr) slog.Attr {
+					if len(groups) == 0 && (attr.Key == slog.TimeKey || attr.Key == slog.LevelKey) {
+						return slog.Attr{}
+					}
+					return attr
+				},
+			})
+			tt.log(tt.ctx, slog.New(NewLogHandler(handler)))
+
+			got := decodeRecord(t, out.Bytes())
+			if !equalJSON(got, tt.want) {
+				t.Errorf("record = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLogHandlerKeepsTheLevel(t *testing.T) {
+	var out bytes.Buffer
+	logger := slog.New(NewLogHandler(slog.NewTextHandler(&out, &slog.HandlerOptions{Level: slog.LevelWarn})))
+	ctx := WithID(context.Background(), "req-1")
+
+	logger.InfoContext(ctx, "hidden")
+	logger.WarnContext(ctx, "shown")
+	if got := out.String(); strings.Contains(got, "hidden") || !strings.Contains(got, "msg=shown request_id=req-1") {
+		t.Errorf("log = %q, want only the warning with the request ID", got)
+	}
+}
+
+// decodeRecord decodes a JSON log record
+func decodeRecord(t *testing.T, data []byte) map[string]any {
+	t.Helper()
+
+	var record map[string]any
+	if err := json.Unmarshal(data, &record); err != nil {
+		t.Fatalf("log record %q: %v", data, err)
+	}
+	return record
+}
+
+// equalJSON compares decoded JSON values
+func equalJSON(a, b any) bool {
+	x, _ := json.Marshal(a)
+	y, _ := json.Marshal(b)
+	return bytes.Equal(x, y)
+}
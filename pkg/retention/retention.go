@@ -0,0 +1,271 @@
+// Package retention deletes expired records of the auxiliary data stores
+// Every data type has its own retention period. The cleanup runs once a day
+// inside a low load window or on demand through the admin API. Fight records
+// and the active snapshot are never deleted by this mechanism.
+package retention
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"easypars/pkg/backfill"
+	"easypars/pkg/clock"
+	"easypars/pkg/history"
+	"easypars/pkg/snapshot"
+)
+
+// TriggerRetention marks retention runs in the parse history
+const TriggerRetention = "retention"
+
+// Data types with a retention period
+const (
+	// CategorySnapshots is the pending (rejected) snapshot
+	CategorySnapshots = "snapshots"
+	// CategoryParseHistory is the finished parse runs with their logs
+	CategoryParseHistory = "parse_history"
+)
+
+// checkInterval is how often the scheduler wakes up to check the window
+const checkInterval = time.Minute
+
+var (
+	// ErrRunning is returned when starting a scheduler that is already running
+	ErrRunning = errors.New("retention is already running")
+	// ErrNotRunning is returned when stopping a scheduler that is not running
+	ErrNotRunning = errors.New("retention is not running")
+)
+
+// Policy maps data types to their retention periods
+// A data type without a positive period is kept forever
+type Policy map[string]time.Duration
+
+// Target prunes the expired records of a single data type
+type Target struct {
+	Category string
+	// Prune deletes the records created before the given time and returns
+	// their number; with dryRun the records are only counted
+	Prune func(ctx context.Context, before time.Time, dryRun bool) (int, error)
+}
+
+// HistoryTarget prunes finished parse runs
+func HistoryTarget(hist *history.History) Target {
+	return Target{
+		Category: CategoryParseHistory,
+		Prune: func(ctx context.Context, before time.Time, dryRun bool) (int, error) {
+			return hist.Prune(before, dryRun), nil
+		},
+	}
+}
+
+// SnapshotTarget prunes the pending snapshot, the active one is always kept
+func SnapshotTarget(store *snapshot.Store) Target {
+	return Target{
+		Category: CategorySnapshots,
+		Prune: func(ctx context.Context, before time.Time, dryRun bool) (int, error) {
+			if store.PrunePending(before, dryRun) {
+				return 1, nil
+			}
+			return 0, nil
+		},
+	}
+}
+
+// CategoryReport describes the cleanup of a single data type
+type CategoryReport struct {
+	Category string `json:"category"`
+	// Period is the retention period, records older than Cutoff expire
+	Period  string    `json:"period"`
+	Cutoff  time.Time `json:"cutoff"`
+	Deleted int       `json:"deleted"`
+	Error   string    `json:"error,omitempty"`
+}
+
+// Report summarizes a retention run
+type Report struct {
+	DryRun bool `json:"dry_run"`
+	// RunID identifies the parse history run, dry runs are not recorded
+	RunID      string           `json:"run_id,omitempty"`
+	StartedAt  time.Time        `json:"started_at"`
+	DurationMs int64            `json:"duration_ms"`
+	Deleted    int              `json:"deleted"`
+	Categories []CategoryReport `json:"categories"`
+}
+
+// Config holds the retention periods and the cleanup schedule
+type Config struct {
+	Policy Policy
+	// Window is the daily time range in which the cleanup runs
+	Window backfill.Window
+	// Location is the time zone of the window, UTC is used when nil
+	Location *time.Location
+}
+
+// Runner deletes expired records of its targets
+// Runs are serialized; the scheduler runs the cleanup once per window
+type Runner struct {
+	// Clock provides the current time, the system clock is used when nil
+	Clock clock.Clock
+
+	cfg     Config
+	targets []Target
+	history *history.History
+
+	// runMu serializes scheduled and manual runs
+	runMu sync.Mutex
+
+	mu      sync.Mutex
+	running bool
+	cancel  context.CancelFunc
+	// lastWindow is the start of the window the last scheduled run happened in
+	lastWindow time.Time
+}
+
+// New creates a stopped runner
+// The history is optional; when set, every run that deletes records is
+// recorded as a parse run with the deleted counts
+func New(cfg Config, targets []Target, hist *history.History) *Runner {
+	if cfg.Location == nil {
+		cfg.Location = time.UTC
+	}
+
+	return &Runner{
+		cfg:     cfg,
+		targets: targets,
+		history: hist,
+	}
+}
+
+// Run deletes the expired records of every target with a retention period
+// With dryRun the expired records are only counted
+func (r *Runner) Run(ctx context.Context, dryRun bool) (Report, error) {
+	r.runMu.Lock()
+	defer r.runMu.Unlock()
+
+	now := r.clock().Now()
+	report := Report{DryRun: dryRun, StartedAt: now, Categories: []CategoryReport{}}
+
+	// Step 1: Record the run first, a running run is never pruned itself
+	if r.history != nil && !dryRun {
+		run := r.history.Start(TriggerRetention)
+		report.RunID = run.ID
+	}
+
+	// Step 2: Prune every data type with a period
+	var errs []error
+	pruned := make(map[string]int)
+	for _, target := range r.targets {
+		period := r.cfg.Policy[target.Category]
+		if period <= 0 {
+			continue
+		}
+
+		category := CategoryReport{
+			Category: target.Category,
+			Period:   period.String(),
+			Cutoff:   now.Add(-period),
+		}
+		deleted, err := target.Prune(ctx, category.Cutoff, dryRun)
+		if err != nil {
+			category.Error = err.Error()
+			errs = append(errs, fmt.Errorf("error pruning %s: %w", target.Category, err))
+		}
+		category.Deleted = deleted
+		report.Deleted += deleted
+		pruned[target.Category] = deleted
+		report.Categories = append(report.Categories, category)
+	}
+	err := errors.Join(errs...)
+	report.DurationMs = r.clock().Now().Sub(now).Milliseconds()
+
+	// Step 3: Report the deleted counts
+	if report.RunID != "" {
+		r.history.Finish(report.RunID, history.RunResult{Pruned: pruned, Err: err})
+	}
+	slog.Info("Retention finished", "deleted", report.Deleted, "pruned", pruned, "dry_run", dryRun)
+
+	return report, err
+}
+
+// Start launches the daily cleanup loop
+func (r *Runner) Start() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.running {
+		return ErrRunning
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	r.running = true
+	r.cancel = cancel
+
+	go r.loop(ctx)
+
+	slog.Info("Retention started", "window", r.cfg.Window.String())
+	return nil
+}
+
+// Stop stops the daily cleanup loop, a run in progress is finished
+func (r *Runner) Stop() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if !r.running {
+		return ErrNotRunning
+	}
+
+	r.cancel()
+	r.running = false
+
+	slog.Info("Retention stopped")
+	return nil
+}
+
+// loop wakes up periodically and runs the cleanup once per window
+func (r *Runner) loop(ctx context.Context) {
+	ticker := time.NewTicker(checkInterval)
+	defer ticker.Stop()
+
+	for {
+		r.step(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// step runs the cleanup when inside a window that has not been served yet
+func (r *Runner) step(ctx context.Context) {
+	now := r.clock().Now().In(r.cfg.Location)
+	if !r.cfg.Window.Contains(now) {
+		return
+	}
+
+	r.mu.Lock()
+	windowStart := r.cfg.Window.StartOf(now)
+	if !r.running || windowStart.Equal(r.lastWindow) {
+		r.mu.Unlock()
+		return
+	}
+	r.lastWindow = windowStart
+	r.mu.Unlock()
+
+	if _, err := r.Run(ctx, false); err != nil {
+		slog.ErrorContext(ctx, "Retention failed", "error", err)
+	}
+}
+
+// clock returns the configured clock or the system clock
+func (r *Runner) clock() clock.Clock {
+	if r.Clock != nil {
+		return r.Clock
+	}
+	return clock.Real{}
+}
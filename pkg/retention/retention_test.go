@@ -0,0 +1,173 @@
+package retention
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"easypars/models"
+	"easypars/pkg/backfill"
+	"easypars/pkg/clock"
+	"easypars/pkg/history"
+	"easypars/pkg/snapshot"
+)
+
+// day is the unit of the retention periods
+const day = 24 * time.Hour
+
+// newTargets returns a history with two finished runs and a running one,
+// and a snapshot store with an active snapshot and a pending one built five
+// days before now
+func newTargets(t *testing.T, now time.Time) (*history.History, *snapshot.Store) {
+	t.Helper()
+
+	hist := history.New(10, 10)
+	for i := 0; i < 2; i++ {
+		run := hist.Start("scheduler")
+		hist.Finish(run.ID, history.RunResult{FightCount: 6})
+	}
+	hist.Start("scheduler")
+
+	store := snapshot.NewStore(snapshot.DefaultGuard())
+	active := snapshot.Build([]models.Fight{{Date: "2024-05-18", Fighter1: "Oleksandr Usyk", Fighter2: "Tyson Fury"}})
+	active.BuiltAt = now.Add(-100 * day)
+	if err := store.Publish(active); err != nil {
+		t.Fatal(err)
+	}
+	pending := snapshot.Build(nil)
+	pending.BuiltAt = now.Add(-5 * day)
+	if err := store.Publish(pending); err == nil {
+		t.Fatal("an empty snapshot passed the guard")
+	}
+
+	return hist, store
+}
+
+func TestRun(t *testing.T) {
+	now := time.Now()
+	defaults := Policy{CategorySnapshots: 7 * day, CategoryParseHistory: 30 * day}
+
+	tests := []struct {
+		name   string
+		policy Policy
+		// after is how long after the records were created the cleanup runs
+		after  time.Duration
+		dryRun bool
+		// deleted are the deleted counts by data type
+		deleted map[string]int
+	}{
+		{"nothing expired", defaults, 0, false, map[string]int{CategorySnapshots: 0, CategoryParseHistory: 0}},
+		{"pending snapshot expired", defaults, 3 * day, false, map[string]int{CategorySnapshots: 1, CategoryParseHistory: 0}},
+		{"parse runs expired", defaults, 31 * day, false, map[string]int{CategorySnapshots: 1, CategoryParseHistory: 2}},
+		{"dry run", defaults, 31 * day, true, map[string]int{CategorySnapshots: 1, CategoryParseHistory: 2}},
+		{"kept forever", Policy{CategoryParseHistory: 30 * day}, 400 * day, false, map[string]int{CategoryParseHistory: 2}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hist, store := newTargets(t, now)
+			active := store.Active()
+			r := New(Config{Policy: tt.policy}, []Target{HistoryTarget(hist), SnapshotTarget(store)}, hist)
+			r.Clock = clock.Fixed{Time: now.Add(tt.after)}
+
+			report, err := r.Run(context.Background(), tt.dryRun)
+			if err != nil {
+				t.Fatalf("Run: %v", err)
+			}
+			deleted := make(map[string]int)
+			total := 0
+			for _, category := range report.Categories {
+				deleted[category.Category] = category.Deleted
+				total += category.Deleted
+				if want := now.Add(tt.after).Add(-tt.policy[category.Category]); !category.Cutoff.Equal(want) {
+					t.Errorf("%s cutoff = %s, want %s", category.Category, category.Cutoff, want)
+				}
+			}
+			if !reflect.DeepEqual(deleted, tt.deleted) || report.Deleted != total {
+				t.Errorf("deleted = %v (total %d), want %v", deleted, report.Deleted, tt.deleted)
+			}
+
+			// The active snapshot and the running run are never deleted
+			if store.Active() != active {
+				t.Error("the active snapshot was replaced")
+			}
+			pending, _ := store.Pending()
+			if wantPending := tt.dryRun || tt.deleted[CategorySnapshots] == 0; (pending != nil) != wantPending {
+				t.Errorf("pending snapshot kept = %v, want %v", pending != nil, wantPending)
+			}
+			runs := map[string][]history.ParseRun{}
+			for _, run := range hist.List() {
+				runs[run.Trigger] = append(runs[run.Trigger], run)
+			}
+			wantRuns := 3
+			if !tt.dryRun {
+				wantRuns -= tt.deleted[CategoryParseHistory]
+			}
+			if len(runs["scheduler"]) != wantRuns {
+				t.Errorf("%d scheduler runs left, want %d", len(runs["scheduler"]), wantRuns)
+			}
+
+			// Real runs are recorded with the deleted counts, dry runs are not
+			if tt.dryRun {
+				if report.RunID != "" || len(runs[TriggerRetention]) != 0 {
+					t.Errorf("a dry run was recorded as %q", report.RunID)
+				}
+				return
+			}
+			if len(runs[TriggerRetention]) != 1 || runs[TriggerRetention][0].ID != report.RunID {
+				t.Fatalf("retention runs = %+v, want run %s", runs[TriggerRetention], report.RunID)
+			}
+			if pruned := runs[TriggerRetention][0].Pruned; !reflect.DeepEqual(pruned, tt.deleted) {
+				t.Errorf("recorded pruned counts = %v, want %v", pruned, tt.deleted)
+			}
+		})
+	}
+}
+
+func TestRunReportsTargetErrors(t *testing.T) {
+	failing := Target{Category: "audit", Prune: func(context.Context, time.Time, bool) (int, error) {
+		return 0, errors.New("disk full")
+	}}
+	hist := history.New(10, 10)
+	r := New(Config{Policy: Policy{"audit": day, CategoryParseHistory: day}}, []Target{failing, HistoryTarget(hist)}, hist)
+
+	report, err := r.Run(context.Background(), false)
+	if err == nil {
+		t.Fatal("Run with a failing target succeeded")
+	}
+	if len(report.Categories) != 2 || report.Categories[0].Error == "" || report.Categories[1].Error != "" {
+		t.Errorf("categories = %+v, want the error of audit only", report.Categories)
+	}
+	if run, ok := hist.Get(report.RunID); !ok || run.Status != history.StatusFailed {
+		t.Errorf("retention run = %+v, want a failed run", run)
+	}
+}
+
+func TestStepRunsOncePerWindow(t *testing.T) {
+	window, err := backfill.ParseWindow("03:00-05:00")
+	if err != nil {
+		t.Fatal(err)
+	}
+	hist := history.New(10, 10)
+	r := New(Config{Policy: Policy{CategoryParseHistory: 30 * day}, Window: window}, []Target{HistoryTarget(hist)}, hist)
+	r.running = true
+
+	steps := []struct {
+		at   time.Time
+		runs int
+	}{
+		{time.Date(2024, time.June, 10, 2, 59, 0, 0, time.UTC), 0},
+		{time.Date(2024, time.June, 10, 3, 0, 0, 0, time.UTC), 1},
+		{time.Date(2024, time.June, 10, 4, 30, 0, 0, time.UTC), 1},
+		{time.Date(2024, time.June, 10, 5, 0, 0, 0, time.UTC), 1},
+		{time.Date(2024, time.June, 11, 3, 1, 0, 0, time.UTC), 2},
+	}
+	for _, step := range steps {
+		r.Clock = clock.Fixed{Time: step.at}
+		r.step(context.Background())
+		if got := len(hist.List()); got != step.runs {
+			t.Errorf("retention runs at %s = %d, want %d", step.at.Format("Jan 2 15:04"), got, step.runs)
+		}
+	}
+}
@@ -0,0 +1,141 @@
+// Package safeexec isolates failures of user configurable code
+// Regular expressions, templates and post-processors configured by the
+// operator run through Exec, so a panic in one of them turns into an error
+// naming the configuration element instead of crashing the run.
+package safeexec
+
+import (
+	"fmt"
+	"log/slog"
+	"runtime/debug"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Default is the registry used by the package level Exec
+var Default = NewRegistry()
+
+// Exec runs fn through the default registry
+func Exec(source string, fn func() error) error {
+	return Default.Exec(source, fn)
+}
+
+// SourceState describes the failures of a configuration element
+type SourceState struct {
+	// Source names the configuration element ("volatile pattern '...'")
+	Source string `json:"source"`
+	// Errors is the total number of failed executions
+	Errors int64 `json:"errors"`
+	// Degraded is set while the last execution failed
+	Degraded  bool      `json:"degraded"`
+	LastError string    `json:"last_error,omitempty"`
+	Since     time.Time `json:"since"`
+}
+
+// Registry tracks failures per configuration element
+// It is safe for concurrent use
+type Registry struct {
+	mu      sync.Mutex
+	sources map[string]*SourceState
+}
+
+// NewRegistry creates an empty registry
+func NewRegistry() *Registry {
+	return &Registry{sources: make(map[string]*SourceState)}
+}
+
+// Exec runs fn and converts a panic into an error naming the source
+// A failure marks the source degraded and is logged once until the source
+// succeeds again; a success clears the degradation
+func (r *Registry) Exec(source string, fn func() error) (err error) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			err = fmt.Errorf("%s panicked: %v", source, recovered)
+			r.record(source, err, debug.Stack())
+		}
+	}()
+
+	err = fn()
+	if err != nil {
+		err = fmt.Errorf("%s: %w", source, err)
+		r.record(source, err, nil)
+		return err
+	}
+
+	r.markRecovered(source)
+	return nil
+}
+
+// Degraded returns the sources whose last execution failed, sorted by name
+func (r *Registry) Degraded() []SourceState {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var degraded []SourceState
+	for _, state := range r.sources {
+		if state.Degraded {
+			degraded = append(degraded, *state)
+		}
+	}
+	sort.Slice(degraded, func(i, j int) bool { return degraded[i].Source < degraded[j].Source })
+
+	return degraded
+}
+
+// ErrorCounts returns the total number of failures per source
+func (r *Registry) ErrorCounts() map[string]int64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	counts := make(map[string]int64, len(r.sources))
+	for source, state := range r.sources {
+		if state.Errors > 0 {
+			counts[source] = state.Errors
+		}
+	}
+
+	return counts
+}
+
+// record registers a failure of the source
+func (r *Registry) record(source string, err error, stack []byte) {
+	r.mu.Lock()
+	state, ok := r.sources[source]
+	if !ok {
+		state = &SourceState{Source: source}
+		r.sources[source] = state
+	}
+	state.Errors++
+	state.LastError = err.Error()
+	firstFailure := !state.Degraded
+	if firstFailure {
+		state.Degraded = true
+		state.Since = time.Now()
+	}
+	r.mu.Unlock()
+
+	// Log only the first failure of a degradation, later ones are counted
+	if firstFailure {
+		if stack != nil {
+			slog.Error("Configuration element failed", "error", err, "stack", string(stack))
+		} else {
+			slog.Error("Configuration element failed", "error", err)
+		}
+	}
+}
+
+// markRecovered clears the degradation of the source after a successful execution
+func (r *Registry) markRecovered(source string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	state, ok := r.sources[source]
+	if !ok || !state.Degraded {
+		return
+	}
+
+	state.Degraded = false
+	state.Since = time.Time{}
+	slog.Info("Configuration element recovered", "source", source)
+}
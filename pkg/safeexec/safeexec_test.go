@@ -0,0 +1,102 @@
+package safeexec
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestExec(t *testing.T) {
+	errBroken := errors.New("broken")
+
+	tests := []struct {
+		name     string
+		fn       func() error
+		wantErr  string
+		degraded bool
+	}{
+		{"success", func() error { return nil }, "", false},
+		{"error", func() error { return errBroken }, "digest template 'daily': broken", true},
+		{"panic", func() error { panic("index out of range") }, "digest template 'daily' panicked: index out of range", true},
+		{"nil map panic", func() error {
+			var m map[string]int
+			m["x"] = 1
+			return nil
+		}, "digest template 'daily' panicked: assignment to entry in nil map", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			registry := NewRegistry()
+			err := registry.Exec("digest template 'daily'", tt.fn)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("Exec = %v, want nil", err)
+				}
+			} else if err == nil || err.Error() != tt.wantErr {
+				t.Fatalf("Exec = %v, want %q", err, tt.wantErr)
+			}
+
+			if got := len(registry.Degraded()) == 1; got != tt.degraded {
+				t.Errorf("degraded = %v, want %v", got, tt.degraded)
+			}
+		})
+	}
+}
+
+func TestExecKeepsTheWrappedError(t *testing.T) {
+	errBroken := errors.New("broken")
+	err := NewRegistry().Exec("blacklist pattern 'x'", func() error { return errBroken })
+	if !errors.Is(err, errBroken) {
+		t.Errorf("Exec = %v, want it to wrap the error of the element", err)
+	}
+}
+
+func TestDegradationAndRecovery(t *testing.T) {
+	registry := NewRegistry()
+	broken := func() error { panic("bad template") }
+	fixed := func() error { return nil }
+
+	// A broken element is skipped while the others keep running
+	for i := 0; i < 3; i++ {
+		registry.Exec("digest template 'daily'", broken)
+		if err := registry.Exec("digest template 'weekly'", fixed); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	degraded := registry.Degraded()
+	if len(degraded) != 1 || degraded[0].Source != "digest template 'daily'" || degraded[0].Errors != 3 {
+		t.Fatalf("degraded = %+v, want the daily template with 3 errors", degraded)
+	}
+	if !strings.Contains(degraded[0].LastError, "bad template") || degraded[0].Since.IsZero() {
+		t.Errorf("state = %+v, want the last error and the start of the degradation", degraded[0])
+	}
+
+	// Fixing the configuration clears the degradation, the count stays
+	if err := registry.Exec("digest template 'daily'", fixed); err != nil {
+		t.Fatal(err)
+	}
+	if degraded := registry.Degraded(); len(degraded) != 0 {
+		t.Errorf("degraded after the fix = %+v, want none", degraded)
+	}
+	if want := map[string]int64{"digest template 'daily'": 3}; !reflect.DeepEqual(registry.ErrorCounts(), want) {
+		t.Errorf("error counts = %v, want %v", registry.ErrorCounts(), want)
+	}
+}
+
+func TestDegradedIsSorted(t *testing.T) {
+	registry := NewRegistry()
+	for _, source := range []string{"volatile pattern 'b'", "blacklist pattern 'a'", "post-processor 'c'"} {
+		registry.Exec(source, func() error { return errors.New("broken") })
+	}
+
+	var sources []string
+	for _, state := range registry.Degraded() {
+		sources = append(sources, state.Source)
+	}
+	want := []string{"blacklist pattern 'a'", "post-processor 'c'", "volatile pattern 'b'"}
+	if !reflect.DeepEqual(sources, want) {
+		t.Errorf("degraded = %q, want %q", sources, want)
+	}
+}
@@ -0,0 +1,196 @@
+// Package searchstats counts what users search for
+// Terms are kept per day for the last week, without any client identity
+package searchstats
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"easypars/models"
+	"easypars/pkg/clock"
+)
+
+// Tracker limits
+const (
+	// Days is the number of daily buckets kept
+	Days = 7
+	// MaxTerms is the number of terms a day keeps after truncation
+	MaxTerms = 1000
+	// maxTermLength bounds the length of a recorded term in runes
+	maxTermLength = 100
+)
+
+// TermStat is the search count of a single term
+type TermStat struct {
+	Term  string `json:"term"`
+	Count int    `json:"count"`
+	// Empty counts searches that found nothing
+	Empty      int     `json:"empty"`
+	EmptyShare float64 `json:"empty_share"`
+}
+
+// Report holds the most searched terms of today and of the last week
+type Report struct {
+	Today []TermStat `json:"today"`
+	Week  []TermStat `json:"week"`
+}
+
+// termCount is the counter of a term within a day
+type termCount struct {
+	count int
+	empty int
+}
+
+// dayBucket holds the counters of one day
+type dayBucket struct {
+	day   string
+	terms map[string]*termCount
+}
+
+// Tracker counts normalized search terms in a ring of daily buckets
+// It is safe for concurrent use
+type Tracker struct {
+	// Clock provides the current day, the system clock is used when nil
+	Clock clock.Clock
+
+	mu      sync.Mutex
+	buckets [Days]dayBucket
+}
+
+// New creates an empty tracker
+func New() *Tracker {
+	return &Tracker{}
+}
+
+// Record counts a search for the term that returned resultCount results
+// Terms are normalized like fighter names; empty terms are ignored
+func (t *Tracker) Record(term string, resultCount int) {
+	term = normalizeTerm(term)
+	if term == "" {
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	bucket := t.bucket(clock.Today(t.clock()))
+	counter, ok := bucket.terms[term]
+	if !ok {
+		counter = &termCount{}
+		bucket.terms[term] = counter
+	}
+	counter.count++
+	if resultCount == 0 {
+		counter.empty++
+	}
+
+	// Truncate rarely: only once the map has grown to twice the limit
+	if len(bucket.terms) > 2*MaxTerms {
+		truncate(bucket.terms, MaxTerms)
+	}
+}
+
+// Top returns the n most searched terms of today and of the last Days days
+func (t *Tracker) Top(n int) Report {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	today := clock.Today(t.clock())
+	oldest := today.AddDate(0, 0, -(Days - 1)).Format("2006-01-02")
+	todayKey := today.Format("2006-01-02")
+
+	daily := make(map[string]*termCount)
+	weekly := make(map[string]*termCount)
+	for i := range t.buckets {
+		bucket := &t.buckets[i]
+		if bucket.day == "" || bucket.day < oldest || bucket.day > todayKey {
+			continue
+		}
+		for term, counter := range bucket.terms {
+			add(weekly, term, counter)
+			if bucket.day == todayKey {
+				add(daily, term, counter)
+			}
+		}
+	}
+
+	return Report{Today: topTerms(daily, n), Week: topTerms(weekly, n)}
+}
+
+// bucket returns the bucket of the day, resetting the slot of an older day
+// The caller must hold the lock
+func (t *Tracker) bucket(day time.Time) *dayBucket {
+	key := day.Format("2006-01-02")
+	dayNumber := time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, time.UTC).Unix() / 86400
+	slot := &t.buckets[dayNumber%Days]
+	if slot.day != key {
+		slot.day = key
+		slot.terms = make(map[string]*termCount)
+	}
+
+	return slot
+}
+
+// clock returns the configured clock or the system clock
+func (t *Tracker) clock() clock.Clock {
+	if t.Clock != nil {
+		return t.Clock
+	}
+	return clock.Real{}
+}
+
+// normalizeTerm normalizes and bounds a search term
+func normalizeTerm(term string) string {
+	term = models.NormalizeName(term)
+	if runes := []rune(term); len(runes) > maxTermLength {
+		term = string(runes[:maxTermLength])
+	}
+
+	return term
+}
+
+// add merges a counter into a map of totals
+func add(totals map[string]*termCount, term string, counter *termCount) {
+	total, ok := totals[term]
+	if !ok {
+		total = &termCount{}
+		totals[term] = total
+	}
+	total.count += counter.count
+	total.empty += counter.empty
+}
+
+// topTerms returns the n terms with the highest counts
+// Ties are broken alphabetically so the report is stable
+func topTerms(totals map[string]*termCount, n int) []TermStat {
+	stats := make([]TermStat, 0, len(totals))
+	for term, counter := range totals {
+		stats = append(stats, TermStat{
+			Term:       term,
+			Count:      counter.count,
+			Empty:      counter.empty,
+			EmptyShare: float64(counter.empty) / float64(counter.count),
+		})
+	}
+
+	sort.Slice(stats, func(i, j int) bool {
+		if stats[i].Count != stats[j].Count {
+			return stats[i].Count > stats[j].Count
+		}
+		return stats[i].Term < stats[j].Term
+	})
+
+	if n > 0 && len(stats) > n {
+		stats = stats[:n]
+	}
+
+	return stats
+}
+
+// truncate keeps only the limit most counted terms
+func truncate(terms map[string]*termCount, limit int) {
+	for _, stat := range topTerms(terms, 0)[limit:] {
+		delete(terms, stat.Term)
+	}
+}
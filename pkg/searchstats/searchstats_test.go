@@ -0,0 +1,177 @@
+package searchstats
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+// dayClock is a clock moved by the test
+type dayClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *dayClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *dayClock) addDays(days int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.AddDate(0, 0, days)
+}
+
+// newTestTracker returns a tracker with the clock at 2024-06-10
+func newTestTracker() (*Tracker, *dayClock) {
+	clk := &dayClock{now: time.Date(2024, time.June, 10, 12, 0, 0, 0, time.UTC)}
+	tracker := New()
+	tracker.Clock = clk
+
+	return tracker, clk
+}
+
+// counts returns the terms with their counts
+func counts(stats []TermStat) map[string]int {
+	result := make(map[string]int, len(stats))
+	for _, stat := range stats {
+		result[stat.Term] = stat.Count
+	}
+
+	return result
+}
+
+func TestRecordNormalizesTerms(t *testing.T) {
+	tracker, _ := newTestTracker()
+	for _, term := range []string{"Usyk", "  usyk ", "USYK", "", "   ", "Fury"} {
+		tracker.Record(term, 1)
+	}
+
+	want := []TermStat{{Term: "usyk", Count: 3}, {Term: "fury", Count: 1}}
+	if got := tracker.Top(10).Today; !reflect.DeepEqual(got, want) {
+		t.Errorf("today = %+v, want %+v", got, want)
+	}
+}
+
+func TestEmptyShare(t *testing.T) {
+	tracker, _ := newTestTracker()
+	results := map[string][]int{
+		"usyk":    {3, 3, 3, 3},
+		"nobody":  {0, 0},
+		"bivol":   {1, 0, 2, 0},
+		"beterb":  {0},
+		"inoue":   {5},
+		"nakatan": {0, 4, 4, 4},
+	}
+	for term, counts := range results {
+		for _, count := range counts {
+			tracker.Record(term, count)
+		}
+	}
+
+	want := map[string]float64{"usyk": 0, "nobody": 1, "bivol": 0.5, "beterb": 1, "inoue": 0, "nakatan": 0.25}
+	for _, stat := range tracker.Top(0).Today {
+		if stat.EmptyShare != want[stat.Term] {
+			t.Errorf("empty share of %s = %v, want %v", stat.Term, stat.EmptyShare, want[stat.Term])
+		}
+		if stat.Empty != int(want[stat.Term]*float64(stat.Count)) {
+			t.Errorf("empty searches of %s = %d of %d, want the share %v", stat.Term, stat.Empty, stat.Count, want[stat.Term])
+		}
+	}
+}
+
+func TestDailyBuckets(t *testing.T) {
+	tracker, clk := newTestTracker()
+
+	steps := []struct {
+		name  string
+		days  int
+		terms []string
+		today map[string]int
+		week  map[string]int
+	}{
+		{"first day", 0, []string{"usyk", "usyk", "fury"}, map[string]int{"usyk": 2, "fury": 1}, map[string]int{"usyk": 2, "fury": 1}},
+		{"next day", 1, []string{"usyk", "bivol"}, map[string]int{"usyk": 1, "bivol": 1}, map[string]int{"usyk": 3, "fury": 1, "bivol": 1}},
+		{"quiet day", 1, nil, map[string]int{}, map[string]int{"usyk": 3, "fury": 1, "bivol": 1}},
+		// The first day leaves the week: it is seven days ago now
+		{"a week later", 5, []string{"inoue"}, map[string]int{"inoue": 1}, map[string]int{"usyk": 1, "bivol": 1, "inoue": 1}},
+		// The slot of the second day is reused for today
+		{"slot reused", 1, []string{"inoue"}, map[string]int{"inoue": 1}, map[string]int{"inoue": 2}},
+		{"after a long pause", 30, nil, map[string]int{}, map[string]int{}},
+	}
+	for _, step := range steps {
+		clk.addDays(step.days)
+		for _, term := range step.terms {
+			tracker.Record(term, 1)
+		}
+
+		report := tracker.Top(50)
+		if got := counts(report.Today); !reflect.DeepEqual(got, step.today) {
+			t.Errorf("%s: today = %v, want %v", step.name, got, step.today)
+		}
+		if got := counts(report.Week); !reflect.DeepEqual(got, step.week) {
+			t.Errorf("%s: week = %v, want %v", step.name, got, step.week)
+		}
+	}
+}
+
+func TestTruncation(t *testing.T) {
+	tracker, _ := newTestTracker()
+
+	// A popular term, then single searches until the day holds twice the
+	// limit plus one term
+	for i := 0; i < 5; i++ {
+		tracker.Record("usyk", 1)
+	}
+	for i := 0; i < 2*MaxTerms; i++ {
+		tracker.Record(fmt.Sprintf("rare %04d", i), 1)
+	}
+
+	if terms := len(tracker.Top(0).Today); terms != MaxTerms {
+		t.Errorf("terms kept = %d, want %d after the truncation", terms, MaxTerms)
+	}
+
+	top := tracker.Top(1).Today
+	if len(top) != 1 || top[0].Term != "usyk" || top[0].Count != 5 {
+		t.Errorf("top term = %+v, want usyk kept by the truncation", top)
+	}
+}
+
+func TestConcurrentRecords(t *testing.T) {
+	tracker, clk := newTestTracker()
+
+	const workers, searches = 8, 500
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < searches; i++ {
+				tracker.Record(fmt.Sprintf("fighter %d", i%10), i%2)
+				if i%100 == 0 {
+					tracker.Top(5)
+				}
+			}
+		}()
+	}
+	// The day changes while the workers record
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		clk.addDays(1)
+	}()
+	wg.Wait()
+
+	total, empty := 0, 0
+	for _, stat := range tracker.Top(0).Week {
+		total += stat.Count
+		empty += stat.Empty
+	}
+	if total != workers*searches || empty != workers*searches/2 {
+		t.Errorf("recorded %d searches, %d empty, want %d and %d", total, empty, workers*searches, workers*searches/2)
+	}
+}
@@ -0,0 +1,261 @@
+package snapshot
+
+import (
+	"fmt"
+	"math/rand"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"easypars/models"
+)
+
+// fightGenerator makes random fights over a fixed pool of fighters and
+// locations, so fighters meet several times and locations repeat
+type fightGenerator struct {
+	rng       *rand.Rand
+	fighters  int
+	locations int
+	next      int
+}
+
+func newFightGenerator(seed int64, fighters, locations int) *fightGenerator {
+	return &fightGenerator{rng: rand.New(rand.NewSource(seed)), fighters: fighters, locations: locations}
+}
+
+// name returns a spelling of the fighter: the names differ in case and
+// spacing now and then, which the aggregates must merge
+func (g *fightGenerator) name(n int) string {
+	name := fmt.Sprintf("Boxer %d", n)
+	switch g.rng.Intn(10) {
+	case 0:
+		return strings.ToUpper(name)
+	case 1:
+		return strings.Replace(name, " ", "  ", 1)
+	}
+
+	return name
+}
+
+// ids returns the external IDs of the fighter, sometimes missing or wrong
+func (g *fightGenerator) ids(n int) map[string]string {
+	switch g.rng.Intn(8) {
+	case 0:
+		return nil
+	case 1:
+		return map[string]string{models.ExternalSourceBoxRec: fmt.Sprint(900000 + g.rng.Intn(5))}
+	}
+
+	return map[string]string{models.ExternalSourceBoxRec: fmt.Sprint(100000 + n)}
+}
+
+// location returns a spelling of a location
+func (g *fightGenerator) location() string {
+	n := g.rng.Intn(g.locations)
+	switch g.rng.Intn(12) {
+	case 0:
+		return ""
+	case 1:
+		return fmt.Sprintf("city %d, country %d", n, n%7)
+	}
+
+	return fmt.Sprintf("City %d, Country %d", n, n%7)
+}
+
+// fight returns a new fight with a unique key
+func (g *fightGenerator) fight() models.Fight {
+	g.next++
+	a, b := g.rng.Intn(g.fighters), g.rng.Intn(g.fighters)
+	if a == b {
+		b = (b + 1) % g.fighters
+	}
+	date := time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC).AddDate(0, 0, g.next)
+	fight := models.Fight{
+		Date:                date.Format("2006-01-02"),
+		Fighter1:            g.name(a),
+		Fighter2:            g.name(b),
+		Location:            g.location(),
+		Status:              models.StatusCompleted,
+		Fighter1ExternalIDs: g.ids(a),
+		Fighter2ExternalIDs: g.ids(b),
+	}
+	fight.Key = fight.NaturalKey()
+
+	return fight
+}
+
+// fights returns n new fights
+func (g *fightGenerator) fights(n int) []models.Fight {
+	fights := make([]models.Fight, n)
+	for i := range fights {
+		fights[i] = g.fight()
+	}
+
+	return fights
+}
+
+// mutate returns the fights with changes random fights added, removed or
+// changed; changes keep the key, as a parse again of the same fight does
+func (g *fightGenerator) mutate(fights []models.Fight, changes int) []models.Fight {
+	next := append([]models.Fight{}, fights...)
+	for i := 0; i < changes; i++ {
+		switch op := g.rng.Intn(3); {
+		case op == 0 || len(next) == 0:
+			next = append(next, g.fight())
+		case op == 1:
+			j := g.rng.Intn(len(next))
+			next = append(next[:j], next[j+1:]...)
+		default:
+			j := g.rng.Intn(len(next))
+			fight := next[j]
+			switch g.rng.Intn(3) {
+			case 0:
+				fight.Location = g.location()
+			case 1:
+				fight.Fighter1ExternalIDs = g.ids(g.rng.Intn(g.fighters))
+			default:
+				fight.Fighter2 = strings.ToLower(fight.Fighter2)
+			}
+			next[j] = fight
+		}
+	}
+
+	return next
+}
+
+// buildAggregates builds the fighter and location aggregates of the snapshot
+func buildAggregates(s *Snapshot) {
+	s.Fighters()
+	s.Locations()
+}
+
+func TestIncrementalAggregatesMatchFullBuild(t *testing.T) {
+	for seed := int64(1); seed <= 5; seed++ {
+		gen := newFightGenerator(seed, 60, 15)
+		fights := gen.fights(300)
+		prev := Build(fights)
+		buildAggregates(prev)
+
+		for step := 0; step < 40; step++ {
+			fights = gen.mutate(fights, 1+gen.rng.Intn(10))
+			next := BuildWith(fights, BuildOptions{Previous: prev})
+			buildAggregates(next)
+			if !next.fighters.incremental || !next.locations.incremental {
+				t.Fatalf("seed %d step %d: aggregates were rebuilt in full", seed, step)
+			}
+
+			full := Build(fights)
+			if !reflect.DeepEqual(next.Fighters(), full.Fighters()) {
+				t.Fatalf("seed %d step %d: incremental fighters differ from a full build", seed, step)
+			}
+			if !reflect.DeepEqual(next.fighters.get().external, full.fighters.get().external) ||
+				!reflect.DeepEqual(next.fighters.get().warnings, full.fighters.get().warnings) {
+				t.Fatalf("seed %d step %d: incremental external IDs or warnings differ from a full build", seed, step)
+			}
+			if !reflect.DeepEqual(next.Locations(), full.Locations()) ||
+				!reflect.DeepEqual(next.LocationCandidates(), full.LocationCandidates()) {
+				t.Fatalf("seed %d step %d: incremental locations differ from a full build", seed, step)
+			}
+			if differences := next.Reconcile(); len(differences) > 0 {
+				t.Fatalf("seed %d step %d: Reconcile reported %v", seed, step, differences)
+			}
+			prev = next
+		}
+	}
+}
+
+func TestIncrementalAggregatesNeedBuiltPredecessor(t *testing.T) {
+	gen := newFightGenerator(1, 20, 5)
+	fights := gen.fights(50)
+	prev := Build(fights)
+
+	// Nothing to update from: the predecessor never built its aggregates
+	next := BuildWith(gen.mutate(fights, 3), BuildOptions{Previous: prev})
+	buildAggregates(next)
+	if next.HasIncrementalAggregates() {
+		t.Error("aggregates were updated from a predecessor without built aggregates")
+	}
+
+	// The state of a predecessor is handed over once
+	first := BuildWith(fights, BuildOptions{Previous: next})
+	second := BuildWith(fights, BuildOptions{Previous: next})
+	buildAggregates(first)
+	buildAggregates(second)
+	if !first.HasIncrementalAggregates() || second.HasIncrementalAggregates() {
+		t.Errorf("incremental = %v and %v, want only the first successor updated", first.HasIncrementalAggregates(), second.HasIncrementalAggregates())
+	}
+	if !reflect.DeepEqual(first.Fighters(), second.Fighters()) {
+		t.Error("the incremental and the full successor differ")
+	}
+}
+
+func TestReconcileFindsDifference(t *testing.T) {
+	gen := newFightGenerator(7, 30, 8)
+	fights := gen.fights(100)
+	prev := Build(fights)
+	buildAggregates(prev)
+	next := BuildWith(gen.mutate(fights, 5), BuildOptions{Previous: prev})
+	buildAggregates(next)
+	if differences := next.Reconcile(); len(differences) > 0 {
+		t.Fatalf("Reconcile reported %v before the tampering", differences)
+	}
+
+	// A counting bug of the incremental logic, introduced by hand
+	next.fighters.value.fighters[3].FightCount++
+	next.locations.value.grouping.Locations[0].FightCount++
+
+	differences := next.Reconcile()
+	if len(differences) != 2 {
+		t.Fatalf("Reconcile reported %v, want the fighter and the location difference", differences)
+	}
+	if !strings.Contains(differences[0], "fighters differ") || !strings.Contains(differences[0], "position 3") ||
+		!strings.Contains(differences[1], "locations differ") {
+		t.Errorf("Reconcile reported %v", differences)
+	}
+
+	// Aggregates built in full have nothing to reconcile
+	full := Build(fights)
+	buildAggregates(full)
+	full.fighters.value.fighters[0].FightCount++
+	if differences := full.Reconcile(); len(differences) != 0 {
+		t.Errorf("Reconcile of a full build reported %v", differences)
+	}
+}
+
+// BenchmarkAggregateUpdate compares updating the fighter and location
+// aggregates of 50k fights with a diff of 10 changes against building them
+// from all fights
+func BenchmarkAggregateUpdate(b *testing.B) {
+	gen := newFightGenerator(1, 5000, 300)
+	fights := gen.fights(50000)
+
+	b.Run("incremental", func(b *testing.B) {
+		prev := Build(fights)
+		buildAggregates(prev)
+		current := fights
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			b.StopTimer()
+			current = gen.mutate(current, 10)
+			next := BuildWith(current, BuildOptions{Previous: prev})
+			b.StartTimer()
+
+			buildAggregates(next)
+			if !next.HasIncrementalAggregates() {
+				b.Fatal("aggregates were rebuilt in full")
+			}
+			prev = next
+		}
+	})
+
+	b.Run("full", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			b.StopTimer()
+			next := Build(fights)
+			b.StartTimer()
+
+			buildAggregates(next)
+		}
+	})
+}
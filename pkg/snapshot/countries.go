@@ -0,0 +1,54 @@
+package snapshot
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"easypars/models"
+	"easypars/pkg/countries"
+)
+
+// maxListedCountries bounds the unknown flags listed in the warning
+const maxListedCountries = 10
+
+// annotateCountries sets the country codes of the fighters and locations
+// The flags are resolved again on every build, so countries added to the
+// dictionary apply to stored fights too. Unknown flags stay in the raw
+// fields and are reported in a warning.
+func annotateCountries(fights []models.Fight) []string {
+	unknown := make(map[string]bool)
+	resolveFlag := func(flag string) string {
+		if flag == "" {
+			return ""
+		}
+		iso2, ok := countries.ResolveFlag(flag)
+		if !ok {
+			unknown[flag] = true
+		}
+		return iso2
+	}
+
+	for i := range fights {
+		fight := &fights[i]
+		if fight.Raw != nil {
+			fight.Fighter1Country = resolveFlag(fight.Raw.Boxer1Country)
+			fight.Fighter2Country = resolveFlag(fight.Raw.Boxer2Country)
+		}
+		fight.LocationCountry, _ = countries.FromLocation(fight.Location)
+	}
+
+	if len(unknown) == 0 {
+		return nil
+	}
+	flags := make([]string, 0, len(unknown))
+	for flag := range unknown {
+		flags = append(flags, flag)
+	}
+	sort.Strings(flags)
+	if len(flags) > maxListedCountries {
+		flags = append(flags[:maxListedCountries], "...")
+	}
+
+	return []string{fmt.Sprintf("%d unknown fighter countries kept raw: %s", len(unknown), strings.Join(flags, ", "))}
+}
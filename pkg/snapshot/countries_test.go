@@ -0,0 +1,52 @@
+package snapshot
+
+import (
+	"strings"
+	"testing"
+
+	"easypars/models"
+)
+
+func TestAnnotateCountries(t *testing.T) {
+	flagged := func(flag1, flag2, location string) models.Fight {
+		return models.Fight{Location: location, Raw: &models.RawFields{Boxer1Country: flag1, Boxer2Country: flag2}}
+	}
+
+	tests := []struct {
+		name     string
+		fight    models.Fight
+		want     [3]string
+		warnings int
+	}{
+		{"names", flagged("Украина", "Great Britain", "Эр-Рияд, Саудовская Аравия"), [3]string{"UA", "GB", "SA"}, 0},
+		{"flag addresses", flagged("/flags/ua.png", "/flags/GBR.gif", "Riyadh"), [3]string{"UA", "GB", ""}, 0},
+		{"unknown flag", flagged("Атлантида", "USA", "Las Vegas, USA"), [3]string{"", "US", "US"}, 1},
+		{"no raw fields", models.Fight{Location: "Лондон, Англия"}, [3]string{"", "", "GB"}, 0},
+	}
+	for _, tt := range tests {
+		fights := []models.Fight{tt.fight}
+		warnings := annotateCountries(fights)
+		fight := fights[0]
+		if got := [3]string{fight.Fighter1Country, fight.Fighter2Country, fight.LocationCountry}; got != tt.want {
+			t.Errorf("%s: countries = %q, want %q", tt.name, got, tt.want)
+		}
+		if len(warnings) != tt.warnings {
+			t.Errorf("%s: warnings = %q, want %d", tt.name, warnings, tt.warnings)
+		}
+		if fight.Raw != nil && fight.Raw.Boxer1Country != tt.fight.Raw.Boxer1Country {
+			t.Errorf("%s: raw flag = %q, want it kept", tt.name, fight.Raw.Boxer1Country)
+		}
+	}
+}
+
+func TestAnnotateCountriesListsUnknownFlags(t *testing.T) {
+	var fights []models.Fight
+	for _, flag := range []string{"Атлантида", "Гондор", "Атлантида", "Мордор"} {
+		fights = append(fights, models.Fight{Raw: &models.RawFields{Boxer1Country: flag}})
+	}
+
+	warnings := annotateCountries(fights)
+	if len(warnings) != 1 || !strings.HasPrefix(warnings[0], "3 unknown fighter countries kept raw: Атлантида, Гондор, Мордор") {
+		t.Errorf("warnings = %q, want the three unknown flags", warnings)
+	}
+}
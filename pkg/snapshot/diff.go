@@ -0,0 +1,127 @@
+package snapshot
+
+import (
+	"maps"
+
+	"easypars/models"
+)
+
+// FightChange is a fight whose aggregate inputs changed between two snapshots
+type FightChange struct {
+	Old models.Fight
+	New models.Fight
+}
+
+// FightsDiff lists the fights added, removed and changed between two snapshots
+// Only the fields the aggregates depend on (fighter names, their external IDs
+// and the location) count as changes; other edits do not touch the aggregates.
+type FightsDiff struct {
+	Added   []models.Fight
+	Removed []models.Fight
+	Changed []FightChange
+}
+
+// Len returns the number of fights in the diff
+func (d FightsDiff) Len() int {
+	return len(d.Added) + len(d.Removed) + len(d.Changed)
+}
+
+// DiffFights compares the fights of two snapshots
+// The diff is only meaningful when natural keys are unique in both
+// snapshots, otherwise false is returned and aggregates are built in full
+func DiffFights(prev, next *Snapshot) (FightsDiff, bool) {
+	var diff FightsDiff
+	if !prev.keysUnique() || !next.keysUnique() {
+		return diff, false
+	}
+
+	for i := range next.Fights {
+		fight := &next.Fights[i]
+		idx, ok := prev.byKey[fight.Key]
+		if !ok {
+			diff.Added = append(diff.Added, *fight)
+			continue
+		}
+		if old := &prev.Fights[idx]; !aggregateInputsEqual(old, fight) {
+			diff.Changed = append(diff.Changed, FightChange{Old: *old, New: *fight})
+		}
+	}
+	for i := range prev.Fights {
+		if _, ok := next.byKey[prev.Fights[i].Key]; !ok {
+			diff.Removed = append(diff.Removed, prev.Fights[i])
+		}
+	}
+
+	return diff, true
+}
+
+// aggregateInputsEqual reports whether two versions of a fight contribute
+// the same to the fighter and location aggregates
+func aggregateInputsEqual(a, b *models.Fight) bool {
+	return a.Fighter1 == b.Fighter1 && a.Fighter2 == b.Fighter2 && a.Location == b.Location &&
+		maps.Equal(a.Fighter1ExternalIDs, b.Fighter1ExternalIDs) &&
+		maps.Equal(a.Fighter2ExternalIDs, b.Fighter2ExternalIDs)
+}
+
+// NewlyCompleted returns the completed fights of next that were not
+// completed in prev: fights that got their result and fights that appeared
+// with one
+// The fights keep the order of next.
+func NewlyCompleted(prev, next *Snapshot) []models.Fight {
+	var completed []models.Fight
+	for i := range next.Fights {
+		fight := &next.Fights[i]
+		if fight.Status != models.StatusCompleted {
+			continue
+		}
+		if old, ok := prev.Get(fight.Key); ok && old.Status == models.StatusCompleted {
+			continue
+		}
+		completed = append(completed, *fight)
+	}
+
+	return completed
+}
+
+// FightUpdates returns the fights of next that prev does not have and the
+// fights whose source data changed, both in the order of next
+// Only the fields parsed from the source count: the fields derived when a
+// snapshot is built and the storage timestamps change without the fight
+// changing.
+func FightUpdates(prev, next *Snapshot) (added, updated []models.Fight) {
+	for i := range next.Fights {
+		fight := &next.Fights[i]
+		old, ok := prev.Get(fight.Key)
+		switch {
+		case !ok:
+			added = append(added, *fight)
+		case !sourceFieldsEqual(&old, fight):
+			updated = append(updated, *fight)
+		}
+	}
+
+	return added, updated
+}
+
+// sourceFieldsEqual reports whether two versions of a fight hold the same
+// data parsed from the source
+func sourceFieldsEqual(a, b *models.Fight) bool {
+	return a.Date == b.Date && a.Fighter1 == b.Fighter1 && a.Fighter2 == b.Fighter2 &&
+		a.Result == b.Result && a.ResultType == b.ResultType && a.Round == b.Round &&
+		a.Location == b.Location && a.Status == b.Status && a.CardPosition == b.CardPosition &&
+		a.HiddenInSource == b.HiddenInSource &&
+		recordsEqual(a.Fighter1Record, b.Fighter1Record) && recordsEqual(a.Fighter2Record, b.Fighter2Record) &&
+		maps.Equal(a.Fighter1ExternalIDs, b.Fighter1ExternalIDs) &&
+		maps.Equal(a.Fighter2ExternalIDs, b.Fighter2ExternalIDs)
+}
+
+// recordsEqual reports whether two fighter records are the same, both
+// missing included
+func recordsEqual(a, b *models.FighterRecord) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+
+	return a.Wins == b.Wins && a.Losses == b.Losses && a.Draws == b.Draws &&
+		(a.KOs == nil) == (b.KOs == nil) && (a.KOs == nil || *a.KOs == *b.KOs)
+}
@@ -0,0 +1,83 @@
+package snapshot
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"easypars/models"
+)
+
+func TestFightUpdates(t *testing.T) {
+	ko := 30
+	otherKO := 31
+	base := func() []models.Fight {
+		fights := []models.Fight{
+			bout("2024-06-22", "Canelo Alvarez", "Edgar Berlanga", ""),
+			bout("2024-06-08", "Anthony Joshua", "Francis Ngannou", "KO 2"),
+		}
+		fights[1].Fighter1Record = &models.FighterRecord{Wins: 28, Losses: 3, KOs: &ko}
+		return fights
+	}
+
+	tests := []struct {
+		name    string
+		change  func(fights []models.Fight) []models.Fight
+		added   []string
+		updated []string
+	}{
+		{"unchanged", func(fights []models.Fight) []models.Fight { return fights }, nil, nil},
+		{"result filled in", func(fights []models.Fight) []models.Fight {
+			fights[0].Result, fights[0].Status = "UD 12", models.StatusCompleted
+			return fights
+		}, nil, []string{"Canelo Alvarez"}},
+		{"record removed", func(fights []models.Fight) []models.Fight {
+			fights[1].Fighter1Record = nil
+			return fights
+		}, nil, []string{"Anthony Joshua"}},
+		{"same record", func(fights []models.Fight) []models.Fight {
+			same := ko
+			fights[1].Fighter1Record = &models.FighterRecord{Wins: 28, Losses: 3, KOs: &same}
+			return fights
+		}, nil, nil},
+		{"KOs of the record changed", func(fights []models.Fight) []models.Fight {
+			fights[1].Fighter1Record = &models.FighterRecord{Wins: 28, Losses: 3, KOs: &otherKO}
+			return fights
+		}, nil, []string{"Anthony Joshua"}},
+		{"fight added", func(fights []models.Fight) []models.Fight {
+			return append(fights, bout("2024-06-01", "Dmitry Bivol", "Malik Zinad", "UD"))
+		}, []string{"Dmitry Bivol"}, nil},
+		{"fight removed", func(fights []models.Fight) []models.Fight { return fights[1:] }, nil, nil},
+		{"volatile fields", func(fights []models.Fight) []models.Fight {
+			parsedAt := time.Date(2024, time.June, 10, 12, 0, 0, 0, time.UTC)
+			fights[0].ParsedAt = &parsedAt
+			fights[0].SourceURL = "https://vringe.example/results"
+			fights[0].Confidence = 0.5
+			return fights
+		}, nil, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			prev := Build(base())
+			next := Build(tt.change(base()))
+
+			added, updated := FightUpdates(prev, next)
+			if got := fighters1(added); !reflect.DeepEqual(got, tt.added) {
+				t.Errorf("added = %q, want %q", got, tt.added)
+			}
+			if got := fighters1(updated); !reflect.DeepEqual(got, tt.updated) {
+				t.Errorf("updated = %q, want %q", got, tt.updated)
+			}
+		})
+	}
+}
+
+// fighters1 returns the first fighter of every fight
+func fighters1(fights []models.Fight) []string {
+	var names []string
+	for _, fight := range fights {
+		names = append(names, fight.Fighter1)
+	}
+
+	return names
+}
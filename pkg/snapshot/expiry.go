@@ -0,0 +1,103 @@
+package snapshot
+
+import (
+	"fmt"
+	"time"
+
+	"easypars/models"
+	"easypars/pkg/clock"
+)
+
+// Reasons of an expected change of the data
+const (
+	// ChangeUpcomingFight means results are expected after the broadcast
+	// of the nearest upcoming fight starts
+	ChangeUpcomingFight = "upcoming_fight"
+	// ChangeFightsToday means fights of today have no result yet; they may
+	// appear until the end of the day
+	ChangeFightsToday = "fights_today"
+	// ChangeTTL means nothing is expected before the standard TTL
+	ChangeTTL = "ttl"
+)
+
+// DefaultBroadcastStart is the typical start of a fight broadcast, as an
+// offset from midnight in the source time zone
+const DefaultBroadcastStart = 19 * time.Hour
+
+// ChangeHints holds what the next expected change of the data depends on
+type ChangeHints struct {
+	// TTL is the longest time data is considered fresh
+	TTL time.Duration
+	// BroadcastStart is the typical start of a broadcast after midnight,
+	// DefaultBroadcastStart when zero
+	BroadcastStart time.Duration
+	// Location is the time zone of the source, UTC when nil
+	Location *time.Location
+}
+
+// ExpectedChange is the time the data is expected to change
+type ExpectedChange struct {
+	At     time.Time `json:"at"`
+	Reason string    `json:"reason"`
+}
+
+// Stale reports whether the expected change of the snapshot has come
+// A snapshot without an expected change is never stale by this rule.
+func (s *Snapshot) Stale(now time.Time) bool {
+	return !s.NextExpectedChange.At.IsZero() && !now.Before(s.NextExpectedChange.At)
+}
+
+// ParseBroadcastStart parses a "HH:MM" broadcast start time
+func ParseBroadcastStart(value string) (time.Duration, error) {
+	t, err := time.Parse("15:04", value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid broadcast start %q: must be HH:MM", value)
+	}
+
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
+
+// NextExpectedChange returns when the fights are expected to change
+// It is the earliest of the broadcast start on the day of the nearest
+// upcoming fight, the end of the current day while fights of today have no
+// result, and the standard TTL. Days are taken in the source time zone, so
+// a fight on the source's evening is not expected at the server's evening.
+func NextExpectedChange(fights []models.Fight, c clock.Clock, hints ChangeHints) ExpectedChange {
+	location := hints.Location
+	if location == nil {
+		location = time.UTC
+	}
+	broadcastStart := hints.BroadcastStart
+	if broadcastStart == 0 {
+		broadcastStart = DefaultBroadcastStart
+	}
+	if c == nil {
+		c = clock.Real{}
+	}
+
+	now := c.Now().In(location)
+	today := clock.Today(clock.Fixed{Time: now})
+	next := ExpectedChange{At: now.Add(hints.TTL), Reason: ChangeTTL}
+
+	for _, fight := range fights {
+		if fight.Status != models.StatusScheduled {
+			continue
+		}
+		day, err := time.ParseInLocation("2006-01-02", fight.Date, location)
+		if err != nil || day.Before(today) {
+			continue
+		}
+
+		// Step 1: Results of the day appear after the broadcast starts
+		if start := day.Add(broadcastStart); start.After(now) && start.Before(next.At) {
+			next = ExpectedChange{At: start, Reason: ChangeUpcomingFight}
+		}
+
+		// Step 2: Fights of today may get their result until the end of the day
+		if end := today.AddDate(0, 0, 1); day.Equal(today) && end.Before(next.At) {
+			next = ExpectedChange{At: end, Reason: ChangeFightsToday}
+		}
+	}
+
+	return next
+}
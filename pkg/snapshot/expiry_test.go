@@ -0,0 +1,123 @@
+package snapshot
+
+import (
+	"testing"
+	"time"
+
+	"easypars/models"
+	"easypars/pkg/clock"
+)
+
+func TestNextExpectedChange(t *testing.T) {
+	moscow := time.FixedZone("MSK", 3*60*60)
+	// noon in UTC is 15:00 in Moscow
+	noon := time.Date(2024, time.June, 10, 12, 0, 0, 0, time.UTC)
+	scheduled := func(date string) models.Fight {
+		return models.Fight{Date: date, Fighter1: "Canelo", Fighter2: "Munguia", Status: models.StatusScheduled}
+	}
+	completed := models.Fight{Date: "2024-06-10", Fighter1: "Usyk", Fighter2: "Fury", Result: "SD", Status: models.StatusCompleted}
+
+	tests := []struct {
+		name   string
+		fights []models.Fight
+		now    time.Time
+		ttl    time.Duration
+		want   ExpectedChange
+	}{
+		{
+			name:   "fight tomorrow evening",
+			fights: []models.Fight{completed, scheduled("2024-06-20"), scheduled("2024-06-11")},
+			now:    noon, ttl: 48 * time.Hour,
+			want: ExpectedChange{time.Date(2024, time.June, 11, 19, 0, 0, 0, moscow), ChangeUpcomingFight},
+		},
+		{
+			name:   "no upcoming fights",
+			fights: []models.Fight{completed, scheduled("2024-06-01")},
+			now:    noon, ttl: 10 * time.Minute,
+			want: ExpectedChange{noon.Add(10 * time.Minute), ChangeTTL},
+		},
+		{
+			name:   "ttl before the fight",
+			fights: []models.Fight{scheduled("2024-06-11")},
+			now:    noon, ttl: time.Hour,
+			want: ExpectedChange{noon.Add(time.Hour), ChangeTTL},
+		},
+		{
+			name:   "fight today before the broadcast",
+			fights: []models.Fight{scheduled("2024-06-10")},
+			now:    noon, ttl: 48 * time.Hour,
+			want: ExpectedChange{time.Date(2024, time.June, 10, 19, 0, 0, 0, moscow), ChangeUpcomingFight},
+		},
+		{
+			name:   "fight today without a result",
+			fights: []models.Fight{scheduled("2024-06-10")},
+			now:    time.Date(2024, time.June, 10, 20, 30, 0, 0, moscow), ttl: 48 * time.Hour,
+			want: ExpectedChange{time.Date(2024, time.June, 11, 0, 0, 0, 0, moscow), ChangeFightsToday},
+		},
+		{
+			// 22:00 in UTC is already the next day in Moscow
+			name:   "days of the source time zone",
+			fights: []models.Fight{scheduled("2024-06-10"), scheduled("2024-06-11")},
+			now:    time.Date(2024, time.June, 10, 22, 0, 0, 0, time.UTC), ttl: 48 * time.Hour,
+			want: ExpectedChange{time.Date(2024, time.June, 11, 19, 0, 0, 0, moscow), ChangeUpcomingFight},
+		},
+	}
+	for _, tt := range tests {
+		hints := ChangeHints{TTL: tt.ttl, Location: moscow}
+		got := NextExpectedChange(tt.fights, clock.Fixed{Time: tt.now}, hints)
+		if !got.At.Equal(tt.want.At) || got.Reason != tt.want.Reason {
+			t.Errorf("%s: next change = %s (%s), want %s (%s)", tt.name, got.At, got.Reason, tt.want.At, tt.want.Reason)
+		}
+	}
+}
+
+func TestNextExpectedChangeBroadcastStart(t *testing.T) {
+	start, err := ParseBroadcastStart("21:30")
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Date(2024, time.June, 10, 12, 0, 0, 0, time.UTC)
+	fights := []models.Fight{{Date: "2024-06-11", Status: models.StatusScheduled}}
+
+	got := NextExpectedChange(fights, clock.Fixed{Time: now}, ChangeHints{TTL: 48 * time.Hour, BroadcastStart: start})
+	if want := time.Date(2024, time.June, 11, 21, 30, 0, 0, time.UTC); !got.At.Equal(want) {
+		t.Errorf("next change = %s, want %s", got.At, want)
+	}
+}
+
+func TestParseBroadcastStart(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    time.Duration
+		wantErr bool
+	}{
+		{"19:00", 19 * time.Hour, false},
+		{"00:15", 15 * time.Minute, false},
+		{"7:30", 7*time.Hour + 30*time.Minute, false},
+		{"19:60", 0, true},
+		{"24:00", 0, true},
+		{"evening", 0, true},
+		{"", 0, true},
+	}
+	for _, tt := range tests {
+		got, err := ParseBroadcastStart(tt.value)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("ParseBroadcastStart(%q) = %v, %v; want %v, error %v", tt.value, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestStale(t *testing.T) {
+	at := time.Date(2024, time.June, 11, 16, 0, 0, 0, time.UTC)
+	snap := &Snapshot{NextExpectedChange: ExpectedChange{At: at, Reason: ChangeUpcomingFight}}
+
+	if snap.Stale(at.Add(-time.Second)) {
+		t.Error("the snapshot is stale before its expected change")
+	}
+	if !snap.Stale(at) || !snap.Stale(at.Add(time.Hour)) {
+		t.Error("the snapshot is not stale once its expected change has come")
+	}
+	if (&Snapshot{}).Stale(at) {
+		t.Error("a snapshot without an expected change is stale")
+	}
+}
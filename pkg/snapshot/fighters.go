@@ -0,0 +1,434 @@
+package snapshot
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"easypars/models"
+)
+
+// externalKey builds the index key of an external ID ("boxrec:447121")
+func externalKey(source, id string) string {
+	return source + ":" + id
+}
+
+// fighterTally counts what the fights of a single fighter say about it
+// Fighters rarely have more than one spelling or ID per source, so the
+// counters are short lists rather than maps
+type fighterTally struct {
+	fights int
+	// names counts the spellings of the fighter name
+	names []counter
+	// dates counts the dates of the fights
+	dates []counter
+	// opponents counts the spellings of the opponent names
+	opponents []counter
+	// ids counts the external IDs
+	ids []idCounter
+}
+
+// counter counts the fights mentioning a value
+type counter struct {
+	value string
+	count int
+}
+
+// idCounter counts the fights mentioning an external ID
+type idCounter struct {
+	source string
+	id     string
+	count  int
+}
+
+// fighterState holds the tallies of every fighter of a fight list
+// Adding and removing fights only touches the tallies of their fighters, so
+// a small diff updates the aggregate without scanning all fights. The state
+// is mutable and belongs to one aggregate at a time (see handoff).
+type fighterState struct {
+	tallies map[string]*fighterTally
+	// conflicts holds the conflicting ID warnings by fighter key
+	conflicts map[string][]string
+	// claims maps "source:id" to the keys of the fighters using the ID
+	claims map[string]map[string]bool
+	// shared holds the shared ID warnings by "source:id"
+	shared map[string][]string
+}
+
+// newFighterState creates an empty state
+func newFighterState() *fighterState {
+	return &fighterState{
+		tallies:   make(map[string]*fighterTally),
+		conflicts: make(map[string][]string),
+		claims:    make(map[string]map[string]bool),
+		shared:    make(map[string][]string),
+	}
+}
+
+// fighterAggregate holds the fighters of the data set and their indexes
+// It is immutable once built; only the state moves on to the next snapshot.
+type fighterAggregate struct {
+	// fighters are sorted by key
+	fighters []models.Fighter
+	// external indexes the unambiguous external IDs, sorted by "source:id"
+	external []externalEntry
+	warnings []string
+
+	// state is the tally the aggregate was built from
+	state *handoff[fighterState]
+}
+
+// externalEntry maps an external ID to the key of its fighter
+type externalEntry struct {
+	id         string
+	fighterKey string
+}
+
+// buildFighters aggregates the fighters of the data set from scratch
+// External IDs known for a fighter from any of its fights belong to the
+// fighter and are attached to all of its fights in the view. A fighter is
+// named after the most frequent spelling and takes the most frequent ID of
+// every source (alphabetically first on ties). Placeholder opponents are not
+// fighters. Names are compared normalized and shown with their whitespace
+// collapsed, so "Tyson Fury " and "Tyson  Fury" are one fighter.
+func buildFighters(fights []models.Fight) fighterAggregate {
+	state := newFighterState()
+	touched := make(map[string]bool)
+	for _, fight := range fights {
+		state.apply(fight, 1, touched)
+	}
+
+	return state.materialize(nil, touched)
+}
+
+// applyDiff updates the aggregate with the fights of the diff
+// The state is taken over from prev, which stays readable but can no
+// longer be updated. False is returned when the state was already taken.
+func (prev *fighterAggregate) applyDiff(diff FightsDiff) (fighterAggregate, bool) {
+	state := prev.state.take()
+	if state == nil {
+		return fighterAggregate{}, false
+	}
+
+	touched := make(map[string]bool)
+	for _, fight := range diff.Removed {
+		state.apply(fight, -1, touched)
+	}
+	for _, change := range diff.Changed {
+		state.apply(change.Old, -1, touched)
+		state.apply(change.New, 1, touched)
+	}
+	for _, fight := range diff.Added {
+		state.apply(fight, 1, touched)
+	}
+
+	return state.materialize(prev, touched), true
+}
+
+// apply adds (delta 1) or removes (delta -1) a fight, collecting the keys of
+// the touched fighters
+func (st *fighterState) apply(fight models.Fight, delta int, touched map[string]bool) {
+	st.count(fight.Fighter1, fight.Fighter2, fight.Date, fight.Fighter1ExternalIDs, delta, touched)
+	st.count(fight.Fighter2, fight.Fighter1, fight.Date, fight.Fighter2ExternalIDs, delta, touched)
+}
+
+// count updates the tally of a single fighter
+func (st *fighterState) count(name, opponent, date string, ids map[string]string, delta int, touched map[string]bool) {
+	key := models.NormalizeName(name)
+	if key == "" || placeholderNames[key] {
+		return
+	}
+
+	tally, ok := st.tallies[key]
+	if !ok {
+		tally = &fighterTally{}
+		st.tallies[key] = tally
+	}
+	tally.fights += delta
+	tally.names = addCounter(tally.names, collapseSpaces(name), delta)
+	if date != "" {
+		tally.dates = addCounter(tally.dates, date, delta)
+	}
+	if opponentKey := models.NormalizeName(opponent); opponentKey != "" && !placeholderNames[opponentKey] {
+		tally.opponents = addCounter(tally.opponents, collapseSpaces(opponent), delta)
+	}
+	for source, id := range ids {
+		tally.ids = addIDCounter(tally.ids, source, id, delta)
+	}
+	if tally.fights <= 0 {
+		delete(st.tallies, key)
+	}
+	touched[key] = true
+}
+
+// collapseSpaces trims a name and collapses its inner whitespace
+func collapseSpaces(name string) string {
+	return strings.Join(strings.Fields(name), " ")
+}
+
+// addCounter changes the count of a value, dropping it at zero
+func addCounter(counters []counter, value string, delta int) []counter {
+	for i := range counters {
+		if counters[i].value == value {
+			counters[i].count += delta
+			if counters[i].count <= 0 {
+				counters = append(counters[:i], counters[i+1:]...)
+			}
+			return counters
+		}
+	}
+	if delta > 0 {
+		counters = append(counters, counter{value: value, count: delta})
+	}
+
+	return counters
+}
+
+// addIDCounter changes the count of an external ID, dropping it at zero
+func addIDCounter(counters []idCounter, source, id string, delta int) []idCounter {
+	for i := range counters {
+		if counters[i].source == source && counters[i].id == id {
+			counters[i].count += delta
+			if counters[i].count <= 0 {
+				counters = append(counters[:i], counters[i+1:]...)
+			}
+			return counters
+		}
+	}
+	if delta > 0 {
+		counters = append(counters, idCounter{source: source, id: id, count: delta})
+	}
+
+	return counters
+}
+
+// fighter derives the fighter record and its conflicting ID warnings
+func (t *fighterTally) fighter(key string) (models.Fighter, []string) {
+	fighter := models.Fighter{
+		Name:        mostFrequent(t.names),
+		Key:         key,
+		ExternalIDs: make(map[string]string, len(t.ids)),
+		FightCount:  t.fights,
+		Opponents:   t.opponentNames(),
+	}
+	for _, date := range t.dates {
+		fighter.LastFightDate = max(fighter.LastFightDate, date.value)
+	}
+	if len(t.ids) == 0 {
+		return fighter, nil
+	}
+
+	// Group the IDs by source, sources and IDs in a fixed order
+	ids := append([]idCounter(nil), t.ids...)
+	sort.Slice(ids, func(i, j int) bool {
+		if ids[i].source != ids[j].source {
+			return ids[i].source < ids[j].source
+		}
+		return ids[i].id < ids[j].id
+	})
+
+	var warnings []string
+	for start := 0; start < len(ids); {
+		end := start
+		bySource := make([]counter, 0, 1)
+		for end < len(ids) && ids[end].source == ids[start].source {
+			bySource = append(bySource, counter{value: ids[end].id, count: ids[end].count})
+			end++
+		}
+
+		source := ids[start].source
+		chosen := mostFrequent(bySource)
+		fighter.ExternalIDs[source] = chosen
+		for _, id := range bySource {
+			if id.value != chosen {
+				warnings = append(warnings, fmt.Sprintf("fighter %q has conflicting %s IDs %s and %s", fighter.Name, source, chosen, id.value))
+			}
+		}
+		start = end
+	}
+
+	return fighter, warnings
+}
+
+// opponentNames returns the opponents, one per normalized name in the most
+// frequent spelling, sorted by normalized name
+func (t *fighterTally) opponentNames() []string {
+	spellings := make(map[string][]counter)
+	for _, opponent := range t.opponents {
+		key := models.NormalizeName(opponent.value)
+		spellings[key] = append(spellings[key], opponent)
+	}
+
+	names := make([]string, 0, len(spellings))
+	for _, key := range sortedKeys(spellings) {
+		names = append(names, mostFrequent(spellings[key]))
+	}
+
+	return names
+}
+
+// materialize builds the aggregate after the touched fighters changed
+// prev is the aggregate before the change, nil when every fighter was touched.
+// Untouched fighters are copied from prev, so the cost follows the size of
+// the change plus a copy of the sorted lists.
+func (st *fighterState) materialize(prev *fighterAggregate, touched map[string]bool) fighterAggregate {
+	// Step 1: Derive the touched fighters and release their old external IDs
+	keys := sortedKeys(touched)
+	updated := make([]models.Fighter, 0, len(keys))
+	affected := make(map[string]bool)
+	for _, key := range keys {
+		if prev != nil {
+			if old, ok := prev.find(key); ok {
+				for source, id := range old.ExternalIDs {
+					affected[externalKey(source, id)] = true
+					delete(st.claims[externalKey(source, id)], key)
+				}
+			}
+		}
+
+		delete(st.conflicts, key)
+		tally, ok := st.tallies[key]
+		if !ok {
+			continue
+		}
+		fighter, conflicts := tally.fighter(key)
+		if len(conflicts) > 0 {
+			st.conflicts[key] = conflicts
+		}
+		for source, id := range fighter.ExternalIDs {
+			ext := externalKey(source, id)
+			affected[ext] = true
+			if st.claims[ext] == nil {
+				st.claims[ext] = make(map[string]bool)
+			}
+			st.claims[ext][key] = true
+		}
+		updated = append(updated, fighter)
+	}
+
+	// Step 2: Merge the touched fighters into the untouched ones
+	agg := fighterAggregate{state: newHandoff(st)}
+	var prevFighters []models.Fighter
+	var prevExternal []externalEntry
+	if prev != nil {
+		prevFighters, prevExternal = prev.fighters, prev.external
+	}
+	agg.fighters = mergeSorted(prevFighters, keys, updated, func(f models.Fighter) string { return f.Key })
+
+	// Step 3: Index the affected external IDs; an ID claimed by two fighters
+	// is ambiguous and left out of the index
+	var entries []externalEntry
+	affectedKeys := sortedKeys(affected)
+	for _, ext := range affectedKeys {
+		delete(st.shared, ext)
+		claimants := sortedKeys(st.claims[ext])
+		switch len(claimants) {
+		case 0:
+			delete(st.claims, ext)
+		case 1:
+			entries = append(entries, externalEntry{id: ext, fighterKey: claimants[0]})
+		default:
+			first, _ := agg.find(claimants[0])
+			for _, key := range claimants[1:] {
+				other, _ := agg.find(key)
+				st.shared[ext] = append(st.shared[ext], fmt.Sprintf("external ID %s is shared by %q and %q", ext, first.Name, other.Name))
+			}
+		}
+	}
+	agg.external = mergeSorted(prevExternal, affectedKeys, entries, func(e externalEntry) string { return e.id })
+
+	// Step 4: Collect the warnings, conflicts by fighter, then shared IDs
+	for _, key := range sortedKeys(st.conflicts) {
+		agg.warnings = append(agg.warnings, st.conflicts[key]...)
+	}
+	for _, ext := range sortedKeys(st.shared) {
+		agg.warnings = append(agg.warnings, st.shared[ext]...)
+	}
+
+	return agg
+}
+
+// mergeSorted replaces the entries of a list sorted by key
+// keys are the sorted keys being replaced and updates their new entries,
+// sorted; a key without an update is dropped. The runs between the keys are
+// copied in bulk, so a few keys cost little even in a long list.
+func mergeSorted[T any](base []T, keys []string, updates []T, keyOf func(T) string) []T {
+	merged := make([]T, 0, len(base)+len(updates))
+	pos, next := 0, 0
+	for _, key := range keys {
+		idx := pos + sort.Search(len(base)-pos, func(i int) bool { return keyOf(base[pos+i]) >= key })
+		merged = append(merged, base[pos:idx]...)
+		pos = idx
+		if pos < len(base) && keyOf(base[pos]) == key {
+			pos++
+		}
+		if next < len(updates) && keyOf(updates[next]) == key {
+			merged = append(merged, updates[next])
+			next++
+		}
+	}
+
+	return append(merged, base[pos:]...)
+}
+
+// find returns the fighter with the given key
+func (a *fighterAggregate) find(key string) (models.Fighter, bool) {
+	idx := sort.Search(len(a.fighters), func(i int) bool { return a.fighters[i].Key >= key })
+	if idx == len(a.fighters) || a.fighters[idx].Key != key {
+		return models.Fighter{}, false
+	}
+
+	return a.fighters[idx], true
+}
+
+// mostFrequent returns the most counted value, alphabetically first on ties
+func mostFrequent(counters []counter) string {
+	best := counter{}
+	for _, c := range counters {
+		if c.count > best.count || (c.count == best.count && c.value < best.value) {
+			best = c
+		}
+	}
+
+	return best.value
+}
+
+// sortedKeys returns the keys of a map in a fixed order
+// so results and warnings do not depend on map iteration
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
+
+// externalIDs returns the external IDs of the named fighter, nil when none
+// The fight gets its own copy, so the snapshot fighters stay immutable
+func (a *fighterAggregate) externalIDs(name string) map[string]string {
+	fighter, ok := a.find(models.NormalizeName(name))
+	if !ok || len(fighter.ExternalIDs) == 0 {
+		return nil
+	}
+
+	ids := make(map[string]string, len(fighter.ExternalIDs))
+	for source, id := range fighter.ExternalIDs {
+		ids[source] = id
+	}
+
+	return ids
+}
+
+// FighterByExternalID returns the fighter with the given ID in an external source
+func (s *Snapshot) FighterByExternalID(source, id string) (models.Fighter, bool) {
+	fighters := s.fighters.get()
+	key := externalKey(source, id)
+	idx := sort.Search(len(fighters.external), func(i int) bool { return fighters.external[i].id >= key })
+	if idx == len(fighters.external) || fighters.external[idx].id != key {
+		return models.Fighter{}, false
+	}
+
+	return fighters.find(fighters.external[idx].fighterKey)
+}
@@ -0,0 +1,71 @@
+package snapshot
+
+import (
+	"reflect"
+	"testing"
+
+	"easypars/models"
+)
+
+func TestBuildFighters(t *testing.T) {
+	fight := func(date, fighter1, fighter2 string) models.Fight {
+		return models.Fight{Date: date, Fighter1: fighter1, Fighter2: fighter2}
+	}
+
+	tests := []struct {
+		name   string
+		fights []models.Fight
+		want   []models.Fighter
+	}{
+		{
+			// The opponents of a fighter keep the spelling of its own fights
+			name: "spellings of one fighter",
+			fights: []models.Fight{
+				fight("2024-05-18", "Tyson Fury ", "Oleksandr Usyk"),
+				fight("2024-12-21", "Oleksandr Usyk", "Tyson  Fury"),
+				fight("2021-10-09", "tyson fury", "Deontay Wilder"),
+			},
+			want: []models.Fighter{
+				{Name: "Deontay Wilder", Key: "deontay wilder", FightCount: 1, LastFightDate: "2021-10-09", Opponents: []string{"tyson fury"}},
+				{Name: "Oleksandr Usyk", Key: "oleksandr usyk", FightCount: 2, LastFightDate: "2024-12-21", Opponents: []string{"Tyson Fury"}},
+				{Name: "Tyson Fury", Key: "tyson fury", FightCount: 3, LastFightDate: "2024-12-21", Opponents: []string{"Deontay Wilder", "Oleksandr Usyk"}},
+			},
+		},
+		{
+			// A placeholder opponent is neither an opponent nor a fighter
+			name: "undated and placeholder opponents",
+			fights: []models.Fight{
+				fight("", "Daniel Dubois", "TBA"),
+				fight("2024-06-01", "Daniel Dubois", "Filip Hrgovic"),
+			},
+			want: []models.Fighter{
+				{Name: "Daniel Dubois", Key: "daniel dubois", FightCount: 2, LastFightDate: "2024-06-01", Opponents: []string{"Filip Hrgovic"}},
+				{Name: "Filip Hrgovic", Key: "filip hrgovic", FightCount: 1, LastFightDate: "2024-06-01", Opponents: []string{"Daniel Dubois"}},
+			},
+		},
+		{
+			name: "cyrillic names",
+			fights: []models.Fight{
+				fight("2024-05-18", "Александр  Усик", "Тайсон Фьюри"),
+			},
+			want: []models.Fighter{
+				{Name: "Александр Усик", Key: "александр усик", FightCount: 1, LastFightDate: "2024-05-18", Opponents: []string{"Тайсон Фьюри"}},
+				{Name: "Тайсон Фьюри", Key: "тайсон фьюри", FightCount: 1, LastFightDate: "2024-05-18", Opponents: []string{"Александр Усик"}},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fighters := buildFighters(tt.fights).fighters
+			for i := range fighters {
+				if len(fighters[i].ExternalIDs) != 0 {
+					t.Errorf("fighter %s has external IDs %v, want none", fighters[i].Name, fighters[i].ExternalIDs)
+				}
+				fighters[i].ExternalIDs = nil
+			}
+			if !reflect.DeepEqual(fighters, tt.want) {
+				t.Errorf("fighters = %+v\nwant %+v", fighters, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,190 @@
+package snapshot
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Aggregate names, as used by snapshot.precompute
+const (
+	AggregateFighters  = "fighters"
+	AggregateLocations = "locations"
+	AggregateInterest  = "interest"
+	AggregateView      = "view"
+)
+
+// aggregateNames lists every lazy aggregate in build order
+var aggregateNames = []string{AggregateFighters, AggregateLocations, AggregateInterest, AggregateView}
+
+// ValidateAggregates checks that every name is a known aggregate
+func ValidateAggregates(names []string) error {
+	for _, name := range names {
+		known := false
+		for _, candidate := range aggregateNames {
+			known = known || name == candidate
+		}
+		if !known {
+			return fmt.Errorf("unknown snapshot aggregate %q, expected one of %v", name, aggregateNames)
+		}
+	}
+
+	return nil
+}
+
+// AggregateStats describes a lazy aggregate of a snapshot
+type AggregateStats struct {
+	Name string `json:"name"`
+	// Built tells whether the aggregate of this snapshot has been built
+	Built bool `json:"built"`
+	// BuildMs is how long building it took
+	BuildMs float64 `json:"build_ms"`
+	// Incremental tells whether it was updated from the previous snapshot
+	// instead of being built from all fights
+	Incremental bool `json:"incremental"`
+	// TotalBuilds counts the builds of the aggregate across all snapshots
+	TotalBuilds int64 `json:"total_builds"`
+}
+
+// totalBuilds counts aggregate builds by name across all snapshots
+var totalBuilds = struct {
+	sync.Mutex
+	counts map[string]*atomic.Int64
+}{counts: make(map[string]*atomic.Int64)}
+
+// buildCounter returns the process wide build counter of an aggregate
+func buildCounter(name string) *atomic.Int64 {
+	totalBuilds.Lock()
+	defer totalBuilds.Unlock()
+
+	counter, ok := totalBuilds.counts[name]
+	if !ok {
+		counter = &atomic.Int64{}
+		totalBuilds.counts[name] = counter
+	}
+
+	return counter
+}
+
+// lazyAgg builds a value on first use, exactly once
+// Concurrent first calls wait for the single build.
+type lazyAgg[T any] struct {
+	name string
+	// build returns the value and whether it was updated incrementally
+	build func() (T, bool)
+
+	once        sync.Once
+	value       T
+	built       atomic.Bool
+	incremental bool
+	duration    time.Duration
+	builds      atomic.Int64
+}
+
+// newLazyAgg creates a lazy aggregate that is always built in full
+func newLazyAgg[T any](name string, build func() T) *lazyAgg[T] {
+	return newIncrementalAgg(name, func() (T, bool) { return build(), false })
+}
+
+// newIncrementalAgg creates a lazy aggregate that may be updated from the
+// aggregate of the previous snapshot
+func newIncrementalAgg[T any](name string, build func() (T, bool)) *lazyAgg[T] {
+	return &lazyAgg[T]{name: name, build: build}
+}
+
+// get returns the value, building it on the first call
+// The build function is released afterwards, so the inputs it captured (such
+// as the previous aggregate) can be collected
+func (l *lazyAgg[T]) get() T {
+	l.once.Do(func() {
+		start := time.Now()
+		l.value, l.incremental = l.build()
+		l.build = nil
+		l.duration = time.Since(start)
+		l.builds.Add(1)
+		buildCounter(l.name).Add(1)
+		l.built.Store(true)
+	})
+
+	return l.value
+}
+
+// isBuilt reports whether the value has been built
+func (l *lazyAgg[T]) isBuilt() bool {
+	return l.built.Load()
+}
+
+// handoff passes the mutable state of an aggregate on to the aggregate of the
+// next snapshot
+// The state can be taken only once; a second snapshot built from the same
+// predecessor finds it gone and builds its aggregate in full.
+type handoff[T any] struct {
+	mu    sync.Mutex
+	state *T
+}
+
+// newHandoff wraps the state
+func newHandoff[T any](state *T) *handoff[T] {
+	return &handoff[T]{state: state}
+}
+
+// take returns the state and forgets it, nil when already taken
+func (h *handoff[T]) take() *T {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	state := h.state
+	h.state = nil
+	return state
+}
+
+// stats returns the state of the aggregate
+// The duration and mode are read only after built is set, which happens
+// after they are written
+func (l *lazyAgg[T]) stats() AggregateStats {
+	stats := AggregateStats{Name: l.name, TotalBuilds: buildCounter(l.name).Load()}
+	if l.built.Load() {
+		stats.Built = true
+		stats.Incremental = l.incremental
+		stats.BuildMs = float64(l.duration) / float64(time.Millisecond)
+	}
+
+	return stats
+}
+
+// Aggregates returns the state of the lazy aggregates of the snapshot
+func (s *Snapshot) Aggregates() []AggregateStats {
+	stats := []AggregateStats{
+		s.fighters.stats(),
+		s.locations.stats(),
+		s.interest.stats(),
+		s.view.stats(),
+	}
+	sort.SliceStable(stats, func(i, j int) bool { return stats[i].Name < stats[j].Name })
+
+	return stats
+}
+
+// Precompute builds the named aggregates now instead of on first use
+func (s *Snapshot) Precompute(names []string) error {
+	if err := ValidateAggregates(names); err != nil {
+		return err
+	}
+
+	for _, name := range names {
+		switch name {
+		case AggregateFighters:
+			s.fighters.get()
+		case AggregateLocations:
+			s.locations.get()
+		case AggregateInterest:
+			s.interest.get()
+		case AggregateView:
+			s.view.get()
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,130 @@
+package snapshot
+
+import (
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"easypars/models"
+)
+
+// builtAggregates returns the names of the built aggregates of the snapshot
+func builtAggregates(s *Snapshot) []string {
+	var built []string
+	for _, aggregate := range s.Aggregates() {
+		if aggregate.Built {
+			built = append(built, aggregate.Name)
+		}
+	}
+	return built
+}
+
+// lazyFights are the fights of the lazy aggregate tests
+func lazyFights() []models.Fight {
+	return []models.Fight{
+		bout("2024-05-18", "Oleksandr Usyk", "Tyson Fury", "SD"),
+		bout("2024-12-21", "Tyson Fury", "Oleksandr Usyk", "UD"),
+		bout("2099-06-01", "Dmitry Bivol", "Artur Beterbiev", "vs"),
+	}
+}
+
+func TestLazyAggBuildsOnceUnderConcurrentFirstUse(t *testing.T) {
+	var calls atomic.Int32
+	agg := newLazyAgg("lazy test", func() int {
+		calls.Add(1)
+		return 42
+	})
+	before := buildCounter("lazy test").Load()
+
+	// Run with -race: the first callers race for the build
+	var wg sync.WaitGroup
+	for i := 0; i < 32; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if got := agg.get(); got != 42 {
+				t.Errorf("get = %d, want 42", got)
+			}
+			agg.stats()
+		}()
+	}
+	wg.Wait()
+
+	if got := calls.Load(); got != 1 {
+		t.Errorf("build ran %d times, want once", got)
+	}
+	stats := agg.stats()
+	if !stats.Built || stats.Incremental || stats.TotalBuilds-before != 1 || agg.builds.Load() != 1 {
+		t.Errorf("stats = %+v, want one full build", stats)
+	}
+}
+
+func TestAggregatesAreBuiltOnFirstUse(t *testing.T) {
+	tests := []struct {
+		name  string
+		use   func(*Snapshot)
+		built []string
+	}{
+		{"nothing read", func(*Snapshot) {}, nil},
+		{"fight by key", func(s *Snapshot) { s.Get(s.Fights[0].Key) }, nil},
+		{"fighters", func(s *Snapshot) { s.Fighters() }, []string{AggregateFighters}},
+		{"warnings", func(s *Snapshot) { s.AllWarnings() }, []string{AggregateFighters}},
+		{"locations", func(s *Snapshot) { s.Locations() }, []string{AggregateLocations}},
+		{"view", func(s *Snapshot) { s.View() }, []string{AggregateFighters, AggregateInterest, AggregateLocations, AggregateView}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := Build(lazyFights())
+			tt.use(s)
+			if got := builtAggregates(s); !reflect.DeepEqual(got, tt.built) {
+				t.Errorf("built aggregates = %q, want %q", got, tt.built)
+			}
+
+			// A second use builds nothing again
+			before := s.Aggregates()
+			tt.use(s)
+			for i, aggregate := range s.Aggregates() {
+				if aggregate.TotalBuilds != before[i].TotalBuilds {
+					t.Errorf("%s was built again", aggregate.Name)
+				}
+			}
+		})
+	}
+}
+
+func TestPrecompute(t *testing.T) {
+	tests := []struct {
+		name       string
+		precompute []string
+		built      []string
+		wantErr    bool
+	}{
+		{"none", nil, nil, false},
+		{"fighters", []string{AggregateFighters}, []string{AggregateFighters}, false},
+		{"interest and locations", []string{AggregateInterest, AggregateLocations}, []string{AggregateInterest, AggregateLocations}, false},
+		{"view", []string{AggregateView}, []string{AggregateFighters, AggregateInterest, AggregateLocations, AggregateView}, false},
+		{"unknown", []string{"trie"}, nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := ValidateAggregates(tt.precompute); (err != nil) != tt.wantErr {
+				t.Fatalf("ValidateAggregates(%q) = %v, want error %v", tt.precompute, err, tt.wantErr)
+			}
+
+			s := Build(lazyFights())
+			if err := s.Precompute(tt.precompute); (err != nil) != tt.wantErr {
+				t.Fatalf("Precompute(%q) = %v, want error %v", tt.precompute, err, tt.wantErr)
+			}
+			if got := builtAggregates(s); !reflect.DeepEqual(got, tt.built) {
+				t.Errorf("built aggregates after Precompute = %q, want %q", got, tt.built)
+			}
+
+			// The build option warms the same aggregates
+			s = BuildWith(lazyFights(), BuildOptions{Precompute: tt.precompute})
+			if got := builtAggregates(s); !reflect.DeepEqual(got, tt.built) {
+				t.Errorf("built aggregates with the build option = %q, want %q", got, tt.built)
+			}
+		})
+	}
+}
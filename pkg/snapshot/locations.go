@@ -0,0 +1,82 @@
+package snapshot
+
+import (
+	"easypars/models"
+	"easypars/pkg/locations"
+)
+
+// locationAggregate holds the canonical locations of the data set
+// It is immutable once built; only the spelling counts move on to the next
+// snapshot.
+type locationAggregate struct {
+	grouping *locations.Grouping
+	// aliases is the dictionary the grouping was built with
+	aliases *locations.Dictionary
+
+	// counts maps every location spelling to its number of fights
+	counts *handoff[map[string]int]
+}
+
+// buildLocations canonicalizes the locations of the data set
+// Fights are given the ID of their canonical location in the view
+func buildLocations(fights []models.Fight, aliases *locations.Dictionary) locationAggregate {
+	counts := make(map[string]int)
+	for _, fight := range fights {
+		if fight.Location != "" {
+			counts[fight.Location]++
+		}
+	}
+
+	return locationAggregate{
+		grouping: locations.Group(counts, aliases),
+		aliases:  aliases,
+		counts:   newHandoff(&counts),
+	}
+}
+
+// applyDiff updates the locations with the fights of the diff
+// Clustering only runs again when a spelling appeared or disappeared;
+// otherwise the previous clusters are recounted. The counts are taken over
+// from prev; false is returned when they were already taken or the alias
+// dictionary changed.
+func (prev *locationAggregate) applyDiff(diff FightsDiff, aliases *locations.Dictionary) (locationAggregate, bool) {
+	if prev.aliases != aliases {
+		return locationAggregate{}, false
+	}
+	countsRef := prev.counts.take()
+	if countsRef == nil {
+		return locationAggregate{}, false
+	}
+
+	counts := *countsRef
+	update := func(location string, delta int) {
+		if location != "" {
+			addCount(counts, location, delta)
+		}
+	}
+	for _, fight := range diff.Removed {
+		update(fight.Location, -1)
+	}
+	for _, change := range diff.Changed {
+		update(change.Old.Location, -1)
+		update(change.New.Location, 1)
+	}
+	for _, fight := range diff.Added {
+		update(fight.Location, 1)
+	}
+
+	grouping, ok := prev.grouping.Recount(counts)
+	if !ok {
+		grouping = locations.Group(counts, aliases)
+	}
+
+	return locationAggregate{grouping: grouping, aliases: aliases, counts: newHandoff(&counts)}, true
+}
+
+// addCount changes a counter, dropping it when it reaches zero
+func addCount(counts map[string]int, key string, delta int) {
+	counts[key] += delta
+	if counts[key] <= 0 {
+		delete(counts, key)
+	}
+}
@@ -0,0 +1,48 @@
+package snapshot
+
+import (
+	"bytes"
+	"encoding/json"
+	"math/rand"
+	"testing"
+
+	"easypars/models"
+)
+
+// snapshotJSON serializes everything a snapshot serves
+func snapshotJSON(t *testing.T, snap *Snapshot) []byte {
+	t.Helper()
+
+	data, err := json.Marshal(struct {
+		Fights    []models.Fight `json:"fights"`
+		Fighters  any            `json:"fighters"`
+		Locations any            `json:"locations"`
+		Warnings  []string       `json:"warnings"`
+	}{snap.View().Fights(), snap.Fighters(), snap.Locations(), snap.AllWarnings()})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return data
+}
+
+func TestBuildsAreByteIdentical(t *testing.T) {
+	// Few dates and locations, so many fights tie on everything but the key
+	fights := newFightGenerator(7, 40, 4).fights(300)
+	for i := range fights {
+		fights[i].Date = fights[i].Date[:8] + "01"
+		fights[i].CardPosition = i % 3
+	}
+
+	first := snapshotJSON(t, Build(fights))
+	for seed := int64(1); seed <= 5; seed++ {
+		shuffled := append([]models.Fight(nil), fights...)
+		rand.New(rand.NewSource(seed)).Shuffle(len(shuffled), func(i, j int) {
+			shuffled[i], shuffled[j] = shuffled[j], shuffled[i]
+		})
+
+		if again := snapshotJSON(t, Build(shuffled)); !bytes.Equal(first, again) {
+			t.Fatalf("build of the input shuffled with seed %d serializes differently", seed)
+		}
+	}
+}
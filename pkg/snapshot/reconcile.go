@@ -0,0 +1,67 @@
+package snapshot
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// Reconcile rebuilds the incrementally updated aggregates of the snapshot
+// from all fights and describes every difference
+// An incremental update must give the same result as a full build, so a
+// difference points to a bug in the incremental logic. Aggregates that were
+// built in full or are not built yet are skipped.
+func (s *Snapshot) Reconcile() []string {
+	var differences []string
+
+	if s.fighters.isBuilt() && s.fighters.incremental {
+		incremental := s.fighters.get()
+		full := buildFighters(s.Fights)
+		if i := firstDifference(incremental.fighters, full.fighters); i >= 0 {
+			differences = append(differences, fmt.Sprintf("fighters differ from a full build (%d vs %d fighters, first at position %d)",
+				len(incremental.fighters), len(full.fighters), i))
+		}
+		if i := firstDifference(incremental.external, full.external); i >= 0 {
+			differences = append(differences, fmt.Sprintf("external ID index differs from a full build (%d vs %d IDs, first at position %d)",
+				len(incremental.external), len(full.external), i))
+		}
+		if i := firstDifference(incremental.warnings, full.warnings); i >= 0 {
+			differences = append(differences, fmt.Sprintf("fighter warnings differ from a full build (%d vs %d warnings, first at position %d)",
+				len(incremental.warnings), len(full.warnings), i))
+		}
+	}
+
+	if s.locations.isBuilt() && s.locations.incremental {
+		incremental := s.locations.get().grouping
+		full := buildLocations(s.Fights, s.opts.LocationAliases).grouping
+		if i := firstDifference(incremental.Locations, full.Locations); i >= 0 {
+			differences = append(differences, fmt.Sprintf("locations differ from a full build (%d vs %d locations, first at position %d)",
+				len(incremental.Locations), len(full.Locations), i))
+		}
+		if i := firstDifference(incremental.Candidates, full.Candidates); i >= 0 {
+			differences = append(differences, fmt.Sprintf("location candidates differ from a full build (%d vs %d candidates, first at position %d)",
+				len(incremental.Candidates), len(full.Candidates), i))
+		}
+	}
+
+	return differences
+}
+
+// HasIncrementalAggregates reports whether an aggregate of the snapshot was
+// built incrementally, i.e. whether Reconcile has anything to check
+func (s *Snapshot) HasIncrementalAggregates() bool {
+	return (s.fighters.isBuilt() && s.fighters.incremental) ||
+		(s.locations.isBuilt() && s.locations.incremental)
+}
+
+// firstDifference returns the first position where two lists differ, -1 when
+// they are equal
+// Nil and empty lists are equal.
+func firstDifference[T any](a, b []T) int {
+	for i := 0; i < max(len(a), len(b)); i++ {
+		if i >= len(a) || i >= len(b) || !reflect.DeepEqual(a[i], b[i]) {
+			return i
+		}
+	}
+
+	return -1
+}
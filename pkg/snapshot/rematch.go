@@ -0,0 +1,110 @@
+package snapshot
+
+import (
+	"fmt"
+	"sort"
+
+	"easypars/models"
+)
+
+// placeholderNames are opponent names used before a fight is confirmed
+// Fights against a placeholder do not count as a meeting of the pair
+var placeholderNames = map[string]bool{
+	"tba":                  true,
+	"tbd":                  true,
+	"?":                    true,
+	"соперник уточняется":  true,
+	"соперник не объявлен": true,
+}
+
+// isPlaceholderName reports whether the name stands for an unknown opponent
+func isPlaceholderName(name string) bool {
+	normalized := models.NormalizeName(name)
+	return normalized == "" || placeholderNames[normalized]
+}
+
+// pairKey returns the canonical key of a fighter pair independent of order
+func pairKey(fight models.Fight) string {
+	name1 := models.NormalizeName(fight.Fighter1)
+	name2 := models.NormalizeName(fight.Fighter2)
+	if name2 < name1 {
+		name1, name2 = name2, name1
+	}
+
+	return name1 + "|" + name2
+}
+
+// annotateRematches marks repeated meetings of the same fighter pair
+// Every fight of a pair that met more than once gets a meeting number ordered
+// by date; later meetings are flagged as rematches and reference the earlier
+// ones. Two fights of one pair on the same day are a data error: both get the
+// same number and a warning is returned.
+func annotateRematches(fights []models.Fight) []string {
+	var warnings []string
+
+	// Index fights by the canonical fighter pair
+	pairs := make(map[string][]int)
+	for i, fight := range fights {
+		if isPlaceholderName(fight.Fighter1) || isPlaceholderName(fight.Fighter2) {
+			continue
+		}
+		key := pairKey(fight)
+		pairs[key] = append(pairs[key], i)
+	}
+
+	// Walk the pairs in a fixed order so warnings are deterministic
+	keys := make([]string, 0, len(pairs))
+	for key := range pairs {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		meetings := pairs[key]
+		if len(meetings) < 2 {
+			continue
+		}
+
+		// Order the meetings chronologically, fights without a date go last
+		sort.SliceStable(meetings, func(a, b int) bool {
+			dateA, dateB := fights[meetings[a]].Date, fights[meetings[b]].Date
+			if dateA == "" || dateB == "" {
+				return dateA != "" && dateB == ""
+			}
+			return dateA < dateB
+		})
+
+		number := 0
+		for pos, idx := range meetings {
+			fight := &fights[idx]
+
+			// Same-day meetings share the number of the first one
+			if pos > 0 && fight.Date != "" && fight.Date == fights[meetings[pos-1]].Date {
+				warnings = append(warnings, fmt.Sprintf(
+					"fighters %s and %s have more than one fight on %s",
+					fight.Fighter1, fight.Fighter2, fight.Date))
+			} else {
+				number++
+			}
+
+			fight.MeetingNumber = number
+			fight.Rematch = number > 1
+			fight.PreviousMeetings = nil
+
+			// Reference every meeting with a lower number
+			for _, prevIdx := range meetings[:pos] {
+				prev := fights[prevIdx]
+				if prev.Date == fight.Date {
+					continue
+				}
+				fight.PreviousMeetings = append(fight.PreviousMeetings, models.PreviousMeeting{
+					Key:    prev.Key,
+					Date:   prev.Date,
+					Result: prev.Result,
+				})
+			}
+		}
+	}
+
+	return warnings
+}
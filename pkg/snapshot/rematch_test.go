@@ -0,0 +1,119 @@
+package snapshot
+
+import (
+	"reflect"
+	"testing"
+
+	"easypars/models"
+)
+
+// meeting is the expected rematch fields of a fight
+type meeting struct {
+	number   int
+	rematch  bool
+	previous []string
+}
+
+// bout returns a keyed fight of the pair
+func bout(date, fighter1, fighter2, result string) models.Fight {
+	fight := models.Fight{Date: date, Fighter1: fighter1, Fighter2: fighter2, Result: result, Location: "Las Vegas"}
+	fight.AssignKey()
+	return fight
+}
+
+func TestAnnotateRematches(t *testing.T) {
+	// The Fury - Wilder trilogy, listed newest first with the names swapped
+	// between the meetings, like the results pages show it
+	trilogy := []models.Fight{
+		bout("2021-10-09", "Tyson Fury", "Deontay Wilder", "KO 11"),
+		bout("2020-02-22", "Deontay Wilder", "Tyson Fury", "TKO 7"),
+		bout("2018-12-01", "deontay  WILDER", "Tyson Fury", "D"),
+	}
+
+	tests := []struct {
+		name     string
+		fights   []models.Fight
+		want     []meeting
+		warnings int
+	}{
+		{
+			name:   "trilogy",
+			fights: trilogy,
+			want: []meeting{
+				{3, true, []string{trilogy[2].Key, trilogy[1].Key}},
+				{2, true, []string{trilogy[2].Key}},
+				{1, false, nil},
+			},
+		},
+		{
+			name:   "single fight",
+			fights: []models.Fight{bout("2024-05-18", "Oleksandr Usyk", "Tyson Fury", "SD")},
+			want:   []meeting{{0, false, nil}},
+		},
+		{
+			name: "swapped names",
+			fights: []models.Fight{
+				bout("2024-05-18", "Oleksandr Usyk", "Tyson Fury", "SD"),
+				bout("2024-12-21", "Tyson Fury", "Oleksandr Usyk", "UD"),
+			},
+			want: []meeting{{1, false, nil}, {2, true, []string{"2024-05-18|oleksandr usyk|tyson fury|las vegas"}}},
+		},
+		{
+			name: "placeholder opponent",
+			fights: []models.Fight{
+				bout("2024-05-18", "Oleksandr Usyk", "TBA", ""),
+				bout("2024-12-21", "Oleksandr Usyk", "tba", ""),
+			},
+			want: []meeting{{0, false, nil}, {0, false, nil}},
+		},
+		{
+			name: "two fights on one day",
+			fights: []models.Fight{
+				bout("2024-05-18", "Oleksandr Usyk", "Tyson Fury", "SD"),
+				{Date: "2024-05-18", Fighter1: "Tyson Fury", Fighter2: "Oleksandr Usyk", Location: "Riyadh", Key: "2024-05-18|oleksandr usyk|tyson fury|riyadh"},
+			},
+			want:     []meeting{{1, false, nil}, {1, false, nil}},
+			warnings: 1,
+		},
+		{
+			name: "undated meeting last",
+			fights: []models.Fight{
+				bout("", "Oleksandr Usyk", "Tyson Fury", ""),
+				bout("2024-05-18", "Oleksandr Usyk", "Tyson Fury", "SD"),
+			},
+			want: []meeting{{2, true, []string{"2024-05-18|oleksandr usyk|tyson fury|las vegas"}}, {1, false, nil}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fights := append([]models.Fight(nil), tt.fights...)
+			warnings := annotateRematches(fights)
+			if len(warnings) != tt.warnings {
+				t.Errorf("warnings = %q, want %d", warnings, tt.warnings)
+			}
+			for i, fight := range fights {
+				var previous []string
+				for _, prev := range fight.PreviousMeetings {
+					previous = append(previous, prev.Key)
+				}
+				got := meeting{fight.MeetingNumber, fight.Rematch, previous}
+				if !reflect.DeepEqual(got, tt.want[i]) {
+					t.Errorf("fight %d (%s) = %+v, want %+v", i, fight.Key, got, tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestPreviousMeetingsCarryTheResults(t *testing.T) {
+	fights := []models.Fight{
+		bout("2020-02-22", "Deontay Wilder", "Tyson Fury", "TKO 7"),
+		bout("2021-10-09", "Tyson Fury", "Deontay Wilder", "KO 11"),
+	}
+	annotateRematches(fights)
+
+	want := []models.PreviousMeeting{{Key: fights[0].Key, Date: "2020-02-22", Result: "TKO 7"}}
+	if !reflect.DeepEqual(fights[1].PreviousMeetings, want) {
+		t.Errorf("previous meetings = %+v, want %+v", fights[1].PreviousMeetings, want)
+	}
+}
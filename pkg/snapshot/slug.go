@@ -0,0 +1,251 @@
+package snapshot
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+
+	"easypars/models"
+	"easypars/pkg/locations"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// unknownSlugPart replaces a fighter name without a single usable character
+const unknownSlugPart = "unknown"
+
+// latinFold spells Latin letters that have no decomposition in ASCII
+var latinFold = map[rune]string{
+	'ß': "ss", 'ø': "o", 'ł': "l", 'đ': "d", 'æ': "ae", 'œ': "oe", 'ı': "i", 'þ': "th",
+}
+
+// makeSlug builds the base slug of a fight: the surnames (last word of the
+// name) of both fighters and the date, "usyk-vs-fury-2024-05-18"
+// The slug is ASCII only; Cyrillic is transliterated and diacritics are
+// dropped. Fights sharing a base slug are told apart by assignSlugs.
+func makeSlug(fight models.Fight) string {
+	slug := slugPart(surname(fight.Fighter1)) + "-vs-" + slugPart(surname(fight.Fighter2))
+	if date := slugText(fight.Date); date != "" {
+		slug += "-" + date
+	}
+
+	return slug
+}
+
+// surname returns the last word of a fighter name
+func surname(name string) string {
+	words := strings.Fields(name)
+	if len(words) == 0 {
+		return ""
+	}
+
+	return words[len(words)-1]
+}
+
+// slugPart converts a name to a slug part, unknownSlugPart when nothing is left
+func slugPart(text string) string {
+	if part := slugText(text); part != "" {
+		return part
+	}
+
+	return unknownSlugPart
+}
+
+// slugText lowercases and transliterates the text, keeps ASCII letters and
+// digits and joins the remaining runs with hyphens
+func slugText(text string) string {
+	var b strings.Builder
+	for _, r := range norm.NFD.String(locations.Transliterate(text)) {
+		switch {
+		case unicode.Is(unicode.Mn, r):
+			// Combining marks of decomposed letters ("é" -> "e")
+		case latinFold[r] != "":
+			b.WriteString(latinFold[r])
+		case ('a' <= r && r <= 'z') || ('0' <= r && r <= '9'):
+			b.WriteRune(r)
+		default:
+			b.WriteByte(' ')
+		}
+	}
+
+	return strings.Join(strings.Fields(b.String()), "-")
+}
+
+// assignSlugs sets the slug of every fight and returns the slug index
+// Fights sharing a base slug (the same pair on the same day) are ordered by
+// card position, unknown positions last, then by natural key: the first one
+// keeps the base slug, the others get -2, -3 and so on. The order only
+// depends on the fights, so rebuilding the same data gives the same slugs.
+func assignSlugs(fights []models.Fight) map[string]int {
+	groups := make(map[string][]int)
+	for i := range fights {
+		base := makeSlug(fights[i])
+		groups[base] = append(groups[base], i)
+	}
+
+	// Base slugs go first, so a suffixed slug never takes the base slug of
+	// another group
+	bases := sortedKeys(groups)
+	bySlug := make(map[string]int, len(fights))
+	for _, base := range bases {
+		members := groups[base]
+		sort.SliceStable(members, func(a, b int) bool {
+			return slugOrderLess(&fights[members[a]], &fights[members[b]])
+		})
+		bySlug[base] = members[0]
+		fights[members[0]].Slug = base
+	}
+	for _, base := range bases {
+		suffix := 2
+		for _, idx := range groups[base][1:] {
+			slug := fmt.Sprintf("%s-%d", base, suffix)
+			for _, taken := bySlug[slug]; taken; _, taken = bySlug[slug] {
+				suffix++
+				slug = fmt.Sprintf("%s-%d", base, suffix)
+			}
+			bySlug[slug] = idx
+			fights[idx].Slug = slug
+			suffix++
+		}
+	}
+
+	return bySlug
+}
+
+// slugOrderLess orders fights sharing a base slug
+func slugOrderLess(a, b *models.Fight) bool {
+	if a.CardPosition != b.CardPosition {
+		if a.CardPosition == 0 || b.CardPosition == 0 {
+			return b.CardPosition == 0
+		}
+		return a.CardPosition < b.CardPosition
+	}
+
+	return a.Key < b.Key
+}
+
+// journalSlugs builds the rename journal of s from the previous snapshot
+// The journal maps slugs fights no longer have to their natural keys, so a
+// fight keeps being found by its old slug after a fighter was renamed or
+// another fight took its collision suffix. Entries of prev are carried over;
+// entries of fights that are gone and slugs in use again are dropped.
+func journalSlugs(prev, s *Snapshot) map[string]string {
+	moved := matchRenamed(prev, s)
+	journal := make(map[string]string)
+	record := func(slug, key string) {
+		if next, ok := moved[key]; ok {
+			key = next
+		}
+		if _, ok := s.byKey[key]; !ok {
+			return
+		}
+		// Current slugs always win over the journal
+		if _, taken := s.bySlug[slug]; taken {
+			return
+		}
+		journal[slug] = key
+	}
+
+	for slug, key := range prev.renamedSlugs {
+		record(slug, key)
+	}
+	for i := range prev.Fights {
+		record(prev.Fights[i].Slug, prev.Fights[i].Key)
+	}
+
+	return journal
+}
+
+// matchRenamed maps the keys of fights that disappeared from prev to the
+// keys of the fights new in s that are the same bout with a renamed fighter
+// A bout matches when the date and location are the same, one of the
+// fighters kept the name and exactly one new fight qualifies. A renamed
+// fighter with a different external ID of the same source is another
+// person, e.g. a replaced opponent.
+func matchRenamed(prev, s *Snapshot) map[string]string {
+	added := make(map[string][]int)
+	for i := range s.Fights {
+		if _, ok := prev.byKey[s.Fights[i].Key]; !ok {
+			added[s.Fights[i].Date] = append(added[s.Fights[i].Date], i)
+		}
+	}
+
+	moved := make(map[string]string)
+	if len(added) == 0 {
+		return moved
+	}
+	for i := range prev.Fights {
+		old := &prev.Fights[i]
+		if _, ok := s.byKey[old.Key]; ok {
+			continue
+		}
+		var match *models.Fight
+		matches := 0
+		for _, idx := range added[old.Date] {
+			if sameBout(old, &s.Fights[idx]) {
+				match = &s.Fights[idx]
+				matches++
+			}
+		}
+		if matches == 1 {
+			moved[old.Key] = match.Key
+		}
+	}
+
+	return moved
+}
+
+// sameBout reports whether a new fight is the old one with a renamed fighter
+func sameBout(old, fight *models.Fight) bool {
+	if old.Location != fight.Location {
+		return false
+	}
+
+	name := models.NormalizeName
+	switch {
+	case name(old.Fighter1) == name(fight.Fighter1):
+		return samePerson(old.Fighter2ExternalIDs, fight.Fighter2ExternalIDs)
+	case name(old.Fighter2) == name(fight.Fighter2):
+		return samePerson(old.Fighter1ExternalIDs, fight.Fighter1ExternalIDs)
+	case name(old.Fighter1) == name(fight.Fighter2):
+		return samePerson(old.Fighter2ExternalIDs, fight.Fighter1ExternalIDs)
+	case name(old.Fighter2) == name(fight.Fighter1):
+		return samePerson(old.Fighter1ExternalIDs, fight.Fighter2ExternalIDs)
+	}
+
+	return false
+}
+
+// samePerson reports whether two ID sets of a fighter do not contradict
+// each other
+func samePerson(a, b map[string]string) bool {
+	for source, id := range a {
+		if other, ok := b[source]; ok && other != id {
+			return false
+		}
+	}
+
+	return true
+}
+
+// FightBySlug returns the fight with the given slug, derived fields included
+func (s *Snapshot) FightBySlug(slug string) (models.Fight, bool) {
+	idx, ok := s.bySlug[slug]
+	if !ok {
+		return models.Fight{}, false
+	}
+
+	return s.View().At(idx), true
+}
+
+// RenamedSlug returns the current slug of the fight an earlier snapshot
+// served under the given slug
+func (s *Snapshot) RenamedSlug(slug string) (string, bool) {
+	key, ok := s.renamedSlugs[slug]
+	if !ok {
+		return "", false
+	}
+
+	return s.Fights[s.byKey[key]].Slug, true
+}
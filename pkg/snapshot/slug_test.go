@@ -0,0 +1,142 @@
+package snapshot
+
+import (
+	"testing"
+
+	"easypars/models"
+)
+
+func TestMakeSlug(t *testing.T) {
+	tests := []struct {
+		name     string
+		fighter1 string
+		fighter2 string
+		date     string
+		want     string
+	}{
+		{"latin", "Oleksandr Usyk", "Tyson Fury", "2024-05-18", "usyk-vs-fury-2024-05-18"},
+		{"cyrillic", "Дмитрий Бивол", "Максим Власов", "2024-06-01", "bivol-vs-vlasov-2024-06-01"},
+		{"soft sign", "Александр Усик", "Тайсон Фьюри", "2024-05-18", "usik-vs-fyuri-2024-05-18"},
+		{"diacritics", "Lukáš Konečný", "Jürgen Braehmer", "2024-05-18", "konecny-vs-braehmer-2024-05-18"},
+		{"letters without decomposition", "Sören Weiß", "Kasper Løkke", "2024-05-18", "weiss-vs-lokke-2024-05-18"},
+		{"apostrophe", "Shane O'Neil", "Tyson Fury", "2024-05-18", "o-neil-vs-fury-2024-05-18"},
+		{"nothing usable", "???", "", "2024-05-18", "unknown-vs-unknown-2024-05-18"},
+		{"no date", "Oleksandr Usyk", "Tyson Fury", "", "usyk-vs-fury"},
+		{"extra spaces and case", "  oleksandr   USYK ", "Tyson  Fury", "2024-05-18", "usyk-vs-fury-2024-05-18"},
+	}
+	for _, tt := range tests {
+		got := makeSlug(models.Fight{Fighter1: tt.fighter1, Fighter2: tt.fighter2, Date: tt.date})
+		if got != tt.want {
+			t.Errorf("%s: makeSlug(%q, %q, %q) = %q, want %q", tt.name, tt.fighter1, tt.fighter2, tt.date, got, tt.want)
+		}
+		if !slugPatternOK(got) {
+			t.Errorf("%s: slug %q has characters outside [a-z0-9-]", tt.name, got)
+		}
+	}
+}
+
+// slugPatternOK reports whether the slug is lowercase latin letters and
+// digits separated by single hyphens
+func slugPatternOK(slug string) bool {
+	for i, r := range slug {
+		switch {
+		case 'a' <= r && r <= 'z', '0' <= r && r <= '9':
+		case r == '-' && i > 0 && i < len(slug)-1 && slug[i-1] != '-':
+		default:
+			return false
+		}
+	}
+	return slug != ""
+}
+
+func TestSlugCollisions(t *testing.T) {
+	// The same pair twice on one day, in different venues, and a fight whose
+	// base slug looks like a suffixed one
+	fights := func() []models.Fight {
+		fights := []models.Fight{
+			{Date: "2024-05-18", Fighter1: "Oleksandr Usyk", Fighter2: "Tyson Fury", Location: "Riyadh", CardPosition: 2},
+			{Date: "2024-05-18", Fighter1: "Oleksandr Usyk", Fighter2: "Tyson Fury", Location: "London", CardPosition: 1},
+			{Date: "2024-05-18", Fighter1: "Oleksandr Usyk", Fighter2: "Tyson Fury", Location: "Kyiv"},
+			{Date: "2024-05-18", Fighter1: "Jai Opetaia", Fighter2: "Mairis Briedis", Location: "Riyadh"},
+		}
+		for i := range fights {
+			fights[i].AssignKey()
+		}
+		return fights
+	}
+
+	want := map[string]string{
+		"London": "usyk-vs-fury-2024-05-18",
+		"Riyadh": "usyk-vs-fury-2024-05-18-2",
+		"Kyiv":   "usyk-vs-fury-2024-05-18-3",
+	}
+	check := func(name string, s *Snapshot) {
+		t.Helper()
+		for _, fight := range s.Fights {
+			if fight.Fighter1 != "Oleksandr Usyk" {
+				continue
+			}
+			if fight.Slug != want[fight.Location] {
+				t.Errorf("%s: slug of the fight in %s = %q, want %q", name, fight.Location, fight.Slug, want[fight.Location])
+			}
+			if found, ok := s.FightBySlug(fight.Slug); !ok || found.Key != fight.Key {
+				t.Errorf("%s: FightBySlug(%q) = %s, want %s", name, fight.Slug, found.Key, fight.Key)
+			}
+		}
+	}
+
+	// Rebuilding in any input order gives the same suffixes
+	check("first build", Build(fights()))
+	reversed := fights()
+	for i, j := 0, len(reversed)-1; i < j; i, j = i+1, j-1 {
+		reversed[i], reversed[j] = reversed[j], reversed[i]
+	}
+	check("reversed input", Build(reversed))
+	check("rebuild", BuildWith(fights(), BuildOptions{Previous: Build(fights())}))
+}
+
+func TestSuffixNeverTakesABaseSlug(t *testing.T) {
+	// "smith-vs-jones-2" is the base slug of a fight without a date whose
+	// second fighter is spelled "Jones-2"
+	fights := []models.Fight{
+		{Fighter1: "John Smith", Fighter2: "Bob Jones", Location: "Leeds"},
+		{Fighter1: "John Smith", Fighter2: "Bob Jones", Location: "York"},
+		{Fighter1: "John Smith", Fighter2: "Jones-2", Location: "Hull"},
+	}
+	s := Build(fights)
+
+	slugs := make(map[string]string)
+	for _, fight := range s.Fights {
+		slugs[fight.Location] = fight.Slug
+	}
+	if slugs["Hull"] != "smith-vs-jones-2" || slugs["Leeds"] != "smith-vs-jones" || slugs["York"] != "smith-vs-jones-3" {
+		t.Errorf("slugs = %v, want the base slug of Hull kept and York suffixed -3", slugs)
+	}
+}
+
+func TestRenamedFighterKeepsTheOldSlug(t *testing.T) {
+	before := []models.Fight{{Date: "2024-05-18", Fighter1: "Oleksandr Usyk", Fighter2: "Tyson Furi", Location: "Riyadh"}}
+	after := []models.Fight{{Date: "2024-05-18", Fighter1: "Oleksandr Usyk", Fighter2: "Tyson Fury", Location: "Riyadh"}}
+	first := Build(before)
+	second := BuildWith(after, BuildOptions{Previous: first})
+	third := BuildWith(after, BuildOptions{Previous: second})
+
+	const oldSlug, newSlug = "usyk-vs-furi-2024-05-18", "usyk-vs-fury-2024-05-18"
+	for name, s := range map[string]*Snapshot{"renamed": second, "next build": third} {
+		if _, ok := s.FightBySlug(oldSlug); ok {
+			t.Errorf("%s: the old slug still indexes a fight", name)
+		}
+		if current, ok := s.RenamedSlug(oldSlug); !ok || current != newSlug {
+			t.Errorf("%s: RenamedSlug(%q) = %q, %v; want %q", name, oldSlug, current, ok, newSlug)
+		}
+	}
+
+	// Another person is not a rename: the journal forgets the gone fight
+	replaced := []models.Fight{{Date: "2024-05-18", Fighter1: "Oleksandr Usyk", Fighter2: "Tyson Fury", Location: "Riyadh",
+		Fighter2ExternalIDs: map[string]string{models.ExternalSourceBoxRec: "2"}}}
+	prev := []models.Fight{{Date: "2024-05-18", Fighter1: "Oleksandr Usyk", Fighter2: "Tyson Furi", Location: "Riyadh",
+		Fighter2ExternalIDs: map[string]string{models.ExternalSourceBoxRec: "1"}}}
+	if _, ok := BuildWith(replaced, BuildOptions{Previous: Build(prev)}).RenamedSlug(oldSlug); ok {
+		t.Error("a replaced opponent with another BoxRec ID was journaled as a rename")
+	}
+}
@@ -0,0 +1,287 @@
+package snapshot
+
+import (
+	"time"
+
+	"easypars/models"
+	"easypars/pkg/locations"
+	"easypars/pkg/newslink"
+	"easypars/pkg/parser"
+	"easypars/pkg/stats"
+)
+
+// Snapshot is an immutable view of the fight data served by the API
+// It is built once from a parsed or stored fight list and then only read.
+// The fights are prepared when the snapshot is built; the heavier
+// aggregates (fighters, locations, interest scores and the fight view
+// combining them) are built on first use, so a snapshot nobody reads costs
+// little. The fighter and location aggregates are updated from the previous
+// snapshot with the difference of the fights when it had them built.
+type Snapshot struct {
+	// Fights holds the fights in the canonical order (see models.CompareCanonical)
+	// with the rematch fields filled in. Fields derived from aggregates
+	// (external IDs of the fighters, location_id, interest_score) are only
+	// set in View. Readers share the slice and must not modify it.
+	Fights []models.Fight
+	// BuiltAt is the time the snapshot was built
+	BuiltAt time.Time
+	// Warnings lists data problems noticed while building the snapshot
+	// Warnings of lazy aggregates are included in AllWarnings
+	Warnings []string
+	// Columns holds the column diagnostics of the parse the snapshot came from
+	Columns []parser.ColumnDiagnostics
+	// Fingerprint is the content fingerprint of the source page, empty for stored data
+	Fingerprint string
+	// News holds the news items of the build with RelatedFightKey set for
+	// those linked to a fight of the snapshot
+	News []models.NewsItem
+	// NextExpectedChange is when the fights are expected to change, set
+	// when the snapshot is published (see NextExpectedChange); zero when unknown
+	NextExpectedChange ExpectedChange
+
+	// byKey indexes Fights by natural key
+	byKey map[string]int
+	// bySlug indexes Fights by slug
+	bySlug map[string]int
+	// renamedSlugs maps former slugs of the fights to their natural keys
+	renamedSlugs map[string]string
+	// opts are the build options, without the previous snapshot
+	opts BuildOptions
+
+	// Lazy aggregates
+	fighters  *lazyAgg[fighterAggregate]
+	locations *lazyAgg[locationAggregate]
+	interest  *lazyAgg[map[string]float64]
+	view      *lazyAgg[[]models.Fight]
+}
+
+// BuildOptions holds the optional inputs of a snapshot build
+type BuildOptions struct {
+	// LocationAliases is the dictionary of known locations (optional)
+	LocationAliases *locations.Dictionary
+	// Scoring holds the interest score weights, stats.DefaultWeights when nil
+	Scoring *stats.Weights
+	// SearchCounts returns the search counts for the popularity factor of the
+	// interest score; it is called when the scores are built (optional)
+	SearchCounts func() map[string]int
+	// Precompute lists the aggregates built right away instead of on first use
+	Precompute []string
+	// Previous is the snapshot being replaced (optional); its built
+	// aggregates are updated with the difference instead of being rebuilt
+	Previous *Snapshot
+	// News are the published news items (optional); they are linked to the
+	// fights they are about, filling RelatedNews (see pkg/newslink)
+	News []models.NewsItem
+}
+
+// Build creates a snapshot from the given fights
+// The input slice is copied, so callers may keep using it
+func Build(fights []models.Fight) *Snapshot {
+	return BuildWith(fights, BuildOptions{})
+}
+
+// BuildWith creates a snapshot using the given options
+// Unknown names in opts.Precompute are ignored; check them with
+// ValidateAggregates when the configuration is loaded
+func BuildWith(fights []models.Fight, opts BuildOptions) *Snapshot {
+	s := &Snapshot{
+		Fights:  make([]models.Fight, len(fights)),
+		BuiltAt: time.Now(),
+		byKey:   make(map[string]int, len(fights)),
+	}
+	copy(s.Fights, fights)
+
+	// Make sure every fight has a natural key and the ID derived from it,
+	// then keep the fights in the canonical order so equal inputs always
+	// give the same snapshot
+	for i := range s.Fights {
+		if s.Fights[i].Key == "" {
+			s.Fights[i].Key = s.Fights[i].NaturalKey()
+		}
+		s.Fights[i].ID = models.FightID(s.Fights[i].Key)
+	}
+	models.SortCanonical(s.Fights)
+	for i := range s.Fights {
+		s.byKey[s.Fights[i].Key] = i
+	}
+
+	// Link the news to their fights; the links of an earlier build are
+	// replaced, not merged
+	if len(opts.News) > 0 {
+		s.News = make([]models.NewsItem, len(opts.News))
+		copy(s.News, opts.News)
+		for i := range s.Fights {
+			s.Fights[i].RelatedNews = nil
+		}
+		newslink.Apply(newslink.LinkNewsToFights(s.News, s.Fights), s.News, s.Fights)
+	}
+
+	// Rematch fields are cheap and part of every fight, and so are countries
+	s.Warnings = append(s.Warnings, annotateRematches(s.Fights)...)
+	s.Warnings = append(s.Warnings, annotateCountries(s.Fights)...)
+
+	// Slugs are cheap too; the slugs the fights had in the previous snapshot
+	// keep resolving through the rename journal
+	s.bySlug = assignSlugs(s.Fights)
+	if opts.Previous != nil {
+		s.renamedSlugs = journalSlugs(opts.Previous, s)
+	}
+
+	// Aggregates already built for the previous snapshot are updated with
+	// the difference; only the built ones are kept, so snapshots never form
+	// a chain of unbuilt predecessors
+	var diff FightsDiff
+	var prevFighters *lazyAgg[fighterAggregate]
+	var prevLocations *lazyAgg[locationAggregate]
+	if prev := opts.Previous; prev != nil && (prev.fighters.isBuilt() || prev.locations.isBuilt()) {
+		var ok bool
+		if diff, ok = DiffFights(prev, s); ok {
+			if prev.fighters.isBuilt() {
+				prevFighters = prev.fighters
+			}
+			if prev.locations.isBuilt() {
+				prevLocations = prev.locations
+			}
+		}
+	}
+	opts.Previous = nil
+	s.opts = opts
+
+	// Heavy aggregates are built on first use
+	s.fighters = newIncrementalAgg(AggregateFighters, func() (fighterAggregate, bool) {
+		if prevFighters != nil {
+			prev := prevFighters.get()
+			if agg, ok := prev.applyDiff(diff); ok {
+				return agg, true
+			}
+		}
+		return buildFighters(s.Fights), false
+	})
+	s.locations = newIncrementalAgg(AggregateLocations, func() (locationAggregate, bool) {
+		if prevLocations != nil {
+			prev := prevLocations.get()
+			if agg, ok := prev.applyDiff(diff, opts.LocationAliases); ok {
+				return agg, true
+			}
+		}
+		return buildLocations(s.Fights, opts.LocationAliases), false
+	})
+	s.interest = newLazyAgg(AggregateInterest, func() map[string]float64 {
+		return buildInterest(s.Fights, opts)
+	})
+	s.view = newLazyAgg(AggregateView, s.buildView)
+
+	// Installations that care about the first request latency warm up some
+	// aggregates right away
+	for _, name := range opts.Precompute {
+		_ = s.Precompute([]string{name})
+	}
+
+	// Future steps:
+	// - Build event aggregates, incrementally like fighters and locations
+	// - Validate data and collect quality metrics
+
+	return s
+}
+
+// View returns the fights with every derived field filled in
+// It builds the fighter, location and interest aggregates on first use.
+// The view is read-only: it is shared by every reader of the snapshot.
+func (s *Snapshot) View() FightsView {
+	return FightsView{fights: s.view.get()}
+}
+
+// buildView copies the fights and fills in the aggregate derived fields
+func (s *Snapshot) buildView() []models.Fight {
+	fighters := s.fighters.get()
+	grouping := s.locations.get().grouping
+	scores := s.interest.get()
+
+	view := make([]models.Fight, len(s.Fights))
+	copy(view, s.Fights)
+	for i := range view {
+		view[i].Fighter1ExternalIDs = fighters.externalIDs(view[i].Fighter1)
+		view[i].Fighter2ExternalIDs = fighters.externalIDs(view[i].Fighter2)
+		view[i].LocationID = grouping.LocationID(view[i].Location)
+		view[i].InterestScore = scores[view[i].Key]
+	}
+
+	return view
+}
+
+// buildInterest scores the upcoming fights by key
+func buildInterest(fights []models.Fight, opts BuildOptions) map[string]float64 {
+	weights := stats.DefaultWeights()
+	if opts.Scoring != nil {
+		weights = *opts.Scoring
+	}
+	var searches map[string]int
+	if opts.SearchCounts != nil {
+		searches = opts.SearchCounts()
+	}
+
+	scored := make([]models.Fight, len(fights))
+	copy(scored, fights)
+	stats.ScoreUpcoming(scored, searches, weights)
+
+	scores := make(map[string]float64)
+	for _, fight := range scored {
+		if fight.InterestScore != 0 {
+			scores[fight.Key] = fight.InterestScore
+		}
+	}
+
+	return scores
+}
+
+// Fighters returns the fighters of the data set sorted by normalized name
+func (s *Snapshot) Fighters() []models.Fighter {
+	return s.fighters.get().fighters
+}
+
+// Locations returns the canonical locations of the data set sorted by name
+func (s *Snapshot) Locations() []locations.Location {
+	return s.locations.get().grouping.Locations
+}
+
+// LocationCandidates returns similar locations that were not merged
+func (s *Snapshot) LocationCandidates() []locations.Candidate {
+	return s.locations.get().grouping.Candidates
+}
+
+// AllWarnings returns the build warnings together with the warnings of the
+// aggregates, building them if needed
+func (s *Snapshot) AllWarnings() []string {
+	warnings := append([]string(nil), s.Warnings...)
+	return append(warnings, s.fighters.get().warnings...)
+}
+
+// QualityScore rates the snapshot data from 0 to 100
+// The score is the average fight confidence; an empty snapshot scores 0
+func (s *Snapshot) QualityScore() float64 {
+	if len(s.Fights) == 0 {
+		return 0
+	}
+
+	total := 0.0
+	for _, fight := range s.Fights {
+		total += fight.Confidence
+	}
+
+	return total / float64(len(s.Fights)) * 100
+}
+
+// keysUnique reports whether no two fights share a natural key
+func (s *Snapshot) keysUnique() bool {
+	return len(s.byKey) == len(s.Fights)
+}
+
+// Get returns a fight by its natural key
+func (s *Snapshot) Get(key string) (models.Fight, bool) {
+	idx, ok := s.byKey[key]
+	if !ok {
+		return models.Fight{}, false
+	}
+
+	return s.Fights[idx], true
+}
@@ -0,0 +1,135 @@
+package snapshot
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ErrGuardRejected is returned when a snapshot is much worse than the active one
+var ErrGuardRejected = errors.New("snapshot rejected by quality guard")
+
+// ErrNoPending is returned when there is no rejected snapshot to publish
+var ErrNoPending = errors.New("no pending snapshot")
+
+// Guard holds the thresholds used to reject degraded snapshots
+type Guard struct {
+	// MinRatio is the minimal share of the active snapshot's fight count
+	// a new snapshot must have (0.5 means at least half)
+	MinRatio float64
+	// MaxQualityDrop is the maximal allowed drop of the quality score in points
+	MaxQualityDrop float64
+}
+
+// DefaultGuard returns the default guard thresholds
+func DefaultGuard() Guard {
+	return Guard{MinRatio: 0.5, MaxQualityDrop: 20}
+}
+
+// check compares a candidate snapshot with the active one
+// Returns a description of the problem or an empty string when it passes
+func (g Guard) check(active, candidate *Snapshot) string {
+	activeCount := len(active.Fights)
+	candidateCount := len(candidate.Fights)
+	if activeCount > 0 && float64(candidateCount) < g.MinRatio*float64(activeCount) {
+		return fmt.Sprintf("fight count dropped from %d to %d (minimum ratio %.2f)",
+			activeCount, candidateCount, g.MinRatio)
+	}
+
+	drop := active.QualityScore() - candidate.QualityScore()
+	if g.MaxQualityDrop > 0 && drop > g.MaxQualityDrop {
+		return fmt.Sprintf("quality score dropped from %.1f to %.1f (maximum drop %.1f)",
+			active.QualityScore(), candidate.QualityScore(), g.MaxQualityDrop)
+	}
+
+	return ""
+}
+
+// Store holds the active (published) snapshot
+// A candidate much worse than the active snapshot is kept as pending
+// instead of being published, so a truncated source page cannot replace
+// good data. The first snapshot is always published.
+type Store struct {
+	mu      sync.RWMutex
+	guard   Guard
+	active  *Snapshot
+	pending *Snapshot
+	// pendingReason explains why the pending snapshot was rejected
+	pendingReason string
+}
+
+// NewStore creates an empty snapshot store using the given guard
+func NewStore(guard Guard) *Store {
+	return &Store{guard: guard}
+}
+
+// Active returns the published snapshot or nil when nothing was published yet
+func (s *Store) Active() *Snapshot {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.active
+}
+
+// Publish makes the snapshot active unless the guard rejects it
+// A rejected snapshot replaces the previous pending one and
+// ErrGuardRejected is returned wrapped with the reason
+func (s *Store) Publish(candidate *Snapshot) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.active != nil {
+		if reason := s.guard.check(s.active, candidate); reason != "" {
+			s.pending = candidate
+			s.pendingReason = reason
+			return fmt.Errorf("%w: %s", ErrGuardRejected, reason)
+		}
+	}
+
+	s.active = candidate
+	s.pending = nil
+	s.pendingReason = ""
+	return nil
+}
+
+// Pending returns the last rejected snapshot and the rejection reason
+func (s *Store) Pending() (*Snapshot, string) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.pending, s.pendingReason
+}
+
+// PublishPending forces publication of the pending snapshot bypassing the guard
+func (s *Store) PublishPending() (*Snapshot, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.pending == nil {
+		return nil, ErrNoPending
+	}
+
+	s.active = s.pending
+	s.pending = nil
+	s.pendingReason = ""
+	return s.active, nil
+}
+
+// PrunePending drops the pending snapshot when it was built before the given
+// time and reports whether it was (or with dryRun would be) dropped
+// The active snapshot is never pruned
+func (s *Store) PrunePending(before time.Time, dryRun bool) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.pending == nil || !s.pending.BuiltAt.Before(before) {
+		return false
+	}
+
+	if !dryRun {
+		s.pending = nil
+		s.pendingReason = ""
+	}
+	return true
+}
@@ -0,0 +1,99 @@
+package snapshot
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"easypars/models"
+)
+
+// snapshotOf returns a snapshot of n distinct fights of the given confidence
+func snapshotOf(n int, confidence float64) *Snapshot {
+	fights := make([]models.Fight, n)
+	for i := range fights {
+		fights[i] = bout(fmt.Sprintf("2024-05-%02d", i%28+1), fmt.Sprintf("Fighter %d", i), "Opponent", "")
+		fights[i].Confidence = confidence
+	}
+
+	return &Snapshot{Fights: fights}
+}
+
+func TestStorePublishGuard(t *testing.T) {
+	tests := []struct {
+		name      string
+		active    *Snapshot
+		candidate *Snapshot
+		rejected  bool
+	}{
+		{"first snapshot", nil, snapshotOf(1, 0.1), false},
+		{"same size", snapshotOf(10, 0.9), snapshotOf(10, 0.9), false},
+		{"smooth drop", snapshotOf(10, 0.9), snapshotOf(6, 0.8), false},
+		{"exactly half", snapshotOf(10, 0.9), snapshotOf(5, 0.9), false},
+		{"sharp drop", snapshotOf(10, 0.9), snapshotOf(4, 0.9), true},
+		{"empty candidate", snapshotOf(10, 0.9), snapshotOf(0, 0), true},
+		{"quality drop", snapshotOf(10, 0.9), snapshotOf(10, 0.5), true},
+		{"growth from empty", snapshotOf(0, 0), snapshotOf(3, 0.9), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := NewStore(DefaultGuard())
+			if tt.active != nil {
+				if err := store.Publish(tt.active); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			err := store.Publish(tt.candidate)
+			if got := errors.Is(err, ErrGuardRejected); got != tt.rejected {
+				t.Fatalf("Publish = %v, rejected %v, want %v", err, got, tt.rejected)
+			}
+			pending, reason := store.Pending()
+			if tt.rejected {
+				if store.Active() != tt.active {
+					t.Error("a rejected snapshot replaced the active one")
+				}
+				if pending != tt.candidate || reason == "" {
+					t.Errorf("pending = %p (%q), want the rejected snapshot with a reason", pending, reason)
+				}
+				return
+			}
+			if store.Active() != tt.candidate {
+				t.Error("the snapshot was not published")
+			}
+			if pending != nil {
+				t.Errorf("pending = %p after a publication, want none", pending)
+			}
+		})
+	}
+}
+
+func TestStorePublishPending(t *testing.T) {
+	store := NewStore(DefaultGuard())
+	if _, err := store.PublishPending(); !errors.Is(err, ErrNoPending) {
+		t.Errorf("PublishPending without a pending snapshot = %v, want ErrNoPending", err)
+	}
+
+	active, truncated := snapshotOf(10, 0.9), snapshotOf(2, 0.9)
+	if err := store.Publish(active); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Publish(truncated); !errors.Is(err, ErrGuardRejected) {
+		t.Fatalf("Publish of a truncated snapshot = %v, want ErrGuardRejected", err)
+	}
+
+	// Forced publication bypasses the guard and clears the pending snapshot
+	published, err := store.PublishPending()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if published != truncated || store.Active() != truncated {
+		t.Error("the pending snapshot was not published")
+	}
+	if pending, _ := store.Pending(); pending != nil {
+		t.Error("the published snapshot is still pending")
+	}
+	if _, err := store.PublishPending(); !errors.Is(err, ErrNoPending) {
+		t.Errorf("second PublishPending = %v, want ErrNoPending", err)
+	}
+}
@@ -0,0 +1,46 @@
+package snapshot
+
+import (
+	"easypars/models"
+)
+
+// FightsView is the read-only fight list of a snapshot (see Snapshot.View)
+// The list is shared by every request served from the snapshot, so it is
+// never handed out as a slice: reading returns copies of the fights and
+// filtering returns a new slice the caller owns and may sort or page in
+// place. Maps and pointers inside the fights (external IDs, raw fields)
+// are still shared and must not be modified.
+type FightsView struct {
+	fights []models.Fight
+}
+
+// Len returns the number of fights
+func (v FightsView) Len() int {
+	return len(v.fights)
+}
+
+// At returns a copy of the i-th fight
+func (v FightsView) At(i int) models.Fight {
+	return v.fights[i]
+}
+
+// Filter returns a new slice with the fights keep accepts, in view order
+// keep gets a copy of each fight, so it cannot change the snapshot.
+func (v FightsView) Filter(keep func(models.Fight) bool) []models.Fight {
+	filtered := make([]models.Fight, 0, len(v.fights))
+	for _, fight := range v.fights {
+		if keep(fight) {
+			filtered = append(filtered, fight)
+		}
+	}
+
+	return filtered
+}
+
+// Fights returns a copy of all fights
+func (v FightsView) Fights() []models.Fight {
+	fights := make([]models.Fight, len(v.fights))
+	copy(fights, v.fights)
+
+	return fights
+}
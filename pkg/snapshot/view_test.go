@@ -0,0 +1,46 @@
+package snapshot
+
+import (
+	"sort"
+	"testing"
+
+	"easypars/models"
+)
+
+func TestViewReturnsCopies(t *testing.T) {
+	snap := Build(newFightGenerator(1, 20, 5).fights(50))
+	view := snap.View()
+	first := view.At(0)
+
+	// Reordering and editing what the view hands out leaves the snapshot alone
+	fights := view.Fights()
+	sort.Slice(fights, func(i, j int) bool { return fights[i].Key > fights[j].Key })
+	fights[0].Fighter1 = "changed"
+	filtered := view.Filter(func(f models.Fight) bool { return true })
+	filtered[0].Location = "changed"
+	copied := view.At(0)
+	copied.Date = "1999-01-01"
+
+	if again := snap.View().At(0); again.Key != first.Key || again.Fighter1 != first.Fighter1 ||
+		again.Location != first.Location || again.Date != first.Date {
+		t.Errorf("snapshot fight changed through the view: %+v, want %+v", again, first)
+	}
+	if snap.View().Len() != 50 {
+		t.Errorf("view has %d fights, want 50", snap.View().Len())
+	}
+}
+
+// BenchmarkViewFights measures the copy of the fight headers of a 50k
+// fight snapshot, made once per request that orders the whole list
+func BenchmarkViewFights(b *testing.B) {
+	snap := Build(newFightGenerator(1, 5000, 300).fights(50000))
+	view := snap.View()
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if fights := view.Fights(); len(fights) != 50000 {
+			b.Fatal("short copy")
+		}
+	}
+}
@@ -0,0 +1,118 @@
+package startup
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Readiness is the HTTP handler served while the application starts
+// The listener is opened before any component is initialized: /healthz
+// answers right away, /readyz reports progress until the application
+// handler is installed, and every other request gets 503 until then.
+type Readiness struct {
+	startedAt time.Time
+	handler   atomic.Pointer[http.Handler]
+
+	mu      sync.Mutex
+	results map[string]Result
+	ready   bool
+	err     string
+}
+
+// NewReadiness creates a handler in the starting state
+func NewReadiness() *Readiness {
+	return &Readiness{startedAt: time.Now(), results: make(map[string]Result)}
+}
+
+// Record stores the outcome of a component, suitable as the Run callback
+func (r *Readiness) Record(result Result) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.results[result.Name] = result
+}
+
+// Ready installs the application handler and marks the service ready
+func (r *Readiness) Ready(handler http.Handler) {
+	r.handler.Store(&handler)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.ready = true
+}
+
+// Fail marks the start as failed, /readyz keeps answering 503 with the reason
+func (r *Readiness) Fail(err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.err = err.Error()
+}
+
+// ServeHTTP answers probes and passes other requests to the application handler
+func (r *Readiness) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	switch req.URL.Path {
+	case "/healthz":
+		writeJSON(w, http.StatusOK, map[string]string{"status": "alive"})
+		return
+	case "/readyz":
+		r.serveReadyz(w)
+		return
+	}
+
+	if handler := r.handler.Load(); handler != nil {
+		(*handler).ServeHTTP(w, req)
+		return
+	}
+
+	w.Header().Set("Retry-After", "1")
+	writeJSON(w, http.StatusServiceUnavailable, map[string]string{
+		"error":   "starting",
+		"message": "Service is starting",
+	})
+}
+
+// serveReadyz reports the readiness and the initialization of every component
+func (r *Readiness) serveReadyz(w http.ResponseWriter) {
+	r.mu.Lock()
+	components := make([]Result, 0, len(r.results))
+	for _, result := range r.results {
+		components = append(components, result)
+	}
+	ready, startErr := r.ready, r.err
+	r.mu.Unlock()
+	sort.Slice(components, func(i, j int) bool { return components[i].Name < components[j].Name })
+
+	status, code := "starting", http.StatusServiceUnavailable
+	switch {
+	case startErr != "":
+		status = "failed"
+	case ready:
+		status, code = "ready", http.StatusOK
+		for _, result := range components {
+			if result.State != StateOK {
+				status = "degraded"
+				break
+			}
+		}
+	}
+
+	body := map[string]any{
+		"status":     status,
+		"uptime_ms":  time.Since(r.startedAt).Milliseconds(),
+		"components": components,
+	}
+	if startErr != "" {
+		body["error"] = startErr
+	}
+	writeJSON(w, code, body)
+}
+
+// writeJSON writes a JSON response
+func writeJSON(w http.ResponseWriter, code int, body any) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(body)
+}
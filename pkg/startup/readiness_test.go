@@ -0,0 +1,99 @@
+package startup
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// probe serves a GET request of path and decodes the JSON status
+func probe(t *testing.T, r *Readiness, path string) (int, string) {
+	t.Helper()
+
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+	var body struct {
+		Status string `json:"status"`
+		Error  string `json:"error"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("GET %s: %v (%s)", path, err, rec.Body)
+	}
+	if body.Status == "" {
+		return rec.Code, body.Error
+	}
+	return rec.Code, body.Status
+}
+
+func TestReadiness(t *testing.T) {
+	app := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, map[string]string{"status": "app"})
+	})
+
+	tests := []struct {
+		name string
+		// setup brings the readiness into the state of the case
+		setup func(*Readiness)
+		// want maps the requested path to the status code and status
+		want map[string][2]any
+	}{
+		{
+			"starting",
+			func(r *Readiness) { r.Record(Result{Name: "storage", Required: true, State: StateOK}) },
+			map[string][2]any{
+				"/healthz":    {http.StatusOK, "alive"},
+				"/readyz":     {http.StatusServiceUnavailable, "starting"},
+				"/api/fights": {http.StatusServiceUnavailable, "starting"},
+			},
+		},
+		{
+			"ready",
+			func(r *Readiness) {
+				r.Record(Result{Name: "storage", Required: true, State: StateOK})
+				r.Ready(app)
+			},
+			map[string][2]any{
+				"/healthz":    {http.StatusOK, "alive"},
+				"/readyz":     {http.StatusOK, "ready"},
+				"/api/fights": {http.StatusOK, "app"},
+			},
+		},
+		{
+			"degraded by an optional failure",
+			func(r *Readiness) {
+				r.Record(Result{Name: "storage", Required: true, State: StateOK})
+				r.Record(Result{Name: "backfill", State: StateFailed})
+				r.Ready(app)
+			},
+			map[string][2]any{
+				"/readyz":     {http.StatusOK, "degraded"},
+				"/api/fights": {http.StatusOK, "app"},
+			},
+		},
+		{
+			"failed",
+			func(r *Readiness) {
+				r.Record(Result{Name: "storage", Required: true, State: StateFailed})
+				r.Fail(errors.New("required components failed: storage"))
+			},
+			map[string][2]any{
+				"/healthz": {http.StatusOK, "alive"},
+				"/readyz":  {http.StatusServiceUnavailable, "failed"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := NewReadiness()
+			tt.setup(r)
+			for path, want := range tt.want {
+				code, status := probe(t, r, path)
+				if code != want[0] || status != want[1] {
+					t.Errorf("GET %s = %d %q, want %d %q", path, code, status, want[0], want[1])
+				}
+			}
+		})
+	}
+}
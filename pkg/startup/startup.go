@@ -0,0 +1,255 @@
+// Package startup initializes application components in parallel
+// Components form a dependency graph; independent branches start at the
+// same time, each component gets its own timeout, and a failure is fatal
+// only for required components.
+package startup
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Component states reported after initialization
+const (
+	StateOK      = "ok"
+	StateFailed  = "failed"
+	StateSkipped = "skipped"
+)
+
+// DefaultTimeout is used for components without their own timeout
+const DefaultTimeout = 30 * time.Second
+
+// Component is a unit of application initialization
+type Component struct {
+	// Name identifies the component in dependencies, logs and /readyz
+	Name string
+	// DependsOn lists components that must be initialized first
+	DependsOn []string
+	// Required components abort the start when they fail; optional ones
+	// only degrade the service
+	Required bool
+	// Timeout bounds Init, the Run default is used when zero
+	Timeout time.Duration
+	// Init initializes the component; it should return when ctx is done
+	Init func(ctx context.Context) error
+}
+
+// Result is the outcome of a component initialization
+type Result struct {
+	Name       string `json:"name"`
+	Required   bool   `json:"required"`
+	State      string `json:"state"`
+	DurationMs int64  `json:"duration_ms"`
+	Error      string `json:"error,omitempty"`
+}
+
+// Report is the outcome of the whole initialization
+type Report struct {
+	Components []Result `json:"components"`
+	DurationMs int64    `json:"duration_ms"`
+}
+
+// Started lists the components initialized successfully
+func (r Report) Started() []string {
+	var names []string
+	for _, result := range r.Components {
+		if result.State == StateOK {
+			names = append(names, result.Name)
+		}
+	}
+	return names
+}
+
+// Degraded lists the optional components that failed or were skipped
+func (r Report) Degraded() []string {
+	var names []string
+	for _, result := range r.Components {
+		if !result.Required && result.State != StateOK {
+			names = append(names, result.Name)
+		}
+	}
+	return names
+}
+
+// Run initializes the components, starting each as soon as its dependencies are up
+// onResult is called for every finished component and may be nil. A
+// component whose dependency failed is skipped. Run returns an error when
+// a required component failed or was skipped; the report lists everything
+// that came up before.
+func Run(ctx context.Context, components []Component, defaultTimeout time.Duration, onResult func(Result)) (Report, error) {
+	start := time.Now()
+	if err := validate(components); err != nil {
+		return Report{}, err
+	}
+	if defaultTimeout <= 0 {
+		defaultTimeout = DefaultTimeout
+	}
+
+	// done[name] is closed once the component finished, ok[name] tells how
+	done := make(map[string]chan struct{}, len(components))
+	for _, component := range components {
+		done[component.Name] = make(chan struct{})
+	}
+
+	var mu sync.Mutex
+	ok := make(map[string]bool, len(components))
+	results := make([]Result, 0, len(components))
+
+	var wg sync.WaitGroup
+	for _, component := range components {
+		wg.Add(1)
+		go func(component Component) {
+			defer wg.Done()
+			defer close(done[component.Name])
+
+			result := initComponent(ctx, component, defaultTimeout, done, func(name string) bool {
+				mu.Lock()
+				defer mu.Unlock()
+				return ok[name]
+			})
+
+			mu.Lock()
+			ok[component.Name] = result.State == StateOK
+			results = append(results, result)
+			mu.Unlock()
+
+			logResult(result)
+			if onResult != nil {
+				onResult(result)
+			}
+		}(component)
+	}
+	wg.Wait()
+
+	sort.Slice(results, func(i, j int) bool { return results[i].Name < results[j].Name })
+	report := Report{Components: results, DurationMs: time.Since(start).Milliseconds()}
+
+	var failed []string
+	for _, result := range results {
+		if result.Required && result.State != StateOK {
+			failed = append(failed, result.Name)
+		}
+	}
+	if len(failed) > 0 {
+		return report, fmt.Errorf("required components failed: %s (started: %s)",
+			strings.Join(failed, ", "), strings.Join(report.Started(), ", "))
+	}
+
+	return report, nil
+}
+
+// initComponent waits for the dependencies and runs Init within the timeout
+func initComponent(ctx context.Context, component Component, defaultTimeout time.Duration, done map[string]chan struct{}, depOK func(string) bool) Result {
+	result := Result{Name: component.Name, Required: component.Required}
+
+	for _, dependency := range component.DependsOn {
+		<-done[dependency]
+		if !depOK(dependency) {
+			result.State = StateSkipped
+			result.Error = fmt.Sprintf("dependency %s is not available", dependency)
+			return result
+		}
+	}
+
+	timeout := component.Timeout
+	if timeout <= 0 {
+		timeout = defaultTimeout
+	}
+	initCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	// Init runs in its own goroutine, so a component ignoring the context
+	// still cannot hold the start beyond its timeout
+	start := time.Now()
+	errCh := make(chan error, 1)
+	go func() {
+		defer func() {
+			if recovered := recover(); recovered != nil {
+				errCh <- fmt.Errorf("panic: %v", recovered)
+			}
+		}()
+		errCh <- component.Init(initCtx)
+	}()
+
+	var err error
+	select {
+	case err = <-errCh:
+	case <-initCtx.Done():
+		err = fmt.Errorf("timed out after %s", timeout)
+	}
+	result.DurationMs = time.Since(start).Milliseconds()
+
+	if err != nil {
+		result.State = StateFailed
+		result.Error = err.Error()
+		return result
+	}
+
+	result.State = StateOK
+	return result
+}
+
+// logResult writes the outcome of a component to the log
+func logResult(result Result) {
+	switch {
+	case result.State == StateOK:
+		slog.Info("Component initialized", "component", result.Name, "duration_ms", result.DurationMs)
+	case result.Required:
+		slog.Error("Required component failed", "component", result.Name, "state", result.State, "duration_ms", result.DurationMs, "error", result.Error)
+	default:
+		slog.Warn("Optional component failed, running degraded", "component", result.Name, "state", result.State, "duration_ms", result.DurationMs, "error", result.Error)
+	}
+}
+
+// validate checks names, dependencies and the absence of cycles
+func validate(components []Component) error {
+	byName := make(map[string]Component, len(components))
+	for _, component := range components {
+		if component.Name == "" || component.Init == nil {
+			return fmt.Errorf("component %q must have a name and an init function", component.Name)
+		}
+		if _, ok := byName[component.Name]; ok {
+			return fmt.Errorf("duplicate component %s", component.Name)
+		}
+		byName[component.Name] = component
+	}
+
+	// Depth first search over the dependency graph
+	const (
+		visiting = 1
+		visited  = 2
+	)
+	marks := make(map[string]int, len(components))
+	var visit func(name string) error
+	visit = func(name string) error {
+		switch marks[name] {
+		case visiting:
+			return fmt.Errorf("dependency cycle at component %s", name)
+		case visited:
+			return nil
+		}
+		marks[name] = visiting
+		for _, dependency := range byName[name].DependsOn {
+			if _, ok := byName[dependency]; !ok {
+				return fmt.Errorf("component %s depends on unknown component %s", name, dependency)
+			}
+			if err := visit(dependency); err != nil {
+				return err
+			}
+		}
+		marks[name] = visited
+		return nil
+	}
+	for _, component := range components {
+		if err := visit(component.Name); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
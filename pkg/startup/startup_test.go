@@ -0,0 +1,165 @@
+package startup
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// sleeper returns an init function taking d, or less when ctx is done
+func sleeper(d time.Duration, err error) func(context.Context) error {
+	return func(ctx context.Context) error {
+		select {
+		case <-time.After(d):
+			return err
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+func TestRunInitializesIndependentComponentsInParallel(t *testing.T) {
+	const delay = 200 * time.Millisecond
+	components := []Component{
+		{Name: "storage", Required: true, Init: sleeper(delay, nil)},
+		{Name: "sources", Required: true, Init: sleeper(delay, nil)},
+		{Name: "presets", Required: true, Init: sleeper(delay, nil)},
+		{Name: "backfill", DependsOn: []string{"storage"}, Init: sleeper(delay, nil)},
+	}
+
+	start := time.Now()
+	report, err := Run(context.Background(), components, time.Second, nil)
+	elapsed := time.Since(start)
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+
+	// Three branches in parallel and one dependent: two delays, not four
+	if elapsed < 2*delay || elapsed >= 3*delay {
+		t.Errorf("initialization took %s, want about %s", elapsed, 2*delay)
+	}
+	if want := []string{"backfill", "presets", "sources", "storage"}; !reflect.DeepEqual(report.Started(), want) {
+		t.Errorf("started = %q, want %q", report.Started(), want)
+	}
+	for _, result := range report.Components {
+		if result.DurationMs < delay.Milliseconds() {
+			t.Errorf("%s took %d ms, want at least %d", result.Name, result.DurationMs, delay.Milliseconds())
+		}
+	}
+}
+
+func TestRunFailures(t *testing.T) {
+	failure := errors.New("connection refused")
+	tests := []struct {
+		name       string
+		components []Component
+		wantErr    bool
+		states     map[string]string
+		degraded   []string
+	}{
+		{
+			name: "optional failure",
+			components: []Component{
+				{Name: "storage", Required: true, Init: sleeper(0, nil)},
+				{Name: "backfill", DependsOn: []string{"storage"}, Init: sleeper(0, failure)},
+			},
+			states:   map[string]string{"storage": StateOK, "backfill": StateFailed},
+			degraded: []string{"backfill"},
+		},
+		{
+			name: "dependent of an optional failure",
+			components: []Component{
+				{Name: "redis", Init: sleeper(0, failure)},
+				{Name: "warmup", DependsOn: []string{"redis"}, Init: sleeper(0, nil)},
+				{Name: "storage", Required: true, Init: sleeper(0, nil)},
+			},
+			states:   map[string]string{"redis": StateFailed, "warmup": StateSkipped, "storage": StateOK},
+			degraded: []string{"redis", "warmup"},
+		},
+		{
+			name: "required failure",
+			components: []Component{
+				{Name: "storage", Required: true, Init: sleeper(0, failure)},
+				{Name: "sources", Required: true, Init: sleeper(0, nil)},
+			},
+			wantErr: true,
+			states:  map[string]string{"storage": StateFailed, "sources": StateOK},
+		},
+		{
+			name: "required dependent of an optional failure",
+			components: []Component{
+				{Name: "redis", Init: sleeper(0, failure)},
+				{Name: "cache", Required: true, DependsOn: []string{"redis"}, Init: sleeper(0, nil)},
+			},
+			wantErr:  true,
+			states:   map[string]string{"redis": StateFailed, "cache": StateSkipped},
+			degraded: []string{"redis"},
+		},
+		{
+			name: "timeout",
+			components: []Component{
+				{Name: "storage", Required: true, Timeout: 20 * time.Millisecond, Init: func(context.Context) error {
+					time.Sleep(time.Second)
+					return nil
+				}},
+			},
+			wantErr: true,
+			states:  map[string]string{"storage": StateFailed},
+		},
+		{
+			name: "panic",
+			components: []Component{
+				{Name: "presets", Init: func(context.Context) error { panic("nil map") }},
+			},
+			states:   map[string]string{"presets": StateFailed},
+			degraded: []string{"presets"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report, err := Run(context.Background(), tt.components, time.Second, nil)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Run = %v, want error %v", err, tt.wantErr)
+			}
+			if err != nil && !strings.Contains(err.Error(), "started:") {
+				t.Errorf("error %q does not list the started components", err)
+			}
+			states := make(map[string]string)
+			for _, result := range report.Components {
+				states[result.Name] = result.State
+			}
+			if !reflect.DeepEqual(states, tt.states) {
+				t.Errorf("states = %v, want %v", states, tt.states)
+			}
+			if degraded := report.Degraded(); !reflect.DeepEqual(degraded, tt.degraded) {
+				t.Errorf("degraded = %q, want %q", degraded, tt.degraded)
+			}
+		})
+	}
+}
+
+func TestRunRejectsInvalidGraphs(t *testing.T) {
+	noop := sleeper(0, nil)
+	tests := []struct {
+		name       string
+		components []Component
+		want       string
+	}{
+		{"unnamed", []Component{{Init: noop}}, "must have a name"},
+		{"without init", []Component{{Name: "storage"}}, "must have a name"},
+		{"duplicate", []Component{{Name: "storage", Init: noop}, {Name: "storage", Init: noop}}, "duplicate"},
+		{"unknown dependency", []Component{{Name: "backfill", DependsOn: []string{"storage"}, Init: noop}}, "unknown component"},
+		{"cycle", []Component{
+			{Name: "a", DependsOn: []string{"b"}, Init: noop},
+			{Name: "b", DependsOn: []string{"a"}, Init: noop},
+		}, "cycle"},
+	}
+	for _, tt := range tests {
+		if _, err := Run(context.Background(), tt.components, time.Second, nil); err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s: Run = %v, want an error with %q", tt.name, err, tt.want)
+		}
+	}
+}
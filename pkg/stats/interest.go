@@ -0,0 +1,270 @@
+// Package stats derives statistics and rankings from the fight data
+package stats
+
+import (
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"easypars/models"
+)
+
+// Weights sets how much each factor contributes to the interest score
+// Every factor is scaled to 0..1 before weighting, so with the default
+// weights the score ranges from 0 to 100
+type Weights struct {
+	// Wins rewards the combined number of known wins of both fighters
+	Wins float64 `json:"wins"`
+	// Title rewards fights announced as title fights
+	Title float64 `json:"title"`
+	// MainEvent rewards the headliner of a card
+	MainEvent float64 `json:"main_event"`
+	// Unbeaten rewards fights of two fighters without a loss
+	Unbeaten float64 `json:"unbeaten"`
+	// Rematch rewards repeated meetings
+	Rematch float64 `json:"rematch"`
+	// Popularity rewards fighters users search for
+	Popularity float64 `json:"popularity"`
+}
+
+// DefaultWeights returns the weights used when none are configured
+func DefaultWeights() Weights {
+	return Weights{
+		Wins:       30,
+		Title:      25,
+		MainEvent:  15,
+		Unbeaten:   15,
+		Rematch:    10,
+		Popularity: 5,
+	}
+}
+
+// winsScale is the combined win count that gives the full wins factor
+const winsScale = 60
+
+// recordPattern matches a professional record such as "(22-0, 14 KO)"
+var recordPattern = regexp.MustCompile(`\((\d{1,3})-(\d{1,3})(?:-(\d{1,3}))?`)
+
+// titlePattern matches title fight markers in the row text
+var titlePattern = regexp.MustCompile(`(?i)титул|чемпион|пояс|title|belt|\b(?:wba|wbc|ibf|wbo)\b`)
+
+// Record is the professional record of a fighter
+type Record struct {
+	Wins   int `json:"wins"`
+	Losses int `json:"losses"`
+	Draws  int `json:"draws"`
+}
+
+// ParseRecord extracts the record from the text of a boxer cell
+func ParseRecord(text string) (Record, bool) {
+	match := recordPattern.FindStringSubmatch(text)
+	if match == nil {
+		return Record{}, false
+	}
+
+	record := Record{}
+	record.Wins, _ = strconv.Atoi(match[1])
+	record.Losses, _ = strconv.Atoi(match[2])
+	if match[3] != "" {
+		record.Draws, _ = strconv.Atoi(match[3])
+	}
+
+	return record, true
+}
+
+// FighterAggregate holds what is known about a fighter across the data set
+type FighterAggregate struct {
+	// Record is the most recent known record, valid when HasRecord is set
+	Record    Record
+	HasRecord bool
+	// Popularity is the share of searches for the fighter relative to the
+	// most searched fighter (0..1)
+	Popularity float64
+}
+
+// AggregateFighters collects fighter aggregates by normalized name
+// The record is taken from the latest fight whose raw text holds one.
+// searches maps normalized search terms to their counts and may be nil.
+func AggregateFighters(fights []models.Fight, searches map[string]int) map[string]FighterAggregate {
+	aggregates := make(map[string]FighterAggregate)
+	recordDates := make(map[string]string)
+
+	collect := func(name, rawText, date string) {
+		key := models.NormalizeName(name)
+		if key == "" {
+			return
+		}
+		aggregate := aggregates[key]
+		if record, ok := ParseRecord(rawText); ok && date >= recordDates[key] {
+			aggregate.Record = record
+			aggregate.HasRecord = true
+			recordDates[key] = date
+		}
+		aggregates[key] = aggregate
+	}
+	for _, fight := range fights {
+		var boxer1, boxer2 string
+		if fight.Raw != nil {
+			boxer1, boxer2 = fight.Raw.Boxer1Text, fight.Raw.Boxer2Text
+		}
+		collect(fight.Fighter1, boxer1, fight.Date)
+		collect(fight.Fighter2, boxer2, fight.Date)
+	}
+
+	applyPopularity(aggregates, searches)
+
+	return aggregates
+}
+
+// applyPopularity sets the popularity of every fighter from search counts
+// A search counts for a fighter when the term is a part of the name
+func applyPopularity(aggregates map[string]FighterAggregate, searches map[string]int) {
+	if len(searches) == 0 {
+		return
+	}
+
+	counts := make(map[string]int, len(aggregates))
+	top := 0
+	for name := range aggregates {
+		for term, count := range searches {
+			if len([]rune(term)) >= 3 && strings.Contains(name, term) {
+				counts[name] += count
+			}
+		}
+		top = max(top, counts[name])
+	}
+	if top == 0 {
+		return
+	}
+
+	for name, count := range counts {
+		aggregate := aggregates[name]
+		aggregate.Popularity = float64(count) / float64(top)
+		aggregates[name] = aggregate
+	}
+}
+
+// ComputeInterestScore rates how interesting an upcoming fight is expected to be
+// Missing fighter data lowers the score but never fails it: unknown
+// records count as no wins and no unbeaten bonus.
+func ComputeInterestScore(fight models.Fight, fighters map[string]FighterAggregate, headliner bool, weights Weights) float64 {
+	fighter1 := fighters[models.NormalizeName(fight.Fighter1)]
+	fighter2 := fighters[models.NormalizeName(fight.Fighter2)]
+
+	score := 0.0
+
+	// Factor 1: combined wins
+	wins := 0
+	if fighter1.HasRecord {
+		wins += fighter1.Record.Wins
+	}
+	if fighter2.HasRecord {
+		wins += fighter2.Record.Wins
+	}
+	score += weights.Wins * min(float64(wins)/winsScale, 1)
+
+	// Factor 2: title fight
+	if isTitleFight(fight) {
+		score += weights.Title
+	}
+
+	// Factor 3: headliner of the card
+	if headliner {
+		score += weights.MainEvent
+	}
+
+	// Factor 4: both fighters unbeaten
+	if fighter1.HasRecord && fighter2.HasRecord &&
+		fighter1.Record.Losses == 0 && fighter2.Record.Losses == 0 {
+		score += weights.Unbeaten
+	}
+
+	// Factor 5: rematch
+	if fight.Rematch {
+		score += weights.Rematch
+	}
+
+	// Factor 6: star power
+	score += weights.Popularity * max(fighter1.Popularity, fighter2.Popularity)
+
+	return score
+}
+
+// isTitleFight looks for title markers in the texts of the row
+func isTitleFight(fight models.Fight) bool {
+	texts := []string{fight.Result, fight.Location}
+	if fight.Raw != nil {
+		texts = append(texts, fight.Raw.ResultText, fight.Raw.Boxer1Text, fight.Raw.Boxer2Text)
+	}
+
+	for _, text := range texts {
+		if titlePattern.MatchString(text) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ScoreUpcoming sets InterestScore on every scheduled fight
+// The headliner of a card (same date and location) is its fight with the
+// most combined wins; a card of a single fight has no headliner.
+func ScoreUpcoming(fights []models.Fight, searches map[string]int, weights Weights) {
+	fighters := AggregateFighters(fights, searches)
+	headliners := findHeadliners(fights, fighters)
+
+	for i := range fights {
+		if fights[i].Status != models.StatusScheduled {
+			fights[i].InterestScore = 0
+			continue
+		}
+		fights[i].InterestScore = ComputeInterestScore(fights[i], fighters, headliners[i], weights)
+	}
+}
+
+// findHeadliners marks the headliner of every card with more than one fight
+func findHeadliners(fights []models.Fight, fighters map[string]FighterAggregate) map[int]bool {
+	cards := make(map[string][]int)
+	for i, fight := range fights {
+		if fight.Status != models.StatusScheduled || fight.Location == "" {
+			continue
+		}
+		key := fight.Date + "|" + models.NormalizeName(fight.Location)
+		cards[key] = append(cards[key], i)
+	}
+
+	combinedWins := func(fight models.Fight) int {
+		return fighters[models.NormalizeName(fight.Fighter1)].Record.Wins +
+			fighters[models.NormalizeName(fight.Fighter2)].Record.Wins
+	}
+
+	headliners := make(map[int]bool)
+	for _, card := range cards {
+		if len(card) < 2 {
+			continue
+		}
+		best := card[0]
+		for _, idx := range card[1:] {
+			if combinedWins(fights[idx]) > combinedWins(fights[best]) {
+				best = idx
+			}
+		}
+		if combinedWins(fights[best]) > 0 {
+			headliners[best] = true
+		}
+	}
+
+	return headliners
+}
+
+// SortByInterest orders fights by interest score, highest first
+// Ties are broken by the canonical order, so the result is always the same
+func SortByInterest(fights []models.Fight) {
+	sort.SliceStable(fights, func(i, j int) bool {
+		if fights[i].InterestScore != fights[j].InterestScore {
+			return fights[i].InterestScore > fights[j].InterestScore
+		}
+		return models.CompareCanonical(fights[i], fights[j]) < 0
+	})
+}
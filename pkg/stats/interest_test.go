@@ -0,0 +1,146 @@
+package stats
+
+import (
+	"testing"
+
+	"easypars/models"
+)
+
+// scheduled returns an upcoming fight with the texts of the boxer cells
+func scheduled(fighter1, record1, fighter2, record2, location string) models.Fight {
+	return models.Fight{
+		Date:     "2024-06-22",
+		Fighter1: fighter1,
+		Fighter2: fighter2,
+		Location: location,
+		Status:   models.StatusScheduled,
+		Raw: &models.RawFields{
+			Boxer1Text: fighter1 + " " + record1,
+			Boxer2Text: fighter2 + " " + record2,
+		},
+	}
+}
+
+// scoreOf returns the interest score of the fight between the fighters
+func scoreOf(t *testing.T, fights []models.Fight, fighter1 string) float64 {
+	t.Helper()
+
+	for _, fight := range fights {
+		if fight.Fighter1 == fighter1 {
+			return fight.InterestScore
+		}
+	}
+	t.Fatalf("no fight of %s", fighter1)
+	return 0
+}
+
+func TestUnbeatenTitleFightOutranksFiller(t *testing.T) {
+	title := scheduled("Artur Beterbiev", "(20-0, 20 KO)", "Dmitry Bivol", "(23-0, 12 KO)", "Riyadh")
+	title.Raw.ResultText = "WBC, WBA, IBF, WBO title"
+	filler := scheduled("Journeyman One", "(10-8)", "Journeyman Two", "(5-12, 1 KO)", "Riyadh")
+	fights := []models.Fight{filler, title}
+
+	ScoreUpcoming(fights, nil, DefaultWeights())
+	titleScore, fillerScore := scoreOf(t, fights, "Artur Beterbiev"), scoreOf(t, fights, "Journeyman One")
+
+	// Wins 43/60 of 30, title 25, main event 15, unbeaten 15
+	if want := 30*43.0/60 + 25 + 15 + 15; titleScore != want {
+		t.Errorf("title fight score = %v, want %v", titleScore, want)
+	}
+	if want := 30 * 15.0 / 60; fillerScore != want {
+		t.Errorf("filler fight score = %v, want %v", fillerScore, want)
+	}
+
+	SortByInterest(fights)
+	if fights[0].Fighter1 != "Artur Beterbiev" {
+		t.Errorf("first fight by interest = %s vs %s, want the title fight", fights[0].Fighter1, fights[0].Fighter2)
+	}
+}
+
+func TestWeightsChangeTheOrder(t *testing.T) {
+	rematch := scheduled("Oleksandr Usyk", "", "Tyson Fury", "", "")
+	rematch.Rematch = true
+	unbeaten := scheduled("Prospect One", "(12-0)", "Prospect Two", "(9-0)", "")
+
+	fights := []models.Fight{rematch, unbeaten}
+	ScoreUpcoming(fights, nil, DefaultWeights())
+	SortByInterest(fights)
+	if fights[0].Fighter1 != "Prospect One" {
+		t.Errorf("first fight with the default weights = %s, want the unbeaten prospects", fights[0].Fighter1)
+	}
+
+	weights := DefaultWeights()
+	weights.Rematch = 50
+	ScoreUpcoming(fights, nil, weights)
+	SortByInterest(fights)
+	if fights[0].Fighter1 != "Oleksandr Usyk" {
+		t.Errorf("first fight with a heavy rematch weight = %s, want the rematch", fights[0].Fighter1)
+	}
+}
+
+func TestScoringWithoutFighterData(t *testing.T) {
+	unknown := models.Fight{Date: "2024-06-22", Fighter1: "Unknown One", Fighter2: "", Status: models.StatusScheduled}
+
+	if score := ComputeInterestScore(unknown, nil, false, DefaultWeights()); score != 0 {
+		t.Errorf("score without any data = %v, want 0", score)
+	}
+
+	fights := []models.Fight{unknown, scheduled("Half Known", "(5-0)", "No Record", "", "")}
+	ScoreUpcoming(fights, nil, DefaultWeights())
+	if score := scoreOf(t, fights, "Half Known"); score != 30*5.0/60 {
+		t.Errorf("score with one known record = %v, want only the wins of that record", score)
+	}
+}
+
+func TestOnlyScheduledFightsAreScored(t *testing.T) {
+	done := scheduled("Artur Beterbiev", "(20-0)", "Dmitry Bivol", "(23-0)", "")
+	done.Status = models.StatusCompleted
+	done.InterestScore = 99
+
+	fights := []models.Fight{done}
+	ScoreUpcoming(fights, nil, DefaultWeights())
+	if fights[0].InterestScore != 0 {
+		t.Errorf("completed fight score = %v, want 0", fights[0].InterestScore)
+	}
+}
+
+func TestPopularity(t *testing.T) {
+	fights := []models.Fight{
+		scheduled("Oleksandr Usyk", "", "Daniel Dubois", "", ""),
+		scheduled("Anthony Joshua", "", "Francis Ngannou", "", ""),
+	}
+	searches := map[string]int{"usyk": 40, "joshua": 10, "ab": 1000}
+
+	fighters := AggregateFighters(fights, searches)
+	if got := fighters[models.NormalizeName("Oleksandr Usyk")].Popularity; got != 1 {
+		t.Errorf("popularity of the most searched fighter = %v, want 1", got)
+	}
+	if got := fighters[models.NormalizeName("Anthony Joshua")].Popularity; got != 0.25 {
+		t.Errorf("popularity of Joshua = %v, want 0.25", got)
+	}
+
+	ScoreUpcoming(fights, searches, DefaultWeights())
+	if score := scoreOf(t, fights, "Oleksandr Usyk"); score != 5 {
+		t.Errorf("score of the most searched fighter = %v, want the full popularity weight", score)
+	}
+}
+
+func TestParseRecord(t *testing.T) {
+	tests := []struct {
+		text string
+		want Record
+		ok   bool
+	}{
+		{"Oleksandr Usyk (22-0, 14 KO)", Record{Wins: 22}, true},
+		{"Deontay Wilder (43-3-1)", Record{Wins: 43, Losses: 3, Draws: 1}, true},
+		{"Francis Ngannou (0-1)", Record{Losses: 1}, true},
+		{"Zhilei Zhang", Record{}, false},
+		{"", Record{}, false},
+	}
+	for _, tt := range tests {
+		got, ok := ParseRecord(tt.text)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("ParseRecord(%q) = %+v, %v, want %+v, %v", tt.text, got, ok, tt.want, tt.ok)
+		}
+	}
+}
@@ -0,0 +1,45 @@
+package storage
+
+import (
+	"strconv"
+	"time"
+
+	"easypars/models"
+)
+
+// DiffFight returns the changes between a stored fight and the same fight
+// parsed again
+// Only the values read from the source are compared. Volatile fields such
+// as parsed_at, missing_since, the source page or the confidence change
+// without the fight changing and are ignored, so storing an unchanged fight
+// records nothing.
+func DiffFight(stored, incoming models.Fight, detectedAt time.Time) []models.FightChange {
+	key := incoming.Key
+	if key == "" {
+		key = incoming.NaturalKey()
+	}
+
+	var changes []models.FightChange
+	compare := func(field, before, after string) {
+		if before != after {
+			changes = append(changes, models.FightChange{
+				FightKey:   key,
+				Field:      field,
+				Old:        before,
+				New:        after,
+				DetectedAt: detectedAt,
+			})
+		}
+	}
+	compare("fighter1", stored.Fighter1, incoming.Fighter1)
+	compare("fighter2", stored.Fighter2, incoming.Fighter2)
+	compare("result", stored.Result, incoming.Result)
+	compare("result_type", stored.ResultType, incoming.ResultType)
+	compare("round", strconv.Itoa(stored.Round), strconv.Itoa(incoming.Round))
+	compare("location", stored.Location, incoming.Location)
+	compare("status", stored.Status, incoming.Status)
+	compare("fighter1_record", stored.Fighter1Record.String(), incoming.Fighter1Record.String())
+	compare("fighter2_record", stored.Fighter2Record.String(), incoming.Fighter2Record.String())
+
+	return changes
+}
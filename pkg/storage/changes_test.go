@@ -0,0 +1,107 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"easypars/models"
+)
+
+func TestDiffFight(t *testing.T) {
+	detectedAt := time.Date(2024, time.June, 10, 12, 0, 0, 0, time.UTC)
+	stored := testFight("2024-05-18", "Usyk", "Fury", "")
+	stored.Location = "Kingdom Arena, Riyadh"
+	parsedAt := detectedAt.Add(-time.Hour)
+	stored.ParsedAt = &parsedAt
+
+	tests := []struct {
+		name   string
+		update func(fight *models.Fight)
+		want   []string
+	}{
+		{"unchanged", func(*models.Fight) {}, nil},
+		{"result filled in", func(fight *models.Fight) {
+			fight.Result, fight.Status = "SD", models.StatusCompleted
+		}, []string{`result: "" -> "SD"`, `status: "scheduled" -> "completed"`}},
+		{"location corrected", func(fight *models.Fight) {
+			fight.Location = "Kingdom Arena Riyadh"
+		}, []string{`location: "Kingdom Arena, Riyadh" -> "Kingdom Arena Riyadh"`}},
+		{"volatile fields", func(fight *models.Fight) {
+			now := detectedAt
+			fight.ParsedAt, fight.MissingSince = &now, &now
+			fight.SourceURL = "https://vringe.example/results/2024-05"
+			fight.Confidence = 0.5
+		}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			incoming := stored
+			incoming.ParsedAt = nil
+			tt.update(&incoming)
+
+			var got []string
+			for _, change := range DiffFight(stored, incoming, detectedAt) {
+				if change.FightKey != incoming.NaturalKey() || !change.DetectedAt.Equal(detectedAt) {
+					t.Errorf("change %+v, want the key %s detected at %v", change, incoming.NaturalKey(), detectedAt)
+				}
+				got = append(got, fmt.Sprintf("%s: %q -> %q", change.Field, change.Old, change.New))
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("DiffFight = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUpsertRecordsChanges(t *testing.T) {
+	tests := []struct {
+		name   string
+		update func(fight *models.Fight)
+		want   []string
+	}{
+		{"schedule to result", func(fight *models.Fight) {
+			fight.Result, fight.Status = "KO 3", models.StatusCompleted
+		}, []string{"result", "status"}},
+		{"location correction", func(fight *models.Fight) {
+			fight.Location = "Wembley Stadium London"
+		}, []string{"location"}},
+		{"no-op", func(*models.Fight) {}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			forEachBackend(t, func(t *testing.T, open func() (FightRepository, error)) {
+				ctx := context.Background()
+				repo := mustOpen(t, open)
+
+				fight := testFight("2024-06-08", "Joshua", "Ngannou", "")
+				fight.Location = "Wembley Stadium, London"
+				if _, err := repo.UpsertFights(ctx, []models.Fight{fight}); err != nil {
+					t.Fatalf("first UpsertFights: %v", err)
+				}
+				tt.update(&fight)
+				result, err := repo.UpsertFights(ctx, []models.Fight{fight})
+				if err != nil {
+					t.Fatalf("second UpsertFights: %v", err)
+				}
+				if result.Inserted != 0 || result.Updated != 1 || result.Changes != len(tt.want) {
+					t.Errorf("second upsert = %+v, want 1 updated with %d changes", result, len(tt.want))
+				}
+
+				changes, err := repo.Changes(ctx, fight.NaturalKey())
+				if err != nil {
+					t.Fatalf("Changes: %v", err)
+				}
+				var fields []string
+				for _, change := range changes {
+					fields = append(fields, change.Field)
+				}
+				if !reflect.DeepEqual(fields, tt.want) {
+					t.Errorf("changed fields = %q, want %q", fields, tt.want)
+				}
+			})
+		})
+	}
+}
@@ -0,0 +1,349 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"testing"
+
+	"easypars/models"
+	"easypars/pkg/config"
+)
+
+// backend opens a repository for the conformance suite
+// open may be called again after Close to reopen the same data, as after a
+// restart of the service.
+type backend struct {
+	name string
+	open func(t *testing.T) func() (FightRepository, error)
+}
+
+// backends lists the storage backends the suite runs against
+// Every backend is opened through Open with its storage.type, like the
+// service does. PostgreSQL runs only when EASYPARS_TEST_POSTGRES_HOST is
+// set, the other settings coming from EASYPARS_TEST_POSTGRES_PORT, _USER,
+// _PASSWORD and _DBNAME; the fight tables of that database are emptied.
+func backends() []backend {
+	return []backend{
+		{name: "sqlite", open: func(t *testing.T) func() (FightRepository, error) {
+			cfg := config.StorageConfig{Type: "sqlite", Path: filepath.Join(t.TempDir(), "easypars.db"), BusyTimeoutMs: 5000}
+			return func() (FightRepository, error) { return Open(cfg, config.DatabaseConfig{}) }
+		}},
+		{name: "file", open: func(t *testing.T) func() (FightRepository, error) {
+			cfg := config.StorageConfig{Type: "file", Path: filepath.Join(t.TempDir(), "fights.json"), Checksum: true}
+			return func() (FightRepository, error) { return Open(cfg, config.DatabaseConfig{}) }
+		}},
+		{name: "postgres", open: func(t *testing.T) func() (FightRepository, error) {
+			host := os.Getenv("EASYPARS_TEST_POSTGRES_HOST")
+			if host == "" {
+				t.Skip("EASYPARS_TEST_POSTGRES_HOST is not set")
+			}
+			port, _ := strconv.Atoi(os.Getenv("EASYPARS_TEST_POSTGRES_PORT"))
+			if port == 0 {
+				port = 5432
+			}
+			cfg := config.DatabaseConfig{
+				Host:     host,
+				Port:     port,
+				User:     os.Getenv("EASYPARS_TEST_POSTGRES_USER"),
+				Password: os.Getenv("EASYPARS_TEST_POSTGRES_PASSWORD"),
+				DBName:   os.Getenv("EASYPARS_TEST_POSTGRES_DBNAME"),
+				SSLMode:  "disable",
+			}
+
+			storage := config.StorageConfig{Type: "postgres"}
+			repo, err := Open(storage, cfg)
+			if err != nil {
+				t.Fatalf("opening postgres: %v", err)
+			}
+			db := repo.(*gormRepository).db
+			if err := db.Exec("DELETE FROM fight_changes").Error; err != nil {
+				t.Fatalf("cleaning fight_changes: %v", err)
+			}
+			if err := db.Exec("DELETE FROM fights").Error; err != nil {
+				t.Fatalf("cleaning fights: %v", err)
+			}
+			repo.Close()
+
+			return func() (FightRepository, error) { return Open(storage, cfg) }
+		}},
+	}
+}
+
+// forEachBackend runs the test against every available backend
+func forEachBackend(t *testing.T, test func(t *testing.T, open func() (FightRepository, error))) {
+	for _, b := range backends() {
+		t.Run(b.name, func(t *testing.T) {
+			test(t, b.open(t))
+		})
+	}
+}
+
+// mustOpen opens a repository and closes it at the end of the test
+func mustOpen(t *testing.T, open func() (FightRepository, error)) FightRepository {
+	t.Helper()
+
+	repo, err := open()
+	if err != nil {
+		t.Fatalf("opening the repository: %v", err)
+	}
+	t.Cleanup(func() { repo.Close() })
+
+	return repo
+}
+
+func testFight(date, fighter1, fighter2, result string) models.Fight {
+	status := models.StatusCompleted
+	if result == "" {
+		status = models.StatusScheduled
+	}
+
+	return models.Fight{
+		Date:     date,
+		Fighter1: fighter1,
+		Fighter2: fighter2,
+		Result:   result,
+		Location: "London",
+		Status:   status,
+	}
+}
+
+func TestRepositoryCRUD(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, open func() (FightRepository, error)) {
+		ctx := context.Background()
+		repo := mustOpen(t, open)
+
+		// Insert
+		fights := []models.Fight{
+			testFight("2024-05-18", "Usyk", "Fury", ""),
+			testFight("2024-06-01", "Canelo", "Munguia", "UD"),
+			testFight("2024-04-20", "Haney", "Garcia", "MD"),
+		}
+		result, err := repo.UpsertFights(ctx, fights)
+		if err != nil {
+			t.Fatalf("UpsertFights: %v", err)
+		}
+		if result != (UpsertResult{Inserted: 3}) {
+			t.Errorf("first upsert = %+v, want 3 inserted", result)
+		}
+
+		// Read
+		key := fights[0].NaturalKey()
+		fight, err := repo.GetByKey(ctx, key)
+		if err != nil {
+			t.Fatalf("GetByKey: %v", err)
+		}
+		if fight.Fighter1 != "Usyk" || fight.Status != models.StatusScheduled || fight.ParsedAt == nil {
+			t.Errorf("GetByKey = %+v, want the stored scheduled fight with parsed_at", fight)
+		}
+		if fight.ID != models.FightID(key) {
+			t.Errorf("GetByKey ID = %q, want %q derived from the key", fight.ID, models.FightID(key))
+		}
+		if _, err := repo.GetByKey(ctx, "2000-01-01|a|b|"); !errors.Is(err, ErrNotFound) {
+			t.Errorf("GetByKey of an unknown key: err = %v, want ErrNotFound", err)
+		}
+		for _, tt := range []struct {
+			id   string
+			want string
+		}{
+			{models.FightID(key), key},
+			{models.FightID(fights[1].NaturalKey()), fights[1].NaturalKey()},
+			{models.FightID("2000-01-01|a|b|"), ""},
+			{key, ""},
+			{"", ""},
+		} {
+			fight, err := repo.GetByID(ctx, tt.id)
+			if tt.want == "" {
+				if !errors.Is(err, ErrNotFound) {
+					t.Errorf("GetByID(%q): err = %v, want ErrNotFound", tt.id, err)
+				}
+				continue
+			}
+			if err != nil || fight.Key != tt.want || fight.ID != tt.id {
+				t.Errorf("GetByID(%q) = %+v (%v), want the fight %s", tt.id, fight, err, tt.want)
+			}
+		}
+
+		list, err := repo.List(ctx, FightFilter{})
+		if err != nil {
+			t.Fatalf("List: %v", err)
+		}
+		if got := dates(list); fmt.Sprint(got) != "[2024-06-01 2024-05-18 2024-04-20]" {
+			t.Errorf("List order = %v, want newest first", got)
+		}
+		for _, stored := range list {
+			if stored.ID == "" || stored.ID != models.FightID(stored.Key) {
+				t.Errorf("listed fight %s has the ID %q, want %q", stored.Key, stored.ID, models.FightID(stored.Key))
+			}
+		}
+		list, err = repo.List(ctx, FightFilter{From: "2024-05-01", To: "2024-05-31"})
+		if err != nil || len(list) != 1 || list[0].Key != key {
+			t.Errorf("List by date = %v (%v), want the Usyk fight", dates(list), err)
+		}
+		list, err = repo.List(ctx, FightFilter{Search: "GARC"})
+		if err != nil || len(list) != 1 || list[0].Fighter2 != "Garcia" {
+			t.Errorf("List by search = %v (%v), want the Garcia fight", dates(list), err)
+		}
+		list, err = repo.List(ctx, FightFilter{Limit: 1, Offset: 1})
+		if err != nil || fmt.Sprint(dates(list)) != "[2024-05-18]" {
+			t.Errorf("List page = %v (%v), want the second fight", dates(list), err)
+		}
+
+		// Update: the same fight with swapped and respelled names gets its result
+		updated := testFight("2024-05-18", "fury ", "USYK", "SD")
+		result, err = repo.UpsertFights(ctx, []models.Fight{updated})
+		if err != nil {
+			t.Fatalf("UpsertFights: %v", err)
+		}
+		if result.Inserted != 0 || result.Updated != 1 || result.Changes == 0 {
+			t.Errorf("second upsert = %+v, want 1 updated with changes", result)
+		}
+		fight, err = repo.GetByKey(ctx, key)
+		if err != nil || fight.Result != "SD" || fight.Status != models.StatusCompleted {
+			t.Errorf("updated fight = %+v (%v), want the SD result", fight, err)
+		}
+		changes, err := repo.Changes(ctx, key)
+		if err != nil {
+			t.Fatalf("Changes: %v", err)
+		}
+		if !hasChange(changes, "result", "", "SD") || !hasChange(changes, "status", models.StatusScheduled, models.StatusCompleted) {
+			t.Errorf("Changes = %+v, want the result and status changes", changes)
+		}
+		if list, _ := repo.List(ctx, FightFilter{}); len(list) != 3 {
+			t.Errorf("List after update has %d fights, want 3", len(list))
+		}
+
+		// Storing the same values again records nothing
+		result, err = repo.UpsertFights(ctx, []models.Fight{updated})
+		if err != nil || result != (UpsertResult{Updated: 1}) {
+			t.Errorf("unchanged upsert = %+v (%v), want 1 updated without changes", result, err)
+		}
+
+		// Delete
+		if err := repo.Delete(ctx, key); err != nil {
+			t.Fatalf("Delete: %v", err)
+		}
+		if _, err := repo.GetByKey(ctx, key); !errors.Is(err, ErrNotFound) {
+			t.Errorf("GetByKey after Delete: err = %v, want ErrNotFound", err)
+		}
+		if changes, err := repo.Changes(ctx, key); err != nil || len(changes) != 0 {
+			t.Errorf("Changes after Delete = %+v (%v), want none", changes, err)
+		}
+		if err := repo.Delete(ctx, key); !errors.Is(err, ErrNotFound) {
+			t.Errorf("second Delete: err = %v, want ErrNotFound", err)
+		}
+	})
+}
+
+func TestRepositoryConcurrentReadWrite(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, open func() (FightRepository, error)) {
+		ctx := context.Background()
+		repo := mustOpen(t, open)
+
+		const writers, batches, readers = 4, 10, 4
+		var wg sync.WaitGroup
+		errs := make(chan error, writers*batches+readers*batches)
+		for w := 0; w < writers; w++ {
+			wg.Add(1)
+			go func(w int) {
+				defer wg.Done()
+				for b := 0; b < batches; b++ {
+					fight := testFight(fmt.Sprintf("2024-%02d-%02d", w+1, b+1), fmt.Sprintf("Boxer %d", w), fmt.Sprintf("Rival %d", b), "")
+					if _, err := repo.UpsertFights(ctx, []models.Fight{fight}); err != nil {
+						errs <- fmt.Errorf("writer %d: %w", w, err)
+					}
+				}
+			}(w)
+		}
+		for r := 0; r < readers; r++ {
+			wg.Add(1)
+			go func(r int) {
+				defer wg.Done()
+				for b := 0; b < batches; b++ {
+					if _, err := repo.List(ctx, FightFilter{Search: "boxer"}); err != nil {
+						errs <- fmt.Errorf("reader %d: %w", r, err)
+					}
+				}
+			}(r)
+		}
+		wg.Wait()
+		close(errs)
+		for err := range errs {
+			t.Error(err)
+		}
+
+		list, err := repo.List(ctx, FightFilter{})
+		if err != nil {
+			t.Fatalf("List: %v", err)
+		}
+		if len(list) != writers*batches {
+			t.Errorf("stored %d fights, want %d", len(list), writers*batches)
+		}
+	})
+}
+
+func TestRepositoryReopen(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, open func() (FightRepository, error)) {
+		ctx := context.Background()
+
+		repo, err := open()
+		if err != nil {
+			t.Fatalf("opening the repository: %v", err)
+		}
+		fight := testFight("2024-05-18", "Usyk", "Fury", "")
+		if _, err := repo.UpsertFights(ctx, []models.Fight{fight}); err != nil {
+			t.Fatalf("UpsertFights: %v", err)
+		}
+		fight.Result = "SD"
+		fight.Status = models.StatusCompleted
+		if _, err := repo.UpsertFights(ctx, []models.Fight{fight}); err != nil {
+			t.Fatalf("UpsertFights: %v", err)
+		}
+		if err := repo.Close(); err != nil {
+			t.Fatalf("Close: %v", err)
+		}
+
+		// The data written before the restart is there after it
+		repo = mustOpen(t, open)
+		stored, err := repo.GetByKey(ctx, fight.NaturalKey())
+		if err != nil {
+			t.Fatalf("GetByKey after reopen: %v", err)
+		}
+		if stored.Result != "SD" {
+			t.Errorf("reopened fight result = %q, want SD", stored.Result)
+		}
+		changes, err := repo.Changes(ctx, fight.NaturalKey())
+		if err != nil || !hasChange(changes, "result", "", "SD") {
+			t.Errorf("reopened changes = %+v (%v), want the result change", changes, err)
+		}
+
+		// Upserts keep matching the fights stored before the restart
+		result, err := repo.UpsertFights(ctx, []models.Fight{fight})
+		if err != nil || result.Inserted != 0 || result.Updated != 1 {
+			t.Errorf("upsert after reopen = %+v (%v), want 1 updated", result, err)
+		}
+	})
+}
+
+func dates(fights []models.Fight) []string {
+	out := make([]string, 0, len(fights))
+	for _, fight := range fights {
+		out = append(out, fight.Date)
+	}
+
+	return out
+}
+
+func hasChange(changes []models.FightChange, field, before, after string) bool {
+	for _, change := range changes {
+		if change.Field == field && change.Old == before && change.New == after {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,478 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"easypars/models"
+)
+
+// fileFormatVersion is the version of the file backend layout
+const fileFormatVersion = 1
+
+// ErrUnrecoverable is returned when a damaged storage file cannot be recovered
+var ErrUnrecoverable = errors.New("storage file is damaged and cannot be recovered")
+
+// fileEnvelope is the layout of the storage file
+// Count and Checksum are written before Data, so even a truncated file tells
+// how many fights it held and whether it was protected by a checksum.
+type fileEnvelope struct {
+	Version int `json:"version"`
+	Count   int `json:"count"`
+	// Checksum is the SHA-256 of the Data array, empty when disabled
+	Checksum string          `json:"checksum,omitempty"`
+	Data     json.RawMessage `json:"data"`
+	// Changes is the change history of the fights, written after the fights
+	// so a truncated file loses the history before any fight
+	Changes []models.FightChange `json:"changes,omitempty"`
+}
+
+// storedFight decodes a fight of the data array
+// Files written before fight IDs were derived from the natural key hold a
+// numeric id; it is skipped and the ID is derived again when stored.
+type storedFight struct {
+	models.Fight
+	LegacyID json.RawMessage `json:"id,omitempty"`
+}
+
+// Recovery describes a storage file that was loaded partially after damage
+type Recovery struct {
+	Path string `json:"path"`
+	// LoadedRecords fights were read; LostRecords is -1 when the file was
+	// damaged before its fight count
+	LoadedRecords int `json:"loaded_records"`
+	LostRecords   int `json:"lost_records"`
+	// LostBytes is the size of the unreadable tail of the file
+	LostBytes int64     `json:"lost_bytes"`
+	Error     string    `json:"error"`
+	LoadedAt  time.Time `json:"loaded_at"`
+}
+
+// LoadResult is the outcome of FileStore.Load
+type LoadResult struct {
+	Fights []models.Fight
+	// Changes is the change history, not recovered from a damaged file
+	Changes []models.FightChange
+	// Recovered is set when only a readable prefix of a damaged file was loaded
+	Recovered bool
+	// LostRecords is -1 when the number of lost fights is unknown
+	LostRecords int
+	LostBytes   int64
+}
+
+// FileStore keeps fights in memory and persists them to a single JSON file
+// Every write replaces the file atomically. A damaged file, e.g. truncated
+// after a disk failure, is loaded up to the first unreadable fight instead of
+// failing the start; see Load.
+type FileStore struct {
+	path string
+	// checksum protects the written files with a checksum
+	checksum bool
+	// allowRecovery allows partial loading of checksum protected files
+	allowRecovery bool
+
+	mu       sync.RWMutex
+	fights   map[string]models.Fight
+	changes  map[string][]models.FightChange
+	recovery *Recovery
+}
+
+// OpenFile opens the file backend and loads the stored fights
+// A missing file is an empty store.
+func OpenFile(path string, checksum, allowRecovery bool) (*FileStore, error) {
+	s := &FileStore{
+		path:          path,
+		checksum:      checksum,
+		allowRecovery: allowRecovery,
+		fights:        make(map[string]models.Fight),
+		changes:       make(map[string][]models.FightChange),
+	}
+
+	result, err := s.Load()
+	if err != nil {
+		return nil, err
+	}
+	// Files written before the location was part of the natural key are
+	// keyed again, with their change history
+	rekeyed := make(map[string]string)
+	for _, fight := range result.Fights {
+		if isLegacyKey(fight.Key) {
+			rekeyed[fight.Key] = fight.NaturalKey()
+			fight.Key = rekeyed[fight.Key]
+		}
+		s.put(fight)
+	}
+	for _, change := range result.Changes {
+		if key, ok := rekeyed[change.FightKey]; ok {
+			change.FightKey = key
+		}
+		s.changes[change.FightKey] = append(s.changes[change.FightKey], change)
+	}
+	if result.Recovered {
+		s.recovery = &Recovery{
+			Path:          path,
+			LoadedRecords: len(result.Fights),
+			LostRecords:   result.LostRecords,
+			LostBytes:     result.LostBytes,
+			Error:         "storage file is damaged, loaded the readable part",
+			LoadedAt:      time.Now(),
+		}
+		lost := "an unknown number of"
+		if result.LostRecords >= 0 {
+			lost = fmt.Sprint(result.LostRecords)
+		}
+		slog.Warn("Storage file is damaged, fights recovered", "path", path, "fight_count", len(result.Fights),
+			"lost_fights", lost, "lost_bytes", result.LostBytes)
+	}
+
+	slog.Info("File storage opened", "path", path, "fight_count", len(s.fights))
+	return s, nil
+}
+
+// Load reads the fights of the storage file
+// When the file does not decode as a whole, the fights of the data array are
+// read one by one up to the first unreadable one and the readable prefix is
+// returned with Recovered set. Files protected by a checksum are recovered
+// only when allowed; ErrUnrecoverable is returned when nothing can be read.
+func (s *FileStore) Load() (LoadResult, error) {
+	data, err := os.ReadFile(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return LoadResult{}, nil
+	}
+	if err != nil {
+		return LoadResult{}, fmt.Errorf("error reading storage file: %w", err)
+	}
+
+	// Step 1: Decode the whole file and verify the checksum
+	var envelope fileEnvelope
+	decodeErr := json.Unmarshal(data, &envelope)
+	if decodeErr == nil {
+		var stored []storedFight
+		decodeErr = json.Unmarshal(envelope.Data, &stored)
+		if decodeErr == nil {
+			fights := make([]models.Fight, len(stored))
+			for i := range stored {
+				fights[i] = stored[i].Fight
+			}
+			if envelope.Checksum == "" || envelope.Checksum == dataChecksum(envelope.Data) {
+				return LoadResult{Fights: fights, Changes: envelope.Changes}, nil
+			}
+			if !s.allowRecovery {
+				return LoadResult{}, fmt.Errorf("storage file %s: checksum mismatch", s.path)
+			}
+			// Every fight decodes, only the checksum does not match
+			return LoadResult{Fights: fights, Changes: envelope.Changes, Recovered: true, LostRecords: 0}, nil
+		}
+	}
+
+	// Step 2: Read the readable prefix of a damaged file
+	result, checksummed, err := recoverPrefix(data)
+	if checksummed && !s.allowRecovery {
+		return LoadResult{}, fmt.Errorf("storage file %s is damaged and protected by a checksum, recovery not allowed: %w", s.path, decodeErr)
+	}
+	if err != nil {
+		return LoadResult{}, fmt.Errorf("%w: %s: %v", ErrUnrecoverable, s.path, decodeErr)
+	}
+
+	return result, nil
+}
+
+// recoverPrefix reads the envelope fields and the fights of the data array
+// up to the first decoding error
+// It reports whether the envelope declared a checksum and fails when the
+// file is damaged before the data array.
+func recoverPrefix(data []byte) (LoadResult, bool, error) {
+	result := LoadResult{Recovered: true, LostRecords: -1}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	count, checksummed := -1, false
+
+	if token, err := dec.Token(); err != nil || token != json.Delim('{') {
+		return result, false, errors.New("not a storage file")
+	}
+	for dec.More() {
+		token, err := dec.Token()
+		if err != nil {
+			return result, checksummed, err
+		}
+		switch token {
+		case "count":
+			if err := dec.Decode(&count); err != nil {
+				return result, checksummed, err
+			}
+		case "checksum":
+			var checksum string
+			if err := dec.Decode(&checksum); err != nil {
+				return result, checksummed, err
+			}
+			checksummed = checksum != ""
+		case "data":
+			if token, err := dec.Token(); err != nil || token != json.Delim('[') {
+				return result, checksummed, errors.New("data is not an array")
+			}
+			read := dec.InputOffset()
+			for dec.More() {
+				var fight storedFight
+				if err := dec.Decode(&fight); err != nil {
+					break
+				}
+				result.Fights = append(result.Fights, fight.Fight)
+				read = dec.InputOffset()
+			}
+			result.LostBytes = int64(len(data)) - read
+			if count >= 0 {
+				result.LostRecords = max(0, count-len(result.Fights))
+			}
+			return result, checksummed, nil
+		default:
+			var skipped json.RawMessage
+			if err := dec.Decode(&skipped); err != nil {
+				return result, checksummed, err
+			}
+		}
+	}
+
+	return result, checksummed, errors.New("no data array")
+}
+
+// Recovery returns the description of the recovered storage file, nil when
+// the file was loaded completely
+func (s *FileStore) Recovery() *Recovery {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.recovery == nil {
+		return nil
+	}
+	recovery := *s.recovery
+	return &recovery
+}
+
+// UpsertFights inserts new fights and updates stored ones matched by natural key
+func (s *FileStore) UpsertFights(ctx context.Context, fights []models.Fight) (UpsertResult, error) {
+	var result UpsertResult
+	if len(fights) == 0 {
+		return result, nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// The last occurrence of a key inside the batch wins, like in the SQL backends
+	seen := make(map[string]bool, len(fights))
+	parsedAt := time.Now()
+	for _, fight := range fights {
+		if fight.Key == "" {
+			fight.Key = fight.NaturalKey()
+		}
+		stampParsedAt(&fight, parsedAt)
+		if stored, ok := s.fights[fight.Key]; ok {
+			if !seen[fight.Key] {
+				result.Updated++
+			}
+			changes := DiffFight(stored, fight, parsedAt)
+			s.changes[fight.Key] = append(s.changes[fight.Key], changes...)
+			result.Changes += len(changes)
+		} else {
+			result.Inserted++
+		}
+		seen[fight.Key] = true
+		s.put(fight)
+	}
+
+	if err := s.save(); err != nil {
+		return UpsertResult{}, fmt.Errorf("error upserting fights: %w", err)
+	}
+
+	return result, nil
+}
+
+// List returns stored fights matching the filter in the canonical order
+func (s *FileStore) List(ctx context.Context, filter FightFilter) ([]models.Fight, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	search := strings.ToLower(filter.Search)
+	fights := make([]models.Fight, 0, len(s.fights))
+	for _, fight := range s.fights {
+		if filter.From != "" && fight.Date < filter.From {
+			continue
+		}
+		if filter.To != "" && fight.Date > filter.To {
+			continue
+		}
+		if search != "" && !strings.Contains(strings.ToLower(fight.Fighter1), search) &&
+			!strings.Contains(strings.ToLower(fight.Fighter2), search) {
+			continue
+		}
+		fights = append(fights, fight)
+	}
+	models.SortCanonical(fights)
+
+	if filter.Offset > 0 {
+		fights = fights[min(filter.Offset, len(fights)):]
+	}
+	if filter.Limit > 0 && filter.Limit < len(fights) {
+		fights = fights[:filter.Limit]
+	}
+
+	return fights, nil
+}
+
+// GetByKey returns a single fight by its natural key
+func (s *FileStore) GetByKey(ctx context.Context, key string) (*models.Fight, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	fight, ok := s.fights[key]
+	if !ok {
+		return nil, ErrNotFound
+	}
+
+	return &fight, nil
+}
+
+// GetByID returns a single fight by its public ID
+func (s *FileStore) GetByID(ctx context.Context, id string) (*models.Fight, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, fight := range s.fights {
+		if id != "" && fight.ID == id {
+			return &fight, nil
+		}
+	}
+
+	return nil, ErrNotFound
+}
+
+// Delete removes a fight and its change history by its natural key
+func (s *FileStore) Delete(ctx context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.fights[key]; !ok {
+		return ErrNotFound
+	}
+	delete(s.fights, key)
+	delete(s.changes, key)
+
+	if err := s.save(); err != nil {
+		return fmt.Errorf("error deleting fight %s: %w", key, err)
+	}
+
+	return nil
+}
+
+// Changes returns the recorded changes of a fight, oldest first
+func (s *FileStore) Changes(ctx context.Context, key string) ([]models.FightChange, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return slices.Clone(s.changes[key]), nil
+}
+
+// Close releases nothing, every write is already on disk
+func (s *FileStore) Close() error {
+	return nil
+}
+
+// put stores a fight with the ID derived from its key
+// Only the stored fields are kept, like in the SQL backends.
+// The caller must hold the lock
+func (s *FileStore) put(fight models.Fight) {
+	fight.ID = models.FightID(fight.Key)
+	fight.Rematch = false
+	fight.MeetingNumber = 0
+	fight.PreviousMeetings = nil
+	fight.LocationID = ""
+	fight.InterestScore = 0
+	fight.Slug = ""
+	fight.RelatedNews = nil
+	s.fights[fight.Key] = fight
+}
+
+// save writes all fights to the file atomically
+// The file is rewritten completely, so a damaged file is replaced by the
+// next write. The caller must hold the lock
+func (s *FileStore) save() error {
+	fights := make([]models.Fight, 0, len(s.fights))
+	for _, fight := range s.fights {
+		fights = append(fights, fight)
+	}
+	sort.Slice(fights, func(i, j int) bool { return fights[i].Key < fights[j].Key })
+
+	data, err := json.Marshal(fights)
+	if err != nil {
+		return fmt.Errorf("error encoding fights: %w", err)
+	}
+	envelope := fileEnvelope{Version: fileFormatVersion, Count: len(fights), Data: data}
+	for _, fight := range fights {
+		envelope.Changes = append(envelope.Changes, s.changes[fight.Key]...)
+	}
+	if s.checksum {
+		envelope.Checksum = dataChecksum(data)
+	}
+	encoded, err := json.Marshal(envelope)
+	if err != nil {
+		return fmt.Errorf("error encoding storage file: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), ".storage-*.tmp")
+	if err != nil {
+		return fmt.Errorf("error saving storage file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(encoded); err != nil {
+		tmp.Close()
+		return fmt.Errorf("error saving storage file: %w", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("error saving storage file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("error saving storage file: %w", err)
+	}
+
+	if err := os.Rename(tmp.Name(), s.path); err != nil {
+		return fmt.Errorf("error saving storage file: %w", err)
+	}
+
+	return nil
+}
+
+// dataChecksum returns the hex SHA-256 of the compacted data array
+func dataChecksum(data json.RawMessage) string {
+	var compact bytes.Buffer
+	if err := json.Compact(&compact, data); err != nil {
+		compact.Reset()
+		compact.Write(data)
+	}
+	sum := sha256.Sum256(compact.Bytes())
+
+	return hex.EncodeToString(sum[:])
+}
+
+// RecoveryOf returns the recovery description of a repository loaded from a
+// damaged file, nil for other repositories and complete loads
+func RecoveryOf(repo FightRepository) *Recovery {
+	if store, ok := repo.(*FileStore); ok {
+		return store.Recovery()
+	}
+
+	return nil
+}
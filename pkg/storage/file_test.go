@@ -0,0 +1,253 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"easypars/models"
+)
+
+// legacyFile is a storage file written when fights had numeric IDs
+const legacyFile = `{"version":1,"count":2,"data":[
+{"id":7,"date":"2024-05-18","fighter1":"Usyk","fighter2":"Fury","result":"SD","location":"Riyadh","key":"2024-05-18|fury|usyk","status":"completed","confidence":1,"rematch":false},
+{"id":8,"date":"2024-06-01","fighter1":"Bivol","fighter2":"Beterbiev","result":"","location":"Riyadh","key":"2024-06-01|beterbiev|bivol","status":"scheduled","confidence":1,"rematch":false}
+]}`
+
+func TestFileStoreLoadsNumericIDs(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "fights.json")
+	if err := os.WriteFile(path, []byte(legacyFile), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	store, err := OpenFile(path, false, false)
+	if err != nil {
+		t.Fatalf("OpenFile: %v", err)
+	}
+	if store.Recovery() != nil {
+		t.Errorf("legacy file loaded as damaged: %+v", store.Recovery())
+	}
+
+	fights, err := store.List(context.Background(), FightFilter{})
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if len(fights) != 2 {
+		t.Fatalf("loaded %d fights, want 2", len(fights))
+	}
+	for _, fight := range fights {
+		if fight.ID != models.FightID(fight.Key) {
+			t.Errorf("fight %s has the ID %q, want %q", fight.Key, fight.ID, models.FightID(fight.Key))
+		}
+	}
+}
+
+func TestFileStoreKeysLegacyFightsWithTheLocation(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "fights.json")
+	if err := os.WriteFile(path, []byte(legacyFile), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	store, err := OpenFile(path, false, false)
+	if err != nil {
+		t.Fatalf("OpenFile: %v", err)
+	}
+	ctx := context.Background()
+	if _, err := store.GetByKey(ctx, "2024-05-18|fury|usyk"); err == nil {
+		t.Error("the fight is still stored under its key without the location")
+	}
+	fight, err := store.GetByKey(ctx, "2024-05-18|fury|usyk|riyadh")
+	if err != nil {
+		t.Fatalf("GetByKey of the key with the location: %v", err)
+	}
+	if fight.Result != "SD" || fight.ID != models.FightID(fight.Key) {
+		t.Errorf("fight = %+v, want the stored result and the ID of the new key", fight)
+	}
+
+	// The same fight parsed again updates the record instead of adding one
+	parsed := models.Fight{Date: "2024-05-18", Fighter1: "Usyk", Fighter2: "Fury", Result: "SD", Location: "Riyadh", Status: models.StatusCompleted}
+	parsed.AssignKey()
+	result, err := store.UpsertFights(ctx, []models.Fight{parsed})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Inserted != 0 || result.Updated != 1 {
+		t.Errorf("upsert of a stored fight = %+v, want 1 update", result)
+	}
+}
+
+// writeStorageFile stores n fights with a file store and returns the path
+// and the content of the written file
+func writeStorageFile(t *testing.T, n int, checksum bool) (string, []byte) {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "fights.json")
+	store, err := OpenFile(path, checksum, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fights := make([]models.Fight, n)
+	for i := range fights {
+		fights[i] = models.Fight{Date: fmt.Sprintf("2024-05-%02d", i+1), Fighter1: "Oleksandr Usyk", Fighter2: fmt.Sprintf("Opponent %d", i), Location: "Riyadh", Status: models.StatusCompleted}
+		fights[i].AssignKey()
+	}
+	if _, err := store.UpsertFights(context.Background(), fights); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return path, data
+}
+
+func TestFileStoreRecovery(t *testing.T) {
+	const total = 10
+	tests := []struct {
+		name          string
+		checksum      bool
+		allowRecovery bool
+		// damage returns the damaged content of the file
+		damage func(data []byte) []byte
+		// loaded is the number of recovered fights, -1 when loading fails
+		loaded    int
+		recovered bool
+		wantErr   error
+	}{
+		{
+			name:   "intact file",
+			damage: func(data []byte) []byte { return data },
+			loaded: total,
+		},
+		{
+			name:      "truncated at 70%",
+			damage:    func(data []byte) []byte { return data[:len(data)*7/10] },
+			loaded:    6,
+			recovered: true,
+		},
+		{
+			name: "truncated inside a record",
+			damage: func(data []byte) []byte {
+				return data[:bytes.Index(data, []byte(`"fighter2":"Opponent 3"`))]
+			},
+			loaded:    3,
+			recovered: true,
+		},
+		{
+			name:    "completely damaged",
+			damage:  func([]byte) []byte { return []byte("\x00\x00 not json at all") },
+			loaded:  -1,
+			wantErr: ErrUnrecoverable,
+		},
+		{
+			name:    "truncated before the data",
+			damage:  func(data []byte) []byte { return data[:bytes.Index(data, []byte(`"data"`))] },
+			loaded:  -1,
+			wantErr: ErrUnrecoverable,
+		},
+		{
+			name:     "checksum file truncated, recovery not allowed",
+			checksum: true,
+			damage:   func(data []byte) []byte { return data[:len(data)*7/10] },
+			loaded:   -1,
+		},
+		{
+			name:          "checksum file truncated, recovery allowed",
+			checksum:      true,
+			allowRecovery: true,
+			damage:        func(data []byte) []byte { return data[:len(data)*7/10] },
+			loaded:        6,
+			recovered:     true,
+		},
+		{
+			name:     "checksum mismatch, recovery not allowed",
+			checksum: true,
+			damage:   func(data []byte) []byte { return bytes.Replace(data, []byte("Opponent 3"), []byte("Opponent 8"), 1) },
+			loaded:   -1,
+		},
+		{
+			name:          "checksum mismatch, recovery allowed",
+			checksum:      true,
+			allowRecovery: true,
+			damage:        func(data []byte) []byte { return bytes.Replace(data, []byte("Opponent 3"), []byte("Opponent 8"), 1) },
+			loaded:        total,
+			recovered:     true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path, data := writeStorageFile(t, total, tt.checksum)
+			damaged := tt.damage(data)
+			if err := os.WriteFile(path, damaged, 0o644); err != nil {
+				t.Fatal(err)
+			}
+
+			store, err := OpenFile(path, tt.checksum, tt.allowRecovery)
+			if tt.loaded < 0 {
+				if err == nil {
+					t.Fatal("OpenFile of an unloadable file succeeded")
+				}
+				if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+					t.Errorf("OpenFile = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("OpenFile: %v", err)
+			}
+
+			fights, err := store.List(context.Background(), FightFilter{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(fights) != tt.loaded {
+				t.Errorf("loaded %d fights, want %d", len(fights), tt.loaded)
+			}
+			recovery := store.Recovery()
+			if (recovery != nil) != tt.recovered {
+				t.Fatalf("recovery = %+v, want recovered %v", recovery, tt.recovered)
+			}
+			if recovery == nil {
+				return
+			}
+			if recovery.LoadedRecords != tt.loaded || recovery.LostRecords != total-tt.loaded {
+				t.Errorf("recovery = %d loaded and %d lost, want %d and %d", recovery.LoadedRecords, recovery.LostRecords, tt.loaded, total-tt.loaded)
+			}
+			if lostTail := tt.loaded < total; lostTail != (recovery.LostBytes > 0) || recovery.LostBytes >= int64(len(damaged)) {
+				t.Errorf("lost bytes = %d of %d", recovery.LostBytes, len(damaged))
+			}
+		})
+	}
+}
+
+func TestRecoveredFileIsRewrittenByTheNextWrite(t *testing.T) {
+	path, data := writeStorageFile(t, 10, false)
+	if err := os.WriteFile(path, data[:len(data)/2], 0o644); err != nil {
+		t.Fatal(err)
+	}
+	store, err := OpenFile(path, false, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	recovered := store.Recovery().LoadedRecords
+
+	fight := models.Fight{Date: "2024-06-01", Fighter1: "Dmitry Bivol", Fighter2: "Malik Zinad", Location: "Riyadh", Status: models.StatusCompleted}
+	fight.AssignKey()
+	if _, err := store.UpsertFights(context.Background(), []models.Fight{fight}); err != nil {
+		t.Fatal(err)
+	}
+
+	reopened, err := OpenFile(path, false, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fights, _ := reopened.List(context.Background(), FightFilter{})
+	if reopened.Recovery() != nil || len(fights) != recovered+1 {
+		t.Errorf("reopened file = %d fights, recovery %+v; want %d fights in an intact file", len(fights), reopened.Recovery(), recovered+1)
+	}
+}
@@ -0,0 +1,240 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"easypars/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// upsertColumns are the columns refreshed when a known fight is parsed again
+var upsertColumns = []string{
+	"date", "fighter1", "fighter2", "result", "result_type", "round", "location",
+	"status", "confidence", "hidden_in_source", "year_adjusted", "warnings",
+	"fighter1_external_ids", "fighter2_external_ids", "raw", "card_position",
+	"fighter1_record", "fighter2_record", "source_url", "missing_since",
+	"parsed_at",
+}
+
+// gormRepository implements FightRepository on top of GORM
+// The implementation is shared by all SQL backends
+type gormRepository struct {
+	db *gorm.DB
+
+	// writeMu serializes writes for backends with a single writer (sqlite)
+	// It stays nil for backends that handle concurrent writers themselves
+	writeMu *sync.Mutex
+}
+
+// lockWrite acquires the writer lock when the backend needs one
+// The returned function releases it
+func (r *gormRepository) lockWrite() func() {
+	if r.writeMu == nil {
+		return func() {}
+	}
+	r.writeMu.Lock()
+	return r.writeMu.Unlock
+}
+
+// UpsertFights inserts new fights and updates stored ones matched by natural key
+func (r *gormRepository) UpsertFights(ctx context.Context, fights []models.Fight) (UpsertResult, error) {
+	var result UpsertResult
+	if len(fights) == 0 {
+		return result, nil
+	}
+
+	// Fill in natural keys and drop duplicates inside the batch
+	// The last occurrence of a key wins, matching the ON CONFLICT behaviour
+	byKey := make(map[string]int, len(fights))
+	batch := make([]models.Fight, 0, len(fights))
+	parsedAt := time.Now()
+	for _, fight := range fights {
+		fight.RowID = 0
+		if fight.Key == "" {
+			fight.Key = fight.NaturalKey()
+		}
+		stampParsedAt(&fight, parsedAt)
+		if idx, ok := byKey[fight.Key]; ok {
+			batch[idx] = fight
+			continue
+		}
+		byKey[fight.Key] = len(batch)
+		batch = append(batch, fight)
+	}
+
+	keys := make([]string, 0, len(batch))
+	for _, fight := range batch {
+		keys = append(keys, fight.Key)
+	}
+
+	defer r.lockWrite()()
+
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// Load the fights that already exist to report inserted vs updated
+		// rows and to record what changed
+		var existing []models.Fight
+		if err := tx.Where("key IN ?", keys).Find(&existing).Error; err != nil {
+			return err
+		}
+		var changes []models.FightChange
+		for _, stored := range existing {
+			changes = append(changes, DiffFight(stored, batch[byKey[stored.Key]], parsedAt)...)
+		}
+
+		err := tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "key"}},
+			DoUpdates: clause.AssignmentColumns(upsertColumns),
+		}).Create(&batch).Error
+		if err != nil {
+			return err
+		}
+		if len(changes) > 0 {
+			if err := tx.Create(&changes).Error; err != nil {
+				return err
+			}
+		}
+
+		result.Updated = len(existing)
+		result.Inserted = len(batch) - len(existing)
+		result.Changes = len(changes)
+		return nil
+	})
+	if err != nil {
+		return UpsertResult{}, fmt.Errorf("error upserting fights: %w", err)
+	}
+
+	return result, nil
+}
+
+// List returns stored fights matching the filter in the canonical order
+// (newest first, then card position with unknown positions last, then key)
+func (r *gormRepository) List(ctx context.Context, filter FightFilter) ([]models.Fight, error) {
+	query := r.db.WithContext(ctx).Model(&models.Fight{})
+
+	if filter.From != "" {
+		query = query.Where("date >= ?", filter.From)
+	}
+	if filter.To != "" {
+		query = query.Where("date <= ?", filter.To)
+	}
+	if filter.Search != "" {
+		pattern := "%" + strings.ToLower(filter.Search) + "%"
+		query = query.Where("LOWER(fighter1) LIKE ? OR LOWER(fighter2) LIKE ?", pattern, pattern)
+	}
+	if filter.Limit > 0 {
+		query = query.Limit(filter.Limit)
+	}
+	if filter.Offset > 0 {
+		query = query.Offset(filter.Offset)
+	}
+
+	var fights []models.Fight
+	if err := query.Order("date = ''").Order("date DESC").Order("card_position = 0").Order("card_position").Order("key").Find(&fights).Error; err != nil {
+		return nil, fmt.Errorf("error listing fights: %w", err)
+	}
+	for i := range fights {
+		fights[i].ID = models.FightID(fights[i].Key)
+	}
+
+	return fights, nil
+}
+
+// GetByKey returns a single fight by its natural key
+// Find is used instead of First, so an unknown key, e.g. looked up by the
+// fight history, is not logged as a query error by GORM.
+func (r *gormRepository) GetByKey(ctx context.Context, key string) (*models.Fight, error) {
+	var fights []models.Fight
+	err := r.db.WithContext(ctx).Where("key = ?", key).Limit(1).Find(&fights).Error
+	if err != nil {
+		return nil, fmt.Errorf("error loading fight %s: %w", key, err)
+	}
+	if len(fights) == 0 {
+		return nil, ErrNotFound
+	}
+	fights[0].ID = models.FightID(fights[0].Key)
+
+	return &fights[0], nil
+}
+
+// GetByID returns a single fight by its public ID
+// The ID is derived from the key and not stored, so the keys are scanned for
+// it; only the key column is read.
+func (r *gormRepository) GetByID(ctx context.Context, id string) (*models.Fight, error) {
+	if id == "" {
+		return nil, ErrNotFound
+	}
+
+	var keys []string
+	if err := r.db.WithContext(ctx).Model(&models.Fight{}).Pluck("key", &keys).Error; err != nil {
+		return nil, fmt.Errorf("error looking up fight %s: %w", id, err)
+	}
+	for _, key := range keys {
+		if models.FightID(key) == id {
+			return r.GetByKey(ctx, key)
+		}
+	}
+
+	return nil, ErrNotFound
+}
+
+// Delete removes a fight and its change history by its natural key
+func (r *gormRepository) Delete(ctx context.Context, key string) error {
+	defer r.lockWrite()()
+
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		res := tx.Where("key = ?", key).Delete(&models.Fight{})
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return ErrNotFound
+		}
+		return tx.Where("fight_key = ?", key).Delete(&models.FightChange{}).Error
+	})
+	if errors.Is(err, ErrNotFound) {
+		return ErrNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("error deleting fight %s: %w", key, err)
+	}
+
+	return nil
+}
+
+// Changes returns the recorded changes of a fight, oldest first
+func (r *gormRepository) Changes(ctx context.Context, key string) ([]models.FightChange, error) {
+	var changes []models.FightChange
+	err := r.db.WithContext(ctx).Where("fight_key = ?", key).Order("detected_at").Order("id").Find(&changes).Error
+	if err != nil {
+		return nil, fmt.Errorf("error loading changes of fight %s: %w", key, err)
+	}
+
+	return changes, nil
+}
+
+// Ping checks that the database answers, for the readiness probe
+func (r *gormRepository) Ping(ctx context.Context) error {
+	sqlDB, err := r.db.DB()
+	if err != nil {
+		return err
+	}
+
+	return sqlDB.PingContext(ctx)
+}
+
+// Close releases the underlying database connection
+func (r *gormRepository) Close() error {
+	sqlDB, err := r.db.DB()
+	if err != nil {
+		return err
+	}
+
+	return sqlDB.Close()
+}
@@ -0,0 +1,89 @@
+package storage
+
+import (
+	"fmt"
+	"time"
+
+	"easypars/models"
+
+	"gorm.io/gorm"
+)
+
+// schemaVersion is the database schema version this build works with
+// Bump it whenever the stored models change
+const schemaVersion = 12
+
+// locationKeyVersion is the first schema version whose natural keys hold the
+// location; fights stored earlier are keyed again when it is applied
+const locationKeyVersion = 12
+
+// schemaMigration records an applied schema version
+type schemaMigration struct {
+	Version   int `gorm:"primaryKey"`
+	AppliedAt time.Time
+}
+
+// migrate brings the database schema up to schemaVersion
+// It refuses to work with a database created by a newer build
+func migrate(db *gorm.DB) error {
+	if err := db.AutoMigrate(&schemaMigration{}); err != nil {
+		return fmt.Errorf("error creating schema version table: %w", err)
+	}
+
+	var current int
+	if err := db.Model(&schemaMigration{}).Select("COALESCE(MAX(version), 0)").Scan(&current).Error; err != nil {
+		return fmt.Errorf("error reading schema version: %w", err)
+	}
+
+	if current > schemaVersion {
+		return fmt.Errorf("database schema version %d is newer than supported version %d", current, schemaVersion)
+	}
+
+	if err := db.AutoMigrate(&models.Fight{}); err != nil {
+		return fmt.Errorf("error migrating fights table: %w", err)
+	}
+	if err := db.AutoMigrate(&models.FightChange{}); err != nil {
+		return fmt.Errorf("error migrating fight changes table: %w", err)
+	}
+
+	if current > 0 && current < locationKeyVersion {
+		if err := rekeyFights(db); err != nil {
+			return err
+		}
+	}
+
+	if current < schemaVersion {
+		applied := schemaMigration{Version: schemaVersion, AppliedAt: time.Now()}
+		if err := db.Create(&applied).Error; err != nil {
+			return fmt.Errorf("error recording schema version: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// rekeyFights moves fights stored under a key without the location, and their
+// change history, to the current natural key
+// Old keys were unique by date and fighters, so the new keys are unique too.
+func rekeyFights(db *gorm.DB) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		var fights []models.Fight
+		if err := tx.Select("id", "key", "date", "fighter1", "fighter2", "location").Find(&fights).Error; err != nil {
+			return fmt.Errorf("error loading fights to key again: %w", err)
+		}
+		for _, fight := range fights {
+			newKey := fight.NaturalKey()
+			if !isLegacyKey(fight.Key) || newKey == fight.Key {
+				continue
+			}
+			if err := tx.Model(&models.Fight{}).Where("id = ?", fight.RowID).Update("key", newKey).Error; err != nil {
+				return fmt.Errorf("error keying fight %s again: %w", fight.Key, err)
+			}
+			if err := tx.Model(&models.FightChange{}).Where("fight_key = ?", fight.Key).Update("fight_key", newKey).Error; err != nil {
+				return fmt.Errorf("error keying the changes of fight %s again: %w", fight.Key, err)
+			}
+		}
+
+		return nil
+	})
+}
@@ -0,0 +1,62 @@
+package storage
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"easypars/models"
+)
+
+func TestMigrateKeysLegacyFightsWithTheLocation(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "easypars.db")
+	ctx := context.Background()
+
+	// A database of schema version 11 with a fight and its change history
+	// stored under the key without the location
+	repo, err := OpenSQLite(path, 5000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fight := models.Fight{Date: "2024-05-18", Fighter1: "Usyk", Fighter2: "Fury", Result: "vs", Location: "Riyadh", Status: models.StatusScheduled}
+	fight.AssignKey()
+	if _, err := repo.UpsertFights(ctx, []models.Fight{fight}); err != nil {
+		t.Fatal(err)
+	}
+	fight.Result, fight.Status = "SD", models.StatusCompleted
+	if _, err := repo.UpsertFights(ctx, []models.Fight{fight}); err != nil {
+		t.Fatal(err)
+	}
+	const legacyKey = "2024-05-18|fury|usyk"
+	db := repo.(*gormRepository).db
+	for _, stmt := range []string{
+		"UPDATE fights SET key = '" + legacyKey + "'",
+		"UPDATE fight_changes SET fight_key = '" + legacyKey + "'",
+		"UPDATE schema_migrations SET version = 11",
+	} {
+		if err := db.Exec(stmt).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	repo.Close()
+
+	repo, err = OpenSQLite(path, 5000)
+	if err != nil {
+		t.Fatalf("OpenSQLite of a version 11 database: %v", err)
+	}
+	defer repo.Close()
+
+	stored, err := repo.GetByKey(ctx, fight.Key)
+	if err != nil {
+		t.Fatalf("GetByKey of the key with the location: %v", err)
+	}
+	if stored.Result != "SD" || stored.ID != fight.ID {
+		t.Errorf("fight = %+v, want the stored result and the ID %s", stored, fight.ID)
+	}
+	if _, err := repo.GetByKey(ctx, legacyKey); err == nil {
+		t.Error("the fight is still stored under its key without the location")
+	}
+	if changes, err := repo.Changes(ctx, fight.Key); err != nil || len(changes) == 0 {
+		t.Errorf("Changes = %+v (%v), want the history moved to the new key", changes, err)
+	}
+}
@@ -0,0 +1,111 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"easypars/models"
+)
+
+// DefaultMissingGrace is how long a fight that disappeared from its page is
+// kept when no grace period is configured
+const DefaultMissingGrace = 7 * 24 * time.Hour
+
+// MissingReport is the outcome of TrackMissing
+type MissingReport struct {
+	// Marked holds the keys of the fights that disappeared in this run
+	Marked []string `json:"marked,omitempty"`
+	// Deleted holds the keys of the fights deleted after the grace period
+	Deleted []string `json:"deleted,omitempty"`
+	// Missing holds the fights of the page that are missing but kept, the
+	// newly marked ones included
+	Missing []models.Fight `json:"missing,omitempty"`
+}
+
+// TrackMissing checks which stored fights of a page disappeared from it
+// A fight last seen on sourceURL that is not among the seen fights is not
+// deleted right away: it may have moved to the archive of its month. It is
+// marked with MissingSince and kept; a parse of the archive that finds it
+// stores it with the archive URL and clears the mark, and so does the page
+// when the fight comes back. Fights still missing after the grace period
+// are deleted. Seen fights must be stored before the check.
+func TrackMissing(ctx context.Context, repo FightRepository, sourceURL string, seen []models.Fight, now time.Time, grace time.Duration) (MissingReport, error) {
+	var report MissingReport
+	if sourceURL == "" {
+		return report, nil
+	}
+	if grace <= 0 {
+		grace = DefaultMissingGrace
+	}
+
+	present := make(map[string]bool, len(seen))
+	for _, fight := range seen {
+		present[keyOf(fight)] = true
+	}
+
+	stored, err := repo.List(ctx, FightFilter{})
+	if err != nil {
+		return report, fmt.Errorf("error loading stored fights: %w", err)
+	}
+
+	// Step 1: Sort the fights that left the page into new, expired and kept
+	var marked []models.Fight
+	for _, fight := range stored {
+		if fight.SourceURL != sourceURL || present[keyOf(fight)] {
+			continue
+		}
+		switch {
+		case fight.MissingSince == nil:
+			since := now
+			fight.MissingSince = &since
+			marked = append(marked, fight)
+			report.Marked = append(report.Marked, fight.Key)
+			report.Missing = append(report.Missing, fight)
+		case now.Sub(*fight.MissingSince) >= grace:
+			report.Deleted = append(report.Deleted, fight.Key)
+		default:
+			report.Missing = append(report.Missing, fight)
+		}
+	}
+
+	// Step 2: Store the new marks and delete the expired fights
+	if len(marked) > 0 {
+		if _, err := repo.UpsertFights(ctx, marked); err != nil {
+			return report, fmt.Errorf("error marking missing fights: %w", err)
+		}
+	}
+	for _, key := range report.Deleted {
+		if err := repo.Delete(ctx, key); err != nil && !errors.Is(err, ErrNotFound) {
+			return report, fmt.Errorf("error deleting missing fight %s: %w", key, err)
+		}
+	}
+
+	return report, nil
+}
+
+// ListMissing returns the stored fights marked as missing from their page
+func ListMissing(ctx context.Context, repo FightRepository) ([]models.Fight, error) {
+	stored, err := repo.List(ctx, FightFilter{})
+	if err != nil {
+		return nil, fmt.Errorf("error loading stored fights: %w", err)
+	}
+
+	var missing []models.Fight
+	for _, fight := range stored {
+		if fight.MissingSince != nil {
+			missing = append(missing, fight)
+		}
+	}
+
+	return missing, nil
+}
+
+// keyOf returns the natural key of a fight, computing it when unset
+func keyOf(fight models.Fight) string {
+	if fight.Key != "" {
+		return fight.Key
+	}
+	return fight.NaturalKey()
+}
@@ -0,0 +1,136 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"easypars/models"
+)
+
+func TestTrackMissing(t *testing.T) {
+	const (
+		resultsURL = "https://vringe.example/results"
+		archiveURL = "https://vringe.example/results/2024-05"
+	)
+	start := time.Date(2024, time.June, 1, 12, 0, 0, 0, time.UTC)
+	usyk := testFight("2024-05-18", "Oleksandr Usyk", "Tyson Fury", "SD")
+	usyk.AssignKey()
+	bivol := testFight("2024-05-01", "Dmitry Bivol", "Malik Zinad", "UD")
+	bivol.AssignKey()
+
+	// step is a parse of a page on a day after start; want is the state of
+	// the Bivol fight after it: "stored", "missing" or "deleted"
+	type step struct {
+		day   int
+		url   string
+		bivol bool
+		want  string
+	}
+	tests := []struct {
+		name  string
+		steps []step
+		// source is the page of the Bivol fight at the end, unless deleted
+		source string
+	}{
+		{"moved to the archive", []step{
+			{0, resultsURL, true, "stored"},
+			{1, resultsURL, false, "missing"},
+			{3, archiveURL, true, "stored"},
+			{9, resultsURL, false, "stored"},
+		}, archiveURL},
+		{"deleted from the source", []step{
+			{0, resultsURL, true, "stored"},
+			{1, resultsURL, false, "missing"},
+			{5, resultsURL, false, "missing"},
+			{8, resultsURL, false, "deleted"},
+		}, ""},
+		{"back on the results page", []step{
+			{0, resultsURL, true, "stored"},
+			{1, resultsURL, false, "missing"},
+			{3, resultsURL, true, "stored"},
+			{9, resultsURL, true, "stored"},
+		}, resultsURL},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			forEachBackend(t, func(t *testing.T, open func() (FightRepository, error)) {
+				repo := mustOpen(t, open)
+				ctx := context.Background()
+
+				for _, s := range tt.steps {
+					now := start.AddDate(0, 0, s.day)
+					var seen []models.Fight
+					if s.url == resultsURL {
+						seen = append(seen, usyk)
+					}
+					if s.bivol {
+						seen = append(seen, bivol)
+					}
+					for i := range seen {
+						seen[i].SourceURL = s.url
+					}
+					if _, err := repo.UpsertFights(ctx, seen); err != nil {
+						t.Fatal(err)
+					}
+
+					report, err := TrackMissing(ctx, repo, s.url, seen, now, 7*24*time.Hour)
+					if err != nil {
+						t.Fatalf("day %d: TrackMissing: %v", s.day, err)
+					}
+					stored, err := repo.GetByKey(ctx, bivol.Key)
+					state := "stored"
+					switch {
+					case errors.Is(err, ErrNotFound):
+						state = "deleted"
+					case err != nil:
+						t.Fatal(err)
+					case stored.MissingSince != nil:
+						state = "missing"
+					}
+					if state != s.want {
+						t.Fatalf("day %d: the fight is %s (report %+v), want %s", s.day, state, report, s.want)
+					}
+					if state == "missing" && len(report.Missing) != 1 {
+						t.Errorf("day %d: %d fights kept as missing, want the missing fight", s.day, len(report.Missing))
+					}
+				}
+
+				stored, err := repo.GetByKey(ctx, bivol.Key)
+				if tt.source != "" && (err != nil || stored.SourceURL != tt.source) {
+					t.Errorf("source of the fight = %q (%v), want %q", stored.SourceURL, err, tt.source)
+				}
+				if _, err := repo.GetByKey(ctx, usyk.Key); err != nil {
+					t.Errorf("the fight still on the results page: %v", err)
+				}
+			})
+		})
+	}
+}
+
+func TestListMissing(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, open func() (FightRepository, error)) {
+		repo := mustOpen(t, open)
+		ctx := context.Background()
+		fights := []models.Fight{
+			testFight("2024-05-18", "Oleksandr Usyk", "Tyson Fury", "SD"),
+			testFight("2024-05-01", "Dmitry Bivol", "Malik Zinad", "UD"),
+		}
+		for i := range fights {
+			fights[i].AssignKey()
+			fights[i].SourceURL = "https://vringe.example/results"
+		}
+		if _, err := repo.UpsertFights(ctx, fights); err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := TrackMissing(ctx, repo, "https://vringe.example/results", fights[:1], time.Now(), 0); err != nil {
+			t.Fatal(err)
+		}
+		missing, err := ListMissing(ctx, repo)
+		if err != nil || len(missing) != 1 || missing[0].Key != fights[1].Key {
+			t.Errorf("ListMissing = %+v (%v), want %s", missing, err, fights[1].Key)
+		}
+	})
+}
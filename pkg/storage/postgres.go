@@ -0,0 +1,25 @@
+package storage
+
+import (
+	"easypars/pkg/config"
+	"easypars/pkg/db"
+)
+
+// OpenPostgres connects to the configured PostgreSQL database and migrates
+// the schema
+// PostgreSQL handles concurrent writers itself, so writes are not serialized.
+func OpenPostgres(cfg config.DatabaseConfig) (FightRepository, error) {
+	gormDB, err := db.OpenPostgres(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := migrate(gormDB); err != nil {
+		if sqlDB, dbErr := gormDB.DB(); dbErr == nil {
+			sqlDB.Close()
+		}
+		return nil, err
+	}
+
+	return &gormRepository{db: gormDB}, nil
+}
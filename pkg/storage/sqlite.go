@@ -0,0 +1,40 @@
+package storage
+
+import (
+	"fmt"
+	"log/slog"
+	"sync"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// OpenSQLite opens (or creates) a sqlite database file and migrates the schema
+// The pure Go driver is used so the binary builds without cgo
+func OpenSQLite(path string, busyTimeoutMs int) (FightRepository, error) {
+	// WAL lets readers work while a write is in progress,
+	// busy_timeout makes sqlite wait for a lock instead of failing with SQLITE_BUSY
+	dsn := fmt.Sprintf("%s?_pragma=journal_mode(WAL)&_pragma=busy_timeout(%d)&_pragma=foreign_keys(1)",
+		path, busyTimeoutMs)
+
+	db, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Warn),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error opening sqlite database %s: %w", path, err)
+	}
+
+	if err := migrate(db); err != nil {
+		if sqlDB, dbErr := db.DB(); dbErr == nil {
+			sqlDB.Close()
+		}
+		return nil, err
+	}
+
+	slog.Info("SQLite storage opened", "path", path)
+
+	// sqlite allows a single writer at a time, so writes go through a mutex
+	// to keep the scheduler and API requests from hitting SQLITE_BUSY
+	return &gormRepository{db: db, writeMu: &sync.Mutex{}}, nil
+}
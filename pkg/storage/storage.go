@@ -0,0 +1,96 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"easypars/models"
+	"easypars/pkg/config"
+)
+
+// ErrNotFound is returned when a requested fight does not exist in storage
+var ErrNotFound = errors.New("fight not found")
+
+// FightRepository describes persistent storage for parsed fights
+// Every backend must provide the same upsert semantics: fights are matched
+// by their natural key, new keys are inserted and known keys are updated in
+// place, so a fight parsed again (a scheduled fight getting its result)
+// never makes a second row
+type FightRepository interface {
+	// UpsertFights inserts new fights and updates already stored ones
+	UpsertFights(ctx context.Context, fights []models.Fight) (UpsertResult, error)
+	// List returns stored fights matching the filter
+	List(ctx context.Context, filter FightFilter) ([]models.Fight, error)
+	// GetByKey returns a single fight by its natural key
+	GetByKey(ctx context.Context, key string) (*models.Fight, error)
+	// GetByID returns a single fight by its public ID (see models.FightID)
+	GetByID(ctx context.Context, id string) (*models.Fight, error)
+	// Delete removes a fight and its change history by its natural key
+	Delete(ctx context.Context, key string) error
+	// Changes returns the changes recorded when the fight was stored again
+	// with different values, oldest first
+	Changes(ctx context.Context, key string) ([]models.FightChange, error)
+	// Close releases the underlying connection
+	Close() error
+}
+
+// UpsertResult reports how many fights were inserted and updated
+// Changes counts the field changes recorded for the updated fights.
+type UpsertResult struct {
+	Inserted int `json:"inserted"`
+	Updated  int `json:"updated"`
+	Changes  int `json:"changes"`
+}
+
+// FightFilter holds the list query options
+// Empty fields are not applied
+type FightFilter struct {
+	// From and To limit the fight date (YYYY-MM-DD, inclusive)
+	From string
+	To   string
+	// Search matches a substring of either fighter name
+	Search string
+	// Limit and Offset paginate the result, Limit 0 means no limit
+	Limit  int
+	Offset int
+}
+
+// isLegacyKey reports a natural key stored before the location was part of
+// it: the date and the two fighters only
+func isLegacyKey(key string) bool {
+	return strings.Count(key, "|") == 2
+}
+
+// stampParsedAt sets ParsedAt of a fight coming from a parse
+// Fights loaded from storage and stored again, e.g. to mark them missing,
+// already carry one and keep it.
+func stampParsedAt(fight *models.Fight, now time.Time) {
+	if fight.ParsedAt == nil {
+		fight.ParsedAt = &now
+	}
+}
+
+// Open creates the repository selected by the storage configuration
+// The postgres backend connects with the database configuration.
+// Returns nil without an error when storage is disabled
+func Open(cfg config.StorageConfig, database config.DatabaseConfig) (FightRepository, error) {
+	switch cfg.Type {
+	case "", "none":
+		return nil, nil
+	case "sqlite":
+		return OpenSQLite(cfg.Path, cfg.BusyTimeoutMs)
+	case "postgres":
+		return OpenPostgres(database)
+	case "file":
+		store, err := OpenFile(cfg.Path, cfg.Checksum, cfg.AllowRecovery)
+		if err != nil {
+			return nil, err
+		}
+		return store, nil
+	default:
+		return nil, fmt.Errorf("unsupported storage type: %s", cfg.Type)
+	}
+}
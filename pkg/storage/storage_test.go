@@ -0,0 +1,76 @@
+package storage
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"easypars/models"
+	"easypars/pkg/config"
+)
+
+// chdir changes the working directory for the rest of the test
+func chdir(t *testing.T, dir string) {
+	t.Helper()
+
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(wd) })
+}
+
+func TestOpenDefaultConfig(t *testing.T) {
+	// Without a config file the service persists to ./easypars.db
+	chdir(t, t.TempDir())
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		t.Fatalf("LoadConfig: %v", err)
+	}
+	if cfg.Storage.Type != "sqlite" || cfg.Storage.Path != "./easypars.db" {
+		t.Fatalf("default storage = %s at %s, want sqlite at ./easypars.db", cfg.Storage.Type, cfg.Storage.Path)
+	}
+
+	// The database is created on the first run
+	ctx := context.Background()
+	repo, err := Open(cfg.Storage, cfg.Database)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	fight := testFight("2024-05-18", "Usyk", "Fury", "SD")
+	if _, err := repo.UpsertFights(ctx, []models.Fight{fight}); err != nil {
+		t.Fatalf("UpsertFights: %v", err)
+	}
+	if err := repo.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	if _, err := os.Stat("easypars.db"); err != nil {
+		t.Fatalf("database file not created: %v", err)
+	}
+
+	// and keeps the fights for the next one
+	repo, err = Open(cfg.Storage, cfg.Database)
+	if err != nil {
+		t.Fatalf("reopening: %v", err)
+	}
+	defer repo.Close()
+	if stored, err := repo.GetByKey(ctx, fight.NaturalKey()); err != nil || stored.Result != "SD" {
+		t.Errorf("GetByKey after reopen = %+v (%v), want the stored fight", stored, err)
+	}
+}
+
+func TestOpenStorageTypes(t *testing.T) {
+	for _, typ := range []string{"", "none"} {
+		repo, err := Open(config.StorageConfig{Type: typ}, config.DatabaseConfig{})
+		if repo != nil || err != nil {
+			t.Errorf("Open(%q) = %v, %v, want no repository", typ, repo, err)
+		}
+	}
+
+	if _, err := Open(config.StorageConfig{Type: "mysql"}, config.DatabaseConfig{}); err == nil {
+		t.Error("Open of an unknown type succeeded, want an error")
+	}
+}
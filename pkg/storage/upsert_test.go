@@ -0,0 +1,99 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"easypars/models"
+	"easypars/pkg/clock"
+	"easypars/pkg/parser"
+)
+
+// resultsPage is a results page of June 2024 with the Joshua fight carrying
+// the result and the Bivol fight already decided
+func resultsPage(result string) string {
+	return fmt.Sprintf(`<html><body><div class="month">Июнь 2024</div><table>`+
+		`<tr><td class="date">1</td><td class="place">Riyadh</td><td class="boxer_1">Dmitry Bivol</td><td class="vs">UD</td><td class="boxer_2">Malik Zinad</td></tr>`+
+		`<tr><td class="date">8</td><td class="place">London</td><td class="boxer_1">Anthony Joshua</td><td class="vs">%s</td><td class="boxer_2">Francis Ngannou</td></tr>`+
+		`</table></body></html>`, result)
+}
+
+// parsePage parses the results page like the refresher does before storing
+func parsePage(t *testing.T, page string) []models.Fight {
+	t.Helper()
+
+	p := parser.NewParser("")
+	p.Clock = clock.Fixed{Time: time.Date(2024, time.June, 10, 12, 0, 0, 0, time.UTC)}
+	fights, err := p.ParseFromReader(strings.NewReader(page))
+	if err != nil {
+		t.Fatalf("ParseFromReader: %v", err)
+	}
+
+	return fights
+}
+
+func TestUpsertReparsedFights(t *testing.T) {
+	tests := []struct {
+		name    string
+		before  string
+		after   string
+		changes bool
+	}{
+		{"TBD gets a result", "TBD", "KO 3", true},
+		{"vs gets a result", "vs", "UD", true},
+		{"result corrected", "KO 3", "TKO 3", true},
+		{"unchanged", "KO 3", "KO 3", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			forEachBackend(t, func(t *testing.T, open func() (FightRepository, error)) {
+				ctx := context.Background()
+				repo := mustOpen(t, open)
+
+				first := parsePage(t, resultsPage(tt.before))
+				result, err := repo.UpsertFights(ctx, first)
+				if err != nil {
+					t.Fatalf("first UpsertFights: %v", err)
+				}
+				if result.Inserted != 2 || result.Updated != 0 {
+					t.Errorf("first upsert = %+v, want 2 inserted", result)
+				}
+				stored, err := repo.GetByKey(ctx, first[1].Key)
+				if err != nil {
+					t.Fatalf("GetByKey after the first parse: %v", err)
+				}
+				firstParsedAt := *stored.ParsedAt
+
+				second := parsePage(t, resultsPage(tt.after))
+				result, err = repo.UpsertFights(ctx, second)
+				if err != nil {
+					t.Fatalf("second UpsertFights: %v", err)
+				}
+				if result.Inserted != 0 || result.Updated != 2 || (result.Changes > 0) != tt.changes {
+					t.Errorf("second upsert = %+v, want 2 updated, changes %v", result, tt.changes)
+				}
+
+				list, err := repo.List(ctx, FightFilter{})
+				if err != nil {
+					t.Fatalf("List: %v", err)
+				}
+				if len(list) != 2 {
+					t.Fatalf("List has %d fights, want 2", len(list))
+				}
+				stored, err = repo.GetByKey(ctx, second[1].Key)
+				if err != nil {
+					t.Fatalf("GetByKey after the second parse: %v", err)
+				}
+				if stored.Result != tt.after {
+					t.Errorf("result = %q, want %q", stored.Result, tt.after)
+				}
+				if stored.ParsedAt == nil || stored.ParsedAt.Before(firstParsedAt) {
+					t.Errorf("parsed_at = %v, want at least %v", stored.ParsedAt, firstParsedAt)
+				}
+			})
+		})
+	}
+}
@@ -0,0 +1,177 @@
+// Package stream fans fight updates out to the clients of the live endpoints
+// The API publishes the fights added or changed by a new snapshot to a Hub;
+// every connected client has its own buffered channel of events. A client
+// that does not keep up is evicted rather than slowing down the others, and
+// closing the hub ends every client, e.g. when the server shuts down.
+package stream
+
+import (
+	"errors"
+	"sync"
+
+	"easypars/models"
+)
+
+// Event types
+const (
+	// EventAdded is sent for a fight the previous snapshot did not have
+	EventAdded = "fight.added"
+	// EventUpdated is sent for a fight whose source data changed
+	EventUpdated = "fight.updated"
+)
+
+// Defaults used for zero Config fields
+const (
+	DefaultClientBuffer = 256
+	DefaultMaxClients   = 1000
+)
+
+var (
+	// ErrTooManyClients is returned by Subscribe when MaxClients are connected
+	ErrTooManyClients = errors.New("too many stream clients")
+	// ErrClosed is returned by Subscribe after Close, and by Client.Err for
+	// the clients ended by Close
+	ErrClosed = errors.New("stream closed")
+	// ErrSlowClient is returned by Client.Err for a client evicted because
+	// its buffer was full
+	ErrSlowClient = errors.New("stream client too slow")
+)
+
+// Event is an update of a single fight
+type Event struct {
+	Type  string
+	Fight models.Fight
+}
+
+// Config tunes a Hub
+type Config struct {
+	// ClientBuffer is the number of events buffered for every client; it
+	// should exceed the updates of a single refresh, or every client is
+	// evicted by it
+	ClientBuffer int
+	// MaxClients bounds the number of clients connected at once
+	MaxClients int
+}
+
+// Client is a subscription to the events of a hub
+type Client struct {
+	events chan Event
+	// err is why the client ended, set before events is closed
+	err error
+}
+
+// Events returns the events of the client
+// The channel is closed when the client is evicted, unsubscribed or the hub
+// is closed; Err tells which.
+func (c *Client) Events() <-chan Event {
+	return c.events
+}
+
+// Err returns why the events channel was closed: ErrSlowClient, ErrClosed,
+// or nil for a client that unsubscribed
+// It must only be called once the channel is closed.
+func (c *Client) Err() error {
+	return c.err
+}
+
+// Hub broadcasts events to its clients
+type Hub struct {
+	cfg Config
+
+	mu      sync.Mutex
+	clients map[*Client]struct{}
+	closed  bool
+	evicted int64
+}
+
+// NewHub creates a hub without clients
+func NewHub(cfg Config) *Hub {
+	if cfg.ClientBuffer <= 0 {
+		cfg.ClientBuffer = DefaultClientBuffer
+	}
+	if cfg.MaxClients <= 0 {
+		cfg.MaxClients = DefaultMaxClients
+	}
+
+	return &Hub{cfg: cfg, clients: make(map[*Client]struct{})}
+}
+
+// Subscribe connects a new client
+// The client must be unsubscribed when it goes away.
+func (h *Hub) Subscribe() (*Client, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.closed {
+		return nil, ErrClosed
+	}
+	if len(h.clients) >= h.cfg.MaxClients {
+		return nil, ErrTooManyClients
+	}
+	client := &Client{events: make(chan Event, h.cfg.ClientBuffer)}
+	h.clients[client] = struct{}{}
+
+	return client, nil
+}
+
+// Unsubscribe disconnects a client; disconnecting it again does nothing
+func (h *Hub) Unsubscribe(client *Client) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.remove(client, nil)
+}
+
+// Publish sends the events to every client
+// It never blocks: a client whose buffer cannot take all the events is
+// evicted, so it does not miss updates silently.
+func (h *Hub) Publish(events []Event) {
+	if len(events) == 0 {
+		return
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for client := range h.clients {
+		if cap(client.events)-len(client.events) < len(events) {
+			h.remove(client, ErrSlowClient)
+			h.evicted++
+			continue
+		}
+		for _, event := range events {
+			client.events <- event
+		}
+	}
+}
+
+// Close ends every client and refuses new ones
+func (h *Hub) Close() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.closed = true
+	for client := range h.clients {
+		h.remove(client, ErrClosed)
+	}
+}
+
+// Stats returns the number of connected clients and of the clients evicted
+// since the start
+func (h *Hub) Stats() (clients int, evicted int64) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	return len(h.clients), h.evicted
+}
+
+// remove ends a connected client with the reason
+// The caller must hold the lock
+func (h *Hub) remove(client *Client, reason error) {
+	if _, ok := h.clients[client]; !ok {
+		return
+	}
+	delete(h.clients, client)
+	client.err = reason
+	close(client.events)
+}
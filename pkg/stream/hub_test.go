@@ -0,0 +1,199 @@
+package stream
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"easypars/models"
+)
+
+// events returns n added events of distinct fights
+func events(n int) []Event {
+	events := make([]Event, n)
+	for i := range events {
+		events[i] = Event{Type: EventAdded, Fight: models.Fight{Fighter1: string(rune('A' + i))}}
+	}
+
+	return events
+}
+
+// drain returns the events buffered for the client
+func drain(client *Client) []Event {
+	var got []Event
+	for {
+		select {
+		case event, open := <-client.Events():
+			if !open {
+				return got
+			}
+			got = append(got, event)
+		default:
+			return got
+		}
+	}
+}
+
+// closed reports whether the events channel of the client is closed
+func closed(client *Client) bool {
+	drain(client)
+	select {
+	case _, open := <-client.Events():
+		return !open
+	default:
+		return false
+	}
+}
+
+func TestHubPublish(t *testing.T) {
+	tests := []struct {
+		name    string
+		buffer  int
+		publish []int
+		// received is the number of events of each client, -1 for an
+		// evicted client
+		received int
+		evicted  int64
+	}{
+		{"single event", 4, []int{1}, 1, 0},
+		{"buffer filled", 4, []int{2, 2}, 4, 0},
+		{"batch larger than the buffer", 4, []int{5}, -1, 2},
+		{"buffer overflowing", 4, []int{3, 2}, -1, 2},
+		{"nothing to publish", 4, []int{0}, 0, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hub := NewHub(Config{ClientBuffer: tt.buffer})
+			first, _ := hub.Subscribe()
+			second, _ := hub.Subscribe()
+
+			var published []Event
+			for _, n := range tt.publish {
+				batch := events(n)
+				published = append(published, batch...)
+				hub.Publish(batch)
+			}
+
+			for i, client := range []*Client{first, second} {
+				got := drain(client)
+				if tt.received < 0 {
+					if !closed(client) || !errors.Is(client.Err(), ErrSlowClient) {
+						t.Errorf("client %d got %d events, want it evicted as too slow", i, len(got))
+					}
+					continue
+				}
+				if len(got) != tt.received || (len(got) > 0 && !reflect.DeepEqual(got, published)) {
+					t.Errorf("client %d got %+v, want %+v", i, got, published)
+				}
+			}
+			if _, evicted := hub.Stats(); evicted != tt.evicted {
+				t.Errorf("evicted = %d, want %d", evicted, tt.evicted)
+			}
+		})
+	}
+}
+
+func TestHubEvictsOnlyTheSlowClient(t *testing.T) {
+	hub := NewHub(Config{ClientBuffer: 2})
+	slow, _ := hub.Subscribe()
+	fast, _ := hub.Subscribe()
+
+	hub.Publish(events(2))
+	drain(fast)
+	hub.Publish(events(1))
+
+	if !closed(slow) || !errors.Is(slow.Err(), ErrSlowClient) {
+		t.Error("the slow client was not evicted")
+	}
+	if got := drain(fast); len(got) != 1 {
+		t.Errorf("the fast client got %d events, want 1", len(got))
+	}
+	if clients, evicted := hub.Stats(); clients != 1 || evicted != 1 {
+		t.Errorf("stats = %d clients, %d evicted, want 1 and 1", clients, evicted)
+	}
+}
+
+func TestHubUnsubscribe(t *testing.T) {
+	hub := NewHub(Config{})
+	client, _ := hub.Subscribe()
+	other, _ := hub.Subscribe()
+
+	hub.Unsubscribe(client)
+	hub.Unsubscribe(client)
+	if !closed(client) || client.Err() != nil {
+		t.Errorf("unsubscribed client: closed %v, err %v, want closed without an error", closed(client), client.Err())
+	}
+
+	// The other clients keep receiving
+	hub.Publish(events(1))
+	if got := drain(other); len(got) != 1 {
+		t.Errorf("the other client got %d events, want 1", len(got))
+	}
+	if clients, _ := hub.Stats(); clients != 1 {
+		t.Errorf("clients = %d, want 1", clients)
+	}
+}
+
+func TestHubSubscribe(t *testing.T) {
+	tests := []struct {
+		name    string
+		max     int
+		clients int
+		close   bool
+		err     error
+	}{
+		{"below the limit", 2, 1, false, nil},
+		{"at the limit", 2, 2, false, ErrTooManyClients},
+		{"closed hub", 2, 0, true, ErrClosed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hub := NewHub(Config{MaxClients: tt.max})
+			var connected []*Client
+			for range tt.clients {
+				client, err := hub.Subscribe()
+				if err != nil {
+					t.Fatal(err)
+				}
+				connected = append(connected, client)
+			}
+			if tt.close {
+				hub.Close()
+			}
+
+			if _, err := hub.Subscribe(); !errors.Is(err, tt.err) {
+				t.Errorf("Subscribe = %v, want %v", err, tt.err)
+			}
+
+			// A client that leaves makes room for another
+			if errors.Is(tt.err, ErrTooManyClients) {
+				hub.Unsubscribe(connected[0])
+				if _, err := hub.Subscribe(); err != nil {
+					t.Errorf("Subscribe after a client left = %v, want a client", err)
+				}
+			}
+		})
+	}
+}
+
+func TestHubClose(t *testing.T) {
+	hub := NewHub(Config{})
+	clients := []*Client{}
+	for range 3 {
+		client, _ := hub.Subscribe()
+		clients = append(clients, client)
+	}
+
+	hub.Close()
+	for i, client := range clients {
+		if !closed(client) || !errors.Is(client.Err(), ErrClosed) {
+			t.Errorf("client %d: closed %v, err %v, want ended with ErrClosed", i, closed(client), client.Err())
+		}
+	}
+	// Publishing to and unsubscribing from a closed hub do nothing
+	hub.Publish(events(1))
+	hub.Unsubscribe(clients[0])
+	if clients, _ := hub.Stats(); clients != 0 {
+		t.Errorf("clients = %d after Close, want 0", clients)
+	}
+}
@@ -0,0 +1,77 @@
+// Package tracing sets up the OpenTelemetry tracing of the service
+// The API opens a span per request and the parser child spans for the
+// fetch, the extraction and the fan-out over pages and sources, so a slow
+// request shows where its time went. Spans are exported over OTLP/HTTP.
+// Without Setup the global provider is the OpenTelemetry no-op one: the
+// spans started by Tracer record nothing and cost next to nothing.
+package tracing
+
+import (
+	"context"
+	"fmt"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// instrumentationName names the tracer of the service
+const instrumentationName = "easypars"
+
+// Config selects the exporter and the sampling of the traces
+type Config struct {
+	// Endpoint is the host:port of the OTLP/HTTP collector
+	Endpoint string
+	// Insecure sends the spans over plain HTTP
+	Insecure bool
+	// SampleRatio is the share of the traces started here that are
+	// recorded; traces continued from a caller follow its decision
+	SampleRatio float64
+	// ServiceName is the service.name of the spans
+	ServiceName string
+}
+
+// Setup installs the global tracer provider exporting to the collector and
+// the W3C trace context propagator
+// The returned shutdown flushes the spans still buffered; call it on exit.
+func Setup(ctx context.Context, cfg Config) (shutdown func(context.Context) error, err error) {
+	options := []otlptracehttp.Option{otlptracehttp.WithEndpoint(cfg.Endpoint)}
+	if cfg.Insecure {
+		options = append(options, otlptracehttp.WithInsecure())
+	}
+	exporter, err := otlptracehttp.New(ctx, options...)
+	if err != nil {
+		return nil, fmt.Errorf("error creating the OTLP exporter: %w", err)
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(cfg.SampleRatio))),
+		sdktrace.WithResource(resource.NewSchemaless(attribute.String("service.name", cfg.ServiceName))),
+	)
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+
+	return provider.Shutdown, nil
+}
+
+// Tracer returns the tracer of the service from the global provider
+// It is looked up on every call, so spans follow a provider installed
+// after the caller was created.
+func Tracer() trace.Tracer {
+	return otel.Tracer(instrumentationName)
+}
+
+// End ends the span, marking it failed with err when err is not nil
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
@@ -0,0 +1,378 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+
+	"easypars/models"
+	"easypars/pkg/netguard"
+)
+
+// EventFightsCompleted is the event of fights that got a result
+const EventFightsCompleted = "fights.completed"
+
+// Headers of a delivery
+const (
+	// SignatureHeader carries "sha256=" and the hex HMAC-SHA256 of the body
+	// keyed with the secret of the subscription (see Sign)
+	SignatureHeader = "X-EasyPars-Signature"
+	EventHeader     = "X-EasyPars-Event"
+	DeliveryHeader  = "X-EasyPars-Delivery"
+)
+
+// Defaults used for zero Config fields
+const (
+	DefaultTimeout      = 5 * time.Second
+	DefaultMaxAttempts  = 3
+	DefaultRetryBackoff = time.Second
+	DefaultLogSize      = 500
+)
+
+// Delivery states
+const (
+	StatePending   = "pending"
+	StateDelivered = "delivered"
+	StateFailed    = "failed"
+)
+
+// Config tunes the deliveries of a Dispatcher
+type Config struct {
+	// Timeout bounds every attempt of a delivery
+	Timeout time.Duration
+	// MaxAttempts is the number of attempts of a delivery, the first
+	// included
+	MaxAttempts int
+	// RetryBackoff is the pause before the second attempt; it doubles with
+	// every further attempt
+	RetryBackoff time.Duration
+	// LogSize is the number of deliveries kept for the delivery log
+	LogSize int
+	// AllowedHosts are host names and IP addresses deliveries may reach
+	// even on loopback, private or link-local addresses, which are refused
+	// otherwise (see ErrBlockedAddress)
+	AllowedHosts []string
+}
+
+// Event is the payload posted to the subscribers
+type Event struct {
+	ID        string         `json:"id"`
+	Type      string         `json:"event"`
+	CreatedAt time.Time      `json:"created_at"`
+	Fights    []models.Fight `json:"fights"`
+}
+
+// Attempt is one request of a delivery
+type Attempt struct {
+	At         time.Time `json:"at"`
+	DurationMs int64     `json:"duration_ms"`
+	StatusCode int       `json:"status_code,omitempty"`
+	Error      string    `json:"error,omitempty"`
+}
+
+// Delivery is an event posted to a subscriber, with its attempts
+type Delivery struct {
+	ID         string     `json:"id"`
+	HookID     string     `json:"hook_id"`
+	URL        string     `json:"url"`
+	Event      string     `json:"event"`
+	EventID    string     `json:"event_id"`
+	FightCount int        `json:"fight_count"`
+	State      string     `json:"state"`
+	Attempts   []Attempt  `json:"attempts"`
+	CreatedAt  time.Time  `json:"created_at"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+}
+
+// Dispatcher posts events to the subscriptions of a Store
+// Every delivery runs in its own goroutine: an attempt answered with 2xx
+// delivers the event, a network error, a timeout, 408, 429 or 5xx is
+// retried after a growing pause, any other status fails the delivery.
+// Redirects are not followed and internal addresses outside the allowed
+// hosts are refused when connecting.
+type Dispatcher struct {
+	hooks   *Store
+	cfg     Config
+	allowed netguard.Allowlist
+	client  *http.Client
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+
+	mu      sync.Mutex
+	stopped bool
+	// log holds the recent deliveries, oldest first
+	log []*Delivery
+}
+
+// NewDispatcher creates a dispatcher for the subscriptions of the store
+func NewDispatcher(hooks *Store, cfg Config) *Dispatcher {
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = DefaultTimeout
+	}
+	if cfg.MaxAttempts <= 0 {
+		cfg.MaxAttempts = DefaultMaxAttempts
+	}
+	if cfg.RetryBackoff <= 0 {
+		cfg.RetryBackoff = DefaultRetryBackoff
+	}
+	if cfg.LogSize <= 0 {
+		cfg.LogSize = DefaultLogSize
+	}
+
+	allowed := netguard.NewAllowlist(cfg.AllowedHosts)
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Dispatcher{
+		hooks:   hooks,
+		cfg:     cfg,
+		allowed: allowed,
+		client:  newDeliveryClient(allowed, cfg.Timeout),
+		ctx:     ctx,
+		cancel:  cancel,
+	}
+}
+
+// Hooks returns the subscriptions of the dispatcher
+func (d *Dispatcher) Hooks() *Store {
+	return d.hooks
+}
+
+// CheckURL rejects a callback URL the dispatcher would refuse to deliver
+// to, so a registration fails at once rather than every delivery
+// Only IP addresses are checked; host names are resolved when connecting.
+func (d *Dispatcher) CheckURL(raw string) error {
+	if err := ValidateURL(raw); err != nil {
+		return err
+	}
+
+	return checkURL(d.allowed, raw)
+}
+
+// Send posts the event to every subscription in the background
+// The event gets an ID and a creation time when it has none.
+func (d *Dispatcher) Send(event Event) {
+	// Send counts as running until its deliveries are started, so Stop
+	// waits for them
+	d.mu.Lock()
+	if d.stopped {
+		d.mu.Unlock()
+		return
+	}
+	d.wg.Add(1)
+	d.mu.Unlock()
+	defer d.wg.Done()
+
+	if event.ID == "" {
+		id, err := randomID()
+		if err != nil {
+			slog.Error("Webhook event not sent", "event", event.Type, "error", err)
+			return
+		}
+		event.ID = id
+	}
+	if event.CreatedAt.IsZero() {
+		event.CreatedAt = time.Now().UTC()
+	}
+
+	body, err := json.Marshal(event)
+	if err != nil {
+		slog.Error("Webhook event not sent", "event", event.Type, "error", err)
+		return
+	}
+
+	for _, hook := range d.hooks.List() {
+		id, err := randomID()
+		if err != nil {
+			slog.Error("Webhook delivery not started", "hook_id", hook.ID, "error", err)
+			continue
+		}
+		delivery := &Delivery{
+			ID:         id,
+			HookID:     hook.ID,
+			URL:        hook.URL,
+			Event:      event.Type,
+			EventID:    event.ID,
+			FightCount: len(event.Fights),
+			State:      StatePending,
+			Attempts:   []Attempt{},
+			CreatedAt:  time.Now().UTC(),
+		}
+		d.record(delivery)
+
+		d.wg.Add(1)
+		go func() {
+			defer d.wg.Done()
+			d.deliver(hook, delivery, body)
+		}()
+	}
+}
+
+// Deliveries returns the logged deliveries of a subscription, newest first;
+// every logged delivery when hookID is empty
+func (d *Dispatcher) Deliveries(hookID string) []Delivery {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	deliveries := []Delivery{}
+	for i := len(d.log) - 1; i >= 0; i-- {
+		delivery := d.log[i]
+		if hookID != "" && delivery.HookID != hookID {
+			continue
+		}
+		copied := *delivery
+		copied.Attempts = append([]Attempt(nil), delivery.Attempts...)
+		deliveries = append(deliveries, copied)
+	}
+
+	return deliveries
+}
+
+// Stop cancels the pending retries and waits for the attempts in flight
+// until ctx ends; events sent afterwards are dropped
+func (d *Dispatcher) Stop(ctx context.Context) error {
+	d.mu.Lock()
+	d.stopped = true
+	d.mu.Unlock()
+	d.cancel()
+
+	done := make(chan struct{})
+	go func() {
+		d.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Sign returns the value of SignatureHeader for a body and a secret
+// Receivers recompute it over the raw request body and compare the two in
+// constant time.
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// deliver posts the body to the hook until an attempt succeeds, fails for
+// good or the attempts are used up
+func (d *Dispatcher) deliver(hook Hook, delivery *Delivery, body []byte) {
+	backoff := d.cfg.RetryBackoff
+	for attempt := 1; ; attempt++ {
+		result, retry := d.post(hook, delivery, body)
+		state := StatePending
+		switch {
+		case result.Error == "" && !retry:
+			state = StateDelivered
+		case !retry || attempt >= d.cfg.MaxAttempts:
+			state = StateFailed
+		}
+		d.update(delivery, result, state)
+
+		switch state {
+		case StateDelivered:
+			slog.Info("Webhook delivered", "hook_id", hook.ID, "delivery_id", delivery.ID, "attempts", attempt)
+			return
+		case StateFailed:
+			slog.Warn("Webhook delivery failed", "hook_id", hook.ID, "delivery_id", delivery.ID,
+				"attempts", attempt, "status_code", result.StatusCode, "error", result.Error)
+			return
+		}
+
+		select {
+		case <-d.ctx.Done():
+			d.update(delivery, Attempt{}, StateFailed)
+			return
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+}
+
+// post makes one attempt of a delivery and reports whether a failure is
+// worth retrying
+// The attempt is only bounded by the timeout, so Stop lets it finish.
+func (d *Dispatcher) post(hook Hook, delivery *Delivery, body []byte) (Attempt, bool) {
+	ctx, cancel := context.WithTimeout(context.Background(), d.cfg.Timeout)
+	defer cancel()
+
+	start := time.Now()
+	result := Attempt{At: start.UTC()}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, hook.URL, bytes.NewReader(body))
+	if err != nil {
+		result.Error = err.Error()
+		return result, false
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "EasyPars-Webhook/1.0")
+	req.Header.Set(EventHeader, delivery.Event)
+	req.Header.Set(DeliveryHeader, delivery.ID)
+	if hook.Secret != "" {
+		req.Header.Set(SignatureHeader, Sign(hook.Secret, body))
+	}
+
+	resp, err := d.client.Do(req)
+	result.DurationMs = time.Since(start).Milliseconds()
+	if err != nil {
+		result.Error = err.Error()
+		// A refused address stays refused, retrying it is pointless
+		return result, !errors.Is(err, ErrBlockedAddress)
+	}
+	// Drain a little of the body so the connection can be reused
+	_, _ = io.CopyN(io.Discard, resp.Body, 4096)
+	resp.Body.Close()
+
+	result.StatusCode = resp.StatusCode
+	switch {
+	case resp.StatusCode >= 200 && resp.StatusCode < 300:
+		return result, false
+	case resp.StatusCode == http.StatusRequestTimeout, resp.StatusCode == http.StatusTooManyRequests, resp.StatusCode >= 500:
+		result.Error = fmt.Sprintf("receiver answered %d", resp.StatusCode)
+		return result, true
+	default:
+		result.Error = fmt.Sprintf("receiver answered %d", resp.StatusCode)
+		return result, false
+	}
+}
+
+// record adds a delivery to the log, dropping the oldest over LogSize
+func (d *Dispatcher) record(delivery *Delivery) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.log = append(d.log, delivery)
+	if over := len(d.log) - d.cfg.LogSize; over > 0 {
+		d.log = append(d.log[:0:0], d.log[over:]...)
+	}
+}
+
+// update records an attempt and the new state of a delivery
+// A zero attempt only changes the state, e.g. when a retry is cancelled.
+func (d *Dispatcher) update(delivery *Delivery, attempt Attempt, state string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if !attempt.At.IsZero() {
+		delivery.Attempts = append(delivery.Attempts, attempt)
+	}
+	delivery.State = state
+	if state != StatePending {
+		finished := time.Now().UTC()
+		delivery.FinishedAt = &finished
+	}
+}
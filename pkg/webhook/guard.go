@@ -0,0 +1,54 @@
+package webhook
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"easypars/pkg/netguard"
+)
+
+// ErrBlockedAddress is returned for a delivery to a loopback, private,
+// link-local or otherwise internal address that is not allowed (see
+// pkg/netguard)
+var ErrBlockedAddress = netguard.ErrBlockedAddress
+
+// checkURL rejects callback URLs whose host is a blocked IP address
+// Host names are not resolved here, their addresses are checked when a
+// delivery connects (see newDeliveryClient).
+func checkURL(allowed netguard.Allowlist, raw string) error {
+	target, err := url.Parse(raw)
+	if err != nil {
+		return fmt.Errorf("%w: url must be an absolute http(s) URL", ErrInvalidHook)
+	}
+	host := target.Hostname()
+	if allowed.Allows(host) {
+		return nil
+	}
+	if netguard.BlockedHost(host) {
+		return fmt.Errorf("%w: %w: %s is an internal address, list it in webhooks.allowed_hosts to allow it",
+			ErrInvalidHook, ErrBlockedAddress, host)
+	}
+
+	return nil
+}
+
+// newDeliveryClient returns the HTTP client of the deliveries
+// Hosts of the allowlist are dialed as they are, every address any other
+// host resolves to is checked when connecting. Redirects are not followed
+// and no proxy is used: a proxy would be the only address checked, a
+// redirect could lead anywhere.
+func newDeliveryClient(allowed netguard.Allowlist, timeout time.Duration) *http.Client {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = netguard.Dialer(timeout, func(_ context.Context, host string) bool {
+		return !allowed.Allows(host)
+	})
+
+	return &http.Client{
+		Transport:     transport,
+		CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse },
+	}
+}
@@ -0,0 +1,155 @@
+package webhook
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// receiver is a callback server on 127.0.0.1 counting its requests
+type receiver struct {
+	*httptest.Server
+	hits atomic.Int32
+}
+
+// newReceiver starts a receiver answering with the status codes in turn,
+// the last one for every further request
+func newReceiver(t *testing.T, statuses ...int) *receiver {
+	t.Helper()
+
+	r := &receiver{}
+	r.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		n := int(r.hits.Add(1))
+		body, _ := io.ReadAll(req.Body)
+		if req.Header.Get(SignatureHeader) != Sign("hook secret", body) {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.WriteHeader(statuses[min(n, len(statuses))-1])
+	}))
+	t.Cleanup(r.Close)
+
+	return r
+}
+
+// deliverOnce registers the URL, sends one event and waits until its
+// delivery is finished
+func deliverOnce(t *testing.T, cfg Config, rawURL string) Delivery {
+	t.Helper()
+
+	store, err := NewStore("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.Create(rawURL, "hook secret"); err != nil {
+		t.Fatal(err)
+	}
+	d := NewDispatcher(store, cfg)
+	d.Send(Event{Type: "fights.completed"})
+
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		deliveries := d.Deliveries("")
+		if len(deliveries) != 1 {
+			t.Fatalf("got %d deliveries, want 1", len(deliveries))
+		}
+		if deliveries[0].State != StatePending {
+			return deliveries[0]
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatal("delivery still pending")
+
+	return Delivery{}
+}
+
+func TestCheckURL(t *testing.T) {
+	store, err := NewStore("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	d := NewDispatcher(store, Config{AllowedHosts: []string{"10.0.0.12", "[fd00::1]", "Bot.LAN"}})
+
+	tests := []struct {
+		url     string
+		blocked bool
+	}{
+		{"http://127.0.0.1:8080/hook", true},
+		{"http://169.254.169.254/latest/meta-data/", true},
+		{"http://[::1]/hook", true},
+		{"http://[::ffff:192.168.0.1]/hook", true},
+		{"https://192.168.0.10/hook", true},
+		{"https://example.com/hook", false},
+		{"https://93.184.216.34/hook", false},
+		// Host names are checked when connecting
+		{"http://localhost/hook", false},
+		// Allowed hosts
+		{"http://10.0.0.12:9000/hook", false},
+		{"http://[fd00::1]/hook", false},
+		{"http://bot.lan/hook", false},
+	}
+	for _, tt := range tests {
+		err := d.CheckURL(tt.url)
+		if tt.blocked && (!errors.Is(err, ErrInvalidHook) || !errors.Is(err, ErrBlockedAddress)) {
+			t.Errorf("CheckURL(%s) = %v, want ErrInvalidHook and ErrBlockedAddress", tt.url, err)
+		}
+		if !tt.blocked && err != nil {
+			t.Errorf("CheckURL(%s) = %v, want nil", tt.url, err)
+		}
+	}
+
+	if err := d.CheckURL("ftp://example.com/hook"); !errors.Is(err, ErrInvalidHook) || errors.Is(err, ErrBlockedAddress) {
+		t.Errorf("CheckURL of an ftp URL = %v, want ErrInvalidHook only", err)
+	}
+}
+
+func TestDeliveryToLoopbackIsRefused(t *testing.T) {
+	r := newReceiver(t, http.StatusOK)
+	target, err := url.Parse(r.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for name, rawURL := range map[string]string{
+		"IP address": r.URL + "/hook",
+		// A host name is refused once it resolves to the loopback address
+		"host name": "http://localhost:" + target.Port() + "/hook",
+	} {
+		t.Run(name, func(t *testing.T) {
+			delivery := deliverOnce(t, Config{MaxAttempts: 3, RetryBackoff: time.Millisecond}, rawURL)
+			if delivery.State != StateFailed {
+				t.Errorf("state = %s, want %s", delivery.State, StateFailed)
+			}
+			if len(delivery.Attempts) != 1 {
+				t.Errorf("got %d attempts, want 1: a refused address is not retried", len(delivery.Attempts))
+			}
+		})
+	}
+	if hits := r.hits.Load(); hits != 0 {
+		t.Errorf("receiver got %d requests, want none", hits)
+	}
+}
+
+func TestDeliveryToAllowedHost(t *testing.T) {
+	r := newReceiver(t, http.StatusServiceUnavailable, http.StatusOK)
+	target, err := url.Parse(r.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	delivery := deliverOnce(t, Config{MaxAttempts: 3, RetryBackoff: time.Millisecond, AllowedHosts: []string{target.Hostname()}}, r.URL+"/hook")
+	if delivery.State != StateDelivered {
+		t.Errorf("state = %s (attempts %+v), want %s", delivery.State, delivery.Attempts, StateDelivered)
+	}
+	if len(delivery.Attempts) != 2 || delivery.Attempts[0].StatusCode != http.StatusServiceUnavailable {
+		t.Errorf("attempts = %+v, want a retried 503 and a 200", delivery.Attempts)
+	}
+	if hits := r.hits.Load(); hits != 2 {
+		t.Errorf("receiver got %d requests, want 2", hits)
+	}
+}
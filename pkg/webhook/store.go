@@ -0,0 +1,240 @@
+// Package webhook pushes new fight results to subscribed URLs
+// Subscriptions are registered through the admin API and kept in a Store;
+// a Dispatcher posts each event to every subscriber, signed with the secret
+// of the subscription, retries failed deliveries and keeps a log of them.
+package webhook
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// MaxHooks bounds the number of subscriptions
+const MaxHooks = 100
+
+// maxURLLength bounds the length of a callback URL
+const maxURLLength = 2048
+
+var (
+	// ErrInvalidHook is returned for a subscription with an invalid URL
+	ErrInvalidHook = errors.New("invalid webhook")
+	// ErrHookNotFound is returned for an unknown subscription ID
+	ErrHookNotFound = errors.New("webhook not found")
+	// ErrTooManyHooks is returned when the store holds MaxHooks subscriptions
+	ErrTooManyHooks = errors.New("too many webhooks")
+)
+
+// Hook is a subscription of a callback URL
+// The secret signs the deliveries and is never returned by the API.
+type Hook struct {
+	ID        string    `json:"id"`
+	URL       string    `json:"url"`
+	Secret    string    `json:"-"`
+	HasSecret bool      `json:"has_secret"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// savedHook is a subscription as written to the store file, secret included
+type savedHook struct {
+	ID        string    `json:"id"`
+	URL       string    `json:"url"`
+	Secret    string    `json:"secret,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Store keeps the subscriptions in memory
+// When a file path is set, subscriptions are saved to it after every change
+// and loaded on start, so they survive restarts.
+type Store struct {
+	mu    sync.RWMutex
+	path  string
+	hooks map[string]Hook
+}
+
+// NewStore creates a store, loading the subscriptions of path when the
+// file exists
+func NewStore(path string) (*Store, error) {
+	s := &Store{path: path, hooks: make(map[string]Hook)}
+	if path != "" {
+		if err := s.load(); err != nil {
+			return nil, err
+		}
+	}
+
+	return s, nil
+}
+
+// ValidateURL checks that a callback URL is an absolute http(s) URL
+func ValidateURL(raw string) error {
+	if len(raw) > maxURLLength {
+		return fmt.Errorf("%w: url must be at most %d characters", ErrInvalidHook, maxURLLength)
+	}
+	target, err := url.Parse(raw)
+	if err != nil || (target.Scheme != "http" && target.Scheme != "https") || target.Host == "" {
+		return fmt.Errorf("%w: url must be an absolute http(s) URL", ErrInvalidHook)
+	}
+
+	return nil
+}
+
+// Create registers a subscription of the URL with an optional secret
+func (s *Store) Create(rawURL, secret string) (Hook, error) {
+	if err := ValidateURL(rawURL); err != nil {
+		return Hook{}, err
+	}
+	id, err := randomID()
+	if err != nil {
+		return Hook{}, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(s.hooks) >= MaxHooks {
+		return Hook{}, fmt.Errorf("%w: at most %d can be registered", ErrTooManyHooks, MaxHooks)
+	}
+	hook := Hook{ID: id, URL: rawURL, Secret: secret, HasSecret: secret != "", CreatedAt: time.Now().UTC()}
+	s.hooks[id] = hook
+	if err := s.save(); err != nil {
+		delete(s.hooks, id)
+		return Hook{}, err
+	}
+
+	return hook, nil
+}
+
+// Get returns the subscription with the ID
+func (s *Store) Get(id string) (Hook, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	hook, ok := s.hooks[id]
+	return hook, ok
+}
+
+// List returns the subscriptions, oldest first
+func (s *Store) List() []Hook {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	hooks := make([]Hook, 0, len(s.hooks))
+	for _, hook := range s.hooks {
+		hooks = append(hooks, hook)
+	}
+	sort.Slice(hooks, func(i, j int) bool {
+		if !hooks[i].CreatedAt.Equal(hooks[j].CreatedAt) {
+			return hooks[i].CreatedAt.Before(hooks[j].CreatedAt)
+		}
+		return hooks[i].ID < hooks[j].ID
+	})
+
+	return hooks
+}
+
+// Delete removes the subscription with the ID
+func (s *Store) Delete(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	hook, ok := s.hooks[id]
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrHookNotFound, id)
+	}
+	delete(s.hooks, id)
+	if err := s.save(); err != nil {
+		s.hooks[id] = hook
+		return err
+	}
+
+	return nil
+}
+
+// randomID returns a random subscription ID of 16 hex characters
+func randomID() (string, error) {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("error generating webhook ID: %w", err)
+	}
+
+	return hex.EncodeToString(b), nil
+}
+
+// load reads the subscriptions from the file
+func (s *Store) load() error {
+	data, err := os.ReadFile(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("error reading webhooks file: %w", err)
+	}
+
+	var saved []savedHook
+	if err := json.Unmarshal(data, &saved); err != nil {
+		return fmt.Errorf("error decoding webhooks file: %w", err)
+	}
+	for _, hook := range saved {
+		if err := ValidateURL(hook.URL); err != nil || hook.ID == "" {
+			return fmt.Errorf("error decoding webhooks file: invalid webhook %q", hook.ID)
+		}
+		s.hooks[hook.ID] = Hook{
+			ID:        hook.ID,
+			URL:       hook.URL,
+			Secret:    hook.Secret,
+			HasSecret: hook.Secret != "",
+			CreatedAt: hook.CreatedAt,
+		}
+	}
+
+	return nil
+}
+
+// save writes all subscriptions to the file atomically
+// The file holds the secrets, so it is only readable by its owner.
+// The caller must hold the lock
+func (s *Store) save() error {
+	if s.path == "" {
+		return nil
+	}
+
+	saved := make([]savedHook, 0, len(s.hooks))
+	for _, hook := range s.hooks {
+		saved = append(saved, savedHook{ID: hook.ID, URL: hook.URL, Secret: hook.Secret, CreatedAt: hook.CreatedAt})
+	}
+	sort.Slice(saved, func(i, j int) bool { return saved[i].ID < saved[j].ID })
+
+	data, err := json.Marshal(saved)
+	if err != nil {
+		return fmt.Errorf("error encoding webhooks: %w", err)
+	}
+
+	// CreateTemp creates the file with mode 0600
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), ".webhooks-*.tmp")
+	if err != nil {
+		return fmt.Errorf("error saving webhooks: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("error saving webhooks: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("error saving webhooks: %w", err)
+	}
+
+	if err := os.Rename(tmp.Name(), s.path); err != nil {
+		return fmt.Errorf("error saving webhooks: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,107 @@
+// Package widget renders the "upcoming fights" block embedded by third-party
+// sites in an iframe
+// The block is a self-contained HTML document: styles are inline and there
+// is no script, so it works under a strict Content-Security-Policy. All data
+// is escaped by html/template; fighter names come from the HTML of the
+// source and must never be trusted.
+package widget
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+
+	"easypars/models"
+)
+
+// Themes of the widget
+const (
+	ThemeLight = "light"
+	ThemeDark  = "dark"
+)
+
+// Widget size suggested to embedding sites, in pixels
+const (
+	Width  = 400
+	Height = 360
+)
+
+// palette holds the colors of a theme
+type palette struct {
+	Background string
+	Text       string
+	Muted      string
+	Border     string
+	Accent     string
+}
+
+// palettes maps a theme to its colors
+var palettes = map[string]palette{
+	ThemeLight: {Background: "#ffffff", Text: "#1b1f3b", Muted: "#6b6f85", Border: "#e3e4ec", Accent: "#e63946"},
+	ThemeDark:  {Background: "#1b1f3b", Text: "#ffffff", Muted: "#a9abc0", Border: "#2e335a", Accent: "#e63946"},
+}
+
+// Data is the content of the widget
+type Data struct {
+	// Fights are the upcoming fights in the order shown
+	Fights []models.Fight
+	// SourceURL is the address of the source site, linked below the list
+	SourceURL string
+	// Theme is ThemeLight or ThemeDark, ThemeLight when unknown
+	Theme string
+}
+
+// page is the widget document
+// The styles only use colors from the palette, never data.
+var page = template.Must(template.New("widget").Parse(`<!DOCTYPE html>
+<html lang="ru">
+<head>
+<meta charset="utf-8">
+<title>Ближайшие бои</title>
+<style>
+body{margin:0;padding:12px;font:14px/1.4 -apple-system,"Segoe UI",Roboto,sans-serif;background:{{.Colors.Background}};color:{{.Colors.Text}}}
+h1{margin:0 0 8px;font-size:16px;border-bottom:3px solid {{.Colors.Accent}};padding-bottom:4px}
+ul{list-style:none;margin:0;padding:0}
+li{padding:6px 0;border-bottom:1px solid {{.Colors.Border}}}
+.names{font-weight:600}
+.info,.empty,footer{color:{{.Colors.Muted}};font-size:12px}
+footer{margin-top:8px}
+a{color:{{.Colors.Accent}}}
+</style>
+</head>
+<body>
+<h1>Ближайшие бои</h1>
+{{- if .Fights}}
+<ul>
+{{- range .Fights}}
+<li><div class="names">{{or .Fighter1 "TBA"}} vs {{or .Fighter2 "TBA"}}</div><div class="info">{{.Date}}{{if .Location}} · {{.Location}}{{end}}</div></li>
+{{- end}}
+</ul>
+{{- else}}
+<p class="empty">Нет объявленных боёв</p>
+{{- end}}
+{{- if .SourceURL}}
+<footer>Источник: <a href="{{.SourceURL}}" target="_blank" rel="noopener noreferrer">{{.SourceURL}}</a></footer>
+{{- end}}
+</body>
+</html>
+`))
+
+// Render returns the widget document
+func Render(data Data) ([]byte, error) {
+	colors, ok := palettes[data.Theme]
+	if !ok {
+		colors = palettes[ThemeLight]
+	}
+
+	var buf bytes.Buffer
+	err := page.Execute(&buf, struct {
+		Data
+		Colors palette
+	}{data, colors})
+	if err != nil {
+		return nil, fmt.Errorf("error rendering the widget: %w", err)
+	}
+
+	return buf.Bytes(), nil
+}
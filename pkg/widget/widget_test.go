@@ -0,0 +1,84 @@
+package widget
+
+import (
+	"strings"
+	"testing"
+
+	"easypars/models"
+)
+
+func TestRenderEscapesSourceData(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    Data
+		raw     string
+		escaped string
+	}{
+		{
+			name:    "script in a fighter name",
+			data:    Data{Fights: []models.Fight{{Fighter1: "<script>alert(1)</script>", Fighter2: "Tyson Fury"}}},
+			raw:     "<script>alert(1)</script>",
+			escaped: "&lt;script&gt;alert(1)&lt;/script&gt; vs Tyson Fury",
+		},
+		{
+			name:    "attribute injection in a fighter name",
+			data:    Data{Fights: []models.Fight{{Fighter1: "Oleksandr Usyk", Fighter2: `"><img src=x onerror=alert(1)>`}}},
+			raw:     `<img src=x onerror=alert(1)>`,
+			escaped: "Oleksandr Usyk vs &#34;&gt;&lt;img src=x onerror=alert(1)&gt;",
+		},
+		{
+			name:    "markup in a location",
+			data:    Data{Fights: []models.Fight{{Fighter1: "A", Fighter2: "B", Date: "2024-06-22", Location: "<b>Riyadh</b>"}}},
+			raw:     "<b>Riyadh</b>",
+			escaped: "2024-06-22 · &lt;b&gt;Riyadh&lt;/b&gt;",
+		},
+		{
+			name:    "javascript source URL",
+			data:    Data{SourceURL: "javascript:alert(1)"},
+			raw:     `href="javascript:alert(1)"`,
+			escaped: `href="#ZgotmplZ"`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			page, err := Render(tt.data)
+			if err != nil {
+				t.Fatalf("Render: %v", err)
+			}
+			html := string(page)
+			if strings.Contains(html, tt.raw) {
+				t.Errorf("the widget contains %q unescaped:\n%s", tt.raw, html)
+			}
+			if !strings.Contains(html, tt.escaped) {
+				t.Errorf("the widget does not contain %q:\n%s", tt.escaped, html)
+			}
+			if strings.Count(html, "<script") != 0 {
+				t.Errorf("the widget contains a script element:\n%s", html)
+			}
+		})
+	}
+}
+
+func TestRenderThemes(t *testing.T) {
+	tests := []struct {
+		theme      string
+		background string
+	}{
+		{ThemeLight, "background:#ffffff"},
+		{ThemeDark, "background:#1b1f3b"},
+		{"", "background:#ffffff"},
+		{"neon", "background:#ffffff"},
+	}
+	for _, tt := range tests {
+		page, err := Render(Data{Theme: tt.theme})
+		if err != nil {
+			t.Fatalf("Render(%q): %v", tt.theme, err)
+		}
+		if !strings.Contains(string(page), tt.background) {
+			t.Errorf("theme %q does not use %s", tt.theme, tt.background)
+		}
+		if !strings.Contains(string(page), "Нет объявленных боёв") {
+			t.Errorf("theme %q without fights does not show the empty notice", tt.theme)
+		}
+	}
+}